@@ -9,8 +9,11 @@ import psycopg2
 import redis
 import json
 import os
+import socket
 from concurrent import futures
 import logging
+import signal
+from grpc_health.v1 import health, health_pb2, health_pb2_grpc
 
 # Import generated gRPC code (you'll need to generate this)
 # from fraud_detection_pb2 import *
@@ -27,6 +30,17 @@ POSTGRES_USER = os.getenv('POSTGRES_USER', 'fraud_user')
 POSTGRES_PASSWORD = os.getenv('POSTGRES_PASSWORD', 'fraud_password')
 REDIS_HOST = os.getenv('REDIS_HOST', 'localhost')
 REDIS_PORT = int(os.getenv('REDIS_PORT', 6379))
+REDIS_USERNAME = os.getenv('REDIS_USERNAME') or None
+REDIS_PASSWORD = os.getenv('REDIS_PASSWORD') or None
+REDIS_DB = int(os.getenv('REDIS_DB', 0))
+REDIS_TLS = os.getenv('REDIS_TLS', 'false').lower() == 'true'
+REDIS_TLS_CA_FILE = os.getenv('REDIS_TLS_CA_FILE') or None
+ML_TLS_CERT_FILE = os.getenv('ML_TLS_CERT_FILE')
+ML_TLS_KEY_FILE = os.getenv('ML_TLS_KEY_FILE')
+ML_TLS_CLIENT_CA_FILE = os.getenv('ML_TLS_CLIENT_CA_FILE')
+# Long enough for clients' health checks to notice NOT_SERVING (the API's
+# ml_endpoints.health_interval times health_failures).
+SHUTDOWN_DRAIN_SECONDS = float(os.getenv('SHUTDOWN_DRAIN_SECONDS', 12))
 
 class FraudDetectionMLServicer:
     def __init__(self):
@@ -37,7 +51,12 @@ class FraudDetectionMLServicer:
         self.load_model()
         
         # Initialize connections
-        self.redis_client = redis.Redis(host=REDIS_HOST, port=REDIS_PORT, decode_responses=True)
+        self.redis_client = redis.Redis(
+            host=REDIS_HOST, port=REDIS_PORT, db=REDIS_DB,
+            username=REDIS_USERNAME, password=REDIS_PASSWORD,
+            ssl=REDIS_TLS, ssl_ca_certs=REDIS_TLS_CA_FILE,
+            client_name=f"fraud_ml@{socket.gethostname()}",
+            decode_responses=True)
         self.postgres_conn = psycopg2.connect(
             host=POSTGRES_HOST,
             database=POSTGRES_DB,
@@ -341,6 +360,23 @@ class FraudDetectionMLServicer:
             logger.error(f"Error getting fraud alerts: {e}")
             return []
 
+def server_credentials():
+    """TLS credentials from ML_TLS_CERT_FILE/ML_TLS_KEY_FILE. With
+    ML_TLS_CLIENT_CA_FILE clients must present a certificate it signed (mTLS).
+    The files are re-read for each new connection, so rotated certificates
+    take effect without a restart."""
+    def read(path):
+        with open(path, 'rb') as f:
+            return f.read()
+
+    def load():
+        client_ca = read(ML_TLS_CLIENT_CA_FILE) if ML_TLS_CLIENT_CA_FILE else None
+        return grpc.ssl_server_certificate_configuration(
+            [(read(ML_TLS_KEY_FILE), read(ML_TLS_CERT_FILE))], root_certificates=client_ca)
+
+    return grpc.dynamic_ssl_server_credentials(
+        load(), load, require_client_authentication=bool(ML_TLS_CLIENT_CA_FILE))
+
 async def serve():
     """Start the gRPC server"""
     server = grpc.aio.server(futures.ThreadPoolExecutor(max_workers=10))
@@ -350,11 +386,29 @@ async def serve():
     #     FraudDetectionMLServicer(), server
     # )
     
+    # grpc.health.v1, polled by the API to route around instances that are
+    # down or shutting down.
+    health_servicer = health.aio.HealthServicer()
+    health_pb2_grpc.add_HealthServicer_to_server(health_servicer, server)
+
     listen_addr = '[::]:50051'
-    server.add_insecure_port(listen_addr)
+    if ML_TLS_CERT_FILE:
+        server.add_secure_port(listen_addr, server_credentials())
+    else:
+        server.add_insecure_port(listen_addr)
     
     logger.info(f"Starting gRPC server on {listen_addr}")
     await server.start()
+    await health_servicer.set('', health_pb2.HealthCheckResponse.SERVING)
+
+    # On SIGTERM report NOT_SERVING first, so clients move to another
+    # instance, then let in-flight calls finish.
+    async def shutdown():
+        await health_servicer.enter_graceful_shutdown()
+        await asyncio.sleep(SHUTDOWN_DRAIN_SECONDS)
+        await server.stop(5)
+
+    asyncio.get_running_loop().add_signal_handler(signal.SIGTERM, lambda: asyncio.ensure_future(shutdown()))
     await server.wait_for_termination()
 
 if __name__ == '__main__':
@@ -0,0 +1,135 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "math/rand"
+    "net/http"
+    "net/netip"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+var (
+    abuseStrikes = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_abuse_strikes_total",
+        Help: "Abuse strikes against client IPs, by reason: rate_limit, auth or honeypot.",
+    }, []string{"reason"})
+    tarpitted = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_tarpitted_total",
+        Help: "Requests from abusive IPs held back per abuse.tarpit.",
+    })
+    abuseBlocked = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_abuse_blocklisted_total",
+        Help: "Client IPs added to the blocklist after abuse.block_at strikes.",
+    })
+)
+
+func abuseKey(ip string) string { return "abuse:" + ip }
+
+// withAbuse tarpits, and with abuse.decoy fools, clients whose IP has
+// abuse.threshold strikes, and turns requests for honeypot paths into
+// strikes. /health and /metrics are left alone, and while Redis is down so
+// is everything else.
+func withAbuse(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        cfg := config.Get().Abuse
+        if !cfg.Enabled || r.URL.Path == "/health" || r.URL.Path == "/metrics" || !cacheUp() { next.ServeHTTP(w, r); return }
+        for _, p := range cfg.HoneypotPaths {
+            if r.URL.Path == p {
+                strike(r, "honeypot")
+                tarpit(r, cfg.Tarpit)
+                serveDecoy(w, r)
+                return
+            }
+        }
+        n, err := rdb.Get(r.Context(), abuseKey(clientIP(r))).Int()
+        noteRedisErr(err)
+        if n < cfg.Threshold { next.ServeHTTP(w, r); return }
+        tarpit(r, cfg.Tarpit)
+        if cfg.Decoy { serveDecoy(w, r); return }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// tarpit holds the request for d, or until the client gives up.
+func tarpit(r *http.Request, d time.Duration) {
+    tarpitted.Inc()
+    t := time.NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-t.C:
+    case <-r.Context().Done():
+    }
+}
+
+// strike counts one abuse strike against r's IP and blocklists the IP when
+// that takes it to abuse.block_at. Strikes expire abuse.window after the
+// last one. Only an IP the connection came from directly is blocklisted:
+// one followed back through X-Forwarded-For is tarpitted but left off the
+// blocklist, which would otherwise be written from a header.
+func strike(r *http.Request, reason string) {
+    cfg := config.Get().Abuse
+    if !cfg.Enabled || !cacheUp() { return }
+    abuseStrikes.WithLabelValues(reason).Inc()
+    ip := clientIP(r)
+    pipe := rdb.TxPipeline()
+    incr := pipe.Incr(r.Context(), abuseKey(ip))
+    pipe.Expire(r.Context(), abuseKey(ip), cfg.Window)
+    if _, err := pipe.Exec(r.Context()); err != nil { noteRedisErr(err); return }
+    n := incr.Val()
+    // Only the strike that reaches block_at sees it, so one instance adds
+    // the entry.
+    if cfg.BlockAt > 0 && n == int64(cfg.BlockAt) && ip == peerIP(r) {
+        go blocklistAbuser(ip, cfg.BlockAction, fmt.Sprintf("API abuse: %d strikes, last for %s", n, reason))
+    }
+}
+
+// blocklistAbuser adds ip to the blocklist, so the abuse also counts
+// against transactions made from it.
+func blocklistAbuser(ip, action, reason string) {
+    addr, err := netip.ParseAddr(ip)
+    if err != nil { return }
+    e := store.BlocklistEntry{Kind: blockCIDR, Value: netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()).String(), Action: action, Reason: reason}
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    if _, err := blocklistStore.AddBlocklistEntry(qctx, e); err != nil {
+        if !errors.Is(err, store.ErrExists) { log.Printf("abuse: can't blocklist %s: %v", ip, err) }
+        return
+    }
+    abuseBlocked.Inc()
+    log.Printf("abuse: blocklisted %s (%s): %s", ip, action, reason)
+    refreshGeoRules()
+}
+
+// serveDecoy answers like the API would without doing anything: scoring
+// requests get a plausible REVIEW, everything else an empty success.
+func serveDecoy(w http.ResponseWriter, r *http.Request) {
+    switch r.URL.Path {
+    case "/transactions/process", "/transactions/score-only":
+        var req struct {
+            TransactionID string `json:"transaction_id"`
+        }
+        json.NewDecoder(io.LimitReader(r.Body, bodyLimit())).Decode(&req)
+        score := 0.4 + rand.Float64()*0.2
+        writeJSON(w, http.StatusOK, TransactionResponse{
+            TransactionID:    req.TransactionID,
+            FraudScore:       score,
+            Confidence:       0.5 + rand.Float64()*0.3,
+            RiskFactors:      []string{},
+            ProcessingTimeMs: 20 + rand.Intn(60),
+            Decision:         decisionReview,
+        })
+    default:
+        writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+    }
+}
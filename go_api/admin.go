@@ -0,0 +1,130 @@
+package main
+
+import (
+    "errors"
+    "log"
+    "net/http"
+    "slices"
+    "strings"
+    "sync"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/go_api/internal/oidc"
+    "example.com/fraud/internal/config"
+)
+
+var adminDenied = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "fraud_api_admin_denied_total",
+    Help: "Admin API requests refused, by reason: unauthenticated, forbidden or idp_unavailable.",
+}, []string{"reason"})
+
+// Roles the OIDC-protected endpoints need. Admins may do anything analysts
+// may.
+const (
+    roleAnalyst = "analyst"
+    roleAdmin   = "admin"
+)
+
+// adminVerifier checks admin tokens against admin.oidc_issuer, which only
+// changes on restart.
+var adminVerifier = sync.OnceValue(func() *oidc.Verifier {
+    a := config.Get().Admin
+    return &oidc.Verifier{Issuer: a.OIDCIssuer, Audience: a.OIDCAudience}
+})
+
+// adminHandler is the /admin surface: rule statistics, the blocklist, API
+// key usage and exports, for people signed in through the corporate SSO.
+// Scoring and the other service-to-service endpoints stay outside it; the
+// analyst and tuning endpoints outside /admin are wrapped in routes.
+func adminHandler() http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/admin/rules/", ruleStatsHandler)
+    mux.HandleFunc("/admin/blocklist", blocklistHandler)
+    mux.HandleFunc("/admin/blocklist/", blocklistHandler)
+    mux.HandleFunc("/admin/usage", usageHandler)
+    mux.HandleFunc("/admin/usage/export", usageExportHandler)
+    mux.HandleFunc("/admin/export/sar", sarExportHandler)
+    return withAdminAuth(roleAdmin, mux)
+}
+
+// withAdminWrites puts the methods that change something behind
+// withAdminAuth and lets reads through, for endpoints the dashboard and
+// other services poll.
+func withAdminWrites(role string, next http.Handler) http.Handler {
+    authed := withAdminAuth(role, next)
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method == http.MethodGet || r.Method == http.MethodHead { next.ServeHTTP(w, r); return }
+        authed.ServeHTTP(w, r)
+    })
+}
+
+// withAdminAuth admits requests with a valid OIDC bearer token from a user
+// who holds role, and logs each one with who made it. It answers 401
+// without a valid token, 403 for a user who doesn't hold the role and 503
+// when the identity provider can't be reached.
+func withAdminAuth(role string, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        cfg := config.Get().Admin
+        if cfg.OIDCIssuer == "" { http.Error(w, "admin API not enabled", http.StatusNotFound); return }
+        token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+        if !ok || token == "" {
+            adminDenied.WithLabelValues("unauthenticated").Inc()
+            strike(r, "auth")
+            w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+            http.Error(w, "bearer token required", http.StatusUnauthorized)
+            return
+        }
+        claims, err := adminVerifier().Verify(r.Context(), strings.TrimSpace(token))
+        if errors.Is(err, oidc.ErrToken) {
+            adminDenied.WithLabelValues("unauthenticated").Inc()
+            strike(r, "auth")
+            w.Header().Set("WWW-Authenticate", `Bearer realm="admin", error="invalid_token"`)
+            http.Error(w, err.Error(), http.StatusUnauthorized)
+            return
+        }
+        if err != nil {
+            adminDenied.WithLabelValues("idp_unavailable").Inc()
+            log.Printf("admin: can't verify token: %v", err)
+            http.Error(w, "identity provider unavailable", http.StatusServiceUnavailable)
+            return
+        }
+        user := claims.String("email")
+        if user == "" { user = claims.String("sub") }
+        if !adminAllowed(cfg, claims, role) {
+            adminDenied.WithLabelValues("forbidden").Inc()
+            log.Printf("admin: %s denied %s %s (needs %s)", user, r.Method, r.URL.Path, role)
+            http.Error(w, "forbidden", http.StatusForbidden)
+            return
+        }
+        log.Printf("admin: %s %s %s [%s]", user, r.Method, r.URL.RequestURI(), r.Header.Get("X-Request-ID"))
+        next.ServeHTTP(w, r)
+    })
+}
+
+// adminAllowed reports whether the token's user holds role: members of an
+// allowed group and holders of an allowed, verified email address. Admins
+// are admin.allowed_groups and allowed_emails; analysts are those plus
+// analyst_groups and analyst_emails. With no lists nobody is allowed,
+// though config validation doesn't let that happen.
+func adminAllowed(cfg config.Admin, claims oidc.Claims, role string) bool {
+    groups, emails := cfg.AllowedGroups, cfg.AllowedEmails
+    if role == roleAnalyst {
+        groups = append(slices.Clone(groups), cfg.AnalystGroups...)
+        emails = append(slices.Clone(emails), cfg.AnalystEmails...)
+    }
+    for _, g := range claims.Strings(cfg.GroupsClaim) {
+        for _, a := range groups {
+            if g == a { return true }
+        }
+    }
+    // Without email_verified, anyone who can set their email at the
+    // issuer could claim an allowed one.
+    if verified, _ := claims["email_verified"].(bool); !verified { return false }
+    email := claims.String("email")
+    for _, a := range emails {
+        if strings.EqualFold(email, a) { return true }
+    }
+    return false
+}
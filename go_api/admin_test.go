@@ -0,0 +1,36 @@
+package main
+
+import (
+    "testing"
+
+    "example.com/fraud/go_api/internal/oidc"
+    "example.com/fraud/internal/config"
+)
+
+func TestAdminAllowed(t *testing.T) {
+    cfg := config.Admin{GroupsClaim: "groups", AllowedGroups: []string{"fraud-admins"}, AnalystEmails: []string{"analyst@example.com"}}
+    admin := oidc.Claims{"groups": []interface{}{"fraud-admins"}}
+    analyst := oidc.Claims{"email": "Analyst@example.com", "email_verified": true}
+    unverified := oidc.Claims{"email": "analyst@example.com"}
+    stranger := oidc.Claims{"groups": []interface{}{"sales"}, "email": "someone@example.com", "email_verified": true}
+    cases := []struct {
+        name   string
+        cfg    config.Admin
+        claims oidc.Claims
+        role   string
+        want   bool
+    }{
+        {"admin as admin", cfg, admin, roleAdmin, true},
+        {"admin as analyst", cfg, admin, roleAnalyst, true},
+        {"analyst as analyst", cfg, analyst, roleAnalyst, true},
+        {"analyst as admin", cfg, analyst, roleAdmin, false},
+        {"unverified email", cfg, unverified, roleAnalyst, false},
+        {"stranger", cfg, stranger, roleAnalyst, false},
+        // No lists lets nobody in rather than everyone the issuer knows.
+        {"no lists", config.Admin{GroupsClaim: "groups"}, admin, roleAdmin, false},
+        {"analyst lists only", config.Admin{GroupsClaim: "groups", AnalystGroups: []string{"fraud-admins"}}, admin, roleAdmin, false},
+    }
+    for _, c := range cases {
+        if got := adminAllowed(c.cfg, c.claims, c.role); got != c.want { t.Errorf("%s: adminAllowed = %v, want %v", c.name, got, c.want) }
+    }
+}
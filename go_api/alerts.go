@@ -0,0 +1,271 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+)
+
+// alertHistoryLimit caps the audit entries GET /alerts/{id}/history reads.
+const alertHistoryLimit = 500
+
+// alertBatch is how many alerts one auto-close or SLA UPDATE takes at most.
+const alertBatch = 1000
+
+// alertHandler serves /alerts/suppressions and the actions on one alert:
+// POST /alerts/{id}/resolve, POST /alerts/{id}/assign, GET and POST
+// /alerts/{id}/comments and GET /alerts/{id}/history.
+func alertHandler(w http.ResponseWriter, r *http.Request) {
+    rest := strings.TrimPrefix(r.URL.Path, "/alerts/")
+    if rest == "suppressions" || strings.HasPrefix(rest, "suppressions/") {
+        suppressionsHandler(w, r, strings.Trim(strings.TrimPrefix(rest, "suppressions"), "/"))
+        return
+    }
+    parts := strings.Split(rest, "/")
+    if len(parts) != 2 || parts[0] == "" { http.NotFound(w, r); return }
+    id := parts[0]
+    switch parts[1] {
+    case "resolve":
+        if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+        resolveAlertHandler(w, r, id)
+    case "assign":
+        if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+        assignAlertHandler(w, r, id)
+    case "comments":
+        switch r.Method {
+        case http.MethodGet:
+            qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+            defer cancel()
+            comments, err := alertStore.Comments(qctx, id)
+            if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+            writeJSONConditional(w, r, map[string]interface{}{"alert_id": id, "comments": threadComments(comments)}, time.Time{})
+        case http.MethodPost:
+            addCommentHandler(w, r, id)
+        default:
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        }
+    case "history":
+        if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+        alertHistoryHandler(w, r, id)
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// resolveAlertHandler closes an open alert with an analyst's verdict:
+// {"analyst": "jdoe", "resolution": "FRAUD"} confirms it and
+// "FALSE_POSITIVE" overturns it.
+func resolveAlertHandler(w http.ResponseWriter, r *http.Request, id string) {
+    var body struct {
+        Analyst    string `json:"analyst"`
+        Resolution string `json:"resolution"`
+    }
+    if !decodeBody(w, r, &body, bodyLimit()) { return }
+    body.Analyst = strings.TrimSpace(body.Analyst)
+    if body.Analyst == "" || len(body.Analyst) > 100 { http.Error(w, "analyst is required, at most 100 characters", http.StatusBadRequest); return }
+    resolution := strings.ToUpper(body.Resolution)
+    if resolution != store.ResolutionFraud && resolution != store.ResolutionFalsePositive {
+        http.Error(w, "resolution must be FRAUD or FALSE_POSITIVE", http.StatusBadRequest)
+        return
+    }
+    qctx, cancel := conn.QueryCtx(r.Context())
+    defer cancel()
+    a, err := alertStore.Resolve(qctx, id, body.Analyst, resolution)
+    switch {
+    case errors.Is(err, store.ErrNotFound):
+        http.Error(w, "Alert not found", http.StatusNotFound)
+    case errors.Is(err, store.ErrNotOpen):
+        http.Error(w, "Alert is already resolved", http.StatusConflict)
+    case err != nil:
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+    default:
+        alertsResolved.WithLabelValues(resolution).Inc()
+        status := store.TxApproved
+        if resolution == store.ResolutionFraud { status = store.TxDeclined }
+        setTransactionStatus(r.Context(), a.TransactionID, status, settleableStatuses, body.Analyst, "review")
+        writeJSON(w, http.StatusOK, a)
+    }
+}
+
+// assignAlertHandler hands an open alert to an analyst:
+// {"assignee": "jdoe", "author": "lead"}. An empty assignee unassigns it.
+func assignAlertHandler(w http.ResponseWriter, r *http.Request, id string) {
+    var body struct {
+        Assignee string `json:"assignee"`
+        Author   string `json:"author"`
+    }
+    if !decodeBody(w, r, &body, bodyLimit()) { return }
+    body.Assignee, body.Author = strings.TrimSpace(body.Assignee), strings.TrimSpace(body.Author)
+    if body.Author == "" || len(body.Author) > 100 { http.Error(w, "author is required, at most 100 characters", http.StatusBadRequest); return }
+    if len(body.Assignee) > 100 { http.Error(w, "assignee must be at most 100 characters", http.StatusBadRequest); return }
+    qctx, cancel := conn.QueryCtx(r.Context())
+    defer cancel()
+    a, err := alertStore.Assign(qctx, id, body.Assignee, body.Author)
+    switch {
+    case errors.Is(err, store.ErrNotFound):
+        http.Error(w, "Alert not found", http.StatusNotFound)
+    case errors.Is(err, store.ErrNotOpen):
+        http.Error(w, "Alert is already resolved", http.StatusConflict)
+    case err != nil:
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+    default:
+        if body.Assignee != "" { setTransactionStatus(r.Context(), a.TransactionID, store.TxReviewed, reviewableStatuses, body.Author, "review") }
+        writeJSON(w, http.StatusOK, a)
+    }
+}
+
+// addCommentHandler stores {"author": "jdoe", "body": "...", "parent_id": 12};
+// parent_id is optional and makes the comment a reply.
+func addCommentHandler(w http.ResponseWriter, r *http.Request, id string) {
+    var c store.AlertComment
+    if !decodeBody(w, r, &c, bodyLimit()) { return }
+    c.AlertID, c.Replies = id, nil
+    c.Author, c.Body = strings.TrimSpace(c.Author), strings.TrimSpace(c.Body)
+    if c.Author == "" || len(c.Author) > 100 { http.Error(w, "author is required, at most 100 characters", http.StatusBadRequest); return }
+    if c.Body == "" || len(c.Body) > 4000 { http.Error(w, "body is required, at most 4000 characters", http.StatusBadRequest); return }
+    qctx, cancel := conn.QueryCtx(r.Context())
+    defer cancel()
+    c, err := alertStore.AddComment(qctx, c)
+    if errors.Is(err, store.ErrNotFound) { http.Error(w, "Alert or parent comment not found", http.StatusNotFound); return }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    writeJSON(w, http.StatusCreated, c)
+}
+
+// threadComments nests replies under their parents, keeping each level
+// oldest first.
+func threadComments(flat []store.AlertComment) []store.AlertComment {
+    children := map[int64][]store.AlertComment{}
+    var roots []store.AlertComment
+    for _, c := range flat {
+        if c.ParentID == nil { roots = append(roots, c); continue }
+        children[*c.ParentID] = append(children[*c.ParentID], c)
+    }
+    var nest func([]store.AlertComment) []store.AlertComment
+    nest = func(level []store.AlertComment) []store.AlertComment {
+        for i := range level { level[i].Replies = nest(children[level[i].ID]) }
+        return level
+    }
+    if roots == nil { roots = []store.AlertComment{} }
+    return nest(roots)
+}
+
+// alertActivity is one entry in an alert's timeline.
+type alertActivity struct {
+    At      time.Time       `json:"at"`
+    Actor   string          `json:"actor"`
+    Action  string          `json:"action"`
+    Details json.RawMessage `json:"details,omitempty"`
+}
+
+// alertHistoryHandler returns the alert, its timeline oldest first (the
+// audit log's status changes, assignments and notifications, plus each
+// comment) and the threaded comments.
+func alertHistoryHandler(w http.ResponseWriter, r *http.Request, id string) {
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    a, err := caseStore.Alert(qctx, id)
+    if errors.Is(err, store.ErrNotFound) { http.Error(w, "Alert not found", http.StatusNotFound); return }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    entries, err := auditStore.AuditLog(qctx, "alert", id, store.Page{Limit: alertHistoryLimit})
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    comments, err := alertStore.Comments(qctx, id)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+
+    timeline := make([]alertActivity, 0, len(entries)+len(comments)+1)
+    created := false
+    for i := len(entries) - 1; i >= 0; i-- {
+        e := entries[i]
+        created = created || e.Action == "alert.created"
+        timeline = append(timeline, alertActivity{At: e.At, Actor: e.Actor, Action: e.Action, Details: e.Details})
+    }
+    // Alerts raised before activity was logged have no creation entry.
+    if !created { timeline = append(timeline, alertActivity{At: a.CreatedAt, Actor: "system", Action: "alert.created"}) }
+    for _, c := range comments {
+        details, _ := json.Marshal(map[string]interface{}{"comment_id": c.ID, "parent_id": c.ParentID})
+        timeline = append(timeline, alertActivity{At: c.CreatedAt, Actor: c.Author, Action: "comment.added", Details: details})
+    }
+    sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].At.Before(timeline[j].At) })
+    writeJSONConditional(w, r, map[string]interface{}{"alert": a, "timeline": timeline, "comments": threadComments(comments)}, time.Time{})
+}
+
+// runAlertAutoClose closes stale alerts every auto_close.interval (see
+// config.AutoClose), a batch at a time until none are left. Any instance
+// may do it: rows being closed by another are skipped.
+func runAlertAutoClose() {
+    for {
+        time.Sleep(config.Get().AutoClose.Interval)
+        cfg := config.Get().AutoClose
+        if cfg.MaxAge <= 0 { continue }
+        severities := make([]string, len(cfg.Severities))
+        for i, s := range cfg.Severities { severities[i] = strings.ToUpper(s) }
+        before := time.Now().UTC().Add(-cfg.MaxAge)
+        total := 0
+        for {
+            qctx, cancel := conn.QueryCtx(ctx)
+            closed, err := alertStore.CloseStale(qctx, severities, before, alertBatch)
+            cancel()
+            if err != nil { log.Printf("alert auto-close failed: %v", err); break }
+            for _, a := range closed { alertsAutoClosed.WithLabelValues(a.Severity).Inc() }
+            total += len(closed)
+            if len(closed) < alertBatch { break }
+        }
+        if total > 0 { log.Printf("auto-closed %d stale alerts raised before %s", total, before.Format(time.RFC3339)) }
+    }
+}
+
+// runAlertSLA marks the open alerts left unacknowledged past their
+// severity's alert_sla target as breached every alert_sla.interval, and
+// announces each on fraud-alerts. As with auto-close, any instance may do
+// it: an alert is only marked, and announced, once.
+func runAlertSLA() {
+    for {
+        time.Sleep(config.Get().AlertSLA.Interval)
+        cfg := config.Get().AlertSLA
+        now := time.Now().UTC()
+        for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
+            target := cfg.Target(sev)
+            if target <= 0 { continue }
+            for {
+                qctx, cancel := conn.QueryCtx(ctx)
+                breached, err := alertStore.BreachSLA(qctx, sev, now.Add(-target), alertBatch)
+                cancel()
+                if err != nil { log.Printf("alert SLA check failed: %v", err); break }
+                for _, a := range breached { notifySLABreach(a, target) }
+                if len(breached) < alertBatch { break }
+            }
+        }
+    }
+}
+
+// notifySLABreach publishes an ALERT_SLA_BREACHED alert for a, keyed by its
+// alert ID. Like caller anomaly alerts it is best-effort: the breach is
+// recorded on the alert whether or not it goes out.
+func notifySLABreach(a store.Alert, target time.Duration) {
+    alertSLABreaches.WithLabelValues(a.Severity).Inc()
+    desc := fmt.Sprintf("%s alert %s was not acknowledged within %s of being raised at %s UTC", a.Severity, a.AlertID, target, a.CreatedAt.UTC().Format("15:04"))
+    log.Printf("alert SLA breached: %s", desc)
+    ev := events.AlertEvent{
+        AlertID:       "SLA_" + a.AlertID,
+        TransactionID: a.TransactionID,
+        AlertType:     "ALERT_SLA_BREACHED",
+        Severity:      a.Severity,
+        Description:   desc,
+        FraudScore:    a.Confidence,
+        Timestamp:     time.Now().Unix(),
+    }
+    codec := events.ProtobufCodec
+    if kafkaReady.Load() { codec = alertCodec }
+    b, err := codec.Encode(ev)
+    if err != nil { log.Printf("encode alert event: %v", err); return }
+    if err := alertPub.Publish([]byte(a.AlertID), b, codec.ContentType()); err != nil { log.Printf("publish alert: %v", err) }
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+    "context"
+    "strconv"
+
+    "example.com/fraud/internal/feature"
+)
+
+// AmountStats reads the running statistics the processor keeps in
+// user_amount_stats:<id> (n, mean and m2). There are none while Redis is
+// unavailable.
+func (liveSource) AmountStats(ctx context.Context, userID string) (feature.AmountStats, bool) {
+    if !cacheUp() { return feature.AmountStats{}, false }
+    vals, err := rdb.HMGet(ctx, "user_amount_stats:"+userID, "n", "mean", "m2").Result()
+    if err != nil { noteRedisErr(err); return feature.AmountStats{}, false }
+    num := func(v interface{}) float64 {
+        s, _ := v.(string)
+        f, _ := strconv.ParseFloat(s, 64)
+        return f
+    }
+    return feature.AmountStats{N: num(vals[0]), Mean: num(vals[1]), M2: num(vals[2])}, true
+}
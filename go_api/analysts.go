@@ -0,0 +1,112 @@
+package main
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+type analystStatsResponse struct {
+    Since    time.Time         `json:"since"`
+    Queue     alertQueue        `json:"queue"`
+    AutoClose autoCloseStats    `json:"auto_close"`
+    Analysts  []analystWorkload `json:"analysts"`
+}
+
+// autoCloseStats is how many of the alerts resolved in the period were
+// closed as stale rather than by an analyst.
+type autoCloseStats struct {
+    Resolved   int64   `json:"alerts_resolved"`
+    AutoClosed int64   `json:"auto_closed"`
+    Rate       float64 `json:"auto_close_rate"`
+}
+
+type alertQueue struct {
+    Open        int64        `json:"open"`
+    OldestOpen  *time.Time   `json:"oldest_open_at"`
+    SLABreached int64        `json:"sla_breached"`
+    BySeverity  []queueDepth `json:"by_severity"`
+}
+
+type queueDepth struct {
+    Severity    string    `json:"severity"`
+    Open        int64     `json:"open"`
+    OldestOpen  time.Time `json:"oldest_open_at"`
+    SLABreached int64     `json:"sla_breached"`
+}
+
+// analystWorkload is one analyst's share of the resolved alerts. The
+// false-positive rate is how often they overturned an alert; the overturn
+// rate is how often a later label (a chargeback, say) overturned them.
+type analystWorkload struct {
+    Analyst           string  `json:"analyst"`
+    Handled           int64   `json:"alerts_handled"`
+    Share             float64 `json:"share_of_handled"`
+    ConfirmedFraud    int64   `json:"confirmed_fraud"`
+    FalsePositives    int64   `json:"false_positives"`
+    FalsePositiveRate float64 `json:"false_positive_rate"`
+    Overturned        int64   `json:"overturned_by_label"`
+    OverturnRate      float64 `json:"overturn_rate"`
+    MeanTTR           float64 `json:"mean_time_to_resolution_seconds"`
+    MedianTTR         float64 `json:"median_time_to_resolution_seconds"`
+}
+
+// analystStatsHandler serves GET /stats/analysts?days=30: the open alert
+// queue and how much of it is past its SLA, the share of alerts resolved in
+// the last days days that were auto-closed, and per analyst the alerts they
+// resolved.
+func analystStatsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    days := 30
+    if v := r.URL.Query().Get("days"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > 365 { http.Error(w, "days must be 1-365", http.StatusBadRequest); return }
+        days = n
+    }
+    since := time.Now().UTC().AddDate(0, 0, -days)
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    stats, err := analystStore.AnalystStats(qctx, since)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    depths, err := analystStore.Queue(qctx)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    closed, autoClosed, err := analystStore.Closures(qctx, since)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+
+    resp := analystStatsResponse{Since: since, Queue: alertQueue{BySeverity: []queueDepth{}}, Analysts: []analystWorkload{}}
+    resp.AutoClose = autoCloseStats{Resolved: closed, AutoClosed: autoClosed, Rate: ratio(autoClosed, closed)}
+    for _, d := range depths {
+        resp.Queue.Open += d.Open
+        resp.Queue.SLABreached += d.Breached
+        if resp.Queue.OldestOpen == nil || d.Oldest.Before(*resp.Queue.OldestOpen) {
+            oldest := d.Oldest
+            resp.Queue.OldestOpen = &oldest
+        }
+        resp.Queue.BySeverity = append(resp.Queue.BySeverity, queueDepth{Severity: d.Severity, Open: d.Open, OldestOpen: d.Oldest, SLABreached: d.Breached})
+    }
+    var total int64
+    for _, s := range stats { total += s.Handled }
+    for _, s := range stats {
+        resp.Analysts = append(resp.Analysts, analystWorkload{
+            Analyst:           s.Analyst,
+            Handled:           s.Handled,
+            Share:             ratio(s.Handled, total),
+            ConfirmedFraud:    s.Fraud,
+            FalsePositives:    s.FalsePositive,
+            FalsePositiveRate: ratio(s.FalsePositive, s.Handled),
+            Overturned:        s.Overturned,
+            OverturnRate:      ratio(s.Overturned, s.Handled),
+            MeanTTR:           s.MeanResolution.Seconds(),
+            MedianTTR:         s.P50Resolution.Seconds(),
+        })
+    }
+    writeJSON(w, http.StatusOK, resp)
+}
+
+func ratio(n, of int64) float64 {
+    if of == 0 { return 0 }
+    return float64(n) / float64(of)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "net/http"
+    "strings"
+
+    "example.com/fraud/internal/config"
+)
+
+// apiKeyFingerprint stands in for an API key wherever one would otherwise
+// be kept, logged or sent on: the first 16 hex digits of its SHA-256, which
+// tell keys apart but can't be used to call the API.
+func apiKeyFingerprint(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(sum[:8])
+}
+
+// apiKeyLabel names a fingerprinted key for people: its api_keys name and
+// fingerprint when it is configured, else the fingerprint alone.
+func apiKeyLabel(fingerprint string) string {
+    for _, k := range config.Get().APIKeys.Keys {
+        name, h, _ := strings.Cut(k, "=")
+        if strings.HasPrefix(strings.ToLower(strings.TrimSpace(h)), fingerprint) { return strings.TrimSpace(name) + " (" + fingerprint + ")" }
+    }
+    return fingerprint
+}
+
+// apiKeyName is the api_keys name of the request's X-API-Key, or "" when it
+// sent none or one that isn't configured.
+func apiKeyName(r *http.Request) string {
+    key := r.Header.Get("X-API-Key")
+    if key == "" { return "" }
+    sum := sha256.Sum256([]byte(key))
+    return config.Get().APIKeys.Name(hex.EncodeToString(sum[:]))
+}
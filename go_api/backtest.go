@@ -0,0 +1,175 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+)
+
+// Backtest jobs are kept in Redis so any instance can report on them.
+const (
+    backtestTTL        = 7 * 24 * time.Hour
+    backtestMaxRange   = 366 * 24 * time.Hour
+    backtestMaxSamples = 100
+)
+
+// backtestSlots bounds how many backtests one instance runs at a time.
+var backtestSlots = make(chan struct{}, 2)
+
+type backtestChange struct {
+    TransactionID string  `json:"transaction_id"`
+    Amount        float64 `json:"amount"`
+    FraudScore    float64 `json:"fraud_score"`
+    Before        string  `json:"before"`
+    After         string  `json:"after"`
+}
+
+// backtestReport compares the decisions the current rules give the stored
+// scores with those the candidate rules would give them.
+type backtestReport struct {
+    ID         string                 `json:"id"`
+    Status     string                 `json:"status"` // running, done or failed
+    Error      string                 `json:"error,omitempty"`
+    From       time.Time              `json:"from"`
+    To         time.Time              `json:"to"`
+    Rules      map[string]interface{} `json:"rules"`
+    StartedAt  time.Time              `json:"started_at"`
+    FinishedAt *time.Time             `json:"finished_at,omitempty"`
+
+    Transactions int            `json:"transactions"`
+    Changed      int            `json:"changed"`
+    Transitions  map[string]int `json:"transitions"` // "APPROVE->REVIEW": n
+    Before       decisionTotals `json:"before"`
+    After        decisionTotals `json:"after"`
+    // Samples are the first changed transactions.
+    Samples []backtestChange `json:"samples"`
+}
+
+type decisionTotals struct {
+    Approved       int     `json:"approved"`
+    Reviewed       int     `json:"reviewed"`
+    Declined       int     `json:"declined"`
+    DeclinedAmount float64 `json:"declined_amount"`
+}
+
+func (t *decisionTotals) add(decision string, amount float64) {
+    switch decision {
+    case decisionApprove:
+        t.Approved++
+    case decisionReview:
+        t.Reviewed++
+    case decisionDecline:
+        t.Declined++
+        t.DeclinedAmount += amount
+    }
+}
+
+// candidateRules overlays overrides, keyed like the rules section of the
+// config file, on the current rules and validates the result.
+func candidateRules(overrides map[string]interface{}) (config.Rules, error) {
+    cfg := *config.Get()
+    b, err := yaml.Marshal(overrides)
+    if err != nil { return config.Rules{}, err }
+    if err := yaml.Unmarshal(b, &cfg.Rules); err != nil { return config.Rules{}, err }
+    return cfg.Rules, cfg.Validate()
+}
+
+// backtestHandler serves POST /backtest, which starts a backtest of
+// {"from": ..., "to": ..., "rules": {"fraud_threshold": 0.8, ...}} and
+// answers 202 with its id, and GET /backtest/{id}, which reports on it.
+func backtestHandler(w http.ResponseWriter, r *http.Request) {
+    id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/backtest"), "/")
+    switch {
+    case r.Method == http.MethodGet && id != "":
+        if !cacheUp() { http.Error(w, "Redis unavailable", http.StatusServiceUnavailable); return }
+        b, err := rdb.Get(r.Context(), "backtest:"+id).Bytes()
+        if err != nil { http.Error(w, "Backtest not found", http.StatusNotFound); return }
+        w.Header().Set("Content-Type", "application/json")
+        w.Write(b)
+    case r.Method == http.MethodPost && id == "":
+        var body struct {
+            From  time.Time              `json:"from"`
+            To    time.Time              `json:"to"`
+            Rules map[string]interface{} `json:"rules"`
+        }
+        if !decodeBody(w, r, &body, bodyLimit()) { return }
+        if !body.To.After(body.From) { http.Error(w, "to must be after from", http.StatusBadRequest); return }
+        if body.To.Sub(body.From) > backtestMaxRange { http.Error(w, "a backtest can cover at most a year", http.StatusBadRequest); return }
+        rules, err := candidateRules(body.Rules)
+        if err != nil { http.Error(w, "rules: "+err.Error(), http.StatusBadRequest); return }
+        if !cacheUp() { http.Error(w, "Redis unavailable", http.StatusServiceUnavailable); return }
+        select {
+        case backtestSlots <- struct{}{}:
+        default:
+            http.Error(w, "Too many backtests running", http.StatusTooManyRequests)
+            return
+        }
+        rep := &backtestReport{
+            ID:          fmt.Sprintf("bt_%d", time.Now().UnixNano()),
+            Status:      "running",
+            From:        body.From.UTC(),
+            To:          body.To.UTC(),
+            Rules:       body.Rules,
+            StartedAt:   time.Now().UTC(),
+            Transitions: map[string]int{},
+            Samples:     []backtestChange{},
+        }
+        if err := saveBacktest(rep); err != nil { <-backtestSlots; http.Error(w, err.Error(), http.StatusServiceUnavailable); return }
+        go func() {
+            defer func() { <-backtestSlots }()
+            runBacktest(rep, config.Get().Rules, rules)
+        }()
+        writeJSON(w, http.StatusAccepted, map[string]interface{}{"id": rep.ID, "status": rep.Status})
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
+
+// runBacktest replays the stored scores of the range through both rule sets.
+// Only the decision layer is replayed: thresholds, expected-loss settings
+// and false-positive cost. Stored transactions carry no feature snapshot,
+// so changes to feature rules can't be backtested this way.
+func runBacktest(rep *backtestReport, current, candidate config.Rules) {
+    // Replays can cover months of partitions; allow them far more than the
+    // per-request query timeout.
+    qctx, cancel := context.WithTimeout(store.ReadOnly(ctx), 30*time.Minute)
+    defer cancel()
+    err := txStore.Range(qctx, rep.From, rep.To, func(t store.Transaction) error {
+        before := scoreDecision(current, t.Amount, t.FraudScore, t.FraudScore > current.FraudThreshold)
+        after := scoreDecision(candidate, t.Amount, t.FraudScore, t.FraudScore > candidate.FraudThreshold)
+        rep.Transactions++
+        rep.Before.add(before, t.Amount)
+        rep.After.add(after, t.Amount)
+        if before == after { return nil }
+        rep.Changed++
+        rep.Transitions[before+"->"+after]++
+        if len(rep.Samples) < backtestMaxSamples {
+            rep.Samples = append(rep.Samples, backtestChange{TransactionID: t.TransactionID, Amount: t.Amount, FraudScore: t.FraudScore, Before: before, After: after})
+        }
+        return nil
+    })
+    now := time.Now().UTC()
+    rep.FinishedAt = &now
+    rep.Status = "done"
+    if err != nil { rep.Status, rep.Error = "failed", err.Error() }
+    if err := saveBacktest(rep); err != nil { log.Printf("backtest %s: save report: %v", rep.ID, err) }
+}
+
+func saveBacktest(rep *backtestReport) error {
+    b, err := json.Marshal(rep)
+    if err != nil { return err }
+    err = rdb.Set(ctx, "backtest:"+rep.ID, b, backtestTTL).Err()
+    noteRedisErr(err)
+    if err != nil { return errors.New("can't store backtest: " + err.Error()) }
+    return nil
+}
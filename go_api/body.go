@@ -0,0 +1,81 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+
+    "example.com/fraud/internal/config"
+)
+
+// maxJSONDepth bounds how deeply a request body's objects and arrays may
+// nest; no endpoint takes more than a few levels.
+const maxJSONDepth = 32
+
+// bodyLimit is the size cap for JSON request bodies other than batches.
+func bodyLimit() int64 { return int64(config.Get().API.MaxBodyKB) << 10 }
+
+func batchBodyLimit() int64 { return int64(config.Get().API.MaxBatchBodyMB) << 20 }
+
+func importBodyLimit() int64 { return int64(config.Get().API.MaxImportMB) << 20 }
+
+// decodeBody reads r's JSON body into v. When it can't, it answers the
+// request itself and returns false: 413 for a body over limit bytes, 400
+// for one that is malformed, nested deeper than maxJSONDepth, followed by
+// more data or, with api.strict_json, carrying fields v doesn't have.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}, limit int64) bool {
+    b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+    if err != nil { bodyError(w, err); return false }
+    if err := checkDepth(b); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return false }
+    dec := json.NewDecoder(bytes.NewReader(b))
+    if config.Get().API.StrictJSON { dec.DisallowUnknownFields() }
+    if err := dec.Decode(v); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return false }
+    if _, err := dec.Token(); err != io.EOF {
+        http.Error(w, "unexpected data after the JSON body", http.StatusBadRequest)
+        return false
+    }
+    return true
+}
+
+// bodyError answers a failed body read: 413 naming the limit when the body
+// was too large, 400 otherwise.
+func bodyError(w http.ResponseWriter, err error) {
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        http.Error(w, "request body larger than "+byteSize(tooLarge.Limit), http.StatusRequestEntityTooLarge)
+        return
+    }
+    http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func byteSize(n int64) string {
+    if n >= 1<<20 && n%(1<<20) == 0 { return fmt.Sprintf("%d MB", n>>20) }
+    if n >= 1<<10 && n%(1<<10) == 0 { return fmt.Sprintf("%d KB", n>>10) }
+    return fmt.Sprintf("%d bytes", n)
+}
+
+// checkDepth scans b for objects and arrays nested beyond maxJSONDepth,
+// before the decoder spends anything on them.
+func checkDepth(b []byte) error {
+    depth := 0
+    inString, escaped := false, false
+    for _, c := range b {
+        switch {
+        case escaped:
+            escaped = false
+        case inString:
+            if c == '\\' { escaped = true } else if c == '"' { inString = false }
+        case c == '"':
+            inString = true
+        case c == '{' || c == '[':
+            depth++
+            if depth > maxJSONDepth { return fmt.Errorf("JSON nested deeper than %d levels", maxJSONDepth) }
+        case c == '}' || c == ']':
+            depth--
+        }
+    }
+    return nil
+}
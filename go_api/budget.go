@@ -0,0 +1,45 @@
+package main
+
+import (
+    "context"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+)
+
+// With api.latency_budget set, scoring a transaction has that long from the
+// moment the request arrived. Enrichment stages and the ML call run under
+// the budget's deadline; once it has passed, the remaining stages are
+// skipped and the rules score what was gathered, and the response is marked
+// partial_evaluation. Writes (the transaction, spend limits, counters) are
+// not optional and run under the request's own context.
+var (
+    partialEvaluations = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_partial_evaluations_total",
+        Help: "Transactions scored without every stage because the latency budget ran out.",
+    })
+    budgetSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_budget_skipped_stages_total",
+        Help: "Enrichment stages and ML calls skipped or cut short by the latency budget, by stage.",
+    }, []string{"stage"})
+)
+
+// withLatencyBudget bounds ctx by api.latency_budget from start; without a
+// budget it only adds a cancel.
+func withLatencyBudget(ctx context.Context, start time.Time) (context.Context, context.CancelFunc) {
+    budget := config.Get().API.LatencyBudget
+    if budget <= 0 { return context.WithCancel(ctx) }
+    return context.WithDeadline(ctx, start.Add(budget))
+}
+
+// budgetSpent reports whether there is no time left to run stage, and
+// records it as skipped on f if so.
+func budgetSpent(ctx context.Context, stage string, f *features) bool {
+    if ctx.Err() == nil { return false }
+    f.Skipped = append(f.Skipped, stage)
+    budgetSkipped.WithLabelValues(stage).Inc()
+    return true
+}
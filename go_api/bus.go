@@ -0,0 +1,71 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "strings"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/segmentio/kafka-go"
+
+    "example.com/fraud/internal/config"
+)
+
+// publisher writes events to one topic. EVENT_BUS selects the transport:
+// kafka (default) or redis, which maps each topic onto a Redis stream of the
+// same name so small deployments can run without a Kafka cluster.
+type publisher interface {
+    Publish(key, value []byte, contentType string) error
+    Close() error
+}
+
+// newPublisher returns a publisher for topic. batchSize overrides
+// KAFKA_BATCH_SIZE when positive; it has no effect on Redis.
+func newPublisher(brokers []string, topic string, batchSize int) (publisher, error) {
+    switch t := strings.ToLower(config.Get().EventBus); t {
+    case "kafka":
+        w := newAsyncWriter(brokers, topic)
+        if batchSize > 0 { w.BatchSize = batchSize }
+        return kafkaPublisher{w: w}, nil
+    case "redis":
+        return redisPublisher{stream: topic, maxLen: int64(config.Get().Redis.StreamMaxLen)}, nil
+    default:
+        return nil, fmt.Errorf("unknown EVENT_BUS %q", t)
+    }
+}
+
+type kafkaPublisher struct{ w *kafka.Writer }
+
+func (p kafkaPublisher) Publish(key, value []byte, contentType string) error {
+    return p.w.WriteMessages(ctx, kafka.Message{Key: key, Value: value, Headers: []kafka.Header{{Key: "content-type", Value: []byte(contentType)}}})
+}
+
+func (p kafkaPublisher) Close() error { return p.w.Close() }
+
+// redisPublisher appends entries with key, value and content_type fields.
+// XADD is synchronous, so failures are spilled to the outbox right away.
+type redisPublisher struct {
+    stream string
+    maxLen int64
+}
+
+func (p redisPublisher) Publish(key, value []byte, contentType string) error {
+    err := rdb.XAdd(ctx, &redis.XAddArgs{
+        Stream: p.stream,
+        MaxLen: p.maxLen,
+        Approx: true,
+        Values: map[string]interface{}{"key": key, "value": value, "content_type": contentType},
+    }).Err()
+    if err == nil {
+        kafkaDelivered.WithLabelValues(p.stream).Inc()
+        return nil
+    }
+    kafkaDeliveryFailures.WithLabelValues(p.stream).Inc()
+    noteRedisErr(err)
+    log.Printf("redis stream delivery to %s failed: %v", p.stream, err)
+    if serr := spillToOutbox(p.stream, key, value, contentType, err); serr != nil { return serr }
+    outboxSpilled.WithLabelValues(p.stream).Inc()
+    return nil
+}
+
+func (redisPublisher) Close() error { return nil }
@@ -0,0 +1,51 @@
+package main
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "example.com/fraud/internal/config"
+)
+
+// runCacheWarmer preloads Redis with the risk score and average amount of the
+// CACHE_WARM_USERS most active users of the last day, at startup and then
+// every interval (0 warms once), so a Redis flush or deploy doesn't send
+// every hot user's first requests to Postgres.
+func runCacheWarmer(interval time.Duration) {
+    for {
+        start := time.Now()
+        if !cacheUp() {
+            log.Printf("cache warm skipped: redis unavailable")
+        } else if n, err := warmHotUsers(config.Get().API.CacheWarmUsers, interval); err != nil {
+            log.Printf("cache warm failed: %v", err)
+        } else {
+            log.Printf("cache warm: %d users in %s", n, time.Since(start).Round(time.Millisecond))
+        }
+        if interval <= 0 { return }
+        time.Sleep(interval)
+    }
+}
+
+func warmHotUsers(limit int, interval time.Duration) (int, error) {
+    // The aggregate scans a day of transactions; allow it more than the
+    // per-request query timeout.
+    qctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+    defer cancel()
+    users, err := userStore.HotUsers(qctx, time.Now().UTC().Add(-24*time.Hour), historyStart(), limit)
+    if err != nil { return 0, err }
+
+    // Averages outlive one interval so they don't lapse before the next run.
+    avgTTL := 2 * interval
+    if avgTTL <= 0 { avgTTL = time.Hour }
+    pipe := rdb.Pipeline()
+    for _, u := range users {
+        // SETNX: a value already present was written by the processor and is
+        // at least as fresh as this read.
+        pipe.SetNX(ctx, "user_risk:"+u.UserID, u.RiskScore, config.Get().API.UserRiskCacheTTL)
+        pipe.Set(ctx, "user_avg_amount:"+u.UserID, u.AvgAmount, avgTTL)
+    }
+    _, err = pipe.Exec(ctx)
+    noteRedisErr(err)
+    return len(users), err
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+// Caller anomaly kinds, as named in alerts and metrics.
+const (
+    anomalyVolumeSpike = "volume_spike"
+    anomalyEnumeration = "enumeration"
+    anomalyAgentChurn  = "user_agent_churn"
+)
+
+// maxCallerSet bounds the user agents and missed IDs kept per key and
+// minute between flushes; past it the thresholds have long been crossed.
+const maxCallerSet = 1000
+
+var callerAnomalies = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "fraud_api_caller_anomalies_total",
+    Help: "API keys flagged for unusual traffic, by kind: volume_spike, enumeration or user_agent_churn.",
+}, []string{"kind"})
+
+type callerKey struct {
+    minute time.Time
+    fingerprint string
+}
+
+type callerStats struct {
+    requests int64
+    agents   map[string]bool
+    misses   map[string]bool
+}
+
+// Caller traffic seen since the last flush to Redis.
+var (
+    callerMu     sync.Mutex
+    callerCounts = map[callerKey]*callerStats{}
+)
+
+func callerEntry(fingerprint string) *callerStats {
+    k := callerKey{time.Now().UTC().Truncate(time.Minute), fingerprint}
+    s := callerCounts[k]
+    if s == nil {
+        s = &callerStats{agents: map[string]bool{}, misses: map[string]bool{}}
+        callerCounts[k] = s
+    }
+    return s
+}
+
+// observeCaller records a request against its API key's fingerprint for
+// the anomaly checks, or against anonymousKey when the key isn't
+// configured, so made-up keys can't grow the tracked set. Only
+// fingerprints go to Redis, alerts and logs, never the keys.
+func observeCaller(r *http.Request, fingerprint string) {
+    if !config.Get().Callers.Enabled { return }
+    ua := r.UserAgent()
+    if len(ua) > 200 { ua = ua[:200] }
+    callerMu.Lock()
+    defer callerMu.Unlock()
+    s := callerEntry(fingerprint)
+    s.requests++
+    if len(s.agents) < maxCallerSet { s.agents[ua] = true }
+}
+
+// noteTransactionMiss records a lookup of a transaction ID that doesn't
+// exist, the mark of a caller walking through IDs.
+func noteTransactionMiss(r *http.Request, id string) {
+    c, ok := r.Context().Value(apiCallerCtxKey{}).(apiCaller)
+    if !ok || !config.Get().Callers.Enabled { return }
+    callerMu.Lock()
+    defer callerMu.Unlock()
+    s := callerEntry(c.fingerprint)
+    if len(s.misses) < maxCallerSet { s.misses[id] = true }
+}
+
+func callerRedisKey(fingerprint, what string, minute time.Time) string {
+    return "caller:" + fingerprint + ":" + what + ":" + strconv.FormatInt(minute.Unix(), 10)
+}
+
+func callersKey(minute time.Time) string { return "callers:" + strconv.FormatInt(minute.Unix(), 10) }
+
+// runCallerMonitor flushes this instance's caller traffic to Redis every
+// 15 seconds, where all instances' traffic adds up, and checks each minute
+// once every instance has flushed it.
+func runCallerMonitor() {
+    var checked time.Time
+    for {
+        time.Sleep(15 * time.Second)
+        if !config.Get().Callers.Enabled || !cacheUp() { continue }
+        flushCallers()
+        minute := time.Now().UTC().Truncate(time.Minute).Add(-2 * time.Minute)
+        if minute.After(checked) {
+            checked = minute
+            checkCallers(minute)
+        }
+    }
+}
+
+// flushCallers adds the traffic seen since the last flush to Redis:
+// request counts, and HyperLogLogs of user agents and missed IDs, per key
+// and minute. Traffic that fails to write is dropped; the checks are
+// best effort.
+func flushCallers() {
+    callerMu.Lock()
+    pending := callerCounts
+    callerCounts = map[callerKey]*callerStats{}
+    callerMu.Unlock()
+    if len(pending) == 0 { return }
+    ttl := config.Get().Callers.Baseline + 10*time.Minute
+    pipe := rdb.Pipeline()
+    for k, s := range pending {
+        req := callerRedisKey(k.fingerprint, "req", k.minute)
+        pipe.IncrBy(ctx, req, s.requests)
+        pipe.Expire(ctx, req, ttl)
+        pipe.SAdd(ctx, callersKey(k.minute), k.fingerprint)
+        pipe.Expire(ctx, callersKey(k.minute), 10*time.Minute)
+        for what, set := range map[string]map[string]bool{"ua": s.agents, "miss": s.misses} {
+            if len(set) == 0 { continue }
+            members := make([]interface{}, 0, len(set))
+            for m := range set { members = append(members, m) }
+            key := callerRedisKey(k.fingerprint, what, k.minute)
+            pipe.PFAdd(ctx, key, members...)
+            pipe.Expire(ctx, key, 10*time.Minute)
+        }
+    }
+    if _, err := pipe.Exec(ctx); err != nil { noteRedisErr(err); log.Printf("caller traffic flush failed: %v", err) }
+}
+
+// checkCallers looks at every key seen in minute for anomalies. One
+// instance does it per minute.
+func checkCallers(minute time.Time) {
+    first, err := rdb.SetNX(ctx, "callers:checked:"+strconv.FormatInt(minute.Unix(), 10), 1, 10*time.Minute).Result()
+    if err != nil || !first { noteRedisErr(err); return }
+    keys, err := rdb.SMembers(ctx, callersKey(minute)).Result()
+    if err != nil { noteRedisErr(err); return }
+    cfg := config.Get().Callers
+    for _, fingerprint := range keys {
+        baselineKeys := make([]string, 0, int(cfg.Baseline/time.Minute))
+        for m := minute.Add(-cfg.Baseline); m.Before(minute); m = m.Add(time.Minute) { baselineKeys = append(baselineKeys, callerRedisKey(fingerprint, "req", m)) }
+        pipe := rdb.Pipeline()
+        req := pipe.Get(ctx, callerRedisKey(fingerprint, "req", minute))
+        baseline := pipe.MGet(ctx, baselineKeys...)
+        agents := pipe.PFCount(ctx, callerRedisKey(fingerprint, "ua", minute))
+        misses := pipe.PFCount(ctx, callerRedisKey(fingerprint, "miss", minute))
+        if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil { noteRedisErr(err); return }
+        n, _ := req.Int64()
+        var total int64
+        for _, v := range baseline.Val() {
+            if s, ok := v.(string); ok {
+                c, _ := strconv.ParseInt(s, 10, 64)
+                total += c
+            }
+        }
+        avg := float64(total) / float64(len(baselineKeys))
+        if n >= int64(cfg.SpikeMinRequests) && float64(n) >= cfg.SpikeFactor*avg {
+            raiseCallerAlert(fingerprint, anomalyVolumeSpike, fmt.Sprintf("API key %s made %d requests in the minute from %s UTC, against an average of %.0f over the %d minutes before", apiKeyLabel(fingerprint), n, minute.Format("15:04"), avg, len(baselineKeys)))
+        }
+        if m := misses.Val(); m >= int64(cfg.EnumerationMisses) {
+            raiseCallerAlert(fingerprint, anomalyEnumeration, fmt.Sprintf("API key %s looked up about %d transaction IDs that don't exist in the minute from %s UTC", apiKeyLabel(fingerprint), m, minute.Format("15:04")))
+        }
+        if a := agents.Val(); a >= int64(cfg.UserAgents) {
+            raiseCallerAlert(fingerprint, anomalyAgentChurn, fmt.Sprintf("API key %s was used with about %d different User-Agents in the minute from %s UTC", apiKeyLabel(fingerprint), a, minute.Format("15:04")))
+        }
+    }
+}
+
+// raiseCallerAlert publishes an API_CALLER_ANOMALY alert, unless one of
+// the same kind went out for the key within callers.cooldown. Like score
+// drift alerts it concerns no transaction, so it goes to the fraud-alerts
+// topic only.
+func raiseCallerAlert(fingerprint, kind, desc string) {
+    first, err := rdb.SetNX(ctx, "callers:alerted:"+fingerprint+":"+kind, 1, config.Get().Callers.Cooldown).Result()
+    if err != nil || !first { noteRedisErr(err); return }
+    callerAnomalies.WithLabelValues(kind).Inc()
+    log.Printf("caller anomaly %s: %s", kind, desc)
+    ev := events.AlertEvent{
+        AlertID:     events.NewAlertID(),
+        AlertType:   "API_CALLER_ANOMALY",
+        Severity:    "HIGH",
+        Description: desc,
+        Timestamp:   time.Now().Unix(),
+    }
+    codec := events.ProtobufCodec
+    if kafkaReady.Load() { codec = alertCodec }
+    b, err := codec.Encode(ev)
+    if err != nil { log.Printf("encode alert event: %v", err); return }
+    if err := alertPub.Publish([]byte(fingerprint), b, codec.ContentType()); err != nil { log.Printf("publish alert: %v", err) }
+}
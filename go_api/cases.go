@@ -0,0 +1,80 @@
+package main
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+// casesHandler lists the cases the processor groups alerts into:
+// GET /cases?status=OPEN&limit=100.
+func casesHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    status := strings.ToUpper(q.Get("status"))
+    if status == "" { status = "OPEN" }
+    limit := 100
+    if s := q.Get("limit"); s != "" {
+        if v, err := strconv.Atoi(s); err == nil { limit = v }
+    }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    out, err := caseStore.Cases(qctx, status, limit)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if out == nil { out = []store.Case{} }
+    writeJSON(w, http.StatusOK, out)
+}
+
+// caseHandler serves GET /cases/{id}, the case with its alerts, and
+// POST /cases/{id}/close.
+func caseHandler(w http.ResponseWriter, r *http.Request) {
+    parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/cases/"), "/")
+    id, err := strconv.ParseInt(parts[0], 10, 64)
+    if err != nil || len(parts) > 2 { http.NotFound(w, r); return }
+    switch {
+    case len(parts) == 1 && r.Method == http.MethodGet:
+        qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+        defer cancel()
+        c, err := caseStore.Case(qctx, id)
+        if errors.Is(err, store.ErrNotFound) { http.Error(w, "Case not found", http.StatusNotFound); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        alerts, err := caseStore.CaseAlerts(qctx, id)
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if alerts == nil { alerts = []store.Alert{} }
+        writeJSON(w, http.StatusOK, map[string]interface{}{"case": c, "alerts": alerts})
+    case len(parts) == 2 && parts[1] == "close" && r.Method == http.MethodPost:
+        closeCaseHandler(w, r, id)
+    case len(parts) == 1 || parts[1] == "close":
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// closeCaseHandler closes a case: {"analyst": "jdoe"}. Its alerts keep
+// their own status.
+func closeCaseHandler(w http.ResponseWriter, r *http.Request, id int64) {
+    var body struct {
+        Analyst string `json:"analyst"`
+    }
+    if !decodeBody(w, r, &body, bodyLimit()) { return }
+    body.Analyst = strings.TrimSpace(body.Analyst)
+    if body.Analyst == "" || len(body.Analyst) > 100 { http.Error(w, "analyst is required, at most 100 characters", http.StatusBadRequest); return }
+    qctx, cancel := conn.QueryCtx(r.Context())
+    defer cancel()
+    c, err := caseStore.CloseCase(qctx, id, body.Analyst)
+    switch {
+    case errors.Is(err, store.ErrNotFound):
+        http.Error(w, "Case not found", http.StatusNotFound)
+    case errors.Is(err, store.ErrNotOpen):
+        http.Error(w, "Case is already closed", http.StatusConflict)
+    case err != nil:
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+    default:
+        writeJSON(w, http.StatusOK, c)
+    }
+}
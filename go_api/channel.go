@@ -0,0 +1,68 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "strings"
+
+    "example.com/fraud/internal/config"
+)
+
+// Payment channels. Each has its own amount threshold in the rules; card
+// payments are additionally checked for velocity, since card fraud comes as
+// many quick purchases while ACH, wire and P2P fraud comes as a few large
+// transfers.
+const (
+    channelCard = "card"
+    channelACH  = "ach"
+    channelWire = "wire"
+    channelP2P  = "p2p"
+)
+
+// normalizeChannel lower-cases req.Channel, defaulting it to card, and
+// rejects unknown channels.
+func normalizeChannel(req *TransactionRequest) error {
+    req.Channel = strings.ToLower(req.Channel)
+    switch req.Channel {
+    case "":
+        req.Channel = channelCard
+    case channelCard, channelACH, channelWire, channelP2P:
+    default:
+        return fmt.Errorf("channel must be one of card, ach, wire, p2p")
+    }
+    return nil
+}
+
+// highAmount is the rules' high-amount threshold for channel.
+func highAmount(rules config.Rules, channel string) float64 {
+    switch channel {
+    case channelACH:
+        return rules.ACHHighAmount
+    case channelWire:
+        return rules.WireHighAmount
+    case channelP2P:
+        return rules.P2PHighAmount
+    }
+    return rules.HighAmount
+}
+
+// CardVelocity reads the counter countCardTransaction keeps for the current
+// rules.card_velocity_window. There is none while Redis is unavailable.
+func (liveSource) CardVelocity(ctx context.Context, userID string) (int, bool) {
+    if !cacheUp() { return 0, false }
+    v, err := rdb.Get(ctx, "card_velocity:"+userID).Result()
+    if err != nil { noteRedisErr(err); return 0, false }
+    n, _ := strconv.Atoi(v)
+    return n, true
+}
+
+// countCardTransaction adds a scored card transaction to the user's
+// velocity counter. The window starts with the first transaction counted.
+func countCardTransaction(ctx context.Context, userID string) {
+    if !cacheUp() { return }
+    key := "card_velocity:" + userID
+    n, err := rdb.Incr(ctx, key).Result()
+    if err != nil { noteRedisErr(err); return }
+    if n == 1 { noteRedisErr(rdb.Expire(ctx, key, config.Get().Rules.CardVelocityWindow).Err()) }
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/spf13/cobra"
+    "gopkg.in/yaml.v3"
+
+    "example.com/fraud/internal/config"
+)
+
+// configCmd covers the rule thresholds too: they live in the config file
+// and the services re-read them on SIGHUP.
+func configCmd() *cobra.Command {
+    cmd := &cobra.Command{
+        Use:   "config",
+        Short: "Validate and print the configuration",
+        // check reports load errors itself instead of failing in the root's
+        // config.Init.
+        PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+    }
+
+    check := &cobra.Command{
+        Use:   "check [FILE]",
+        Short: "Validate a config file together with the current environment",
+        Args:  cobra.MaximumNArgs(1),
+        RunE: func(_ *cobra.Command, args []string) error {
+            path := configFile
+            if len(args) == 1 { path = args[0] }
+            if _, err := config.Load(path); err != nil { return err }
+            fmt.Println("ok")
+            return nil
+        },
+    }
+
+    show := &cobra.Command{
+        Use:   "show",
+        Short: "Print the effective configuration as YAML, passwords masked",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            cfg, err := config.Load(configFile)
+            if err != nil { return err }
+            if cfg.Postgres.Password != "" { cfg.Postgres.Password = "********" }
+            if cfg.Redis.Password != "" { cfg.Redis.Password = "********" }
+            if cfg.Kafka.SASLPassword != "" { cfg.Kafka.SASLPassword = "********" }
+            if cfg.Search.Password != "" { cfg.Search.Password = "********" }
+            if cfg.ClickHouse.Password != "" { cfg.ClickHouse.Password = "********" }
+            if cfg.Archive.SecretAccessKey != "" { cfg.Archive.SecretAccessKey = "********" }
+            if cfg.Archive.SessionToken != "" { cfg.Archive.SessionToken = "********" }
+            if cfg.Reports.Email.Password != "" { cfg.Reports.Email.Password = "********" }
+            if cfg.Webhooks.StripeSecret != "" { cfg.Webhooks.StripeSecret = "********" }
+            if cfg.Webhooks.AdyenHMACKey != "" { cfg.Webhooks.AdyenHMACKey = "********" }
+            if cfg.Secrets.VaultToken != "" { cfg.Secrets.VaultToken = "********" }
+            enc := yaml.NewEncoder(os.Stdout)
+            enc.SetIndent(2)
+            if err := enc.Encode(cfg); err != nil { return err }
+            return enc.Close()
+        },
+    }
+
+    cmd.AddCommand(check, show)
+    return cmd
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/spf13/cobra"
+)
+
+// e2eCmd drives one transaction through a running deployment, API to
+// Kafka to processor to alert, and fails on the first step that doesn't
+// happen. It talks only to the API, so it checks what a client would see;
+// start the stack first (docker compose up -d) and point --api-url at it.
+func e2eCmd() *cobra.Command {
+    apiURL := os.Getenv("FRAUD_API_URL")
+    if apiURL == "" { apiURL = "http://localhost:8000" }
+    var timeout time.Duration
+    cmd := &cobra.Command{
+        Use:   "e2e",
+        Short: "Check the score, Kafka, processor and alert flow end to end against a running stack",
+        Long: "Wait for the API to report Postgres and Redis up, score an obviously fraudulent transaction for a fresh e2e-* user, " +
+            "read it back, then wait for the processor to store an alert for it and publish it to the alerts topic. " +
+            "Each step prints ok or FAIL; the command exits non-zero on the first failure. " +
+            "The transaction and alert are left in place, under user e2e-<unix nanos> and merchant e2e-merchant.",
+        Args: cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            e := &e2eRun{base: strings.TrimSuffix(apiURL, "/"), client: &http.Client{Timeout: 10 * time.Second}, deadline: time.Now().Add(timeout)}
+            var txID, alertID string
+            steps := []struct {
+                name string
+                run  func() error
+            }{
+                {"api healthy", e.healthy},
+                {"transaction scored as fraud", func() (err error) { txID, err = e.score(); return err }},
+                {"transaction stored", func() error { return e.stored(txID) }},
+                {"alert raised by the processor", func() (err error) { alertID, err = e.alert(txID); return err }},
+                {"alert published", func() error { return e.published(alertID) }},
+            }
+            for _, s := range steps {
+                start := time.Now()
+                if err := s.run(); err != nil {
+                    fmt.Printf("FAIL %s: %v\n", s.name, err)
+                    return fmt.Errorf("e2e failed at %q", s.name)
+                }
+                fmt.Printf("ok   %s (%s)\n", s.name, time.Since(start).Round(time.Millisecond))
+            }
+            fmt.Printf("transaction %s, alert %s\n", txID, alertID)
+            return nil
+        },
+    }
+    f := cmd.Flags()
+    f.StringVar(&apiURL, "api-url", apiURL, "base URL of go_api (env FRAUD_API_URL)")
+    f.DurationVar(&timeout, "timeout", 2*time.Minute, "how long to wait for the stack to come up and the alert to appear")
+    return cmd
+}
+
+type e2eRun struct {
+    base     string
+    client   *http.Client
+    deadline time.Time
+}
+
+// get decodes the JSON answer to GET path into out.
+func (e *e2eRun) get(path string, out interface{}) error {
+    return e.do(http.MethodGet, path, nil, out)
+}
+
+func (e *e2eRun) do(method, path string, body interface{}, out interface{}) error {
+    var r io.Reader
+    if body != nil {
+        b, err := json.Marshal(body)
+        if err != nil { return err }
+        r = bytes.NewReader(b)
+    }
+    req, err := http.NewRequestWithContext(ctx, method, e.base+path, r)
+    if err != nil { return err }
+    if body != nil { req.Header.Set("Content-Type", "application/json") }
+    resp, err := e.client.Do(req)
+    if err != nil { return err }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        b, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(b)))
+    }
+    return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// poll calls check every second until it reports done or the run's
+// deadline passes, and returns check's last error then.
+func (e *e2eRun) poll(check func() (bool, error)) error {
+    for {
+        done, err := check()
+        if done { return err }
+        if time.Now().After(e.deadline) {
+            if err == nil { err = fmt.Errorf("timed out") }
+            return err
+        }
+        time.Sleep(time.Second)
+    }
+}
+
+func (e *e2eRun) healthy() error {
+    return e.poll(func() (bool, error) {
+        var status map[string]string
+        if err := e.get("/health", &status); err != nil { return false, err }
+        if status["postgres"] != "up" || status["redis"] != "up" { return false, fmt.Errorf("health: %v", status) }
+        return true, nil
+    })
+}
+
+// score sends a transaction every rule agrees on: a high amount at a risky
+// merchant from a brand-new account.
+func (e *e2eRun) score() (string, error) {
+    created := time.Now().UTC()
+    tx := map[string]interface{}{
+        "user_id":            fmt.Sprintf("e2e-%d", created.UnixNano()),
+        "amount":             9500.00,
+        "merchant_id":        "e2e-merchant",
+        "merchant_risk":      0.99,
+        "device_id":          fmt.Sprintf("e2e-dev-%d", created.UnixNano()),
+        "ip_address":         "198.18.0.1",
+        "account_created_at": created,
+    }
+    var out struct {
+        TransactionID string  `json:"transaction_id"`
+        IsFraud       bool    `json:"is_fraud"`
+        FraudScore    float64 `json:"fraud_score"`
+    }
+    if err := e.do(http.MethodPost, "/transactions/process", tx, &out); err != nil { return "", err }
+    if out.TransactionID == "" { return "", fmt.Errorf("no transaction_id in the response") }
+    if !out.IsFraud { return out.TransactionID, fmt.Errorf("transaction %s scored %.2f, not fraud", out.TransactionID, out.FraudScore) }
+    return out.TransactionID, nil
+}
+
+// stored reads the transaction back, allowing for replica lag.
+func (e *e2eRun) stored(txID string) error {
+    return e.poll(func() (bool, error) {
+        var out struct {
+            TransactionID string `json:"transaction_id"`
+        }
+        if err := e.get("/transactions/"+txID, &out); err != nil { return false, err }
+        if out.TransactionID != txID { return true, fmt.Errorf("read back transaction %q", out.TransactionID) }
+        return true, nil
+    })
+}
+
+// alert waits for the processor to consume the transaction from Kafka and
+// store its FRAUD_DETECTED alert.
+func (e *e2eRun) alert(txID string) (string, error) {
+    var alertID string
+    err := e.poll(func() (bool, error) {
+        var alerts []struct {
+            AlertID       string `json:"alert_id"`
+            TransactionID string `json:"transaction_id"`
+            AlertType     string `json:"alert_type"`
+        }
+        if err := e.get("/alerts?status=OPEN&limit=500", &alerts); err != nil { return false, err }
+        for _, a := range alerts {
+            if a.TransactionID == txID && a.AlertType == "FRAUD_DETECTED" {
+                alertID = a.AlertID
+                return true, nil
+            }
+        }
+        return false, fmt.Errorf("no open FRAUD_DETECTED alert for %s", txID)
+    })
+    return alertID, err
+}
+
+// published waits for the alert's alert.notified entry, which the processor
+// records once the alerts topic has accepted it.
+func (e *e2eRun) published(alertID string) error {
+    return e.poll(func() (bool, error) {
+        var history struct {
+            Timeline []struct {
+                Action string `json:"action"`
+            } `json:"timeline"`
+        }
+        if err := e.get("/alerts/"+alertID+"/history", &history); err != nil { return false, err }
+        for _, h := range history.Timeline {
+            if h.Action == "alert.notified" { return true, nil }
+        }
+        return false, fmt.Errorf("alert %s has no alert.notified entry", alertID)
+    })
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "text/tabwriter"
+
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/go_api/internal/warehouse"
+    "example.com/fraud/internal/config"
+)
+
+func exportCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "export", Short: "Run and inspect the data warehouse export"}
+    run := &cobra.Command{
+        Use:   "run",
+        Short: "Export everything changed since the last export now",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            cfg := config.Get().Warehouse
+            wh, err := openWarehouse(cfg)
+            if err != nil { return err }
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            loaded, err := warehouse.Export(ctx, st, wh, cfg.BatchSize, cfg.Lag)
+            for _, t := range store.ExportTables { fmt.Printf("%s: %d rows\n", t.Name, loaded[t.Name]) }
+            return err
+        },
+    }
+    status := &cobra.Command{
+        Use:   "status",
+        Short: "Print how far each table has been exported",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            wh, err := openWarehouse(config.Get().Warehouse)
+            if err != nil { return err }
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            marks, err := st.Watermarks(ctx, wh.Name())
+            if err != nil { return err }
+            fmt.Println(wh.Name())
+            tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+            fmt.Fprintln(tw, "TABLE\tWATERMARK\tLAST KEY\tROWS\tUPDATED")
+            for _, w := range marks {
+                fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", w.Table, w.At.Format("2006-01-02 15:04:05.000000"), w.Key, w.Rows, w.UpdatedAt.Format("2006-01-02 15:04:05"))
+            }
+            return tw.Flush()
+        },
+    }
+    cmd.AddCommand(run, status)
+    return cmd
+}
+
+func openWarehouse(cfg config.Warehouse) (warehouse.Warehouse, error) {
+    wh, err := warehouse.New(cfg)
+    if err == nil && wh == nil { err = errors.New("no warehouse configured (warehouse.kind is empty)") }
+    return wh, err
+}
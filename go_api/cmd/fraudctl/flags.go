@@ -0,0 +1,68 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/internal/flags"
+)
+
+func flagsCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "flags", Short: "List and change feature flags"}
+
+    list := &cobra.Command{
+        Use:   "list",
+        Short: "Print every flag",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            rdb, err := openRedis()
+            if err != nil { return err }
+            defer rdb.Close()
+            all, err := flags.New(rdb).List(ctx)
+            if err != nil { return err }
+            tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+            fmt.Fprintln(tw, "NAME\tENABLED\tPERCENTAGE\tTENANTS")
+            for _, f := range all {
+                fmt.Fprintf(tw, "%s\t%t\t%d\t%s\n", f.Name, f.Enabled, f.Percentage, strings.Join(f.Tenants, ","))
+            }
+            return tw.Flush()
+        },
+    }
+
+    var f flags.Flag
+    set := &cobra.Command{
+        Use:   "set NAME",
+        Short: "Create or replace a flag",
+        Long:  "Create or replace a flag. The flag is on for every --tenant and, when --enabled, for --percentage percent of everyone else. Services apply the change on their next refresh (flags.refresh_interval).",
+        Args:  cobra.ExactArgs(1),
+        RunE: func(_ *cobra.Command, args []string) error {
+            if f.Percentage < 0 || f.Percentage > 100 { return fmt.Errorf("--percentage must be between 0 and 100") }
+            rdb, err := openRedis()
+            if err != nil { return err }
+            defer rdb.Close()
+            return flags.New(rdb).Set(ctx, args[0], f)
+        },
+    }
+    set.Flags().BoolVar(&f.Enabled, "enabled", false, "turn the flag on for --percentage of all traffic")
+    set.Flags().IntVar(&f.Percentage, "percentage", 100, "share of keys (users) the flag is on for when --enabled")
+    set.Flags().StringSliceVar(&f.Tenants, "tenant", nil, "tenant the flag is always on for; repeatable")
+
+    del := &cobra.Command{
+        Use:   "delete NAME",
+        Short: "Delete a flag, so services fall back to its default",
+        Args:  cobra.ExactArgs(1),
+        RunE: func(_ *cobra.Command, args []string) error {
+            rdb, err := openRedis()
+            if err != nil { return err }
+            defer rdb.Close()
+            return flags.New(rdb).Delete(ctx, args[0])
+        },
+    }
+
+    cmd.AddCommand(list, set, del)
+    return cmd
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/spf13/cobra"
+)
+
+func ipCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "ip", Short: "Inspect and lift card-testing IP blocks"}
+
+    list := &cobra.Command{
+        Use:   "blocked",
+        Short: "Print blocked IPs, the transaction that triggered each block and its remaining time",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            rdb, err := openRedis()
+            if err != nil { return err }
+            defer rdb.Close()
+            tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+            fmt.Fprintln(tw, "IP\tTRANSACTION\tREMAINING")
+            iter := rdb.Scan(ctx, 0, "blocked_ip:*", 100).Iterator()
+            for iter.Next(ctx) {
+                key := iter.Val()
+                txID, err := rdb.Get(ctx, key).Result()
+                if err != nil { continue }
+                ttl, _ := rdb.TTL(ctx, key).Result()
+                fmt.Fprintf(tw, "%s\t%s\t%s\n", strings.TrimPrefix(key, "blocked_ip:"), txID, ttl)
+            }
+            if err := iter.Err(); err != nil { return err }
+            return tw.Flush()
+        },
+    }
+
+    unblock := &cobra.Command{
+        Use:   "unblock IP...",
+        Short: "Lift the block on one or more IPs",
+        Args:  cobra.MinimumNArgs(1),
+        RunE: func(_ *cobra.Command, args []string) error {
+            rdb, err := openRedis()
+            if err != nil { return err }
+            defer rdb.Close()
+            for _, ip := range args {
+                n, err := rdb.Del(ctx, "blocked_ip:"+ip).Result()
+                if err != nil { return err }
+                if n == 0 { fmt.Printf("%s: not blocked\n", ip) } else { fmt.Printf("%s: unblocked\n", ip) }
+            }
+            return nil
+        },
+    }
+
+    cmd.AddCommand(list, unblock)
+    return cmd
+}
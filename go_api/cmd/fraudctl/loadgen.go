@@ -0,0 +1,371 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "math"
+    "math/rand"
+    "net/http"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "text/tabwriter"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+)
+
+// genTx is a generated transaction in the shape of POST
+// /transactions/process; fraud is the generator's ground truth.
+type genTx struct {
+    UserID     string  `json:"user_id"`
+    Amount     float64 `json:"amount"`
+    MerchantID string  `json:"merchant_id"`
+    MCC        string  `json:"mcc"`
+    Channel    string  `json:"channel"`
+    DeviceID   string  `json:"device_id"`
+    IPAddress  string  `json:"ip_address"`
+    Email      string  `json:"email"`
+    Country    string  `json:"country"`
+    fraud      bool
+}
+
+type genUser struct {
+    id, device, ip, email, country string
+    median                         float64
+    merchants                      []int
+}
+
+type genMerchant struct {
+    id, mcc string
+}
+
+var (
+    // Everyday categories: grocery, restaurants, fuel, fast food, drug
+    // stores, department stores, taxis, digital goods.
+    everydayMCCs = []string{"5411", "5812", "5541", "5814", "5912", "5311", "4121", "5818"}
+    // Codes internal/mcc names; fraud leans on them.
+    riskyMCCs = []string{"7995", "6051", "6540", "4829"}
+    countries = []string{"US", "US", "US", "US", "GB", "DE", "FR", "CA", "IN", "BR"}
+)
+
+// generator produces a reproducible stream of transactions for a fixed
+// population of users and merchants. It isn't safe for concurrent use.
+type generator struct {
+    rng       *rand.Rand
+    users     []genUser
+    merchants []genMerchant
+    fraudRate float64
+}
+
+func newGenerator(seed int64, users, merchants int, fraudRate float64) *generator {
+    g := &generator{rng: rand.New(rand.NewSource(seed)), fraudRate: fraudRate}
+    for i := 0; i < merchants; i++ {
+        code := everydayMCCs[g.rng.Intn(len(everydayMCCs))]
+        if g.rng.Float64() < 0.05 { code = riskyMCCs[g.rng.Intn(len(riskyMCCs))] }
+        g.merchants = append(g.merchants, genMerchant{id: fmt.Sprintf("loadgen-m%05d", i), mcc: code})
+    }
+    for i := 0; i < users; i++ {
+        u := genUser{
+            id:      fmt.Sprintf("loadgen-u%06d", i),
+            device:  g.device(),
+            ip:      g.ip(),
+            country: countries[g.rng.Intn(len(countries))],
+            // Typical spend varies a lot between users: medians from a few
+            // dollars to a few hundred, around $33.
+            median: math.Exp(3.5 + 0.8*g.rng.NormFloat64()),
+        }
+        u.email = u.id + "@example.com"
+        // Most of a user's spend goes to a handful of regular merchants.
+        for j := 0; j < 3+g.rng.Intn(5); j++ { u.merchants = append(u.merchants, g.rng.Intn(merchants)) }
+        g.users = append(g.users, u)
+    }
+    return g
+}
+
+func (g *generator) device() string { return fmt.Sprintf("dev-%012x", g.rng.Int63n(1<<48)) }
+
+// ip returns an address in 198.18.0.0/15, the range reserved for benchmark
+// traffic, so generated IPs never collide with real customers'.
+func (g *generator) ip() string {
+    n := g.rng.Intn(1 << 17)
+    return fmt.Sprintf("198.%d.%d.%d", 18+n>>16, n>>8&0xff, n&0xff)
+}
+
+func (g *generator) amount(median float64) float64 {
+    return math.Round(median*math.Exp(0.5*g.rng.NormFloat64())*100) / 100
+}
+
+// next returns one transaction: a user's routine purchase, or with
+// probability fraudRate an account takeover from a new device and IP.
+func (g *generator) next() genTx {
+    u := g.users[g.rng.Intn(len(g.users))]
+    m := g.merchants[u.merchants[g.rng.Intn(len(u.merchants))]]
+    if g.rng.Float64() < 0.2 { m = g.merchants[g.rng.Intn(len(g.merchants))] }
+    tx := genTx{UserID: u.id, Amount: g.amount(u.median), MerchantID: m.id, MCC: m.mcc, Channel: "card", DeviceID: u.device, IPAddress: u.ip, Email: u.email, Country: u.country}
+    if g.rng.Float64() >= g.fraudRate { return tx }
+
+    tx.fraud = true
+    tx.Amount = math.Round(u.median*(3+10*g.rng.Float64())*100) / 100
+    tx.DeviceID, tx.IPAddress = g.device(), g.ip()
+    if g.rng.Float64() < 0.5 {
+        m = g.merchants[g.rng.Intn(len(g.merchants))]
+        tx.MerchantID, tx.MCC = m.id, riskyMCCs[g.rng.Intn(len(riskyMCCs))]
+    }
+    if g.rng.Float64() < 0.3 { tx.Email = fmt.Sprintf("%s@mailinator.com", tx.DeviceID) }
+    if g.rng.Float64() < 0.3 { tx.Country = countries[g.rng.Intn(len(countries))] }
+    return tx
+}
+
+// burst returns size fraudulent transactions sent back to back, alternating
+// between card testing (small charges on many cards from one IP and device)
+// and velocity (one taken-over account spending at many merchants).
+func (g *generator) burst(size int, cardTesting bool) []genTx {
+    txs := make([]genTx, 0, size)
+    ip, device := g.ip(), g.device()
+    u := g.users[g.rng.Intn(len(g.users))]
+    m := g.merchants[g.rng.Intn(len(g.merchants))]
+    for i := 0; i < size; i++ {
+        tx := genTx{Channel: "card", DeviceID: device, IPAddress: ip, fraud: true}
+        if cardTesting {
+            v := g.users[g.rng.Intn(len(g.users))]
+            tx.UserID, tx.Email, tx.Country = v.id, v.email, v.country
+            tx.MerchantID, tx.MCC = m.id, m.mcc
+            tx.Amount = float64(1+g.rng.Intn(500)) / 100
+        } else {
+            n := g.merchants[g.rng.Intn(len(g.merchants))]
+            tx.UserID, tx.Email, tx.Country = u.id, u.email, u.country
+            tx.MerchantID, tx.MCC = n.id, n.mcc
+            tx.Amount = g.amount(2 * u.median)
+        }
+        txs = append(txs, tx)
+    }
+    return txs
+}
+
+// loadStats collects the outcome of every send; safe for concurrent use.
+type loadStats struct {
+    mu        sync.Mutex
+    latencies []time.Duration
+    decisions map[string]int
+    // flagged counts is_fraud responses by ground truth.
+    flagged, total [2]int
+    failed         atomic.Int64
+}
+
+func (s *loadStats) record(tx genTx, d time.Duration, decision string, isFraud bool) {
+    truth := 0
+    if tx.fraud { truth = 1 }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.latencies = append(s.latencies, d)
+    if decision != "" { s.decisions[decision]++ }
+    s.total[truth]++
+    if isFraud { s.flagged[truth]++ }
+}
+
+func (s *loadStats) print(elapsed time.Duration, scored bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    sent := len(s.latencies)
+    tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintf(tw, "sent\t%d (%d fraud)\n", sent, s.total[1])
+    fmt.Fprintf(tw, "failed\t%d\n", s.failed.Load())
+    fmt.Fprintf(tw, "elapsed\t%s (%.1f tx/s)\n", elapsed.Round(time.Millisecond), float64(sent)/elapsed.Seconds())
+    if sent > 0 {
+        sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+        pct := func(p float64) time.Duration { return s.latencies[int(p*float64(sent-1))].Round(time.Microsecond) }
+        fmt.Fprintf(tw, "latency\tp50 %s  p95 %s  p99 %s  max %s\n", pct(0.50), pct(0.95), pct(0.99), s.latencies[sent-1].Round(time.Microsecond))
+    }
+    if scored {
+        for _, d := range []string{"APPROVE", "REVIEW", "DECLINE"} { fmt.Fprintf(tw, "%s\t%d\n", strings.ToLower(d), s.decisions[d]) }
+        fmt.Fprintf(tw, "flagged fraud\t%d/%d\n", s.flagged[1], s.total[1])
+        fmt.Fprintf(tw, "flagged legitimate\t%d/%d\n", s.flagged[0], s.total[0])
+    }
+    tw.Flush()
+}
+
+func loadgenCmd() *cobra.Command {
+    apiURL := os.Getenv("FRAUD_API_URL")
+    if apiURL == "" { apiURL = "http://localhost:8000" }
+    var (
+        target, tenant, topic         string
+        rate, users, merchants, count int
+        concurrency, burstSize        int
+        fraudRate                     float64
+        duration, burstEvery          time.Duration
+        seed                          int64
+    )
+    cmd := &cobra.Command{
+        Use:   "loadgen",
+        Short: "Send a synthetic transaction stream to the API or straight to Kafka",
+        Long: "Generate transactions for a fixed population of users and merchants: routine purchases around each user's typical amount at their regular merchants, " +
+            "a --fraud-rate share of account takeovers (new device and IP, high amounts, risky MCCs), and optional card-testing and velocity bursts. " +
+            "--target api posts to /transactions/process and reports latency, decisions and how much of the fraud was flagged; " +
+            "--target kafka publishes scored transaction events to the processor's topic, bypassing the API. " +
+            "Users and merchants are named loadgen-u*/loadgen-m* and IPs come from 198.18.0.0/15, so generated data is easy to find and delete.",
+        Args: cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            if rate <= 0 || users <= 0 || merchants <= 0 || concurrency <= 0 { return fmt.Errorf("--rate, --users, --merchants and --concurrency must be positive") }
+            if fraudRate < 0 || fraudRate > 1 { return fmt.Errorf("--fraud-rate must be between 0 and 1") }
+            if count <= 0 && duration <= 0 { return fmt.Errorf("one of --count or --duration is required") }
+            if seed == 0 { seed = time.Now().UnixNano() }
+
+            var send func(genTx) error
+            var flush func() error
+            stats := &loadStats{decisions: map[string]int{}}
+            switch target {
+            case "api":
+                send = apiSender(apiURL, tenant, stats)
+            case "kafka":
+                if topic == "" { topic = config.Get().Processor.Topic }
+                w := conn.NewKafkaWriter(config.Get().Kafka.Brokers, topic)
+                w.Async, w.BatchSize, w.BatchTimeout = true, config.Get().Kafka.BatchSize, config.Get().Kafka.Linger
+                w.Completion = func(messages []kafka.Message, err error) {
+                    if err != nil { stats.failed.Add(int64(len(messages))) }
+                }
+                send, flush = kafkaSender(w, stats), w.Close
+            default:
+                return fmt.Errorf("unknown --target %q (want api or kafka)", target)
+            }
+
+            gen := newGenerator(seed, users, merchants, fraudRate)
+            jobs := make(chan genTx, concurrency)
+            var wg sync.WaitGroup
+            for i := 0; i < concurrency; i++ {
+                wg.Add(1)
+                go func() {
+                    defer wg.Done()
+                    for tx := range jobs {
+                        if err := send(tx); err != nil {
+                            if stats.failed.Add(1) <= 5 { fmt.Fprintf(os.Stderr, "send: %v\n", err) }
+                        }
+                    }
+                }()
+            }
+
+            fmt.Fprintf(os.Stderr, "loadgen: %s, %d tx/s, seed %d\n", target, rate, seed)
+            start := time.Now()
+            tick := time.NewTicker(time.Second / time.Duration(rate))
+            defer tick.Stop()
+            var nextBurst <-chan time.Time
+            if burstEvery > 0 && burstSize > 0 {
+                bt := time.NewTicker(burstEvery)
+                defer bt.Stop()
+                nextBurst = bt.C
+            }
+            sent, cardTesting := 0, true
+            for (count <= 0 || sent < count) && (duration <= 0 || time.Since(start) < duration) {
+                select {
+                case <-nextBurst:
+                    for _, tx := range gen.burst(burstSize, cardTesting) {
+                        if count > 0 && sent >= count { break }
+                        jobs <- tx
+                        sent++
+                    }
+                    cardTesting = !cardTesting
+                case <-tick.C:
+                    jobs <- gen.next()
+                    sent++
+                }
+            }
+            close(jobs)
+            wg.Wait()
+            // Closing the writer flushes buffered batches, whose failures
+            // count too.
+            if flush != nil { flush() }
+            stats.print(time.Since(start), target == "api")
+            return nil
+        },
+    }
+    f := cmd.Flags()
+    f.StringVar(&target, "target", "api", "where to send transactions: api or kafka")
+    f.StringVar(&apiURL, "api-url", apiURL, "base URL of go_api (env FRAUD_API_URL)")
+    f.StringVar(&tenant, "tenant", "", "X-Tenant-ID sent with each request")
+    f.StringVar(&topic, "topic", "", "Kafka topic (default processor.topic)")
+    f.IntVar(&rate, "rate", 50, "transactions per second, excluding bursts")
+    f.IntVar(&count, "count", 1000, "stop after this many transactions (0 = no limit)")
+    f.DurationVar(&duration, "duration", 0, "stop after this long (0 = no limit)")
+    f.IntVar(&users, "users", 1000, "size of the user population")
+    f.IntVar(&merchants, "merchants", 200, "number of merchants")
+    f.Float64Var(&fraudRate, "fraud-rate", 0.02, "share of account-takeover transactions, 0-1")
+    f.DurationVar(&burstEvery, "burst-every", 0, "start a card-testing or velocity burst this often (0 = no bursts)")
+    f.IntVar(&burstSize, "burst-size", 20, "transactions per burst")
+    f.IntVar(&concurrency, "concurrency", 8, "requests in flight")
+    f.Int64Var(&seed, "seed", 0, "random seed, for a repeatable stream (default random)")
+    return cmd
+}
+
+func apiSender(apiURL, tenant string, stats *loadStats) func(genTx) error {
+    client := &http.Client{Timeout: 10 * time.Second}
+    url := strings.TrimSuffix(apiURL, "/") + "/transactions/process"
+    return func(tx genTx) error {
+        body, err := json.Marshal(tx)
+        if err != nil { return err }
+        req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+        if err != nil { return err }
+        req.Header.Set("Content-Type", "application/json")
+        if tenant != "" { req.Header.Set("X-Tenant-ID", tenant) }
+        start := time.Now()
+        resp, err := client.Do(req)
+        if err != nil { return err }
+        defer resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            b, _ := io.ReadAll(resp.Body)
+            return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+        }
+        var out struct {
+            IsFraud  bool   `json:"is_fraud"`
+            Decision string `json:"decision"`
+        }
+        if err := json.NewDecoder(resp.Body).Decode(&out); err != nil { return err }
+        stats.record(tx, time.Since(start), out.Decision, out.IsFraud)
+        return nil
+    }
+}
+
+// kafkaSender publishes transactions as go_api would after scoring them. The
+// score is made up from the ground truth, so the processor's aggregates see
+// a plausible fraud mix without the API in the loop.
+func kafkaSender(w *kafka.Writer, stats *loadStats) func(genTx) error {
+    var seq atomic.Int64
+    base := time.Now().UnixNano()
+    return func(tx genTx) error {
+        n := seq.Add(1)
+        // Derived from the sequence number so the score doesn't need the
+        // generator's (single-threaded) random source.
+        score := float64(n%300) / 1000
+        if tx.fraud { score = 0.7 + float64(n%300)/1000 }
+        ev := events.TransactionEvent{
+            TransactionID: strconv.FormatInt(base+n, 10),
+            UserID:        tx.UserID,
+            Amount:        tx.Amount,
+            FraudScore:    score,
+            IsFraud:       tx.fraud,
+            Timestamp:     time.Now().Unix(),
+            DeviceID:      &tx.DeviceID,
+            IPAddress:     &tx.IPAddress,
+            MerchantID:    &tx.MerchantID,
+            MCC:           &tx.MCC,
+            Channel:       &tx.Channel,
+            Country:       &tx.Country,
+        }
+        b, err := events.ProtobufCodec.Encode(ev)
+        if err != nil { return err }
+        start := time.Now()
+        err = w.WriteMessages(ctx, kafka.Message{Key: []byte(tx.UserID), Value: b, Headers: []kafka.Header{{Key: "content-type", Value: []byte(events.ProtobufCodec.ContentType())}}})
+        if err != nil { return err }
+        stats.record(tx, time.Since(start), "", false)
+        return nil
+    }
+}
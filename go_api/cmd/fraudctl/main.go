@@ -0,0 +1,47 @@
+// Command fraudctl runs operational tasks against a deployment: feature
+// flags, config checks, outbox replay, partition retention, re-scoring,
+// card-testing IP blocks, synthetic load, the warehouse export, scheduled
+// reports, the entity rollups, protobuf contract checks and an end-to-end
+// check of a running stack.
+// It reads the same config file and environment variables as the services,
+// so run it with the environment of the service it is meant to act for.
+package main
+
+import (
+    "context"
+    "os"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+var (
+    configFile string
+    ctx        = context.Background()
+)
+
+func main() {
+    root := &cobra.Command{
+        Use:          "fraudctl",
+        Short:        "Operational tasks for the fraud detection services",
+        SilenceUsage: true,
+        PersistentPreRunE: func(*cobra.Command, []string) error { return config.Init(configFile) },
+    }
+    root.PersistentFlags().StringVar(&configFile, "config", os.Getenv("CONFIG_FILE"), "YAML config file; environment variables override it")
+    root.AddCommand(flagsCmd(), configCmd(), outboxCmd(), retentionCmd(), txCmd(), ipCmd(), loadgenCmd(), exportCmd(), reportCmd(), statsCmd(), e2eCmd())
+    if err := root.Execute(); err != nil { os.Exit(1) }
+}
+
+// openStore connects to the primary; every command here writes or must not
+// see replica lag.
+func openStore() (*store.Postgres, func(), error) {
+    pool, err := conn.NewPgPool(ctx, config.Get().Postgres.Host, "fraudctl_pg_pool")
+    if err != nil { return nil, nil, err }
+    return store.NewPostgres(pool, nil), pool.Close, nil
+}
+
+func openRedis() (*redis.Client, error) { return conn.NewRedis(ctx, "fraudctl") }
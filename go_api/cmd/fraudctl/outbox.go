@@ -0,0 +1,70 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/go_api/internal/outbox"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+func outboxCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "outbox", Short: "Inspect and replay events the event bus rejected"}
+    var topic string
+    var limit int
+    cmd.PersistentFlags().StringVar(&topic, "topic", "", "only messages for this topic (default all)")
+    cmd.PersistentFlags().IntVar(&limit, "limit", 100, "maximum number of messages")
+
+    list := &cobra.Command{
+        Use:   "list",
+        Short: "Print pending messages, oldest first",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            qctx, cancel := conn.QueryCtx(ctx)
+            defer cancel()
+            pending, err := st.Pending(qctx, topic, limit)
+            if err != nil { return err }
+            tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+            fmt.Fprintln(tw, "ID\tTOPIC\tKEY\tCREATED\tERROR")
+            for _, m := range pending {
+                fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", m.ID, m.Topic, m.Key, m.CreatedAt.Format("2006-01-02 15:04:05"), m.Error)
+            }
+            return tw.Flush()
+        },
+    }
+
+    replay := &cobra.Command{
+        Use:   "replay",
+        Short: "Publish pending messages to the event bus and mark them delivered",
+        Long:  "Publish pending messages, oldest first, to the transport selected by event_bus, marking each delivered once the bus accepts it. Stops at the first failure, since the bus is most likely still down. go_api does the same every api.outbox_relay_interval.",
+        Args:  cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            var rdb *redis.Client
+            if strings.EqualFold(config.Get().EventBus, "redis") {
+                if rdb, err = openRedis(); err != nil { return err }
+                defer rdb.Close()
+            }
+            pub, err := outbox.NewPublisher(rdb)
+            if err != nil { return err }
+            defer pub.Close()
+            n, err := outbox.Drain(ctx, st, pub, topic, limit)
+            fmt.Printf("replayed %d messages\n", n)
+            return err
+        },
+    }
+
+    cmd.AddCommand(list, replay)
+    return cmd
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/go_api/internal/report"
+    "example.com/fraud/internal/config"
+)
+
+func reportCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "report", Short: "Generate the scheduled fraud reports on demand"}
+    var date, out string
+    run := &cobra.Command{
+        Use:   "run daily|weekly",
+        Short: "Build a report and deliver it, or write it to --out",
+        Long: "Builds the daily report for --date, or the weekly report for the week containing it.\n" +
+            "Without --date it builds the last complete period, as the scheduler does.",
+        Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+        ValidArgs: []string{report.Daily, report.Weekly},
+        RunE: func(_ *cobra.Command, args []string) error {
+            kind := args[0]
+            at := time.Now()
+            if date != "" {
+                d, err := time.Parse("2006-01-02", date)
+                if err != nil { return fmt.Errorf("--date: %w", err) }
+                at = d.AddDate(0, 0, 1)
+                if kind == report.Weekly { at = d.AddDate(0, 0, 7) }
+            }
+            from, to := report.Period(kind, at)
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            r, err := report.Build(ctx, st, kind, from, to)
+            if err != nil { return err }
+            cfg := config.Get().Reports
+            if out != "" {
+                for _, f := range report.Render(r, cfg.Formats) {
+                    path := filepath.Join(out, f.Name)
+                    if err := os.WriteFile(path, f.Data, 0o644); err != nil { return err }
+                    fmt.Println(path)
+                }
+                return nil
+            }
+            sent, err := report.Deliver(ctx, cfg, config.Get().Archive, r)
+            if len(sent) > 0 { fmt.Printf("%s sent via %s\n", r.Title(), strings.Join(sent, ", ")) }
+            if err == nil && len(sent) == 0 { err = errors.New("no report destination configured; use --out") }
+            return err
+        },
+    }
+    run.Flags().StringVar(&date, "date", "", "a day in the period to report, YYYY-MM-DD (UTC)")
+    run.Flags().StringVar(&out, "out", "", "write the files to this directory instead of delivering them")
+    cmd.AddCommand(run)
+    return cmd
+}
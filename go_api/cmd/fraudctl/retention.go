@@ -0,0 +1,42 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/spf13/cobra"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+)
+
+func retentionCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "retention", Short: "Drop old monthly partitions"}
+    var months int
+    purge := &cobra.Command{
+        Use:   "purge",
+        Short: "Drop partitions older than --months now instead of at the next maintenance run",
+        Args:  cobra.NoArgs,
+        RunE: func(c *cobra.Command, _ []string) error {
+            if !c.Flags().Changed("months") { months = config.Get().Partitions.RetentionMonths }
+            if months <= 0 { return fmt.Errorf("retention is disabled (partitions.retention_months is %d); pass --months", months) }
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            qctx, cancel := context.WithTimeout(ctx, time.Minute)
+            defer cancel()
+            now := time.Now().UTC()
+            cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -months, 0)
+            for _, table := range store.PartitionedTables {
+                dropped, err := st.DropPartitionsBefore(qctx, table, cutoff)
+                if err != nil { return fmt.Errorf("%s: %w", table, err) }
+                fmt.Printf("%s: dropped %d partitions before %s\n", table, dropped, cutoff.Format("2006-01"))
+            }
+            return nil
+        },
+    }
+    purge.Flags().IntVar(&months, "months", 0, "months to keep (default partitions.retention_months)")
+    cmd.AddCommand(purge)
+    return cmd
+}
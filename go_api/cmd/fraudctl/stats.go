@@ -0,0 +1,45 @@
+package main
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/spf13/cobra"
+)
+
+func statsCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "stats", Short: "Maintain the entity rollups behind GET /stats/top"}
+    var from, to string
+    rollup := &cobra.Command{
+        Use:   "rollup",
+        Short: "Recompute the daily rollups for --from through --to",
+        Long: "The API only recomputes the last stats.rollup_lookback_days days. Use this to backfill\n" +
+            "after upgrading, or to count labels recorded for older transactions.",
+        Args: cobra.NoArgs,
+        RunE: func(*cobra.Command, []string) error {
+            last := time.Now().UTC().Truncate(24 * time.Hour)
+            if to != "" {
+                d, err := time.Parse("2006-01-02", to)
+                if err != nil { return fmt.Errorf("--to: %w", err) }
+                last = d
+            }
+            first, err := time.Parse("2006-01-02", from)
+            if err != nil { return fmt.Errorf("--from: %w", err) }
+            if first.After(last) { return fmt.Errorf("--from is after --to") }
+            st, closeStore, err := openStore()
+            if err != nil { return err }
+            defer closeStore()
+            // A day at a time keeps each transaction, and the lock, short.
+            for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+                if err := st.RollUp(ctx, d, d.AddDate(0, 0, 1)); err != nil { return fmt.Errorf("%s: %w", d.Format("2006-01-02"), err) }
+                fmt.Println(d.Format("2006-01-02"))
+            }
+            return nil
+        },
+    }
+    rollup.Flags().StringVar(&from, "from", "", "first day, YYYY-MM-DD (UTC)")
+    rollup.Flags().StringVar(&to, "to", "", "last day, YYYY-MM-DD (UTC); default today")
+    rollup.MarkFlagRequired("from")
+    cmd.AddCommand(rollup)
+    return cmd
+}
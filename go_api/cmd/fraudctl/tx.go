@@ -0,0 +1,48 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/spf13/cobra"
+)
+
+func txCmd() *cobra.Command {
+    cmd := &cobra.Command{Use: "tx", Short: "Act on stored transactions"}
+    apiURL := os.Getenv("FRAUD_API_URL")
+    if apiURL == "" { apiURL = "http://localhost:8000" }
+    var tenant string
+
+    // Re-scoring goes through the API so it uses the API's flags, ML client
+    // and caches rather than a second copy of the scoring path.
+    rescore := &cobra.Command{
+        Use:   "rescore ID...",
+        Short: "Score transactions again with the current rules and model and store the result",
+        Args:  cobra.MinimumNArgs(1),
+        RunE: func(_ *cobra.Command, args []string) error {
+            client := &http.Client{Timeout: 10 * time.Second}
+            for _, id := range args {
+                req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/transactions/"+id+"/rescore", nil)
+                if err != nil { return err }
+                if tenant != "" { req.Header.Set("X-Tenant-ID", tenant) }
+                resp, err := client.Do(req)
+                if err != nil { return err }
+                body, err := io.ReadAll(resp.Body)
+                resp.Body.Close()
+                if err != nil { return err }
+                if resp.StatusCode != http.StatusOK { return fmt.Errorf("%s: %s: %s", id, resp.Status, strings.TrimSpace(string(body))) }
+                os.Stdout.Write(body)
+            }
+            return nil
+        },
+    }
+    rescore.Flags().StringVar(&apiURL, "api-url", apiURL, "base URL of go_api (env FRAUD_API_URL)")
+    rescore.Flags().StringVar(&tenant, "tenant", "", "X-Tenant-ID to evaluate feature flags for")
+
+    cmd.AddCommand(rescore)
+    return cmd
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+    "compress/gzip"
+    "compress/zlib"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+
+    "example.com/fraud/internal/config"
+)
+
+// flushWriteCloser is what gzip.Writer and zlib.Writer have in common.
+type flushWriteCloser interface {
+    io.WriteCloser
+    Flush() error
+    Reset(io.Writer)
+}
+
+var compressors = map[string]*sync.Pool{
+    "gzip":    {New: func() interface{} { return gzip.NewWriter(nil) }},
+    "deflate": {New: func() interface{} { return zlib.NewWriter(nil) }},
+}
+
+// withCompression inflates request bodies sent with Content-Encoding gzip
+// or deflate, up to api.max_decompressed_mb, and compresses responses of at
+// least api.compress_min_bytes for clients whose Accept-Encoding allows it.
+// Handlers see neither.
+func withCompression(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        cfg := config.Get().API
+        if ce := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); ce != "" && ce != "identity" {
+            body, err := inflate(ce, r.Body)
+            if err != nil { http.Error(w, err.Error(), http.StatusUnsupportedMediaType); return }
+            r.Body = http.MaxBytesReader(w, body, int64(cfg.MaxDecompressedMB)<<20)
+            r.Header.Del("Content-Encoding")
+            r.Header.Del("Content-Length")
+            r.ContentLength = -1
+        }
+        enc := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+        if enc == "" || r.Method == http.MethodHead { next.ServeHTTP(w, r); return }
+        cw := &compressWriter{ResponseWriter: w, encoding: enc, min: cfg.CompressMinBytes}
+        defer cw.close()
+        next.ServeHTTP(cw, r)
+    })
+}
+
+func inflate(encoding string, body io.Reader) (io.ReadCloser, error) {
+    switch encoding {
+    case "gzip", "x-gzip":
+        zr, err := gzip.NewReader(body)
+        if err != nil { return nil, fmt.Errorf("gzip body: %v", err) }
+        return zr, nil
+    case "deflate":
+        zr, err := zlib.NewReader(body)
+        if err != nil { return nil, fmt.Errorf("deflate body: %v", err) }
+        return zr, nil
+    }
+    return nil, fmt.Errorf("unsupported Content-Encoding %q; use gzip or deflate", encoding)
+}
+
+// acceptedEncoding picks gzip or deflate per Accept-Encoding, by q value
+// with gzip winning ties, or "" when neither is acceptable.
+func acceptedEncoding(header string) string {
+    best, bestQ := "", 0.0
+    for _, part := range strings.Split(header, ",") {
+        name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+        name = strings.ToLower(strings.TrimSpace(name))
+        if name == "*" { name = "gzip" }
+        if name != "gzip" && name != "deflate" { continue }
+        q := 1.0
+        if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+            f, err := strconv.ParseFloat(v, 64)
+            if err != nil { continue }
+            q = f
+        }
+        if q > bestQ || (q == bestQ && name == "gzip") { best, bestQ = name, q }
+    }
+    return best
+}
+
+// compressWriter holds back the first min bytes of a response to decide
+// whether it is worth compressing: a response that ends sooner, carries its
+// own Content-Encoding or is already compressed goes out as it is.
+type compressWriter struct {
+    http.ResponseWriter
+    encoding string
+    min      int
+
+    status  int
+    buf     []byte
+    started bool
+    zw      flushWriteCloser
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+    if c.status == 0 { c.status = status }
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+    if c.status == 0 { c.status = http.StatusOK }
+    if !c.started {
+        c.buf = append(c.buf, p...)
+        if len(c.buf) >= c.min { return len(p), c.start(true) }
+        return len(p), nil
+    }
+    if c.zw != nil { return c.zw.Write(p) }
+    return c.ResponseWriter.Write(p)
+}
+
+// start sends the headers and whatever was held back, compressing from
+// here on if compress and the response allows it.
+func (c *compressWriter) start(compress bool) error {
+    c.started = true
+    h := c.Header()
+    h.Add("Vary", "Accept-Encoding")
+    // net/http would sniff the compressed bytes.
+    if h.Get("Content-Type") == "" && len(c.buf) > 0 { h.Set("Content-Type", http.DetectContentType(c.buf)) }
+    if compress && compressible(c.status, h) {
+        h.Set("Content-Encoding", c.encoding)
+        h.Del("Content-Length")
+        c.zw = compressors[c.encoding].Get().(flushWriteCloser)
+        c.zw.Reset(c.ResponseWriter)
+    }
+    c.ResponseWriter.WriteHeader(c.status)
+    buf := c.buf
+    c.buf = nil
+    if len(buf) == 0 { return nil }
+    if c.zw != nil {
+        _, err := c.zw.Write(buf)
+        return err
+    }
+    _, err := c.ResponseWriter.Write(buf)
+    return err
+}
+
+func compressible(status int, h http.Header) bool {
+    if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified { return false }
+    if h.Get("Content-Encoding") != "" { return false }
+    ct := h.Get("Content-Type")
+    for _, done := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/x-gzip", "application/zstd"} {
+        if strings.HasPrefix(ct, done) { return false }
+    }
+    return true
+}
+
+// Flush sends what was written so far, compressed if compressing, for
+// handlers that stream.
+func (c *compressWriter) Flush() {
+    if !c.started {
+        if c.status == 0 { c.status = http.StatusOK }
+        c.start(true)
+    }
+    if c.zw != nil { c.zw.Flush() }
+    if f, ok := c.ResponseWriter.(http.Flusher); ok { f.Flush() }
+}
+
+func (c *compressWriter) close() {
+    if !c.started {
+        if c.status == 0 && len(c.buf) == 0 { return }
+        c.start(false)
+    }
+    if c.zw != nil {
+        c.zw.Close()
+        c.zw.Reset(nil)
+        compressors[c.encoding].Put(c.zw)
+        c.zw = nil
+    }
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// writeJSONConditional answers a GET of a resource the dashboard polls: v
+// as JSON with a weak ETag of the body, and Last-Modified when modified
+// isn't zero, or 304 with neither body nor Content-Type when the client's
+// If-None-Match or If-Modified-Since shows it already has that version.
+// Cache-Control: no-cache makes browsers revalidate on every poll.
+func writeJSONConditional(w http.ResponseWriter, r *http.Request, v interface{}, modified time.Time) {
+    b, err := json.Marshal(v)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    b = append(b, '\n')
+    sum := sha256.Sum256(b)
+    // Weak, since compression changes the bytes on the wire.
+    etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+    h := w.Header()
+    h.Set("ETag", etag)
+    h.Set("Cache-Control", "no-cache")
+    if !modified.IsZero() { h.Set("Last-Modified", modified.UTC().Format(http.TimeFormat)) }
+    if notModified(r, etag, modified) { w.WriteHeader(http.StatusNotModified); return }
+    h.Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    w.Write(b)
+}
+
+// notModified evaluates r's preconditions per RFC 9110: If-None-Match
+// when present, compared weakly, and otherwise If-Modified-Since, to the
+// second.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+    if r.Method != http.MethodGet && r.Method != http.MethodHead { return false }
+    if inm := r.Header.Get("If-None-Match"); inm != "" {
+        for _, t := range strings.Split(inm, ",") {
+            t = strings.TrimSpace(t)
+            if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") { return true }
+        }
+        return false
+    }
+    ims := r.Header.Get("If-Modified-Since")
+    if ims == "" || modified.IsZero() { return false }
+    t, err := http.ParseTime(ims)
+    return err == nil && !modified.Truncate(time.Second).After(t)
+}
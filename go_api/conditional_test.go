@@ -0,0 +1,43 @@
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestNotModified(t *testing.T) {
+    const etag = `W/"0123abcd"`
+    modified := time.Date(2026, 5, 1, 10, 0, 0, 500*int(time.Millisecond), time.UTC)
+    at := modified.Truncate(time.Second).Format(http.TimeFormat)
+    before := modified.Add(-time.Second).Format(http.TimeFormat)
+    cases := []struct {
+        name     string
+        method   string
+        inm, ims string
+        modified time.Time
+        want     bool
+    }{
+        {"no preconditions", "GET", "", "", modified, false},
+        {"matching weak tag", "GET", etag, "", modified, true},
+        {"matching strong tag", "GET", `"0123abcd"`, "", modified, true},
+        {"one of several tags", "GET", `"ffff", W/"0123abcd"`, "", modified, true},
+        {"star", "GET", "*", "", modified, true},
+        {"different tag", "GET", `W/"ffff"`, "", modified, false},
+        {"tag wins over date", "GET", `W/"ffff"`, at, modified, false},
+        {"HEAD", "HEAD", etag, "", modified, true},
+        {"not for writes", "POST", etag, "", modified, false},
+        {"same second", "GET", "", at, modified, true},
+        {"later date", "GET", "", modified.Add(time.Hour).Format(http.TimeFormat), modified, true},
+        {"changed since", "GET", "", before, modified, false},
+        {"unparsable date", "GET", "", "yesterday", modified, false},
+        {"no modification time", "GET", "", at, time.Time{}, false},
+    }
+    for _, c := range cases {
+        r := httptest.NewRequest(c.method, "/alerts", nil)
+        if c.inm != "" { r.Header.Set("If-None-Match", c.inm) }
+        if c.ims != "" { r.Header.Set("If-Modified-Since", c.ims) }
+        if got := notModified(r, etag, c.modified); got != c.want { t.Errorf("%s: notModified = %v, want %v", c.name, got, c.want) }
+    }
+}
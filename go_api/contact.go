@@ -0,0 +1,67 @@
+package main
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/go_api/internal/contact"
+    "example.com/fraud/internal/config"
+)
+
+// emailFirstSeenTTL bounds how long an address's first sighting is kept;
+// anything older counts as established anyway.
+const emailFirstSeenTTL = 2 * 365 * 24 * time.Hour
+
+// validateContact checks the optional email and normalizes the phone to
+// E.164.
+func validateContact(req *TransactionRequest) error {
+    if req.Email != nil {
+        if _, err := contact.EmailDomain(*req.Email); err != nil { return err }
+    }
+    if req.Phone != nil {
+        p, err := contact.NormalizePhone(*req.Phone)
+        if err != nil { return err }
+        req.Phone = &p
+    }
+    return nil
+}
+
+// contactEnricher adds the email and phone signals.
+type contactEnricher struct{}
+
+func (contactEnricher) Name() string { return "contact" }
+
+func (contactEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    f.EmailAgeDays = -1
+    if req.Email != nil {
+        domain, _ := contact.EmailDomain(*req.Email)
+        f.DisposableEmail = contact.Disposable(domain, config.Get().Rules.DisposableEmailDomains)
+        f.EmailAgeDays = emailAgeDays(ctx, *req.Email)
+    }
+    if req.Phone != nil { f.VoIPPhone = contact.VoIP(*req.Phone) }
+}
+
+// emailAgeDays is how long ago this service first saw the address, 0 for
+// one seen now for the first time, or -1 while Redis is unavailable. The
+// address is stored only as a hash, and not at all for a dry run.
+func emailAgeDays(ctx context.Context, email string) float64 {
+    if !cacheUp() { return -1 }
+    sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+    key := "email_first_seen:" + hex.EncodeToString(sum[:])
+    now := time.Now().Unix()
+    if !dryRun(ctx) {
+        if _, err := rdb.SetNX(ctx, key, now, emailFirstSeenTTL).Result(); err != nil { noteRedisErr(err); return -1 }
+    }
+    v, err := rdb.Get(ctx, key).Result()
+    if errors.Is(err, redis.Nil) { return 0 }
+    if err != nil { noteRedisErr(err); return -1 }
+    first, _ := strconv.ParseInt(v, 10, 64)
+    return float64(now-first) / 86400
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+
+    "example.com/fraud/internal/config"
+)
+
+const (
+    corsMethods = "GET,POST,PUT,DELETE,OPTIONS"
+    corsHeaders = "Content-Type,Authorization,Idempotency-Key,X-Tenant-ID,X-API-Key,Content-Encoding,If-None-Match,If-Modified-Since"
+)
+
+// withCORS lets the browser origins in http.allowed_origins call the API.
+// Requests from other origins are still served, without the headers a
+// browser needs to hand the response to the page; their preflights get
+// 403.
+func withCORS(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        origin := r.Header.Get("Origin")
+        if origin == "" { next.ServeHTTP(w, r); return }
+        cfg := config.Get().HTTP
+        h := w.Header()
+        h.Add("Vary", "Origin")
+        preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+        allowed, wildcard := originAllowed(cfg.AllowedOrigins, origin)
+        if !allowed {
+            if preflight { http.Error(w, "origin not allowed", http.StatusForbidden); return }
+            next.ServeHTTP(w, r)
+            return
+        }
+        if wildcard { h.Set("Access-Control-Allow-Origin", "*") } else { h.Set("Access-Control-Allow-Origin", origin) }
+        if cfg.AllowCredentials { h.Set("Access-Control-Allow-Credentials", "true") }
+        if !preflight {
+            h.Set("Access-Control-Expose-Headers", "ETag, Retry-After, WWW-Authenticate, X-Request-ID, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
+            next.ServeHTTP(w, r)
+            return
+        }
+        h.Add("Vary", "Access-Control-Request-Method")
+        h.Add("Vary", "Access-Control-Request-Headers")
+        h.Set("Access-Control-Allow-Methods", corsMethods)
+        h.Set("Access-Control-Allow-Headers", corsHeaders)
+        if cfg.PreflightMaxAge > 0 { h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.PreflightMaxAge.Seconds()))) }
+        w.WriteHeader(http.StatusNoContent)
+    })
+}
+
+// originAllowed reports whether origin is in allowed, and whether that is
+// through "*".
+func originAllowed(allowed []string, origin string) (ok, wildcard bool) {
+    for _, a := range allowed {
+        a = strings.TrimSuffix(strings.TrimSpace(a), "/")
+        if a == "*" { return true, true }
+        if strings.EqualFold(a, origin) { return true, false }
+    }
+    return false, false
+}
+
+// withSecurityHeaders adds the headers every response should carry. The
+// API only serves JSON and files to download, so nothing may frame it or
+// load resources from its responses.
+func withSecurityHeaders(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        h := w.Header()
+        h.Set("X-Content-Type-Options", "nosniff")
+        h.Set("X-Frame-Options", "DENY")
+        h.Set("Referrer-Policy", "no-referrer")
+        h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+        if age := config.Get().HTTP.HSTSMaxAge; age > 0 {
+            h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(age.Seconds()))+"; includeSubDomains")
+        }
+        next.ServeHTTP(w, r)
+    })
+}
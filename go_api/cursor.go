@@ -0,0 +1,82 @@
+package main
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strconv"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+)
+
+// A list endpoint answers one page at a time, newest first, with the
+// next_cursor to pass back as ?cursor= for the page after it; the last page
+// has none. A cursor is the sort time and ID of the page's last row,
+// base64url-encoded so clients treat it as opaque. Pages are keyed on that
+// position rather than an offset, so rows added while a client pages
+// through don't shift or repeat the rows it has yet to see.
+
+type cursorKey struct {
+    At time.Time `json:"t"`
+    ID string    `json:"i"`
+}
+
+func encodeCursor(c store.Cursor) string {
+    b, _ := json.Marshal(cursorKey{At: c.At, ID: c.ID})
+    return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*store.Cursor, error) {
+    b, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil { return nil, fmt.Errorf("malformed cursor") }
+    var k cursorKey
+    if err := json.Unmarshal(b, &k); err != nil || k.ID == "" || k.At.IsZero() { return nil, fmt.Errorf("malformed cursor") }
+    return &store.Cursor{At: k.At, ID: k.ID}, nil
+}
+
+// listPage is the page a list request asks for.
+type listPage struct {
+    limit int
+    after *store.Cursor
+}
+
+// pageParams reads ?limit=, 1 to max and def when absent, and ?cursor=.
+// When either is bad it answers 400 itself and returns false.
+func pageParams(w http.ResponseWriter, r *http.Request, def, max int) (listPage, bool) {
+    q := r.URL.Query()
+    p := listPage{limit: def}
+    if v := q.Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > max { http.Error(w, fmt.Sprintf("limit must be 1-%d", max), http.StatusBadRequest); return p, false }
+        p.limit = n
+    }
+    if v := q.Get("cursor"); v != "" {
+        c, err := decodeCursor(v)
+        if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return p, false }
+        p.after = c
+    }
+    return p, true
+}
+
+// query asks the store for one row more than the page holds, which tells
+// whether another page follows.
+func (p listPage) query() store.Page { return store.Page{Limit: p.limit + 1, After: p.after} }
+
+// pageOf trims rows, as returned for p.query(), to the page and returns the
+// cursor of the page after it, or "" when this is the last.
+func pageOf[T any](p listPage, rows []T, key func(T) store.Cursor) ([]T, string) {
+    if rows == nil { rows = []T{} }
+    if len(rows) <= p.limit { return rows, "" }
+    rows = rows[:p.limit]
+    return rows, encodeCursor(key(rows[len(rows)-1]))
+}
+
+// pageBody is a list response: the page's rows under name, and next_cursor
+// unless it is the last page.
+func pageBody(name string, rows interface{}, next string) map[string]interface{} {
+    body := map[string]interface{}{name: rows}
+    if next != "" { body["next_cursor"] = next }
+    return body
+}
@@ -0,0 +1,71 @@
+package main
+
+import "example.com/fraud/internal/config"
+
+// Decisions returned with every scored transaction. REVIEW holds the
+// payment for an analyst or a step-up check.
+const (
+    decisionApprove = "APPROVE"
+    decisionReview  = "REVIEW"
+    decisionDecline = "DECLINE"
+)
+
+var decisionRank = map[string]int{decisionApprove: 0, decisionReview: 1, decisionDecline: 2}
+
+// stricter returns whichever of a and b holds the payment back more.
+func stricter(a, b string) string {
+    if decisionRank[b] > decisionRank[a] { return b }
+    return a
+}
+
+// scoreDecision turns the score into a decision. With
+// rules.cost_sensitive on it weighs what the transaction is expected to lose
+// to fraud (score × amount) against the expected cost of stopping it if it
+// is legitimate ((1 − score) × rules.false_positive_cost): a transaction is
+// approved whenever stopping it costs more than letting it through, declined
+// when it is also over the fraud threshold, and otherwise reviewed once its
+// expected loss reaches rules.review_expected_loss. So a $3 payment scoring
+// 0.72 is approved while a $9,000 wire scoring 0.65 goes to review.
+func scoreDecision(rules config.Rules, amount, score float64, isFraud bool) string {
+    if !rules.CostSensitive {
+        if isFraud { return decisionDecline }
+        return decisionApprove
+    }
+    loss := expectedLoss(amount, score)
+    switch {
+    case loss <= (1-score)*rules.FalsePositiveCost:
+        return decisionApprove
+    case isFraud:
+        return decisionDecline
+    case rules.ReviewExpectedLoss > 0 && loss >= rules.ReviewExpectedLoss:
+        return decisionReview
+    }
+    return decisionApprove
+}
+
+// expectedLoss is the amount the transaction is expected to lose to fraud.
+func expectedLoss(amount, score float64) float64 { return score * amount }
+
+// decide turns the score into a decision and applies the gates that
+// override it: geo blocks are declined, high-value transactions from users
+// whose KYC failed are declined and from users whose KYC is pending
+// reviewed, and flagged duplicates are reviewed when duplicates.review is
+// on. It returns the decline reason for a geo block and the risk factors for
+// any gate that applied.
+func decide(req TransactionRequest, f features, score float64, isFraud, duplicate bool) (string, string, []string) {
+    d := scoreDecision(config.Get().Rules, req.Amount, score, isFraud)
+    var rf []string
+    if f.GeoBlock != "" { return decisionDecline, f.GeoBlock, []string{f.GeoBlock} }
+    if req.Amount >= config.Get().Rules.KYCAmount {
+        switch f.KYCStatus {
+        case kycFailed:
+            d = stricter(d, decisionDecline)
+            rf = append(rf, "kyc_failed")
+        case kycPending:
+            d = stricter(d, decisionReview)
+            rf = append(rf, "kyc_pending")
+        }
+    }
+    if duplicate && config.Get().Duplicates.Review { d = stricter(d, decisionReview) }
+    return d, "", rf
+}
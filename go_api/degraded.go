@@ -0,0 +1,59 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log"
+    "sync/atomic"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// While Redis is unreachable the API runs degraded: cache reads and writes
+// are skipped, so features come straight from Postgres, and scoring
+// responses carry "degraded": true. The first failed Redis call trips the
+// mode, so later requests don't each wait out a timeout; monitorRedis
+// clears it once Redis answers again.
+var (
+    redisDegraded atomic.Bool
+
+    redisDegradedGauge = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_api_redis_degraded",
+        Help: "1 while Redis is unreachable and the API serves without its caches.",
+    })
+    degradedResponses = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_degraded_responses_total",
+        Help: "Scoring responses served while Redis was unreachable.",
+    })
+)
+
+// cacheUp reports whether Redis should be used at all.
+func cacheUp() bool { return !redisDegraded.Load() }
+
+// noteRedisErr trips degraded mode on any error but a cache miss or the
+// caller giving up or running out of time.
+func noteRedisErr(err error) {
+    if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) { return }
+    if !redisDegraded.Swap(true) {
+        redisDegradedGauge.Set(1)
+        log.Printf("redis unavailable, serving degraded: %v", err)
+    }
+}
+
+// monitorRedis pings Redis every interval while degraded.
+func monitorRedis(interval time.Duration) {
+    for {
+        time.Sleep(interval)
+        if cacheUp() { continue }
+        pctx, cancel := context.WithTimeout(ctx, time.Second)
+        err := rdb.Ping(pctx).Err()
+        cancel()
+        if err != nil { continue }
+        redisDegraded.Store(false)
+        redisDegradedGauge.Set(0)
+        log.Printf("redis reachable again, leaving degraded mode")
+    }
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+    "context"
+    "strconv"
+
+    "example.com/fraud/internal/config"
+)
+
+// findDuplicate records txID under the request's user, merchant and amount
+// for duplicates.window and returns the transaction already recorded there,
+// or "" if there is none. Unlike the response cache this catches a payment
+// submitted twice as separate requests (a double click, a client that
+// retries with a fresh Idempotency-Key). Nothing is flagged while Redis is
+// unavailable.
+func findDuplicate(ctx context.Context, txID string, req TransactionRequest) string {
+    window := config.Get().Duplicates.Window
+    if window <= 0 || !cacheUp() { return "" }
+    key := duplicateKey(req)
+    set, err := rdb.SetNX(ctx, key, txID, window).Result()
+    if err != nil { noteRedisErr(err); return "" }
+    if set { return "" }
+    prev, err := rdb.Get(ctx, key).Result()
+    if err != nil { noteRedisErr(err); return "" }
+    return prev
+}
+
+// peekDuplicate is findDuplicate without recording anything.
+func peekDuplicate(ctx context.Context, req TransactionRequest) string {
+    if config.Get().Duplicates.Window <= 0 || !cacheUp() { return "" }
+    prev, err := rdb.Get(ctx, duplicateKey(req)).Result()
+    if err != nil { noteRedisErr(err); return "" }
+    return prev
+}
+
+func duplicateKey(req TransactionRequest) string {
+    return "recent_tx:" + req.UserID + ":" + req.MerchantID + ":" + strconv.FormatFloat(req.Amount, 'f', 2, 64)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+    "encoding/json"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "google.golang.org/protobuf/proto"
+
+    "example.com/fraud/go_api/internal/msgpack"
+    pb "example.com/fraud/go_api/internal/pb/protos"
+)
+
+// Response encodings the scoring endpoints offer besides JSON, picked from
+// the Accept header. Protobuf answers are FraudResponse and
+// BatchFraudResponse from protos/fraud_detection.proto; MessagePack ones
+// have the JSON's keys.
+const (
+    contentJSON     = "application/json"
+    contentProtobuf = "application/x-protobuf"
+    contentMsgpack  = "application/msgpack"
+)
+
+var mediaTypes = map[string]string{
+    "application/json":                contentJSON,
+    "application/x-protobuf":          contentProtobuf,
+    "application/protobuf":            contentProtobuf,
+    "application/vnd.google.protobuf": contentProtobuf,
+    "application/msgpack":             contentMsgpack,
+    "application/x-msgpack":           contentMsgpack,
+    "application/vnd.msgpack":         contentMsgpack,
+}
+
+// responseType is the encoding to answer r in: the supported type Accept
+// ranks highest by q value, earliest on a tie, and JSON when Accept names
+// none of them.
+func responseType(r *http.Request) string {
+    best, bestQ := contentJSON, -1.0
+    for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+        mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil { continue }
+        ct, ok := mediaTypes[mt]
+        if !ok { continue }
+        q := 1.0
+        if s, ok := params["q"]; ok {
+            if q, err = strconv.ParseFloat(s, 64); err != nil { continue }
+        }
+        if q > bestQ && q > 0 { best, bestQ = ct, q }
+    }
+    return best
+}
+
+// writeScore writes a TransactionResponse or BatchTransactionResponse in
+// the encoding r asks for.
+func writeScore(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+    w.Header().Add("Vary", "Accept")
+    if lang := riskFactorLanguage(w, r); lang != "" {
+        switch t := v.(type) {
+        case TransactionResponse:
+            t.RiskFactorDescriptions = describeRiskFactors(t.RiskFactors, lang)
+            v = t
+        case BatchTransactionResponse:
+            results := make([]BatchResult, len(t.Results))
+            for i, res := range t.Results {
+                if res.Error == "" { res.RiskFactorDescriptions = describeRiskFactors(res.RiskFactors, lang) }
+                results[i] = res
+            }
+            t.Results = results
+            v = t
+        }
+    }
+    ct := responseType(r)
+    var b []byte
+    var err error
+    switch ct {
+    case contentProtobuf:
+        switch v := v.(type) {
+        case TransactionResponse:
+            b, err = proto.Marshal(fraudResponseProto(v, ""))
+        case BatchTransactionResponse:
+            b, err = proto.Marshal(batchResponseProto(v))
+        }
+    case contentMsgpack:
+        b, err = msgpack.Marshal(v)
+    default:
+        writeJSON(w, status, v)
+        return
+    }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    w.Header().Set("Content-Type", ct)
+    w.WriteHeader(status)
+    w.Write(b)
+}
+
+// writeCachedScore answers with a response cached as JSON, re-encoding it
+// when r asks for something else or for risk factor descriptions.
+func writeCachedScore(w http.ResponseWriter, r *http.Request, cached string) {
+    var resp TransactionResponse
+    plain := responseType(r) == contentJSON && strings.TrimSpace(r.Header.Get("Accept-Language")) == ""
+    if plain || json.Unmarshal([]byte(cached), &resp) != nil {
+        w.Header().Add("Vary", "Accept")
+        w.Header().Add("Vary", "Accept-Language")
+        w.Header().Set("Content-Type", contentJSON)
+        w.Write([]byte(cached))
+        return
+    }
+    writeScore(w, r, http.StatusOK, resp)
+}
+
+func fraudResponseProto(resp TransactionResponse, batchErr string) *pb.FraudResponse {
+    return &pb.FraudResponse{
+        TransactionId:     resp.TransactionID,
+        IsFraud:           resp.IsFraud,
+        FraudScore:        resp.FraudScore,
+        Confidence:        resp.Confidence,
+        RiskFactors:       resp.RiskFactors,
+        ProcessingTimeMs:  int64(resp.ProcessingTimeMs),
+        Degraded:          resp.Degraded,
+        DuplicateOf:       resp.DuplicateOf,
+        Decision:          resp.Decision,
+        ExpectedLoss:      resp.ExpectedLoss,
+        DeclineReason:     resp.DeclineReason,
+        PartialEvaluation: resp.PartialEvaluation,
+        Error:             batchErr,
+        ScoringTier:       resp.ScoringTier,
+        FallbackReason:    resp.FallbackReason,
+    }
+}
+
+func batchResponseProto(resp BatchTransactionResponse) *pb.BatchFraudResponse {
+    out := &pb.BatchFraudResponse{TotalProcessingTimeMs: int64(resp.TotalProcessingTimeMs)}
+    for _, res := range resp.Results { out.Responses = append(out.Responses, fraudResponseProto(res.TransactionResponse, res.Error)) }
+    return out
+}
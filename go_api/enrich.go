@@ -0,0 +1,115 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/feature"
+)
+
+// An Enricher computes some of a transaction's features. Stages run in the
+// order of enrichers, each under its own deadline
+// (enrichment.timeout / enrichment.timeouts); a stage that runs out of time
+// or fails leaves its features at their neutral values rather than failing
+// the request. Later stages may read what earlier ones filled in.
+type Enricher interface {
+    // Name identifies the stage in config and metrics.
+    Name() string
+    Enrich(ctx context.Context, req TransactionRequest, f *features)
+}
+
+// enrichers lists every stage in run order. Add new signals here.
+var enrichers = []Enricher{
+    reputationEnricher{},
+    historyEnricher{},
+    categoryEnricher{},
+    velocityEnricher{},
+    contactEnricher{},
+    kycEnricher{},
+    tenureEnricher{},
+    travelEnricher{},
+    geoEnricher{},
+    precomputedEnricher{},
+}
+
+var (
+    enricherLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "fraud_api_enricher_seconds",
+        Help:    "Time spent in each feature enrichment stage.",
+        Buckets: prometheus.ExponentialBuckets(0.0005, 2, 12),
+    }, []string{"stage"})
+    enricherTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_enricher_timeouts_total",
+        Help: "Enrichment stages cut off by their deadline.",
+    }, []string{"stage"})
+)
+
+// liveSource reads the state the shared feature computations (see
+// internal/feature) work from, as the processor keeps it in Redis, falling
+// back to Postgres where Redis may not have it.
+type liveSource struct{}
+
+// featureSource is what the enrichers read through: liveSource, or Feast in
+// front of it when feast.url is set (see initFeast).
+var featureSource feature.Source = liveSource{}
+
+// enrich runs the enabled stages for req. Stages are skipped once ctx's
+// latency budget has run out, and one cut short by it counts as skipped.
+func enrich(ctx context.Context, req TransactionRequest) features {
+    f := features{UserRisk: feature.DefaultUserRisk, AmountRatio: 1}
+    cfg := config.Get().Enrichment
+    for _, e := range enrichers {
+        name := e.Name()
+        if !cfg.Enabled(name) || budgetSpent(ctx, name, &f) { continue }
+        sctx, cancel := context.WithTimeout(ctx, cfg.StageTimeout(name))
+        start := time.Now()
+        e.Enrich(sctx, req, &f)
+        enricherLatency.WithLabelValues(name).Observe(time.Since(start).Seconds())
+        if !budgetSpent(ctx, name, &f) && errors.Is(sctx.Err(), context.DeadlineExceeded) { enricherTimeouts.WithLabelValues(name).Inc() }
+        cancel()
+    }
+    return f
+}
+
+// reputationEnricher adds the user's running risk score.
+type reputationEnricher struct{}
+
+func (reputationEnricher) Name() string { return "reputation" }
+
+func (reputationEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    f.UserRisk = feature.UserRisk(ctx, featureSource, req.UserID)
+}
+
+// historyEnricher compares the amount with the user's past amounts.
+type historyEnricher struct{}
+
+func (historyEnricher) Name() string { return "history" }
+
+func (historyEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    f.AmountRatio, f.AmountZScore = feature.History(ctx, featureSource, req.UserID, req.Amount)
+}
+
+// categoryEnricher adds the merchant category features.
+type categoryEnricher struct{}
+
+func (categoryEnricher) Name() string { return "category" }
+
+func (categoryEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    if req.MCC == nil { return }
+    c := feature.Categorize(ctx, featureSource, req.UserID, *req.MCC, config.Get().Rules.HighRiskCategories)
+    f.Category, f.HighRiskCategory, f.FirstTimeCategory, f.CategoryShare = c.Name, c.HighRisk, c.FirstTime, c.Share
+}
+
+// velocityEnricher counts the user's recent card transactions.
+type velocityEnricher struct{}
+
+func (velocityEnricher) Name() string { return "velocity" }
+
+func (velocityEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    if req.Channel == channelCard { f.CardVelocity = feature.CardVelocity(ctx, featureSource, req.UserID) }
+}
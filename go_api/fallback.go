@@ -0,0 +1,185 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+)
+
+// Scoring tiers, best first, and why a request wanting the ML service was
+// scored by a lower one. Responses carry both as scoring_tier and
+// fallback_reason.
+const (
+    tierML         = "ml"
+    tierLocalModel = "local_model"
+    tierRules      = "rules"
+
+    fallbackMLError     = "ml_error"
+    fallbackMLUnhealthy = "ml_unhealthy"
+    fallbackBudget      = "latency_budget"
+)
+
+var (
+    scoringTiers = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_scoring_tier_total",
+        Help: "Transactions scored, by the tier that scored them and why a higher tier didn't (empty when none was wanted).",
+    }, []string{"tier", "fallback_reason"})
+    mlUnhealthyGauge = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_api_ml_unhealthy",
+        Help: "1 while the ML service is skipped after fallback.ml_failures consecutive errors.",
+    })
+)
+
+// scoreTiered scores req with the best tier that can. When useML it is the
+// ML service if that is healthy, then the local model if one is loaded,
+// then the rules; otherwise the rules, as the ml_grpc flag asks. It records
+// the tier, and why the ML service was passed over, on f.
+func scoreTiered(rctx context.Context, req TransactionRequest, f *features, useML bool) (score, confidence float64, riskFactors []string) {
+    if useML {
+        switch {
+        case budgetSpent(rctx, "ml", f):
+            f.FallbackReason = fallbackBudget
+        case !mlHealth.allow(time.Now()):
+            f.FallbackReason = fallbackMLUnhealthy
+        default:
+            s, conf, rfs, err := getFraudScoreGRPC(rctx, req, *f)
+            if err == nil {
+                mlHealth.record(nil)
+                f.Tier = tierML
+                scoringTiers.WithLabelValues(tierML, "").Inc()
+                return s, conf, rfs
+            }
+            // Running out of budget says nothing about the service, and
+            // the endpoints' own health checks already account for them
+            // all being down.
+            if budgetSpent(rctx, "ml", f) {
+                f.FallbackReason = fallbackBudget
+            } else if errors.Is(err, errNoMLEndpoint) {
+                f.FallbackReason = fallbackMLUnhealthy
+            } else {
+                mlHealth.record(err)
+                f.FallbackReason = fallbackMLError
+            }
+        }
+    }
+    score, confidence, riskFactors = getFraudScorePlaceholder(req, *f)
+    f.Tier = tierRules
+    if useML && localModel != nil {
+        score, confidence = localModel.score(req, *f)
+        f.Tier = tierLocalModel
+    }
+    scoringTiers.WithLabelValues(f.Tier, f.FallbackReason).Inc()
+    return score, confidence, riskFactors
+}
+
+// scoringTiersUp lists the tiers that could score a request now, best
+// first, for /health.
+func scoringTiersUp() []string {
+    var out []string
+    if config.Get().API.UseMLGRPC && mlHealth.healthy() && currentMLPool.Load().pick(nil) != nil { out = append(out, tierML) }
+    if localModel != nil { out = append(out, tierLocalModel) }
+    return append(out, tierRules)
+}
+
+// mlHealth trips after fallback.ml_failures consecutive ML errors, so
+// requests stop waiting out a service that is down, and lets one request
+// through every fallback.ml_cooldown to see whether it is back.
+var mlHealth breaker
+
+type breaker struct {
+    mu        sync.Mutex
+    failures  int
+    openUntil time.Time
+}
+
+func (b *breaker) healthy() bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.failures < config.Get().Fallback.MLFailures
+}
+
+// allow reports whether to call the service: always while it is healthy,
+// and otherwise for the first call after each cooldown.
+func (b *breaker) allow(now time.Time) bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.failures < config.Get().Fallback.MLFailures { return true }
+    if now.Before(b.openUntil) { return false }
+    b.openUntil = now.Add(config.Get().Fallback.MLCooldown)
+    return true
+}
+
+func (b *breaker) record(err error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    cfg := config.Get().Fallback
+    wasOpen := b.failures >= cfg.MLFailures
+    if err == nil {
+        b.failures = 0
+        if wasOpen {
+            mlUnhealthyGauge.Set(0)
+            log.Printf("ML service answering again, scoring with it")
+        }
+        return
+    }
+    b.failures++
+    if b.failures >= cfg.MLFailures && !wasOpen {
+        b.openUntil = time.Now().Add(cfg.MLCooldown)
+        mlUnhealthyGauge.Set(1)
+        log.Printf("ML service failed %d times in a row, falling back for %s at a time: %v", b.failures, cfg.MLCooldown, err)
+    }
+}
+
+// fallbackModel is a logistic regression over the features the ML service
+// gets (modelInput), exported from the
+// training pipeline as JSON. Features it has no weight for don't count, and
+// ones it weighs but the transaction lacks count as 0.
+type fallbackModel struct {
+    Version    string             `json:"version"`
+    Intercept  float64            `json:"intercept"`
+    Weights    map[string]float64 `json:"weights"`
+    Confidence float64            `json:"confidence"`
+}
+
+// localModel is nil when no model is loaded.
+var localModel *fallbackModel
+
+// initLocalModel loads fallback.local_model, if one is configured. A model
+// that can't be loaded leaves the rules as the only fallback rather than
+// stopping the API.
+func initLocalModel() {
+    path := config.Get().Fallback.LocalModel
+    if path == "" { return }
+    m, err := readFallbackModel(path)
+    if err != nil { log.Printf("local model disabled: %v", err); return }
+    localModel = m
+    log.Printf("local model %s: %d weights from %s", m.Version, len(m.Weights), path)
+}
+
+func readFallbackModel(path string) (*fallbackModel, error) {
+    b, err := os.ReadFile(path)
+    if err != nil { return nil, err }
+    var m fallbackModel
+    if err := json.Unmarshal(b, &m); err != nil { return nil, fmt.Errorf("%s: %v", path, err) }
+    if len(m.Weights) == 0 { return nil, fmt.Errorf("%s: no weights", path) }
+    if m.Confidence <= 0 || m.Confidence > 1 { m.Confidence = 0.7 }
+    return &m, nil
+}
+
+func (m *fallbackModel) score(req TransactionRequest, f features) (float64, float64) {
+    x := modelInput(req, f)
+    z := m.Intercept
+    for name, w := range m.Weights { z += w * x[name] }
+    return 1 / (1 + math.Exp(-z)), m.Confidence
+}
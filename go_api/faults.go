@@ -0,0 +1,19 @@
+package main
+
+import (
+    "context"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    "example.com/fraud/internal/conn"
+)
+
+// faultInterceptor injects grpc faults (faults.latency, faults.error_rate)
+// into calls to the ML service and enrichment plugins. Injected errors come
+// back as Unavailable, as from a service that is down.
+func faultInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+    if err := conn.Fault(ctx, "grpc"); err != nil { return status.Error(codes.Unavailable, err.Error()) }
+    return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/feature"
+)
+
+var (
+    feastClient = &http.Client{}
+
+    feastRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_feast_requests_total",
+        Help: "Calls to the Feast feature server, by outcome (ok, error).",
+    }, []string{"outcome"})
+    feastMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_feast_misses_total",
+        Help: "Mapped lookups Feast had no value for, read from Redis and Postgres instead.",
+    }, []string{"lookup"})
+)
+
+// initFeast reads the features mapped in feast.features from the Feast
+// feature server while feast.url is set.
+func initFeast() {
+    cfg := config.Get().Feast
+    if cfg.URL == "" { return }
+    featureSource = feastSource{fallback: liveSource{}}
+    log.Printf("feast: reading features from %s", cfg.URL)
+}
+
+// feastSource serves the lookups mapped to Feast features from the feature
+// server and the rest from fallback, as it does any Feast has no value for.
+type feastSource struct{ fallback feature.Source }
+
+func (s feastSource) UserRisk(ctx context.Context, userID string) (float64, bool) {
+    if v, ok := feastGet(ctx, userID, "user_risk")["user_risk"]; ok { return v, true }
+    return s.fallback.UserRisk(ctx, userID)
+}
+
+func (s feastSource) AverageAmount(ctx context.Context, userID string) (float64, bool) {
+    if v, ok := feastGet(ctx, userID, "average_amount")["average_amount"]; ok { return v, true }
+    return s.fallback.AverageAmount(ctx, userID)
+}
+
+// AmountStats is only taken from Feast when it has all three values.
+func (s feastSource) AmountStats(ctx context.Context, userID string) (feature.AmountStats, bool) {
+    vals := feastGet(ctx, userID, "amount_count", "amount_mean", "amount_m2")
+    n, ok1 := vals["amount_count"]
+    mean, ok2 := vals["amount_mean"]
+    m2, ok3 := vals["amount_m2"]
+    if ok1 && ok2 && ok3 { return feature.AmountStats{N: n, Mean: mean, M2: m2}, true }
+    return s.fallback.AmountStats(ctx, userID)
+}
+
+// CategoryCounts are per category, which a Feast feature view can't key
+// by, so they always come from the fallback.
+func (s feastSource) CategoryCounts(ctx context.Context, userID, category string) (int64, int64, bool) {
+    return s.fallback.CategoryCounts(ctx, userID, category)
+}
+
+func (s feastSource) CardVelocity(ctx context.Context, userID string) (int, bool) {
+    if v, ok := feastGet(ctx, userID, "card_velocity")["card_velocity"]; ok { return int(v), true }
+    return s.fallback.CardVelocity(ctx, userID)
+}
+
+// feastGet reads the features mapped to lookups for userID in one call to
+// the feature server's /get-online-features. It returns the values Feast
+// had, by lookup, and none when the call fails.
+func feastGet(ctx context.Context, userID string, lookups ...string) map[string]float64 {
+    cfg := config.Get().Feast
+    refs := map[string]string{}
+    var features []string
+    for _, l := range lookups {
+        ref := cfg.Feature(l)
+        if ref == "" { continue }
+        refs[l] = ref
+        features = append(features, ref)
+    }
+    out := map[string]float64{}
+    if len(features) == 0 { return out }
+    body, _ := json.Marshal(map[string]interface{}{
+        "features":           features,
+        "entities":           map[string][]string{cfg.Entity: {userID}},
+        "full_feature_names": true,
+    })
+    cctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+    defer cancel()
+    names, err := feastCall(cctx, cfg.URL, body)
+    if err != nil { feastRequests.WithLabelValues("error").Inc(); return out }
+    feastRequests.WithLabelValues("ok").Inc()
+    for l, ref := range refs {
+        // With full_feature_names Feast names view:feature view__feature.
+        v, ok := names[strings.Replace(ref, ":", "__", 1)]
+        if !ok { feastMisses.WithLabelValues(l).Inc(); continue }
+        out[l] = v
+    }
+    return out
+}
+
+// feastCall posts body to /get-online-features and returns the numeric
+// values of the one entity asked for that Feast has (status PRESENT), by
+// feature name.
+func feastCall(ctx context.Context, baseURL string, body []byte) (map[string]float64, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/get-online-features", bytes.NewReader(body))
+    if err != nil { return nil, err }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := feastClient.Do(req)
+    if err != nil { return nil, err }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK { return nil, fmt.Errorf("feast: %s", resp.Status) }
+    var res struct {
+        Metadata struct {
+            FeatureNames []string `json:"feature_names"`
+        } `json:"metadata"`
+        Results []struct {
+            Values   []interface{} `json:"values"`
+            Statuses []string      `json:"statuses"`
+        } `json:"results"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&res); err != nil { return nil, err }
+    if len(res.Results) != len(res.Metadata.FeatureNames) { return nil, fmt.Errorf("feast: %d results for %d features", len(res.Results), len(res.Metadata.FeatureNames)) }
+    out := map[string]float64{}
+    for i, name := range res.Metadata.FeatureNames {
+        r := res.Results[i]
+        if len(r.Values) == 0 || len(r.Statuses) == 0 || r.Statuses[0] != "PRESENT" { continue }
+        if v, ok := r.Values[0].(float64); ok { out[name] = v }
+    }
+    return out, nil
+}
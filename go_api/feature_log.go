@@ -0,0 +1,90 @@
+package main
+
+import (
+    "encoding/json"
+    "log"
+    "math/rand"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+// featurePub carries featureRecords to feature_log.topic; nil when that is
+// empty.
+var featurePub publisher
+
+var featureRecords = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "fraud_api_feature_log_records_total",
+    Help: "Decisions considered for the feature log, by outcome (published, sampled_out, error).",
+}, []string{"outcome"})
+
+// featureRecord is what a decision was based on: the feature vector exactly
+// as the ML service, or the local model standing in for it, was given, and
+// what came of it. Training on these rather than on features recomputed
+// offline keeps the model from learning on values it never sees online.
+type featureRecord struct {
+    TransactionID string             `json:"transaction_id"`
+    UserID        string             `json:"user_id"`
+    MerchantID    string             `json:"merchant_id"`
+    DeviceID      *string            `json:"device_id,omitempty"`
+    IPAddress     *string            `json:"ip_address,omitempty"`
+    Timestamp     int64              `json:"timestamp"`
+    Features      map[string]float64 `json:"features"`
+    // Skipped lists the enrichment stages the latency budget left out, whose
+    // features are at their neutral values.
+    Skipped        []string `json:"skipped_stages,omitempty"`
+    FraudScore     float64  `json:"fraud_score"`
+    Confidence     float64  `json:"confidence"`
+    ScoringTier    string   `json:"scoring_tier"`
+    FallbackReason string   `json:"fallback_reason,omitempty"`
+    // LocalModelVersion is set when the local model did the scoring.
+    LocalModelVersion string   `json:"local_model_version,omitempty"`
+    RiskFactors       []string `json:"risk_factors"`
+    IsFraud           bool     `json:"is_fraud"`
+    Decision          string   `json:"decision"`
+}
+
+// modelInput is the feature vector the ML service gets: amount and
+// merchant_risk travel as fields of their own, the rest as
+// additional_features.
+func modelInput(req TransactionRequest, f features) map[string]float64 {
+    x := mlFeatures(req, f)
+    x["amount"] = req.Amount
+    x["merchant_risk"] = req.MerchantRisk
+    return x
+}
+
+// logFeatures publishes the record of a decision to feature_log.topic for
+// feature_log.percent of transactions. Records are JSON whatever
+// kafka.encoding says, keyed by user_id, and like transaction events are
+// best-effort.
+func logFeatures(txID string, req TransactionRequest, f features, resp TransactionResponse) {
+    if featurePub == nil { return }
+    if rand.Float64()*100 >= config.Get().FeatureLog.Percent { featureRecords.WithLabelValues("sampled_out").Inc(); return }
+    rec := featureRecord{
+        TransactionID:  txID,
+        UserID:         req.UserID,
+        MerchantID:     req.MerchantID,
+        DeviceID:       req.DeviceID,
+        IPAddress:      req.IPAddress,
+        Timestamp:      time.Now().Unix(),
+        Features:       modelInput(req, f),
+        Skipped:        f.Skipped,
+        FraudScore:     resp.FraudScore,
+        Confidence:     resp.Confidence,
+        ScoringTier:    f.Tier,
+        FallbackReason: f.FallbackReason,
+        RiskFactors:    resp.RiskFactors,
+        IsFraud:        resp.IsFraud,
+        Decision:       resp.Decision,
+    }
+    if f.Tier == tierLocalModel { rec.LocalModelVersion = localModel.Version }
+    b, err := json.Marshal(rec)
+    if err == nil { err = featurePub.Publish([]byte(req.UserID), b, events.ContentTypeJSON) }
+    if err != nil { featureRecords.WithLabelValues("error").Inc(); log.Printf("publish feature record: %v", err); return }
+    featureRecords.WithLabelValues("published").Inc()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+    "math"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/flags"
+)
+
+// Feature flags the API checks, evaluated per X-Tenant-ID and user_id. See
+// internal/flags for how they are stored.
+const (
+    // flagMLGRPC scores with the ML service; api.use_ml_grpc applies while
+    // the flag is undefined.
+    flagMLGRPC = "ml_grpc"
+    // flagShadowScoring also runs the scorer that didn't decide the request
+    // and records how far apart the two are, without changing the response.
+    flagShadowScoring = "shadow_scoring"
+    // flagThresholdAutotune replaces rules.fraud_threshold with the
+    // threshold analysis's recommendation.
+    flagThresholdAutotune = "threshold_autotune"
+)
+
+var (
+    featureFlags *flags.Client
+
+    shadowDelta = promauto.NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "fraud_api_shadow_score_delta",
+        Help:    "Absolute difference between the shadow and live fraud score, by shadow scorer.",
+        Buckets: []float64{0.01, 0.05, 0.1, 0.2, 0.3, 0.5, 1},
+    }, []string{"scorer"})
+    shadowMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_shadow_decision_mismatches_total",
+        Help: "Requests where the shadow scorer's fraud decision differed from the live one, by shadow scorer.",
+    }, []string{"scorer"})
+    shadowErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_shadow_errors_total",
+        Help: "Shadow scoring attempts that failed, by shadow scorer.",
+    }, []string{"scorer"})
+)
+
+// shadowScore compares liveScore with the other scorer: the rules when the
+// ML service decided, the ML service otherwise.
+func shadowScore(req TransactionRequest, f features, liveScore float64, liveML bool) {
+    scorer := "ml"
+    var score float64
+    if liveML {
+        scorer = "rules"
+        score, _, _ = getFraudScorePlaceholder(req, f)
+    } else {
+        s, _, _, err := getFraudScoreGRPC(ctx, req, f)
+        if err != nil { shadowErrors.WithLabelValues(scorer).Inc(); return }
+        score = s
+    }
+    shadowDelta.WithLabelValues(scorer).Observe(math.Abs(score - liveScore))
+    threshold := config.Get().Rules.FraudThreshold
+    if (score > threshold) != (liveScore > threshold) { shadowMismatches.WithLabelValues(scorer).Inc() }
+}
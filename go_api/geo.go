@@ -0,0 +1,160 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log"
+    "net/http"
+    "net/netip"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "example.com/fraud/go_api/internal/geoip"
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/feature"
+)
+
+const (
+    blockCountry = "country"
+    blockCIDR    = "cidr"
+
+    actionBlock = "block"
+    actionRisk  = "risk"
+)
+
+var (
+    geoDB *geoip.DB
+    // geoCurrent is the union of the geo config lists and the blocklist
+    // table, swapped in whole by refreshGeoRules.
+    geoCurrent atomic.Pointer[feature.GeoRules]
+)
+
+// initGeo loads the GeoIP database, if one is configured, and the rules,
+// and keeps the rules current every geo.refresh_interval. A database that
+// can't be loaded leaves GeoIP off rather than stopping the API.
+func initGeo() {
+    cfg := config.Get().Geo
+    if cfg.Database != "" {
+        db, err := geoip.Load(cfg.Database)
+        if err != nil {
+            log.Printf("geoip disabled: %v", err)
+        } else {
+            geoDB = db
+            log.Printf("geoip: %d networks from %s", db.Len(), cfg.Database)
+        }
+    }
+    refreshGeoRules()
+    go func() {
+        for {
+            time.Sleep(config.Get().Geo.RefreshInterval)
+            refreshGeoRules()
+        }
+    }()
+}
+
+// refreshGeoRules recompiles the rules. If the blocklist table can't be read
+// the previous entries stay in force.
+func refreshGeoRules() {
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    entries, err := blocklistStore.Blocklist(qctx)
+    if err != nil {
+        log.Printf("blocklist refresh failed: %v", err)
+        if geoCurrent.Load() != nil { return }
+    }
+    cfg := config.Get().Geo
+    r := feature.NewGeoRules()
+    for _, c := range cfg.BlockedCountries { r.AddCountry(strings.ToUpper(c), true) }
+    for _, c := range cfg.HighRiskCountries { r.AddCountry(strings.ToUpper(c), false) }
+    for _, s := range cfg.BlockedCIDRs { r.AddRange(netip.MustParsePrefix(s), true) }
+    for _, s := range cfg.HighRiskCIDRs { r.AddRange(netip.MustParsePrefix(s), false) }
+    for _, e := range entries {
+        switch e.Kind {
+        case blockCountry:
+            r.AddCountry(e.Value, e.Action == actionBlock)
+        case blockCIDR:
+            p, err := netip.ParsePrefix(e.Value)
+            if err != nil { continue }
+            r.AddRange(p, e.Action == actionBlock)
+        }
+    }
+    geoCurrent.Store(r)
+}
+
+// geoEnricher resolves the IP's country with the GeoIP database and checks
+// it, the transaction's country and the IP itself against the geo rules
+// (see feature.GeoRules.Check).
+type geoEnricher struct{}
+
+func (geoEnricher) Name() string { return "geo" }
+
+func (geoEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    r := geoCurrent.Load()
+    if r == nil { return }
+    var country, ip string
+    if req.Country != nil { country = *req.Country }
+    if req.IPAddress != nil { ip = *req.IPAddress }
+    g := r.Check(country, ip, geoDB.Country, f.TravelNotice)
+    f.IPCountry, f.GeoBlock, f.HighRiskCountry, f.HighRiskIPRange = g.IPCountry, g.Block, g.HighRiskCountry, g.HighRiskIPRange
+}
+
+// blocklistHandler serves /admin/blocklist: GET lists the entries, POST
+// adds one ({"kind": "country"|"cidr", "value": "KP", "action":
+// "block"|"risk", "reason": "..."}) and DELETE /admin/blocklist/{id}
+// removes one. Changes apply on this instance at once and on the others
+// within geo.refresh_interval.
+func blocklistHandler(w http.ResponseWriter, r *http.Request) {
+    rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/blocklist"), "/")
+    switch {
+    case r.Method == http.MethodGet && rest == "":
+        qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+        defer cancel()
+        entries, err := blocklistStore.Blocklist(qctx)
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if entries == nil { entries = []store.BlocklistEntry{} }
+        writeJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+    case r.Method == http.MethodPost && rest == "":
+        var e store.BlocklistEntry
+        if !decodeBody(w, r, &e, bodyLimit()) { return }
+        if err := validateBlocklistEntry(&e); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        e, err := blocklistStore.AddBlocklistEntry(qctx, e)
+        if errors.Is(err, store.ErrExists) { http.Error(w, "Entry already exists", http.StatusConflict); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        refreshGeoRules()
+        writeJSON(w, http.StatusCreated, e)
+    case r.Method == http.MethodDelete && rest != "":
+        id, err := strconv.ParseInt(rest, 10, 64)
+        if err != nil { http.Error(w, "invalid entry id", http.StatusBadRequest); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        err = blocklistStore.DeleteBlocklistEntry(qctx, id)
+        if errors.Is(err, store.ErrNotFound) { http.Error(w, "Entry not found", http.StatusNotFound); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        refreshGeoRules()
+        w.WriteHeader(http.StatusNoContent)
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
+
+func validateBlocklistEntry(e *store.BlocklistEntry) error {
+    if e.Action != actionBlock && e.Action != actionRisk { return errors.New("action must be block or risk") }
+    switch e.Kind {
+    case blockCountry:
+        e.Value = strings.ToUpper(e.Value)
+        if !validCountry(e.Value) { return errors.New("value must be an ISO 3166 alpha-2 code") }
+    case blockCIDR:
+        p, err := netip.ParsePrefix(e.Value)
+        if err != nil { return errors.New("value must be a CIDR range like 203.0.113.0/24") }
+        e.Value = p.Masked().String()
+    default:
+        return errors.New("kind must be country or cidr")
+    }
+    return nil
+}
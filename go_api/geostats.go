@@ -0,0 +1,70 @@
+package main
+
+import (
+    "net/http"
+    "strconv"
+
+    "example.com/fraud/go_api/internal/geohash"
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+// geoBucket is one cell of GET /stats/geo. Lat and Lon are the middle of a
+// geohash cell, for placing it on a map.
+type geoBucket struct {
+    Key          string   `json:"key"`
+    Lat          *float64 `json:"lat,omitempty"`
+    Lon          *float64 `json:"lon,omitempty"`
+    Transactions int64    `json:"transactions"`
+    Amount       float64  `json:"amount"`
+    Fraud        int64    `json:"fraud"`
+    FraudAmount  float64  `json:"fraud_amount"`
+    FraudRate    float64  `json:"fraud_rate"`
+}
+
+// geoStatsHandler serves
+// GET /stats/geo?by=geohash&precision=4&from=2024-04-29&to=2024-05-05&limit=1000
+// for the dashboard's heatmap: fraud by country, or by geohash cell of the
+// reported location, most fraud first.
+func geoStatsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    by := q.Get("by")
+    if by == "" { by = "country" }
+    if by != "country" && by != "geohash" { http.Error(w, "by must be country or geohash", http.StatusBadRequest); return }
+    precision := 0
+    if by == "geohash" {
+        precision = 4
+        if v := q.Get("precision"); v != "" {
+            n, err := strconv.Atoi(v)
+            if err != nil || n < 1 || n > geohash.MaxStored { http.Error(w, "precision must be 1-"+strconv.Itoa(geohash.MaxStored), http.StatusBadRequest); return }
+            precision = n
+        }
+    }
+    limit := 1000
+    if v := q.Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > 10000 { http.Error(w, "limit must be 1-10000", http.StatusBadRequest); return }
+        limit = n
+    }
+    from, to, err := statsRange(q)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := statsStore.GeoBuckets(qctx, by, precision, from, to.AddDate(0, 0, 1), limit)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    out := make([]geoBucket, len(rows))
+    for i, b := range rows {
+        out[i] = geoBucket{Key: b.Key, Transactions: b.Transactions, Amount: b.Amount, Fraud: b.Fraud, FraudAmount: b.FraudAmount, FraudRate: ratio(b.Fraud, b.Transactions)}
+        if lat, lon, ok := geohash.Center(b.Key); ok && by == "geohash" { out[i].Lat, out[i].Lon = &lat, &lon }
+    }
+    resp := map[string]interface{}{
+        "by": by,
+        "from": from.Format("2006-01-02"),
+        "to": to.Format("2006-01-02"),
+        "buckets": out,
+    }
+    if by == "geohash" { resp["precision"] = precision }
+    writeJSON(w, http.StatusOK, resp)
+}
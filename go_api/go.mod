@@ -3,11 +3,23 @@ module example.com/fraud/go_api
 go 1.22
 
 require (
+    example.com/fraud/internal v0.0.0
+    github.com/alicebob/miniredis/v2 v2.33.0
     github.com/go-redis/redis/v8 v8.11.5
-    github.com/lib/pq v1.10.9
+    github.com/golang-migrate/migrate/v4 v4.17.1
+    github.com/jackc/pgx/v5 v5.6.0
+    github.com/prometheus/client_golang v1.19.1
     github.com/segmentio/kafka-go v0.4.47
+    github.com/spf13/cobra v1.8.1
+    github.com/testcontainers/testcontainers-go v0.35.0
+    github.com/testcontainers/testcontainers-go/modules/kafka v0.35.0
+    github.com/testcontainers/testcontainers-go/modules/postgres v0.35.0
+    github.com/testcontainers/testcontainers-go/modules/redis v0.35.0
+    go.uber.org/mock v0.4.0
+    golang.org/x/sync v0.8.0
     google.golang.org/grpc v1.65.0
     google.golang.org/protobuf v1.34.2
+    gopkg.in/yaml.v3 v3.0.1
 )
 
-
+replace example.com/fraud/internal => ../internal
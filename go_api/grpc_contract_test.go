@@ -0,0 +1,106 @@
+package main
+
+import (
+    "context"
+    "net"
+    "reflect"
+    "testing"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/test/bufconn"
+    "google.golang.org/protobuf/proto"
+
+    "example.com/fraud/go_api/internal/pb/enricherpb"
+    pb "example.com/fraud/go_api/internal/pb/protos"
+    "example.com/fraud/internal/config"
+)
+
+// serve runs srv on an in-process listener and returns a client connection
+// to it, both closed when the test ends.
+func serve(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+    t.Helper()
+    lis := bufconn.Listen(1 << 20)
+    go srv.Serve(lis)
+    t.Cleanup(srv.Stop)
+    cc, err := grpc.NewClient("passthrough:///bufconn",
+        grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+        grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil { t.Fatal(err) }
+    t.Cleanup(func() { cc.Close() })
+    return cc
+}
+
+type stubScorer struct {
+    pb.UnimplementedFraudDetectionServiceServer
+    got  *pb.TransactionRequest
+    resp *pb.FraudScoreResponse
+}
+
+func (s *stubScorer) GetFraudScore(_ context.Context, req *pb.TransactionRequest) (*pb.FraudScoreResponse, error) {
+    s.got = req
+    return s.resp, nil
+}
+
+// mlScore reaches the ML service through the generated client, and every
+// field of the request and response crosses the wire unchanged.
+func TestMLScoreRoundTrip(t *testing.T) {
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    stub := &stubScorer{resp: &pb.FraudScoreResponse{FraudScore: 0.91, Confidence: 0.8, RiskFactors: []string{"high_amount", "new_device"}}}
+    srv := grpc.NewServer()
+    pb.RegisterFraudDetectionServiceServer(srv, stub)
+    cc := serve(t, srv)
+
+    e := &mlEndpoint{addr: "bufconn", conn: cc, client: pb.NewFraudDetectionServiceClient(cc)}
+    e.healthy.Store(true)
+    prev := currentMLPool.Swap(&mlPool{addrs: []string{e.addr}, endpoints: []*mlEndpoint{e}})
+    t.Cleanup(func() { currentMLPool.Store(prev) })
+
+    req := &pb.TransactionRequest{
+        TransactionId:      "tx-1",
+        UserId:             "user-1",
+        Amount:             1234.56,
+        Timestamp:          1718000000,
+        MerchantId:         "m-1",
+        MerchantRisk:       0.7,
+        LocationLat:        51.5,
+        LocationLon:        -0.12,
+        DeviceId:           "dev-1",
+        IpAddress:          "203.0.113.7",
+        AdditionalFeatures: map[string]float64{"user_risk": 0.5, "amount_ratio": 3},
+    }
+    resp, err := mlScore(context.Background(), req)
+    if err != nil { t.Fatal(err) }
+    if !proto.Equal(stub.got, req) { t.Errorf("server got %v, sent %v", stub.got, req) }
+    if !proto.Equal(resp, stub.resp) { t.Errorf("client got %v, server sent %v", resp, stub.resp) }
+}
+
+type stubEnricher struct {
+    enricherpb.UnimplementedEnricherServer
+    got *enricherpb.EnrichRequest
+}
+
+func (s *stubEnricher) Enrich(_ context.Context, req *enricherpb.EnrichRequest) (*enricherpb.EnrichResponse, error) {
+    s.got = req
+    return &enricherpb.EnrichResponse{Features: map[string]float64{"score": 0.3}, RiskFactors: []string{"disposable_email"}, ScoreAdjustment: 0.1}, nil
+}
+
+// A plugin stage sends the transaction through the generated Enricher client
+// and folds the answer into the features under the plugin's name.
+func TestPluginEnrichRoundTrip(t *testing.T) {
+    stub := &stubEnricher{}
+    srv := grpc.NewServer()
+    enricherpb.RegisterEnricherServer(srv, stub)
+    p := pluginEnricher{name: "email", client: enricherpb.NewEnricherClient(serve(t, srv))}
+
+    mcc, device, ip, email, phone := "5411", "dev-1", "203.0.113.7", "a@example.com", "+447700900000"
+    req := TransactionRequest{UserID: "user-1", Amount: 42.5, MerchantID: "m-1", Channel: "card", MCC: &mcc, DeviceID: &device, IPAddress: &ip, Email: &email, Phone: &phone, Attributes: map[string]string{"plan": "gold"}}
+    var f features
+    p.Enrich(context.Background(), req, &f)
+
+    want := &enricherpb.EnrichRequest{UserId: "user-1", Amount: 42.5, MerchantId: "m-1", Channel: "card", Mcc: &mcc, DeviceId: &device, IpAddress: &ip, Email: &email, Phone: &phone, Attributes: map[string]string{"plan": "gold"}}
+    if !proto.Equal(stub.got, want) { t.Errorf("plugin got %v, want %v", stub.got, want) }
+    if !reflect.DeepEqual(f.Plugin, map[string]float64{"email_score": 0.3}) { t.Errorf("plugin features %v", f.Plugin) }
+    if !reflect.DeepEqual(f.PluginRiskFactors, []string{"disposable_email"}) { t.Errorf("plugin risk factors %v", f.PluginRiskFactors) }
+    if f.ScoreAdjustment != 0.1 { t.Errorf("score adjustment %v, want 0.1", f.ScoreAdjustment) }
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/go-redis/redis/v8"
+    "go.uber.org/mock/gomock"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/go_api/internal/store/mocks"
+    "example.com/fraud/go_api/internal/webhook"
+    "example.com/fraud/internal/config"
+)
+
+// swap sets *p to v for the rest of the test.
+func swap[T any](t *testing.T, p *T, v T) {
+    old := *p
+    *p = v
+    t.Cleanup(func() { *p = old })
+}
+
+// handlerEnv loads the default configuration on top of env and points the
+// Redis client at an in-memory server, which it returns.
+func handlerEnv(t *testing.T, env map[string]string) *miniredis.Miniredis {
+    t.Helper()
+    for k, v := range env { t.Setenv(k, v) }
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    mr := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+    t.Cleanup(func() { client.Close() })
+    swap(t, &rdb, client)
+    redisDegraded.Store(false)
+    return mr
+}
+
+func str(s string) *string { return &s }
+
+func TestGetTransaction(t *testing.T) {
+    handlerEnv(t, nil)
+    ctrl := gomock.NewController(t)
+    txs := mocks.NewMockTransactionStore(ctrl)
+    swap[store.TransactionStore](t, &txStore, txs)
+
+    now := time.Now()
+    id := newTransactionID(now)
+    from, to := transactionTimeWindow(id)
+    stored := store.Transaction{TransactionID: id, UserID: "user-1", Amount: 42, Timestamp: now, Decision: str(decisionApprove), RiskFactors: []string{"high_amount"}, UpdatedAt: now}
+    txs.EXPECT().Get(gomock.Any(), id, from, to).Return(stored, nil)
+    txs.EXPECT().Get(gomock.Any(), "missing", gomock.Any(), gomock.Any()).Return(store.Transaction{}, store.ErrNotFound)
+
+    w := httptest.NewRecorder()
+    getTransactionHandler(w, httptest.NewRequest(http.MethodGet, "/transactions/"+id, nil))
+    if w.Code != http.StatusOK { t.Fatalf("found: status %d: %s", w.Code, w.Body) }
+    var got map[string]interface{}
+    if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil { t.Fatal(err) }
+    if got["transaction_id"] != id || got["user_id"] != "user-1" || got["decision"] != decisionApprove { t.Errorf("found: body %v", got) }
+
+    w = httptest.NewRecorder()
+    getTransactionHandler(w, httptest.NewRequest(http.MethodGet, "/transactions/missing", nil))
+    if w.Code != http.StatusNotFound { t.Errorf("missing: status %d, want 404", w.Code) }
+}
+
+// signedVerification is a verification report signed with secret as the
+// auth system would sign it.
+func signedVerification(id, body, secret string) *http.Request {
+    r := httptest.NewRequest(http.MethodPost, "/transactions/"+id+"/verification", strings.NewReader(body))
+    ts := strconv.FormatInt(time.Now().Unix(), 10)
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(ts + "." + body))
+    r.Header.Set(webhook.CallbackHeader, "t="+ts+",v1="+hex.EncodeToString(mac.Sum(nil)))
+    return r
+}
+
+func TestVerificationSignature(t *testing.T) {
+    handlerEnv(t, map[string]string{"WEBHOOK_VERIFICATION_SECRET": "whsec"})
+    ctrl := gomock.NewController(t)
+    // No store call is expected: unsigned reports never reach the handler.
+    swap[store.TransactionStore](t, &txStore, mocks.NewMockTransactionStore(ctrl))
+
+    body := `{"method":"otp","result":"success"}`
+    unsigned := httptest.NewRequest(http.MethodPost, "/transactions/tx/verification", strings.NewReader(body))
+    cases := []struct {
+        name string
+        r    *http.Request
+        want int
+    }{
+        {"unsigned", unsigned, http.StatusUnauthorized},
+        {"wrong secret", signedVerification("tx", body, "other"), http.StatusUnauthorized},
+    }
+    for _, c := range cases {
+        w := httptest.NewRecorder()
+        verificationHandler(w, c.r, "tx")
+        if w.Code != c.want { t.Errorf("%s: status %d, want %d", c.name, w.Code, c.want) }
+    }
+
+    t.Setenv("WEBHOOK_VERIFICATION_SECRET", "")
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    w := httptest.NewRecorder()
+    verificationHandler(w, signedVerification("tx", body, "whsec"), "tx")
+    if w.Code != http.StatusNotFound { t.Errorf("no secret: status %d, want 404", w.Code) }
+}
+
+// A passed check approves a transaction held for review and leaves the
+// user's risk alone.
+func TestVerificationSuccess(t *testing.T) {
+    handlerEnv(t, map[string]string{"WEBHOOK_VERIFICATION_SECRET": "whsec"})
+    ctrl := gomock.NewController(t)
+    txs := mocks.NewMockTransactionStore(ctrl)
+    swap[store.TransactionStore](t, &txStore, txs)
+    swap[store.AlertStore](t, &alertStore, mocks.NewMockAlertStore(ctrl))
+    swap[store.UserStore](t, &userStore, mocks.NewMockUserStore(ctrl))
+
+    id := newTransactionID(time.Now())
+    from, to := transactionTimeWindow(id)
+    txs.EXPECT().Get(gomock.Any(), id, from, to).Return(store.Transaction{TransactionID: id, UserID: "user-1", Decision: str(decisionReview)}, nil)
+    txs.EXPECT().RecordVerification(gomock.Any(), id, from, to, "otp", verificationSuccess, decisionApprove).Return(nil)
+    txs.EXPECT().SetStatus(gomock.Any(), id, from, to, store.TxApproved, settleableStatuses, "system", "verification").Return(true, nil)
+
+    w := httptest.NewRecorder()
+    verificationHandler(w, signedVerification(id, `{"method":"otp","result":"success"}`, "whsec"), id)
+    if w.Code != http.StatusOK { t.Fatalf("status %d: %s", w.Code, w.Body) }
+    var got map[string]interface{}
+    if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil { t.Fatal(err) }
+    if got["previous_decision"] != decisionReview || got["decision"] != decisionApprove { t.Errorf("body %v", got) }
+    if _, ok := got["user_risk_score"]; ok { t.Errorf("user_risk_score in a successful report: %v", got) }
+}
+
+// A failed check declines the transaction, escalates its alerts and raises
+// the user's risk, in the store and in the cache.
+func TestVerificationFailure(t *testing.T) {
+    mr := handlerEnv(t, map[string]string{"WEBHOOK_VERIFICATION_SECRET": "whsec"})
+    ctrl := gomock.NewController(t)
+    txs := mocks.NewMockTransactionStore(ctrl)
+    alerts := mocks.NewMockAlertStore(ctrl)
+    users := mocks.NewMockUserStore(ctrl)
+    swap[store.TransactionStore](t, &txStore, txs)
+    swap[store.AlertStore](t, &alertStore, alerts)
+    swap[store.UserStore](t, &userStore, users)
+
+    now := time.Now()
+    id := newTransactionID(now)
+    from, to := transactionTimeWindow(id)
+    txs.EXPECT().Get(gomock.Any(), id, from, to).Return(store.Transaction{TransactionID: id, UserID: "user-1", Timestamp: now, Decision: str(decisionReview)}, nil)
+    txs.EXPECT().RecordVerification(gomock.Any(), id, from, to, "3ds", verificationFailure, decisionDecline).Return(nil)
+    txs.EXPECT().SetStatus(gomock.Any(), id, from, to, store.TxDeclined, settleableStatuses, "system", "verification").Return(true, nil)
+    alerts.EXPECT().Escalate(gomock.Any(), gomock.Any(), now).DoAndReturn(func(_ context.Context, a store.Alert, _ time.Time) error {
+        if a.TransactionID != id || a.AlertType != "VERIFICATION_FAILED" || a.Status != "OPEN" { t.Errorf("escalated %+v", a) }
+        return nil
+    })
+    users.EXPECT().AdjustRisk(gomock.Any(), "user-1", config.Get().Rules.VerificationFailureRisk).Return(0.6, nil)
+
+    w := httptest.NewRecorder()
+    verificationHandler(w, signedVerification(id, `{"method":"3ds","result":"failure"}`, "whsec"), id)
+    if w.Code != http.StatusOK { t.Fatalf("status %d: %s", w.Code, w.Body) }
+    var got map[string]interface{}
+    if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil { t.Fatal(err) }
+    if got["decision"] != decisionDecline || got["user_risk_score"] != 0.6 { t.Errorf("body %v", got) }
+    if cached, _ := mr.Get("user_risk:user-1"); cached != "0.6" { t.Errorf("cached risk %q, want 0.6", cached) }
+}
+
+// A transaction takes one report, whether the first is seen on read or
+// only when it is recorded.
+func TestVerificationRecordedOnce(t *testing.T) {
+    handlerEnv(t, map[string]string{"WEBHOOK_VERIFICATION_SECRET": "whsec"})
+    ctrl := gomock.NewController(t)
+    txs := mocks.NewMockTransactionStore(ctrl)
+    swap[store.TransactionStore](t, &txStore, txs)
+
+    id := newTransactionID(time.Now())
+    txs.EXPECT().Get(gomock.Any(), id, gomock.Any(), gomock.Any()).Return(store.Transaction{TransactionID: id, VerificationResult: str(verificationSuccess)}, nil)
+    txs.EXPECT().Get(gomock.Any(), id, gomock.Any(), gomock.Any()).Return(store.Transaction{TransactionID: id, Decision: str(decisionApprove)}, nil)
+    txs.EXPECT().RecordVerification(gomock.Any(), id, gomock.Any(), gomock.Any(), "otp", verificationSuccess, decisionApprove).Return(store.ErrNotFound)
+
+    for _, name := range []string{"seen on read", "lost the race"} {
+        w := httptest.NewRecorder()
+        verificationHandler(w, signedVerification(id, `{"method":"otp","result":"success"}`, "whsec"), id)
+        if w.Code != http.StatusConflict { t.Errorf("%s: status %d, want 409", name, w.Code) }
+    }
+}
+
+func TestTravelNotices(t *testing.T) {
+    mr := handlerEnv(t, nil)
+    ctrl := gomock.NewController(t)
+    travel := mocks.NewMockTravelStore(ctrl)
+    users := mocks.NewMockUserStore(ctrl)
+    swap[store.TravelStore](t, &travelStore, travel)
+    swap[store.UserStore](t, &userStore, users)
+
+    starts := time.Now().UTC().Truncate(time.Second)
+    ends := starts.Add(7 * 24 * time.Hour)
+    want := store.TravelNotice{UserID: "user-1", StartsAt: starts, EndsAt: ends, Countries: []string{"FR", "DE"}}
+    users.EXPECT().Ensure(gomock.Any(), "user-1", gomock.Any(), nil).Return(nil)
+    travel.EXPECT().AddTravelNotice(gomock.Any(), want).DoAndReturn(func(_ context.Context, n store.TravelNotice) (store.TravelNotice, error) {
+        n.ID = 7
+        return n, nil
+    })
+    travel.EXPECT().DeleteTravelNotice(gomock.Any(), "user-1", int64(8)).Return(store.ErrNotFound)
+    travel.EXPECT().DeleteTravelNotice(gomock.Any(), "user-1", int64(7)).Return(errors.New("connection reset"))
+    mr.Set("travel_notices:user-1", "[]")
+
+    cases := []struct {
+        name, method, path, body string
+        want                     int
+    }{
+        {"create", http.MethodPost, "/users/user-1/travel-notices", `{"starts_at":"` + starts.Format(time.RFC3339) + `","ends_at":"` + ends.Format(time.RFC3339) + `","countries":["fr","de"]}`, http.StatusCreated},
+        {"no countries", http.MethodPost, "/users/user-1/travel-notices", `{"starts_at":"` + starts.Format(time.RFC3339) + `","ends_at":"` + ends.Format(time.RFC3339) + `"}`, http.StatusBadRequest},
+        {"ends before it starts", http.MethodPost, "/users/user-1/travel-notices", `{"starts_at":"` + ends.Format(time.RFC3339) + `","ends_at":"` + starts.Format(time.RFC3339) + `","countries":["FR"]}`, http.StatusBadRequest},
+        {"delete a missing notice", http.MethodDelete, "/users/user-1/travel-notices/8", "", http.StatusNotFound},
+        {"delete fails", http.MethodDelete, "/users/user-1/travel-notices/7", "", http.StatusInternalServerError},
+        {"bad notice id", http.MethodDelete, "/users/user-1/travel-notices/x", "", http.StatusBadRequest},
+    }
+    for _, c := range cases {
+        w := httptest.NewRecorder()
+        travelNoticesHandler(w, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+        if w.Code != c.want { t.Errorf("%s: status %d, want %d: %s", c.name, w.Code, c.want, w.Body) }
+    }
+    if mr.Exists("travel_notices:user-1") { t.Error("travel notice cache not dropped after a new notice") }
+}
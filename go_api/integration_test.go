@@ -0,0 +1,247 @@
+//go:build integration
+
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+    "github.com/testcontainers/testcontainers-go"
+    tckafka "github.com/testcontainers/testcontainers-go/modules/kafka"
+    tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+    tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+    "github.com/testcontainers/testcontainers-go/wait"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+// TestIntegrationScoreToAlert runs the produce, score and alert path against
+// real Postgres, Redis and Kafka containers: the API, in-process, scores an
+// obviously fraudulent transaction and publishes it, and go_processor, built
+// and started from ../go_processor, consumes it, stores a FRAUD_DETECTED
+// alert and publishes that to fraud-alerts. Run with
+//
+//	go test -tags integration -run Integration -v .
+//
+// which needs a Docker daemon.
+func TestIntegrationScoreToAlert(t *testing.T) {
+    deps := startDependencies(t)
+    deps.setenv(t)
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    if err := initConnections(); err != nil { t.Fatal(err) }
+    if err := runMigrations(); err != nil { t.Fatal(err) }
+    if err := initPlugins(); err != nil { t.Fatal(err) }
+    if err := initMLTLS(); err != nil { t.Fatal(err) }
+    if err := initMLEndpoints(); err != nil { t.Fatal(err) }
+    initGeo()
+    initRiskFactorText()
+    initLocalModel()
+    initFeast()
+    api := httptest.NewServer(routes())
+    defer api.Close()
+    startProcessor(t, deps)
+
+    txID, userID := scoreFraud(t, api.URL)
+
+    // The transaction is stored and its event is on fraud-transactions.
+    now := time.Now().UTC()
+    qctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+    stored, err := txStore.Get(qctx, txID, now.Add(-time.Hour), now.Add(time.Hour))
+    cancel()
+    if err != nil { t.Fatalf("transaction %s not stored: %v", txID, err) }
+    if stored.UserID != userID { t.Fatalf("stored transaction has user %q, want %q", stored.UserID, userID) }
+    readTopic(t, deps.brokers, "fraud-transactions", func(m kafka.Message) bool {
+        var ev events.TransactionEvent
+        if err := events.DecodeTransaction(nil, contentType(m), m.Value, &ev); err != nil { return false }
+        return ev.TransactionID == txID && ev.IsFraud
+    })
+
+    // The processor stores the alert and publishes it.
+    var alertID string
+    poll(t, time.Minute, func() error {
+        qctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+        defer cancel()
+        alerts, err := alertStore.List(qctx, "OPEN", store.Page{Limit: 500})
+        if err != nil { return err }
+        for _, a := range alerts {
+            if a.TransactionID == txID && a.AlertType == "FRAUD_DETECTED" { alertID = a.AlertID; return nil }
+        }
+        return fmt.Errorf("no open FRAUD_DETECTED alert for %s", txID)
+    })
+    readTopic(t, deps.brokers, "fraud-alerts", func(m kafka.Message) bool {
+        return bytes.Contains(m.Value, []byte(alertID))
+    })
+    poll(t, 30*time.Second, func() error {
+        var n int
+        err := pg.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log WHERE entity_type = 'alert' AND entity_id = $1 AND action = 'alert.notified'`, alertID).Scan(&n)
+        if err == nil && n == 0 { err = fmt.Errorf("alert %s has no alert.notified entry", alertID) }
+        return err
+    })
+}
+
+// dependencies are the containers the services need, as their addresses on
+// the host.
+type dependencies struct {
+    pgHost, pgPort       string
+    redisHost, redisPort string
+    brokers              []string
+}
+
+func startDependencies(t *testing.T) dependencies {
+    t.Helper()
+    var d dependencies
+    pgc, err := tcpostgres.Run(ctx, "postgres:15",
+        tcpostgres.WithDatabase("fraud_detection"), tcpostgres.WithUsername("fraud_user"), tcpostgres.WithPassword("fraud_password"),
+        testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").WithOccurrence(2).WithStartupTimeout(time.Minute)))
+    if err != nil { t.Fatalf("postgres: %v", err) }
+    t.Cleanup(func() { _ = pgc.Terminate(context.Background()) })
+    d.pgHost, d.pgPort = hostPort(t, pgc)
+
+    rc, err := tcredis.Run(ctx, "redis:7-alpine")
+    if err != nil { t.Fatalf("redis: %v", err) }
+    t.Cleanup(func() { _ = rc.Terminate(context.Background()) })
+    d.redisHost, d.redisPort = hostPort(t, rc)
+
+    kc, err := tckafka.Run(ctx, "confluentinc/confluent-local:7.5.0", tckafka.WithClusterID("fraud-integration"))
+    if err != nil { t.Fatalf("kafka: %v", err) }
+    t.Cleanup(func() { _ = kc.Terminate(context.Background()) })
+    if d.brokers, err = kc.Brokers(ctx); err != nil { t.Fatal(err) }
+    createTopics(t, d.brokers, "fraud-transactions", "fraud-transactions-priority", "fraud-alerts", "fraud-features")
+    return d
+}
+
+// hostPort is where a container's only exposed port is mapped on the host.
+func hostPort(t *testing.T, c testcontainers.Container) (string, string) {
+    t.Helper()
+    endpoint, err := c.Endpoint(ctx, "")
+    if err != nil { t.Fatal(err) }
+    host, port, err := net.SplitHostPort(endpoint)
+    if err != nil { t.Fatal(err) }
+    return host, port
+}
+
+// env is the configuration both services get, on top of the defaults.
+func (d dependencies) env() map[string]string {
+    return map[string]string{
+        "POSTGRES_HOST":           d.pgHost,
+        "POSTGRES_PORT":           d.pgPort,
+        "REDIS_HOST":              d.redisHost,
+        "REDIS_PORT":              d.redisPort,
+        "KAFKA_BOOTSTRAP_SERVERS": d.brokers[0],
+        "KAFKA_ENCODING":          "protobuf",
+        "EVENT_BUS":               "kafka",
+    }
+}
+
+func (d dependencies) setenv(t *testing.T) {
+    for k, v := range d.env() { t.Setenv(k, v) }
+}
+
+// createTopics creates the topics up front, so neither service races the
+// broker's auto-creation.
+func createTopics(t *testing.T, brokers []string, topics ...string) {
+    t.Helper()
+    c, err := kafka.Dial("tcp", brokers[0])
+    if err != nil { t.Fatal(err) }
+    defer c.Close()
+    controller, err := c.Controller()
+    if err != nil { t.Fatal(err) }
+    cc, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+    if err != nil { t.Fatal(err) }
+    defer cc.Close()
+    configs := make([]kafka.TopicConfig, len(topics))
+    for i, topic := range topics { configs[i] = kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1} }
+    if err := cc.CreateTopics(configs...); err != nil { t.Fatal(err) }
+}
+
+// startProcessor builds go_processor and runs it against the containers
+// until the test ends.
+func startProcessor(t *testing.T, d dependencies) {
+    t.Helper()
+    bin := filepath.Join(t.TempDir(), "go_processor")
+    build := exec.Command("go", "build", "-o", bin, ".")
+    build.Dir = filepath.Join("..", "go_processor")
+    if out, err := build.CombinedOutput(); err != nil { t.Fatalf("build go_processor: %v\n%s", err, out) }
+    cmd := exec.Command(bin)
+    cmd.Env = os.Environ()
+    for k, v := range d.env() { cmd.Env = append(cmd.Env, k+"="+v) }
+    cmd.Env = append(cmd.Env, "PROCESSOR_HTTP_ADDR=127.0.0.1:0", "PROCESSOR_BATCH_LINGER_MS=0", "RISK_STATE_BOOTSTRAP=false")
+    cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+    if err := cmd.Start(); err != nil { t.Fatal(err) }
+    t.Cleanup(func() { _ = cmd.Process.Kill(); _ = cmd.Wait() })
+}
+
+// scoreFraud sends a transaction every rule agrees on, a high amount at a
+// risky merchant from a brand-new account, and returns its ID and user.
+func scoreFraud(t *testing.T, apiURL string) (string, string) {
+    t.Helper()
+    created := time.Now().UTC()
+    userID := fmt.Sprintf("it-%d", created.UnixNano())
+    body, _ := json.Marshal(map[string]interface{}{
+        "user_id":            userID,
+        "amount":             9500.00,
+        "merchant_id":        "it-merchant",
+        "merchant_risk":      0.99,
+        "device_id":          fmt.Sprintf("it-dev-%d", created.UnixNano()),
+        "ip_address":         "198.18.0.1",
+        "account_created_at": created,
+    })
+    resp, err := http.Post(apiURL+"/transactions/process", "application/json", bytes.NewReader(body))
+    if err != nil { t.Fatal(err) }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK { t.Fatalf("POST /transactions/process: %s", resp.Status) }
+    var out TransactionResponse
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil { t.Fatal(err) }
+    if out.TransactionID == "" { t.Fatal("no transaction_id in the response") }
+    if !out.IsFraud { t.Fatalf("transaction %s scored %.2f, not fraud", out.TransactionID, out.FraudScore) }
+    return out.TransactionID, userID
+}
+
+// readTopic reads topic from the start until match accepts a message, and
+// fails the test if none does within 30 seconds.
+func readTopic(t *testing.T, brokers []string, topic string, match func(kafka.Message) bool) {
+    t.Helper()
+    r := kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, Topic: topic, Partition: 0, MinBytes: 1, MaxBytes: 10e6})
+    defer r.Close()
+    rctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+    defer cancel()
+    for {
+        m, err := r.ReadMessage(rctx)
+        if err != nil { t.Fatalf("no matching message on %s: %v", topic, err) }
+        if match(m) { return }
+    }
+}
+
+func contentType(m kafka.Message) string {
+    for _, h := range m.Headers {
+        if h.Key == "content-type" { return string(h.Value) }
+    }
+    return ""
+}
+
+// poll calls check every second until it succeeds, and fails the test with
+// its last error once timeout has passed.
+func poll(t *testing.T, timeout time.Duration, check func() error) {
+    t.Helper()
+    deadline := time.Now().Add(timeout)
+    for {
+        err := check()
+        if err == nil { return }
+        if time.Now().After(deadline) { t.Fatal(err) }
+        time.Sleep(time.Second)
+    }
+}
@@ -0,0 +1,65 @@
+// Package contact derives risk signals from the email address and phone
+// number on a transaction using only local data: a list of disposable email
+// domains and the number ranges national numbering plans reserve for VoIP
+// and other location-independent services. Carrier lookups need a live data
+// source and belong in an enrichment plugin.
+package contact
+
+import (
+    "errors"
+    "strings"
+)
+
+// EmailDomain returns the lower-cased domain of a syntactically plausible
+// address.
+func EmailDomain(email string) (string, error) {
+    at := strings.LastIndexByte(email, '@')
+    if at < 1 || at == len(email)-1 || strings.ContainsAny(email, " \t\r\n") { return "", errors.New("email must be an address like name@example.com") }
+    domain := strings.ToLower(strings.TrimSuffix(email[at+1:], "."))
+    if !strings.Contains(domain, ".") { return "", errors.New("email must be an address like name@example.com") }
+    return domain, nil
+}
+
+// Disposable reports whether domain, or a parent domain of it, is a known
+// throwaway email provider or one of extra.
+func Disposable(domain string, extra []string) bool {
+    for d := domain; d != ""; {
+        if disposableDomains[d] { return true }
+        for _, e := range extra {
+            if strings.EqualFold(e, d) { return true }
+        }
+        i := strings.IndexByte(d, '.')
+        if i < 0 { break }
+        d = d[i+1:]
+    }
+    return false
+}
+
+// NormalizePhone strips spaces, dashes, dots and parentheses from an E.164
+// number and checks it is "+" followed by 8 to 15 digits.
+func NormalizePhone(phone string) (string, error) {
+    var b strings.Builder
+    for i, r := range phone {
+        switch {
+        case r == '+' && i == 0:
+            b.WriteRune(r)
+        case r >= '0' && r <= '9':
+            b.WriteRune(r)
+        case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+        default:
+            return "", errors.New("phone must be in E.164 format, e.g. +14155550123")
+        }
+    }
+    n := b.String()
+    if !strings.HasPrefix(n, "+") || len(n) < 9 || len(n) > 16 || n[1] == '0' { return "", errors.New("phone must be in E.164 format, e.g. +14155550123") }
+    return n, nil
+}
+
+// VoIP reports whether a normalized number lies in a range reserved for
+// VoIP or location-independent numbers, which are cheap to obtain in bulk.
+func VoIP(phone string) bool {
+    for _, p := range voipPrefixes {
+        if strings.HasPrefix(phone, p) { return true }
+    }
+    return false
+}
@@ -0,0 +1,43 @@
+package contact
+
+// disposableDomains are widely used throwaway email providers. The list is
+// deliberately short; rules.disposable_email_domains extends it.
+var disposableDomains = map[string]bool{
+    "10minutemail.com":       true,
+    "20minutemail.com":       true,
+    "discard.email":          true,
+    "dispostable.com":        true,
+    "emailondeck.com":        true,
+    "fakeinbox.com":          true,
+    "getairmail.com":         true,
+    "getnada.com":            true,
+    "guerrillamail.com":      true,
+    "guerrillamail.net":      true,
+    "guerrillamailblock.com": true,
+    "maildrop.cc":            true,
+    "mailinator.com":         true,
+    "mailnesia.com":          true,
+    "mintemail.com":          true,
+    "mohmal.com":             true,
+    "mytemp.email":           true,
+    "sharklasers.com":        true,
+    "spamgourmet.com":        true,
+    "temp-mail.org":          true,
+    "tempail.com":            true,
+    "tempmail.dev":           true,
+    "tempmailo.com":          true,
+    "throwawaymail.com":      true,
+    "trashmail.com":          true,
+    "yopmail.com":            true,
+}
+
+// voipPrefixes are E.164 prefixes that national numbering plans assign to
+// VoIP or location-independent services.
+var voipPrefixes = []string{
+    "+4456",  // UK 056: location-independent electronic communications
+    "+4932",  // Germany 032: national subscriber numbers
+    "+339",   // France 09: VoIP (box) lines
+    "+3185",  // Netherlands 085: location-independent
+    "+3184",  // Netherlands 084: personal assistance / VoIP
+    "+61550", // Australia 0550: location-independent communication
+}
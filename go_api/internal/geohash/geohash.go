@@ -0,0 +1,59 @@
+// Package geohash encodes coordinates as geohashes: base-32 strings naming
+// a cell of the map, where each extra character narrows the cell and every
+// prefix names the larger cell containing it.
+package geohash
+
+import "strings"
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxStored is the precision transactions are stored at, a cell of about
+// 1.2 by 0.6 km; shorter prefixes aggregate them.
+const MaxStored = 6
+
+// Encode returns the geohash of precision characters for lat, lon.
+func Encode(lat, lon float64, precision int) string {
+    lats, lons := [2]float64{-90, 90}, [2]float64{-180, 180}
+    out := make([]byte, precision)
+    even := true
+    for i := range out {
+        var c byte
+        for bit := 4; bit >= 0; bit-- {
+            r, v := &lons, lon
+            if !even { r, v = &lats, lat }
+            mid := (r[0] + r[1]) / 2
+            if v >= mid {
+                c |= 1 << bit
+                r[0] = mid
+            } else {
+                r[1] = mid
+            }
+            even = !even
+        }
+        out[i] = base32[c]
+    }
+    return string(out)
+}
+
+// Center returns the middle of the hash's cell; ok is false if hash is not
+// a geohash.
+func Center(hash string) (lat, lon float64, ok bool) {
+    lats, lons := [2]float64{-90, 90}, [2]float64{-180, 180}
+    even := true
+    for i := 0; i < len(hash); i++ {
+        c := strings.IndexByte(base32, hash[i])
+        if c < 0 { return 0, 0, false }
+        for bit := 4; bit >= 0; bit-- {
+            r := &lons
+            if !even { r = &lats }
+            mid := (r[0] + r[1]) / 2
+            if c&(1<<bit) != 0 {
+                r[0] = mid
+            } else {
+                r[1] = mid
+            }
+            even = !even
+        }
+    }
+    return (lats[0] + lats[1]) / 2, (lons[0] + lons[1]) / 2, true
+}
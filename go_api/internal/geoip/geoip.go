@@ -0,0 +1,73 @@
+// Package geoip maps IP addresses to countries using a CSV database with one
+// network,country_code line per range (ISO 3166 alpha-2), the shape of the
+// country CSVs published by GeoLite2 and DB-IP once joined to their
+// location tables. Lines that don't parse, such as a header, are skipped.
+package geoip
+
+import (
+    "bufio"
+    "errors"
+    "net/netip"
+    "os"
+    "sort"
+    "strings"
+)
+
+type span struct {
+    first, last netip.Addr
+    country     string
+}
+
+// DB is an immutable, sorted set of ranges.
+type DB struct {
+    spans []span
+}
+
+// Load reads the database at path.
+func Load(path string) (*DB, error) {
+    f, err := os.Open(path)
+    if err != nil { return nil, err }
+    defer f.Close()
+    db := &DB{}
+    sc := bufio.NewScanner(f)
+    for sc.Scan() {
+        network, country, ok := strings.Cut(sc.Text(), ",")
+        if !ok { continue }
+        p, err := netip.ParsePrefix(strings.TrimSpace(network))
+        if err != nil { continue }
+        country = strings.ToUpper(strings.Trim(strings.TrimSpace(country), `"`))
+        if len(country) != 2 { continue }
+        p = p.Masked()
+        db.spans = append(db.spans, span{first: p.Addr(), last: LastAddr(p), country: country})
+    }
+    if err := sc.Err(); err != nil { return nil, err }
+    if len(db.spans) == 0 { return nil, errors.New("geoip: no networks in " + path) }
+    sort.Slice(db.spans, func(i, j int) bool { return db.spans[i].first.Less(db.spans[j].first) })
+    return db, nil
+}
+
+// Len is the number of ranges loaded.
+func (db *DB) Len() int { return len(db.spans) }
+
+// Country returns the country of ip, or "" when no range covers it.
+func (db *DB) Country(ip netip.Addr) string {
+    if db == nil { return "" }
+    ip = ip.Unmap()
+    i := sort.Search(len(db.spans), func(i int) bool { return ip.Less(db.spans[i].first) }) - 1
+    if i < 0 { return "" }
+    s := db.spans[i]
+    if ip.BitLen() != s.first.BitLen() || s.last.Less(ip) { return "" }
+    return s.country
+}
+
+// LastAddr returns the highest address in p.
+func LastAddr(p netip.Prefix) netip.Addr {
+    p = p.Masked()
+    b := p.Addr().As16()
+    bits := p.Bits()
+    if p.Addr().Is4() { bits += 96 }
+    for i := bits; i < 128; i++ { b[i/8] |= 1 << (7 - uint(i%8)) }
+    a := netip.AddrFrom16(b)
+    if p.Addr().Is4() { return a.Unmap() }
+    return a
+}
@@ -0,0 +1,251 @@
+// Package msgpack encodes Go values as MessagePack the way encoding/json
+// encodes them as JSON: structs become maps keyed by their json tag names,
+// with omitempty, "-" and embedded structs handled alike, and values that
+// implement encoding.TextMarshaler, such as time.Time, become strings. It
+// only encodes; the API never reads MessagePack.
+package msgpack
+
+import (
+    "encoding"
+    "encoding/binary"
+    "fmt"
+    "math"
+    "reflect"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// Marshal returns the MessagePack encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+    var e encoder
+    if err := e.value(reflect.ValueOf(v)); err != nil { return nil, err }
+    return e.buf, nil
+}
+
+type encoder struct{ buf []byte }
+
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func (e *encoder) value(v reflect.Value) error {
+    if !v.IsValid() { e.buf = append(e.buf, 0xc0); return nil }
+    if v.Type().Implements(textMarshaler) && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+        b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+        if err != nil { return err }
+        e.str(string(b))
+        return nil
+    }
+    switch v.Kind() {
+    case reflect.Pointer, reflect.Interface:
+        if v.IsNil() { e.buf = append(e.buf, 0xc0); return nil }
+        return e.value(v.Elem())
+    case reflect.Bool:
+        if v.Bool() { e.buf = append(e.buf, 0xc3) } else { e.buf = append(e.buf, 0xc2) }
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        e.int(v.Int())
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        e.uint(v.Uint())
+    case reflect.Float32:
+        e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xca), math.Float32bits(float32(v.Float())))
+    case reflect.Float64:
+        e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(v.Float()))
+    case reflect.String:
+        e.str(v.String())
+    case reflect.Slice:
+        if v.IsNil() { e.buf = append(e.buf, 0xc0); return nil }
+        if v.Type().Elem().Kind() == reflect.Uint8 { e.bin(v.Bytes()); return nil }
+        return e.array(v)
+    case reflect.Array:
+        return e.array(v)
+    case reflect.Map:
+        if v.IsNil() { e.buf = append(e.buf, 0xc0); return nil }
+        if v.Type().Key().Kind() != reflect.String { return fmt.Errorf("msgpack: unsupported map key type %s", v.Type().Key()) }
+        keys := v.MapKeys()
+        sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+        e.header(len(keys), 0x80, 0xde, 0xdf)
+        for _, k := range keys {
+            e.str(k.String())
+            if err := e.value(v.MapIndex(k)); err != nil { return err }
+        }
+    case reflect.Struct:
+        return e.structure(v)
+    default:
+        return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+    }
+    return nil
+}
+
+func (e *encoder) array(v reflect.Value) error {
+    e.header(v.Len(), 0x90, 0xdc, 0xdd)
+    for i := 0; i < v.Len(); i++ {
+        if err := e.value(v.Index(i)); err != nil { return err }
+    }
+    return nil
+}
+
+func (e *encoder) structure(v reflect.Value) error {
+    fields := cachedFields(v.Type())
+    var present []field
+    var values []reflect.Value
+    for _, f := range fields {
+        fv, ok := fieldByIndex(v, f.index)
+        if !ok || (f.omitEmpty && isEmpty(fv)) { continue }
+        present = append(present, f)
+        values = append(values, fv)
+    }
+    e.header(len(present), 0x80, 0xde, 0xdf)
+    for i, f := range present {
+        e.str(f.name)
+        if err := e.value(values[i]); err != nil { return err }
+    }
+    return nil
+}
+
+// header writes a map or array header: the fix form for up to 15 entries,
+// then the 16 and 32 bit forms.
+func (e *encoder) header(n int, fix, b16, b32 byte) {
+    switch {
+    case n < 16:
+        e.buf = append(e.buf, fix|byte(n))
+    case n <= math.MaxUint16:
+        e.buf = binary.BigEndian.AppendUint16(append(e.buf, b16), uint16(n))
+    default:
+        e.buf = binary.BigEndian.AppendUint32(append(e.buf, b32), uint32(n))
+    }
+}
+
+func (e *encoder) str(s string) {
+    switch n := len(s); {
+    case n < 32:
+        e.buf = append(e.buf, 0xa0|byte(n))
+    case n <= math.MaxUint8:
+        e.buf = append(e.buf, 0xd9, byte(n))
+    case n <= math.MaxUint16:
+        e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(n))
+    default:
+        e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(n))
+    }
+    e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bin(b []byte) {
+    switch n := len(b); {
+    case n <= math.MaxUint8:
+        e.buf = append(e.buf, 0xc4, byte(n))
+    case n <= math.MaxUint16:
+        e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xc5), uint16(n))
+    default:
+        e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xc6), uint32(n))
+    }
+    e.buf = append(e.buf, b...)
+}
+
+// int writes i in the smallest form that holds it.
+func (e *encoder) int(i int64) {
+    switch {
+    case i >= 0:
+        e.uint(uint64(i))
+    case i >= -32:
+        e.buf = append(e.buf, byte(i))
+    case i >= math.MinInt8:
+        e.buf = append(e.buf, 0xd0, byte(i))
+    case i >= math.MinInt16:
+        e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(i))
+    case i >= math.MinInt32:
+        e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(i))
+    default:
+        e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(i))
+    }
+}
+
+func (e *encoder) uint(u uint64) {
+    switch {
+    case u <= 0x7f:
+        e.buf = append(e.buf, byte(u))
+    case u <= math.MaxUint8:
+        e.buf = append(e.buf, 0xcc, byte(u))
+    case u <= math.MaxUint16:
+        e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(u))
+    case u <= math.MaxUint32:
+        e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(u))
+    default:
+        e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), u)
+    }
+}
+
+type field struct {
+    name      string
+    index     []int
+    omitEmpty bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// cachedFields lists the encoded fields of struct type t in declaration
+// order. Fields of untagged embedded structs are promoted, and lose to a
+// field of the same name nearer the top, as in encoding/json.
+func cachedFields(t reflect.Type) []field {
+    if f, ok := fieldCache.Load(t); ok { return f.([]field) }
+    var out []field
+    seen := map[string]bool{}
+    var walk func(t reflect.Type, index []int, embedded *[]field)
+    walk = func(t reflect.Type, index []int, embedded *[]field) {
+        var next []field
+        for i := 0; i < t.NumField(); i++ {
+            sf := t.Field(i)
+            tag := sf.Tag.Get("json")
+            if tag == "-" { continue }
+            name, opts, _ := strings.Cut(tag, ",")
+            idx := append(append([]int(nil), index...), i)
+            ft := sf.Type
+            if ft.Kind() == reflect.Pointer { ft = ft.Elem() }
+            if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+                walk(ft, idx, &next)
+                continue
+            }
+            if !sf.IsExported() { continue }
+            if name == "" { name = sf.Name }
+            f := field{name: name, index: idx, omitEmpty: strings.Contains(","+opts+",", ",omitempty,")}
+            if embedded != nil { *embedded = append(*embedded, f); continue }
+            if !seen[name] { seen[name] = true; out = append(out, f) }
+        }
+        for _, f := range next {
+            if embedded != nil { *embedded = append(*embedded, f); continue }
+            if !seen[f.name] { seen[f.name] = true; out = append(out, f) }
+        }
+    }
+    walk(t, nil, nil)
+    fieldCache.Store(t, out)
+    return out
+}
+
+// fieldByIndex is v.FieldByIndex, reporting false instead of panicking at
+// a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+    for i, x := range index {
+        if i > 0 && v.Kind() == reflect.Pointer {
+            if v.IsNil() { return reflect.Value{}, false }
+            v = v.Elem()
+        }
+        v = v.Field(x)
+    }
+    return v, true
+}
+
+func isEmpty(v reflect.Value) bool {
+    switch v.Kind() {
+    case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+        return v.Len() == 0
+    case reflect.Bool:
+        return !v.Bool()
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return v.Int() == 0
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        return v.Uint() == 0
+    case reflect.Float32, reflect.Float64:
+        return v.Float() == 0
+    case reflect.Pointer, reflect.Interface:
+        return v.IsNil()
+    }
+    return false
+}
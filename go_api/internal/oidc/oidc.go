@@ -0,0 +1,222 @@
+// Package oidc verifies OpenID Connect tokens: JWTs signed by an issuer's
+// published keys, found through its discovery document. Only the RS and ES
+// algorithms are accepted.
+package oidc
+
+import (
+    "context"
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// ErrToken is what Verify's errors wrap when the token itself is at fault,
+// as opposed to the issuer being unreachable.
+var ErrToken = errors.New("invalid token")
+
+// leeway allows for clock skew between us and the issuer.
+const leeway = time.Minute
+
+// Claims are a verified token's claims.
+type Claims map[string]interface{}
+
+// String returns claim name, or "" if it isn't a string.
+func (c Claims) String(name string) string {
+    s, _ := c[name].(string)
+    return s
+}
+
+// Strings returns claim name as a list, whether the token has it as a
+// list or a single string.
+func (c Claims) Strings(name string) []string {
+    switch v := c[name].(type) {
+    case string:
+        return []string{v}
+    case []interface{}:
+        var out []string
+        for _, x := range v {
+            if s, ok := x.(string); ok { out = append(out, s) }
+        }
+        return out
+    }
+    return nil
+}
+
+// Verifier checks tokens from Issuer for Audience, usually the client ID
+// the tokens were issued to. Its discovery document and keys are fetched on
+// first use; the keys again when a token names one it doesn't know.
+type Verifier struct {
+    Issuer   string
+    Audience string
+
+    mu      sync.Mutex
+    keys    map[string]crypto.PublicKey
+    jwksURI string
+    fetched time.Time
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Verify checks token's signature, issuer, audience and validity period
+// and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 { return nil, fmt.Errorf("%w: not a JWT", ErrToken) }
+    var header struct {
+        Alg string `json:"alg"`
+        Kid string `json:"kid"`
+    }
+    if err := decodeSegment(parts[0], &header); err != nil { return nil, err }
+    hash, ok := hashes[header.Alg]
+    if !ok { return nil, fmt.Errorf("%w: algorithm %q not accepted", ErrToken, header.Alg) }
+    sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+    if err != nil { return nil, fmt.Errorf("%w: signature: %v", ErrToken, err) }
+    key, err := v.key(ctx, header.Kid)
+    if err != nil { return nil, err }
+    h := hash.New()
+    h.Write([]byte(parts[0] + "." + parts[1]))
+    if err := verifySignature(header.Alg, key, hash, h.Sum(nil), sig); err != nil { return nil, err }
+
+    var claims Claims
+    if err := decodeSegment(parts[1], &claims); err != nil { return nil, err }
+    if claims.String("iss") != v.Issuer { return nil, fmt.Errorf("%w: issued by %q", ErrToken, claims.String("iss")) }
+    if !contains(claims.Strings("aud"), v.Audience) { return nil, fmt.Errorf("%w: not issued for %s", ErrToken, v.Audience) }
+    now := time.Now()
+    exp, ok := claims["exp"].(float64)
+    if !ok || now.After(time.Unix(int64(exp), 0).Add(leeway)) { return nil, fmt.Errorf("%w: expired", ErrToken) }
+    if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) { return nil, fmt.Errorf("%w: not valid yet", ErrToken) }
+    return claims, nil
+}
+
+var hashes = map[string]crypto.Hash{
+    "RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+    "ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+func verifySignature(alg string, key crypto.PublicKey, hash crypto.Hash, digest, sig []byte) error {
+    switch k := key.(type) {
+    case *rsa.PublicKey:
+        if alg[:2] == "RS" && rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil { return nil }
+    case *ecdsa.PublicKey:
+        size := (k.Curve.Params().BitSize + 7) / 8
+        if alg[:2] == "ES" && len(sig) == 2*size {
+            r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+            if ecdsa.Verify(k, digest, r, s) { return nil }
+        }
+    }
+    return fmt.Errorf("%w: bad signature", ErrToken)
+}
+
+// key returns the issuer's key kid, refetching the key set when kid isn't
+// in it, at most once a minute so junk tokens can't hammer the issuer.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    if k, ok := v.keys[kid]; ok { return k, nil }
+    if time.Since(v.fetched) < time.Minute && v.keys != nil { return nil, fmt.Errorf("%w: unknown key %q", ErrToken, kid) }
+    if err := v.fetchKeys(ctx); err != nil { return nil, err }
+    if k, ok := v.keys[kid]; ok { return k, nil }
+    // A key set with a single key may leave kid out of tokens.
+    if kid == "" && len(v.keys) == 1 {
+        for _, k := range v.keys { return k, nil }
+    }
+    return nil, fmt.Errorf("%w: unknown key %q", ErrToken, kid)
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context) error {
+    if v.jwksURI == "" {
+        var doc struct {
+            Issuer  string `json:"issuer"`
+            JWKSURI string `json:"jwks_uri"`
+        }
+        if err := getJSON(ctx, strings.TrimSuffix(v.Issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil { return err }
+        if doc.Issuer != v.Issuer { return fmt.Errorf("oidc discovery: issuer is %q, not %q", doc.Issuer, v.Issuer) }
+        if doc.JWKSURI == "" { return errors.New("oidc discovery: no jwks_uri") }
+        v.jwksURI = doc.JWKSURI
+    }
+    var set struct {
+        Keys []jwk `json:"keys"`
+    }
+    if err := getJSON(ctx, v.jwksURI, &set); err != nil { return err }
+    keys := map[string]crypto.PublicKey{}
+    for _, k := range set.Keys {
+        if k.Use != "" && k.Use != "sig" { continue }
+        if pk, err := k.publicKey(); err == nil { keys[k.Kid] = pk }
+    }
+    v.keys, v.fetched = keys, time.Now()
+    return nil
+}
+
+type jwk struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    N   string `json:"n"`
+    E   string `json:"e"`
+    Crv string `json:"crv"`
+    X   string `json:"x"`
+    Y   string `json:"y"`
+}
+
+var curves = map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+    num := func(s string) (*big.Int, error) {
+        b, err := base64.RawURLEncoding.DecodeString(s)
+        if err != nil { return nil, err }
+        return new(big.Int).SetBytes(b), nil
+    }
+    switch k.Kty {
+    case "RSA":
+        n, err := num(k.N)
+        if err != nil { return nil, err }
+        e, err := num(k.E)
+        if err != nil { return nil, err }
+        if !e.IsInt64() || e.Int64() > 1<<31 { return nil, errors.New("bad RSA exponent") }
+        return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+    case "EC":
+        curve, ok := curves[k.Crv]
+        if !ok { return nil, fmt.Errorf("unsupported curve %q", k.Crv) }
+        x, err := num(k.X)
+        if err != nil { return nil, err }
+        y, err := num(k.Y)
+        if err != nil { return nil, err }
+        if !curve.IsOnCurve(x, y) { return nil, errors.New("EC point not on curve") }
+        return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+    }
+    return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil { return err }
+    resp, err := client.Do(req)
+    if err != nil { return err }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK { return fmt.Errorf("%s: %s", url, resp.Status) }
+    return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func decodeSegment(s string, v interface{}) error {
+    b, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil { return fmt.Errorf("%w: %v", ErrToken, err) }
+    if err := json.Unmarshal(b, v); err != nil { return fmt.Errorf("%w: %v", ErrToken, err) }
+    return nil
+}
+
+func contains(list []string, s string) bool {
+    for _, x := range list {
+        if x == s { return true }
+    }
+    return false
+}
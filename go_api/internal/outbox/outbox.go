@@ -0,0 +1,74 @@
+// Package outbox republishes kafka_outbox rows, the events go_api could not
+// hand to the event bus, once the bus accepts writes again. go_api runs it
+// in the background and fraudctl on demand.
+package outbox
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/segmentio/kafka-go"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// Publisher writes outbox messages synchronously the way go_api's
+// publishers do: Kafka messages keyed by user with a content-type header,
+// or Redis stream entries with key, value and content_type fields.
+type Publisher struct {
+    writers map[string]*kafka.Writer
+    rdb     *redis.Client
+}
+
+// NewPublisher targets the transport selected by event_bus; rdb is only
+// used for redis.
+func NewPublisher(rdb *redis.Client) (*Publisher, error) {
+    switch t := strings.ToLower(config.Get().EventBus); t {
+    case "kafka":
+        return &Publisher{writers: map[string]*kafka.Writer{}}, nil
+    case "redis":
+        return &Publisher{rdb: rdb}, nil
+    default:
+        return nil, fmt.Errorf("unknown EVENT_BUS %q", t)
+    }
+}
+
+// Ready reports whether the bus is reachable, so a relay doesn't start a
+// batch that is bound to fail.
+func (p *Publisher) Ready(ctx context.Context) error {
+    if p.rdb != nil { return p.rdb.Ping(ctx).Err() }
+    return conn.PingKafka(ctx, config.Get().Kafka.Brokers)
+}
+
+func (p *Publisher) Publish(ctx context.Context, m store.OutboxMessage) error {
+    if p.rdb != nil {
+        return p.rdb.XAdd(ctx, &redis.XAddArgs{
+            Stream: m.Topic,
+            MaxLen: int64(config.Get().Redis.StreamMaxLen),
+            Approx: true,
+            Values: map[string]interface{}{"key": m.Key, "value": m.Payload, "content_type": m.ContentType},
+        }).Err()
+    }
+    w, ok := p.writers[m.Topic]
+    if !ok {
+        w = conn.NewKafkaWriter(config.Get().Kafka.Brokers, m.Topic)
+        p.writers[m.Topic] = w
+    }
+    return w.WriteMessages(ctx, kafka.Message{Key: m.Key, Value: m.Payload, Headers: []kafka.Header{{Key: "content-type", Value: []byte(m.ContentType)}}})
+}
+
+// Close closes the Kafka writers; the Redis client belongs to the caller.
+func (p *Publisher) Close() {
+    for _, w := range p.writers { w.Close() }
+}
+
+// Drain publishes up to limit pending messages for topic ("" for all),
+// oldest first, and returns how many were delivered. It stops at the first
+// failure, since the bus is most likely still down.
+func Drain(ctx context.Context, st store.OutboxStore, p *Publisher, topic string, limit int) (int, error) {
+    return st.Relay(ctx, topic, limit, func(m store.OutboxMessage) error { return p.Publish(ctx, m) })
+}
@@ -0,0 +1,302 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: enricher.proto
+
+package enricherpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EnrichRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	UserId     string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount     float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	MerchantId string                 `protobuf:"bytes,3,opt,name=merchant_id,json=merchantId,proto3" json:"merchant_id,omitempty"`
+	// card, ach, wire or p2p
+	Channel   string  `protobuf:"bytes,4,opt,name=channel,proto3" json:"channel,omitempty"`
+	Mcc       *string `protobuf:"bytes,5,opt,name=mcc,proto3,oneof" json:"mcc,omitempty"`
+	DeviceId  *string `protobuf:"bytes,6,opt,name=device_id,json=deviceId,proto3,oneof" json:"device_id,omitempty"`
+	IpAddress *string `protobuf:"bytes,7,opt,name=ip_address,json=ipAddress,proto3,oneof" json:"ip_address,omitempty"`
+	// The request's free-form attributes, passed through untouched, so a
+	// plugin can use fields the core service doesn't model.
+	Attributes map[string]string `protobuf:"bytes,8,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Email      *string           `protobuf:"bytes,9,opt,name=email,proto3,oneof" json:"email,omitempty"`
+	// E.164
+	Phone         *string `protobuf:"bytes,10,opt,name=phone,proto3,oneof" json:"phone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrichRequest) Reset() {
+	*x = EnrichRequest{}
+	mi := &file_enricher_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrichRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrichRequest) ProtoMessage() {}
+
+func (x *EnrichRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_enricher_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrichRequest.ProtoReflect.Descriptor instead.
+func (*EnrichRequest) Descriptor() ([]byte, []int) {
+	return file_enricher_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EnrichRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *EnrichRequest) GetMerchantId() string {
+	if x != nil {
+		return x.MerchantId
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetMcc() string {
+	if x != nil && x.Mcc != nil {
+		return *x.Mcc
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetDeviceId() string {
+	if x != nil && x.DeviceId != nil {
+		return *x.DeviceId
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetIpAddress() string {
+	if x != nil && x.IpAddress != nil {
+		return *x.IpAddress
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *EnrichRequest) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+func (x *EnrichRequest) GetPhone() string {
+	if x != nil && x.Phone != nil {
+		return *x.Phone
+	}
+	return ""
+}
+
+type EnrichResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sent to the ML service as additional features, prefixed with the
+	// plugin's configured name ("<name>_<feature>").
+	Features map[string]float64 `protobuf:"bytes,1,rep,name=features,proto3" json:"features,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	// Appended to the transaction's risk factors.
+	RiskFactors []string `protobuf:"bytes,2,rep,name=risk_factors,json=riskFactors,proto3" json:"risk_factors,omitempty"`
+	// Added to the built-in rules' score (which is clamped to [0, 1]).
+	ScoreAdjustment float64 `protobuf:"fixed64,3,opt,name=score_adjustment,json=scoreAdjustment,proto3" json:"score_adjustment,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *EnrichResponse) Reset() {
+	*x = EnrichResponse{}
+	mi := &file_enricher_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrichResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrichResponse) ProtoMessage() {}
+
+func (x *EnrichResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_enricher_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrichResponse.ProtoReflect.Descriptor instead.
+func (*EnrichResponse) Descriptor() ([]byte, []int) {
+	return file_enricher_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EnrichResponse) GetFeatures() map[string]float64 {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *EnrichResponse) GetRiskFactors() []string {
+	if x != nil {
+		return x.RiskFactors
+	}
+	return nil
+}
+
+func (x *EnrichResponse) GetScoreAdjustment() float64 {
+	if x != nil {
+		return x.ScoreAdjustment
+	}
+	return 0
+}
+
+var File_enricher_proto protoreflect.FileDescriptor
+
+const file_enricher_proto_rawDesc = "" +
+	"\n" +
+	"\x0eenricher.proto\x12\x18fraud_detection.enricher\"\xdf\x03\n" +
+	"\rEnrichRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x1f\n" +
+	"\vmerchant_id\x18\x03 \x01(\tR\n" +
+	"merchantId\x12\x18\n" +
+	"\achannel\x18\x04 \x01(\tR\achannel\x12\x15\n" +
+	"\x03mcc\x18\x05 \x01(\tH\x00R\x03mcc\x88\x01\x01\x12 \n" +
+	"\tdevice_id\x18\x06 \x01(\tH\x01R\bdeviceId\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"ip_address\x18\a \x01(\tH\x02R\tipAddress\x88\x01\x01\x12W\n" +
+	"\n" +
+	"attributes\x18\b \x03(\v27.fraud_detection.enricher.EnrichRequest.AttributesEntryR\n" +
+	"attributes\x12\x19\n" +
+	"\x05email\x18\t \x01(\tH\x03R\x05email\x88\x01\x01\x12\x19\n" +
+	"\x05phone\x18\n" +
+	" \x01(\tH\x04R\x05phone\x88\x01\x01\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x06\n" +
+	"\x04_mccB\f\n" +
+	"\n" +
+	"_device_idB\r\n" +
+	"\v_ip_addressB\b\n" +
+	"\x06_emailB\b\n" +
+	"\x06_phone\"\xef\x01\n" +
+	"\x0eEnrichResponse\x12R\n" +
+	"\bfeatures\x18\x01 \x03(\v26.fraud_detection.enricher.EnrichResponse.FeaturesEntryR\bfeatures\x12!\n" +
+	"\frisk_factors\x18\x02 \x03(\tR\vriskFactors\x12)\n" +
+	"\x10score_adjustment\x18\x03 \x01(\x01R\x0fscoreAdjustment\x1a;\n" +
+	"\rFeaturesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x012g\n" +
+	"\bEnricher\x12[\n" +
+	"\x06Enrich\x12'.fraud_detection.enricher.EnrichRequest\x1a(.fraud_detection.enricher.EnrichResponseB<Z:example.com/fraud/go_api/internal/pb/enricherpb;enricherpbb\x06proto3"
+
+var (
+	file_enricher_proto_rawDescOnce sync.Once
+	file_enricher_proto_rawDescData []byte
+)
+
+func file_enricher_proto_rawDescGZIP() []byte {
+	file_enricher_proto_rawDescOnce.Do(func() {
+		file_enricher_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_enricher_proto_rawDesc), len(file_enricher_proto_rawDesc)))
+	})
+	return file_enricher_proto_rawDescData
+}
+
+var file_enricher_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_enricher_proto_goTypes = []any{
+	(*EnrichRequest)(nil),  // 0: fraud_detection.enricher.EnrichRequest
+	(*EnrichResponse)(nil), // 1: fraud_detection.enricher.EnrichResponse
+	nil,                    // 2: fraud_detection.enricher.EnrichRequest.AttributesEntry
+	nil,                    // 3: fraud_detection.enricher.EnrichResponse.FeaturesEntry
+}
+var file_enricher_proto_depIdxs = []int32{
+	2, // 0: fraud_detection.enricher.EnrichRequest.attributes:type_name -> fraud_detection.enricher.EnrichRequest.AttributesEntry
+	3, // 1: fraud_detection.enricher.EnrichResponse.features:type_name -> fraud_detection.enricher.EnrichResponse.FeaturesEntry
+	0, // 2: fraud_detection.enricher.Enricher.Enrich:input_type -> fraud_detection.enricher.EnrichRequest
+	1, // 3: fraud_detection.enricher.Enricher.Enrich:output_type -> fraud_detection.enricher.EnrichResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_enricher_proto_init() }
+func file_enricher_proto_init() {
+	if File_enricher_proto != nil {
+		return
+	}
+	file_enricher_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_enricher_proto_rawDesc), len(file_enricher_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_enricher_proto_goTypes,
+		DependencyIndexes: file_enricher_proto_depIdxs,
+		MessageInfos:      file_enricher_proto_msgTypes,
+	}.Build()
+	File_enricher_proto = out.File
+	file_enricher_proto_goTypes = nil
+	file_enricher_proto_depIdxs = nil
+}
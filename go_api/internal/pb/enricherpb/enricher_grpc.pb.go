@@ -0,0 +1,131 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: enricher.proto
+
+package enricherpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Enricher_Enrich_FullMethodName = "/fraud_detection.enricher.Enricher/Enrich"
+)
+
+// EnricherClient is the client API for Enricher service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Enrichment plugin protocol. A plugin is a gRPC server, usually a sidecar
+// next to go_api, that adds signals to every transaction before it is
+// scored. go_api runs it as one stage of its enrichment pipeline, under the
+// same per-stage timeout as the built-in stages.
+type EnricherClient interface {
+	Enrich(ctx context.Context, in *EnrichRequest, opts ...grpc.CallOption) (*EnrichResponse, error)
+}
+
+type enricherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEnricherClient(cc grpc.ClientConnInterface) EnricherClient {
+	return &enricherClient{cc}
+}
+
+func (c *enricherClient) Enrich(ctx context.Context, in *EnrichRequest, opts ...grpc.CallOption) (*EnrichResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnrichResponse)
+	err := c.cc.Invoke(ctx, Enricher_Enrich_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EnricherServer is the server API for Enricher service.
+// All implementations must embed UnimplementedEnricherServer
+// for forward compatibility.
+//
+// Enrichment plugin protocol. A plugin is a gRPC server, usually a sidecar
+// next to go_api, that adds signals to every transaction before it is
+// scored. go_api runs it as one stage of its enrichment pipeline, under the
+// same per-stage timeout as the built-in stages.
+type EnricherServer interface {
+	Enrich(context.Context, *EnrichRequest) (*EnrichResponse, error)
+	mustEmbedUnimplementedEnricherServer()
+}
+
+// UnimplementedEnricherServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEnricherServer struct{}
+
+func (UnimplementedEnricherServer) Enrich(context.Context, *EnrichRequest) (*EnrichResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Enrich not implemented")
+}
+func (UnimplementedEnricherServer) mustEmbedUnimplementedEnricherServer() {}
+func (UnimplementedEnricherServer) testEmbeddedByValue()                  {}
+
+// UnsafeEnricherServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EnricherServer will
+// result in compilation errors.
+type UnsafeEnricherServer interface {
+	mustEmbedUnimplementedEnricherServer()
+}
+
+func RegisterEnricherServer(s grpc.ServiceRegistrar, srv EnricherServer) {
+	// If the following call pancis, it indicates UnimplementedEnricherServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Enricher_ServiceDesc, srv)
+}
+
+func _Enricher_Enrich_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrichRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EnricherServer).Enrich(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Enricher_Enrich_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EnricherServer).Enrich(ctx, req.(*EnrichRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Enricher_ServiceDesc is the grpc.ServiceDesc for Enricher service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Enricher_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fraud_detection.enricher.Enricher",
+	HandlerType: (*EnricherServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Enrich",
+			Handler:    _Enricher_Enrich_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "enricher.proto",
+}
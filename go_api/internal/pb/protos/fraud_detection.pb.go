@@ -156,8 +156,20 @@ type FraudResponse struct {
 	RiskFactors      []string               `protobuf:"bytes,5,rep,name=risk_factors,json=riskFactors,proto3" json:"risk_factors,omitempty"`
 	ModelVersion     string                 `protobuf:"bytes,6,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"`
 	ProcessingTimeMs int64                  `protobuf:"varint,7,opt,name=processing_time_ms,json=processingTimeMs,proto3" json:"processing_time_ms,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	// The API's verdict, as in its JSON response
+	Degraded          bool    `protobuf:"varint,8,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	DuplicateOf       string  `protobuf:"bytes,9,opt,name=duplicate_of,json=duplicateOf,proto3" json:"duplicate_of,omitempty"`
+	Decision          string  `protobuf:"bytes,10,opt,name=decision,proto3" json:"decision,omitempty"`
+	ExpectedLoss      float64 `protobuf:"fixed64,11,opt,name=expected_loss,json=expectedLoss,proto3" json:"expected_loss,omitempty"`
+	DeclineReason     string  `protobuf:"bytes,12,opt,name=decline_reason,json=declineReason,proto3" json:"decline_reason,omitempty"`
+	PartialEvaluation bool    `protobuf:"varint,13,opt,name=partial_evaluation,json=partialEvaluation,proto3" json:"partial_evaluation,omitempty"`
+	// Set, and the rest left empty, for a batch item that was not scored
+	Error string `protobuf:"bytes,14,opt,name=error,proto3" json:"error,omitempty"`
+	// ml, local_model or rules, and why the ML service didn't score when wanted
+	ScoringTier    string `protobuf:"bytes,15,opt,name=scoring_tier,json=scoringTier,proto3" json:"scoring_tier,omitempty"`
+	FallbackReason string `protobuf:"bytes,16,opt,name=fallback_reason,json=fallbackReason,proto3" json:"fallback_reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *FraudResponse) Reset() {
@@ -239,6 +251,69 @@ func (x *FraudResponse) GetProcessingTimeMs() int64 {
 	return 0
 }
 
+func (x *FraudResponse) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+func (x *FraudResponse) GetDuplicateOf() string {
+	if x != nil {
+		return x.DuplicateOf
+	}
+	return ""
+}
+
+func (x *FraudResponse) GetDecision() string {
+	if x != nil {
+		return x.Decision
+	}
+	return ""
+}
+
+func (x *FraudResponse) GetExpectedLoss() float64 {
+	if x != nil {
+		return x.ExpectedLoss
+	}
+	return 0
+}
+
+func (x *FraudResponse) GetDeclineReason() string {
+	if x != nil {
+		return x.DeclineReason
+	}
+	return ""
+}
+
+func (x *FraudResponse) GetPartialEvaluation() bool {
+	if x != nil {
+		return x.PartialEvaluation
+	}
+	return false
+}
+
+func (x *FraudResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *FraudResponse) GetScoringTier() string {
+	if x != nil {
+		return x.ScoringTier
+	}
+	return ""
+}
+
+func (x *FraudResponse) GetFallbackReason() string {
+	if x != nil {
+		return x.FallbackReason
+	}
+	return ""
+}
+
 // Fraud Score Response
 type FraudScoreResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1028,7 +1103,7 @@ const file_protos_fraud_detection_proto_rawDesc = "" +
 	"\x13additional_features\x18\v \x03(\v2;.fraud_detection.TransactionRequest.AdditionalFeaturesEntryR\x12additionalFeatures\x1aE\n" +
 	"\x17AdditionalFeaturesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\x88\x02\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\xc0\x04\n" +
 	"\rFraudResponse\x12%\n" +
 	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\x12\x19\n" +
 	"\bis_fraud\x18\x02 \x01(\bR\aisFraud\x12\x1f\n" +
@@ -1039,7 +1114,17 @@ const file_protos_fraud_detection_proto_rawDesc = "" +
 	"confidence\x12!\n" +
 	"\frisk_factors\x18\x05 \x03(\tR\vriskFactors\x12#\n" +
 	"\rmodel_version\x18\x06 \x01(\tR\fmodelVersion\x12,\n" +
-	"\x12processing_time_ms\x18\a \x01(\x03R\x10processingTimeMs\"x\n" +
+	"\x12processing_time_ms\x18\a \x01(\x03R\x10processingTimeMs\x12\x1a\n" +
+	"\bdegraded\x18\b \x01(\bR\bdegraded\x12!\n" +
+	"\fduplicate_of\x18\t \x01(\tR\vduplicateOf\x12\x1a\n" +
+	"\bdecision\x18\n" +
+	" \x01(\tR\bdecision\x12#\n" +
+	"\rexpected_loss\x18\v \x01(\x01R\fexpectedLoss\x12%\n" +
+	"\x0edecline_reason\x18\f \x01(\tR\rdeclineReason\x12-\n" +
+	"\x12partial_evaluation\x18\r \x01(\bR\x11partialEvaluation\x12\x14\n" +
+	"\x05error\x18\x0e \x01(\tR\x05error\x12!\n" +
+	"\fscoring_tier\x18\x0f \x01(\tR\vscoringTier\x12'\n" +
+	"\x0ffallback_reason\x18\x10 \x01(\tR\x0efallbackReason\"x\n" +
 	"\x12FraudScoreResponse\x12\x1f\n" +
 	"\vfraud_score\x18\x01 \x01(\x01R\n" +
 	"fraudScore\x12\x1e\n" +
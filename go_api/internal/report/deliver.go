@@ -0,0 +1,111 @@
+package report
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "mime"
+    "mime/multipart"
+    "net"
+    "net/smtp"
+    "net/textproto"
+    "strings"
+    "time"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/s3"
+)
+
+// File is a rendered report.
+type File struct {
+    Name        string
+    ContentType string
+    Data        []byte
+}
+
+// Render renders r in each of formats (csv, pdf).
+func Render(r Report, formats []string) []File {
+    var files []File
+    for _, f := range formats {
+        switch strings.ToLower(f) {
+        case "csv":
+            files = append(files, File{r.Filename("csv"), "text/csv", r.CSV()})
+        case "pdf":
+            files = append(files, File{r.Filename("pdf"), "application/pdf", r.PDF()})
+        }
+    }
+    return files
+}
+
+// Deliver renders r in cfg's formats and sends it to each configured
+// destination, returning those it reached ("email", "s3"). A failed
+// destination doesn't stop the others.
+func Deliver(ctx context.Context, cfg config.Reports, aws config.Archive, r Report) ([]string, error) {
+    files := Render(r, cfg.Formats)
+    var sent []string
+    var errs []error
+    if len(cfg.Email.To) > 0 {
+        if err := Email(cfg.Email, r, files); err != nil {
+            errs = append(errs, fmt.Errorf("email: %w", err))
+        } else {
+            sent = append(sent, "email")
+        }
+    }
+    if cfg.S3.Bucket != "" {
+        if err := Upload(ctx, cfg.S3, aws, r, files); err != nil {
+            errs = append(errs, fmt.Errorf("s3: %w", err))
+        } else {
+            sent = append(sent, "s3")
+        }
+    }
+    return sent, errors.Join(errs...)
+}
+
+// Email sends files to cfg.To as attachments of one message. The relay is
+// authenticated with PLAIN when a username is set, which net/smtp only
+// allows over TLS or to localhost.
+func Email(cfg config.ReportEmail, r Report, files []File) error {
+    var body bytes.Buffer
+    mw := multipart.NewWriter(&body)
+    text, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+    s := r.Summary
+    fmt.Fprintf(text, "%s\r\n\r\n%d transactions, %s declined, %s in losses prevented.\r\n", r.Title(), s.Transactions, percent(s.Declined, s.Transactions), money(s.LossesPrevented))
+    for _, f := range files {
+        part, _ := mw.CreatePart(textproto.MIMEHeader{
+            "Content-Type":              {f.ContentType},
+            "Content-Transfer-Encoding": {"base64"},
+            "Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": f.Name})},
+        })
+        enc := base64.StdEncoding.EncodeToString(f.Data)
+        for len(enc) > 76 {
+            part.Write([]byte(enc[:76] + "\r\n"))
+            enc = enc[76:]
+        }
+        part.Write([]byte(enc + "\r\n"))
+    }
+    mw.Close()
+
+    var msg bytes.Buffer
+    fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n", cfg.From, strings.Join(cfg.To, ", "),
+        mime.QEncoding.Encode("utf-8", r.Title()), time.Now().Format(time.RFC1123Z))
+    fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+    msg.Write(body.Bytes())
+
+    var auth smtp.Auth
+    if cfg.Username != "" {
+        host, _, _ := net.SplitHostPort(cfg.SMTPAddr)
+        auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+    }
+    return smtp.SendMail(cfg.SMTPAddr, auth, cfg.From, cfg.To, msg.Bytes())
+}
+
+// Upload writes files to <prefix><kind>/<name> in the reports bucket.
+func Upload(ctx context.Context, cfg config.ReportS3, aws config.Archive, r Report, files []File) error {
+    client := s3.NewClient(cfg.Endpoint, aws.Region, cfg.Bucket, aws.AccessKeyID, aws.SecretAccessKey, aws.SessionToken)
+    for _, f := range files {
+        if err := client.Put(ctx, cfg.Prefix+r.Kind+"/"+f.Name, f.ContentType, f.Data); err != nil { return fmt.Errorf("%s: %w", f.Name, err) }
+    }
+    return nil
+}
@@ -0,0 +1,89 @@
+package report
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+)
+
+// A4 in points, and the text layout on it.
+const (
+    pageWidth    = 595
+    pageHeight   = 842
+    margin       = 50
+    leading      = 12
+    linesPerPage = (pageHeight - 2*margin - 2*leading) / leading
+)
+
+// textPDF renders lines of monospaced text under a title, paginated. It
+// writes PDF 1.4 by hand with the standard Helvetica and Courier fonts, so
+// nothing is embedded and only Latin-1 text prints as is.
+func textPDF(title string, lines []string) []byte {
+    var pages [][]string
+    for len(lines) > linesPerPage {
+        pages = append(pages, lines[:linesPerPage])
+        lines = lines[linesPerPage:]
+    }
+    pages = append(pages, lines)
+
+    // Objects 1-4 are the catalog, page tree and fonts; each page then
+    // takes two: the page and its content stream.
+    var objects []string
+    kids := make([]string, len(pages))
+    for i := range pages { kids[i] = fmt.Sprintf("%d 0 R", 5+2*i) }
+    objects = append(objects,
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+        "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+        "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+    )
+    for i, page := range pages {
+        var s bytes.Buffer
+        y := pageHeight - margin
+        s.WriteString(fmt.Sprintf("BT /F1 14 Tf %d %d Td (%s) Tj ET\n", margin, y, pdfString(title)))
+        y -= 2 * leading
+        s.WriteString(fmt.Sprintf("BT /F2 9 Tf %d TL %d %d Td\n", leading, margin, y))
+        for _, l := range page { s.WriteString("(" + pdfString(l) + ") Tj T*\n") }
+        s.WriteString("ET\n")
+        s.WriteString(fmt.Sprintf("BT /F2 8 Tf %d %d Td (Page %d of %d) Tj ET\n", pageWidth-margin-70, margin/2, i+1, len(pages)))
+        objects = append(objects,
+            fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i),
+            fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", s.Len(), s.String()),
+        )
+    }
+
+    var out bytes.Buffer
+    out.WriteString("%PDF-1.4\n")
+    offsets := make([]int, len(objects))
+    for i, o := range objects {
+        offsets[i] = out.Len()
+        fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, o)
+    }
+    xref := out.Len()
+    fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+    for _, off := range offsets { fmt.Fprintf(&out, "%010d 00000 n \n", off) }
+    fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+    return out.Bytes()
+}
+
+// pdfString escapes s for a literal string in WinAnsi; runes beyond
+// Latin-1 become '?'.
+func pdfString(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        switch {
+        case r == '(' || r == ')' || r == '\\':
+            b.WriteByte('\\')
+            b.WriteRune(r)
+        case r < 32:
+            b.WriteByte(' ')
+        case r < 128:
+            b.WriteRune(r)
+        case r < 256:
+            fmt.Fprintf(&b, "\\%03o", r)
+        default:
+            b.WriteByte('?')
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,132 @@
+// Package report builds the scheduled fraud summaries: transaction volume,
+// losses prevented and alert dispositions over a day or week. It renders
+// them as CSV or PDF and delivers them by email or to S3.
+package report
+
+import (
+    "bytes"
+    "context"
+    "encoding/csv"
+    "fmt"
+    "strconv"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+)
+
+const (
+    Daily  = "daily"
+    Weekly = "weekly"
+)
+
+// Report is one period's summary.
+type Report struct {
+    Kind        string
+    From, To    time.Time
+    GeneratedAt time.Time
+    Summary     store.FraudSummary
+    Alerts      []store.AlertDisposition
+}
+
+// Period returns the last complete period of kind before now: the previous
+// UTC day, or the previous week from Monday to Monday.
+func Period(kind string, now time.Time) (from, to time.Time) {
+    now = now.UTC()
+    to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    if kind == Weekly {
+        to = to.AddDate(0, 0, -(int(to.Weekday())+6)%7)
+        return to.AddDate(0, 0, -7), to
+    }
+    return to.AddDate(0, 0, -1), to
+}
+
+// Build aggregates the report of kind for [from, to).
+func Build(ctx context.Context, st store.ReportStore, kind string, from, to time.Time) (Report, error) {
+    r := Report{Kind: kind, From: from, To: to, GeneratedAt: time.Now().UTC()}
+    var err error
+    if r.Summary, err = st.FraudSummary(ctx, from, to); err != nil { return r, err }
+    r.Alerts, err = st.AlertDispositions(ctx, from, to)
+    return r, err
+}
+
+// Title names the report and its period, e.g. "Daily fraud report
+// 2024-05-01" or "Weekly fraud report 2024-04-29 to 2024-05-05".
+func (r Report) Title() string {
+    last := r.To.AddDate(0, 0, -1).Format("2006-01-02")
+    if r.Kind == Weekly { return "Weekly fraud report " + r.From.Format("2006-01-02") + " to " + last }
+    return "Daily fraud report " + last
+}
+
+// Filename is fraud-<kind>-<first day of the period>.<ext>.
+func (r Report) Filename(ext string) string {
+    return "fraud-" + r.Kind + "-" + r.From.Format("2006-01-02") + "." + ext
+}
+
+// summaryRows are the period's totals as label and value.
+func (r Report) summaryRows() [][2]string {
+    s := r.Summary
+    return [][2]string{
+        {"Transactions", count(s.Transactions)},
+        {"Amount", money(s.Amount)},
+        {"Approved", count(s.Approved)},
+        {"Held for review", count(s.Review)},
+        {"Declined", count(s.Declined)},
+        {"Declined amount", money(s.DeclinedAmount)},
+        {"Decline rate", percent(s.Declined, s.Transactions)},
+        {"Scored as fraud", count(s.Flagged)},
+        {"Labeled fraud", count(s.LabeledFraud)},
+        {"Losses prevented", money(s.LossesPrevented)},
+        {"Losses missed", money(s.LossesMissed)},
+    }
+}
+
+// alertColumns head the alert disposition table; alertRow fills it.
+var alertColumns = []string{"Alert type", "Severity", "Raised", "Open", "Closed", "Suppressed", "Confirmed fraud", "False positive"}
+
+func alertRow(d store.AlertDisposition) []string {
+    return []string{d.AlertType, d.Severity, count(d.Raised), count(d.Open), count(d.Closed), count(d.Suppressed), count(d.ConfirmedFraud), count(d.FalsePositive)}
+}
+
+// CSV writes the summary as metric,value rows, then a blank line and the
+// alert disposition table.
+func (r Report) CSV() []byte {
+    var buf bytes.Buffer
+    w := csv.NewWriter(&buf)
+    w.Write([]string{"metric", "value"})
+    w.Write([]string{"Period start", r.From.Format(time.RFC3339)})
+    w.Write([]string{"Period end", r.To.Format(time.RFC3339)})
+    for _, row := range r.summaryRows() { w.Write(row[:]) }
+    w.Write(nil)
+    w.Write(alertColumns)
+    for _, d := range r.Alerts { w.Write(alertRow(d)) }
+    w.Flush()
+    return buf.Bytes()
+}
+
+// PDF lays the same content out as a plain text document.
+func (r Report) PDF() []byte {
+    lines := []string{
+        "Period: " + r.From.Format("2006-01-02 15:04") + " to " + r.To.Format("2006-01-02 15:04") + " UTC",
+        "Generated: " + r.GeneratedAt.Format("2006-01-02 15:04") + " UTC",
+        "",
+    }
+    for _, row := range r.summaryRows() { lines = append(lines, fmt.Sprintf("%-20s %16s", row[0], row[1])) }
+    lines = append(lines, "", "Alert dispositions", "")
+    format := "%-22.22s %-9.9s %7s %7s %7s %10s %7s %9s"
+    lines = append(lines, fmt.Sprintf(format, "Type", "Severity", "Raised", "Open", "Closed", "Suppressed", "Fraud", "False pos"))
+    for _, d := range r.Alerts {
+        row := alertRow(d)
+        lines = append(lines, fmt.Sprintf(format, row[0], row[1], row[2], row[3], row[4], row[5], row[6], row[7]))
+    }
+    if len(r.Alerts) == 0 { lines = append(lines, "No alerts were raised.") }
+    return textPDF(r.Title(), lines)
+}
+
+func count(n int64) string { return strconv.FormatInt(n, 10) }
+
+func money(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+func percent(n, of int64) string {
+    if of == 0 { return "0.00%" }
+    return strconv.FormatFloat(100*float64(n)/float64(of), 'f', 2, 64) + "%"
+}
@@ -0,0 +1,1500 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store.go -destination=mocks/mock_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	store "example.com/fraud/go_api/internal/store"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTransactionStore is a mock of TransactionStore interface.
+type MockTransactionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionStoreMockRecorder
+}
+
+// MockTransactionStoreMockRecorder is the mock recorder for MockTransactionStore.
+type MockTransactionStoreMockRecorder struct {
+	mock *MockTransactionStore
+}
+
+// NewMockTransactionStore creates a new mock instance.
+func NewMockTransactionStore(ctrl *gomock.Controller) *MockTransactionStore {
+	mock := &MockTransactionStore{ctrl: ctrl}
+	mock.recorder = &MockTransactionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionStore) EXPECT() *MockTransactionStoreMockRecorder {
+	return m.recorder
+}
+
+// AverageAmount mocks base method.
+func (m *MockTransactionStore) AverageAmount(ctx context.Context, userID string, since time.Time) (float64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AverageAmount", ctx, userID, since)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AverageAmount indicates an expected call of AverageAmount.
+func (mr *MockTransactionStoreMockRecorder) AverageAmount(ctx, userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AverageAmount", reflect.TypeOf((*MockTransactionStore)(nil).AverageAmount), ctx, userID, since)
+}
+
+// Get mocks base method.
+func (m *MockTransactionStore) Get(ctx context.Context, id string, from, to time.Time) (store.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id, from, to)
+	ret0, _ := ret[0].(store.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTransactionStoreMockRecorder) Get(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTransactionStore)(nil).Get), ctx, id, from, to)
+}
+
+// Insert mocks base method.
+func (m *MockTransactionStore) Insert(ctx context.Context, t store.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockTransactionStoreMockRecorder) Insert(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockTransactionStore)(nil).Insert), ctx, t)
+}
+
+// Range mocks base method.
+func (m *MockTransactionStore) Range(ctx context.Context, from, to time.Time, fn func(store.Transaction) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Range", ctx, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Range indicates an expected call of Range.
+func (mr *MockTransactionStoreMockRecorder) Range(ctx, from, to, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Range", reflect.TypeOf((*MockTransactionStore)(nil).Range), ctx, from, to, fn)
+}
+
+// RecordVerification mocks base method.
+func (m *MockTransactionStore) RecordVerification(ctx context.Context, id string, from, to time.Time, method, result, decision string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordVerification", ctx, id, from, to, method, result, decision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordVerification indicates an expected call of RecordVerification.
+func (mr *MockTransactionStoreMockRecorder) RecordVerification(ctx, id, from, to, method, result, decision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordVerification", reflect.TypeOf((*MockTransactionStore)(nil).RecordVerification), ctx, id, from, to, method, result, decision)
+}
+
+// SetStatus mocks base method.
+func (m *MockTransactionStore) SetStatus(ctx context.Context, id string, from, to time.Time, status string, only []string, actor, reason string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, from, to, status, only, actor, reason)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockTransactionStoreMockRecorder) SetStatus(ctx, id, from, to, status, only, actor, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockTransactionStore)(nil).SetStatus), ctx, id, from, to, status, only, actor, reason)
+}
+
+// Transactions mocks base method.
+func (m *MockTransactionStore) Transactions(ctx context.Context, userID, status string, from, to time.Time, page store.Page) ([]store.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transactions", ctx, userID, status, from, to, page)
+	ret0, _ := ret[0].([]store.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transactions indicates an expected call of Transactions.
+func (mr *MockTransactionStoreMockRecorder) Transactions(ctx, userID, status, from, to, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transactions", reflect.TypeOf((*MockTransactionStore)(nil).Transactions), ctx, userID, status, from, to, page)
+}
+
+// UpdateScore mocks base method.
+func (m *MockTransactionStore) UpdateScore(ctx context.Context, id string, from, to time.Time, score float64, isFraud bool, decision string, riskFactors []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScore", ctx, id, from, to, score, isFraud, decision, riskFactors)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateScore indicates an expected call of UpdateScore.
+func (mr *MockTransactionStoreMockRecorder) UpdateScore(ctx, id, from, to, score, isFraud, decision, riskFactors any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScore", reflect.TypeOf((*MockTransactionStore)(nil).UpdateScore), ctx, id, from, to, score, isFraud, decision, riskFactors)
+}
+
+// MockLifecycleStore is a mock of LifecycleStore interface.
+type MockLifecycleStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLifecycleStoreMockRecorder
+}
+
+// MockLifecycleStoreMockRecorder is the mock recorder for MockLifecycleStore.
+type MockLifecycleStoreMockRecorder struct {
+	mock *MockLifecycleStore
+}
+
+// NewMockLifecycleStore creates a new mock instance.
+func NewMockLifecycleStore(ctrl *gomock.Controller) *MockLifecycleStore {
+	mock := &MockLifecycleStore{ctrl: ctrl}
+	mock.recorder = &MockLifecycleStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLifecycleStore) EXPECT() *MockLifecycleStoreMockRecorder {
+	return m.recorder
+}
+
+// Events mocks base method.
+func (m *MockLifecycleStore) Events(ctx context.Context, id string) ([]store.LifecycleEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events", ctx, id)
+	ret0, _ := ret[0].([]store.LifecycleEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Events indicates an expected call of Events.
+func (mr *MockLifecycleStoreMockRecorder) Events(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockLifecycleStore)(nil).Events), ctx, id)
+}
+
+// RecordEvent mocks base method.
+func (m *MockLifecycleStore) RecordEvent(ctx context.Context, id string, from, to time.Time, e store.LifecycleEvent, apply func(store.Lifecycle, *store.LifecycleEvent) (store.Lifecycle, error)) (store.EventOutcome, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordEvent", ctx, id, from, to, e, apply)
+	ret0, _ := ret[0].(store.EventOutcome)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordEvent indicates an expected call of RecordEvent.
+func (mr *MockLifecycleStoreMockRecorder) RecordEvent(ctx, id, from, to, e, apply any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEvent", reflect.TypeOf((*MockLifecycleStore)(nil).RecordEvent), ctx, id, from, to, e, apply)
+}
+
+// MockUserStore is a mock of UserStore interface.
+type MockUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStoreMockRecorder
+}
+
+// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
+type MockUserStoreMockRecorder struct {
+	mock *MockUserStore
+}
+
+// NewMockUserStore creates a new mock instance.
+func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
+	mock := &MockUserStore{ctrl: ctrl}
+	mock.recorder = &MockUserStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
+	return m.recorder
+}
+
+// AdjustRisk mocks base method.
+func (m *MockUserStore) AdjustRisk(ctx context.Context, userID string, delta float64) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustRisk", ctx, userID, delta)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustRisk indicates an expected call of AdjustRisk.
+func (mr *MockUserStoreMockRecorder) AdjustRisk(ctx, userID, delta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustRisk", reflect.TypeOf((*MockUserStore)(nil).AdjustRisk), ctx, userID, delta)
+}
+
+// Ensure mocks base method.
+func (m *MockUserStore) Ensure(ctx context.Context, userID string, risk float64, createdAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ensure", ctx, userID, risk, createdAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ensure indicates an expected call of Ensure.
+func (mr *MockUserStoreMockRecorder) Ensure(ctx, userID, risk, createdAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ensure", reflect.TypeOf((*MockUserStore)(nil).Ensure), ctx, userID, risk, createdAt)
+}
+
+// HotUsers mocks base method.
+func (m *MockUserStore) HotUsers(ctx context.Context, activeSince, historySince time.Time, limit int) ([]store.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HotUsers", ctx, activeSince, historySince, limit)
+	ret0, _ := ret[0].([]store.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HotUsers indicates an expected call of HotUsers.
+func (mr *MockUserStoreMockRecorder) HotUsers(ctx, activeSince, historySince, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HotUsers", reflect.TypeOf((*MockUserStore)(nil).HotUsers), ctx, activeSince, historySince, limit)
+}
+
+// RiskScore mocks base method.
+func (m *MockUserStore) RiskScore(ctx context.Context, userID string) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RiskScore", ctx, userID)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RiskScore indicates an expected call of RiskScore.
+func (mr *MockUserStoreMockRecorder) RiskScore(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RiskScore", reflect.TypeOf((*MockUserStore)(nil).RiskScore), ctx, userID)
+}
+
+// Tenure mocks base method.
+func (m *MockUserStore) Tenure(ctx context.Context, userID string) (store.UserTenure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tenure", ctx, userID)
+	ret0, _ := ret[0].(store.UserTenure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tenure indicates an expected call of Tenure.
+func (mr *MockUserStoreMockRecorder) Tenure(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tenure", reflect.TypeOf((*MockUserStore)(nil).Tenure), ctx, userID)
+}
+
+// Users mocks base method.
+func (m *MockUserStore) Users(ctx context.Context, page store.Page) ([]store.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Users", ctx, page)
+	ret0, _ := ret[0].([]store.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Users indicates an expected call of Users.
+func (mr *MockUserStoreMockRecorder) Users(ctx, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Users", reflect.TypeOf((*MockUserStore)(nil).Users), ctx, page)
+}
+
+// MockMerchantStore is a mock of MerchantStore interface.
+type MockMerchantStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMerchantStoreMockRecorder
+}
+
+// MockMerchantStoreMockRecorder is the mock recorder for MockMerchantStore.
+type MockMerchantStoreMockRecorder struct {
+	mock *MockMerchantStore
+}
+
+// NewMockMerchantStore creates a new mock instance.
+func NewMockMerchantStore(ctrl *gomock.Controller) *MockMerchantStore {
+	mock := &MockMerchantStore{ctrl: ctrl}
+	mock.recorder = &MockMerchantStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMerchantStore) EXPECT() *MockMerchantStoreMockRecorder {
+	return m.recorder
+}
+
+// MCC mocks base method.
+func (m *MockMerchantStore) MCC(ctx context.Context, merchantID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MCC", ctx, merchantID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MCC indicates an expected call of MCC.
+func (mr *MockMerchantStoreMockRecorder) MCC(ctx, merchantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MCC", reflect.TypeOf((*MockMerchantStore)(nil).MCC), ctx, merchantID)
+}
+
+// MockKYCStore is a mock of KYCStore interface.
+type MockKYCStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockKYCStoreMockRecorder
+}
+
+// MockKYCStoreMockRecorder is the mock recorder for MockKYCStore.
+type MockKYCStoreMockRecorder struct {
+	mock *MockKYCStore
+}
+
+// NewMockKYCStore creates a new mock instance.
+func NewMockKYCStore(ctrl *gomock.Controller) *MockKYCStore {
+	mock := &MockKYCStore{ctrl: ctrl}
+	mock.recorder = &MockKYCStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKYCStore) EXPECT() *MockKYCStoreMockRecorder {
+	return m.recorder
+}
+
+// KYCStatus mocks base method.
+func (m *MockKYCStore) KYCStatus(ctx context.Context, userID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KYCStatus", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KYCStatus indicates an expected call of KYCStatus.
+func (mr *MockKYCStoreMockRecorder) KYCStatus(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KYCStatus", reflect.TypeOf((*MockKYCStore)(nil).KYCStatus), ctx, userID)
+}
+
+// SetKYCStatus mocks base method.
+func (m *MockKYCStore) SetKYCStatus(ctx context.Context, userID, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetKYCStatus", ctx, userID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetKYCStatus indicates an expected call of SetKYCStatus.
+func (mr *MockKYCStoreMockRecorder) SetKYCStatus(ctx, userID, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKYCStatus", reflect.TypeOf((*MockKYCStore)(nil).SetKYCStatus), ctx, userID, status)
+}
+
+// MockRuleStore is a mock of RuleStore interface.
+type MockRuleStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRuleStoreMockRecorder
+}
+
+// MockRuleStoreMockRecorder is the mock recorder for MockRuleStore.
+type MockRuleStoreMockRecorder struct {
+	mock *MockRuleStore
+}
+
+// NewMockRuleStore creates a new mock instance.
+func NewMockRuleStore(ctrl *gomock.Controller) *MockRuleStore {
+	mock := &MockRuleStore{ctrl: ctrl}
+	mock.recorder = &MockRuleStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRuleStore) EXPECT() *MockRuleStoreMockRecorder {
+	return m.recorder
+}
+
+// Label mocks base method.
+func (m *MockRuleStore) Label(ctx context.Context, transactionID string, isFraud bool, source string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Label", ctx, transactionID, isFraud, source)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Label indicates an expected call of Label.
+func (mr *MockRuleStoreMockRecorder) Label(ctx, transactionID, isFraud, source any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Label", reflect.TypeOf((*MockRuleStore)(nil).Label), ctx, transactionID, isFraud, source)
+}
+
+// LabeledScores mocks base method.
+func (m *MockRuleStore) LabeledScores(ctx context.Context, since time.Time) ([]store.LabeledScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LabeledScores", ctx, since)
+	ret0, _ := ret[0].([]store.LabeledScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LabeledScores indicates an expected call of LabeledScores.
+func (mr *MockRuleStoreMockRecorder) LabeledScores(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LabeledScores", reflect.TypeOf((*MockRuleStore)(nil).LabeledScores), ctx, since)
+}
+
+// RuleStats mocks base method.
+func (m *MockRuleStore) RuleStats(ctx context.Context, rule string, since time.Time) (store.RuleStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RuleStats", ctx, rule, since)
+	ret0, _ := ret[0].(store.RuleStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RuleStats indicates an expected call of RuleStats.
+func (mr *MockRuleStoreMockRecorder) RuleStats(ctx, rule, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RuleStats", reflect.TypeOf((*MockRuleStore)(nil).RuleStats), ctx, rule, since)
+}
+
+// MockBlocklistStore is a mock of BlocklistStore interface.
+type MockBlocklistStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlocklistStoreMockRecorder
+}
+
+// MockBlocklistStoreMockRecorder is the mock recorder for MockBlocklistStore.
+type MockBlocklistStoreMockRecorder struct {
+	mock *MockBlocklistStore
+}
+
+// NewMockBlocklistStore creates a new mock instance.
+func NewMockBlocklistStore(ctrl *gomock.Controller) *MockBlocklistStore {
+	mock := &MockBlocklistStore{ctrl: ctrl}
+	mock.recorder = &MockBlocklistStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlocklistStore) EXPECT() *MockBlocklistStoreMockRecorder {
+	return m.recorder
+}
+
+// AddBlocklistEntry mocks base method.
+func (m *MockBlocklistStore) AddBlocklistEntry(ctx context.Context, e store.BlocklistEntry) (store.BlocklistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBlocklistEntry", ctx, e)
+	ret0, _ := ret[0].(store.BlocklistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddBlocklistEntry indicates an expected call of AddBlocklistEntry.
+func (mr *MockBlocklistStoreMockRecorder) AddBlocklistEntry(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBlocklistEntry", reflect.TypeOf((*MockBlocklistStore)(nil).AddBlocklistEntry), ctx, e)
+}
+
+// Blocklist mocks base method.
+func (m *MockBlocklistStore) Blocklist(ctx context.Context) ([]store.BlocklistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Blocklist", ctx)
+	ret0, _ := ret[0].([]store.BlocklistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Blocklist indicates an expected call of Blocklist.
+func (mr *MockBlocklistStoreMockRecorder) Blocklist(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Blocklist", reflect.TypeOf((*MockBlocklistStore)(nil).Blocklist), ctx)
+}
+
+// DeleteBlocklistEntry mocks base method.
+func (m *MockBlocklistStore) DeleteBlocklistEntry(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlocklistEntry", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlocklistEntry indicates an expected call of DeleteBlocklistEntry.
+func (mr *MockBlocklistStoreMockRecorder) DeleteBlocklistEntry(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlocklistEntry", reflect.TypeOf((*MockBlocklistStore)(nil).DeleteBlocklistEntry), ctx, id)
+}
+
+// MockLimitStore is a mock of LimitStore interface.
+type MockLimitStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLimitStoreMockRecorder
+}
+
+// MockLimitStoreMockRecorder is the mock recorder for MockLimitStore.
+type MockLimitStoreMockRecorder struct {
+	mock *MockLimitStore
+}
+
+// NewMockLimitStore creates a new mock instance.
+func NewMockLimitStore(ctrl *gomock.Controller) *MockLimitStore {
+	mock := &MockLimitStore{ctrl: ctrl}
+	mock.recorder = &MockLimitStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLimitStore) EXPECT() *MockLimitStoreMockRecorder {
+	return m.recorder
+}
+
+// SetSpendLimits mocks base method.
+func (m *MockLimitStore) SetSpendLimits(ctx context.Context, userID string, l store.SpendLimits) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSpendLimits", ctx, userID, l)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSpendLimits indicates an expected call of SetSpendLimits.
+func (mr *MockLimitStoreMockRecorder) SetSpendLimits(ctx, userID, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSpendLimits", reflect.TypeOf((*MockLimitStore)(nil).SetSpendLimits), ctx, userID, l)
+}
+
+// SpendLimits mocks base method.
+func (m *MockLimitStore) SpendLimits(ctx context.Context, userID string) (store.SpendLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendLimits", ctx, userID)
+	ret0, _ := ret[0].(store.SpendLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SpendLimits indicates an expected call of SpendLimits.
+func (mr *MockLimitStoreMockRecorder) SpendLimits(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendLimits", reflect.TypeOf((*MockLimitStore)(nil).SpendLimits), ctx, userID)
+}
+
+// MockTravelStore is a mock of TravelStore interface.
+type MockTravelStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTravelStoreMockRecorder
+}
+
+// MockTravelStoreMockRecorder is the mock recorder for MockTravelStore.
+type MockTravelStoreMockRecorder struct {
+	mock *MockTravelStore
+}
+
+// NewMockTravelStore creates a new mock instance.
+func NewMockTravelStore(ctrl *gomock.Controller) *MockTravelStore {
+	mock := &MockTravelStore{ctrl: ctrl}
+	mock.recorder = &MockTravelStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTravelStore) EXPECT() *MockTravelStoreMockRecorder {
+	return m.recorder
+}
+
+// AddTravelNotice mocks base method.
+func (m *MockTravelStore) AddTravelNotice(ctx context.Context, n store.TravelNotice) (store.TravelNotice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTravelNotice", ctx, n)
+	ret0, _ := ret[0].(store.TravelNotice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddTravelNotice indicates an expected call of AddTravelNotice.
+func (mr *MockTravelStoreMockRecorder) AddTravelNotice(ctx, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTravelNotice", reflect.TypeOf((*MockTravelStore)(nil).AddTravelNotice), ctx, n)
+}
+
+// DeleteTravelNotice mocks base method.
+func (m *MockTravelStore) DeleteTravelNotice(ctx context.Context, userID string, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTravelNotice", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTravelNotice indicates an expected call of DeleteTravelNotice.
+func (mr *MockTravelStoreMockRecorder) DeleteTravelNotice(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTravelNotice", reflect.TypeOf((*MockTravelStore)(nil).DeleteTravelNotice), ctx, userID, id)
+}
+
+// TravelNotices mocks base method.
+func (m *MockTravelStore) TravelNotices(ctx context.Context, userID string, now time.Time) ([]store.TravelNotice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TravelNotices", ctx, userID, now)
+	ret0, _ := ret[0].([]store.TravelNotice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TravelNotices indicates an expected call of TravelNotices.
+func (mr *MockTravelStoreMockRecorder) TravelNotices(ctx, userID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TravelNotices", reflect.TypeOf((*MockTravelStore)(nil).TravelNotices), ctx, userID, now)
+}
+
+// MockAlertStore is a mock of AlertStore interface.
+type MockAlertStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlertStoreMockRecorder
+}
+
+// MockAlertStoreMockRecorder is the mock recorder for MockAlertStore.
+type MockAlertStoreMockRecorder struct {
+	mock *MockAlertStore
+}
+
+// NewMockAlertStore creates a new mock instance.
+func NewMockAlertStore(ctrl *gomock.Controller) *MockAlertStore {
+	mock := &MockAlertStore{ctrl: ctrl}
+	mock.recorder = &MockAlertStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlertStore) EXPECT() *MockAlertStoreMockRecorder {
+	return m.recorder
+}
+
+// AddComment mocks base method.
+func (m *MockAlertStore) AddComment(ctx context.Context, c store.AlertComment) (store.AlertComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddComment", ctx, c)
+	ret0, _ := ret[0].(store.AlertComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddComment indicates an expected call of AddComment.
+func (mr *MockAlertStoreMockRecorder) AddComment(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockAlertStore)(nil).AddComment), ctx, c)
+}
+
+// Assign mocks base method.
+func (m *MockAlertStore) Assign(ctx context.Context, alertID, assignee, actor string) (store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assign", ctx, alertID, assignee, actor)
+	ret0, _ := ret[0].(store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Assign indicates an expected call of Assign.
+func (mr *MockAlertStoreMockRecorder) Assign(ctx, alertID, assignee, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockAlertStore)(nil).Assign), ctx, alertID, assignee, actor)
+}
+
+// BreachSLA mocks base method.
+func (m *MockAlertStore) BreachSLA(ctx context.Context, severity string, before time.Time, limit int) ([]store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BreachSLA", ctx, severity, before, limit)
+	ret0, _ := ret[0].([]store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BreachSLA indicates an expected call of BreachSLA.
+func (mr *MockAlertStoreMockRecorder) BreachSLA(ctx, severity, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BreachSLA", reflect.TypeOf((*MockAlertStore)(nil).BreachSLA), ctx, severity, before, limit)
+}
+
+// CloseStale mocks base method.
+func (m *MockAlertStore) CloseStale(ctx context.Context, severities []string, before time.Time, limit int) ([]store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseStale", ctx, severities, before, limit)
+	ret0, _ := ret[0].([]store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseStale indicates an expected call of CloseStale.
+func (mr *MockAlertStoreMockRecorder) CloseStale(ctx, severities, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseStale", reflect.TypeOf((*MockAlertStore)(nil).CloseStale), ctx, severities, before, limit)
+}
+
+// Comments mocks base method.
+func (m *MockAlertStore) Comments(ctx context.Context, alertID string) ([]store.AlertComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Comments", ctx, alertID)
+	ret0, _ := ret[0].([]store.AlertComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Comments indicates an expected call of Comments.
+func (mr *MockAlertStoreMockRecorder) Comments(ctx, alertID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Comments", reflect.TypeOf((*MockAlertStore)(nil).Comments), ctx, alertID)
+}
+
+// Escalate mocks base method.
+func (m *MockAlertStore) Escalate(ctx context.Context, a store.Alert, since time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Escalate", ctx, a, since)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Escalate indicates an expected call of Escalate.
+func (mr *MockAlertStoreMockRecorder) Escalate(ctx, a, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Escalate", reflect.TypeOf((*MockAlertStore)(nil).Escalate), ctx, a, since)
+}
+
+// List mocks base method.
+func (m *MockAlertStore) List(ctx context.Context, status string, page store.Page) ([]store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, page)
+	ret0, _ := ret[0].([]store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAlertStoreMockRecorder) List(ctx, status, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAlertStore)(nil).List), ctx, status, page)
+}
+
+// Resolve mocks base method.
+func (m *MockAlertStore) Resolve(ctx context.Context, alertID, analyst, resolution string) (store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, alertID, analyst, resolution)
+	ret0, _ := ret[0].(store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockAlertStoreMockRecorder) Resolve(ctx, alertID, analyst, resolution any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockAlertStore)(nil).Resolve), ctx, alertID, analyst, resolution)
+}
+
+// MockSuppressionStore is a mock of SuppressionStore interface.
+type MockSuppressionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSuppressionStoreMockRecorder
+}
+
+// MockSuppressionStoreMockRecorder is the mock recorder for MockSuppressionStore.
+type MockSuppressionStoreMockRecorder struct {
+	mock *MockSuppressionStore
+}
+
+// NewMockSuppressionStore creates a new mock instance.
+func NewMockSuppressionStore(ctrl *gomock.Controller) *MockSuppressionStore {
+	mock := &MockSuppressionStore{ctrl: ctrl}
+	mock.recorder = &MockSuppressionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSuppressionStore) EXPECT() *MockSuppressionStoreMockRecorder {
+	return m.recorder
+}
+
+// AddSuppression mocks base method.
+func (m *MockSuppressionStore) AddSuppression(ctx context.Context, s store.Suppression) (store.Suppression, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSuppression", ctx, s)
+	ret0, _ := ret[0].(store.Suppression)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddSuppression indicates an expected call of AddSuppression.
+func (mr *MockSuppressionStoreMockRecorder) AddSuppression(ctx, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSuppression", reflect.TypeOf((*MockSuppressionStore)(nil).AddSuppression), ctx, s)
+}
+
+// EndSuppression mocks base method.
+func (m *MockSuppressionStore) EndSuppression(ctx context.Context, id int64, actor string) (store.Suppression, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndSuppression", ctx, id, actor)
+	ret0, _ := ret[0].(store.Suppression)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndSuppression indicates an expected call of EndSuppression.
+func (mr *MockSuppressionStoreMockRecorder) EndSuppression(ctx, id, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndSuppression", reflect.TypeOf((*MockSuppressionStore)(nil).EndSuppression), ctx, id, actor)
+}
+
+// ExpireSuppressions mocks base method.
+func (m *MockSuppressionStore) ExpireSuppressions(ctx context.Context, now time.Time) ([]store.Suppression, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireSuppressions", ctx, now)
+	ret0, _ := ret[0].([]store.Suppression)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireSuppressions indicates an expected call of ExpireSuppressions.
+func (mr *MockSuppressionStoreMockRecorder) ExpireSuppressions(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireSuppressions", reflect.TypeOf((*MockSuppressionStore)(nil).ExpireSuppressions), ctx, now)
+}
+
+// Suppressions mocks base method.
+func (m *MockSuppressionStore) Suppressions(ctx context.Context, live bool) ([]store.Suppression, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suppressions", ctx, live)
+	ret0, _ := ret[0].([]store.Suppression)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Suppressions indicates an expected call of Suppressions.
+func (mr *MockSuppressionStoreMockRecorder) Suppressions(ctx, live any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suppressions", reflect.TypeOf((*MockSuppressionStore)(nil).Suppressions), ctx, live)
+}
+
+// MockAuditStore is a mock of AuditStore interface.
+type MockAuditStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditStoreMockRecorder
+}
+
+// MockAuditStoreMockRecorder is the mock recorder for MockAuditStore.
+type MockAuditStoreMockRecorder struct {
+	mock *MockAuditStore
+}
+
+// NewMockAuditStore creates a new mock instance.
+func NewMockAuditStore(ctrl *gomock.Controller) *MockAuditStore {
+	mock := &MockAuditStore{ctrl: ctrl}
+	mock.recorder = &MockAuditStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditStore) EXPECT() *MockAuditStoreMockRecorder {
+	return m.recorder
+}
+
+// AuditLog mocks base method.
+func (m *MockAuditStore) AuditLog(ctx context.Context, entityType, entityID string, page store.Page) ([]store.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditLog", ctx, entityType, entityID, page)
+	ret0, _ := ret[0].([]store.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuditLog indicates an expected call of AuditLog.
+func (mr *MockAuditStoreMockRecorder) AuditLog(ctx, entityType, entityID, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditLog", reflect.TypeOf((*MockAuditStore)(nil).AuditLog), ctx, entityType, entityID, page)
+}
+
+// MockStatsStore is a mock of StatsStore interface.
+type MockStatsStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsStoreMockRecorder
+}
+
+// MockStatsStoreMockRecorder is the mock recorder for MockStatsStore.
+type MockStatsStoreMockRecorder struct {
+	mock *MockStatsStore
+}
+
+// NewMockStatsStore creates a new mock instance.
+func NewMockStatsStore(ctrl *gomock.Controller) *MockStatsStore {
+	mock := &MockStatsStore{ctrl: ctrl}
+	mock.recorder = &MockStatsStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsStore) EXPECT() *MockStatsStoreMockRecorder {
+	return m.recorder
+}
+
+// GeoBuckets mocks base method.
+func (m *MockStatsStore) GeoBuckets(ctx context.Context, by string, precision int, from, to time.Time, limit int) ([]store.GeoBucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GeoBuckets", ctx, by, precision, from, to, limit)
+	ret0, _ := ret[0].([]store.GeoBucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GeoBuckets indicates an expected call of GeoBuckets.
+func (mr *MockStatsStoreMockRecorder) GeoBuckets(ctx, by, precision, from, to, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeoBuckets", reflect.TypeOf((*MockStatsStore)(nil).GeoBuckets), ctx, by, precision, from, to, limit)
+}
+
+// RollUp mocks base method.
+func (m *MockStatsStore) RollUp(ctx context.Context, from, to time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollUp", ctx, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollUp indicates an expected call of RollUp.
+func (mr *MockStatsStoreMockRecorder) RollUp(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollUp", reflect.TypeOf((*MockStatsStore)(nil).RollUp), ctx, from, to)
+}
+
+// TopEntities mocks base method.
+func (m *MockStatsStore) TopEntities(ctx context.Context, entityType, metric string, from, to time.Time, minTransactions int64, limit int) ([]store.EntityRisk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopEntities", ctx, entityType, metric, from, to, minTransactions, limit)
+	ret0, _ := ret[0].([]store.EntityRisk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopEntities indicates an expected call of TopEntities.
+func (mr *MockStatsStoreMockRecorder) TopEntities(ctx, entityType, metric, from, to, minTransactions, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopEntities", reflect.TypeOf((*MockStatsStore)(nil).TopEntities), ctx, entityType, metric, from, to, minTransactions, limit)
+}
+
+// MockAnalystStore is a mock of AnalystStore interface.
+type MockAnalystStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnalystStoreMockRecorder
+}
+
+// MockAnalystStoreMockRecorder is the mock recorder for MockAnalystStore.
+type MockAnalystStoreMockRecorder struct {
+	mock *MockAnalystStore
+}
+
+// NewMockAnalystStore creates a new mock instance.
+func NewMockAnalystStore(ctrl *gomock.Controller) *MockAnalystStore {
+	mock := &MockAnalystStore{ctrl: ctrl}
+	mock.recorder = &MockAnalystStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnalystStore) EXPECT() *MockAnalystStoreMockRecorder {
+	return m.recorder
+}
+
+// AnalystStats mocks base method.
+func (m *MockAnalystStore) AnalystStats(ctx context.Context, since time.Time) ([]store.AnalystStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnalystStats", ctx, since)
+	ret0, _ := ret[0].([]store.AnalystStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnalystStats indicates an expected call of AnalystStats.
+func (mr *MockAnalystStoreMockRecorder) AnalystStats(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnalystStats", reflect.TypeOf((*MockAnalystStore)(nil).AnalystStats), ctx, since)
+}
+
+// Closures mocks base method.
+func (m *MockAnalystStore) Closures(ctx context.Context, since time.Time) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Closures", ctx, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Closures indicates an expected call of Closures.
+func (mr *MockAnalystStoreMockRecorder) Closures(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Closures", reflect.TypeOf((*MockAnalystStore)(nil).Closures), ctx, since)
+}
+
+// Queue mocks base method.
+func (m *MockAnalystStore) Queue(ctx context.Context) ([]store.QueueDepth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Queue", ctx)
+	ret0, _ := ret[0].([]store.QueueDepth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Queue indicates an expected call of Queue.
+func (mr *MockAnalystStoreMockRecorder) Queue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Queue", reflect.TypeOf((*MockAnalystStore)(nil).Queue), ctx)
+}
+
+// MockOutboxStore is a mock of OutboxStore interface.
+type MockOutboxStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxStoreMockRecorder
+}
+
+// MockOutboxStoreMockRecorder is the mock recorder for MockOutboxStore.
+type MockOutboxStoreMockRecorder struct {
+	mock *MockOutboxStore
+}
+
+// NewMockOutboxStore creates a new mock instance.
+func NewMockOutboxStore(ctrl *gomock.Controller) *MockOutboxStore {
+	mock := &MockOutboxStore{ctrl: ctrl}
+	mock.recorder = &MockOutboxStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxStore) EXPECT() *MockOutboxStoreMockRecorder {
+	return m.recorder
+}
+
+// Pending mocks base method.
+func (m *MockOutboxStore) Pending(ctx context.Context, topic string, limit int) ([]store.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pending", ctx, topic, limit)
+	ret0, _ := ret[0].([]store.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pending indicates an expected call of Pending.
+func (mr *MockOutboxStoreMockRecorder) Pending(ctx, topic, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockOutboxStore)(nil).Pending), ctx, topic, limit)
+}
+
+// Relay mocks base method.
+func (m *MockOutboxStore) Relay(ctx context.Context, topic string, limit int, publish func(store.OutboxMessage) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relay", ctx, topic, limit, publish)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Relay indicates an expected call of Relay.
+func (mr *MockOutboxStoreMockRecorder) Relay(ctx, topic, limit, publish any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relay", reflect.TypeOf((*MockOutboxStore)(nil).Relay), ctx, topic, limit, publish)
+}
+
+// Spill mocks base method.
+func (m *MockOutboxStore) Spill(ctx context.Context, topic string, key, payload []byte, contentType, cause string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Spill", ctx, topic, key, payload, contentType, cause)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Spill indicates an expected call of Spill.
+func (mr *MockOutboxStoreMockRecorder) Spill(ctx, topic, key, payload, contentType, cause any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Spill", reflect.TypeOf((*MockOutboxStore)(nil).Spill), ctx, topic, key, payload, contentType, cause)
+}
+
+// MockCaseStore is a mock of CaseStore interface.
+type MockCaseStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaseStoreMockRecorder
+}
+
+// MockCaseStoreMockRecorder is the mock recorder for MockCaseStore.
+type MockCaseStoreMockRecorder struct {
+	mock *MockCaseStore
+}
+
+// NewMockCaseStore creates a new mock instance.
+func NewMockCaseStore(ctrl *gomock.Controller) *MockCaseStore {
+	mock := &MockCaseStore{ctrl: ctrl}
+	mock.recorder = &MockCaseStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaseStore) EXPECT() *MockCaseStoreMockRecorder {
+	return m.recorder
+}
+
+// Alert mocks base method.
+func (m *MockCaseStore) Alert(ctx context.Context, alertID string) (store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Alert", ctx, alertID)
+	ret0, _ := ret[0].(store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Alert indicates an expected call of Alert.
+func (mr *MockCaseStoreMockRecorder) Alert(ctx, alertID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Alert", reflect.TypeOf((*MockCaseStore)(nil).Alert), ctx, alertID)
+}
+
+// Case mocks base method.
+func (m *MockCaseStore) Case(ctx context.Context, id int64) (store.Case, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Case", ctx, id)
+	ret0, _ := ret[0].(store.Case)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Case indicates an expected call of Case.
+func (mr *MockCaseStoreMockRecorder) Case(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Case", reflect.TypeOf((*MockCaseStore)(nil).Case), ctx, id)
+}
+
+// CaseAlerts mocks base method.
+func (m *MockCaseStore) CaseAlerts(ctx context.Context, id int64) ([]store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaseAlerts", ctx, id)
+	ret0, _ := ret[0].([]store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaseAlerts indicates an expected call of CaseAlerts.
+func (mr *MockCaseStoreMockRecorder) CaseAlerts(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaseAlerts", reflect.TypeOf((*MockCaseStore)(nil).CaseAlerts), ctx, id)
+}
+
+// Cases mocks base method.
+func (m *MockCaseStore) Cases(ctx context.Context, status string, limit int) ([]store.Case, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cases", ctx, status, limit)
+	ret0, _ := ret[0].([]store.Case)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cases indicates an expected call of Cases.
+func (mr *MockCaseStoreMockRecorder) Cases(ctx, status, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cases", reflect.TypeOf((*MockCaseStore)(nil).Cases), ctx, status, limit)
+}
+
+// CloseCase mocks base method.
+func (m *MockCaseStore) CloseCase(ctx context.Context, id int64, analyst string) (store.Case, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseCase", ctx, id, analyst)
+	ret0, _ := ret[0].(store.Case)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseCase indicates an expected call of CloseCase.
+func (mr *MockCaseStoreMockRecorder) CloseCase(ctx, id, analyst any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseCase", reflect.TypeOf((*MockCaseStore)(nil).CloseCase), ctx, id, analyst)
+}
+
+// UserActivity mocks base method.
+func (m *MockCaseStore) UserActivity(ctx context.Context, userID string, from, to time.Time, limit int) ([]store.CaseTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserActivity", ctx, userID, from, to, limit)
+	ret0, _ := ret[0].([]store.CaseTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserActivity indicates an expected call of UserActivity.
+func (mr *MockCaseStoreMockRecorder) UserActivity(ctx, userID, from, to, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserActivity", reflect.TypeOf((*MockCaseStore)(nil).UserActivity), ctx, userID, from, to, limit)
+}
+
+// UserAlerts mocks base method.
+func (m *MockCaseStore) UserAlerts(ctx context.Context, userID string, from, to time.Time) ([]store.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserAlerts", ctx, userID, from, to)
+	ret0, _ := ret[0].([]store.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserAlerts indicates an expected call of UserAlerts.
+func (mr *MockCaseStoreMockRecorder) UserAlerts(ctx, userID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserAlerts", reflect.TypeOf((*MockCaseStore)(nil).UserAlerts), ctx, userID, from, to)
+}
+
+// MockReportStore is a mock of ReportStore interface.
+type MockReportStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportStoreMockRecorder
+}
+
+// MockReportStoreMockRecorder is the mock recorder for MockReportStore.
+type MockReportStoreMockRecorder struct {
+	mock *MockReportStore
+}
+
+// NewMockReportStore creates a new mock instance.
+func NewMockReportStore(ctrl *gomock.Controller) *MockReportStore {
+	mock := &MockReportStore{ctrl: ctrl}
+	mock.recorder = &MockReportStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportStore) EXPECT() *MockReportStoreMockRecorder {
+	return m.recorder
+}
+
+// AlertDispositions mocks base method.
+func (m *MockReportStore) AlertDispositions(ctx context.Context, from, to time.Time) ([]store.AlertDisposition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AlertDispositions", ctx, from, to)
+	ret0, _ := ret[0].([]store.AlertDisposition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AlertDispositions indicates an expected call of AlertDispositions.
+func (mr *MockReportStoreMockRecorder) AlertDispositions(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AlertDispositions", reflect.TypeOf((*MockReportStore)(nil).AlertDispositions), ctx, from, to)
+}
+
+// FraudSummary mocks base method.
+func (m *MockReportStore) FraudSummary(ctx context.Context, from, to time.Time) (store.FraudSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FraudSummary", ctx, from, to)
+	ret0, _ := ret[0].(store.FraudSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FraudSummary indicates an expected call of FraudSummary.
+func (mr *MockReportStoreMockRecorder) FraudSummary(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FraudSummary", reflect.TypeOf((*MockReportStore)(nil).FraudSummary), ctx, from, to)
+}
+
+// MockExportStore is a mock of ExportStore interface.
+type MockExportStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportStoreMockRecorder
+}
+
+// MockExportStoreMockRecorder is the mock recorder for MockExportStore.
+type MockExportStoreMockRecorder struct {
+	mock *MockExportStore
+}
+
+// NewMockExportStore creates a new mock instance.
+func NewMockExportStore(ctrl *gomock.Controller) *MockExportStore {
+	mock := &MockExportStore{ctrl: ctrl}
+	mock.recorder = &MockExportStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportStore) EXPECT() *MockExportStoreMockRecorder {
+	return m.recorder
+}
+
+// AdvanceWatermark mocks base method.
+func (m *MockExportStore) AdvanceWatermark(ctx context.Context, destination string, w store.Watermark, rows int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceWatermark", ctx, destination, w, rows)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdvanceWatermark indicates an expected call of AdvanceWatermark.
+func (mr *MockExportStoreMockRecorder) AdvanceWatermark(ctx, destination, w, rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceWatermark", reflect.TypeOf((*MockExportStore)(nil).AdvanceWatermark), ctx, destination, w, rows)
+}
+
+// Changed mocks base method.
+func (m *MockExportStore) Changed(ctx context.Context, t store.ExportTable, w store.Watermark, until time.Time, limit int) ([]map[string]any, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changed", ctx, t, w, until, limit)
+	ret0, _ := ret[0].([]map[string]any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Changed indicates an expected call of Changed.
+func (mr *MockExportStoreMockRecorder) Changed(ctx, t, w, until, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changed", reflect.TypeOf((*MockExportStore)(nil).Changed), ctx, t, w, until, limit)
+}
+
+// Watermarks mocks base method.
+func (m *MockExportStore) Watermarks(ctx context.Context, destination string) ([]store.Watermark, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watermarks", ctx, destination)
+	ret0, _ := ret[0].([]store.Watermark)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watermarks indicates an expected call of Watermarks.
+func (mr *MockExportStoreMockRecorder) Watermarks(ctx, destination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watermarks", reflect.TypeOf((*MockExportStore)(nil).Watermarks), ctx, destination)
+}
+
+// MockPartitionStore is a mock of PartitionStore interface.
+type MockPartitionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockPartitionStoreMockRecorder
+}
+
+// MockPartitionStoreMockRecorder is the mock recorder for MockPartitionStore.
+type MockPartitionStoreMockRecorder struct {
+	mock *MockPartitionStore
+}
+
+// NewMockPartitionStore creates a new mock instance.
+func NewMockPartitionStore(ctrl *gomock.Controller) *MockPartitionStore {
+	mock := &MockPartitionStore{ctrl: ctrl}
+	mock.recorder = &MockPartitionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPartitionStore) EXPECT() *MockPartitionStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateMonthlyPartition mocks base method.
+func (m *MockPartitionStore) CreateMonthlyPartition(ctx context.Context, table string, month time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMonthlyPartition", ctx, table, month)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMonthlyPartition indicates an expected call of CreateMonthlyPartition.
+func (mr *MockPartitionStoreMockRecorder) CreateMonthlyPartition(ctx, table, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMonthlyPartition", reflect.TypeOf((*MockPartitionStore)(nil).CreateMonthlyPartition), ctx, table, month)
+}
+
+// DropPartitionsBefore mocks base method.
+func (m *MockPartitionStore) DropPartitionsBefore(ctx context.Context, table string, cutoff time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropPartitionsBefore", ctx, table, cutoff)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DropPartitionsBefore indicates an expected call of DropPartitionsBefore.
+func (mr *MockPartitionStoreMockRecorder) DropPartitionsBefore(ctx, table, cutoff any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropPartitionsBefore", reflect.TypeOf((*MockPartitionStore)(nil).DropPartitionsBefore), ctx, table, cutoff)
+}
+
+// MockUsageStore is a mock of UsageStore interface.
+type MockUsageStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsageStoreMockRecorder
+}
+
+// MockUsageStoreMockRecorder is the mock recorder for MockUsageStore.
+type MockUsageStoreMockRecorder struct {
+	mock *MockUsageStore
+}
+
+// NewMockUsageStore creates a new mock instance.
+func NewMockUsageStore(ctrl *gomock.Controller) *MockUsageStore {
+	mock := &MockUsageStore{ctrl: ctrl}
+	mock.recorder = &MockUsageStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsageStore) EXPECT() *MockUsageStoreMockRecorder {
+	return m.recorder
+}
+
+// AddUsage mocks base method.
+func (m *MockUsageStore) AddUsage(ctx context.Context, usage []store.APIKeyUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUsage", ctx, usage)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUsage indicates an expected call of AddUsage.
+func (mr *MockUsageStoreMockRecorder) AddUsage(ctx, usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsage", reflect.TypeOf((*MockUsageStore)(nil).AddUsage), ctx, usage)
+}
+
+// Usage mocks base method.
+func (m *MockUsageStore) Usage(ctx context.Context, apiKey string, from, to time.Time) ([]store.APIKeyUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx, apiKey, from, to)
+	ret0, _ := ret[0].([]store.APIKeyUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockUsageStoreMockRecorder) Usage(ctx, apiKey, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockUsageStore)(nil).Usage), ctx, apiKey, from, to)
+}
+
+// UsageByKey mocks base method.
+func (m *MockUsageStore) UsageByKey(ctx context.Context, from, to time.Time) ([]store.APIKeyUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsageByKey", ctx, from, to)
+	ret0, _ := ret[0].([]store.APIKeyUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsageByKey indicates an expected call of UsageByKey.
+func (mr *MockUsageStoreMockRecorder) UsageByKey(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsageByKey", reflect.TypeOf((*MockUsageStore)(nil).UsageByKey), ctx, from, to)
+}
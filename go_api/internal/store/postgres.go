@@ -0,0 +1,1108 @@
+package store
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+
+    "example.com/fraud/internal/events"
+)
+
+// Postgres implements every store interface on a pgx pool. Reads made with a
+// context from ReadOnly go to the replica returned by replica, when it
+// returns one.
+type Postgres struct {
+    primary *pgxpool.Pool
+    replica func() *pgxpool.Pool
+}
+
+func NewPostgres(primary *pgxpool.Pool, replica func() *pgxpool.Pool) *Postgres {
+    return &Postgres{primary: primary, replica: replica}
+}
+
+type readOnlyKey struct{}
+
+// ReadOnly marks ctx as tolerating replica reads.
+func ReadOnly(ctx context.Context) context.Context { return context.WithValue(ctx, readOnlyKey{}, true) }
+
+func (p *Postgres) reader(ctx context.Context) *pgxpool.Pool {
+    if ro, _ := ctx.Value(readOnlyKey{}).(bool); ro && p.replica != nil {
+        if r := p.replica(); r != nil { return r }
+    }
+    return p.primary
+}
+
+func (p *Postgres) Insert(ctx context.Context, t Transaction) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO transactions (transaction_id, user_id, amount, timestamp, merchant_id, merchant_risk, mcc, channel, country, behavioral_score, session_id, fraud_score, is_fraud, decision, risk_factors,
+                                                             location_lat, location_lon, geohash, ip_country) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)`,
+        t.TransactionID, t.UserID, t.Amount, t.Timestamp, t.MerchantID, t.MerchantRisk, t.MCC, t.Channel, t.Country, t.BehavioralScore, t.SessionID, t.FraudScore, t.IsFraud, t.Decision, t.RiskFactors,
+        t.LocationLat, t.LocationLon, t.Geohash, t.IPCountry)
+    return err
+}
+
+func (p *Postgres) Get(ctx context.Context, id string, from, to time.Time) (Transaction, error) {
+    var t Transaction
+    err := p.reader(ctx).QueryRow(ctx, `SELECT transaction_id, user_id, amount, timestamp, merchant_id, merchant_risk, mcc, channel, country, behavioral_score, session_id, fraud_score, is_fraud, decision, risk_factors, verification_result, updated_at,
+                                               status, lifecycle_status, captured_amount, refunded_amount FROM transactions
+                                        WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3`, id, from, to).
+        Scan(&t.TransactionID, &t.UserID, &t.Amount, &t.Timestamp, &t.MerchantID, &t.MerchantRisk, &t.MCC, &t.Channel, &t.Country, &t.BehavioralScore, &t.SessionID, &t.FraudScore, &t.IsFraud, &t.Decision, &t.RiskFactors, &t.VerificationResult, &t.UpdatedAt,
+            &t.Status, &t.LifecycleStatus, &t.CapturedAmount, &t.RefundedAmount)
+    if errors.Is(err, pgx.ErrNoRows) { return t, ErrNotFound }
+    return t, err
+}
+
+func (p *Postgres) AverageAmount(ctx context.Context, userID string, since time.Time) (float64, bool, error) {
+    var avg *float64
+    if err := p.reader(ctx).QueryRow(ctx, `SELECT AVG(amount) FROM transactions WHERE user_id = $1 AND timestamp > $2`, userID, since).Scan(&avg); err != nil {
+        return 0, false, err
+    }
+    if avg == nil { return 0, false, nil }
+    return *avg, true, nil
+}
+
+func (p *Postgres) UpdateScore(ctx context.Context, id string, from, to time.Time, score float64, isFraud bool, decision string, riskFactors []string) error {
+    tag, err := p.primary.Exec(ctx, `UPDATE transactions SET fraud_score = $4, is_fraud = $5, decision = $6, risk_factors = $7 WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3`,
+        id, from, to, score, isFraud, decision, riskFactors)
+    if err != nil { return err }
+    if tag.RowsAffected() == 0 { return ErrNotFound }
+    return nil
+}
+
+func (p *Postgres) MCC(ctx context.Context, merchantID string) (string, error) {
+    var mcc string
+    err := p.reader(ctx).QueryRow(ctx, `SELECT mcc FROM merchants WHERE merchant_id = $1`, merchantID).Scan(&mcc)
+    if errors.Is(err, pgx.ErrNoRows) { return "", ErrNotFound }
+    return mcc, err
+}
+
+func (p *Postgres) Ensure(ctx context.Context, userID string, risk float64, createdAt *time.Time) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO users (user_id, risk_score, created_at) VALUES ($1, $2, COALESCE($3::timestamp, CURRENT_TIMESTAMP))
+                                   ON CONFLICT (user_id) DO UPDATE SET created_at = EXCLUDED.created_at
+                                   WHERE $3::timestamp IS NOT NULL AND EXCLUDED.created_at < users.created_at`, userID, risk, createdAt)
+    return err
+}
+
+func (p *Postgres) Tenure(ctx context.Context, userID string) (UserTenure, error) {
+    var t UserTenure
+    err := p.reader(ctx).QueryRow(ctx, `SELECT created_at, first_transaction_at FROM users WHERE user_id = $1`, userID).Scan(&t.CreatedAt, &t.FirstTransactionAt)
+    if errors.Is(err, pgx.ErrNoRows) { return t, ErrNotFound }
+    return t, err
+}
+
+func (p *Postgres) RiskScore(ctx context.Context, userID string) (float64, error) {
+    var risk float64
+    err := p.reader(ctx).QueryRow(ctx, `SELECT risk_score FROM users WHERE user_id = $1`, userID).Scan(&risk)
+    if errors.Is(err, pgx.ErrNoRows) { return 0, ErrNotFound }
+    return risk, err
+}
+
+func (p *Postgres) HotUsers(ctx context.Context, activeSince, historySince time.Time, limit int) ([]UserProfile, error) {
+    rows, err := p.reader(ctx).Query(ctx, `WITH hot AS (
+                                               SELECT user_id FROM transactions
+                                               WHERE timestamp > $1
+                                               GROUP BY user_id ORDER BY COUNT(*) DESC LIMIT $2
+                                           )
+                                           SELECT u.user_id, u.risk_score, COALESCE(AVG(t.amount), 0)
+                                           FROM hot JOIN users u ON u.user_id = hot.user_id
+                                           JOIN transactions t ON t.user_id = hot.user_id AND t.timestamp > $3
+                                           GROUP BY u.user_id, u.risk_score`, activeSince, limit, historySince)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []UserProfile
+    for rows.Next() {
+        var u UserProfile
+        if err := rows.Scan(&u.UserID, &u.RiskScore, &u.AvgAmount); err != nil { return out, err }
+        out = append(out, u)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) RecordVerification(ctx context.Context, id string, from, to time.Time, method, result, decision string) error {
+    tag, err := p.primary.Exec(ctx, `UPDATE transactions SET verification_method = $4, verification_result = $5, decision = NULLIF($6, '')
+                                     WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3 AND verification_result IS NULL`,
+        id, from, to, method, result, decision)
+    if err != nil { return err }
+    if tag.RowsAffected() == 0 { return ErrNotFound }
+    return nil
+}
+
+func (p *Postgres) Range(ctx context.Context, from, to time.Time, fn func(Transaction) error) error {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT transaction_id, user_id, amount, timestamp, channel, fraud_score, is_fraud FROM transactions
+                                           WHERE timestamp >= $1 AND timestamp < $2 ORDER BY timestamp`, from, to)
+    if err != nil { return err }
+    defer rows.Close()
+    for rows.Next() {
+        var t Transaction
+        if err := rows.Scan(&t.TransactionID, &t.UserID, &t.Amount, &t.Timestamp, &t.Channel, &t.FraudScore, &t.IsFraud); err != nil { return err }
+        if err := fn(t); err != nil { return err }
+    }
+    return rows.Err()
+}
+
+func (p *Postgres) Transactions(ctx context.Context, userID, status string, from, to time.Time, page Page) ([]Transaction, error) {
+    after, args := keyset("timestamp", "transaction_id", page.After, userID, from, to, page.Limit, status)
+    rows, err := p.reader(ctx).Query(ctx, `SELECT transaction_id, user_id, amount, timestamp, merchant_id, merchant_risk, mcc, channel, country, behavioral_score, session_id, fraud_score, is_fraud, decision, risk_factors, verification_result,
+                                                  status, lifecycle_status, captured_amount, refunded_amount FROM transactions
+                                           WHERE ($1 = '' OR user_id = $1) AND timestamp >= $2 AND timestamp < $3 AND ($5 = '' OR status = $5)`+after+` ORDER BY timestamp DESC, transaction_id DESC LIMIT $4`, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Transaction
+    for rows.Next() {
+        var t Transaction
+        if err := rows.Scan(&t.TransactionID, &t.UserID, &t.Amount, &t.Timestamp, &t.MerchantID, &t.MerchantRisk, &t.MCC, &t.Channel, &t.Country, &t.BehavioralScore, &t.SessionID, &t.FraudScore, &t.IsFraud, &t.Decision, &t.RiskFactors, &t.VerificationResult,
+            &t.Status, &t.LifecycleStatus, &t.CapturedAmount, &t.RefundedAmount); err != nil { return nil, err }
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) SetStatus(ctx context.Context, id string, from, to time.Time, status string, only []string, actor, reason string) (bool, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return false, err }
+    defer tx.Rollback(context.Background())
+    var prev string
+    err = tx.QueryRow(ctx, `SELECT status FROM transactions WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3 FOR UPDATE`, id, from, to).Scan(&prev)
+    if errors.Is(err, pgx.ErrNoRows) { return false, ErrNotFound }
+    if err != nil { return false, err }
+    allowed := false
+    for _, s := range only { allowed = allowed || s == prev }
+    if !allowed || prev == status { return false, nil }
+    if _, err := tx.Exec(ctx, `UPDATE transactions SET status = $4 WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3`, id, from, to, status); err != nil { return false, err }
+    if err := audit(ctx, tx, actor, "transaction.status", "transaction", id, map[string]string{"from": prev, "to": status, "reason": reason}); err != nil { return false, err }
+    return true, tx.Commit(ctx)
+}
+
+func (p *Postgres) RecordEvent(ctx context.Context, id string, from, to time.Time, e LifecycleEvent, apply func(Lifecycle, *LifecycleEvent) (Lifecycle, error)) (EventOutcome, error) {
+    var out EventOutcome
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return out, err }
+    defer tx.Rollback(context.Background())
+    l := &out.Before
+    err = tx.QueryRow(ctx, `SELECT transaction_id, user_id, timestamp, decision, amount, lifecycle_status, captured_amount, refunded_amount FROM transactions
+                            WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3 FOR UPDATE`, id, from, to).
+        Scan(&l.TransactionID, &l.UserID, &l.Timestamp, &l.Decision, &l.Amount, &l.Status, &l.Captured, &l.Refunded)
+    if errors.Is(err, pgx.ErrNoRows) { return out, ErrNotFound }
+    if err != nil { return out, err }
+    out.After = out.Before
+    // Checked before apply, which would reject a retried event the first
+    // attempt already applied.
+    var seen bool
+    if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM transaction_events WHERE event_id = $1)`, e.EventID).Scan(&seen); err != nil { return out, err }
+    if seen { return out, ErrExists }
+    if out.After, err = apply(out.Before, &e); err != nil { return out, err }
+    a := out.After
+    err = tx.QueryRow(ctx, `INSERT INTO transaction_events (event_id, transaction_id, event_type, amount, occurred_at, source) VALUES ($1, $2, $3, $4, $5, $6)
+                            ON CONFLICT (event_id) DO NOTHING RETURNING created_at`, e.EventID, id, e.Type, e.Amount, e.OccurredAt, e.Source).Scan(&e.CreatedAt)
+    if errors.Is(err, pgx.ErrNoRows) { out.After = out.Before; return out, ErrExists }
+    if err != nil { return out, err }
+    out.Event = e
+    if _, err := tx.Exec(ctx, `UPDATE transactions SET lifecycle_status = $4, captured_amount = $5, refunded_amount = $6 WHERE transaction_id = $1 AND timestamp BETWEEN $2 AND $3`,
+        id, from, to, a.Status, a.Captured, a.Refunded); err != nil { return out, err }
+    if a.Status == StatusReversed && out.Before.Status != StatusReversed {
+        rows, err := tx.Query(ctx, `UPDATE fraud_alerts SET status = 'RESOLVED', resolved_at = now(), resolution = $2
+                                    WHERE transaction_id = $1 AND COALESCE(status, 'OPEN') = 'OPEN' RETURNING alert_id`, id, ResolutionReversed)
+        if err != nil { return out, err }
+        for rows.Next() {
+            var alertID string
+            if err := rows.Scan(&alertID); err != nil { rows.Close(); return out, err }
+            out.ClosedAlerts = append(out.ClosedAlerts, alertID)
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil { return out, err }
+        for _, alertID := range out.ClosedAlerts {
+            if err := audit(ctx, tx, "system", "alert.closed", "alert", alertID, map[string]string{"resolution": ResolutionReversed, "event_id": e.EventID}); err != nil { return out, err }
+        }
+    }
+    return out, tx.Commit(ctx)
+}
+
+func (p *Postgres) Events(ctx context.Context, id string) ([]LifecycleEvent, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT event_id, transaction_id, event_type, amount, occurred_at, source, created_at FROM transaction_events
+                                           WHERE transaction_id = $1 ORDER BY id`, id)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []LifecycleEvent
+    for rows.Next() {
+        var e LifecycleEvent
+        if err := rows.Scan(&e.EventID, &e.TransactionID, &e.Type, &e.Amount, &e.OccurredAt, &e.Source, &e.CreatedAt); err != nil { return nil, err }
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) Users(ctx context.Context, page Page) ([]User, error) {
+    after, args := keyset("created_at", "user_id", page.After, page.Limit)
+    rows, err := p.reader(ctx).Query(ctx, `SELECT user_id, COALESCE(risk_score, 0.5), created_at, COALESCE(updated_at, created_at) FROM users
+                                           WHERE true`+after+` ORDER BY created_at DESC, user_id DESC LIMIT $1`, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []User
+    for rows.Next() {
+        var u User
+        if err := rows.Scan(&u.UserID, &u.RiskScore, &u.CreatedAt, &u.UpdatedAt); err != nil { return nil, err }
+        out = append(out, u)
+    }
+    return out, rows.Err()
+}
+
+// keyset returns the condition that starts a listing ordered by timeCol and
+// idCol, descending, after c, with args extended by its parameters. It is
+// empty for the first page.
+func keyset(timeCol, idCol string, c *Cursor, args ...interface{}) (string, []interface{}) {
+    if c == nil { return "", args }
+    n := len(args)
+    return fmt.Sprintf(" AND (%s, %s) < ($%d, $%d)", timeCol, idCol, n+1, n+2), append(args, c.At, c.ID)
+}
+
+func (p *Postgres) AdjustRisk(ctx context.Context, userID string, delta float64) (float64, error) {
+    var risk float64
+    err := p.primary.QueryRow(ctx, `UPDATE users SET risk_score = LEAST(1, GREATEST(0, COALESCE(risk_score, 0.5) + $2)), updated_at = CURRENT_TIMESTAMP
+                                    WHERE user_id = $1 RETURNING risk_score`, userID, delta).Scan(&risk)
+    if errors.Is(err, pgx.ErrNoRows) { return 0, ErrNotFound }
+    return risk, err
+}
+
+// Escalate runs under the same per-transaction advisory lock the processor
+// takes when creating alerts, so it can't race an alert being opened.
+func (p *Postgres) Escalate(ctx context.Context, a Alert, since time.Time) error {
+    dbtx, err := p.primary.Begin(ctx)
+    if err != nil { return err }
+    defer dbtx.Rollback(context.Background())
+    if _, err := dbtx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, a.TransactionID); err != nil { return err }
+    rows, err := dbtx.Query(ctx, `UPDATE fraud_alerts SET severity = 'CRITICAL' WHERE transaction_id = $1 AND status = 'OPEN' AND created_at >= $2 RETURNING alert_id`, a.TransactionID, since)
+    if err != nil { return err }
+    var escalated []string
+    for rows.Next() {
+        var id string
+        if err := rows.Scan(&id); err != nil { rows.Close(); return err }
+        escalated = append(escalated, id)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return err }
+    for _, id := range escalated {
+        if err := audit(ctx, dbtx, "system", "alert.escalated", "alert", id, map[string]string{"severity": "CRITICAL", "reason": a.Description}); err != nil { return err }
+    }
+    if len(escalated) == 0 {
+        if a.AlertID == "" { a.AlertID = events.NewAlertID() }
+        _, err = dbtx.Exec(ctx, `INSERT INTO fraud_alerts (alert_id, transaction_id, alert_type, severity, description, confidence_score, status) VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+            a.AlertID, a.TransactionID, a.AlertType, a.Severity, a.Description, a.Confidence, a.Status)
+        if err != nil { return err }
+        if err := audit(ctx, dbtx, "system", "alert.created", "alert", a.AlertID, map[string]string{"status": a.Status, "severity": a.Severity}); err != nil { return err }
+    }
+    return dbtx.Commit(ctx)
+}
+
+func (p *Postgres) KYCStatus(ctx context.Context, userID string) (string, error) {
+    var status string
+    err := p.reader(ctx).QueryRow(ctx, `SELECT status FROM user_kyc WHERE user_id = $1`, userID).Scan(&status)
+    if errors.Is(err, pgx.ErrNoRows) { return "", ErrNotFound }
+    return status, err
+}
+
+func (p *Postgres) SetKYCStatus(ctx context.Context, userID, status string) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO user_kyc (user_id, status) VALUES ($1, $2)
+                                   ON CONFLICT (user_id) DO UPDATE SET status = EXCLUDED.status, updated_at = now()`, userID, status)
+    return err
+}
+
+func (p *Postgres) Label(ctx context.Context, transactionID string, isFraud bool, source string) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO transaction_labels (transaction_id, is_fraud, source) VALUES ($1, $2, NULLIF($3, ''))
+                                   ON CONFLICT (transaction_id) DO UPDATE SET is_fraud = EXCLUDED.is_fraud, source = EXCLUDED.source, labeled_at = now()`,
+        transactionID, isFraud, source)
+    return err
+}
+
+func (p *Postgres) RuleStats(ctx context.Context, rule string, since time.Time) (RuleStats, error) {
+    var s RuleStats
+    err := p.reader(ctx).QueryRow(ctx, `SELECT COUNT(*),
+                                               COUNT(*) FILTER (WHERE t.decision = 'DECLINE'),
+                                               COALESCE(SUM(t.amount) FILTER (WHERE t.decision = 'DECLINE'), 0),
+                                               COUNT(l.transaction_id),
+                                               COUNT(*) FILTER (WHERE l.is_fraud)
+                                        FROM transactions t LEFT JOIN transaction_labels l ON l.transaction_id = t.transaction_id
+                                        WHERE t.timestamp >= $2 AND t.risk_factors @> ARRAY[$1]::text[]`, rule, since).
+        Scan(&s.Hits, &s.Declined, &s.DeclinedAmount, &s.Labeled, &s.LabeledFraud)
+    return s, err
+}
+
+func (p *Postgres) LabeledScores(ctx context.Context, since time.Time) ([]LabeledScore, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT t.fraud_score, t.amount, l.is_fraud
+                                           FROM transactions t JOIN transaction_labels l ON l.transaction_id = t.transaction_id
+                                           WHERE t.timestamp >= $1`, since)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []LabeledScore
+    for rows.Next() {
+        var s LabeledScore
+        if err := rows.Scan(&s.Score, &s.Amount, &s.IsFraud); err != nil { return nil, err }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) Blocklist(ctx context.Context) ([]BlocklistEntry, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT id, kind, value, action, COALESCE(reason, ''), created_at FROM blocklist ORDER BY id`)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []BlocklistEntry
+    for rows.Next() {
+        var e BlocklistEntry
+        if err := rows.Scan(&e.ID, &e.Kind, &e.Value, &e.Action, &e.Reason, &e.CreatedAt); err != nil { return nil, err }
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) AddBlocklistEntry(ctx context.Context, e BlocklistEntry) (BlocklistEntry, error) {
+    err := p.primary.QueryRow(ctx, `INSERT INTO blocklist (kind, value, action, reason) VALUES ($1, $2, $3, NULLIF($4, ''))
+                                    ON CONFLICT (kind, value) DO NOTHING RETURNING id, created_at`, e.Kind, e.Value, e.Action, e.Reason).Scan(&e.ID, &e.CreatedAt)
+    if errors.Is(err, pgx.ErrNoRows) { return e, ErrExists }
+    return e, err
+}
+
+func (p *Postgres) DeleteBlocklistEntry(ctx context.Context, id int64) error {
+    tag, err := p.primary.Exec(ctx, `DELETE FROM blocklist WHERE id = $1`, id)
+    if err != nil { return err }
+    if tag.RowsAffected() == 0 { return ErrNotFound }
+    return nil
+}
+
+func (p *Postgres) SpendLimits(ctx context.Context, userID string) (SpendLimits, error) {
+    var l SpendLimits
+    err := p.reader(ctx).QueryRow(ctx, `SELECT daily, weekly FROM user_spend_limits WHERE user_id = $1`, userID).Scan(&l.Daily, &l.Weekly)
+    if errors.Is(err, pgx.ErrNoRows) { return l, ErrNotFound }
+    return l, err
+}
+
+func (p *Postgres) SetSpendLimits(ctx context.Context, userID string, l SpendLimits) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO user_spend_limits (user_id, daily, weekly) VALUES ($1, $2, $3)
+                                   ON CONFLICT (user_id) DO UPDATE SET daily = EXCLUDED.daily, weekly = EXCLUDED.weekly, updated_at = now()`,
+        userID, l.Daily, l.Weekly)
+    return err
+}
+
+func (p *Postgres) AddTravelNotice(ctx context.Context, n TravelNotice) (TravelNotice, error) {
+    err := p.primary.QueryRow(ctx, `INSERT INTO travel_notices (user_id, starts_at, ends_at, countries) VALUES ($1, $2, $3, $4)
+                                    RETURNING id, created_at`, n.UserID, n.StartsAt, n.EndsAt, n.Countries).Scan(&n.ID, &n.CreatedAt)
+    return n, err
+}
+
+func (p *Postgres) TravelNotices(ctx context.Context, userID string, now time.Time) ([]TravelNotice, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT id, user_id, starts_at, ends_at, countries, created_at FROM travel_notices
+                                           WHERE user_id = $1 AND ends_at > $2 ORDER BY starts_at, id`, userID, now)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []TravelNotice
+    for rows.Next() {
+        var n TravelNotice
+        if err := rows.Scan(&n.ID, &n.UserID, &n.StartsAt, &n.EndsAt, &n.Countries, &n.CreatedAt); err != nil { return nil, err }
+        out = append(out, n)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) DeleteTravelNotice(ctx context.Context, userID string, id int64) error {
+    tag, err := p.primary.Exec(ctx, `DELETE FROM travel_notices WHERE user_id = $1 AND id = $2`, userID, id)
+    if err != nil { return err }
+    if tag.RowsAffected() == 0 { return ErrNotFound }
+    return nil
+}
+
+func (p *Postgres) List(ctx context.Context, status string, page Page) ([]Alert, error) {
+    after, args := keyset("created_at", "alert_id", page.After, status, page.Limit)
+    rows, err := p.reader(ctx).Query(ctx, `SELECT alert_id, transaction_id, alert_type, severity, description, confidence_score, status, created_at, acknowledged_at, sla_breached_at
+                                           FROM fraud_alerts WHERE status = $1`+after+` ORDER BY created_at DESC, alert_id DESC LIMIT $2`, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Alert
+    for rows.Next() {
+        var a Alert
+        if err := rows.Scan(&a.AlertID, &a.TransactionID, &a.AlertType, &a.Severity, &a.Description, &a.Confidence, &a.Status, &a.CreatedAt, &a.AcknowledgedAt, &a.SLABreachedAt); err != nil { continue }
+        out = append(out, a)
+    }
+    return out, nil
+}
+
+func (p *Postgres) Resolve(ctx context.Context, alertID, analyst, resolution string) (Alert, error) {
+    return p.updateOpenAlert(ctx, alertID, analyst, "alert.resolved", map[string]string{"resolution": resolution},
+        `status = 'RESOLVED', resolved_at = now(), resolved_by = $2, resolution = $3, acknowledged_at = COALESCE(acknowledged_at, now())`, analyst, resolution)
+}
+
+func (p *Postgres) Assign(ctx context.Context, alertID, assignee, actor string) (Alert, error) {
+    action := "alert.assigned"
+    if assignee == "" { action = "alert.unassigned" }
+    return p.updateOpenAlert(ctx, alertID, actor, action, map[string]string{"assignee": assignee}, `assigned_to = NULLIF($2, ''), acknowledged_at = COALESCE(acknowledged_at, CASE WHEN $2 <> '' THEN now() END)`, assignee)
+}
+
+// updateOpenAlert applies set, whose parameters start at $2, to the open
+// alert and audits it as action in the same transaction.
+func (p *Postgres) updateOpenAlert(ctx context.Context, alertID, actor, action string, details interface{}, set string, args ...interface{}) (Alert, error) {
+    var a Alert
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return a, err }
+    defer tx.Rollback(context.Background())
+    err = tx.QueryRow(ctx, `UPDATE fraud_alerts SET `+set+` WHERE alert_id = $1 AND COALESCE(status, 'OPEN') = 'OPEN'
+                            RETURNING `+alertColumns, append([]interface{}{alertID}, args...)...).
+        Scan(alertDest(&a)...)
+    if errors.Is(err, pgx.ErrNoRows) {
+        var exists bool
+        if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM fraud_alerts WHERE alert_id = $1)`, alertID).Scan(&exists); err != nil { return a, err }
+        if exists { return a, ErrNotOpen }
+        return a, ErrNotFound
+    }
+    if err != nil { return a, err }
+    if err := audit(ctx, tx, actor, action, "alert", alertID, details); err != nil { return a, err }
+    return a, tx.Commit(ctx)
+}
+
+func (p *Postgres) CloseStale(ctx context.Context, severities []string, before time.Time, limit int) ([]Alert, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return nil, err }
+    defer tx.Rollback(context.Background())
+    rows, err := tx.Query(ctx, `UPDATE fraud_alerts SET status = 'RESOLVED', resolved_at = now(), resolution = $3
+                                WHERE (alert_id, created_at) IN (SELECT alert_id, created_at FROM fraud_alerts
+                                                                 WHERE COALESCE(status, 'OPEN') = 'OPEN' AND assigned_to IS NULL
+                                                                   AND severity = ANY($1) AND created_at < $2
+                                                                 ORDER BY created_at LIMIT $4 FOR UPDATE SKIP LOCKED)
+                                RETURNING `+alertColumns, severities, before, ResolutionAutoClosedStale, limit)
+    if err != nil { return nil, err }
+    out, err := collectAlerts(rows)
+    if err != nil { return nil, err }
+    for _, a := range out {
+        if err := audit(ctx, tx, "system", "alert.closed", "alert", a.AlertID, map[string]string{"resolution": ResolutionAutoClosedStale}); err != nil { return nil, err }
+    }
+    return out, tx.Commit(ctx)
+}
+
+func (p *Postgres) BreachSLA(ctx context.Context, severity string, before time.Time, limit int) ([]Alert, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return nil, err }
+    defer tx.Rollback(context.Background())
+    rows, err := tx.Query(ctx, `UPDATE fraud_alerts SET sla_breached_at = now()
+                                WHERE (alert_id, created_at) IN (SELECT alert_id, created_at FROM fraud_alerts
+                                                                 WHERE COALESCE(status, 'OPEN') = 'OPEN' AND acknowledged_at IS NULL AND sla_breached_at IS NULL
+                                                                   AND severity = $1 AND created_at < $2
+                                                                 ORDER BY created_at LIMIT $3 FOR UPDATE SKIP LOCKED)
+                                RETURNING `+alertColumns, severity, before, limit)
+    if err != nil { return nil, err }
+    out, err := collectAlerts(rows)
+    if err != nil { return nil, err }
+    for _, a := range out {
+        if err := audit(ctx, tx, "system", "alert.sla_breached", "alert", a.AlertID, map[string]string{"severity": a.Severity}); err != nil { return nil, err }
+    }
+    return out, tx.Commit(ctx)
+}
+
+// alertColumns are what updates of single alerts return, read by alertDest.
+const alertColumns = `alert_id, transaction_id, alert_type, severity, COALESCE(description, ''), COALESCE(confidence_score, 0),
+                      status, created_at, assigned_to, resolved_at, resolved_by, resolution, acknowledged_at, sla_breached_at`
+
+func alertDest(a *Alert) []interface{} {
+    return []interface{}{&a.AlertID, &a.TransactionID, &a.AlertType, &a.Severity, &a.Description, &a.Confidence, &a.Status, &a.CreatedAt,
+        &a.AssignedTo, &a.ResolvedAt, &a.ResolvedBy, &a.Resolution, &a.AcknowledgedAt, &a.SLABreachedAt}
+}
+
+// collectAlerts reads and closes rows of alertColumns, so the transaction
+// they came from can go on to audit them.
+func collectAlerts(rows pgx.Rows) ([]Alert, error) {
+    defer rows.Close()
+    var out []Alert
+    for rows.Next() {
+        var a Alert
+        if err := rows.Scan(alertDest(&a)...); err != nil { return nil, err }
+        out = append(out, a)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) AddComment(ctx context.Context, c AlertComment) (AlertComment, error) {
+    err := p.primary.QueryRow(ctx, `INSERT INTO alert_comments (alert_id, parent_id, author, body)
+                                    SELECT $1, $2, $3, $4
+                                    WHERE EXISTS (SELECT 1 FROM fraud_alerts WHERE alert_id = $1)
+                                      AND ($2::BIGINT IS NULL OR EXISTS (SELECT 1 FROM alert_comments WHERE id = $2 AND alert_id = $1))
+                                    RETURNING id, created_at`, c.AlertID, c.ParentID, c.Author, c.Body).Scan(&c.ID, &c.CreatedAt)
+    if errors.Is(err, pgx.ErrNoRows) { return c, ErrNotFound }
+    return c, err
+}
+
+func (p *Postgres) Comments(ctx context.Context, alertID string) ([]AlertComment, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT id, alert_id, parent_id, author, body, created_at FROM alert_comments WHERE alert_id = $1 ORDER BY id`, alertID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []AlertComment
+    for rows.Next() {
+        var c AlertComment
+        if err := rows.Scan(&c.ID, &c.AlertID, &c.ParentID, &c.Author, &c.Body, &c.CreatedAt); err != nil { return nil, err }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+const caseAlertColumns = `a.alert_id, a.transaction_id, a.alert_type, a.severity, COALESCE(a.description, ''), COALESCE(a.confidence_score, 0), COALESCE(a.status, ''), a.created_at,
+                          COALESCE(t.user_id, ''), a.case_id, a.assigned_to, a.resolved_at, a.resolved_by, a.resolution, a.acknowledged_at, a.sla_breached_at`
+
+func scanCaseAlert(row pgx.Row) (Alert, error) {
+    var a Alert
+    err := row.Scan(&a.AlertID, &a.TransactionID, &a.AlertType, &a.Severity, &a.Description, &a.Confidence, &a.Status, &a.CreatedAt, &a.UserID, &a.CaseID, &a.AssignedTo, &a.ResolvedAt, &a.ResolvedBy, &a.Resolution, &a.AcknowledgedAt, &a.SLABreachedAt)
+    return a, err
+}
+
+const caseColumns = `c.id, c.status, c.alert_count, c.opened_at, c.last_alert_at, c.closed_at, c.closed_by,
+                     ARRAY(SELECT e.entity_type FROM case_entities e WHERE e.case_id = c.id ORDER BY e.entity_type, e.entity_value),
+                     ARRAY(SELECT e.entity_value FROM case_entities e WHERE e.case_id = c.id ORDER BY e.entity_type, e.entity_value)`
+
+func scanCase(row pgx.Row) (Case, error) {
+    var c Case
+    var types, values []string
+    if err := row.Scan(&c.ID, &c.Status, &c.AlertCount, &c.OpenedAt, &c.LastAlertAt, &c.ClosedAt, &c.ClosedBy, &types, &values); err != nil { return c, err }
+    c.Entities = make([]CaseEntity, len(types))
+    for i := range types { c.Entities[i] = CaseEntity{Type: types[i], Value: values[i]} }
+    return c, nil
+}
+
+func (p *Postgres) Cases(ctx context.Context, status string, limit int) ([]Case, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT `+caseColumns+` FROM fraud_cases c WHERE c.status = $1 ORDER BY c.last_alert_at DESC LIMIT $2`, status, limit)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Case
+    for rows.Next() {
+        c, err := scanCase(rows)
+        if err != nil { return nil, err }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) Case(ctx context.Context, id int64) (Case, error) {
+    c, err := scanCase(p.reader(ctx).QueryRow(ctx, `SELECT `+caseColumns+` FROM fraud_cases c WHERE c.id = $1`, id))
+    if errors.Is(err, pgx.ErrNoRows) { return c, ErrNotFound }
+    return c, err
+}
+
+func (p *Postgres) CaseAlerts(ctx context.Context, id int64) ([]Alert, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT `+caseAlertColumns+` FROM fraud_alerts a
+                                           LEFT JOIN transactions t ON t.transaction_id = a.transaction_id
+                                           WHERE a.case_id = $1 ORDER BY a.created_at`, id)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Alert
+    for rows.Next() {
+        a, err := scanCaseAlert(rows)
+        if err != nil { return nil, err }
+        out = append(out, a)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) CloseCase(ctx context.Context, id int64, analyst string) (Case, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return Case{}, err }
+    defer tx.Rollback(context.Background())
+    tag, err := tx.Exec(ctx, `UPDATE fraud_cases SET status = 'CLOSED', closed_at = now(), closed_by = $2 WHERE id = $1 AND status = 'OPEN'`, id, analyst)
+    if err != nil { return Case{}, err }
+    c, err := scanCase(tx.QueryRow(ctx, `SELECT `+caseColumns+` FROM fraud_cases c WHERE c.id = $1`, id))
+    if errors.Is(err, pgx.ErrNoRows) { return c, ErrNotFound }
+    if err != nil { return c, err }
+    if tag.RowsAffected() == 0 { return c, ErrNotOpen }
+    if err := audit(ctx, tx, analyst, "case.closed", "case", strconv.FormatInt(id, 10), map[string]int{"alert_count": c.AlertCount}); err != nil { return c, err }
+    return c, tx.Commit(ctx)
+}
+
+func (p *Postgres) Alert(ctx context.Context, alertID string) (Alert, error) {
+    a, err := scanCaseAlert(p.reader(ctx).QueryRow(ctx, `SELECT `+caseAlertColumns+` FROM fraud_alerts a
+                                                        LEFT JOIN transactions t ON t.transaction_id = a.transaction_id
+                                                        WHERE a.alert_id = $1 ORDER BY a.created_at LIMIT 1`, alertID))
+    if errors.Is(err, pgx.ErrNoRows) { return a, ErrNotFound }
+    return a, err
+}
+
+func (p *Postgres) UserAlerts(ctx context.Context, userID string, from, to time.Time) ([]Alert, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT `+caseAlertColumns+` FROM fraud_alerts a
+                                           JOIN transactions t ON t.transaction_id = a.transaction_id
+                                           WHERE t.user_id = $1 AND a.created_at >= $2 AND a.created_at < $3 ORDER BY a.created_at`, userID, from, to)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Alert
+    for rows.Next() {
+        a, err := scanCaseAlert(rows)
+        if err != nil { return nil, err }
+        out = append(out, a)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) UserActivity(ctx context.Context, userID string, from, to time.Time, limit int) ([]CaseTransaction, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT t.transaction_id, t.user_id, t.amount, t.timestamp, COALESCE(t.merchant_id, ''), COALESCE(t.merchant_risk, 0), t.mcc, t.channel, t.country,
+                                                  t.behavioral_score, t.session_id, COALESCE(t.fraud_score, 0), t.is_fraud, t.decision, t.risk_factors, t.verification_result,
+                                                  t.verification_method, t.status, t.created_at, t.updated_at, l.is_fraud, l.source, l.labeled_at
+                                           FROM transactions t LEFT JOIN transaction_labels l ON l.transaction_id = t.transaction_id
+                                           WHERE t.user_id = $1 AND t.timestamp >= $2 AND t.timestamp < $3 ORDER BY t.timestamp LIMIT $4`, userID, from, to, limit)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []CaseTransaction
+    for rows.Next() {
+        var c CaseTransaction
+        t := &c.Transaction
+        if err := rows.Scan(&t.TransactionID, &t.UserID, &t.Amount, &t.Timestamp, &t.MerchantID, &t.MerchantRisk, &t.MCC, &t.Channel, &t.Country,
+            &t.BehavioralScore, &t.SessionID, &t.FraudScore, &t.IsFraud, &t.Decision, &t.RiskFactors, &t.VerificationResult,
+            &c.VerificationMethod, &t.Status, &c.CreatedAt, &c.UpdatedAt, &c.Label, &c.LabelSource, &c.LabeledAt); err != nil {
+            return nil, err
+        }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) FraudSummary(ctx context.Context, from, to time.Time) (FraudSummary, error) {
+    var s FraudSummary
+    err := p.reader(ctx).QueryRow(ctx, `SELECT COUNT(*), COALESCE(SUM(t.amount), 0),
+                                               COUNT(*) FILTER (WHERE t.decision = 'APPROVE'),
+                                               COUNT(*) FILTER (WHERE t.decision = 'REVIEW'),
+                                               COUNT(*) FILTER (WHERE t.decision = 'DECLINE'),
+                                               COALESCE(SUM(t.amount) FILTER (WHERE t.decision = 'DECLINE'), 0),
+                                               COUNT(*) FILTER (WHERE t.is_fraud),
+                                               COUNT(*) FILTER (WHERE l.is_fraud),
+                                               COALESCE(SUM(t.amount) FILTER (WHERE t.decision = 'DECLINE' AND COALESCE(l.is_fraud, t.is_fraud)), 0),
+                                               COALESCE(SUM(t.amount) FILTER (WHERE l.is_fraud AND t.decision IS DISTINCT FROM 'DECLINE'), 0)
+                                        FROM transactions t LEFT JOIN transaction_labels l ON l.transaction_id = t.transaction_id
+                                        WHERE t.timestamp >= $1 AND t.timestamp < $2`, from, to).
+        Scan(&s.Transactions, &s.Amount, &s.Approved, &s.Review, &s.Declined, &s.DeclinedAmount, &s.Flagged, &s.LabeledFraud, &s.LossesPrevented, &s.LossesMissed)
+    return s, err
+}
+
+func (p *Postgres) AlertDispositions(ctx context.Context, from, to time.Time) ([]AlertDisposition, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT a.alert_type, a.severity, COUNT(*),
+                                                  COUNT(*) FILTER (WHERE COALESCE(a.status, 'OPEN') = 'OPEN'),
+                                                  COUNT(*) FILTER (WHERE a.status NOT IN ('OPEN', 'SUPPRESSED')),
+                                                  COUNT(*) FILTER (WHERE a.status = 'SUPPRESSED'),
+                                                  COUNT(*) FILTER (WHERE l.is_fraud),
+                                                  COUNT(*) FILTER (WHERE NOT l.is_fraud)
+                                           FROM fraud_alerts a LEFT JOIN transaction_labels l ON l.transaction_id = a.transaction_id
+                                           WHERE a.created_at >= $1 AND a.created_at < $2
+                                           GROUP BY a.alert_type, a.severity ORDER BY COUNT(*) DESC, a.alert_type, a.severity`, from, to)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []AlertDisposition
+    for rows.Next() {
+        var d AlertDisposition
+        if err := rows.Scan(&d.AlertType, &d.Severity, &d.Raised, &d.Open, &d.Closed, &d.Suppressed, &d.ConfirmedFraud, &d.FalsePositive); err != nil { return nil, err }
+        out = append(out, d)
+    }
+    return out, rows.Err()
+}
+
+// RollUp replaces the days' rows in one transaction, so readers never see
+// them half written, under an advisory lock so concurrent runs queue.
+func (p *Postgres) RollUp(ctx context.Context, from, to time.Time) error {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return err }
+    defer tx.Rollback(context.Background())
+    if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('entity_daily_stats'))`); err != nil { return err }
+    from, to = from.UTC().Truncate(24*time.Hour), to.UTC().Truncate(24*time.Hour)
+    if _, err := tx.Exec(ctx, `DELETE FROM entity_daily_stats WHERE day >= $1 AND day < $2`, from, to); err != nil { return err }
+    _, err = tx.Exec(ctx, `INSERT INTO entity_daily_stats (day, entity_type, entity_id, transactions, amount, fraud, fraud_amount, declined)
+                           SELECT t.timestamp::date, e.type, e.id, COUNT(*), SUM(t.amount),
+                                  COUNT(*) FILTER (WHERE COALESCE(l.is_fraud, t.is_fraud)),
+                                  COALESCE(SUM(t.amount) FILTER (WHERE COALESCE(l.is_fraud, t.is_fraud)), 0),
+                                  COUNT(*) FILTER (WHERE t.decision = 'DECLINE')
+                           FROM transactions t LEFT JOIN transaction_labels l ON l.transaction_id = t.transaction_id
+                           CROSS JOIN LATERAL (VALUES ('merchant', t.merchant_id), ('user', t.user_id), ('device', t.device_id),
+                                                      ('country', COALESCE(t.ip_country, t.country)), ('geohash', t.geohash)) AS e(type, id)
+                           WHERE t.timestamp >= $1 AND t.timestamp < $2 AND COALESCE(e.id, '') <> ''
+                           GROUP BY 1, 2, 3`, from, to)
+    if err != nil { return err }
+    return tx.Commit(ctx)
+}
+
+// entityOrder is the ORDER BY for each TopEntities metric.
+var entityOrder = map[string]string{
+    MetricFraudRate: `SUM(fraud)::float / SUM(transactions) DESC, SUM(fraud) DESC`,
+    MetricVolume:    `SUM(amount) DESC`,
+}
+
+func (p *Postgres) TopEntities(ctx context.Context, entityType, metric string, from, to time.Time, minTransactions int64, limit int) ([]EntityRisk, error) {
+    order, ok := entityOrder[metric]
+    if !ok { return nil, fmt.Errorf("store: unknown metric %q", metric) }
+    rows, err := p.reader(ctx).Query(ctx, `SELECT entity_id, SUM(transactions)::bigint, SUM(amount)::float, SUM(fraud)::bigint, SUM(fraud_amount)::float, SUM(declined)::bigint
+                                           FROM entity_daily_stats WHERE entity_type = $1 AND day >= $2 AND day < $3
+                                           GROUP BY entity_id HAVING SUM(transactions) >= $4
+                                           ORDER BY `+order+`, entity_id LIMIT $5`, entityType, from, to, minTransactions, limit)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []EntityRisk
+    for rows.Next() {
+        var e EntityRisk
+        if err := rows.Scan(&e.EntityID, &e.Transactions, &e.Amount, &e.Fraud, &e.FraudAmount, &e.Declined); err != nil { return nil, err }
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) GeoBuckets(ctx context.Context, by string, precision int, from, to time.Time, limit int) ([]GeoBucket, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT CASE WHEN $5 > 0 THEN LEFT(entity_id, $5) ELSE entity_id END AS key,
+                                                  SUM(transactions)::bigint, SUM(amount)::float, SUM(fraud)::bigint, SUM(fraud_amount)::float
+                                           FROM entity_daily_stats WHERE entity_type = $1 AND day >= $2 AND day < $3
+                                           GROUP BY key ORDER BY SUM(fraud) DESC, SUM(transactions) DESC, key LIMIT $4`, by, from, to, limit, precision)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []GeoBucket
+    for rows.Next() {
+        var b GeoBucket
+        if err := rows.Scan(&b.Key, &b.Transactions, &b.Amount, &b.Fraud, &b.FraudAmount); err != nil { return nil, err }
+        out = append(out, b)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) AddUsage(ctx context.Context, usage []APIKeyUsage) error {
+    if len(usage) == 0 { return nil }
+    days := make([]time.Time, len(usage))
+    keys := make([]string, len(usage))
+    requests := make([]int64, len(usage))
+    transactions := make([]int64, len(usage))
+    for i, u := range usage { days[i], keys[i], requests[i], transactions[i] = u.Day.UTC(), u.APIKey, u.Requests, u.Transactions }
+    _, err := p.primary.Exec(ctx, `INSERT INTO api_usage_daily (day, api_key, requests, transactions)
+                                   SELECT d::date, k, r, t FROM unnest($1::timestamptz[], $2::text[], $3::bigint[], $4::bigint[]) AS u(d, k, r, t)
+                                   ON CONFLICT (day, api_key) DO UPDATE SET requests = api_usage_daily.requests + EXCLUDED.requests,
+                                                                            transactions = api_usage_daily.transactions + EXCLUDED.transactions`,
+        days, keys, requests, transactions)
+    return err
+}
+
+func (p *Postgres) Usage(ctx context.Context, apiKey string, from, to time.Time) ([]APIKeyUsage, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT day, api_key, requests, transactions FROM api_usage_daily
+                                           WHERE day >= $1 AND day < $2 AND ($3 = '' OR api_key = $3)
+                                           ORDER BY day, api_key`, from, to, apiKey)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []APIKeyUsage
+    for rows.Next() {
+        var u APIKeyUsage
+        if err := rows.Scan(&u.Day, &u.APIKey, &u.Requests, &u.Transactions); err != nil { return nil, err }
+        out = append(out, u)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) UsageByKey(ctx context.Context, from, to time.Time) ([]APIKeyUsage, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT api_key, SUM(requests)::bigint, SUM(transactions)::bigint FROM api_usage_daily
+                                           WHERE day >= $1 AND day < $2 GROUP BY api_key
+                                           ORDER BY SUM(transactions) DESC, SUM(requests) DESC, api_key`, from, to)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []APIKeyUsage
+    for rows.Next() {
+        var u APIKeyUsage
+        if err := rows.Scan(&u.APIKey, &u.Requests, &u.Transactions); err != nil { return nil, err }
+        out = append(out, u)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) AnalystStats(ctx context.Context, since time.Time) ([]AnalystStats, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT a.resolved_by, COUNT(*),
+                                                  COUNT(*) FILTER (WHERE a.resolution = 'FRAUD'),
+                                                  COUNT(*) FILTER (WHERE a.resolution = 'FALSE_POSITIVE'),
+                                                  COUNT(*) FILTER (WHERE l.labeled_at > a.resolved_at AND l.is_fraud <> (a.resolution = 'FRAUD')),
+                                                  AVG(EXTRACT(EPOCH FROM a.resolved_at - a.created_at)::float8),
+                                                  percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM a.resolved_at - a.created_at)::float8)
+                                           FROM fraud_alerts a LEFT JOIN transaction_labels l ON l.transaction_id = a.transaction_id
+                                           WHERE a.resolved_by IS NOT NULL AND a.resolved_at >= $1
+                                           GROUP BY a.resolved_by ORDER BY COUNT(*) DESC, a.resolved_by`, since)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []AnalystStats
+    for rows.Next() {
+        var s AnalystStats
+        var mean, p50 float64
+        if err := rows.Scan(&s.Analyst, &s.Handled, &s.Fraud, &s.FalsePositive, &s.Overturned, &mean, &p50); err != nil { return nil, err }
+        s.MeanResolution, s.P50Resolution = time.Duration(mean*float64(time.Second)), time.Duration(p50*float64(time.Second))
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) Closures(ctx context.Context, since time.Time) (closed, autoClosed int64, err error) {
+    err = p.reader(ctx).QueryRow(ctx, `SELECT COUNT(*), COUNT(*) FILTER (WHERE resolution = $2) FROM fraud_alerts WHERE resolved_at >= $1`,
+        since, ResolutionAutoClosedStale).Scan(&closed, &autoClosed)
+    return closed, autoClosed, err
+}
+
+func (p *Postgres) Queue(ctx context.Context) ([]QueueDepth, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT severity, COUNT(*), MIN(created_at), COUNT(sla_breached_at) FROM fraud_alerts
+                                           WHERE COALESCE(status, 'OPEN') = 'OPEN' GROUP BY severity
+                                           ORDER BY CASE severity WHEN 'CRITICAL' THEN 0 WHEN 'HIGH' THEN 1 WHEN 'MEDIUM' THEN 2 ELSE 3 END, severity`)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []QueueDepth
+    for rows.Next() {
+        var q QueueDepth
+        if err := rows.Scan(&q.Severity, &q.Open, &q.Oldest, &q.Breached); err != nil { return nil, err }
+        out = append(out, q)
+    }
+    return out, rows.Err()
+}
+
+const suppressionColumns = `id, COALESCE(merchant_id, ''), COALESCE(alert_type, ''), COALESCE(severity, ''), starts_at, ends_at, reason, created_by, created_at, expired_at`
+
+func scanSuppression(row pgx.Row) (Suppression, error) {
+    var s Suppression
+    err := row.Scan(&s.ID, &s.MerchantID, &s.AlertType, &s.Severity, &s.StartsAt, &s.EndsAt, &s.Reason, &s.CreatedBy, &s.CreatedAt, &s.ExpiredAt)
+    return s, err
+}
+
+func (p *Postgres) AddSuppression(ctx context.Context, s Suppression) (Suppression, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return s, err }
+    defer tx.Rollback(context.Background())
+    s, err = scanSuppression(tx.QueryRow(ctx, `INSERT INTO alert_suppressions (merchant_id, alert_type, severity, starts_at, ends_at, reason, created_by)
+                                               VALUES (NULLIF($1, ''), NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7) RETURNING `+suppressionColumns,
+        s.MerchantID, s.AlertType, s.Severity, s.StartsAt, s.EndsAt, s.Reason, s.CreatedBy))
+    if err != nil { return s, err }
+    if err := audit(ctx, tx, s.CreatedBy, "suppression.created", "suppression", strconv.FormatInt(s.ID, 10), s); err != nil { return s, err }
+    return s, tx.Commit(ctx)
+}
+
+func (p *Postgres) Suppressions(ctx context.Context, live bool) ([]Suppression, error) {
+    rows, err := p.reader(ctx).Query(ctx, `SELECT `+suppressionColumns+` FROM alert_suppressions WHERE NOT $1 OR expired_at IS NULL ORDER BY id DESC`, live)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Suppression
+    for rows.Next() {
+        s, err := scanSuppression(rows)
+        if err != nil { return nil, err }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) EndSuppression(ctx context.Context, id int64, actor string) (Suppression, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return Suppression{}, err }
+    defer tx.Rollback(context.Background())
+    s, err := scanSuppression(tx.QueryRow(ctx, `UPDATE alert_suppressions SET expired_at = now()
+                                                WHERE id = $1 AND expired_at IS NULL RETURNING `+suppressionColumns, id))
+    if errors.Is(err, pgx.ErrNoRows) {
+        var exists bool
+        if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM alert_suppressions WHERE id = $1)`, id).Scan(&exists); err != nil { return s, err }
+        if exists { return s, ErrNotOpen }
+        return s, ErrNotFound
+    }
+    if err != nil { return s, err }
+    if err := audit(ctx, tx, actor, "suppression.ended", "suppression", strconv.FormatInt(id, 10), map[string]*time.Time{"expired_at": s.ExpiredAt}); err != nil { return s, err }
+    return s, tx.Commit(ctx)
+}
+
+func (p *Postgres) ExpireSuppressions(ctx context.Context, now time.Time) ([]Suppression, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return nil, err }
+    defer tx.Rollback(context.Background())
+    rows, err := tx.Query(ctx, `UPDATE alert_suppressions SET expired_at = ends_at WHERE expired_at IS NULL AND ends_at <= $1 RETURNING `+suppressionColumns, now)
+    if err != nil { return nil, err }
+    var out []Suppression
+    for rows.Next() {
+        s, err := scanSuppression(rows)
+        if err != nil { rows.Close(); return nil, err }
+        out = append(out, s)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+    for _, s := range out {
+        if err := audit(ctx, tx, "system", "suppression.expired", "suppression", strconv.FormatInt(s.ID, 10), map[string]*time.Time{"expired_at": s.ExpiredAt}); err != nil { return nil, err }
+    }
+    return out, tx.Commit(ctx)
+}
+
+// audit records a change in the transaction that makes it.
+func audit(ctx context.Context, tx pgx.Tx, actor, action, entityType, entityID string, details interface{}) error {
+    b, err := json.Marshal(details)
+    if err != nil { return err }
+    _, err = tx.Exec(ctx, `INSERT INTO audit_log (actor, action, entity_type, entity_id, details) VALUES ($1, $2, $3, $4, $5)`, actor, action, entityType, entityID, string(b))
+    return err
+}
+
+func (p *Postgres) AuditLog(ctx context.Context, entityType, entityID string, page Page) ([]AuditEntry, error) {
+    after, args := "", []interface{}{entityType, entityID, page.Limit}
+    if page.After != nil {
+        id, err := strconv.ParseInt(page.After.ID, 10, 64)
+        if err != nil { return nil, fmt.Errorf("store: bad audit cursor ID %q", page.After.ID) }
+        after, args = ` AND (at, id) < ($4, $5)`, append(args, page.After.At, id)
+    }
+    rows, err := p.reader(ctx).Query(ctx, `SELECT id, at, actor, action, entity_type, entity_id, details FROM audit_log
+                                           WHERE ($1 = '' OR entity_type = $1) AND ($2 = '' OR entity_id = $2)`+after+` ORDER BY at DESC, id DESC LIMIT $3`, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []AuditEntry
+    for rows.Next() {
+        var e AuditEntry
+        var details []byte
+        if err := rows.Scan(&e.ID, &e.At, &e.Actor, &e.Action, &e.EntityType, &e.EntityID, &details); err != nil { return nil, err }
+        e.Details = details
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) Spill(ctx context.Context, topic string, key, payload []byte, contentType, cause string) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO kafka_outbox (topic, message_key, payload, content_type, error) VALUES ($1,$2,$3,$4,$5)`,
+        topic, string(key), payload, contentType, cause)
+    return err
+}
+
+const pendingOutbox = `SELECT id, topic, COALESCE(message_key, ''), payload, COALESCE(content_type, ''), COALESCE(error, ''), created_at FROM kafka_outbox
+                       WHERE delivered_at IS NULL AND ($1 = '' OR topic = $1) ORDER BY created_at, id LIMIT $2`
+
+func (p *Postgres) Pending(ctx context.Context, topic string, limit int) ([]OutboxMessage, error) {
+    return queryOutbox(ctx, p.primary, pendingOutbox, topic, limit)
+}
+
+func (p *Postgres) Relay(ctx context.Context, topic string, limit int, publish func(OutboxMessage) error) (int, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return 0, err }
+    defer tx.Rollback(ctx)
+    pending, err := queryOutbox(ctx, tx, pendingOutbox+` FOR UPDATE SKIP LOCKED`, topic, limit)
+    if err != nil { return 0, err }
+    delivered := 0
+    var perr error
+    for _, m := range pending {
+        if perr = publish(m); perr != nil { break }
+        if _, err := tx.Exec(ctx, `UPDATE kafka_outbox SET delivered_at = now() WHERE id = $1`, m.ID); err != nil { return 0, err }
+        delivered++
+    }
+    if err := tx.Commit(ctx); err != nil { return 0, err }
+    return delivered, perr
+}
+
+type querier interface {
+    Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func queryOutbox(ctx context.Context, q querier, sql, topic string, limit int) ([]OutboxMessage, error) {
+    rows, err := q.Query(ctx, sql, topic, limit)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []OutboxMessage
+    for rows.Next() {
+        var m OutboxMessage
+        var key string
+        if err := rows.Scan(&m.ID, &m.Topic, &key, &m.Payload, &m.ContentType, &m.Error, &m.CreatedAt); err != nil { return nil, err }
+        m.Key = []byte(key)
+        out = append(out, m)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) Watermarks(ctx context.Context, destination string) ([]Watermark, error) {
+    rows, err := p.primary.Query(ctx, `SELECT table_name, watermark, last_key, rows_exported, updated_at FROM export_watermarks WHERE destination = $1 ORDER BY table_name`, destination)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Watermark
+    for rows.Next() {
+        var w Watermark
+        if err := rows.Scan(&w.Table, &w.At, &w.Key, &w.Rows, &w.UpdatedAt); err != nil { return nil, err }
+        out = append(out, w)
+    }
+    return out, rows.Err()
+}
+
+// Changed reads the primary: a replica may not have the latest changes
+// yet, and its watermark would then skip them.
+func (p *Postgres) Changed(ctx context.Context, t ExportTable, w Watermark, until time.Time, limit int) ([]map[string]interface{}, error) {
+    cols := make([]string, len(t.Columns))
+    for i, c := range t.Columns {
+        cols[i] = c.Name
+        // Numerics would come back as pgtype.Numeric.
+        if c.Type == ColFloat { cols[i] = c.Name + "::float8 AS " + c.Name }
+    }
+    sql := `SELECT ` + strings.Join(cols, ", ") + ` FROM ` + t.Name +
+        ` WHERE (` + t.Changed + `, ` + t.Key + `) > ($1, $2) AND ` + t.Changed + ` < $3 ORDER BY ` + t.Changed + `, ` + t.Key + ` LIMIT $4`
+    rows, err := p.primary.Query(ctx, sql, w.At, w.Key, until, limit)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []map[string]interface{}
+    for rows.Next() {
+        vals, err := rows.Values()
+        if err != nil { return nil, err }
+        row := make(map[string]interface{}, len(vals))
+        for i, c := range t.Columns { row[c.Name] = vals[i] }
+        out = append(out, row)
+    }
+    return out, rows.Err()
+}
+
+func (p *Postgres) AdvanceWatermark(ctx context.Context, destination string, w Watermark, rows int) error {
+    _, err := p.primary.Exec(ctx, `INSERT INTO export_watermarks (destination, table_name, watermark, last_key, rows_exported) VALUES ($1, $2, $3, $4, $5)
+                                   ON CONFLICT (destination, table_name) DO UPDATE SET watermark = EXCLUDED.watermark, last_key = EXCLUDED.last_key,
+                                       rows_exported = export_watermarks.rows_exported + EXCLUDED.rows_exported, updated_at = now()`,
+        destination, w.Table, w.At, w.Key, rows)
+    return err
+}
+
+func (p *Postgres) CreateMonthlyPartition(ctx context.Context, table string, month time.Time) error {
+    _, err := p.primary.Exec(ctx, `SELECT create_monthly_partition($1, $2)`, table, month)
+    return err
+}
+
+// retentionDependents delete the rows that refer by ID to rows of a
+// partitioned table about to lose its partitions before $1: labels and
+// lifecycle events of transactions, comments on alerts. Partitioned tables
+// can't be the target of foreign keys, so nothing else would. The dropped
+// partitions hold what is older than the month $1 falls in, apart from the
+// default partition, which is never dropped.
+var retentionDependents = map[string][]string{
+    "transactions": {
+        `DELETE FROM transaction_labels WHERE transaction_id IN (SELECT transaction_id FROM transactions
+             WHERE timestamp < date_trunc('month', $1::timestamp) AND tableoid <> 'transactions_default'::regclass)`,
+        `DELETE FROM transaction_events WHERE transaction_id IN (SELECT transaction_id FROM transactions
+             WHERE timestamp < date_trunc('month', $1::timestamp) AND tableoid <> 'transactions_default'::regclass)`,
+    },
+    "fraud_alerts": {
+        `DELETE FROM alert_comments WHERE alert_id IN (SELECT alert_id FROM fraud_alerts
+             WHERE created_at < date_trunc('month', $1::timestamp) AND tableoid <> 'fraud_alerts_default'::regclass)`,
+    },
+}
+
+// DropPartitionsBefore drops the table's monthly partitions that end by
+// cutoff, and with them, in the same transaction, the rows elsewhere that
+// only mean something next to theirs.
+func (p *Postgres) DropPartitionsBefore(ctx context.Context, table string, cutoff time.Time) (int, error) {
+    tx, err := p.primary.Begin(ctx)
+    if err != nil { return 0, err }
+    defer tx.Rollback(context.Background())
+    for _, sql := range retentionDependents[table] {
+        if _, err := tx.Exec(ctx, sql, cutoff); err != nil { return 0, err }
+    }
+    var dropped int
+    if err := tx.QueryRow(ctx, `SELECT drop_partitions_before($1, $2)`, table, cutoff).Scan(&dropped); err != nil { return 0, err }
+    if table == "fraud_alerts" && dropped > 0 {
+        // Dropping a partition fires no triggers, so the IDs of the alerts
+        // that went with it are let go of here, and so are the cases left
+        // without an alert.
+        if _, err := tx.Exec(ctx, `DELETE FROM fraud_alert_ids r WHERE r.created_at < $1
+                                   AND NOT EXISTS (SELECT 1 FROM fraud_alerts a WHERE a.alert_id = r.alert_id AND a.created_at = r.created_at)`, cutoff); err != nil { return 0, err }
+        if _, err := tx.Exec(ctx, `DELETE FROM case_entities e USING fraud_cases c WHERE e.case_id = c.id AND c.last_alert_at < $1
+                                   AND NOT EXISTS (SELECT 1 FROM fraud_alerts a WHERE a.case_id = c.id)`, cutoff); err != nil { return 0, err }
+        if _, err := tx.Exec(ctx, `DELETE FROM fraud_cases c WHERE c.last_alert_at < $1
+                                   AND NOT EXISTS (SELECT 1 FROM fraud_alerts a WHERE a.case_id = c.id)`, cutoff); err != nil { return 0, err }
+    }
+    return dropped, tx.Commit(ctx)
+}
+
+// ReplicaLag is zero when the replica has replayed everything it received,
+// otherwise the age of the last replayed transaction. Comparing LSNs first
+// keeps an idle primary from looking like replication lag.
+func ReplicaLag(ctx context.Context, replica *pgxpool.Pool) (time.Duration, error) {
+    var secs float64
+    err := replica.QueryRow(ctx, `SELECT CASE
+                                      WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+                                      ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+                                  END`).Scan(&secs)
+    return time.Duration(secs * float64(time.Second)), err
+}
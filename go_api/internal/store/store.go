@@ -0,0 +1,731 @@
+// Package store holds the API's SQL behind small interfaces, so handlers can
+// be exercised against the generated mocks in store/mocks instead of a live
+// Postgres.
+package store
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "time"
+)
+
+//go:generate mockgen -source=store.go -destination=mocks/mock_store.go -package=mocks
+
+// ErrNotFound is returned by lookups that match no row.
+var ErrNotFound = errors.New("store: not found")
+
+// ErrExists is returned when an insert would duplicate a unique row.
+var ErrExists = errors.New("store: already exists")
+
+// ErrNotOpen is returned when acting on an alert that is already resolved,
+// a suppression that has already expired or a case that is closed.
+var ErrNotOpen = errors.New("store: not open")
+
+// The outcomes an analyst resolves an alert with: confirmed as fraud, or
+// overturned as a false positive.
+const (
+    ResolutionFraud         = "FRAUD"
+    ResolutionFalsePositive = "FALSE_POSITIVE"
+)
+
+// ResolutionReversed is how alerts are closed, with no analyst, when their
+// transaction is reversed.
+const ResolutionReversed = "REVERSED"
+
+// ResolutionAutoClosedStale is how alerts are closed, with no analyst, when
+// they were left open too long (see AlertStore.CloseStale).
+const ResolutionAutoClosedStale = "AUTO_CLOSED_STALE"
+
+// The statuses a transaction moves through after scoring: SCORED at first,
+// REVIEWED once an analyst takes it up, APPROVED or DECLINED once a review,
+// step-up check or label settles it, CHARGED_BACK once a chargeback comes
+// in.
+const (
+    TxScored      = "SCORED"
+    TxReviewed    = "REVIEWED"
+    TxApproved    = "APPROVED"
+    TxDeclined    = "DECLINED"
+    TxChargedBack = "CHARGED_BACK"
+)
+
+// The post-authorization events a transaction can go through.
+const (
+    EventCapture  = "CAPTURE"
+    EventRefund   = "REFUND"
+    EventVoid     = "VOID"
+    EventReversal = "REVERSAL"
+)
+
+// The lifecycle statuses those events leave a transaction in. Every
+// transaction starts AUTHORIZED.
+const (
+    StatusAuthorized        = "AUTHORIZED"
+    StatusCaptured          = "CAPTURED"
+    StatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+    StatusRefunded          = "REFUNDED"
+    StatusVoided            = "VOIDED"
+    StatusReversed          = "REVERSED"
+)
+
+type Transaction struct {
+    TransactionID   string
+    UserID          string
+    Amount          float64
+    Timestamp       time.Time
+    MerchantID      string
+    MerchantRisk    float64
+    MCC             *string
+    Channel         string
+    Country         *string
+    BehavioralScore *float64
+    SessionID       *string
+    FraudScore      float64
+    IsFraud         bool
+    Decision        *string
+    RiskFactors     []string
+    // VerificationResult is the step-up outcome, success or failure; nil
+    // until one is reported.
+    VerificationResult *string
+    // UpdatedAt is when the row last changed, e.g. by a rescore. Only Get
+    // reads it.
+    UpdatedAt time.Time
+    // Status is one of the Tx statuses, SCORED on Insert.
+    Status string
+    // Where its lifecycle events have left it; Insert leaves the defaults.
+    LifecycleStatus string
+    CapturedAmount  float64
+    RefundedAmount  float64
+    // Where it came from: the reported location with its geohash, and the
+    // GeoIP country of the IP. Only Insert writes these.
+    LocationLat, LocationLon *float64
+    Geohash                  *string
+    IPCountry                *string
+}
+
+type Alert struct {
+    AlertID       string    `json:"alert_id"`
+    TransactionID string    `json:"transaction_id"`
+    AlertType     string    `json:"alert_type"`
+    Severity      string    `json:"severity"`
+    Description   string    `json:"description"`
+    Confidence    float64   `json:"confidence_score"`
+    Status        string    `json:"status"`
+    CreatedAt     time.Time `json:"created_at"`
+    // AcknowledgedAt is when the alert was first assigned or resolved, and
+    // SLABreachedAt when it went past its severity's acknowledgement target
+    // without that.
+    AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+    SLABreachedAt  *time.Time `json:"sla_breached_at,omitempty"`
+    // Only CaseStore, Resolve and Assign fill these.
+    UserID     string     `json:"user_id,omitempty"`
+    CaseID     *int64     `json:"case_id,omitempty"`
+    AssignedTo *string    `json:"assigned_to,omitempty"`
+    ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+    ResolvedBy *string    `json:"resolved_by,omitempty"`
+    Resolution *string    `json:"resolution,omitempty"`
+}
+
+// Case groups the alerts on a user, device or card into one investigation.
+// Entities are everything its alerts have linked it by.
+type Case struct {
+    ID          int64        `json:"case_id"`
+    Status      string       `json:"status"`
+    AlertCount  int          `json:"alert_count"`
+    OpenedAt    time.Time    `json:"opened_at"`
+    LastAlertAt time.Time    `json:"last_alert_at"`
+    ClosedAt    *time.Time   `json:"closed_at,omitempty"`
+    ClosedBy    *string      `json:"closed_by,omitempty"`
+    Entities    []CaseEntity `json:"entities"`
+}
+
+// CaseEntity is a user, device or card a case is linked by.
+type CaseEntity struct {
+    Type  string `json:"type"`
+    Value string `json:"value"`
+}
+
+// AlertComment is an analyst's note on an alert. ParentID is set on a
+// reply; Replies is only filled when the thread is assembled.
+type AlertComment struct {
+    ID        int64          `json:"id"`
+    AlertID   string         `json:"alert_id"`
+    ParentID  *int64         `json:"parent_id,omitempty"`
+    Author    string         `json:"author"`
+    Body      string         `json:"body"`
+    CreatedAt time.Time      `json:"created_at"`
+    Replies   []AlertComment `json:"replies,omitempty"`
+}
+
+// CaseTransaction is a transaction as a case file reports it: what was
+// decided, when, and what was learned about it later.
+type CaseTransaction struct {
+    Transaction
+    VerificationMethod *string
+    CreatedAt          time.Time
+    UpdatedAt          time.Time
+    // Label is nil until the transaction is labeled.
+    Label       *bool
+    LabelSource *string
+    LabeledAt   *time.Time
+}
+
+// OutboxMessage is an event the event bus rejected, awaiting redelivery.
+type OutboxMessage struct {
+    ID          int64
+    Topic       string
+    Key         []byte
+    Payload     []byte
+    ContentType string
+    Error       string
+    CreatedAt   time.Time
+}
+
+// PartitionedTables are range-partitioned by month (see
+// migrations/000002_partition_by_month.up.sql).
+var PartitionedTables = []string{"transactions", "fraud_alerts"}
+
+// Column types of exported tables.
+const (
+    ColString  = "string"
+    ColFloat   = "float"
+    ColBool    = "bool"
+    ColTime    = "time"
+    ColStrings = "strings"
+)
+
+type ExportColumn struct {
+    Name string
+    Type string
+}
+
+// ExportTable is a table the warehouse export copies. Rows are keyed by Key
+// and picked up by Changed, the time they were inserted or last updated
+// (see migrations/000013_warehouse_export.up.sql).
+type ExportTable struct {
+    Name      string
+    Key       string
+    Changed   string
+    Partition string // time column the warehouse table is partitioned on, if any
+    Columns   []ExportColumn
+}
+
+var ExportTables = []ExportTable{
+    {Name: "transactions", Key: "transaction_id", Changed: "updated_at", Partition: "timestamp", Columns: []ExportColumn{
+        {"transaction_id", ColString}, {"user_id", ColString}, {"amount", ColFloat}, {"timestamp", ColTime},
+        {"merchant_id", ColString}, {"mcc", ColString}, {"channel", ColString}, {"country", ColString},
+        {"fraud_score", ColFloat}, {"is_fraud", ColBool}, {"decision", ColString}, {"risk_factors", ColStrings},
+        {"behavioral_score", ColFloat}, {"verification_method", ColString}, {"verification_result", ColString},
+        {"created_at", ColTime}, {"updated_at", ColTime},
+    }},
+    {Name: "fraud_alerts", Key: "alert_id", Changed: "updated_at", Partition: "created_at", Columns: []ExportColumn{
+        {"alert_id", ColString}, {"transaction_id", ColString}, {"alert_type", ColString}, {"severity", ColString},
+        {"description", ColString}, {"confidence_score", ColFloat}, {"status", ColString},
+        {"created_at", ColTime}, {"resolved_at", ColTime}, {"updated_at", ColTime},
+    }},
+    {Name: "transaction_labels", Key: "transaction_id", Changed: "labeled_at", Columns: []ExportColumn{
+        {"transaction_id", ColString}, {"is_fraud", ColBool}, {"source", ColString}, {"labeled_at", ColTime},
+    }},
+}
+
+// Watermark is how far a table has been exported: every row changed before
+// At, or at At with a key up to Key. Rows counts the rows exported so far.
+type Watermark struct {
+    Table     string    `json:"table"`
+    At        time.Time `json:"watermark"`
+    Key       string    `json:"last_key"`
+    Rows      int64     `json:"rows_exported"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserProfile is what the cache warmer preloads for an active user.
+type UserProfile struct {
+    UserID    string
+    RiskScore float64
+    AvgAmount float64
+}
+
+// TravelNotice is a window in which the user expects to transact in
+// Countries.
+type TravelNotice struct {
+    ID        int64     `json:"id"`
+    UserID    string    `json:"user_id"`
+    StartsAt  time.Time `json:"starts_at"`
+    EndsAt    time.Time `json:"ends_at"`
+    Countries []string  `json:"countries"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// RuleStats summarize the transactions a rule (risk factor) fired on.
+type RuleStats struct {
+    Hits           int64
+    Declined       int64
+    DeclinedAmount float64
+    // Labeled hits have a label; LabeledFraud of them were fraud.
+    Labeled      int64
+    LabeledFraud int64
+}
+
+// FraudSummary totals the transactions of a period by decision and
+// outcome. LossesPrevented is the amount declined that was labeled fraud
+// or, while unlabeled, scored as fraud; LossesMissed is the amount labeled
+// fraud that was not declined.
+type FraudSummary struct {
+    Transactions    int64
+    Amount          float64
+    Approved        int64
+    Review          int64
+    Declined        int64
+    DeclinedAmount  float64
+    Flagged         int64 // scored as fraud
+    LabeledFraud    int64
+    LossesPrevented float64
+    LossesMissed    float64
+}
+
+// AlertDisposition counts the alerts of one type and severity raised in a
+// period by where they stand: still open, closed or suppressed, and whether
+// the transaction was since labeled fraud (confirmed) or legitimate (false
+// positive).
+type AlertDisposition struct {
+    AlertType      string
+    Severity       string
+    Raised         int64
+    Open           int64
+    Closed         int64
+    Suppressed     int64
+    ConfirmedFraud int64
+    FalsePositive  int64
+}
+
+// EntityRisk is one merchant's, user's or device's totals over a range of
+// daily rollups. Fraud counts labeled fraud, or the score's verdict where
+// there is no label.
+type EntityRisk struct {
+    EntityID     string
+    Transactions int64
+    Amount       float64
+    Fraud        int64
+    FraudAmount  float64
+    Declined     int64
+}
+
+// GeoBucket is the totals of one country or geohash cell over a range of
+// daily rollups, counting fraud as EntityRisk does.
+type GeoBucket struct {
+    Key          string
+    Transactions int64
+    Amount       float64
+    Fraud        int64
+    FraudAmount  float64
+}
+
+// The metrics TopEntities ranks by: the share of transactions that were
+// fraud, or the amount transacted.
+const (
+    MetricFraudRate = "fraud_rate"
+    MetricVolume    = "volume"
+)
+
+// APIKeyUsage is one API key's traffic on one UTC day, or over a range of
+// days where Day is unset.
+type APIKeyUsage struct {
+    Day          time.Time
+    // APIKey is the key's api_keys name, or anonymous; never the key.
+    APIKey       string
+    Requests     int64
+    Transactions int64
+}
+
+// AnalystStats summarize the alerts one analyst resolved. Overturned counts
+// resolutions that a label recorded afterwards contradicts.
+type AnalystStats struct {
+    Analyst        string
+    Handled        int64
+    Fraud          int64
+    FalsePositive  int64
+    Overturned     int64
+    MeanResolution time.Duration
+    P50Resolution  time.Duration
+}
+
+// QueueDepth is the open alerts of one severity.
+type QueueDepth struct {
+    Severity string
+    Open     int64
+    Oldest   time.Time
+    // Breached counts the open alerts past their SLA.
+    Breached int64
+}
+
+// Suppression silences the alerts matching it between StartsAt and EndsAt;
+// empty criteria match any alert. ExpiredAt is set once it no longer
+// applies, at EndsAt or when ended early.
+type Suppression struct {
+    ID         int64      `json:"id"`
+    MerchantID string     `json:"merchant_id,omitempty"`
+    AlertType  string     `json:"alert_type,omitempty"`
+    Severity   string     `json:"severity,omitempty"`
+    StartsAt   time.Time  `json:"starts_at"`
+    EndsAt     time.Time  `json:"ends_at"`
+    Reason     string     `json:"reason"`
+    CreatedBy  string     `json:"created_by"`
+    CreatedAt  time.Time  `json:"created_at"`
+    ExpiredAt  *time.Time `json:"expired_at,omitempty"`
+}
+
+// AuditEntry is one administrative change, recorded with the change. Alert
+// activity is logged under the "alert" entity type.
+type AuditEntry struct {
+    ID         int64           `json:"id"`
+    At         time.Time       `json:"at"`
+    Actor      string          `json:"actor"`
+    Action     string          `json:"action"`
+    EntityType string          `json:"entity_type"`
+    EntityID   string          `json:"entity_id"`
+    Details    json.RawMessage `json:"details,omitempty"`
+}
+
+// LabeledScore is a labeled transaction's score and outcome.
+type LabeledScore struct {
+    Score   float64
+    Amount  float64
+    IsFraud bool
+}
+
+// BlocklistEntry is a country or IP range that is blocked or high-risk.
+type BlocklistEntry struct {
+    ID        int64     `json:"id"`
+    Kind      string    `json:"kind"`   // country or cidr
+    Value     string    `json:"value"`
+    Action    string    `json:"action"` // block or risk
+    Reason    string    `json:"reason,omitempty"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// SpendLimits are a user's daily and weekly limits; nil means unset.
+type SpendLimits struct {
+    Daily  *float64 `json:"daily"`
+    Weekly *float64 `json:"weekly"`
+}
+
+// LifecycleEvent is a capture, refund, void or reversal of a transaction.
+// Source is where it came in: api or kafka.
+type LifecycleEvent struct {
+    EventID       string    `json:"event_id"`
+    TransactionID string    `json:"transaction_id"`
+    Type          string    `json:"type"`
+    Amount        float64   `json:"amount"`
+    OccurredAt    time.Time `json:"occurred_at"`
+    Source        string    `json:"source"`
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+// Lifecycle is what RecordEvent hands to apply: a transaction's authorized
+// Amount and decision, and where its events have left it.
+type Lifecycle struct {
+    TransactionID string
+    UserID        string
+    Timestamp     time.Time
+    Decision      *string
+    Amount        float64
+    Status        string
+    Captured      float64
+    Refunded      float64
+}
+
+// EventOutcome is the event as recorded, the transaction's lifecycle before
+// and after it, and the alerts it closed.
+type EventOutcome struct {
+    Event         LifecycleEvent
+    Before, After Lifecycle
+    ClosedAlerts  []string
+}
+
+// Page asks a listing for at most Limit rows, starting after the row at
+// After, or at the top when After is nil. Listings are ordered newest first
+// by a time and then by ID, so a page boundary stays put while rows are
+// added above it.
+type Page struct {
+    Limit int
+    After *Cursor
+}
+
+// Cursor is the position of a row in a listing: its sort time and its ID.
+type Cursor struct {
+    At time.Time
+    ID string
+}
+
+// User is a user as listed by Users.
+type User struct {
+    UserID    string    `json:"user_id"`
+    RiskScore float64   `json:"risk_score"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserTenure is when a user was created and made their first transaction.
+type UserTenure struct {
+    CreatedAt          time.Time
+    FirstTransactionAt *time.Time // nil until the processor has seen one
+}
+
+type TransactionStore interface {
+    Insert(ctx context.Context, t Transaction) error
+    // Get looks up a transaction whose timestamp lies in [from, to]; the
+    // bounds let Postgres skip unrelated monthly partitions.
+    Get(ctx context.Context, id string, from, to time.Time) (Transaction, error)
+    // AverageAmount returns the user's mean amount since the given time and
+    // false if they have no transactions in that window.
+    AverageAmount(ctx context.Context, userID string, since time.Time) (float64, bool, error)
+    // UpdateScore overwrites the score and decision of a transaction found
+    // as by Get.
+    UpdateScore(ctx context.Context, id string, from, to time.Time, score float64, isFraud bool, decision string, riskFactors []string) error
+    // RecordVerification stores a step-up outcome and the resulting
+    // decision, returning ErrNotFound unless the transaction exists without
+    // one.
+    RecordVerification(ctx context.Context, id string, from, to time.Time, method, result, decision string) error
+    // Range calls fn with every transaction whose timestamp lies in
+    // [from, to), oldest first, filling the ID, user, amount, channel and
+    // score. An error from fn stops the scan and is returned.
+    Range(ctx context.Context, from, to time.Time, fn func(Transaction) error) error
+    // Transactions returns a page of the transactions with a timestamp in
+    // [from, to), only the user's and with the status when those are set,
+    // newest first by timestamp and transaction ID. It fills what Get does
+    // but UpdatedAt.
+    Transactions(ctx context.Context, userID, status string, from, to time.Time, page Page) ([]Transaction, error)
+    // SetStatus moves a transaction found as by Get to status if it is in
+    // one of the statuses in only, logging the change on actor's behalf
+    // with the reason, and reports whether it moved.
+    SetStatus(ctx context.Context, id string, from, to time.Time, status string, only []string, actor, reason string) (bool, error)
+}
+
+type LifecycleStore interface {
+    // RecordEvent locks the transaction found as by Get and stores e with
+    // the lifecycle apply makes of the transaction's, apply being free to
+    // fill in e's amount. Moving to REVERSED also resolves the
+    // transaction's open alerts as REVERSED. An error from apply is
+    // returned as it is; an event ID already recorded is ErrExists, with
+    // the lifecycle unchanged.
+    RecordEvent(ctx context.Context, id string, from, to time.Time, e LifecycleEvent, apply func(Lifecycle, *LifecycleEvent) (Lifecycle, error)) (EventOutcome, error)
+    // Events returns the transaction's events in the order recorded.
+    Events(ctx context.Context, id string) ([]LifecycleEvent, error)
+}
+
+type UserStore interface {
+    // Ensure creates the user with the given risk score if they don't exist.
+    // A non-nil createdAt earlier than the stored creation time replaces it.
+    Ensure(ctx context.Context, userID string, risk float64, createdAt *time.Time) error
+    RiskScore(ctx context.Context, userID string) (float64, error)
+    Tenure(ctx context.Context, userID string) (UserTenure, error)
+    // AdjustRisk adds delta to the user's risk score, clamped to [0, 1], and
+    // returns the new score.
+    AdjustRisk(ctx context.Context, userID string, delta float64) (float64, error)
+    // HotUsers returns up to limit users ranked by transactions since
+    // activeSince, with their average amount since historySince.
+    HotUsers(ctx context.Context, activeSince, historySince time.Time, limit int) ([]UserProfile, error)
+    // Users returns a page of users, newest first by creation time and
+    // user ID.
+    Users(ctx context.Context, page Page) ([]User, error)
+}
+
+type MerchantStore interface {
+    // MCC returns the latest merchant category code the processor recorded
+    // for the merchant.
+    MCC(ctx context.Context, merchantID string) (string, error)
+}
+
+// KYCStore holds each user's KYC outcome.
+type KYCStore interface {
+    KYCStatus(ctx context.Context, userID string) (string, error)
+    SetKYCStatus(ctx context.Context, userID, status string) error
+}
+
+// RuleStore records labels and reports rule effectiveness against them.
+type RuleStore interface {
+    Label(ctx context.Context, transactionID string, isFraud bool, source string) error
+    RuleStats(ctx context.Context, rule string, since time.Time) (RuleStats, error)
+    // LabeledScores returns every labeled transaction since the given time.
+    LabeledScores(ctx context.Context, since time.Time) ([]LabeledScore, error)
+}
+
+type BlocklistStore interface {
+    Blocklist(ctx context.Context) ([]BlocklistEntry, error)
+    // AddBlocklistEntry returns ErrExists if the kind and value are
+    // already listed.
+    AddBlocklistEntry(ctx context.Context, e BlocklistEntry) (BlocklistEntry, error)
+    DeleteBlocklistEntry(ctx context.Context, id int64) error
+}
+
+// LimitStore holds per-user overrides of the configured spend limits.
+type LimitStore interface {
+    SpendLimits(ctx context.Context, userID string) (SpendLimits, error)
+    SetSpendLimits(ctx context.Context, userID string, l SpendLimits) error
+}
+
+type TravelStore interface {
+    // AddTravelNotice stores n and returns it with its ID and CreatedAt.
+    AddTravelNotice(ctx context.Context, n TravelNotice) (TravelNotice, error)
+    // TravelNotices returns the user's notices that end after now, earliest
+    // first.
+    TravelNotices(ctx context.Context, userID string, now time.Time) ([]TravelNotice, error)
+    DeleteTravelNotice(ctx context.Context, userID string, id int64) error
+}
+
+type AlertStore interface {
+    // List returns a page of the alerts with the status, newest first by
+    // creation time and alert ID.
+    List(ctx context.Context, status string, page Page) ([]Alert, error)
+    // Escalate raises the transaction's open alerts created since the given
+    // time to CRITICAL, or stores a if it has none.
+    Escalate(ctx context.Context, a Alert, since time.Time) error
+    // Resolve closes an open alert with the analyst's resolution and returns
+    // it, or ErrNotFound or ErrNotOpen.
+    Resolve(ctx context.Context, alertID, analyst, resolution string) (Alert, error)
+    // Assign hands an open alert to assignee, or unassigns it when assignee
+    // is empty, and returns it, or ErrNotFound or ErrNotOpen.
+    Assign(ctx context.Context, alertID, assignee, actor string) (Alert, error)
+    // AddComment stores c and returns it with its ID and CreatedAt, or
+    // ErrNotFound for an unknown alert or a parent on another alert.
+    AddComment(ctx context.Context, c AlertComment) (AlertComment, error)
+    // Comments returns the alert's comments oldest first, unthreaded.
+    Comments(ctx context.Context, alertID string) ([]AlertComment, error)
+    // CloseStale closes up to limit of the oldest open, unassigned alerts of
+    // the severities raised before the given time, as
+    // ResolutionAutoClosedStale, and returns them. Alerts another caller is
+    // closing at the same time are skipped.
+    CloseStale(ctx context.Context, severities []string, before time.Time, limit int) ([]Alert, error)
+    // BreachSLA marks up to limit of the oldest open, unacknowledged alerts
+    // of the severity raised before the given time as having breached their
+    // SLA, and returns them. Alerts already marked are left alone, so each
+    // breach is returned once.
+    BreachSLA(ctx context.Context, severity string, before time.Time, limit int) ([]Alert, error)
+}
+
+// SuppressionStore manages alert suppressions. Every change is written to
+// the audit log in the same transaction.
+type SuppressionStore interface {
+    AddSuppression(ctx context.Context, s Suppression) (Suppression, error)
+    // Suppressions lists suppressions newest first; live limits them to
+    // those not yet expired.
+    Suppressions(ctx context.Context, live bool) ([]Suppression, error)
+    // EndSuppression expires a suppression now on actor's behalf, or returns
+    // ErrNotFound or ErrNotOpen.
+    EndSuppression(ctx context.Context, id int64, actor string) (Suppression, error)
+    // ExpireSuppressions expires those whose window is over by now and
+    // returns them.
+    ExpireSuppressions(ctx context.Context, now time.Time) ([]Suppression, error)
+}
+
+type AuditStore interface {
+    // AuditLog returns a page of entries, newest first by time and ID, for
+    // the entity type and ID when they are set. A cursor's ID is the
+    // entry's.
+    AuditLog(ctx context.Context, entityType, entityID string, page Page) ([]AuditEntry, error)
+}
+
+// StatsStore maintains the daily per-entity rollups, which also cover
+// countries and geohash cells, and ranks entities from them.
+type StatsStore interface {
+    // RollUp recomputes the rollups of the UTC days from from up to but
+    // excluding to's day.
+    RollUp(ctx context.Context, from, to time.Time) error
+    // TopEntities ranks the entities of the type (merchant, user or device)
+    // by metric over the days in [from, to), leaving out those with fewer
+    // than minTransactions.
+    TopEntities(ctx context.Context, entityType, metric string, from, to time.Time, minTransactions int64, limit int) ([]EntityRisk, error)
+    // GeoBuckets totals the days in [from, to) by "country" or by "geohash"
+    // cell, truncating geohashes to precision characters, most fraud first.
+    // Countries are the IP's, or the transaction's where GeoIP has none.
+    GeoBuckets(ctx context.Context, by string, precision int, from, to time.Time, limit int) ([]GeoBucket, error)
+}
+
+// AnalystStore reports on the alert queue and the analysts working it.
+type AnalystStore interface {
+    // AnalystStats covers the alerts resolved since the given time, busiest
+    // analyst first.
+    AnalystStats(ctx context.Context, since time.Time) ([]AnalystStats, error)
+    // Queue returns the open alerts by severity.
+    Queue(ctx context.Context) ([]QueueDepth, error)
+    // Closures counts the alerts resolved since the given time, and how many
+    // of them were auto-closed as stale.
+    Closures(ctx context.Context, since time.Time) (closed, autoClosed int64, err error)
+}
+
+// OutboxStore records events the event bus could not deliver.
+type OutboxStore interface {
+    Spill(ctx context.Context, topic string, key, payload []byte, contentType, cause string) error
+    // Pending returns up to limit undelivered messages, oldest first, for
+    // topic or for every topic when it is empty.
+    Pending(ctx context.Context, topic string, limit int) ([]OutboxMessage, error)
+    // Relay passes the messages Pending would return to publish, marking
+    // each delivered once publish returns nil, and stops at the first error.
+    // Rows are locked while relayed, so concurrent relays never send the
+    // same message twice. It returns how many were delivered.
+    Relay(ctx context.Context, topic string, limit int, publish func(OutboxMessage) error) (int, error)
+}
+
+// CaseStore serves the cases the processor groups alerts into, and gathers
+// the records behind a suspicious activity report.
+type CaseStore interface {
+    // Cases returns up to limit cases with the status, most recent alert
+    // first.
+    Cases(ctx context.Context, status string, limit int) ([]Case, error)
+    // Case returns ErrNotFound for an unknown case ID.
+    Case(ctx context.Context, id int64) (Case, error)
+    // CaseAlerts returns the case's alerts, oldest first.
+    CaseAlerts(ctx context.Context, id int64) ([]Alert, error)
+    // CloseCase closes an open case on the analyst's behalf and returns it,
+    // or ErrNotFound or ErrNotOpen. Later alerts on its entities open a new
+    // case.
+    CloseCase(ctx context.Context, id int64, analyst string) (Case, error)
+    // Alert returns ErrNotFound for an unknown alert ID.
+    Alert(ctx context.Context, alertID string) (Alert, error)
+    // UserAlerts returns the alerts created in [from, to) on the user's
+    // transactions, oldest first.
+    UserAlerts(ctx context.Context, userID string, from, to time.Time) ([]Alert, error)
+    // UserActivity returns up to limit of the user's transactions with a
+    // timestamp in [from, to), oldest first.
+    UserActivity(ctx context.Context, userID string, from, to time.Time, limit int) ([]CaseTransaction, error)
+}
+
+// ReportStore aggregates the scheduled fraud reports.
+type ReportStore interface {
+    // FraudSummary totals the transactions with a timestamp in [from, to).
+    FraudSummary(ctx context.Context, from, to time.Time) (FraudSummary, error)
+    // AlertDispositions covers the alerts created in [from, to), most
+    // raised first.
+    AlertDispositions(ctx context.Context, from, to time.Time) ([]AlertDisposition, error)
+}
+
+// ExportStore tracks the warehouse export per destination.
+type ExportStore interface {
+    // Watermarks returns the destination's watermark for each table it has
+    // exported; tables not yet exported have none.
+    Watermarks(ctx context.Context, destination string) ([]Watermark, error)
+    // Changed returns up to limit rows of t changed after w and before
+    // until, in watermark order, as column name to value.
+    Changed(ctx context.Context, t ExportTable, w Watermark, until time.Time, limit int) ([]map[string]interface{}, error)
+    // AdvanceWatermark moves the destination's watermark for w.Table to w
+    // and adds rows to its count.
+    AdvanceWatermark(ctx context.Context, destination string, w Watermark, rows int) error
+}
+
+// PartitionStore manages the monthly partitions of transactions and
+// fraud_alerts.
+type PartitionStore interface {
+    CreateMonthlyPartition(ctx context.Context, table string, month time.Time) error
+    DropPartitionsBefore(ctx context.Context, table string, cutoff time.Time) (int, error)
+}
+
+// UsageStore meters API traffic per key per day for billing.
+type UsageStore interface {
+    // AddUsage adds each entry's counts to its key's totals for its day.
+    AddUsage(ctx context.Context, usage []APIKeyUsage) error
+    // Usage returns the daily totals of the days in [from, to), by day then
+    // key, for apiKey or for every key when it is empty.
+    Usage(ctx context.Context, apiKey string, from, to time.Time) ([]APIKeyUsage, error)
+    // UsageByKey totals the days in [from, to) per key, busiest first.
+    UsageByKey(ctx context.Context, from, to time.Time) ([]APIKeyUsage, error)
+}
@@ -0,0 +1,187 @@
+package warehouse
+
+import (
+    "bytes"
+    "context"
+    "crypto/rsa"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+)
+
+const bigQueryAPI = "https://bigquery.googleapis.com/bigquery/v2/projects/"
+
+var bigQueryTypes = map[string]string{
+    store.ColString:  "STRING",
+    store.ColFloat:   "FLOAT64",
+    store.ColBool:    "BOOL",
+    store.ColTime:    "TIMESTAMP",
+    store.ColStrings: "ARRAY<STRING>",
+}
+
+// bigQuery runs DDL and MERGE statements through the jobs.query REST API,
+// authenticated as a service account.
+type bigQuery struct {
+    cfg      config.BigQuery
+    email    string
+    tokenURI string
+    key      *rsa.PrivateKey
+
+    mu      sync.Mutex
+    token   string
+    expires time.Time
+}
+
+func newBigQuery(cfg config.BigQuery) (*bigQuery, error) {
+    b, err := os.ReadFile(cfg.CredentialsFile)
+    if err != nil { return nil, err }
+    var sa struct {
+        Type        string `json:"type"`
+        ClientEmail string `json:"client_email"`
+        PrivateKey  string `json:"private_key"`
+        TokenURI    string `json:"token_uri"`
+    }
+    if err := json.Unmarshal(b, &sa); err != nil { return nil, fmt.Errorf("%s: %w", cfg.CredentialsFile, err) }
+    if sa.Type != "service_account" { return nil, fmt.Errorf("%s is not a service account key", cfg.CredentialsFile) }
+    key, err := parseRSAKey([]byte(sa.PrivateKey))
+    if err != nil { return nil, fmt.Errorf("%s: %w", cfg.CredentialsFile, err) }
+    if sa.TokenURI == "" { sa.TokenURI = "https://oauth2.googleapis.com/token" }
+    return &bigQuery{cfg: cfg, email: sa.ClientEmail, tokenURI: sa.TokenURI, key: key}, nil
+}
+
+func (b *bigQuery) Name() string { return "bigquery:" + b.cfg.Project + "." + b.cfg.Dataset }
+
+func (b *bigQuery) table(name string) string { return "`" + b.cfg.Project + "." + b.cfg.Dataset + "." + name + "`" }
+
+func (b *bigQuery) EnsureTable(ctx context.Context, t store.ExportTable) error {
+    cols := make([]string, len(t.Columns))
+    for i, c := range t.Columns { cols[i] = c.Name + " " + bigQueryTypes[c.Type] }
+    ddl := "CREATE TABLE IF NOT EXISTS " + b.table(t.Name) + " (" + strings.Join(cols, ", ") + ")"
+    if t.Partition != "" { ddl += " PARTITION BY DATE(" + t.Partition + ")" }
+    return b.query(ctx, ddl, nil)
+}
+
+// Upsert passes the batch as one JSON string parameter and unpacks it in
+// the MERGE's source query.
+func (b *bigQuery) Upsert(ctx context.Context, t store.ExportTable, rows []map[string]interface{}) error {
+    data, err := rowsJSON(t, rows)
+    if err != nil { return err }
+    fields := make([]string, len(t.Columns))
+    for i, c := range t.Columns {
+        path := "'$." + c.Name + "'"
+        switch c.Type {
+        case store.ColStrings:
+            // Array columns can't hold NULL.
+            fields[i] = "IFNULL(JSON_VALUE_ARRAY(r, " + path + "), [])"
+        case store.ColString:
+            fields[i] = "JSON_VALUE(r, " + path + ")"
+        case store.ColTime:
+            fields[i] = "TIMESTAMP(JSON_VALUE(r, " + path + "))"
+        default:
+            fields[i] = "CAST(JSON_VALUE(r, " + path + ") AS " + bigQueryTypes[c.Type] + ")"
+        }
+        fields[i] += " AS " + c.Name
+    }
+    set, cols, vals := mergeColumns(t)
+    q := "MERGE " + b.table(t.Name) + " t USING (SELECT " + strings.Join(fields, ", ") + " FROM UNNEST(JSON_QUERY_ARRAY(@rows)) AS r) s" +
+        " ON t." + t.Key + " = s." + t.Key +
+        " WHEN MATCHED THEN UPDATE SET " + set +
+        " WHEN NOT MATCHED THEN INSERT (" + cols + ") VALUES (" + vals + ")"
+    return b.query(ctx, q, map[string]string{"rows": data})
+}
+
+// query runs q with string parameters and waits for it to finish.
+func (b *bigQuery) query(ctx context.Context, q string, params map[string]string) error {
+    req := map[string]interface{}{"query": q, "useLegacySql": false, "location": b.cfg.Location, "timeoutMs": 60000}
+    if len(params) > 0 {
+        var qp []interface{}
+        for name, v := range params {
+            qp = append(qp, map[string]interface{}{"name": name, "parameterType": map[string]string{"type": "STRING"}, "parameterValue": map[string]string{"value": v}})
+        }
+        req["parameterMode"] = "NAMED"
+        req["queryParameters"] = qp
+    }
+    body, _ := json.Marshal(req)
+    var res bigQueryResult
+    if err := b.do(ctx, http.MethodPost, bigQueryAPI+b.cfg.Project+"/queries", body, &res); err != nil { return err }
+    for !res.JobComplete {
+        poll := bigQueryAPI + b.cfg.Project + "/queries/" + url.PathEscape(res.JobReference.JobID) + "?maxResults=0&timeoutMs=60000&location=" + url.QueryEscape(res.JobReference.Location)
+        res = bigQueryResult{}
+        if err := b.do(ctx, http.MethodGet, poll, nil, &res); err != nil { return err }
+    }
+    if len(res.Errors) > 0 { return fmt.Errorf("bigquery: %s", res.Errors[0].Message) }
+    return nil
+}
+
+type bigQueryResult struct {
+    JobComplete  bool `json:"jobComplete"`
+    JobReference struct {
+        JobID    string `json:"jobId"`
+        Location string `json:"location"`
+    } `json:"jobReference"`
+    Errors []struct {
+        Message string `json:"message"`
+    } `json:"errors"`
+}
+
+func (b *bigQuery) do(ctx context.Context, method, u string, body []byte, out interface{}) error {
+    token, err := b.accessToken(ctx)
+    if err != nil { return err }
+    req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+    if err != nil { return err }
+    req.Header.Set("Authorization", "Bearer "+token)
+    if body != nil { req.Header.Set("Content-Type", "application/json") }
+    resp, err := httpClient.Do(req)
+    if err != nil { return err }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 {
+        var e struct{ Error struct{ Message string `json:"message"` } `json:"error"` }
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+        if json.Unmarshal(msg, &e) == nil && e.Error.Message != "" { return fmt.Errorf("bigquery: %s: %s", resp.Status, e.Error.Message) }
+        return fmt.Errorf("bigquery: %s: %s", resp.Status, bytes.TrimSpace(msg))
+    }
+    return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken exchanges a signed assertion for an OAuth token, reusing it
+// until shortly before it expires.
+func (b *bigQuery) accessToken(ctx context.Context) (string, error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.token != "" && time.Now().Before(b.expires) { return b.token, nil }
+    now := time.Now()
+    assertion, err := signJWT(b.key, map[string]interface{}{
+        "iss":   b.email,
+        "scope": "https://www.googleapis.com/auth/bigquery",
+        "aud":   b.tokenURI,
+        "iat":   now.Unix(),
+        "exp":   now.Add(time.Hour).Unix(),
+    })
+    if err != nil { return "", err }
+    form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {assertion}}
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.tokenURI, strings.NewReader(form.Encode()))
+    if err != nil { return "", err }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    resp, err := httpClient.Do(req)
+    if err != nil { return "", err }
+    defer resp.Body.Close()
+    var tok struct {
+        AccessToken string `json:"access_token"`
+        ExpiresIn   int    `json:"expires_in"`
+        Error       string `json:"error_description"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil { return "", err }
+    if tok.AccessToken == "" { return "", errors.New("bigquery token: " + tok.Error) }
+    b.token, b.expires = tok.AccessToken, now.Add(time.Duration(tok.ExpiresIn)*time.Second-time.Minute)
+    return b.token, nil
+}
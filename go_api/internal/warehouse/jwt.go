@@ -0,0 +1,40 @@
+package warehouse
+
+import (
+    "crypto"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/json"
+    "encoding/pem"
+    "errors"
+)
+
+// signJWT returns claims as a JWT signed with RS256, the only algorithm
+// either warehouse's service authentication accepts.
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+    header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+    payload, err := json.Marshal(claims)
+    if err != nil { return "", err }
+    enc := base64.RawURLEncoding
+    signing := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+    sum := sha256.Sum256([]byte(signing))
+    sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+    if err != nil { return "", err }
+    return signing + "." + enc.EncodeToString(sig), nil
+}
+
+// parseRSAKey reads an unencrypted RSA private key in PKCS #8 or PKCS #1
+// PEM.
+func parseRSAKey(data []byte) (*rsa.PrivateKey, error) {
+    block, _ := pem.Decode(data)
+    if block == nil { return nil, errors.New("no PEM private key found") }
+    if block.Type == "RSA PRIVATE KEY" { return x509.ParsePKCS1PrivateKey(block.Bytes) }
+    k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+    if err != nil { return nil, err }
+    rk, ok := k.(*rsa.PrivateKey)
+    if !ok { return nil, errors.New("private key is not RSA") }
+    return rk, nil
+}
@@ -0,0 +1,129 @@
+package warehouse
+
+import (
+    "bytes"
+    "context"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+)
+
+var snowflakeTypes = map[string]string{
+    store.ColString:  "VARCHAR",
+    store.ColFloat:   "FLOAT",
+    store.ColBool:    "BOOLEAN",
+    store.ColTime:    "TIMESTAMP_NTZ",
+    store.ColStrings: "ARRAY",
+}
+
+// snowflake runs statements through the SQL API with key-pair (JWT)
+// authentication.
+type snowflake struct {
+    cfg config.Snowflake
+    url string
+    key *rsa.PrivateKey
+    // issuer and subject of the JWT: ACCOUNT.USER, the former with the
+    // public key's fingerprint appended.
+    issuer, subject string
+}
+
+func newSnowflake(cfg config.Snowflake) (*snowflake, error) {
+    pemKey, err := os.ReadFile(cfg.PrivateKeyFile)
+    if err != nil { return nil, err }
+    key, err := parseRSAKey(pemKey)
+    if err != nil { return nil, fmt.Errorf("%s: %w", cfg.PrivateKeyFile, err) }
+    pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+    if err != nil { return nil, err }
+    fp := sha256.Sum256(pub)
+    // The JWT names the account without any region or cloud suffix.
+    account := strings.ToUpper(strings.SplitN(cfg.Account, ".", 2)[0])
+    subject := account + "." + strings.ToUpper(cfg.User)
+    return &snowflake{
+        cfg:     cfg,
+        url:     "https://" + strings.ToLower(cfg.Account) + ".snowflakecomputing.com/api/v2/statements",
+        key:     key,
+        issuer:  subject + ".SHA256:" + base64.StdEncoding.EncodeToString(fp[:]),
+        subject: subject,
+    }, nil
+}
+
+func (s *snowflake) Name() string { return "snowflake:" + s.cfg.Account + "." + s.cfg.Database + "." + s.cfg.Schema }
+
+func (s *snowflake) EnsureTable(ctx context.Context, t store.ExportTable) error {
+    cols := make([]string, len(t.Columns))
+    for i, c := range t.Columns { cols[i] = c.Name + " " + snowflakeTypes[c.Type] }
+    ddl := "CREATE TABLE IF NOT EXISTS " + t.Name + " (" + strings.Join(cols, ", ") + ")"
+    if t.Partition != "" { ddl += " CLUSTER BY (TO_DATE(" + t.Partition + "))" }
+    return s.exec(ctx, ddl, "")
+}
+
+// Upsert binds the batch as one JSON string and flattens it in the MERGE's
+// source query.
+func (s *snowflake) Upsert(ctx context.Context, t store.ExportTable, rows []map[string]interface{}) error {
+    data, err := rowsJSON(t, rows)
+    if err != nil { return err }
+    fields := make([]string, len(t.Columns))
+    for i, c := range t.Columns { fields[i] = "f.value:" + c.Name + "::" + snowflakeTypes[c.Type] + " AS " + c.Name }
+    set, cols, vals := mergeColumns(t)
+    q := "MERGE INTO " + t.Name + " t USING (SELECT " + strings.Join(fields, ", ") + " FROM TABLE(FLATTEN(PARSE_JSON(?))) f) s" +
+        " ON t." + t.Key + " = s." + t.Key +
+        " WHEN MATCHED THEN UPDATE SET " + set +
+        " WHEN NOT MATCHED THEN INSERT (" + cols + ") VALUES (" + vals + ")"
+    return s.exec(ctx, q, data)
+}
+
+// exec runs one statement, with bind as its only bind variable when set,
+// and waits for it to finish.
+func (s *snowflake) exec(ctx context.Context, statement, bind string) error {
+    req := map[string]interface{}{"statement": statement, "timeout": 600, "database": s.cfg.Database, "schema": s.cfg.Schema}
+    if s.cfg.Warehouse != "" { req["warehouse"] = s.cfg.Warehouse }
+    if s.cfg.Role != "" { req["role"] = s.cfg.Role }
+    if bind != "" { req["bindings"] = map[string]interface{}{"1": map[string]string{"type": "TEXT", "value": bind}} }
+    body, _ := json.Marshal(req)
+    status, res, err := s.do(ctx, http.MethodPost, s.url, body)
+    // 202: still running; poll its handle.
+    for err == nil && status == http.StatusAccepted {
+        time.Sleep(time.Second)
+        status, res, err = s.do(ctx, http.MethodGet, s.url+"/"+res.Handle, nil)
+    }
+    return err
+}
+
+type snowflakeResult struct {
+    Handle  string `json:"statementHandle"`
+    Message string `json:"message"`
+}
+
+func (s *snowflake) do(ctx context.Context, method, u string, body []byte) (int, snowflakeResult, error) {
+    var res snowflakeResult
+    now := time.Now()
+    token, err := signJWT(s.key, map[string]interface{}{"iss": s.issuer, "sub": s.subject, "iat": now.Unix(), "exp": now.Add(time.Hour).Unix()})
+    if err != nil { return 0, res, err }
+    req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+    if err != nil { return 0, res, err }
+    req.Header.Set("Authorization", "Bearer "+token)
+    req.Header.Set("X-Snowflake-Authorization-Token-Type", "KEYPAIR_JWT")
+    req.Header.Set("Accept", "application/json")
+    if body != nil { req.Header.Set("Content-Type", "application/json") }
+    resp, err := httpClient.Do(req)
+    if err != nil { return 0, res, err }
+    defer resp.Body.Close()
+    msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    json.Unmarshal(msg, &res)
+    if resp.StatusCode >= 300 {
+        if res.Message == "" { res.Message = string(bytes.TrimSpace(msg)) }
+        return resp.StatusCode, res, fmt.Errorf("snowflake: %s: %s", resp.Status, res.Message)
+    }
+    return resp.StatusCode, res, nil
+}
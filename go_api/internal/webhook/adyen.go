@@ -0,0 +1,88 @@
+package webhook
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Adyen verifies standard notifications, which carry an HMAC per item
+// rather than per request: additionalData.hmacSignature is the base64
+// HMAC-SHA256, keyed with the hex-decoded HMAC key, of the item's
+// pspReference, originalReference, merchantAccountCode, merchantReference,
+// amount value, currency, eventCode and success joined by colons. Every item
+// must verify.
+type Adyen struct {
+    HMACKey string // hex, as shown in the Customer Area
+}
+
+type adyenNotification struct {
+    Items []struct {
+        Item adyenItem `json:"NotificationRequestItem"`
+    } `json:"notificationItems"`
+}
+
+type adyenItem struct {
+    PSPReference        string `json:"pspReference"`
+    OriginalReference   string `json:"originalReference"`
+    MerchantAccountCode string `json:"merchantAccountCode"`
+    MerchantReference   string `json:"merchantReference"`
+    Amount              struct {
+        Value    int64  `json:"value"`
+        Currency string `json:"currency"`
+    } `json:"amount"`
+    EventCode      string            `json:"eventCode"`
+    Success        string            `json:"success"`
+    AdditionalData map[string]string `json:"additionalData"`
+}
+
+func (a Adyen) Verify(_ http.Header, body []byte, _ time.Time) error {
+    key, err := hex.DecodeString(a.HMACKey)
+    if err != nil { return fmt.Errorf("adyen hmac key: %w", err) }
+    var n adyenNotification
+    if err := json.Unmarshal(body, &n); err != nil { return fmt.Errorf("adyen notification: %w", err) }
+    if len(n.Items) == 0 { return ErrSignature }
+    for _, it := range n.Items {
+        i := it.Item
+        got, err := base64.StdEncoding.DecodeString(i.AdditionalData["hmacSignature"])
+        if err != nil { return ErrSignature }
+        mac := hmac.New(sha256.New, key)
+        mac.Write([]byte(strings.Join([]string{i.PSPReference, i.OriginalReference, i.MerchantAccountCode, i.MerchantReference,
+            strconv.FormatInt(i.Amount.Value, 10), i.Amount.Currency, i.EventCode, i.Success}, ":")))
+        if !hmac.Equal(got, mac.Sum(nil)) { return ErrSignature }
+    }
+    return nil
+}
+
+// Payments maps successful AUTHORISATION items to payments. The user is
+// additionalData.shopperReference, which Adyen only includes when the
+// merchant sends one; items without it are dropped. The card is identified
+// by additionalData.alias when card aliases are enabled on the account.
+func (Adyen) Payments(body []byte) ([]Payment, error) {
+    var n adyenNotification
+    if err := json.Unmarshal(body, &n); err != nil { return nil, fmt.Errorf("adyen notification: %w", err) }
+    var out []Payment
+    for _, it := range n.Items {
+        i := it.Item
+        if i.EventCode != "AUTHORISATION" || i.Success != "true" { continue }
+        user := i.AdditionalData["shopperReference"]
+        if user == "" { continue }
+        out = append(out, Payment{
+            EventID:         i.PSPReference,
+            UserID:          user,
+            MerchantID:      i.MerchantAccountCode,
+            Amount:          float64(i.Amount.Value) / minorUnits(i.Amount.Currency),
+            Currency:        i.Amount.Currency,
+            Channel:         "card",
+            CardFingerprint: i.AdditionalData["alias"],
+        })
+    }
+    return out, nil
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import (
+    "net/http"
+    "time"
+)
+
+// CallbackHeader carries the signature of a callback from our own services.
+const CallbackHeader = "X-Fraud-Signature"
+
+// Callback verifies callbacks from internal services, such as the auth
+// system reporting step-up verification results. They are signed the way
+// Stripe signs webhooks, t=<unix time>,v1=<hex HMAC-SHA256 of "t.body">,
+// in the X-Fraud-Signature header.
+type Callback struct {
+    Secret    string
+    Tolerance time.Duration
+}
+
+func (c Callback) Verify(h http.Header, body []byte, now time.Time) error {
+    return verifyTimestamped(h.Get(CallbackHeader), c.Secret, c.Tolerance, body, now)
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Stripe verifies the Stripe-Signature header: t=<unix time> and one or more
+// v1=<hex HMAC-SHA256 of "t.body"> with the endpoint secret. Signatures
+// older than Tolerance are refused so a captured request can't be replayed.
+type Stripe struct {
+    Secret    string
+    Tolerance time.Duration
+}
+
+func (s Stripe) Verify(h http.Header, body []byte, now time.Time) error {
+    return verifyTimestamped(h.Get("Stripe-Signature"), s.Secret, s.Tolerance, body, now)
+}
+
+// verifyTimestamped checks a t=<unix time>,v1=<hex HMAC-SHA256 of "t.body">
+// signature header, accepting it when any v1 matches and t is within
+// tolerance of now.
+func verifyTimestamped(header, secret string, tolerance time.Duration, body []byte, now time.Time) error {
+    var ts string
+    var sigs []string
+    for _, part := range strings.Split(header, ",") {
+        k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+        switch k {
+        case "t":
+            ts = v
+        case "v1":
+            sigs = append(sigs, v)
+        }
+    }
+    t, err := strconv.ParseInt(ts, 10, 64)
+    if err != nil || len(sigs) == 0 { return ErrSignature }
+    if age := now.Sub(time.Unix(t, 0)); age > tolerance || age < -tolerance { return fmt.Errorf("%w: timestamp outside tolerance", ErrSignature) }
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(ts + "."))
+    mac.Write(body)
+    want := mac.Sum(nil)
+    for _, sig := range sigs {
+        got, err := hex.DecodeString(sig)
+        if err == nil && hmac.Equal(got, want) { return nil }
+    }
+    return ErrSignature
+}
+
+type stripeEvent struct {
+    ID      string `json:"id"`
+    Type    string `json:"type"`
+    Account string `json:"account"`
+    Data    struct {
+        Object struct {
+            Amount         int64             `json:"amount"`
+            Currency       string            `json:"currency"`
+            Customer       string            `json:"customer"`
+            Metadata       map[string]string `json:"metadata"`
+            PaymentDetails struct {
+                Type string `json:"type"`
+                Card struct {
+                    Fingerprint string `json:"fingerprint"`
+                } `json:"card"`
+            } `json:"payment_method_details"`
+        } `json:"object"`
+    } `json:"data"`
+}
+
+// Payments maps charge.succeeded and charge.pending (ACH debits stay
+// pending for days) to a payment. The user is metadata.user_id or else the
+// Stripe customer; the merchant is metadata.merchant_id or else the
+// connected account. Card charges carry the card's fingerprint. Charges with
+// no user are dropped.
+func (Stripe) Payments(body []byte) ([]Payment, error) {
+    var ev stripeEvent
+    if err := json.Unmarshal(body, &ev); err != nil { return nil, fmt.Errorf("stripe event: %w", err) }
+    if ev.Type != "charge.succeeded" && ev.Type != "charge.pending" { return nil, nil }
+    o := ev.Data.Object
+    p := Payment{
+        EventID:         ev.ID,
+        UserID:          firstNonEmpty(o.Metadata["user_id"], o.Customer),
+        MerchantID:      firstNonEmpty(o.Metadata["merchant_id"], ev.Account, "stripe"),
+        Amount:          float64(o.Amount) / minorUnits(o.Currency),
+        Currency:        strings.ToUpper(o.Currency),
+        Channel:         "card",
+        CardFingerprint: o.PaymentDetails.Card.Fingerprint,
+    }
+    if t := o.PaymentDetails.Type; t == "ach_debit" || t == "us_bank_account" { p.Channel = "ach" }
+    if p.UserID == "" { return nil, nil }
+    return []Payment{p}, nil
+}
+
+func firstNonEmpty(vs ...string) string {
+    for _, v := range vs {
+        if v != "" { return v }
+    }
+    return ""
+}
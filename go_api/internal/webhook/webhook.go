@@ -0,0 +1,49 @@
+// Package webhook verifies and translates payment processors' webhook
+// notifications into payments go_api can score. Each provider checks its
+// own signature scheme and maps its event payload; events that don't
+// describe a new payment are acknowledged and dropped.
+package webhook
+
+import (
+    "errors"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// ErrSignature is returned for a missing, malformed or wrong signature.
+var ErrSignature = errors.New("webhook: invalid signature")
+
+// Payment is a payment extracted from a provider event.
+type Payment struct {
+    // EventID identifies the notification, so a redelivery can be
+    // recognised.
+    EventID    string
+    UserID     string
+    MerchantID string
+    Amount     float64
+    Currency   string
+    Channel    string // card or ach
+    // CardFingerprint identifies the card across payments, when the
+    // provider reports one.
+    CardFingerprint string
+}
+
+type Provider interface {
+    // Verify authenticates the raw request body against the headers.
+    Verify(h http.Header, body []byte, now time.Time) error
+    // Payments extracts the payments from a verified body.
+    Payments(body []byte) ([]Payment, error)
+}
+
+// minorUnits is 10^exponent for ISO 4217 currencies whose minor unit isn't
+// the usual cent; providers send amounts in minor units.
+func minorUnits(currency string) float64 {
+    switch strings.ToUpper(currency) {
+    case "BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG", "RWF", "UGX", "VND", "VUV", "XAF", "XOF", "XPF":
+        return 1
+    case "BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND":
+        return 1000
+    }
+    return 100
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+)
+
+const (
+    stripeSecret = "whsec_test_secret"
+    stripeBody   = `{"id":"evt_1","type":"charge.succeeded"}`
+    // stripeSig is the v1 signature of "1718000000." + stripeBody, computed
+    // independently of the code under test.
+    stripeSig = "548a9a636ad68e3bc1f8f7d02a0d1876460ad47c65f2f360732996389aa91641"
+)
+
+func TestStripeVerify(t *testing.T) {
+    signed := time.Unix(1718000000, 0)
+    s := Stripe{Secret: stripeSecret, Tolerance: 5 * time.Minute}
+    other := strings.Repeat("0", 64)
+    cases := []struct {
+        name   string
+        header string
+        body   string
+        now    time.Time
+        ok     bool
+    }{
+        {"valid", "t=1718000000,v1=" + stripeSig, stripeBody, signed, true},
+        {"spaces after commas", "t=1718000000, v1=" + stripeSig, stripeBody, signed, true},
+        {"unknown schemes ignored", "t=1718000000,v0=abc,v1=" + stripeSig, stripeBody, signed, true},
+        {"second of two v1 signatures", "t=1718000000,v1=" + other + ",v1=" + stripeSig, stripeBody, signed, true},
+        {"first of two v1 signatures", "t=1718000000,v1=" + stripeSig + ",v1=" + other, stripeBody, signed, true},
+        {"at the tolerance", "t=1718000000,v1=" + stripeSig, stripeBody, signed.Add(5 * time.Minute), true},
+        {"too old", "t=1718000000,v1=" + stripeSig, stripeBody, signed.Add(5*time.Minute + time.Second), false},
+        {"too far in the future", "t=1718000000,v1=" + stripeSig, stripeBody, signed.Add(-5*time.Minute - time.Second), false},
+        {"tampered body", "t=1718000000,v1=" + stripeSig, strings.Replace(stripeBody, "evt_1", "evt_2", 1), signed, false},
+        {"tampered timestamp", "t=1718000001,v1=" + stripeSig, stripeBody, signed, false},
+        {"wrong signatures only", "t=1718000000,v1=" + other + ",v1=" + other, stripeBody, signed, false},
+        {"signature not hex", "t=1718000000,v1=zz" + stripeSig[2:], stripeBody, signed, false},
+        {"no v1", "t=1718000000,v0=" + stripeSig, stripeBody, signed, false},
+        {"no timestamp", "v1=" + stripeSig, stripeBody, signed, false},
+        {"timestamp not a number", "t=soon,v1=" + stripeSig, stripeBody, signed, false},
+        {"no header", "", stripeBody, signed, false},
+    }
+    for _, c := range cases {
+        h := http.Header{}
+        if c.header != "" { h.Set("Stripe-Signature", c.header) }
+        err := s.Verify(h, []byte(c.body), c.now)
+        if c.ok && err != nil { t.Errorf("%s: %v", c.name, err) }
+        if !c.ok && !errors.Is(err, ErrSignature) { t.Errorf("%s: got %v, want ErrSignature", c.name, err) }
+    }
+}
+
+// The signature is checked with the configured secret, not just for shape.
+func TestStripeVerifyWrongSecret(t *testing.T) {
+    h := http.Header{"Stripe-Signature": {"t=1718000000,v1=" + stripeSig}}
+    err := Stripe{Secret: "whsec_other", Tolerance: time.Minute}.Verify(h, []byte(stripeBody), time.Unix(1718000000, 0))
+    if !errors.Is(err, ErrSignature) { t.Errorf("got %v, want ErrSignature", err) }
+}
+
+// Callbacks are signed like Stripe webhooks, in their own header.
+func TestCallbackVerify(t *testing.T) {
+    c := Callback{Secret: stripeSecret, Tolerance: time.Minute}
+    now := time.Unix(1718000000, 0)
+    if err := c.Verify(http.Header{CallbackHeader: {"t=1718000000,v1=" + stripeSig}}, []byte(stripeBody), now); err != nil { t.Errorf("valid: %v", err) }
+    if err := c.Verify(http.Header{"Stripe-Signature": {"t=1718000000,v1=" + stripeSig}}, []byte(stripeBody), now); !errors.Is(err, ErrSignature) { t.Errorf("Stripe header: got %v, want ErrSignature", err) }
+    if err := c.Verify(http.Header{}, []byte(stripeBody), now); !errors.Is(err, ErrSignature) { t.Errorf("unsigned: got %v, want ErrSignature", err) }
+}
+
+// adyenKey and the first item's signature are the example from Adyen's
+// documentation; the second item was signed with the same key.
+const adyenKey = "44782DEF547AAA06C910C43932B1EB0C71FC68D9D0C057550C48EC2ACF6BA056"
+
+func adyenBody(items ...string) string {
+    return `{"live":"false","notificationItems":[` + strings.Join(items, ",") + `]}`
+}
+
+func signedItem(psp, ref string, value int, currency, sig string) string {
+    return `{"NotificationRequestItem":{"pspReference":"` + psp + `","merchantAccountCode":"TestMerchant","merchantReference":"` + ref +
+        `","amount":{"value":` + strconv.Itoa(value) + `,"currency":"` + currency + `"},"eventCode":"AUTHORISATION","success":"true",` +
+        `"additionalData":{"hmacSignature":"` + sig + `"}}}`
+}
+
+func TestAdyenVerify(t *testing.T) {
+    first := signedItem("7914073381342284", "TestPayment-1407325143704", 1130, "EUR", "coqCmt/IZ4E3CzPvMY8zTjQVL5hYJUiBRg8UU+iCWo0=")
+    second := signedItem("8313842560770001", "order-2", 2500, "USD", "167ph/rm5RFAjAdyaAHhIuOm7Zehc6rPZiMIVHsZgdU=")
+    cases := []struct {
+        name string
+        key  string
+        body string
+        ok   bool
+    }{
+        {"documented example", adyenKey, adyenBody(first), true},
+        {"lowercase key", strings.ToLower(adyenKey), adyenBody(first), true},
+        {"every item signed", adyenKey, adyenBody(first, second), true},
+        {"tampered amount", adyenKey, adyenBody(strings.Replace(first, "1130", "11300", 1)), false},
+        {"tampered currency", adyenKey, adyenBody(strings.Replace(first, "EUR", "USD", 1)), false},
+        {"tampered reference", adyenKey, adyenBody(strings.Replace(first, "TestPayment", "TestPaymenT", 1)), false},
+        {"one item's signature moved to another", adyenKey, adyenBody(first, strings.Replace(second, "167ph/rm5RFAjAdyaAHhIuOm7Zehc6rPZiMIVHsZgdU=", "coqCmt/IZ4E3CzPvMY8zTjQVL5hYJUiBRg8UU+iCWo0=", 1)), false},
+        {"unsigned item", adyenKey, adyenBody(first, signedItem("8313842560770001", "order-2", 2500, "USD", "")), false},
+        {"signature not base64", adyenKey, adyenBody(signedItem("7914073381342284", "TestPayment-1407325143704", 1130, "EUR", "not base64!")), false},
+        {"wrong key", strings.Repeat("00", 32), adyenBody(first), false},
+        {"no items", adyenKey, adyenBody(), false},
+    }
+    for _, c := range cases {
+        err := Adyen{HMACKey: c.key}.Verify(nil, []byte(c.body), time.Time{})
+        if c.ok && err != nil { t.Errorf("%s: %v", c.name, err) }
+        if !c.ok && !errors.Is(err, ErrSignature) { t.Errorf("%s: got %v, want ErrSignature", c.name, err) }
+    }
+}
+
+// A key or body that can't be read is an error of its own, not a bad
+// signature.
+func TestAdyenVerifyMalformed(t *testing.T) {
+    if err := (Adyen{HMACKey: "not hex"}).Verify(nil, []byte(adyenBody()), time.Time{}); err == nil || errors.Is(err, ErrSignature) { t.Errorf("bad key: got %v", err) }
+    if err := (Adyen{HMACKey: adyenKey}).Verify(nil, []byte("{"), time.Time{}); err == nil || errors.Is(err, ErrSignature) { t.Errorf("bad body: got %v", err) }
+}
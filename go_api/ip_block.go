@@ -0,0 +1,23 @@
+package main
+
+import (
+    "context"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var blockedRequests = promauto.NewCounter(prometheus.CounterOpts{
+    Name: "fraud_api_blocked_requests_total",
+    Help: "Scoring requests rejected because their IP is blocked for card testing.",
+})
+
+// ipBlocked reports whether the processor has temporarily blocked the
+// request's IP after detecting card testing from it (blocked_ip:<ip>). While
+// Redis is unavailable no IP is treated as blocked.
+func ipBlocked(ctx context.Context, req TransactionRequest) bool {
+    if req.IPAddress == nil || *req.IPAddress == "" || !cacheUp() { return false }
+    n, err := rdb.Exists(ctx, "blocked_ip:"+*req.IPAddress).Result()
+    if err != nil { noteRedisErr(err); return false }
+    return n > 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+    "net/http"
+    "time"
+
+    "example.com/fraud/go_api/internal/iso20022"
+)
+
+type ISO20022Result struct {
+    EndToEndID string `json:"end_to_end_id,omitempty"`
+    UETR       string `json:"uetr,omitempty"`
+    // Error is set, and the rest left empty, when the transfer could not be
+    // stored; the other transfers of the message are still scored.
+    Error string `json:"error,omitempty"`
+    TransactionResponse
+}
+
+// iso20022Handler scores every credit transfer of a pacs.008 message as a
+// wire transaction from the debtor account (the user) to the creditor
+// account (the merchant). Amounts are taken as they are, in the message's
+// settlement currency. The message is parsed in full before anything is
+// scored, so a malformed one is rejected without side effects.
+func iso20022Handler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    start := time.Now()
+    transfers, err := iso20022.ParsePacs008(http.MaxBytesReader(w, r.Body, importBodyLimit()))
+    if err != nil { bodyError(w, err); return }
+    results := make([]ISO20022Result, 0, len(transfers))
+    for _, ct := range transfers {
+        req := TransactionRequest{UserID: ct.DebtorAccount, Amount: ct.Amount, MerchantID: ct.CreditorAccount, Channel: channelWire}
+        res := ISO20022Result{EndToEndID: ct.EndToEndID, UETR: ct.UETR}
+        resp, err := scoreBulk(r.Context(), req, r.Header.Get("X-Tenant-ID"))
+        if err != nil {
+            res.Error = err.Error()
+        } else {
+            res.TransactionResponse = resp
+            if resp.Degraded { degradedResponses.Inc() }
+        }
+        results = append(results, res)
+    }
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "message_id": transfers[0].MessageID,
+        "results": results,
+        "total_processing_time_ms": int(time.Since(start).Milliseconds()),
+    })
+}
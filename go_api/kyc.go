@@ -0,0 +1,92 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+const (
+    kycPending  = "pending"
+    kycVerified = "verified"
+    kycFailed   = "failed"
+    // kycNone caches "no KYC record".
+    kycNone = "none"
+)
+
+// kycCacheTTL can be long: PUT /users/{id}/kyc drops the cached value.
+const kycCacheTTL = time.Hour
+
+// kycEnricher adds the user's KYC status for the KYC gate in decide.
+type kycEnricher struct{}
+
+func (kycEnricher) Name() string { return "kyc" }
+
+func (kycEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    f.KYCStatus = userKYC(ctx, req.UserID)
+}
+
+// userKYC returns the user's KYC status from user_kyc:<id>, falling back to
+// Postgres, or "" when there is no record or it can't be read.
+func userKYC(ctx context.Context, userID string) string {
+    key := "user_kyc:" + userID
+    if cacheUp() {
+        v, err := rdb.Get(ctx, key).Result()
+        if err == nil {
+            if v == kycNone { return "" }
+            return v
+        }
+        noteRedisErr(err)
+    }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    status, err := kycStore.KYCStatus(qctx, userID)
+    switch {
+    case errors.Is(err, store.ErrNotFound):
+        status = kycNone
+    case err != nil:
+        return ""
+    }
+    if cacheUp() { noteRedisErr(rdb.Set(ctx, key, status, kycCacheTTL).Err()) }
+    if status == kycNone { return "" }
+    return status
+}
+
+// kycHandler serves GET and PUT /users/{id}/kyc. PUT takes
+// {"status": "pending"|"verified"|"failed"}.
+func kycHandler(w http.ResponseWriter, r *http.Request) {
+    id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/kyc")
+    switch r.Method {
+    case http.MethodGet:
+        qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+        defer cancel()
+        status, err := kycStore.KYCStatus(qctx, id)
+        if errors.Is(err, store.ErrNotFound) { http.Error(w, "No KYC record", http.StatusNotFound); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        writeJSONConditional(w, r, map[string]interface{}{"user_id": id, "kyc_status": status}, time.Time{})
+    case http.MethodPut:
+        var body struct {
+            Status string `json:"status"`
+        }
+        if !decodeBody(w, r, &body, bodyLimit()) { return }
+        switch body.Status {
+        case kycPending, kycVerified, kycFailed:
+        default:
+            http.Error(w, "status must be pending, verified or failed", http.StatusBadRequest)
+            return
+        }
+        if err := ensureUserExists(r.Context(), id, nil); err != nil { http.Error(w, "Failed to prepare user", http.StatusInternalServerError); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        if err := kycStore.SetKYCStatus(qctx, id, body.Status); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if cacheUp() { noteRedisErr(rdb.Del(ctx, "user_kyc:"+id).Err()) }
+        writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": id, "kyc_status": body.Status})
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+    "github.com/segmentio/kafka-go"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+var lifecycleEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "fraud_api_lifecycle_events_total",
+    Help: "Post-authorization events received, by type, source and outcome: applied, duplicate, rejected or failed.",
+}, []string{"type", "source", "outcome"})
+
+// errEventRejected is what an event the transaction's lifecycle doesn't
+// allow, such as refunding a void, fails with.
+var errEventRejected = errors.New("event rejected")
+
+// lifecycleRequest is a capture, refund, void or reversal as posted to
+// /transactions/{id}/events or read from lifecycle.topic, where it also
+// names the transaction. An amount of 0 captures the whole authorization
+// or refunds all that is left; voids and reversals always cover everything
+// outstanding.
+type lifecycleRequest struct {
+    EventID       string    `json:"event_id"`
+    TransactionID string    `json:"transaction_id"`
+    Type          string    `json:"type"`
+    Amount        float64   `json:"amount"`
+    OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (req lifecycleRequest) event(source string) (store.LifecycleEvent, error) {
+    e := store.LifecycleEvent{EventID: req.EventID, TransactionID: req.TransactionID, Type: strings.ToUpper(req.Type), Amount: req.Amount, OccurredAt: req.OccurredAt, Source: source}
+    switch {
+    case e.EventID == "" || len(e.EventID) > 100:
+        return e, errors.New("event_id is required, at most 100 characters")
+    case e.TransactionID == "":
+        return e, errors.New("transaction_id is required")
+    case e.Type != store.EventCapture && e.Type != store.EventRefund && e.Type != store.EventVoid && e.Type != store.EventReversal:
+        return e, errors.New("type must be CAPTURE, REFUND, VOID or REVERSAL")
+    case math.IsNaN(e.Amount) || math.IsInf(e.Amount, 0) || e.Amount < 0:
+        return e, errors.New("amount must be a non-negative number")
+    }
+    e.Amount = cents(e.Amount)
+    if e.OccurredAt.IsZero() { e.OccurredAt = time.Now() }
+    e.OccurredAt = e.OccurredAt.UTC()
+    return e, nil
+}
+
+func cents(v float64) float64 { return math.Round(v*100) / 100 }
+
+// exposure is what a transaction can still cost: its authorization until
+// it is captured, then what was captured less what was refunded.
+func exposure(l store.Lifecycle) float64 {
+    switch l.Status {
+    case store.StatusAuthorized:
+        return l.Amount
+    case store.StatusCaptured, store.StatusPartiallyRefunded:
+        return cents(l.Captured - l.Refunded)
+    }
+    return 0
+}
+
+// applyLifecycle is where e leaves a transaction at l, or errEventRejected.
+// It fills in e's amount where the event covers the rest of the
+// transaction.
+func applyLifecycle(l store.Lifecycle, e *store.LifecycleEvent) (store.Lifecycle, error) {
+    reject := func(format string, args ...interface{}) (store.Lifecycle, error) {
+        return l, fmt.Errorf("%w: "+format, append([]interface{}{errEventRejected}, args...)...)
+    }
+    if l.Decision != nil && *l.Decision == decisionDecline { return reject("the transaction was declined") }
+    switch e.Type {
+    case store.EventCapture:
+        if l.Status != store.StatusAuthorized { return reject("can't capture a %s transaction", l.Status) }
+        if e.Amount == 0 { e.Amount = l.Amount }
+        if e.Amount > l.Amount { return reject("capture of %.2f exceeds the authorized %.2f", e.Amount, l.Amount) }
+        l.Status, l.Captured = store.StatusCaptured, e.Amount
+    case store.EventVoid:
+        if l.Status != store.StatusAuthorized { return reject("can't void a %s transaction", l.Status) }
+        e.Amount = l.Amount
+        l.Status = store.StatusVoided
+    case store.EventRefund:
+        if l.Status != store.StatusCaptured && l.Status != store.StatusPartiallyRefunded { return reject("can't refund a %s transaction", l.Status) }
+        left := exposure(l)
+        if e.Amount == 0 { e.Amount = left }
+        if e.Amount > left { return reject("refund of %.2f exceeds the %.2f left to refund", e.Amount, left) }
+        l.Refunded = cents(l.Refunded + e.Amount)
+        l.Status = store.StatusPartiallyRefunded
+        if l.Refunded >= l.Captured { l.Status = store.StatusRefunded }
+    case store.EventReversal:
+        if exposure(l) == 0 { return reject("nothing to reverse on a %s transaction", l.Status) }
+        e.Amount = exposure(l)
+        l.Status = store.StatusReversed
+    }
+    return l, nil
+}
+
+// recordLifecycleEvent applies e to its transaction and takes what the
+// transaction no longer stands to cost off its user's spend counters.
+func recordLifecycleEvent(ctx context.Context, e store.LifecycleEvent) (store.EventOutcome, error) {
+    from, to := transactionTimeWindow(e.TransactionID)
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    out, err := lifecycleStore.RecordEvent(qctx, e.TransactionID, from, to, e, applyLifecycle)
+    switch {
+    case err == nil:
+        lifecycleEvents.WithLabelValues(e.Type, e.Source, "applied").Inc()
+    case errors.Is(err, store.ErrExists):
+        lifecycleEvents.WithLabelValues(e.Type, e.Source, "duplicate").Inc()
+        return out, err
+    case errors.Is(err, errEventRejected), errors.Is(err, store.ErrNotFound):
+        lifecycleEvents.WithLabelValues(e.Type, e.Source, "rejected").Inc()
+        return out, err
+    default:
+        lifecycleEvents.WithLabelValues(e.Type, e.Source, "failed").Inc()
+        return out, err
+    }
+    unspend(ctx, out.After.UserID, out.Before.Timestamp, cents(exposure(out.Before)-exposure(out.After)))
+    if len(out.ClosedAlerts) > 0 { log.Printf("transaction %s reversed by %s; closed alerts %s", e.TransactionID, e.EventID, strings.Join(out.ClosedAlerts, ", ")) }
+    return out, nil
+}
+
+func lifecycleJSON(l store.Lifecycle) map[string]interface{} {
+    return map[string]interface{}{
+        "transaction_id": l.TransactionID,
+        "lifecycle_status": l.Status,
+        "amount": l.Amount,
+        "captured_amount": l.Captured,
+        "refunded_amount": l.Refunded,
+        "exposure": exposure(l),
+    }
+}
+
+// lifecycleEventsHandler serves GET /transactions/{id}/events, the
+// transaction's events oldest first, and POST, which records one:
+// {"event_id": "evt_1", "type": "REFUND", "amount": 25, "occurred_at": "2024-05-01T10:00:00Z"}.
+// A new event is answered 201 with the transaction's lifecycle after it; one
+// whose event_id was seen before changes nothing and is answered 200.
+func lifecycleEventsHandler(w http.ResponseWriter, r *http.Request, id string) {
+    switch r.Method {
+    case http.MethodGet:
+        from, to := transactionTimeWindow(id)
+        qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+        defer cancel()
+        t, err := txStore.Get(qctx, id, from, to)
+        if errors.Is(err, store.ErrNotFound) { http.Error(w, "Transaction not found", http.StatusNotFound); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        events, err := lifecycleStore.Events(qctx, id)
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if events == nil { events = []store.LifecycleEvent{} }
+        resp := lifecycleJSON(store.Lifecycle{TransactionID: id, Amount: t.Amount, Status: t.LifecycleStatus, Captured: t.CapturedAmount, Refunded: t.RefundedAmount})
+        resp["events"] = events
+        writeJSONConditional(w, r, resp, t.UpdatedAt)
+    case http.MethodPost:
+        var req lifecycleRequest
+        if !decodeBody(w, r, &req, bodyLimit()) { return }
+        if req.TransactionID != "" && req.TransactionID != id { http.Error(w, "transaction_id doesn't match the path", http.StatusBadRequest); return }
+        req.TransactionID = id
+        e, err := req.event("api")
+        if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+        out, err := recordLifecycleEvent(r.Context(), e)
+        switch {
+        case errors.Is(err, store.ErrExists):
+            resp := lifecycleJSON(out.After)
+            resp["duplicate"] = true
+            writeJSON(w, http.StatusOK, resp)
+        case errors.Is(err, store.ErrNotFound):
+            http.Error(w, "Transaction not found", http.StatusNotFound)
+        case errors.Is(err, errEventRejected):
+            http.Error(w, err.Error(), http.StatusConflict)
+        case err != nil:
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+        default:
+            resp := lifecycleJSON(out.After)
+            resp["event"] = out.Event
+            resp["released"] = cents(exposure(out.Before) - exposure(out.After))
+            resp["closed_alerts"] = out.ClosedAlerts
+            writeJSON(w, http.StatusCreated, resp)
+        }
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
+
+// runLifecycleConsumer applies the events on lifecycle.topic, read from
+// Kafka or, with EVENT_BUS=redis, the stream of that name. A message that
+// can't be applied for lack of Postgres is retried until it can; one that
+// is malformed, for an unknown transaction or rejected is logged and
+// skipped.
+func runLifecycleConsumer() {
+    cfg := config.Get().Lifecycle
+    if cfg.Topic == "" { return }
+    if strings.ToLower(config.Get().EventBus) == "redis" { consumeLifecycleStream(cfg); return }
+    r := kafka.NewReader(kafka.ReaderConfig{
+        Brokers:  config.Get().Kafka.Brokers,
+        Dialer:   conn.KafkaDialer(),
+        GroupID:  cfg.GroupID,
+        Topic:    cfg.Topic,
+        MinBytes: 1,
+        MaxBytes: 10e6,
+    })
+    defer r.Close()
+    for {
+        m, err := r.FetchMessage(ctx)
+        if err != nil { log.Printf("lifecycle consumer: %v", err); time.Sleep(time.Second); continue }
+        for !handleLifecycleMessage(m.Value) { time.Sleep(5 * time.Second) }
+        if err := r.CommitMessages(ctx, m); err != nil { log.Printf("lifecycle consumer commit: %v", err) }
+    }
+}
+
+// consumeLifecycleStream reads the stream in consumer group cfg.GroupID,
+// first what this instance was delivered before a restart and never
+// acknowledged, then new entries.
+func consumeLifecycleStream(cfg config.Lifecycle) {
+    consumer, _ := os.Hostname()
+    for {
+        err := rdb.XGroupCreateMkStream(ctx, cfg.Topic, cfg.GroupID, "0").Err()
+        if err == nil || strings.HasPrefix(err.Error(), "BUSYGROUP") { break }
+        log.Printf("lifecycle consumer: %v", err)
+        time.Sleep(5 * time.Second)
+    }
+    last := "0"
+    for {
+        res, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{Group: cfg.GroupID, Consumer: consumer, Streams: []string{cfg.Topic, last}, Count: 100, Block: 5 * time.Second}).Result()
+        if err == redis.Nil { continue }
+        if err != nil { log.Printf("lifecycle consumer: %v", err); time.Sleep(time.Second); continue }
+        n := 0
+        for _, st := range res {
+            for _, x := range st.Messages {
+                n++
+                v, _ := x.Values["value"].(string)
+                for !handleLifecycleMessage([]byte(v)) { time.Sleep(5 * time.Second) }
+                noteRedisErr(rdb.XAck(ctx, cfg.Topic, cfg.GroupID, x.ID).Err())
+            }
+        }
+        if n == 0 { last = ">" }
+    }
+}
+
+// handleLifecycleMessage applies one JSON event and reports whether it is
+// done with, false meaning it should be retried.
+func handleLifecycleMessage(b []byte) bool {
+    var req lifecycleRequest
+    if err := json.Unmarshal(b, &req); err != nil {
+        lifecycleEvents.WithLabelValues("", "kafka", "rejected").Inc()
+        log.Printf("lifecycle event skipped: %v", err)
+        return true
+    }
+    e, err := req.event("kafka")
+    if err != nil {
+        lifecycleEvents.WithLabelValues(e.Type, "kafka", "rejected").Inc()
+        log.Printf("lifecycle event %q skipped: %v", req.EventID, err)
+        return true
+    }
+    _, err = recordLifecycleEvent(ctx, e)
+    switch {
+    case err == nil, errors.Is(err, store.ErrExists):
+        return true
+    case errors.Is(err, store.ErrNotFound), errors.Is(err, errEventRejected):
+        log.Printf("lifecycle event %s on %s skipped: %v", e.EventID, e.TransactionID, err)
+        return true
+    }
+    log.Printf("lifecycle event %s on %s failed, retrying: %v", e.EventID, e.TransactionID, err)
+    return false
+}
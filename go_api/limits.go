@@ -0,0 +1,201 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+const reasonLimitExceeded = "limit_exceeded"
+
+// spendLimitsCacheTTL can be long: PUT /users/{id}/limits drops the cached
+// value.
+const spendLimitsCacheTTL = time.Hour
+
+// spendLimitsNone caches "no overrides".
+const spendLimitsNone = "none"
+
+// spendScript adds ARGV[1] to the day and week counters KEYS[1] and KEYS[2]
+// unless that would take either past its limit (ARGV[2], ARGV[3]; 0 is no
+// limit). It returns 0 when the amount was added, 1 or 2 for the counter
+// that would have been exceeded. Checking and adding in one script keeps
+// concurrent transactions from both slipping under a limit.
+var spendScript = redis.NewScript(`
+local a = tonumber(ARGV[1])
+local day = tonumber(redis.call('GET', KEYS[1]) or '0')
+local week = tonumber(redis.call('GET', KEYS[2]) or '0')
+if tonumber(ARGV[2]) > 0 and day + a > tonumber(ARGV[2]) then return 1 end
+if tonumber(ARGV[3]) > 0 and week + a > tonumber(ARGV[3]) then return 2 end
+redis.call('INCRBYFLOAT', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[1], 172800)
+redis.call('INCRBYFLOAT', KEYS[2], ARGV[1])
+redis.call('EXPIRE', KEYS[2], 691200)
+return 0
+`)
+
+// spendKeys returns the user's counters for the UTC day and ISO week of t.
+func spendKeys(userID string, t time.Time) []string {
+    t = t.UTC()
+    year, week := t.ISOWeek()
+    return []string{
+        "spend:day:" + userID + ":" + t.Format("20060102"),
+        fmt.Sprintf("spend:week:%s:%d-W%02d", userID, year, week),
+    }
+}
+
+// reserveSpend counts req's amount against the user's limits and reports
+// false when it would exceed one. Limits aren't enforced while Redis is
+// unavailable.
+func reserveSpend(ctx context.Context, req TransactionRequest) bool {
+    if !cacheUp() { return true }
+    limits := effectiveSpendLimits(ctx, req.UserID)
+    if limits.Daily == nil && limits.Weekly == nil { return true }
+    var daily, weekly float64
+    if limits.Daily != nil { daily = *limits.Daily }
+    if limits.Weekly != nil { weekly = *limits.Weekly }
+    res, err := spendScript.Run(ctx, rdb, spendKeys(req.UserID, time.Now()), req.Amount, daily, weekly).Int()
+    if err != nil { noteRedisErr(err); return true }
+    if res != 0 { limitsExceeded.Inc() }
+    return res == 0
+}
+
+// withinSpendLimits reports whether reserveSpend would accept req, without
+// counting anything.
+func withinSpendLimits(ctx context.Context, req TransactionRequest) bool {
+    if !cacheUp() { return true }
+    limits := effectiveSpendLimits(ctx, req.UserID)
+    if limits.Daily == nil && limits.Weekly == nil { return true }
+    vals, err := rdb.MGet(ctx, spendKeys(req.UserID, time.Now())...).Result()
+    if err != nil { noteRedisErr(err); return true }
+    if limits.Daily != nil && spent(vals[0])+req.Amount > *limits.Daily { return false }
+    return limits.Weekly == nil || spent(vals[1])+req.Amount <= *limits.Weekly
+}
+
+// releaseSpend takes back an amount reserveSpend counted, for a transaction
+// that ends up declined.
+func releaseSpend(ctx context.Context, req TransactionRequest) {
+    if !cacheUp() { return }
+    pipe := rdb.Pipeline()
+    for _, k := range spendKeys(req.UserID, time.Now()) { pipe.IncrByFloat(ctx, k, -req.Amount) }
+    _, err := pipe.Exec(ctx)
+    noteRedisErr(err)
+}
+
+// unspendScript takes ARGV[1] off the counters in KEYS that still exist,
+// stopping at 0 and keeping their expiry.
+var unspendScript = redis.NewScript(`
+for _, k in ipairs(KEYS) do
+  local v = redis.call('GET', k)
+  if v then
+    local n = tonumber(v) - tonumber(ARGV[1])
+    if n < 0 then n = 0 end
+    redis.call('INCRBYFLOAT', k, n - tonumber(v))
+  end
+end
+return 0
+`)
+
+// unspend takes amount off what a user's transaction at t counted against
+// their limits, once a refund, void or reversal means it won't be spent.
+// Counters that have expired are left alone.
+func unspend(ctx context.Context, userID string, t time.Time, amount float64) {
+    if !cacheUp() || amount <= 0 { return }
+    noteRedisErr(unspendScript.Run(ctx, rdb, spendKeys(userID, t), amount).Err())
+}
+
+// effectiveSpendLimits returns the user's override from spend_limits:<id>
+// or Postgres, falling back per limit to limits.daily and limits.weekly.
+// Nil means no limit.
+func effectiveSpendLimits(ctx context.Context, userID string) store.SpendLimits {
+    cfg := config.Get().Limits
+    out := store.SpendLimits{}
+    if cfg.Daily > 0 { out.Daily = &cfg.Daily }
+    if cfg.Weekly > 0 { out.Weekly = &cfg.Weekly }
+    o, ok := userSpendLimits(ctx, userID)
+    if !ok { return out }
+    if o.Daily != nil { out.Daily = o.Daily }
+    if o.Weekly != nil { out.Weekly = o.Weekly }
+    if out.Daily != nil && *out.Daily == 0 { out.Daily = nil }
+    if out.Weekly != nil && *out.Weekly == 0 { out.Weekly = nil }
+    return out
+}
+
+// userSpendLimits returns the user's overrides; ok is false if they have
+// none or they can't be read.
+func userSpendLimits(ctx context.Context, userID string) (store.SpendLimits, bool) {
+    key := "spend_limits:" + userID
+    var l store.SpendLimits
+    if cacheUp() {
+        v, err := rdb.Get(ctx, key).Result()
+        if err == nil {
+            if v == spendLimitsNone { return l, false }
+            if json.Unmarshal([]byte(v), &l) == nil { return l, true }
+        }
+        noteRedisErr(err)
+    }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    l, err := limitStore.SpendLimits(qctx, userID)
+    switch {
+    case errors.Is(err, store.ErrNotFound):
+        if cacheUp() { noteRedisErr(rdb.Set(ctx, key, spendLimitsNone, spendLimitsCacheTTL).Err()) }
+        return l, false
+    case err != nil:
+        return l, false
+    }
+    if cacheUp() {
+        b, _ := json.Marshal(l)
+        noteRedisErr(rdb.Set(ctx, key, b, spendLimitsCacheTTL).Err())
+    }
+    return l, true
+}
+
+// spent parses a counter read with MGET; a missing counter is 0.
+func spent(v interface{}) float64 {
+    s, _ := v.(string)
+    n, _ := strconv.ParseFloat(s, 64)
+    return n
+}
+
+// limitsHandler serves GET and PUT /users/{id}/limits. GET returns the
+// limits in force and what the user has spent against them; PUT sets the
+// user's overrides, {"daily": 500, "weekly": null}, where null falls back to
+// the configured default and 0 removes the limit.
+func limitsHandler(w http.ResponseWriter, r *http.Request) {
+    id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/limits")
+    switch r.Method {
+    case http.MethodGet:
+        limits := effectiveSpendLimits(r.Context(), id)
+        resp := map[string]interface{}{"user_id": id, "daily": limits.Daily, "weekly": limits.Weekly}
+        if cacheUp() {
+            keys := spendKeys(id, time.Now())
+            vals, err := rdb.MGet(r.Context(), keys...).Result()
+            noteRedisErr(err)
+            if err == nil { resp["spent_today"], resp["spent_this_week"] = spent(vals[0]), spent(vals[1]) }
+        }
+        writeJSONConditional(w, r, resp, time.Time{})
+    case http.MethodPut:
+        var l store.SpendLimits
+        if !decodeBody(w, r, &l, bodyLimit()) { return }
+        if (l.Daily != nil && *l.Daily < 0) || (l.Weekly != nil && *l.Weekly < 0) { http.Error(w, "limits must not be negative", http.StatusBadRequest); return }
+        if err := ensureUserExists(r.Context(), id, nil); err != nil { http.Error(w, "Failed to prepare user", http.StatusInternalServerError); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        if err := limitStore.SetSpendLimits(qctx, id, l); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if cacheUp() { noteRedisErr(rdb.Del(ctx, "spend_limits:"+id).Err()) }
+        writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": id, "daily": l.Daily, "weekly": l.Weekly})
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
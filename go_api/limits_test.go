@@ -0,0 +1,43 @@
+package main
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/go-redis/redis/v8"
+)
+
+// spendScript checks both limits before adding to either counter, so a
+// refused amount leaves them as they were.
+func TestSpendScript(t *testing.T) {
+    mr := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+    t.Cleanup(func() { client.Close() })
+    keys := []string{"spend:day:u", "spend:week:u"}
+    steps := []struct {
+        name          string
+        amount        float64
+        daily, weekly float64
+        want          int
+        day, week     string
+    }{
+        {"no limits", 40, 0, 0, 0, "40", "40"},
+        {"under both", 50, 100, 500, 0, "90", "90"},
+        {"up to the daily limit exactly", 10, 100, 500, 0, "100", "100"},
+        {"past the daily limit", 0.01, 100, 500, 1, "100", "100"},
+        {"past the weekly limit", 20, 0, 110, 2, "100", "100"},
+        {"daily is checked first", 500, 100, 110, 1, "100", "100"},
+        {"fractions", 0.25, 0, 0, 0, "100.25", "100.25"},
+    }
+    for _, s := range steps {
+        got, err := spendScript.Run(context.Background(), client, keys, s.amount, s.daily, s.weekly).Int()
+        if err != nil { t.Fatalf("%s: %v", s.name, err) }
+        if got != s.want { t.Errorf("%s: got %d, want %d", s.name, got, s.want) }
+        if day, _ := mr.Get(keys[0]); day != s.day { t.Errorf("%s: day counter %s, want %s", s.name, day, s.day) }
+        if week, _ := mr.Get(keys[1]); week != s.week { t.Errorf("%s: week counter %s, want %s", s.name, week, s.week) }
+    }
+    if ttl := mr.TTL(keys[0]); ttl != 48*time.Hour { t.Errorf("day counter expires in %v, want 48h", ttl) }
+    if ttl := mr.TTL(keys[1]); ttl != 8*24*time.Hour { t.Errorf("week counter expires in %v, want 8 days", ttl) }
+}
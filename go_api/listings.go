@@ -0,0 +1,46 @@
+package main
+
+import (
+    "net/http"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+// transactionsHandler serves GET /transactions?user_id=u1&status=REVIEWED&from=2024-05-01&to=2024-05-07&limit=100,
+// the transactions over the days statsRange reads, narrowed to the user and
+// status when those are set, newest first, a page at a time.
+func transactionsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    from, to, err := statsRange(q)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    status := strings.ToUpper(q.Get("status"))
+    if status != "" && !transactionStatuses[status] { http.Error(w, "status must be SCORED, REVIEWED, APPROVED, DECLINED or CHARGED_BACK", http.StatusBadRequest); return }
+    page, ok := pageParams(w, r, 100, 1000)
+    if !ok { return }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := txStore.Transactions(qctx, q.Get("user_id"), status, from, to.AddDate(0, 0, 1), page.query())
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    txs, next := pageOf(page, rows, func(t store.Transaction) store.Cursor { return store.Cursor{At: t.Timestamp, ID: t.TransactionID} })
+    out := make([]map[string]interface{}, len(txs))
+    lang := riskFactorLanguage(w, r)
+    for i, t := range txs { out[i] = transactionJSON(t, lang) }
+    writeJSONConditional(w, r, pageBody("transactions", out, next), time.Time{})
+}
+
+// usersHandler serves GET /users?limit=100, newest first, a page at a time.
+func usersHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    page, ok := pageParams(w, r, 100, 1000)
+    if !ok { return }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := userStore.Users(qctx, page.query())
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    out, next := pageOf(page, rows, func(u store.User) store.Cursor { return store.Cursor{At: u.CreatedAt, ID: u.UserID} })
+    writeJSONConditional(w, r, pageBody("users", out, next), time.Time{})
+}
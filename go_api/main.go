@@ -2,23 +2,35 @@ package main
 
 import (
     "context"
-    "database/sql"
+    "crypto/sha256"
+    "encoding/hex"
     "encoding/json"
+    "errors"
+    "flag"
     "fmt"
     "log"
     "net/http"
     "os"
-    "strconv"
+    "os/signal"
     "strings"
+    "sync"
+    "sync/atomic"
+    "syscall"
     "time"
 
-    _ "github.com/lib/pq"
-    "github.com/segmentio/kafka-go"
     "github.com/go-redis/redis/v8"
-    "google.golang.org/grpc"
-    "google.golang.org/grpc/credentials/insecure"
-
-    pb "example.com/fraud/go_api/internal/pb"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+
+    "example.com/fraud/go_api/internal/geohash"
+    pb "example.com/fraud/go_api/internal/pb/protos"
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+    "example.com/fraud/internal/feature"
+    "example.com/fraud/internal/flags"
+    "example.com/fraud/internal/mcc"
 )
 
 type TransactionRequest struct {
@@ -30,6 +42,28 @@ type TransactionRequest struct {
     LocationLon    *float64 `json:"location_lon,omitempty"`
     DeviceID       *string  `json:"device_id,omitempty"`
     IPAddress      *string  `json:"ip_address,omitempty"`
+    // MCC is the ISO 18245 merchant category code; when omitted the
+    // merchant's last known code is used.
+    MCC            *string  `json:"mcc,omitempty"`
+    // Channel is the payment rail: card (the default), ach, wire or p2p.
+    Channel        string   `json:"channel,omitempty"`
+    // CardFingerprint is an opaque token for the card, such as the payment
+    // processor's card fingerprint; never the card number.
+    CardFingerprint *string `json:"card_fingerprint,omitempty"`
+    Email          *string  `json:"email,omitempty"`
+    Phone          *string  `json:"phone,omitempty"` // E.164
+    // Country is where the transaction takes place (ISO 3166 alpha-2).
+    Country        *string  `json:"country,omitempty"`
+    // BehavioralScore is the web/mobile SDK's 0-1 risk score for the
+    // session's typing, touch and navigation patterns; SessionID is its
+    // session identifier.
+    BehavioralScore *float64 `json:"behavioral_score,omitempty"`
+    SessionID       *string  `json:"session_id,omitempty"`
+    // AccountCreatedAt is when the user signed up with the client, if it
+    // knows; otherwise the user's first transaction here counts.
+    AccountCreatedAt *time.Time `json:"account_created_at,omitempty"`
+    // Attributes are passed through to enrichment plugins untouched.
+    Attributes     map[string]string `json:"attributes,omitempty"`
 }
 
 type TransactionResponse struct {
@@ -39,6 +73,36 @@ type TransactionResponse struct {
     Confidence       float64  `json:"confidence"`
     RiskFactors      []string `json:"risk_factors"`
     ProcessingTimeMs int      `json:"processing_time_ms"`
+    // Degraded is set when Redis was unavailable: the score was computed
+    // without caches and the response isn't cached for retries.
+    Degraded bool `json:"degraded,omitempty"`
+    // DuplicateOf names an earlier transaction with the same user, merchant
+    // and amount within duplicates.window.
+    DuplicateOf string `json:"duplicate_of,omitempty"`
+    // Decision is APPROVE, REVIEW or DECLINE: the score's verdict, weighed
+    // against the amount, after the KYC and duplicate-review gates.
+    Decision string `json:"decision"`
+    // ExpectedLoss is the amount expected to be lost to fraud, score ×
+    // amount.
+    ExpectedLoss float64 `json:"expected_loss"`
+    // DeclineReason is set for declines that aren't a fraud verdict:
+    // limit_exceeded when the amount is over the user's spend limit,
+    // blocked_country or blocked_ip_range for a geo block.
+    DeclineReason string `json:"decline_reason,omitempty"`
+    // PartialEvaluation is set when api.latency_budget ran out and some
+    // enrichment stages or the ML service were skipped; the rules decided
+    // on what was gathered. Such responses aren't cached for retries.
+    PartialEvaluation bool `json:"partial_evaluation,omitempty"`
+    // RiskFactorDescriptions describes each risk factor in the language
+    // Accept-Language asks for; requests without one don't get it. It is
+    // added when the response is written, so cached responses don't have
+    // it.
+    RiskFactorDescriptions map[string]string `json:"risk_factor_descriptions,omitempty"`
+    // ScoringTier is what produced FraudScore: ml, local_model or rules.
+    // FallbackReason is set when the ML service was wanted but didn't:
+    // ml_error, ml_unhealthy or latency_budget.
+    ScoringTier    string `json:"scoring_tier"`
+    FallbackReason string `json:"fallback_reason,omitempty"`
 }
 
 type BatchTransactionRequest struct {
@@ -46,45 +110,124 @@ type BatchTransactionRequest struct {
 }
 
 type BatchTransactionResponse struct {
-    Results               []TransactionResponse `json:"results"`
-    TotalProcessingTimeMs int                   `json:"total_processing_time_ms"`
+    Results               []BatchResult `json:"results"`
+    TotalProcessingTimeMs int           `json:"total_processing_time_ms"`
+}
+
+// BatchResult is one transaction of a batch, in request order. Error is
+// set, and the rest left empty, when it was not scored.
+type BatchResult struct {
+    Error string `json:"error,omitempty"`
+    TransactionResponse
 }
 
 var (
-    pg       *sql.DB
-    rdb      *redis.Client
-    kafkaW   *kafka.Writer
-    ctx      = context.Background()
+    pg            *pgxpool.Pool
+    rdb           *redis.Client
+    txPub         publisher
+    txPriorityPub publisher
+    txCodec       events.Codec
+    // alertPub carries operational alerts, such as caller anomalies, to
+    // fraud-alerts.
+    alertPub   publisher
+    alertCodec events.Codec
+    ctx           = context.Background()
+
+    // kafkaReady is set once Kafka is reachable and txCodec is built; until
+    // then (only with startup.lazy_kafka) events are encoded as protobuf.
+    kafkaReady atomic.Bool
+
+    // All SQL goes through these; they share one store.Postgres at runtime.
+    txStore          store.TransactionStore
+    userStore        store.UserStore
+    alertStore       store.AlertStore
+    kycStore         store.KYCStore
+    travelStore      store.TravelStore
+    limitStore       store.LimitStore
+    blocklistStore   store.BlocklistStore
+    ruleStore        store.RuleStore
+    merchantStore    store.MerchantStore
+    outboxStore      store.OutboxStore
+    partitionStore   store.PartitionStore
+    exportStore      store.ExportStore
+    caseStore        store.CaseStore
+    reportStore      store.ReportStore
+    analystStore     store.AnalystStore
+    suppressionStore store.SuppressionStore
+    auditStore       store.AuditStore
+    statsStore       store.StatsStore
+    usageStore       store.UsageStore
+    lifecycleStore   store.LifecycleStore
 )
 
-func getenv(key, def string) string {
-    if v := os.Getenv(key); v != "" {
-        return v
-    }
-    return def
-}
-
 func initConnections() error {
     // Postgres
-    pgHost := getenv("POSTGRES_HOST", "localhost")
-    pgDB := getenv("POSTGRES_DB", "fraud_detection")
-    pgUser := getenv("POSTGRES_USER", "fraud_user")
-    pgPass := getenv("POSTGRES_PASSWORD", "fraud_password")
-    dsn := fmt.Sprintf("host=%s dbname=%s user=%s password=%s sslmode=disable", pgHost, pgDB, pgUser, pgPass)
     var err error
-    pg, err = sql.Open("postgres", dsn)
+    cfg := config.Get()
+    attempts := cfg.Startup.RetryAttempts
+    err = conn.Retry(ctx, "postgres", attempts, func() (err error) {
+        pg, err = conn.NewPgPool(ctx, cfg.Postgres.Host, "fraud_api_pg_pool")
+        return err
+    })
     if err != nil { return err }
-    if err = pg.Ping(); err != nil { return err }
+    if cfg.Postgres.ReadHost != "" {
+        err = conn.Retry(ctx, "postgres replica", attempts, func() (err error) {
+            pgReplica, err = conn.NewPgPool(ctx, cfg.Postgres.ReadHost, "fraud_api_pg_read_pool")
+            return err
+        })
+        if err != nil { return err }
+        go monitorReplicaLag(5 * time.Second)
+    }
+    db := store.NewPostgres(pg, usableReplica)
+    txStore, userStore, alertStore, kycStore, travelStore, limitStore, blocklistStore, ruleStore = db, db, db, db, db, db, db, db
+    merchantStore, outboxStore, partitionStore, exportStore, caseStore, reportStore, analystStore = db, db, db, db, db, db, db
+    suppressionStore, auditStore, statsStore, usageStore, lifecycleStore = db, db, db, db, db
 
     // Redis
-    redisHost := getenv("REDIS_HOST", "localhost")
-    redisPort := getenv("REDIS_PORT", "6379")
-    rdb = redis.NewClient(&redis.Options{ Addr: redisHost+":"+redisPort })
-    if err := rdb.Ping(ctx).Err(); err != nil { return err }
-
-    // Kafka (best-effort)
-    brokers := strings.Split(getenv("KAFKA_BOOTSTRAP_SERVERS", "localhost:9092"), ",")
-    kafkaW = &kafka.Writer{Addr: kafka.TCP(brokers...), Topic: "fraud-transactions", Balancer: &kafka.LeastBytes{}}
+    if err := conn.Retry(ctx, "redis", attempts, func() (err error) { rdb, err = conn.NewRedis(ctx, "fraud_api"); return err }); err != nil { return err }
+    go monitorRedis(time.Second)
+    featureFlags = flags.New(rdb)
+    go featureFlags.Run(ctx, cfg.Flags.RefreshInterval)
+
+    // Search cluster (optional)
+    initSearch()
+
+    // Kafka
+    brokers := cfg.Kafka.Brokers
+    // Messages are keyed by user_id; the hash balancer keeps each user's events
+    // on one partition so the processor applies risk updates in order.
+    if txPub, err = newPublisher(brokers, "fraud-transactions", 0); err != nil { return err }
+    // Critical scores skip the bulk topic's batching and queue.
+    if txPriorityPub, err = newPublisher(brokers, "fraud-transactions-priority", 1); err != nil { return err }
+    if alertPub, err = newPublisher(brokers, "fraud-alerts", 1); err != nil { return err }
+    if topic := cfg.FeatureLog.Topic; topic != "" {
+        if featurePub, err = newPublisher(brokers, topic, 0); err != nil { return err }
+    }
+    if cfg.Startup.LazyKafka {
+        go func() {
+            if err := initKafka(0); err != nil { log.Printf("kafka init: %v", err) }
+        }()
+        return nil
+    }
+    return initKafka(attempts)
+}
+
+// initKafka waits for the brokers, when Kafka is the event bus, and builds
+// txCodec and alertCodec, which for Avro registers the schemas. attempts bounds each wait as
+// in conn.Retry.
+func initKafka(attempts int) error {
+    cfg := config.Get()
+    if strings.EqualFold(cfg.EventBus, "kafka") {
+        if err := conn.Retry(ctx, "kafka", attempts, func() error { return conn.PingKafka(ctx, cfg.Kafka.Brokers) }); err != nil { return err }
+    }
+    reg := events.NewRegistry(cfg.Kafka.SchemaRegistryURL)
+    err := conn.Retry(ctx, "schema registry", attempts, func() (err error) {
+        if txCodec, err = events.NewCodec(reg, "fraud-transactions", events.TransactionEventSchema); err != nil { return err }
+        alertCodec, err = events.NewCodec(reg, "fraud-alerts", events.AlertEventSchema)
+        return err
+    })
+    if err != nil { return err }
+    kafkaReady.Store(true)
     return nil
 }
 
@@ -95,122 +238,329 @@ func rootHandler(w http.ResponseWriter, r *http.Request) {
 func healthHandler(w http.ResponseWriter, r *http.Request) {
     status := map[string]string{"redis": "down", "postgres": "down"}
     if err := rdb.Ping(ctx).Err(); err == nil { status["redis"] = "up" }
-    if err := pg.Ping(); err == nil { status["postgres"] = "up" }
-    writeJSON(w, http.StatusOK, map[string]interface{}{"status": "healthy", "services": status})
+    qctx, cancel := conn.QueryCtx(r.Context())
+    defer cancel()
+    if err := pg.Ping(qctx); err == nil { status["postgres"] = "up" }
+    writeJSON(w, http.StatusOK, map[string]interface{}{"status": "healthy", "services": status, "degraded": !cacheUp(), "scoring_tiers": scoringTiersUp(), "ml_endpoints": healthyMLEndpoints()})
 }
 
 func processTransactionHandler(w http.ResponseWriter, r *http.Request) {
     start := time.Now()
     var req TransactionRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    if !decodeBody(w, r, &req, bodyLimit()) { return }
+    if err := validateRequest(&req); err != nil {
         http.Error(w, err.Error(), http.StatusBadRequest)
         return
     }
 
-    txID := fmt.Sprintf("%d", time.Now().UnixNano())
-    cacheKey := "transaction:" + txID
-    if cached, err := rdb.Get(ctx, cacheKey).Result(); err == nil {
-        w.Header().Set("Content-Type", "application/json")
-        w.Write([]byte(cached))
+    cacheKey := responseCacheKey(req, r.Header.Get("Idempotency-Key"))
+    cacheable := responseCacheable(r.Header.Get("Idempotency-Key"))
+    if cacheable && cacheUp() {
+        cached, err := rdb.Get(ctx, cacheKey).Result()
+        if err == nil { writeCachedScore(w, r, cached); return }
+        noteRedisErr(err)
+    }
+    if ipBlocked(r.Context(), req) {
+        blockedRequests.Inc()
+        http.Error(w, "ip address temporarily blocked", http.StatusForbidden)
         return
     }
 
-    // Feature engineering equivalents
-    userRisk := getUserRiskScore(req.UserID)
-    ratio := getAmountToHistoryRatio(req.UserID, req.Amount)
-
-    // Scoring: optional gRPC to Python ML service if enabled, else placeholder
-    useGRPC := strings.ToLower(getenv("USE_ML_GRPC", "false")) == "true"
-    var (
-        fraudScore float64
-        confidence float64
-        riskFactors []string
-    )
-    if useGRPC {
-        // Attempt gRPC call; on error fallback to placeholder
-        if fs, conf, rfs, err := getFraudScoreGRPC(req, userRisk, ratio); err == nil {
-            fraudScore, confidence, riskFactors = fs, conf, rfs
+    release, err := acquireRealtime(r.Context())
+    if err != nil { scoringBusy(w); return }
+    resp, err := processTransaction(r.Context(), req, r.Header.Get("X-Tenant-ID"), start)
+    release()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    b, _ := json.Marshal(resp)
+    if resp.Degraded {
+        degradedResponses.Inc()
+    } else if cacheable && !resp.PartialEvaluation {
+        noteRedisErr(rdb.Set(ctx, cacheKey, string(b), config.Get().API.ResponseCacheTTL).Err())
+    }
+    writeScore(w, r, http.StatusOK, resp)
+    mirror(r, req, resp)
+}
+
+// validateRequest checks the fields the JSON decoder can't and fills in
+// defaults.
+func validateRequest(req *TransactionRequest) error {
+    if req.MCC != nil && !mcc.Valid(*req.MCC) { return errors.New("mcc must be four digits") }
+    if err := validateContact(req); err != nil { return err }
+    if req.Country != nil {
+        c := strings.ToUpper(*req.Country)
+        if !validCountry(c) { return errors.New("country must be an ISO 3166 alpha-2 code") }
+        req.Country = &c
+    }
+    if (req.LocationLat == nil) != (req.LocationLon == nil) { return errors.New("location_lat and location_lon go together") }
+    if req.LocationLat != nil && (*req.LocationLat < -90 || *req.LocationLat > 90) { return errors.New("location_lat must be between -90 and 90") }
+    if req.LocationLon != nil && (*req.LocationLon < -180 || *req.LocationLon > 180) { return errors.New("location_lon must be between -180 and 180") }
+    if req.BehavioralScore != nil && (*req.BehavioralScore < 0 || *req.BehavioralScore > 1) { return errors.New("behavioral_score must be between 0 and 1") }
+    if req.SessionID != nil && len(*req.SessionID) > 100 { return errors.New("session_id must be at most 100 characters") }
+    if req.CardFingerprint != nil && len(*req.CardFingerprint) > 100 { return errors.New("card_fingerprint must be at most 100 characters") }
+    if req.AccountCreatedAt != nil && req.AccountCreatedAt.After(time.Now().Add(time.Minute)) { return errors.New("account_created_at is in the future") }
+    return normalizeChannel(req)
+}
+
+// processTransaction scores, stores and publishes a validated request. It is
+// shared by every ingestion path; callers handle response caching.
+func processTransaction(rctx context.Context, req TransactionRequest, tenant string, start time.Time) (TransactionResponse, error) {
+    txID := newTransactionID(time.Now())
+
+    bctx, cancel := withLatencyBudget(rctx, start)
+    defer cancel()
+    if code := merchantMCC(bctx, req); code != "" { req.MCC = &code }
+    fraudScore, confidence, riskFactors, f := scoreTransaction(bctx, req, tenant)
+    if len(f.Skipped) > 0 { partialEvaluations.Inc() }
+    isFraud := fraudScore > fraudThreshold(tenant, req.UserID)
+    if req.Channel == channelCard { countCardTransaction(rctx, req.UserID) }
+    countNewAccountTransaction(rctx, req.UserID, f)
+    duplicateOf := findDuplicate(rctx, txID, req)
+    if duplicateOf != "" {
+        riskFactors = append(riskFactors, "possible_duplicate")
+        duplicatesFlagged.Inc()
+    }
+    decision, declineReason, gates := decide(req, f, fraudScore, isFraud, duplicateOf != "")
+    riskFactors = append(riskFactors, gates...)
+    // Spend limits are a hard decline regardless of the score. The amount
+    // only counts against them if the transaction isn't declined.
+    reserved := false
+    if decision != decisionDecline {
+        if reserveSpend(rctx, req) {
+            reserved = true
         } else {
-            fraudScore, confidence, riskFactors = getFraudScorePlaceholder(req.Amount, req.MerchantRisk, userRisk, ratio)
+            decision, declineReason = decisionDecline, reasonLimitExceeded
+            riskFactors = append(riskFactors, reasonLimitExceeded)
         }
-    } else {
-        fraudScore, confidence, riskFactors = getFraudScorePlaceholder(req.Amount, req.MerchantRisk, userRisk, ratio)
     }
-    isFraud := fraudScore > 0.7
 
     // Ensure user exists (FK constraint)
-    if err := ensureUserExists(req.UserID); err != nil {
-        http.Error(w, "Failed to prepare user", http.StatusInternalServerError)
-        return
+    if err := ensureUserExists(rctx, req.UserID, req.AccountCreatedAt); err != nil {
+        if reserved { releaseSpend(rctx, req) }
+        return TransactionResponse{}, errors.New("Failed to prepare user")
     }
 
     // Store transaction
-    if err := storeTransaction(txID, req, fraudScore, isFraud); err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
-        return
+    countRuleHits(riskFactors)
+    if err := storeTransaction(rctx, txID, req, f.IPCountry, fraudScore, isFraud, decision, riskFactors); err != nil {
+        if reserved { releaseSpend(rctx, req) }
+        return TransactionResponse{}, err
     }
+    countRealtime(rctx, isFraud, decision, fraudScore)
+    meterScored(rctx)
 
     // Send to Kafka (best-effort)
-    sendToKafka(txID, req, fraudScore, isFraud)
+    sendToKafka(txID, req, fraudScore, isFraud, duplicateOf)
 
     resp := TransactionResponse{
-        TransactionID:    txID,
-        IsFraud:          isFraud,
-        FraudScore:       fraudScore,
-        Confidence:       confidence,
-        RiskFactors:      riskFactors,
-        ProcessingTimeMs: int(time.Since(start).Milliseconds()),
+        TransactionID:     txID,
+        IsFraud:           isFraud,
+        FraudScore:        fraudScore,
+        Confidence:        confidence,
+        RiskFactors:       riskFactors,
+        ProcessingTimeMs:  int(time.Since(start).Milliseconds()),
+        Degraded:          !cacheUp(),
+        DuplicateOf:       duplicateOf,
+        Decision:          decision,
+        ExpectedLoss:      expectedLoss(req.Amount, fraudScore),
+        DeclineReason:     declineReason,
+        PartialEvaluation: len(f.Skipped) > 0,
+        ScoringTier:       f.Tier,
+        FallbackReason:    f.FallbackReason,
     }
-    b, _ := json.Marshal(resp)
-    _ = rdb.Set(ctx, cacheKey, string(b), 5*time.Minute).Err()
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    w.Write(b)
+    logFeatures(txID, req, f, resp)
+    return resp, nil
 }
 
+// scoreTransaction scores req with the ML service when the ml_grpc flag is on
+// for tenant and the user, falling back to the local model and the rules
+// (see scoreTiered), and starts the shadow comparison when that flag is on.
+func scoreTransaction(rctx context.Context, req TransactionRequest, tenant string) (fraudScore, confidence float64, riskFactors []string, f features) {
+    // Feature engineering equivalents
+    f = enrich(rctx, req)
+
+    useML := featureFlags.On(flagMLGRPC, config.Get().API.UseMLGRPC, tenant, req.UserID)
+    fraudScore, confidence, riskFactors = scoreTiered(rctx, req, &f, useML)
+    // Skip the shadow when ML was wanted but failed; it would fail too. A
+    // dry run stays out of the comparison metrics.
+    if (f.Tier == tierML || !useML) && !dryRun(rctx) && featureFlags.On(flagShadowScoring, false, tenant, req.UserID) {
+        go shadowScore(req, f, fraudScore, f.Tier == tierML)
+    }
+    return fraudScore, confidence, riskFactors, f
+}
+
+// responseCacheKey hashes the request as re-encoded after decoding, so
+// whitespace and field order don't matter, together with the client's
+// Idempotency-Key. A retry within api.response_cache_ttl gets the original
+// response instead of being scored and stored a second time.
+func responseCacheKey(req TransactionRequest, idempotencyKey string) string {
+    b, _ := json.Marshal(req)
+    h := sha256.New()
+    h.Write([]byte(idempotencyKey))
+    h.Write([]byte{0})
+    h.Write(b)
+    return "transaction_response:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// responseCacheable reports whether the response cache applies. Requests
+// carry no transaction ID or timestamp of their own, so without an
+// Idempotency-Key an identical body is as likely the same payment submitted
+// again as a retry. While duplicates.window is on such a request is scored
+// again, so findDuplicate can flag it, rather than answered from the cache.
+func responseCacheable(idempotencyKey string) bool {
+    return idempotencyKey != "" || config.Get().Duplicates.Window <= 0
+}
+
+// batchProcessHandler scores up to scoring.max_batch transactions as bulk
+// work, scoring.batch_concurrency at a time. A transaction that fails
+// validation or scoring gets an error in its result; the others are still
+// scored.
 func batchProcessHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
     start := time.Now()
     var req BatchTransactionRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, err.Error(), http.StatusBadRequest)
+    if !decodeBody(w, r, &req, batchBodyLimit()) { return }
+    cfg := config.Get().Scoring
+    if len(req.Transactions) > cfg.MaxBatch {
+        http.Error(w, fmt.Sprintf("at most %d transactions per batch", cfg.MaxBatch), http.StatusRequestEntityTooLarge)
         return
     }
-    results := make([]TransactionResponse, 0, len(req.Transactions))
-    for range req.Transactions {
-        // Minimal stub: create placeholder responses
-        results = append(results, TransactionResponse{TransactionID: fmt.Sprintf("%d", time.Now().UnixNano()), IsFraud: false, FraudScore: 0.5, Confidence: 0.8, RiskFactors: []string{"batch_processing"}, ProcessingTimeMs: 0})
+    tenant := r.Header.Get("X-Tenant-ID")
+    results := make([]BatchResult, len(req.Transactions))
+    workers := make(chan struct{}, cfg.BatchConcurrency)
+    var wg sync.WaitGroup
+    for i, tx := range req.Transactions {
+        if err := validateRequest(&tx); err != nil { results[i].Error = err.Error(); continue }
+        if ipBlocked(r.Context(), tx) {
+            blockedRequests.Inc()
+            results[i].Error = "ip address temporarily blocked"
+            continue
+        }
+        workers <- struct{}{}
+        wg.Add(1)
+        go func() {
+            defer func() { <-workers; wg.Done() }()
+            resp, err := scoreBulk(r.Context(), tx, tenant)
+            if err != nil { results[i].Error = err.Error(); return }
+            if resp.Degraded { degradedResponses.Inc() }
+            results[i].TransactionResponse = resp
+        }()
     }
-    writeJSON(w, http.StatusOK, BatchTransactionResponse{Results: results, TotalProcessingTimeMs: int(time.Since(start).Milliseconds())})
+    wg.Wait()
+    writeScore(w, r, http.StatusOK, BatchTransactionResponse{Results: results, TotalProcessingTimeMs: int(time.Since(start).Milliseconds())})
 }
 
 func getTransactionHandler(w http.ResponseWriter, r *http.Request) {
-    // /transactions/{id}
+    // /transactions/{id}, POST /transactions/{id}/rescore,
+    // POST /transactions/{id}/verification, POST /transactions/{id}/label,
+    // /transactions/{id}/events
     parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/transactions/"), "/")
     if len(parts) == 0 || parts[0] == "" {
         http.Error(w, "missing id", http.StatusBadRequest)
         return
     }
     id := parts[0]
-    row := pg.QueryRow(`SELECT transaction_id, user_id, amount, timestamp, merchant_id, merchant_risk, fraud_score, is_fraud FROM transactions WHERE transaction_id = $1`, id)
-    var (
-        transactionID, userID, merchantID string
-        amount, merchantRisk, fraudScore float64
-        isFraud bool
-        ts time.Time
-    )
-    if err := row.Scan(&transactionID, &userID, &amount, &ts, &merchantID, &merchantRisk, &fraudScore, &isFraud); err != nil {
+    if len(parts) == 2 && parts[1] == "rescore" {
+        if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+        rescoreHandler(w, r, id)
+        return
+    }
+    if len(parts) == 2 && parts[1] == "label" {
+        if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+        // Labels feed the threshold sweep and settle transactions, so only
+        // analysts (and pipelines holding an analyst token) may write them.
+        withAdminAuth(roleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { labelHandler(w, r, id) })).ServeHTTP(w, r)
+        return
+    }
+    if len(parts) == 2 && parts[1] == "events" {
+        lifecycleEventsHandler(w, r, id)
+        return
+    }
+    if len(parts) == 2 && parts[1] == "verification" {
+        if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+        verificationHandler(w, r, id)
+        return
+    }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    from, to := transactionTimeWindow(id)
+    t, err := txStore.Get(qctx, id, from, to)
+    if err != nil {
+        if errors.Is(err, store.ErrNotFound) { noteTransactionMiss(r, id) }
         http.Error(w, "Transaction not found", http.StatusNotFound)
         return
     }
+    writeJSONConditional(w, r, transactionJSON(t, riskFactorLanguage(w, r)), t.UpdatedAt)
+}
+
+// transactionJSON is how GET /transactions/{id} and GET /transactions
+// present a stored transaction, with its risk factors described in lang
+// unless that is "".
+func transactionJSON(t store.Transaction, lang string) map[string]interface{} {
+    out := map[string]interface{}{
+        "transaction_id": t.TransactionID,
+        "user_id": t.UserID,
+        "amount": t.Amount,
+        "timestamp": t.Timestamp,
+        "merchant_id": t.MerchantID,
+        "merchant_risk": t.MerchantRisk,
+        "mcc": t.MCC,
+        "channel": t.Channel,
+        "country": t.Country,
+        "behavioral_score": t.BehavioralScore,
+        "session_id": t.SessionID,
+        "fraud_score": t.FraudScore,
+        "is_fraud": t.IsFraud,
+        "decision": t.Decision,
+        "risk_factors": t.RiskFactors,
+        "status": t.Status,
+        "lifecycle_status": t.LifecycleStatus,
+        "captured_amount": t.CapturedAmount,
+        "refunded_amount": t.RefundedAmount,
+    }
+    if lang != "" { out["risk_factor_descriptions"] = describeRiskFactors(t.RiskFactors, lang) }
+    return out
+}
+
+// rescoreHandler scores a stored transaction again with the current rules,
+// flags and model and overwrites its fraud_score and is_fraud, e.g. after a
+// rule change or for a disputed decision. Nothing is published: downstream
+// consumers already saw the transaction when it was first scored.
+func rescoreHandler(w http.ResponseWriter, r *http.Request, id string) {
+    from, to := transactionTimeWindow(id)
+    qctx, cancel := conn.QueryCtx(r.Context())
+    t, err := txStore.Get(qctx, id, from, to)
+    cancel()
+    if errors.Is(err, store.ErrNotFound) { http.Error(w, "Transaction not found", http.StatusNotFound); return }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+
+    req := TransactionRequest{UserID: t.UserID, Amount: t.Amount, MerchantID: t.MerchantID, MerchantRisk: t.MerchantRisk, MCC: t.MCC, Channel: t.Channel, Country: t.Country,
+        BehavioralScore: t.BehavioralScore, SessionID: t.SessionID}
+    fraudScore, confidence, riskFactors, f := scoreTransaction(r.Context(), req, r.Header.Get("X-Tenant-ID"))
+    isFraud := fraudScore > fraudThreshold(r.Header.Get("X-Tenant-ID"), req.UserID)
+    decision, _, gates := decide(req, f, fraudScore, isFraud, false)
+    riskFactors = append(riskFactors, gates...)
+    qctx, cancel = conn.QueryCtx(r.Context())
+    defer cancel()
+    if err := txStore.UpdateScore(qctx, id, from, to, fraudScore, isFraud, decision, riskFactors); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
     writeJSON(w, http.StatusOK, map[string]interface{}{
-        "transaction_id": transactionID,
-        "user_id": userID,
-        "amount": amount,
-        "timestamp": ts,
-        "merchant_id": merchantID,
-        "merchant_risk": merchantRisk,
+        "transaction_id": id,
+        "previous_fraud_score": t.FraudScore,
+        "previous_is_fraud": t.IsFraud,
+        "previous_decision": t.Decision,
         "fraud_score": fraudScore,
         "is_fraud": isFraud,
+        "decision": decision,
+        "confidence": confidence,
+        "risk_factors": riskFactors,
+        "degraded": !cacheUp(),
+        "scoring_tier": f.Tier,
+        "fallback_reason": f.FallbackReason,
     })
 }
 
@@ -218,94 +568,171 @@ func userRiskHandler(w http.ResponseWriter, r *http.Request) {
     // /users/{id}/risk-score
     id := strings.TrimPrefix(r.URL.Path, "/users/")
     id = strings.TrimSuffix(id, "/risk-score")
-    row := pg.QueryRow(`SELECT risk_score FROM users WHERE user_id = $1`, id)
-    var risk float64
-    if err := row.Scan(&risk); err != nil {
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    risk, err := userStore.RiskScore(qctx, id)
+    if err != nil {
         http.Error(w, "User not found", http.StatusNotFound)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": id, "risk_score": risk})
+    writeJSONConditional(w, r, map[string]interface{}{"user_id": id, "risk_score": risk}, time.Time{})
 }
 
 func alertsHandler(w http.ResponseWriter, r *http.Request) {
-    // /alerts?status=OPEN&limit=100
-    q := r.URL.Query()
-    status := q.Get("status")
+    // /alerts?status=OPEN&limit=100&cursor=...
+    status := r.URL.Query().Get("status")
     if status == "" { status = "OPEN" }
-    limit := 100
-    if s := q.Get("limit"); s != "" {
-        if v, err := strconv.Atoi(s); err == nil { limit = v }
-    }
-    rows, err := pg.Query(`SELECT alert_id, transaction_id, alert_type, severity, description, confidence_score, status, created_at FROM fraud_alerts WHERE status = $1 ORDER BY created_at DESC LIMIT $2`, status, limit)
+    page, ok := pageParams(w, r, 100, 1000)
+    if !ok { return }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := alertStore.List(qctx, status, page.query())
     if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
-    defer rows.Close()
-    type Alert struct {
-        AlertID        string      `json:"alert_id"`
-        TransactionID  string      `json:"transaction_id"`
-        AlertType      string      `json:"alert_type"`
-        Severity       string      `json:"severity"`
-        Description    string      `json:"description"`
-        Confidence     float64     `json:"confidence_score"`
-        Status         string      `json:"status"`
-        CreatedAt      time.Time   `json:"created_at"`
-    }
-    var out []Alert
-    for rows.Next() {
-        var a Alert
-        if err := rows.Scan(&a.AlertID, &a.TransactionID, &a.AlertType, &a.Severity, &a.Description, &a.Confidence, &a.Status, &a.CreatedAt); err != nil { continue }
-        out = append(out, a)
-    }
-    writeJSON(w, http.StatusOK, out)
-}
-
-func getUserRiskScore(userID string) float64 {
-    var risk float64 = 0.5
-    row := pg.QueryRow(`SELECT risk_score FROM users WHERE user_id = $1`, userID)
-    _ = row.Scan(&risk)
-    return risk
-}
-
-func getAmountToHistoryRatio(userID string, amount float64) float64 {
-    var avg sql.NullFloat64
-    row := pg.QueryRow(`SELECT AVG(amount) FROM transactions WHERE user_id = $1`, userID)
-    _ = row.Scan(&avg)
-    base := 100.0
-    if avg.Valid && avg.Float64 > 0 { base = avg.Float64 }
-    return amount / base
-}
-
-func ensureUserExists(userID string) error {
+    out, next := pageOf(page, rows, func(a store.Alert) store.Cursor { return store.Cursor{At: a.CreatedAt, ID: a.AlertID} })
+    writeJSONConditional(w, r, pageBody("alerts", out, next), time.Time{})
+}
+
+// historyStart bounds per-user history aggregates to recent partitions.
+func historyStart() time.Time {
+    return time.Now().UTC().AddDate(0, 0, -config.Get().API.UserHistoryDays)
+}
+
+// AverageAmount is the user's average amount over api.user_history_days.
+// Hot users' averages are preloaded by the cache warmer; for the rest it is
+// queried.
+func (liveSource) AverageAmount(ctx context.Context, userID string) (float64, bool) {
+    if cacheUp() {
+        v, err := rdb.Get(ctx, "user_avg_amount:"+userID).Float64()
+        if err == nil { return v, true }
+        noteRedisErr(err)
+    }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    avg, ok, _ := txStore.AverageAmount(qctx, userID, historyStart())
+    return avg, ok
+}
+
+func ensureUserExists(ctx context.Context, userID string, createdAt *time.Time) error {
     // Insert user with default risk score if not exists
-    _, err := pg.Exec(`INSERT INTO users (user_id, risk_score) VALUES ($1, $2)
-                       ON CONFLICT (user_id) DO NOTHING`, userID, 0.5)
-    return err
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    return userStore.Ensure(qctx, userID, feature.DefaultUserRisk, createdAt)
 }
 
-func getFraudScorePlaceholder(amount, merchantRisk, userRisk, ratio float64) (float64, float64, []string) {
+// features are the per-transaction inputs both scorers use beyond the
+// request itself.
+type features struct {
+    UserRisk    float64
+    AmountRatio float64
+    // AmountZScore is how many standard deviations the amount lies from
+    // the user's mean; 0 until they have enough history.
+    AmountZScore float64
+
+    // Category is the merchant category (see internal/mcc), "" if unknown.
+    Category          string
+    HighRiskCategory  bool
+    // FirstTimeCategory is set when the user has never paid in Category.
+    FirstTimeCategory bool
+    // CategoryShare is the fraction of the user's transactions in Category.
+    CategoryShare     float64
+
+    // CardVelocity counts the user's earlier card transactions within
+    // rules.card_velocity_window; 0 for other channels.
+    CardVelocity int
+
+    DisposableEmail bool
+    // EmailAgeDays is how long ago the email was first seen, -1 if unknown.
+    EmailAgeDays    float64
+    VoIPPhone       bool
+
+    // KYCStatus is pending, verified, failed or "" without a record.
+    KYCStatus string
+
+    AccountAgeHours float64
+    // FirstTransactionHours is how long ago the user's first transaction
+    // was, -1 if this is it.
+    FirstTransactionHours float64
+    // NewAccountTransactions counts earlier transactions while the account
+    // was new; 0 once it isn't.
+    NewAccountTransactions int
+
+    // TravelNotice is set when a notice the user registered covers the
+    // transaction's country now, for location rules to stand down.
+    TravelNotice bool
+
+    // IPCountry is the GeoIP country of the request's IP, "" if unknown.
+    IPCountry       string
+    HighRiskCountry bool
+    HighRiskIPRange bool
+    // GeoBlock is blocked_country or blocked_ip_range when a block rule
+    // matched; decide declines those transactions.
+    GeoBlock string
+
+    // Precomputed holds the aggregates the offline pipeline pushed through
+    // /features/batch, by name.
+    Precomputed map[string]float64
+
+    // Plugin holds enrichment plugins' features, keyed <plugin>_<feature>;
+    // their risk factors and score adjustments apply to the rules' score.
+    Plugin            map[string]float64
+    PluginRiskFactors []string
+    ScoreAdjustment   float64
+
+    // Skipped lists the stages the latency budget left out, "ml" included.
+    Skipped []string
+    // Tier is the scoring tier that scored the transaction; FallbackReason
+    // says why the ML service didn't when it was wanted.
+    Tier           string
+    FallbackReason string
+}
+
+func getFraudScorePlaceholder(req TransactionRequest, f features) (float64, float64, []string) {
+    rules := config.Get().Rules
+    limit := highAmount(rules, req.Channel)
     score := 0.3
-    if amount > 5000 { score += 0.3 }
-    score += 0.2 * merchantRisk
-    score += 0.1 * userRisk
-    if ratio > 5 { score += 0.2 }
+    if req.Amount > limit { score += 0.3 }
+    score += 0.2 * req.MerchantRisk
+    score += 0.1 * f.UserRisk
+    if f.AmountRatio > rules.UnusualAmountRatio { score += 0.2 }
+    if f.AmountZScore > rules.AmountZScore { score += 0.15 }
+    if f.HighRiskCategory && f.FirstTimeCategory { score += 0.2 }
+    if f.CardVelocity >= rules.CardVelocity { score += 0.2 }
+    if f.DisposableEmail { score += 0.15 }
+    if newEmail(f, rules) && req.Amount > limit/2 { score += 0.1 }
+    if f.VoIPPhone { score += 0.1 }
+    if newAccount(f, rules) && req.Amount > rules.NewAccountHighAmount { score += 0.15 }
+    if f.NewAccountTransactions >= rules.NewAccountVelocity { score += 0.15 }
+    if req.BehavioralScore != nil { score += rules.BehavioralWeight * *req.BehavioralScore }
+    if f.HighRiskCountry { score += 0.2 }
+    if f.HighRiskIPRange { score += 0.2 }
+    score += f.ScoreAdjustment
     if score > 1 { score = 1 }
+    if score < 0 { score = 0 }
     rf := []string{}
-    if amount > 5000 { rf = append(rf, "high_amount") }
-    if merchantRisk > 0.8 { rf = append(rf, "high_merchant_risk") }
-    if userRisk > 0.7 { rf = append(rf, "high_user_risk") }
-    if ratio > 5 { rf = append(rf, "unusual_amount_pattern") }
+    if req.Amount > limit { rf = append(rf, "high_amount") }
+    if req.MerchantRisk > rules.HighMerchantRisk { rf = append(rf, "high_merchant_risk") }
+    if f.UserRisk > rules.HighUserRisk { rf = append(rf, "high_user_risk") }
+    if f.AmountRatio > rules.UnusualAmountRatio { rf = append(rf, "unusual_amount_pattern") }
+    if f.AmountZScore > rules.AmountZScore { rf = append(rf, "amount_zscore_outlier") }
+    if f.HighRiskCategory {
+        if f.FirstTimeCategory { rf = append(rf, "first_time_high_risk_category") } else { rf = append(rf, "high_risk_category") }
+    }
+    if f.CardVelocity >= rules.CardVelocity { rf = append(rf, "card_velocity") }
+    if f.DisposableEmail { rf = append(rf, "disposable_email") }
+    if newEmail(f, rules) { rf = append(rf, "new_email") }
+    if f.VoIPPhone { rf = append(rf, "voip_phone") }
+    if newAccount(f, rules) && req.Amount > rules.NewAccountHighAmount { rf = append(rf, "new_account_high_amount") }
+    if f.NewAccountTransactions >= rules.NewAccountVelocity { rf = append(rf, "new_account_velocity") }
+    if req.BehavioralScore != nil && *req.BehavioralScore > rules.HighBehavioralScore { rf = append(rf, "behavioral_anomaly") }
+    if f.HighRiskCountry { rf = append(rf, "high_risk_country") }
+    if f.HighRiskIPRange { rf = append(rf, "high_risk_ip_range") }
+    rf = append(rf, f.PluginRiskFactors...)
     return score, 0.8, rf
 }
 
-// getFraudScoreGRPC is a stub for calling the Python ML gRPC service.
-// Replace with generated client from protos in /protos when available.
-func getFraudScoreGRPC(req TransactionRequest, userRisk, ratio float64) (float64, float64, []string, error) {
-    addr := getenv("ML_GRPC_ADDR", "fraud_ml:50051")
-    conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-    if err != nil { return 0, 0, nil, err }
-    defer conn.Close()
-
-    client := pb.NewFraudDetectionServiceClient(conn)
-
+// getFraudScoreGRPC scores with the Python ML gRPC service, on one of the
+// healthy ML endpoints.
+func getFraudScoreGRPC(rctx context.Context, req TransactionRequest, f features) (float64, float64, []string, error) {
     now := time.Now().Unix()
     pbReq := &pb.TransactionRequest{
         TransactionId: "",
@@ -314,36 +741,126 @@ func getFraudScoreGRPC(req TransactionRequest, userRisk, ratio float64) (float64
         Timestamp:     now,
         MerchantId:    req.MerchantID,
         MerchantRisk:  req.MerchantRisk,
+        AdditionalFeatures: mlFeatures(req, f),
     }
     if req.DeviceID != nil { pbReq.DeviceId = *req.DeviceID }
     if req.IPAddress != nil { pbReq.IpAddress = *req.IPAddress }
 
-    cctx, cancel := context.WithTimeout(ctx, 2*time.Second)
-    defer cancel()
-    resp, err := client.GetFraudScore(cctx, pbReq)
+    resp, err := mlScore(rctx, pbReq)
     if err != nil { return 0, 0, nil, err }
 
-    return resp.GetFraudScore(), resp.GetConfidence(), resp.GetRiskFactors(), nil
+    return resp.GetFraudScore(), resp.GetConfidence(), append(resp.GetRiskFactors(), f.PluginRiskFactors...), nil
 }
 
-func storeTransaction(txID string, t TransactionRequest, fraudScore float64, isFraud bool) error {
-    _, err := pg.Exec(`INSERT INTO transactions (transaction_id, user_id, amount, timestamp, merchant_id, merchant_risk, fraud_score, is_fraud) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
-        txID, t.UserID, t.Amount, time.Now().UTC(), t.MerchantID, t.MerchantRisk, fraudScore, isFraud)
-    return err
+// mlFeatures is what the ML service, and the local model standing in for
+// it, are given besides the request's own fields.
+func mlFeatures(req TransactionRequest, f features) map[string]float64 {
+    out := map[string]float64{
+        "user_risk":     f.UserRisk,
+        "amount_ratio":  f.AmountRatio,
+        "amount_zscore": f.AmountZScore,
+        "category_share": f.CategoryShare,
+        "high_risk_category": boolFeature(f.HighRiskCategory),
+        "first_time_category": boolFeature(f.FirstTimeCategory),
+        "card_velocity": float64(f.CardVelocity),
+        "channel_card": boolFeature(req.Channel == channelCard),
+        "channel_ach": boolFeature(req.Channel == channelACH),
+        "channel_wire": boolFeature(req.Channel == channelWire),
+        "channel_p2p": boolFeature(req.Channel == channelP2P),
+        "disposable_email": boolFeature(f.DisposableEmail),
+        "email_age_days": f.EmailAgeDays,
+        "voip_phone": boolFeature(f.VoIPPhone),
+        "account_age_hours": f.AccountAgeHours,
+        "first_transaction_hours": f.FirstTransactionHours,
+        "new_account_transactions": float64(f.NewAccountTransactions),
+        "travel_notice": boolFeature(f.TravelNotice),
+        "high_risk_country": boolFeature(f.HighRiskCountry),
+        "high_risk_ip_range": boolFeature(f.HighRiskIPRange),
+    }
+    // Absent rather than a sentinel, so the model can tell "no SDK" apart.
+    if req.BehavioralScore != nil {
+        out["behavioral_score"] = *req.BehavioralScore
+        out["behavioral_score_weighted"] = config.Get().Rules.BehavioralWeight * *req.BehavioralScore
+    }
+    for k, v := range f.Precomputed { out["precomputed_"+k] = v }
+    for k, v := range f.Plugin { out[k] = v }
+    return out
 }
 
-func sendToKafka(txID string, t TransactionRequest, fraudScore float64, isFraud bool) {
-    if kafkaW == nil { return }
-    payload := map[string]interface{}{
-        "transaction_id": txID,
-        "user_id": t.UserID,
-        "amount": t.Amount,
-        "fraud_score": fraudScore,
-        "is_fraud": isFraud,
-        "timestamp": time.Now().Unix(),
+// newEmail reports an email first seen within rules.new_email_days.
+func newEmail(f features, rules config.Rules) bool {
+    return f.EmailAgeDays >= 0 && f.EmailAgeDays < float64(rules.NewEmailDays)
+}
+
+func boolFeature(b bool) float64 {
+    if b { return 1 }
+    return 0
+}
+
+// storeTransaction records the scored request; ipCountry is the GeoIP
+// country of its IP, "" if unknown.
+func storeTransaction(ctx context.Context, txID string, t TransactionRequest, ipCountry string, fraudScore float64, isFraud bool, decision string, riskFactors []string) error {
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    st := store.Transaction{
+        TransactionID:   txID,
+        UserID:          t.UserID,
+        Amount:          t.Amount,
+        Timestamp:       time.Now().UTC(),
+        MerchantID:      t.MerchantID,
+        MerchantRisk:    t.MerchantRisk,
+        MCC:             t.MCC,
+        Channel:         t.Channel,
+        Country:         t.Country,
+        BehavioralScore: t.BehavioralScore,
+        SessionID:       t.SessionID,
+        FraudScore:      fraudScore,
+        IsFraud:         isFraud,
+        Decision:        &decision,
+        RiskFactors:     riskFactors,
+        LocationLat:     t.LocationLat,
+        LocationLon:     t.LocationLon,
+    }
+    if t.LocationLat != nil && t.LocationLon != nil {
+        h := geohash.Encode(*t.LocationLat, *t.LocationLon, geohash.MaxStored)
+        st.Geohash = &h
+    }
+    if ipCountry != "" { st.IPCountry = &ipCountry }
+    return txStore.Insert(qctx, st)
+}
+
+// sendToKafka publishes the scored transaction. Scores above
+// api.priority_score_threshold go to the fast-lane topic, which a dedicated
+// processor instance consumes.
+func sendToKafka(txID string, t TransactionRequest, fraudScore float64, isFraud bool, duplicateOf string) {
+    if txPub == nil { return }
+    ev := events.TransactionEvent{
+        TransactionID:   txID,
+        UserID:          t.UserID,
+        Amount:          t.Amount,
+        FraudScore:      fraudScore,
+        IsFraud:         isFraud,
+        Timestamp:       time.Now().Unix(),
+        DeviceID:        t.DeviceID,
+        IPAddress:       t.IPAddress,
+        MerchantID:      &t.MerchantID,
+        MCC:             t.MCC,
+        Channel:         &t.Channel,
+        Country:         t.Country,
+        CardFingerprint: t.CardFingerprint,
+    }
+    if duplicateOf != "" { ev.DuplicateOf = &duplicateOf }
+    codec := events.ProtobufCodec
+    if kafkaReady.Load() { codec = txCodec }
+    b, err := codec.Encode(ev)
+    if err != nil { log.Printf("encode transaction event: %v", err); return }
+    if err := txPub.Publish([]byte(t.UserID), b, codec.ContentType()); err != nil { log.Printf("publish transaction event: %v", err) }
+    // Every event stays on the main topic, so each user's events reach the
+    // processor that keeps their risk and velocity state in order. The
+    // priority topic only gets a copy, for the fast lane to alert on.
+    if fraudScore > config.Get().API.PriorityScoreThreshold {
+        if err := txPriorityPub.Publish([]byte(t.UserID), b, codec.ContentType()); err != nil { log.Printf("publish priority transaction event: %v", err) }
     }
-    b, _ := json.Marshal(payload)
-    _ = kafkaW.WriteMessages(ctx, kafka.Message{Value: b})
 }
 
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
@@ -352,36 +869,110 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
     _ = json.NewEncoder(w).Encode(v)
 }
 
-func withCORS(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        w.Header().Set("Access-Control-Allow-Origin", "*")
-        w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
-        if r.Method == http.MethodOptions { w.WriteHeader(http.StatusNoContent); return }
-        next.ServeHTTP(w, r)
-    })
-}
-
 func main() {
+    migrateOnStart := flag.Bool("migrate", false, "apply pending database migrations before serving")
+    configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file; environment variables override it")
+    flag.Parse()
+
+    if err := config.Init(*configFile); err != nil { log.Fatalf("invalid configuration: %v", err) }
+    config.Watch(*configFile)
+
     if err := initConnections(); err != nil {
         log.Fatalf("startup error: %v", err)
     }
+    if *migrateOnStart {
+        if err := runMigrations(); err != nil { log.Fatalf("migration failed: %v", err) }
+    }
+    go runPartitionMaintenance(config.Get().Partitions.MaintenanceInterval)
+    go runCacheWarmer(config.Get().API.CacheWarmInterval)
+    if err := initPlugins(); err != nil { log.Fatalf("startup error: %v", err) }
+    if err := initMLTLS(); err != nil { log.Fatalf("startup error: %v", err) }
+    if err := initMLEndpoints(); err != nil { log.Fatalf("startup error: %v", err) }
+    initGeo()
+    initRiskFactorText()
+    initLocalModel()
+    initFeast()
+    go runThresholdAnalysis()
+    go runOutboxRelay()
+    go runWarehouseExport()
+    go runReportScheduler()
+    go runSuppressionExpiry()
+    go runAlertAutoClose()
+    go runAlertSLA()
+    go runRollups()
+    go runUsageFlusher()
+    go runCallerMonitor()
+    go runLifecycleConsumer()
+    runWebhookWorkers()
+    runMirrorWorkers()
+
+    addr := ":8000"
+    log.Printf("Go Fraud API listening on %s", addr)
+    srv := &http.Server{ Addr: addr, Handler: routes(), ReadTimeout: 15 * time.Second, WriteTimeout: 15 * time.Second }
+    go func() {
+        if err := srv.ListenAndServe(); err != http.ErrServerClosed { log.Fatal(err) }
+    }()
+
+    // On shutdown stop accepting requests, then flush buffered batches.
+    stop := make(chan os.Signal, 1)
+    signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+    <-stop
+    sctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+    defer cancel()
+    _ = srv.Shutdown(sctx)
+    flushUsage()
+    for _, p := range []publisher{txPub, txPriorityPub, alertPub, featurePub} {
+        if p == nil { continue }
+        if err := p.Close(); err != nil { log.Printf("event bus flush error: %v", err) }
+    }
+}
+
+// routes is the API's handler: every endpoint, behind the middleware that
+// applies to all of them.
+//
+// Analyst operations (changing KYC status, limits, travel notices, alerts
+// and cases, labelling transactions) and reads of the audit trail, search
+// index and analyst stats need an analyst's token; backtests, threshold sweeps and
+// feature pushes, which are expensive or change scoring, need an admin's.
+// Reads of users, alerts and cases stay open to the dashboard and the
+// services that poll them.
+func routes() http.Handler {
+    analystWrites := func(h http.HandlerFunc) http.Handler { return withAdminWrites(roleAnalyst, h) }
+    kyc, limits, travel := analystWrites(kycHandler), analystWrites(limitsHandler), analystWrites(travelNoticesHandler)
     mux := http.NewServeMux()
     mux.HandleFunc("/", rootHandler)
     mux.HandleFunc("/health", healthHandler)
     mux.HandleFunc("/transactions/process", processTransactionHandler)
+    mux.HandleFunc("/transactions/score-only", scoreOnlyHandler)
     mux.HandleFunc("/transactions/batch", batchProcessHandler)
+    mux.HandleFunc("/transactions/iso20022", iso20022Handler)
+    mux.HandleFunc("/transactions", transactionsHandler)
     mux.HandleFunc("/transactions/", getTransactionHandler)
+    mux.HandleFunc("/users", usersHandler)
     mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
         if strings.HasSuffix(r.URL.Path, "/risk-score") { userRiskHandler(w, r); return }
+        if strings.HasSuffix(r.URL.Path, "/kyc") { kyc.ServeHTTP(w, r); return }
+        if strings.HasSuffix(r.URL.Path, "/limits") { limits.ServeHTTP(w, r); return }
+        if strings.Contains(r.URL.Path, "/travel-notices") { travel.ServeHTTP(w, r); return }
         http.NotFound(w, r)
     })
+    mux.HandleFunc("/risk-factors", riskFactorsHandler)
+    mux.Handle("/features/batch", withAdminAuth(roleAdmin, http.HandlerFunc(featuresBatchHandler)))
     mux.HandleFunc("/alerts", alertsHandler)
-
-    addr := ":8000"
-    log.Printf("Go Fraud API listening on %s", addr)
-    srv := &http.Server{ Addr: addr, Handler: withCORS(mux), ReadTimeout: 15 * time.Second, WriteTimeout: 15 * time.Second }
-    log.Fatal(srv.ListenAndServe())
+    mux.Handle("/alerts/", analystWrites(alertHandler))
+    mux.HandleFunc("/cases", casesHandler)
+    mux.Handle("/cases/", analystWrites(caseHandler))
+    mux.Handle("/stats/analysts", withAdminAuth(roleAnalyst, http.HandlerFunc(analystStatsHandler)))
+    mux.HandleFunc("/stats/top", topEntitiesHandler)
+    mux.HandleFunc("/stats/geo", geoStatsHandler)
+    mux.HandleFunc("/stats/realtime", realtimeHandler)
+    mux.Handle("/audit", withAdminAuth(roleAnalyst, http.HandlerFunc(auditHandler)))
+    mux.Handle("/thresholds/analysis", withAdminAuth(roleAdmin, http.HandlerFunc(thresholdAnalysisHandler)))
+    mux.Handle("/backtest", withAdminAuth(roleAdmin, http.HandlerFunc(backtestHandler)))
+    mux.Handle("/backtest/", withAdminAuth(roleAdmin, http.HandlerFunc(backtestHandler)))
+    mux.HandleFunc("/webhooks/", webhookHandler)
+    mux.Handle("/search/", withAdminAuth(roleAnalyst, http.HandlerFunc(searchHandler)))
+    mux.Handle("/admin/", adminHandler())
+    mux.Handle("/metrics", promhttp.Handler())
+    return withRequestID(withCompression(withSecurityHeaders(withCORS(withAbuse(withRateLimit(withUsage(mux)))))))
 }
-
-
@@ -0,0 +1,62 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "strconv"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+const (
+    // Merchants rarely change category; the processor updates the row when
+    // one does.
+    merchantMCCTTL     = time.Hour
+    merchantMCCMissTTL = 5 * time.Minute
+)
+
+// merchantMCC returns the request's MCC, or else the one last recorded for
+// the merchant (cached in merchant_mcc:<id>), or "" when neither is known.
+func merchantMCC(ctx context.Context, req TransactionRequest) string {
+    if req.MCC != nil { return *req.MCC }
+    if req.MerchantID == "" { return "" }
+    key := "merchant_mcc:" + req.MerchantID
+    if cacheUp() {
+        v, err := rdb.Get(ctx, key).Result()
+        if err == nil {
+            if v == userRiskMiss { return "" }
+            return v
+        }
+        noteRedisErr(err)
+    }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    code, err := merchantStore.MCC(qctx, req.MerchantID)
+    switch {
+    case errors.Is(err, store.ErrNotFound):
+        if cacheUp() { noteRedisErr(rdb.Set(ctx, key, userRiskMiss, merchantMCCMissTTL).Err()) }
+        return ""
+    case err != nil:
+        return ""
+    }
+    if cacheUp() { noteRedisErr(rdb.Set(ctx, key, code, merchantMCCTTL).Err()) }
+    return code
+}
+
+// CategoryCounts reads the per-user counts the processor keeps in
+// user_categories:<id> (one field per category plus _total). There are none
+// while Redis is unavailable.
+func (liveSource) CategoryCounts(ctx context.Context, userID, category string) (int64, int64, bool) {
+    if !cacheUp() { return 0, 0, false }
+    vals, err := rdb.HMGet(ctx, "user_categories:"+userID, category, "_total").Result()
+    if err != nil { noteRedisErr(err); return 0, 0, false }
+    return hashInt(vals[0]), hashInt(vals[1]), true
+}
+
+func hashInt(v interface{}) int64 {
+    s, _ := v.(string)
+    n, _ := strconv.ParseInt(s, 10, 64)
+    return n
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+    kafkaDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_kafka_messages_delivered_total",
+        Help: "Messages acknowledged by Kafka, by topic.",
+    }, []string{"topic"})
+    kafkaDeliveryFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_kafka_delivery_failures_total",
+        Help: "Messages Kafka failed to accept, by topic.",
+    }, []string{"topic"})
+    outboxSpilled = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_outbox_spilled_total",
+        Help: "Undelivered messages written to the kafka_outbox table, by topic.",
+    }, []string{"topic"})
+    outboxReplayed = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_outbox_replayed_total",
+        Help: "Messages from the kafka_outbox table delivered by the background relay.",
+    })
+    duplicatesFlagged = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_possible_duplicates_total",
+        Help: "Transactions flagged possible_duplicate.",
+    })
+    limitsExceeded = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_limit_exceeded_total",
+        Help: "Transactions declined for exceeding a spend limit.",
+    })
+    warehouseExported = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_warehouse_exported_rows_total",
+        Help: "Rows loaded into the data warehouse, by table.",
+    }, []string{"table"})
+    warehouseLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_api_warehouse_export_last_success_timestamp_seconds",
+        Help: "When the last warehouse export completed without error.",
+    })
+    alertsResolved = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_alerts_resolved_total",
+        Help: "Alerts resolved by analysts, by resolution (FRAUD or FALSE_POSITIVE).",
+    }, []string{"resolution"})
+    alertsAutoClosed = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_alerts_auto_closed_total",
+        Help: "Open alerts closed as AUTO_CLOSED_STALE after auto_close.max_age, by severity.",
+    }, []string{"severity"})
+    alertSLABreaches = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_alert_sla_breaches_total",
+        Help: "Open alerts that went unacknowledged past their alert_sla target, by severity.",
+    }, []string{"severity"})
+    transactionStatusChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_transaction_status_changes_total",
+        Help: "Transactions moved to a new status, by status and reason (review, verification, label or chargeback).",
+    }, []string{"status", "reason"})
+    reportsDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_reports_delivered_total",
+        Help: "Scheduled reports delivered, by report (daily or weekly) and destination (email or s3).",
+    }, []string{"report", "destination"})
+    reportsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_reports_failed_total",
+        Help: "Scheduled report runs that failed to build or to reach a destination, by report.",
+    }, []string{"report"})
+    scoringInflight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "fraud_api_scoring_inflight",
+        Help: "Transactions being scored, by class (realtime or bulk).",
+    }, []string{"class"})
+    scoringQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "fraud_api_scoring_queued",
+        Help: "Transactions waiting for a scoring slot, by class.",
+    }, []string{"class"})
+    scoringQueueWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "fraud_api_scoring_queue_wait_seconds",
+        Help:    "Time spent waiting for a scoring slot, by class.",
+        Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+    }, []string{"class"})
+    scoringRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_scoring_rejected_total",
+        Help: "Transactions turned away without a scoring slot, by class and reason (timeout or shed).",
+    }, []string{"class", "reason"})
+    scoringLimit = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_api_scoring_limit",
+        Help: "Scoring slots currently allowed by the adaptive limiter.",
+    })
+)
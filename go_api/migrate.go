@@ -0,0 +1,37 @@
+package main
+
+import (
+    "embed"
+    "errors"
+    "log"
+
+    "github.com/golang-migrate/migrate/v4"
+    migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+    "github.com/golang-migrate/migrate/v4/source/iofs"
+    "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Versioned schema migrations, embedded so a binary always carries the schema
+// its queries expect. Add NNNNNN_name.up.sql / .down.sql pairs; never edit a
+// migration that has shipped.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// runMigrations applies pending migrations. The driver holds a Postgres
+// advisory lock, so replicas started together with -migrate don't race.
+func runMigrations() error {
+    src, err := iofs.New(migrationFiles, "migrations")
+    if err != nil { return err }
+    db := stdlib.OpenDBFromPool(pg)
+    defer db.Close()
+    driver, err := migratepgx.WithInstance(db, &migratepgx.Config{})
+    if err != nil { return err }
+    m, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+    if err != nil { return err }
+    if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) { return err }
+    version, dirty, err := m.Version()
+    if err != nil && !errors.Is(err, migrate.ErrNilVersion) { return err }
+    log.Printf("database schema at version %d (dirty=%v)", version, dirty)
+    return nil
+}
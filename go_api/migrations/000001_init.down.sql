@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS fraud_alerts_duplicates;
+DROP TABLE IF EXISTS kafka_outbox;
+DROP TABLE IF EXISTS processed_transactions;
+DROP TABLE IF EXISTS feature_store;
+DROP TABLE IF EXISTS model_metadata;
+DROP TABLE IF EXISTS fraud_alerts;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS users;
@@ -1,4 +1,6 @@
--- Initialize Fraud Detection Database
+-- Baseline schema. Statements are idempotent, and the columns the old
+-- init.sql script's tables lacked are added below, so this also applies
+-- cleanly to databases created by that script.
 
 -- Create tables
 CREATE TABLE IF NOT EXISTS users (
@@ -29,6 +31,7 @@ CREATE TABLE IF NOT EXISTS transactions (
 
 CREATE TABLE IF NOT EXISTS fraud_alerts (
     id SERIAL PRIMARY KEY,
+    alert_id VARCHAR(100) UNIQUE,
     transaction_id VARCHAR(100) NOT NULL,
     alert_type VARCHAR(50) NOT NULL,
     severity VARCHAR(20) NOT NULL,
@@ -57,18 +60,54 @@ CREATE TABLE IF NOT EXISTS model_metadata (
 CREATE TABLE IF NOT EXISTS feature_store (
     id SERIAL PRIMARY KEY,
     user_id VARCHAR(50) NOT NULL,
+    transaction_id VARCHAR(100),
     feature_name VARCHAR(100) NOT NULL,
     feature_value DECIMAL(15,6),
     feature_timestamp TIMESTAMP NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Ledger of transactions whose risk adjustment has been applied, so
+-- redelivered or replayed Kafka messages are not counted twice
+CREATE TABLE IF NOT EXISTS processed_transactions (
+    transaction_id VARCHAR(100) PRIMARY KEY,
+    processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS kafka_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(100) NOT NULL,
+    message_key VARCHAR(100),
+    payload BYTEA NOT NULL,
+    content_type VARCHAR(100),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+-- Columns init.sql's tables didn't have. Alerts it stored get an ID from
+-- their row. Repeats of a transaction's alert of one type, the first kept,
+-- are moved to fraud_alerts_duplicates so the unique index below can be
+-- built without losing what analysts did with them.
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS alert_id VARCHAR(100) UNIQUE;
+ALTER TABLE feature_store ADD COLUMN IF NOT EXISTS transaction_id VARCHAR(100);
+UPDATE fraud_alerts SET alert_id = 'ALERT_' || id WHERE alert_id IS NULL;
+CREATE TABLE IF NOT EXISTS fraud_alerts_duplicates (LIKE fraud_alerts);
+ALTER TABLE fraud_alerts_duplicates ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+INSERT INTO fraud_alerts_duplicates
+SELECT a.*, CURRENT_TIMESTAMP FROM fraud_alerts a
+WHERE EXISTS (SELECT 1 FROM fraud_alerts b WHERE b.transaction_id = a.transaction_id AND b.alert_type = a.alert_type AND b.id < a.id);
+DELETE FROM fraud_alerts a USING fraud_alerts_duplicates d WHERE a.id = d.id;
+
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id);
 CREATE INDEX IF NOT EXISTS idx_transactions_timestamp ON transactions(timestamp);
 CREATE INDEX IF NOT EXISTS idx_transactions_amount ON transactions(amount);
 CREATE INDEX IF NOT EXISTS idx_fraud_alerts_status ON fraud_alerts(status);
 CREATE INDEX IF NOT EXISTS idx_feature_store_user_id ON feature_store(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feature_store_tx_feature ON feature_store(transaction_id, feature_name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fraud_alerts_tx_type ON fraud_alerts(transaction_id, alert_type);
+CREATE INDEX IF NOT EXISTS idx_kafka_outbox_pending ON kafka_outbox(created_at) WHERE delivered_at IS NULL;
 
 -- Insert sample data
 INSERT INTO users (user_id, risk_score) VALUES 
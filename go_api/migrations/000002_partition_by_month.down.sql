@@ -0,0 +1,57 @@
+-- Folds the partitions back into plain tables. Rows in partitions already
+-- dropped by retention are gone.
+
+ALTER TABLE transactions RENAME TO transactions_partitioned;
+ALTER TABLE transactions_partitioned RENAME CONSTRAINT transactions_pkey TO transactions_partitioned_pkey;
+CREATE TABLE transactions (
+    id SERIAL PRIMARY KEY,
+    transaction_id VARCHAR(100) UNIQUE NOT NULL,
+    user_id VARCHAR(50) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    merchant_id VARCHAR(100),
+    merchant_risk DECIMAL(3,2),
+    location_lat DECIMAL(10,8),
+    location_lon DECIMAL(11,8),
+    device_id VARCHAR(100),
+    ip_address INET,
+    is_fraud BOOLEAN DEFAULT FALSE,
+    fraud_score DECIMAL(5,4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+INSERT INTO transactions SELECT * FROM transactions_partitioned;
+SELECT setval(pg_get_serial_sequence('transactions', 'id'), COALESCE((SELECT MAX(id) FROM transactions), 0) + 1, false);
+DROP TABLE transactions_partitioned;
+
+ALTER TABLE fraud_alerts RENAME TO fraud_alerts_partitioned;
+ALTER TABLE fraud_alerts_partitioned RENAME CONSTRAINT fraud_alerts_pkey TO fraud_alerts_partitioned_pkey;
+CREATE TABLE fraud_alerts (
+    id SERIAL PRIMARY KEY,
+    alert_id VARCHAR(100) UNIQUE,
+    transaction_id VARCHAR(100) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    description TEXT,
+    model_version VARCHAR(20),
+    confidence_score DECIMAL(5,4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    status VARCHAR(20) DEFAULT 'OPEN'
+);
+INSERT INTO fraud_alerts SELECT * FROM fraud_alerts_partitioned;
+SELECT setval(pg_get_serial_sequence('fraud_alerts', 'id'), COALESCE((SELECT MAX(id) FROM fraud_alerts), 0) + 1, false);
+DROP TABLE fraud_alerts_partitioned;
+
+DROP INDEX IF EXISTS idx_transactions_user_id;
+DROP INDEX IF EXISTS idx_transactions_timestamp;
+DROP INDEX IF EXISTS idx_transactions_amount;
+DROP INDEX IF EXISTS idx_fraud_alerts_status;
+CREATE INDEX idx_transactions_user_id ON transactions(user_id);
+CREATE INDEX idx_transactions_timestamp ON transactions(timestamp);
+CREATE INDEX idx_transactions_amount ON transactions(amount);
+CREATE INDEX idx_fraud_alerts_status ON fraud_alerts(status);
+CREATE UNIQUE INDEX idx_fraud_alerts_tx_type ON fraud_alerts(transaction_id, alert_type);
+
+DROP FUNCTION IF EXISTS drop_partitions_before(TEXT, DATE);
+DROP FUNCTION IF EXISTS create_monthly_partition(TEXT, DATE);
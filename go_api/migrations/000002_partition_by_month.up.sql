@@ -0,0 +1,136 @@
+-- Range-partition transactions (by timestamp) and fraud_alerts (by
+-- created_at) into monthly partitions so old months can be dropped instead of
+-- deleted row by row. Unique keys on a partitioned table must include the
+-- partition column, so transaction_id is now unique per (transaction_id,
+-- timestamp) and alert_id per (alert_id, created_at). In place of
+-- idx_fraud_alerts_tx_type the processor's AlertStore.CreateOnce stores at
+-- most one alert per transaction and type: it takes a per-transaction
+-- advisory lock and skips the insert when the transaction already has one.
+-- 000026 makes alert_id unique on its own again.
+
+-- create_monthly_partition creates parent_pYYYYMM covering the month that
+-- contains month_start. It is a no-op if the partition already exists.
+CREATE OR REPLACE FUNCTION create_monthly_partition(parent TEXT, month_start DATE)
+RETURNS VOID AS $$
+DECLARE
+    lo DATE := date_trunc('month', month_start)::DATE;
+    hi DATE := (date_trunc('month', month_start) + INTERVAL '1 month')::DATE;
+BEGIN
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+                   parent || '_p' || to_char(lo, 'YYYYMM'), parent, lo, hi);
+END;
+$$ LANGUAGE plpgsql;
+
+-- drop_partitions_before drops parent's monthly partitions that end on or
+-- before cutoff and returns how many were dropped.
+CREATE OR REPLACE FUNCTION drop_partitions_before(parent TEXT, cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    part TEXT;
+    dropped INTEGER := 0;
+BEGIN
+    FOR part IN
+        SELECT c.relname FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        JOIN pg_class p ON p.oid = i.inhparent
+        WHERE p.relname = parent AND c.relname ~ ('^' || parent || '_p[0-9]{6}$')
+    LOOP
+        IF (to_date(right(part, 6), 'YYYYMM') + INTERVAL '1 month')::DATE <= cutoff THEN
+            EXECUTE format('DROP TABLE %I', part);
+            dropped := dropped + 1;
+        END IF;
+    END LOOP;
+    RETURN dropped;
+END;
+$$ LANGUAGE plpgsql;
+
+-- transactions
+ALTER TABLE transactions RENAME TO transactions_unpartitioned;
+ALTER TABLE transactions_unpartitioned RENAME CONSTRAINT transactions_pkey TO transactions_unpartitioned_pkey;
+ALTER TABLE transactions_unpartitioned RENAME CONSTRAINT transactions_transaction_id_key TO transactions_unpartitioned_transaction_id_key;
+ALTER INDEX idx_transactions_user_id RENAME TO idx_transactions_unpartitioned_user_id;
+ALTER INDEX idx_transactions_timestamp RENAME TO idx_transactions_unpartitioned_timestamp;
+ALTER INDEX idx_transactions_amount RENAME TO idx_transactions_unpartitioned_amount;
+
+CREATE TABLE transactions (
+    id BIGSERIAL,
+    transaction_id VARCHAR(100) NOT NULL,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(user_id),
+    amount DECIMAL(10,2) NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    merchant_id VARCHAR(100),
+    merchant_risk DECIMAL(3,2),
+    location_lat DECIMAL(10,8),
+    location_lon DECIMAL(11,8),
+    device_id VARCHAR(100),
+    ip_address INET,
+    is_fraud BOOLEAN DEFAULT FALSE,
+    fraud_score DECIMAL(5,4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, timestamp),
+    UNIQUE (transaction_id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+CREATE TABLE transactions_default PARTITION OF transactions DEFAULT;
+
+-- fraud_alerts
+ALTER TABLE fraud_alerts RENAME TO fraud_alerts_unpartitioned;
+ALTER TABLE fraud_alerts_unpartitioned RENAME CONSTRAINT fraud_alerts_pkey TO fraud_alerts_unpartitioned_pkey;
+ALTER TABLE fraud_alerts_unpartitioned RENAME CONSTRAINT fraud_alerts_alert_id_key TO fraud_alerts_unpartitioned_alert_id_key;
+ALTER INDEX idx_fraud_alerts_status RENAME TO idx_fraud_alerts_unpartitioned_status;
+DROP INDEX idx_fraud_alerts_tx_type;
+
+CREATE TABLE fraud_alerts (
+    id BIGSERIAL,
+    alert_id VARCHAR(100),
+    transaction_id VARCHAR(100) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    description TEXT,
+    model_version VARCHAR(20),
+    confidence_score DECIMAL(5,4),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    status VARCHAR(20) DEFAULT 'OPEN',
+    PRIMARY KEY (id, created_at),
+    UNIQUE (alert_id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE fraud_alerts_default PARTITION OF fraud_alerts DEFAULT;
+
+-- Partitions for every month with existing rows, plus the next two.
+DO $$
+DECLARE
+    m DATE;
+BEGIN
+    FOR m IN
+        SELECT DISTINCT date_trunc('month', timestamp)::DATE FROM transactions_unpartitioned
+        UNION SELECT date_trunc('month', NOW() + n * INTERVAL '1 month')::DATE FROM generate_series(0, 2) n
+    LOOP
+        PERFORM create_monthly_partition('transactions', m);
+    END LOOP;
+    FOR m IN
+        SELECT DISTINCT date_trunc('month', created_at)::DATE FROM fraud_alerts_unpartitioned WHERE created_at IS NOT NULL
+        UNION SELECT date_trunc('month', NOW() + n * INTERVAL '1 month')::DATE FROM generate_series(0, 2) n
+    LOOP
+        PERFORM create_monthly_partition('fraud_alerts', m);
+    END LOOP;
+END $$;
+
+INSERT INTO transactions SELECT * FROM transactions_unpartitioned;
+INSERT INTO fraud_alerts (id, alert_id, transaction_id, alert_type, severity, description, model_version, confidence_score, created_at, resolved_at, status)
+SELECT id, alert_id, transaction_id, alert_type, severity, description, model_version, confidence_score, COALESCE(created_at, CURRENT_TIMESTAMP), resolved_at, status
+FROM fraud_alerts_unpartitioned;
+
+SELECT setval(pg_get_serial_sequence('transactions', 'id'), COALESCE((SELECT MAX(id) FROM transactions), 0) + 1, false);
+SELECT setval(pg_get_serial_sequence('fraud_alerts', 'id'), COALESCE((SELECT MAX(id) FROM fraud_alerts), 0) + 1, false);
+
+DROP TABLE transactions_unpartitioned;
+DROP TABLE fraud_alerts_unpartitioned;
+
+CREATE INDEX idx_transactions_user_id ON transactions(user_id, timestamp);
+CREATE INDEX idx_transactions_timestamp ON transactions(timestamp);
+CREATE INDEX idx_transactions_amount ON transactions(amount);
+CREATE INDEX idx_transactions_transaction_id ON transactions(transaction_id);
+CREATE INDEX idx_fraud_alerts_status ON fraud_alerts(status, created_at);
+CREATE INDEX idx_fraud_alerts_tx_type ON fraud_alerts(transaction_id, alert_type);
@@ -0,0 +1,10 @@
+-- Merchant category codes. merchants keeps the latest code the processor saw
+-- for each merchant, so requests that omit one can fall back to it.
+CREATE TABLE IF NOT EXISTS merchants (
+    merchant_id VARCHAR(100) PRIMARY KEY,
+    mcc CHAR(4) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Propagates to every monthly partition.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS mcc CHAR(4);
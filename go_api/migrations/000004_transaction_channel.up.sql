@@ -0,0 +1,3 @@
+-- Payment rail of each transaction: card, ach, wire or p2p. Rows written
+-- before the column existed were all card payments.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS channel VARCHAR(10) NOT NULL DEFAULT 'card';
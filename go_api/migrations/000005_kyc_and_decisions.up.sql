@@ -0,0 +1,11 @@
+-- KYC outcome per user, reported by the onboarding system through
+-- PUT /users/{id}/kyc. Users without a row haven't started KYC.
+CREATE TABLE IF NOT EXISTS user_kyc (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(user_id),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'verified', 'failed')),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- APPROVE, REVIEW or DECLINE; NULL for transactions scored before decisions
+-- were recorded.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS decision VARCHAR(10);
@@ -0,0 +1,7 @@
+-- When each user made their first transaction, set by the processor. Together
+-- with users.created_at it drives the new-account rules.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS first_transaction_at TIMESTAMP;
+
+UPDATE users u SET first_transaction_at = t.first
+FROM (SELECT user_id, MIN(timestamp) AS first FROM transactions GROUP BY user_id) t
+WHERE u.user_id = t.user_id AND u.first_transaction_at IS NULL;
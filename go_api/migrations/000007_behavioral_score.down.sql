@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS session_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS behavioral_score;
@@ -0,0 +1,4 @@
+-- Behavioral biometrics from the web/mobile SDK: its 0-1 risk score for the
+-- session and the session identifier, both optional.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS behavioral_score DECIMAL(4,3);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS session_id VARCHAR(100);
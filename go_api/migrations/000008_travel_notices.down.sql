@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS country;
+DROP TABLE IF EXISTS travel_notices;
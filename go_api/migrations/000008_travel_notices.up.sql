@@ -0,0 +1,15 @@
+-- Travel windows users register through the issuer app. Transactions in one
+-- of the listed countries (ISO 3166 alpha-2) during the window are expected.
+CREATE TABLE IF NOT EXISTS travel_notices (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(user_id),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    countries TEXT[] NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_travel_notices_user ON travel_notices(user_id, ends_at);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS country CHAR(2);
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS verification_result;
+ALTER TABLE transactions DROP COLUMN IF EXISTS verification_method;
@@ -0,0 +1,5 @@
+-- Outcome of the step-up check (OTP or 3-D Secure) the auth system ran for a
+-- transaction held for review, as reported to
+-- POST /transactions/{id}/verification. Only the first report counts.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS verification_method VARCHAR(10);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS verification_result VARCHAR(10);
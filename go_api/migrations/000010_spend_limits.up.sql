@@ -0,0 +1,8 @@
+-- Per-user overrides of the configured daily and weekly spend limits. A NULL
+-- column falls back to the configured default.
+CREATE TABLE IF NOT EXISTS user_spend_limits (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(user_id),
+    daily DECIMAL(12,2) CHECK (daily >= 0),
+    weekly DECIMAL(12,2) CHECK (weekly >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
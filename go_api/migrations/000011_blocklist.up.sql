@@ -0,0 +1,11 @@
+-- Countries and IP ranges managed through /blocklist. action 'block' declines
+-- matching transactions outright, 'risk' adds to their score.
+CREATE TABLE IF NOT EXISTS blocklist (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('country', 'cidr')),
+    value VARCHAR(50) NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('block', 'risk')),
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, value)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS transaction_labels;
+DROP INDEX IF EXISTS idx_transactions_risk_factors;
+ALTER TABLE transactions DROP COLUMN IF EXISTS risk_factors;
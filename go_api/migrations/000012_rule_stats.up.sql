@@ -0,0 +1,13 @@
+-- Risk factors (rule hits) of each scored transaction, for per-rule
+-- statistics.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS risk_factors TEXT[];
+CREATE INDEX IF NOT EXISTS idx_transactions_risk_factors ON transactions USING GIN (risk_factors);
+
+-- Ground truth learned after scoring (chargebacks, analyst reviews, customer
+-- reports). transactions is partitioned, so there is no foreign key.
+CREATE TABLE IF NOT EXISTS transaction_labels (
+    transaction_id VARCHAR(100) PRIMARY KEY,
+    is_fraud BOOLEAN NOT NULL,
+    source VARCHAR(50),
+    labeled_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS export_watermarks;
+DROP INDEX IF EXISTS idx_transaction_labels_labeled_at;
+DROP INDEX IF EXISTS idx_fraud_alerts_updated_at;
+DROP TRIGGER IF EXISTS fraud_alerts_updated_at ON fraud_alerts;
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS updated_at;
+DROP INDEX IF EXISTS idx_transactions_updated_at;
+DROP TRIGGER IF EXISTS transactions_updated_at ON transactions;
+ALTER TABLE transactions DROP COLUMN IF EXISTS updated_at;
+DROP FUNCTION IF EXISTS touch_updated_at();
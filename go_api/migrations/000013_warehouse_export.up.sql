@@ -0,0 +1,37 @@
+-- Change tracking for the warehouse export. updated_at is set on insert and
+-- by a trigger on every update, so the export can pick up rows changed since
+-- its last run (rescores, verifications, alert escalations). Rows that exist
+-- before this migration all get its time and are exported on the first run.
+CREATE OR REPLACE FUNCTION touch_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Both propagate to every monthly partition.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+DROP TRIGGER IF EXISTS transactions_updated_at ON transactions;
+CREATE TRIGGER transactions_updated_at BEFORE UPDATE ON transactions FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE INDEX IF NOT EXISTS idx_transactions_updated_at ON transactions(updated_at, transaction_id);
+
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+DROP TRIGGER IF EXISTS fraud_alerts_updated_at ON fraud_alerts;
+CREATE TRIGGER fraud_alerts_updated_at BEFORE UPDATE ON fraud_alerts FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_updated_at ON fraud_alerts(updated_at, alert_id);
+
+-- Labels are upserted with labeled_at = now(), which serves the same purpose.
+CREATE INDEX IF NOT EXISTS idx_transaction_labels_labeled_at ON transaction_labels(labeled_at, transaction_id);
+
+-- How far each table has been exported to each destination: every row whose
+-- (change time, key) sorts at or before (watermark, last_key) is loaded.
+CREATE TABLE IF NOT EXISTS export_watermarks (
+    destination VARCHAR(200) NOT NULL,
+    table_name VARCHAR(50) NOT NULL,
+    watermark TIMESTAMP NOT NULL,
+    last_key VARCHAR(100) NOT NULL,
+    rows_exported BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (destination, table_name)
+);
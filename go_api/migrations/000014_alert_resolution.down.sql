@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_fraud_alerts_resolved;
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS resolution;
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS resolved_by;
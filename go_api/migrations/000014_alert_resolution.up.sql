@@ -0,0 +1,5 @@
+-- Who resolved an alert and how: FRAUD confirms it, FALSE_POSITIVE
+-- overturns it. Both propagate to every monthly partition.
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS resolved_by VARCHAR(100);
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS resolution VARCHAR(20);
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_resolved ON fraud_alerts(resolved_at, resolved_by) WHERE resolved_by IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS suppressed_by;
+DROP TABLE IF EXISTS alert_suppressions;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,33 @@
+-- Append-only record of administrative changes: who did what to which
+-- entity, and when.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    details JSONB
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id, at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at);
+
+-- Alerts matching a suppression (empty criteria match anything) between
+-- starts_at and ends_at are stored as SUPPRESSED and not published.
+-- expired_at is set once the window is over, or when it is ended early.
+CREATE TABLE IF NOT EXISTS alert_suppressions (
+    id BIGSERIAL PRIMARY KEY,
+    merchant_id VARCHAR(100),
+    alert_type VARCHAR(50),
+    severity VARCHAR(20),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expired_at TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS idx_alert_suppressions_live ON alert_suppressions(ends_at) WHERE expired_at IS NULL;
+
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS suppressed_by BIGINT;
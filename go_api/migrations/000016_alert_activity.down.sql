@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS alert_comments;
+DROP INDEX IF EXISTS idx_fraud_alerts_assigned;
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS assigned_to;
@@ -0,0 +1,14 @@
+-- Who is working an open alert, and the analysts' discussion of it.
+-- Replies point at their parent comment on the same alert.
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS assigned_to VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_assigned ON fraud_alerts(assigned_to) WHERE assigned_to IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS alert_comments (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id VARCHAR(100) NOT NULL,
+    parent_id BIGINT REFERENCES alert_comments(id),
+    author VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_alert_comments_alert ON alert_comments(alert_id, id);
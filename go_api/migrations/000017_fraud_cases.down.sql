@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_fraud_alerts_case;
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS case_id;
+DROP TABLE IF EXISTS case_entities;
+DROP TABLE IF EXISTS fraud_cases;
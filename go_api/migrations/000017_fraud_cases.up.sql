@@ -0,0 +1,25 @@
+-- Investigations grouping the alerts that share a user, device or card.
+-- case_entities records every entity a case has been linked by; the
+-- processor appends an alert to an open case whose last alert is recent
+-- enough, or opens a new one.
+CREATE TABLE IF NOT EXISTS fraud_cases (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    alert_count INTEGER NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_alert_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    closed_by VARCHAR(100)
+);
+CREATE INDEX IF NOT EXISTS idx_fraud_cases_open ON fraud_cases(last_alert_at) WHERE status = 'OPEN';
+
+CREATE TABLE IF NOT EXISTS case_entities (
+    case_id BIGINT NOT NULL REFERENCES fraud_cases(id),
+    entity_type VARCHAR(10) NOT NULL,
+    entity_value VARCHAR(100) NOT NULL,
+    PRIMARY KEY (entity_type, entity_value, case_id)
+);
+CREATE INDEX IF NOT EXISTS idx_case_entities_case ON case_entities(case_id);
+
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS case_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_case ON fraud_alerts(case_id) WHERE case_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Daily totals per merchant, user and device, recomputed by the API for
+-- recent days so late labels are counted. fraud is the label where there is
+-- one and the score's verdict otherwise.
+CREATE TABLE IF NOT EXISTS entity_daily_stats (
+    day DATE NOT NULL,
+    entity_type VARCHAR(10) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    transactions BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    fraud BIGINT NOT NULL,
+    fraud_amount DECIMAL(15,2) NOT NULL,
+    declined BIGINT NOT NULL,
+    PRIMARY KEY (entity_type, day, entity_id)
+);
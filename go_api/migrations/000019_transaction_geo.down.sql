@@ -0,0 +1,3 @@
+DELETE FROM entity_daily_stats WHERE entity_type IN ('country', 'geohash');
+ALTER TABLE transactions DROP COLUMN IF EXISTS ip_country;
+ALTER TABLE transactions DROP COLUMN IF EXISTS geohash;
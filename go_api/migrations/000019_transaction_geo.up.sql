@@ -0,0 +1,4 @@
+-- Where a transaction came from: the geohash of its location_lat/lon, and
+-- the GeoIP country of its IP. Both feed the geo rollups.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS geohash VARCHAR(12);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ip_country CHAR(2);
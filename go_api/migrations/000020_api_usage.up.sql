@@ -0,0 +1,10 @@
+-- Requests and scored transactions per API key per UTC day, added to by
+-- every API instance as it meters traffic. Feeds GET /usage and the monthly
+-- billing export.
+CREATE TABLE IF NOT EXISTS api_usage_daily (
+    day DATE NOT NULL,
+    api_key VARCHAR(100) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    transactions BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, api_key)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_audit_log_page;
+DROP INDEX IF EXISTS idx_users_page;
+DROP INDEX IF EXISTS idx_transactions_user_page;
+DROP INDEX IF EXISTS idx_transactions_page;
+DROP INDEX IF EXISTS idx_fraud_alerts_status_page;
@@ -0,0 +1,8 @@
+-- Keyset pagination of the list endpoints orders each listing by its sort
+-- key and then its ID; these indexes let Postgres walk that order instead of
+-- sorting.
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_status_page ON fraud_alerts(status, created_at, alert_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_page ON transactions(timestamp, transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_page ON transactions(user_id, timestamp, transaction_id);
+CREATE INDEX IF NOT EXISTS idx_users_page ON users(created_at, user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_page ON audit_log(at, id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transaction_events;
+ALTER TABLE transactions DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS captured_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS lifecycle_status;
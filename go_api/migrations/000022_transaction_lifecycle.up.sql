@@ -0,0 +1,18 @@
+-- Post-authorization events (capture, refund, void, reversal) and where each
+-- transaction stands after them. lifecycle_status is AUTHORIZED until the
+-- first event.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS lifecycle_status VARCHAR(20) NOT NULL DEFAULT 'AUTHORIZED';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS captured_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) UNIQUE NOT NULL,
+    transaction_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_events_tx ON transaction_events(transaction_id, id);
@@ -0,0 +1,11 @@
+-- Where a transaction stands after scoring: SCORED until an analyst takes
+-- up one of its alerts (REVIEWED), a review, step-up check or label settles
+-- it (APPROVED or DECLINED) or a chargeback comes in (CHARGED_BACK). Rows
+-- are never deleted; settling a transaction only closes it.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'SCORED';
+
+-- Labels recorded so far settle their transactions.
+UPDATE transactions t
+SET status = CASE WHEN l.is_fraud AND l.source = 'chargeback' THEN 'CHARGED_BACK' WHEN l.is_fraud THEN 'DECLINED' ELSE 'APPROVED' END
+FROM transaction_labels l
+WHERE l.transaction_id = t.transaction_id AND t.status = 'SCORED';
@@ -0,0 +1,2 @@
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS sla_breached_at;
+ALTER TABLE fraud_alerts DROP COLUMN IF EXISTS acknowledged_at;
@@ -0,0 +1,10 @@
+-- When an analyst first took up an alert, by assigning or resolving it, and
+-- when it went past its severity's acknowledgement target (alert_sla.targets)
+-- without that. Alerts already taken up count as acknowledged when they
+-- were last changed.
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMP;
+ALTER TABLE fraud_alerts ADD COLUMN IF NOT EXISTS sla_breached_at TIMESTAMP;
+
+UPDATE fraud_alerts
+SET acknowledged_at = COALESCE(resolved_at, updated_at)
+WHERE acknowledged_at IS NULL AND (resolved_by IS NOT NULL OR assigned_to IS NOT NULL);
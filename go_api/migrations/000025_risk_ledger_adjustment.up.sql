@@ -0,0 +1,4 @@
+-- The risk adjustment each ledger entry applied, so a replay with
+-- -reapply-risk can move the user's score by the difference to a corrected
+-- one. Entries from before this have none and are never re-applied.
+ALTER TABLE processed_transactions ADD COLUMN IF NOT EXISTS adjustment DOUBLE PRECISION;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS fraud_alerts_register_id ON fraud_alerts;
+DROP FUNCTION IF EXISTS register_alert_id();
+DROP TABLE IF EXISTS fraud_alert_ids;
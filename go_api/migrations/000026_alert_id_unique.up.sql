@@ -0,0 +1,32 @@
+-- Keeps alert_id unique, which the API relies on: it looks alerts up by
+-- alert_id alone. Unique keys on the partitioned fraud_alerts must include
+-- created_at, so fraud_alert_ids registers every alert's ID instead, and the
+-- trigger makes inserting an alert with an ID already taken fail as a
+-- unique violation. Both propagate to every monthly partition.
+CREATE TABLE IF NOT EXISTS fraud_alert_ids (
+    alert_id VARCHAR(100) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Alerts already stored under a taken ID get their row id appended; the
+-- first one keeps it, with the comments and audit entries.
+UPDATE fraud_alerts a SET alert_id = a.alert_id || '_' || a.id
+FROM fraud_alerts b
+WHERE a.alert_id = b.alert_id AND a.id > b.id;
+
+INSERT INTO fraud_alert_ids (alert_id, created_at)
+SELECT alert_id, created_at FROM fraud_alerts WHERE alert_id IS NOT NULL
+ON CONFLICT (alert_id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION register_alert_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.alert_id IS NOT NULL THEN
+        INSERT INTO fraud_alert_ids (alert_id, created_at) VALUES (NEW.alert_id, NEW.created_at);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS fraud_alerts_register_id ON fraud_alerts;
+CREATE TRIGGER fraud_alerts_register_id BEFORE INSERT ON fraud_alerts FOR EACH ROW EXECUTE FUNCTION register_alert_id();
@@ -0,0 +1,2 @@
+-- The keys can't be recovered from their hashes; the rows stay as they are.
+SELECT 1;
@@ -0,0 +1,7 @@
+-- api_usage_daily held whatever X-API-Key callers sent, keys included. Usage
+-- is now metered under each key's api_keys name instead, so the keys that
+-- were stored are replaced with the first 16 hex digits of their SHA-256:
+-- enough to tell the old rows apart, useless for calling the API.
+UPDATE api_usage_daily
+   SET api_key = 'sha256:' || left(encode(sha256(convert_to(api_key, 'UTF8')), 'hex'), 16)
+ WHERE api_key <> 'anonymous' AND api_key NOT LIKE 'sha256:%';
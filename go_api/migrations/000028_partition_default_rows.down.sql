@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION create_monthly_partition(parent TEXT, month_start DATE)
+RETURNS VOID AS $$
+DECLARE
+    lo DATE := date_trunc('month', month_start)::DATE;
+    hi DATE := (date_trunc('month', month_start) + INTERVAL '1 month')::DATE;
+BEGIN
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+                   parent || '_p' || to_char(lo, 'YYYYMM'), parent, lo, hi);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,44 @@
+-- create_monthly_partition used to fail when the default partition already
+-- held rows for the month, as it does once inserts outrun partition
+-- maintenance: Postgres refuses to create a partition whose range overlaps
+-- rows in the default one. Those rows are now moved into the new partition:
+-- the default partition is detached, the month's rows copied through the
+-- parent, which routes them to the new partition, and deleted from it, and
+-- the default partition attached again, all in one transaction. The
+-- fraud_alert_ids entries of moved alerts are let go of first, for the
+-- insert trigger to register them again rather than reject them as taken.
+CREATE OR REPLACE FUNCTION create_monthly_partition(parent TEXT, month_start DATE)
+RETURNS VOID AS $$
+DECLARE
+    lo DATE := date_trunc('month', month_start)::DATE;
+    hi DATE := (date_trunc('month', month_start) + INTERVAL '1 month')::DATE;
+    part TEXT := parent || '_p' || to_char(lo, 'YYYYMM');
+    def TEXT := parent || '_default';
+    keycol TEXT;
+    cols TEXT;
+    pending BOOLEAN := false;
+BEGIN
+    IF to_regclass(part) IS NOT NULL THEN RETURN; END IF;
+    SELECT a.attname INTO keycol FROM pg_partitioned_table t
+    JOIN pg_attribute a ON a.attrelid = t.partrelid AND a.attnum = t.partattrs[0]
+    WHERE t.partrelid = parent::regclass;
+    IF to_regclass(def) IS NOT NULL THEN
+        EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE %I >= %L AND %I < %L)', def, keycol, lo, keycol, hi) INTO pending;
+    END IF;
+    IF NOT pending THEN
+        EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)', part, parent, lo, hi);
+        RETURN;
+    END IF;
+
+    SELECT string_agg(quote_ident(attname), ', ' ORDER BY attnum) INTO cols FROM pg_attribute
+    WHERE attrelid = parent::regclass AND attnum > 0 AND NOT attisdropped;
+    EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', parent, def);
+    EXECUTE format('CREATE TABLE %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)', part, parent, lo, hi);
+    IF parent = 'fraud_alerts' THEN
+        EXECUTE format('DELETE FROM fraud_alert_ids r USING %I d WHERE r.alert_id = d.alert_id AND d.created_at >= %L AND d.created_at < %L', def, lo, hi);
+    END IF;
+    EXECUTE format('INSERT INTO %I (%s) SELECT %s FROM %I WHERE %I >= %L AND %I < %L', parent, cols, cols, def, keycol, lo, keycol, hi);
+    EXECUTE format('DELETE FROM %I WHERE %I >= %L AND %I < %L', def, keycol, lo, keycol, hi);
+    EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I DEFAULT', parent, def);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,144 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "math"
+    "math/rand"
+    "net/http"
+    "sort"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+)
+
+// mirrorHeader marks a mirrored copy. A canary that is itself configured to
+// mirror doesn't pass such requests on.
+const mirrorHeader = "X-Mirrored-Request"
+
+type mirrorJob struct {
+    body   []byte
+    tenant string
+    live   TransactionResponse
+}
+
+var (
+    mirrorQueue  chan mirrorJob
+    mirrorClient = &http.Client{}
+
+    mirrorRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_mirror_requests_total",
+        Help: "Requests copied to the canary, by outcome (match, diff, error, dropped).",
+    }, []string{"outcome"})
+    mirrorDiffs = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_mirror_diffs_total",
+        Help: "Canary answers that differed from the live one, by response field.",
+    }, []string{"field"})
+    mirrorScoreDelta = promauto.NewHistogram(prometheus.HistogramOpts{
+        Name:    "fraud_api_mirror_score_delta",
+        Help:    "Absolute difference between the canary's and the live fraud score.",
+        Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.2, 0.3, 0.5, 1},
+    })
+)
+
+// mirror queues a copy of req for the canary, for mirror.percent of the
+// requests while mirror.url is set. live is the answer already given.
+func mirror(r *http.Request, req TransactionRequest, live TransactionResponse) {
+    cfg := config.Get().Mirror
+    if cfg.URL == "" || cfg.Percent <= 0 || r.Header.Get(mirrorHeader) != "" { return }
+    if rand.Float64()*100 >= cfg.Percent { return }
+    body, err := json.Marshal(req)
+    if err != nil { return }
+    select {
+    case mirrorQueue <- mirrorJob{body: body, tenant: r.Header.Get("X-Tenant-ID"), live: live}:
+    default:
+        mirrorRequests.WithLabelValues("dropped").Inc()
+    }
+}
+
+// runMirrorWorkers sends the queued copies to the canary.
+func runMirrorWorkers() {
+    cfg := config.Get().Mirror
+    mirrorQueue = make(chan mirrorJob, cfg.Queue)
+    for i := 0; i < cfg.Workers; i++ {
+        go func() {
+            for job := range mirrorQueue {
+                if err := sendMirror(job); err != nil {
+                    mirrorRequests.WithLabelValues("error").Inc()
+                    log.Printf("mirror: %v", err)
+                }
+            }
+        }()
+    }
+}
+
+// sendMirror posts job to the canary and logs where its answer differs.
+func sendMirror(job mirrorJob) error {
+    cfg := config.Get().Mirror
+    // Mirroring may have been turned off since the copy was queued.
+    if cfg.URL == "" { return nil }
+    mctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+    defer cancel()
+    req, err := http.NewRequestWithContext(mctx, http.MethodPost, strings.TrimSuffix(cfg.URL, "/")+cfg.Path, bytes.NewReader(job.body))
+    if err != nil { return err }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(mirrorHeader, "1")
+    if job.tenant != "" { req.Header.Set("X-Tenant-ID", job.tenant) }
+    resp, err := mirrorClient.Do(req)
+    if err != nil { return err }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return fmt.Errorf("canary answered %s: %s", resp.Status, strings.TrimSpace(string(b)))
+    }
+    var canary TransactionResponse
+    if err := json.NewDecoder(resp.Body).Decode(&canary); err != nil { return fmt.Errorf("canary response: %v", err) }
+
+    mirrorScoreDelta.Observe(math.Abs(canary.FraudScore - job.live.FraudScore))
+    diffs := mirrorDiff(job.live, canary)
+    if len(diffs) == 0 { mirrorRequests.WithLabelValues("match").Inc(); return nil }
+    mirrorRequests.WithLabelValues("diff").Inc()
+    var desc []string
+    for _, d := range diffs {
+        mirrorDiffs.WithLabelValues(d.field).Inc()
+        desc = append(desc, fmt.Sprintf("%s live=%v canary=%v", d.field, d.live, d.canary))
+    }
+    log.Printf("mirror: canary differs on transaction %s: %s", job.live.TransactionID, strings.Join(desc, ", "))
+    return nil
+}
+
+type mirrorField struct {
+    field        string
+    live, canary interface{}
+}
+
+// mirrorDiff lists the fields of the verdict where canary differs from
+// live. IDs and timings are expected to differ and aren't compared; scores
+// are compared to three decimals.
+func mirrorDiff(live, canary TransactionResponse) []mirrorField {
+    var out []mirrorField
+    add := func(field string, l, c interface{}) { out = append(out, mirrorField{field, l, c}) }
+    if live.IsFraud != canary.IsFraud { add("is_fraud", live.IsFraud, canary.IsFraud) }
+    if math.Abs(live.FraudScore-canary.FraudScore) >= 0.001 { add("fraud_score", live.FraudScore, canary.FraudScore) }
+    if live.Decision != canary.Decision { add("decision", live.Decision, canary.Decision) }
+    if live.DeclineReason != canary.DeclineReason { add("decline_reason", live.DeclineReason, canary.DeclineReason) }
+    if l, c := sortedCopy(live.RiskFactors), sortedCopy(canary.RiskFactors); strings.Join(l, ",") != strings.Join(c, ",") {
+        add("risk_factors", l, c)
+    }
+    if live.PartialEvaluation != canary.PartialEvaluation { add("partial_evaluation", live.PartialEvaluation, canary.PartialEvaluation) }
+    if live.ScoringTier != canary.ScoringTier { add("scoring_tier", live.ScoringTier, canary.ScoringTier) }
+    return out
+}
+
+func sortedCopy(s []string) []string {
+    out := append([]string(nil), s...)
+    sort.Strings(out)
+    return out
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log"
+    "slices"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    healthpb "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/status"
+
+    pb "example.com/fraud/go_api/internal/pb/protos"
+    "example.com/fraud/internal/config"
+)
+
+// errNoMLEndpoint is what an ML call fails with while no endpoint passes
+// its health checks.
+var errNoMLEndpoint = errors.New("no healthy ML endpoint")
+
+var (
+    mlEndpointHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "fraud_api_ml_endpoint_healthy",
+        Help: "1 while the ML endpoint passes its health checks and takes calls.",
+    }, []string{"addr"})
+    mlFailovers = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_api_ml_failovers_total",
+        Help: "ML calls retried on another endpoint after the first was unavailable.",
+    })
+)
+
+// mlEndpoint is one instance of the ML service, with a connection kept open
+// for its calls and health checks.
+type mlEndpoint struct {
+    addr    string
+    conn    *grpc.ClientConn
+    client  pb.FraudDetectionServiceClient
+    health  healthpb.HealthClient
+    healthy atomic.Bool
+    // failures counts consecutive failed checks; only the poller touches it.
+    failures int
+}
+
+type mlPool struct {
+    addrs     []string
+    endpoints []*mlEndpoint
+    next      atomic.Uint64
+}
+
+var (
+    mlPoolMu      sync.Mutex
+    currentMLPool atomic.Pointer[mlPool]
+)
+
+// mlAddrs is ml_endpoints.addrs, or api.ml_grpc_addr when that is empty.
+func mlAddrs() []string {
+    cfg := config.Get()
+    if len(cfg.MLEndpoints.Addrs) > 0 { return cfg.MLEndpoints.Addrs }
+    return []string{cfg.API.MLGRPCAddr}
+}
+
+// initMLEndpoints connects to the ML endpoints and polls their health every
+// ml_endpoints.health_interval. Connections are made lazily, as for
+// enrichment plugins, so endpoints that aren't up yet only fail their
+// checks until they are. A changed address list is picked up by the next
+// poll.
+func initMLEndpoints() error {
+    if _, err := refreshMLPool(); err != nil { return err }
+    go func() {
+        for {
+            p, err := refreshMLPool()
+            if err != nil { log.Printf("ML endpoints not updated: %v", err) }
+            var wg sync.WaitGroup
+            for _, e := range p.endpoints {
+                wg.Add(1)
+                go func() { defer wg.Done(); e.check() }()
+            }
+            wg.Wait()
+            time.Sleep(config.Get().MLEndpoints.HealthInterval)
+        }
+    }()
+    return nil
+}
+
+// refreshMLPool swaps in a pool for the configured addresses when they
+// changed, closing the old pool's connections, and returns the current one.
+func refreshMLPool() (*mlPool, error) {
+    mlPoolMu.Lock()
+    defer mlPoolMu.Unlock()
+    addrs := mlAddrs()
+    old := currentMLPool.Load()
+    if old != nil && slices.Equal(old.addrs, addrs) { return old, nil }
+    p := &mlPool{addrs: slices.Clone(addrs)}
+    for _, addr := range addrs {
+        cc, err := grpc.Dial(addr, grpc.WithTransportCredentials(mlCreds), grpc.WithUnaryInterceptor(faultInterceptor))
+        if err != nil {
+            for _, e := range p.endpoints { e.conn.Close() }
+            if old == nil { return nil, err }
+            return old, err
+        }
+        e := &mlEndpoint{addr: addr, conn: cc, client: pb.NewFraudDetectionServiceClient(cc), health: healthpb.NewHealthClient(cc)}
+        // Healthy until a check says otherwise, so calls aren't refused
+        // before the first poll.
+        e.healthy.Store(true)
+        mlEndpointHealthy.WithLabelValues(addr).Set(1)
+        p.endpoints = append(p.endpoints, e)
+    }
+    currentMLPool.Store(p)
+    if old != nil {
+        for _, e := range old.endpoints {
+            if !slices.Contains(addrs, e.addr) { mlEndpointHealthy.DeleteLabelValues(e.addr) }
+            e.conn.Close()
+        }
+    }
+    log.Printf("ML endpoints: %v, %s", addrs, config.Get().MLEndpoints.Policy)
+    return p, nil
+}
+
+// check runs one grpc.health.v1 Check. An endpoint is marked down after
+// ml_endpoints.health_failures failed checks in a row and up again after
+// one that passes. A service without the health service counts as up as
+// long as it answers.
+func (e *mlEndpoint) check() {
+    cfg := config.Get().MLEndpoints
+    cctx, cancel := context.WithTimeout(ctx, cfg.HealthTimeout)
+    resp, err := e.health.Check(cctx, &healthpb.HealthCheckRequest{})
+    cancel()
+    up := err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+    if status.Code(err) == codes.Unimplemented { up = true }
+    if up {
+        e.failures = 0
+        e.setHealthy(true, nil)
+        return
+    }
+    e.failures++
+    if err == nil { err = errors.New(resp.GetStatus().String()) }
+    if e.failures >= cfg.HealthFailures { e.setHealthy(false, err) }
+}
+
+func (e *mlEndpoint) setHealthy(up bool, err error) {
+    if e.healthy.Swap(up) == up { return }
+    if up {
+        mlEndpointHealthy.WithLabelValues(e.addr).Set(1)
+        log.Printf("ML endpoint %s healthy again", e.addr)
+        return
+    }
+    mlEndpointHealthy.WithLabelValues(e.addr).Set(0)
+    log.Printf("ML endpoint %s unhealthy, taking it out of rotation: %v", e.addr, err)
+}
+
+// pick is the healthy endpoint for the next call, other than skip: the
+// first in configured order with pick_first, the next in turn with
+// round_robin. It is nil when there is none.
+func (p *mlPool) pick(skip *mlEndpoint) *mlEndpoint {
+    var up []*mlEndpoint
+    for _, e := range p.endpoints {
+        if e != skip && e.healthy.Load() { up = append(up, e) }
+    }
+    if len(up) == 0 { return nil }
+    if config.Get().MLEndpoints.Policy == "round_robin" { return up[(p.next.Add(1)-1)%uint64(len(up))] }
+    return up[0]
+}
+
+// healthyMLEndpoints reports each endpoint's health for /health.
+func healthyMLEndpoints() map[string]bool {
+    out := map[string]bool{}
+    if p := currentMLPool.Load(); p != nil {
+        for _, e := range p.endpoints { out[e.addr] = e.healthy.Load() }
+    }
+    return out
+}
+
+// mlScore calls GetFraudScore on a healthy endpoint. An endpoint that turns
+// out to be unavailable is taken out of rotation at once, without waiting
+// for its health checks, and the call is made once more on another.
+func mlScore(rctx context.Context, req *pb.TransactionRequest) (*pb.FraudScoreResponse, error) {
+    p := currentMLPool.Load()
+    if p == nil { return nil, errNoMLEndpoint }
+    e := p.pick(nil)
+    if e == nil { return nil, errNoMLEndpoint }
+    resp, err := e.score(rctx, req)
+    if status.Code(err) != codes.Unavailable || rctx.Err() != nil { return resp, err }
+    e.setHealthy(false, err)
+    next := p.pick(e)
+    if next == nil { return nil, err }
+    mlFailovers.Inc()
+    return next.score(rctx, req)
+}
+
+func (e *mlEndpoint) score(rctx context.Context, req *pb.TransactionRequest) (*pb.FraudScoreResponse, error) {
+    cctx, cancel := context.WithTimeout(rctx, 2*time.Second)
+    defer cancel()
+    return e.client.GetFraudScore(cctx, req)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "log"
+
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/credentials/insecure"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// mlCreds secure connections to the ML service per ml_tls.
+var mlCreds = insecure.NewCredentials()
+
+// initMLTLS sets mlCreds and, unless ml_tls.mode is insecure, checks each ML
+// endpoint proves the configured identity. A service that presents the
+// wrong certificate stops startup; one that isn't reachable yet is checked
+// on every connection instead.
+func initMLTLS() error {
+    cfg, err := conn.MLTLS()
+    if err != nil || cfg == nil { return err }
+    mlCreds = credentials.NewTLS(cfg)
+    for _, addr := range mlAddrs() {
+        err = conn.CheckMLIdentity(ctx, addr, cfg)
+        if errors.Is(err, conn.ErrIdentity) { return fmt.Errorf("ML service at %s: %w", addr, err) }
+        if err != nil {
+            log.Printf("ML service at %s not reachable to check its identity: %v", addr, err)
+            continue
+        }
+        log.Printf("ML service at %s verified over %s", addr, config.Get().MLTLS.Mode)
+    }
+    return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "log"
+    "strconv"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+)
+
+// runPartitionMaintenance keeps partitions.months_ahead future monthly
+// partitions in place, so inserts never fall into the default partition, and
+// drops partitions older than partitions.retention_months (0 keeps
+// everything).
+func runPartitionMaintenance(interval time.Duration) {
+    for {
+        pc := config.Get().Partitions
+        if err := maintainPartitions(pc.MonthsAhead, pc.RetentionMonths); err != nil { log.Printf("partition maintenance failed: %v", err) }
+        time.Sleep(interval)
+    }
+}
+
+func maintainPartitions(ahead, retention int) error {
+    qctx, cancel := context.WithTimeout(ctx, time.Minute)
+    defer cancel()
+    now := time.Now().UTC()
+    month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+    for _, table := range store.PartitionedTables {
+        for i := 0; i <= ahead; i++ {
+            if err := partitionStore.CreateMonthlyPartition(qctx, table, month.AddDate(0, i, 0)); err != nil { return err }
+        }
+        if retention <= 0 { continue }
+        dropped, err := partitionStore.DropPartitionsBefore(qctx, table, month.AddDate(0, -retention, 0))
+        if err != nil { return err }
+        if dropped > 0 { log.Printf("retention: dropped %d %s partitions older than %d months", dropped, table, retention) }
+    }
+    return nil
+}
+
+// newTransactionID names a transaction scored at now: the UnixNano time,
+// which lets lookups find its partition, then a dash and 16 random hex
+// digits so instances scoring in the same nanosecond don't collide.
+func newTransactionID(now time.Time) string {
+    b := make([]byte, 8)
+    rand.Read(b)
+    return strconv.FormatInt(now.UnixNano(), 10) + "-" + hex.EncodeToString(b)
+}
+
+// transactionTimeWindow bounds the partitions a lookup by transaction id has
+// to touch. Ids start with the UnixNano time the API scored the transaction,
+// which is also its timestamp, optionally followed by a dash and a random
+// suffix (ids from before it was added have none); ids not in that form get
+// the full range.
+func transactionTimeWindow(txID string) (time.Time, time.Time) {
+    prefix, _, _ := strings.Cut(txID, "-")
+    nanos, err := strconv.ParseInt(prefix, 10, 64)
+    if err != nil || nanos <= 0 { return time.Time{}, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC) }
+    t := time.Unix(0, nanos).UTC()
+    return t.Add(-time.Hour), t.Add(time.Hour)
+}
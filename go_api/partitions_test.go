@@ -0,0 +1,37 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+// Transaction ids carry the time they were scored, so a lookup by id only
+// touches the partitions around it.
+func TestTransactionTimeWindow(t *testing.T) {
+    scored := time.Date(2026, 3, 31, 23, 30, 0, 0, time.UTC)
+    full := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+    cases := []struct {
+        id       string
+        from, to time.Time
+    }{
+        {newTransactionID(scored), scored.Add(-time.Hour), scored.Add(time.Hour)},
+        {"1774999800000000000", scored.Add(-time.Hour), scored.Add(time.Hour)},
+        {"tx-1", time.Time{}, full},
+        {"-1", time.Time{}, full},
+        {"", time.Time{}, full},
+    }
+    for _, c := range cases {
+        from, to := transactionTimeWindow(c.id)
+        if !from.Equal(c.from) || !to.Equal(c.to) { t.Errorf("%q: window %v-%v, want %v-%v", c.id, from, to, c.from, c.to) }
+    }
+}
+
+func TestNewTransactionIDUnique(t *testing.T) {
+    now := time.Now()
+    seen := map[string]bool{}
+    for i := 0; i < 1000; i++ {
+        id := newTransactionID(now)
+        if seen[id] { t.Fatalf("%s issued twice for the same instant", id) }
+        seen[id] = true
+    }
+}
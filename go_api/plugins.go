@@ -0,0 +1,71 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials/insecure"
+
+    "example.com/fraud/go_api/internal/pb/enricherpb"
+    "example.com/fraud/internal/config"
+)
+
+var enricherErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "fraud_api_enricher_errors_total",
+    Help: "Failed calls to enrichment plugins, by stage.",
+}, []string{"stage"})
+
+// pluginEnricher is an enrichment stage served by an external gRPC plugin
+// (protos/enricher.proto). A failed or slow call contributes nothing.
+type pluginEnricher struct {
+    name   string
+    client enricherpb.EnricherClient
+}
+
+func (p pluginEnricher) Name() string { return p.name }
+
+func (p pluginEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    resp, err := p.client.Enrich(ctx, &enricherpb.EnrichRequest{
+        UserId:     req.UserID,
+        Amount:     req.Amount,
+        MerchantId: req.MerchantID,
+        Channel:    req.Channel,
+        Mcc:        req.MCC,
+        DeviceId:   req.DeviceID,
+        IpAddress:  req.IPAddress,
+        Email:      req.Email,
+        Phone:      req.Phone,
+        Attributes: req.Attributes,
+    })
+    if err != nil {
+        enricherErrors.WithLabelValues(p.name).Inc()
+        return
+    }
+    if f.Plugin == nil { f.Plugin = map[string]float64{} }
+    for k, v := range resp.GetFeatures() { f.Plugin[p.name+"_"+k] = v }
+    f.PluginRiskFactors = append(f.PluginRiskFactors, resp.GetRiskFactors()...)
+    f.ScoreAdjustment += resp.GetScoreAdjustment()
+}
+
+// initPlugins adds a stage for every enrichment.plugins entry, after the
+// built-in stages and in the configured order. Connections are made lazily,
+// so a plugin that isn't up yet only costs its stage until it is.
+func initPlugins() error {
+    for _, entry := range config.Get().Enrichment.Plugins {
+        name, addr, _ := strings.Cut(entry, "=")
+        name, addr = strings.TrimSpace(name), strings.TrimSpace(addr)
+        for _, e := range enrichers {
+            if e.Name() == name { return fmt.Errorf("enrichment plugin %q: stage name already in use", name) }
+        }
+        cc, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithUnaryInterceptor(faultInterceptor))
+        if err != nil { return fmt.Errorf("enrichment plugin %q: %w", name, err) }
+        enrichers = append(enrichers, pluginEnricher{name: name, client: enricherpb.NewEnricherClient(cc)})
+        log.Printf("enrichment plugin %s at %s", name, addr)
+    }
+    return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "net/http"
+    "regexp"
+    "strconv"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+)
+
+// Precomputed features are kept per user in the Redis hash
+// user_features:<id>, one field per feature.
+const precomputedPrefix = "user_features:"
+
+var precomputedName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+var precomputedWritten = promauto.NewCounter(prometheus.CounterOpts{
+    Name: "fraud_api_precomputed_features_written_total",
+    Help: "Users whose precomputed features were written through /features/batch.",
+})
+
+type precomputedUser struct {
+    UserID string             `json:"user_id"`
+    Values map[string]float64 `json:"values"`
+}
+
+// featuresBatchHandler serves POST /features/batch, which the offline
+// pipeline pushes per-user aggregates to: {"features": [{"user_id": ...,
+// "values": {"spend_30d": 1250.0, "device_count_30d": 3}}, ...]}. Values are
+// merged into what the user already has, and all of the user's values
+// expire precomputed.ttl after the last write. The batch is checked as a
+// whole before anything is written.
+func featuresBatchHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    var body struct {
+        Features []precomputedUser `json:"features"`
+    }
+    if !decodeBody(w, r, &body, batchBodyLimit()) { return }
+    cfg := config.Get().Precomputed
+    if len(body.Features) > cfg.MaxBatch {
+        http.Error(w, fmt.Sprintf("at most %d users per batch", cfg.MaxBatch), http.StatusRequestEntityTooLarge)
+        return
+    }
+    for i, u := range body.Features {
+        if err := validatePrecomputed(u); err != nil { http.Error(w, fmt.Sprintf("features[%d]: %v", i, err), http.StatusBadRequest); return }
+    }
+    if !cacheUp() { http.Error(w, "Redis unavailable", http.StatusServiceUnavailable); return }
+    pipe := rdb.Pipeline()
+    for _, u := range body.Features {
+        key := precomputedPrefix + u.UserID
+        fields := make(map[string]interface{}, len(u.Values))
+        for k, v := range u.Values { fields[k] = v }
+        pipe.HSet(r.Context(), key, fields)
+        pipe.Expire(r.Context(), key, cfg.TTL)
+    }
+    if _, err := pipe.Exec(r.Context()); err != nil {
+        noteRedisErr(err)
+        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+        return
+    }
+    precomputedWritten.Add(float64(len(body.Features)))
+    writeJSON(w, http.StatusOK, map[string]interface{}{"written": len(body.Features)})
+}
+
+func validatePrecomputed(u precomputedUser) error {
+    if u.UserID == "" { return fmt.Errorf("user_id is required") }
+    if len(u.Values) == 0 { return fmt.Errorf("values is required") }
+    for k, v := range u.Values {
+        if !precomputedName.MatchString(k) { return fmt.Errorf("feature name %q must be lower-case letters, digits and underscores", k) }
+        if math.IsNaN(v) || math.IsInf(v, 0) { return fmt.Errorf("%s is not a finite number", k) }
+    }
+    return nil
+}
+
+// precomputedEnricher adds the user's precomputed features, which the ML
+// service gets as precomputed_<name>. A user without any, or while Redis is
+// unavailable, gets none.
+type precomputedEnricher struct{}
+
+func (precomputedEnricher) Name() string { return "precomputed" }
+
+func (precomputedEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    if !cacheUp() { return }
+    vals, err := rdb.HGetAll(ctx, precomputedPrefix+req.UserID).Result()
+    if err != nil { noteRedisErr(err); return }
+    for k, s := range vals {
+        v, err := strconv.ParseFloat(s, 64)
+        if err != nil { continue }
+        if f.Precomputed == nil { f.Precomputed = map[string]float64{} }
+        f.Precomputed[k] = v
+    }
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "example.com/fraud/internal/config"
+)
+
+// Scoring classes. Real-time requests have a caller waiting on the answer;
+// bulk work is batch items, ISO 20022 messages and webhook imports.
+const (
+    classRealtime = "realtime"
+    classBulk     = "bulk"
+)
+
+// limitWindow is how often the adaptive limit is reconsidered.
+const limitWindow = time.Second
+
+// errShed rejects a real-time request without queueing it: as many are
+// already waiting as the current limit lets run at once.
+var errShed = errors.New("scoring queue full")
+
+// scoringSlots bounds concurrent scoring. A free slot goes to the oldest
+// waiting real-time request first; bulk work gets one only while no
+// real-time request waits and it holds fewer than its share, so a large
+// import can't starve /transactions/process.
+//
+// With scoring.adaptive the limit follows latency, AIMD style: each
+// window whose p90 scoring time is over scoring.latency_target cuts it by
+// a fifth, each window under the target adds a tenth back, between
+// scoring.min_slots and scoring.slots. Queued requests would only wait
+// longer behind a slow Postgres or ML service, so the excess is turned
+// away early with 503 instead.
+type scoringSlots struct {
+    mu      sync.Mutex
+    running map[string]int
+    waiting map[string][]chan struct{}
+
+    adaptive    int // 0 until the first cut
+    samples     []time.Duration
+    windowStart time.Time
+}
+
+var scoring = &scoringSlots{running: map[string]int{}, waiting: map[string][]chan struct{}{}}
+
+// acquire waits for a slot of class until ctx is done and returns the
+// function that gives it back.
+func (s *scoringSlots) acquire(ctx context.Context, class string) (func(), error) {
+    start := time.Now()
+    s.mu.Lock()
+    if len(s.waiting[class]) == 0 && s.free(class) {
+        s.take(class)
+        s.mu.Unlock()
+        scoringQueueWait.WithLabelValues(class).Observe(0)
+        return s.releaser(class), nil
+    }
+    if class == classRealtime && len(s.waiting[class]) >= s.limit() {
+        s.mu.Unlock()
+        scoringRejected.WithLabelValues(class, "shed").Inc()
+        return nil, errShed
+    }
+    granted := make(chan struct{}, 1)
+    s.waiting[class] = append(s.waiting[class], granted)
+    scoringQueued.WithLabelValues(class).Inc()
+    s.mu.Unlock()
+
+    select {
+    case <-granted:
+        scoringQueueWait.WithLabelValues(class).Observe(time.Since(start).Seconds())
+        return s.releaser(class), nil
+    case <-ctx.Done():
+    }
+    s.mu.Lock()
+    queue := s.waiting[class]
+    for i, ch := range queue {
+        if ch == granted {
+            s.waiting[class] = append(queue[:i:i], queue[i+1:]...)
+            scoringQueued.WithLabelValues(class).Dec()
+            granted = nil
+            break
+        }
+    }
+    s.mu.Unlock()
+    // The slot was handed over just as ctx ended; pass it on.
+    if granted != nil { s.release(class, 0) }
+    scoringRejected.WithLabelValues(class, "timeout").Inc()
+    return nil, ctx.Err()
+}
+
+// releaser gives the slot back and reports how long it was held.
+func (s *scoringSlots) releaser(class string) func() {
+    start := time.Now()
+    return func() { s.release(class, time.Since(start)) }
+}
+
+func (s *scoringSlots) release(class string, held time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.running[class]--
+    scoringInflight.WithLabelValues(class).Dec()
+    if held > 0 { s.observe(held) }
+    s.dispatch()
+}
+
+// limit is the number of slots in use now. Called with mu held.
+func (s *scoringSlots) limit() int {
+    cfg := config.Get().Scoring
+    if !cfg.Adaptive || s.adaptive == 0 || s.adaptive >= cfg.Slots { return cfg.Slots }
+    if s.adaptive < cfg.MinSlots { return cfg.MinSlots }
+    return s.adaptive
+}
+
+// free reports whether class may start now, ignoring who is waiting. Called
+// with mu held.
+func (s *scoringSlots) free(class string) bool {
+    cfg := config.Get().Scoring
+    limit := s.limit()
+    if s.running[classRealtime]+s.running[classBulk] >= limit { return false }
+    if class == classRealtime { return true }
+    bulk := cfg.BulkSlots * limit / cfg.Slots
+    if bulk < 1 { bulk = 1 }
+    return len(s.waiting[classRealtime]) == 0 && s.running[classBulk] < bulk
+}
+
+func (s *scoringSlots) take(class string) {
+    s.running[class]++
+    scoringInflight.WithLabelValues(class).Inc()
+}
+
+// dispatch hands free slots to waiters, real-time first. Called with mu
+// held.
+func (s *scoringSlots) dispatch() {
+    for _, class := range []string{classRealtime, classBulk} {
+        for len(s.waiting[class]) > 0 && s.free(class) {
+            granted := s.waiting[class][0]
+            s.waiting[class] = s.waiting[class][1:]
+            scoringQueued.WithLabelValues(class).Dec()
+            s.take(class)
+            granted <- struct{}{}
+        }
+    }
+}
+
+// observe records one scoring time and, once per limitWindow, moves the
+// adaptive limit. Called with mu held.
+func (s *scoringSlots) observe(d time.Duration) {
+    cfg := config.Get().Scoring
+    s.samples = append(s.samples, d)
+    now := time.Now()
+    if s.windowStart.IsZero() { s.windowStart = now }
+    if now.Sub(s.windowStart) < limitWindow { return }
+    sort.Slice(s.samples, func(i, j int) bool { return s.samples[i] < s.samples[j] })
+    p90 := s.samples[len(s.samples)*9/10]
+    s.samples, s.windowStart = s.samples[:0], now
+    if !cfg.Adaptive { s.adaptive = 0; scoringLimit.Set(float64(cfg.Slots)); return }
+
+    limit := s.limit()
+    if p90 > cfg.LatencyTarget {
+        limit = limit * 4 / 5
+        if limit < cfg.MinSlots { limit = cfg.MinSlots }
+    } else {
+        limit += max(1, limit/10)
+        if limit > cfg.Slots { limit = cfg.Slots }
+    }
+    s.adaptive = limit
+    scoringLimit.Set(float64(limit))
+}
+
+// acquireRealtime waits at most scoring.queue_timeout for a real-time slot.
+func acquireRealtime(rctx context.Context) (func(), error) {
+    ctx, cancel := context.WithTimeout(rctx, config.Get().Scoring.QueueTimeout)
+    defer cancel()
+    return scoring.acquire(ctx, classRealtime)
+}
+
+// scoreBulk runs processTransaction in a bulk slot.
+func scoreBulk(rctx context.Context, req TransactionRequest, tenant string) (TransactionResponse, error) {
+    release, err := scoring.acquire(rctx, classBulk)
+    if err != nil { return TransactionResponse{}, err }
+    defer release()
+    return processTransaction(rctx, req, tenant, time.Now())
+}
+
+// scoringBusy answers a real-time request that found no slot in time.
+func scoringBusy(w http.ResponseWriter) {
+    w.Header().Set("Retry-After", "1")
+    http.Error(w, "scoring capacity exhausted, retry shortly", http.StatusServiceUnavailable)
+}
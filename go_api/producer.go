@@ -0,0 +1,83 @@
+package main
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+
+    "example.com/fraud/go_api/internal/outbox"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// newAsyncWriter returns a batching writer for topic. WriteMessages returns
+// as soon as the message is buffered; batches are flushed when they reach
+// kafka.batch_size messages or kafka.linger elapses, and the outcome is
+// reported to onDelivery.
+func newAsyncWriter(brokers []string, topic string) *kafka.Writer {
+    w := &kafka.Writer{
+        Addr:         kafka.TCP(brokers...),
+        Topic:        topic,
+        Balancer:     &kafka.Hash{},
+        Async:        true,
+        BatchSize:    config.Get().Kafka.BatchSize,
+        BatchTimeout: config.Get().Kafka.Linger,
+        Transport:    conn.KafkaTransport(),
+    }
+    w.Completion = func(messages []kafka.Message, err error) { onDelivery(topic, messages, err) }
+    return w
+}
+
+// onDelivery is the writer's completion callback. Failed batches are spilled
+// to the outbox table so no scored transaction is lost while Kafka is down.
+func onDelivery(topic string, messages []kafka.Message, err error) {
+    if err == nil {
+        kafkaDelivered.WithLabelValues(topic).Add(float64(len(messages)))
+        return
+    }
+    kafkaDeliveryFailures.WithLabelValues(topic).Add(float64(len(messages)))
+    log.Printf("kafka delivery to %s failed for %d messages: %v", topic, len(messages), err)
+    for _, m := range messages {
+        if serr := spillToOutbox(topic, m.Key, m.Value, conn.Header(m, "content-type"), err); serr != nil {
+            log.Printf("outbox spill failed: %v", serr)
+            continue
+        }
+        outboxSpilled.WithLabelValues(topic).Inc()
+    }
+}
+
+// runOutboxRelay republishes spilled events every api.outbox_relay_interval
+// once the event bus accepts writes again, so the processor eventually sees
+// every scored transaction. Replayed events can arrive after newer ones for
+// the same user; the processor applies each transaction_id once either way.
+func runOutboxRelay() {
+    pub, err := outbox.NewPublisher(rdb)
+    if err != nil { log.Printf("outbox relay disabled: %v", err); return }
+    defer pub.Close()
+    for {
+        time.Sleep(config.Get().API.OutboxRelayInterval)
+        relayOutbox(pub)
+    }
+}
+
+func relayOutbox(pub *outbox.Publisher) {
+    const batch = 500
+    rctx, cancel := context.WithTimeout(ctx, time.Minute)
+    defer cancel()
+    if err := pub.Ready(rctx); err != nil { return }
+    for {
+        n, err := outbox.Drain(rctx, outboxStore, pub, "", batch)
+        outboxReplayed.Add(float64(n))
+        if n > 0 { log.Printf("outbox relay: delivered %d messages", n) }
+        if err != nil { log.Printf("outbox relay: %v", err); return }
+        if n < batch { return }
+    }
+}
+
+func spillToOutbox(topic string, key, value []byte, contentType string, cause error) error {
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    return outboxStore.Spill(qctx, topic, key, value, contentType, cause.Error())
+}
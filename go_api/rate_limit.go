@@ -0,0 +1,99 @@
+package main
+
+import (
+    "net"
+    "net/http"
+    "net/netip"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+)
+
+var rateLimited = promauto.NewCounter(prometheus.CounterOpts{
+    Name: "fraud_api_rate_limited_total",
+    Help: "Requests refused with 429 because their client was over rate_limit.requests.",
+})
+
+// withRateLimit counts each client's requests in fixed windows of
+// rate_limit.window and answers 429 beyond rate_limit.requests. Responses
+// carry X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// (seconds until the window ends) so clients can pace themselves. /health
+// and /metrics are not counted, and while Redis is unavailable nothing is
+// limited.
+func withRateLimit(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        cfg := config.Get().RateLimit
+        if cfg.Requests <= 0 || r.URL.Path == "/health" || r.URL.Path == "/metrics" || !cacheUp() { next.ServeHTTP(w, r); return }
+        now := time.Now()
+        window := now.Truncate(cfg.Window)
+        reset := window.Add(cfg.Window)
+        key := "rate_limit:" + rateLimitClient(r) + ":" + strconv.FormatInt(window.Unix(), 10)
+        pipe := rdb.TxPipeline()
+        incr := pipe.Incr(r.Context(), key)
+        pipe.ExpireAt(r.Context(), key, reset.Add(time.Second))
+        if _, err := pipe.Exec(r.Context()); err != nil { noteRedisErr(err); next.ServeHTTP(w, r); return }
+
+        h := w.Header()
+        resetIn := strconv.Itoa(int(reset.Sub(now).Seconds() + 0.999))
+        h.Set("X-RateLimit-Limit", strconv.Itoa(cfg.Requests))
+        h.Set("X-RateLimit-Remaining", strconv.FormatInt(max(int64(cfg.Requests)-incr.Val(), 0), 10))
+        h.Set("X-RateLimit-Reset", resetIn)
+        if incr.Val() > int64(cfg.Requests) {
+            rateLimited.Inc()
+            strike(r, "rate_limit")
+            h.Set("Retry-After", resetIn)
+            http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// rateLimitClient is who a request counts against: the name of its API
+// key, or else the caller's IP. Unverified headers such as X-Tenant-ID
+// aren't used, since a client could send a new value with every request
+// for a fresh allowance.
+func rateLimitClient(r *http.Request) string {
+    if name := apiKeyName(r); name != "" { return "key:" + name }
+    return "ip:" + clientIP(r)
+}
+
+// clientIP is the caller's IP. A request from one of
+// rate_limit.trusted_proxies is followed back through X-Forwarded-For, right
+// to left, to the first address that isn't a trusted proxy: each proxy
+// appends the address it was connected from, so that one was seen by our
+// own infrastructure. Anything left of it came from the client and could be
+// anything.
+func clientIP(r *http.Request) string {
+    host := peerIP(r)
+    if !trustedProxy(host) { return host }
+    hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+    for i := len(hops) - 1; i >= 0; i-- {
+        hop := strings.TrimSpace(hops[i])
+        if _, err := netip.ParseAddr(hop); err != nil { break }
+        if !trustedProxy(hop) { return hop }
+        host = hop
+    }
+    return host
+}
+
+// peerIP is the address of the connection's other end.
+func peerIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil { return r.RemoteAddr }
+    return host
+}
+
+func trustedProxy(ip string) bool {
+    addr, err := netip.ParseAddr(ip)
+    if err != nil { return false }
+    for _, n := range config.Get().RateLimit.TrustedProxies {
+        if p, err := netip.ParsePrefix(n); err == nil && p.Contains(addr.Unmap()) { return true }
+    }
+    return false
+}
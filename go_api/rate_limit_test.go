@@ -0,0 +1,34 @@
+package main
+
+import (
+    "net/http/httptest"
+    "testing"
+
+    "example.com/fraud/internal/config"
+)
+
+// Only addresses our own proxies appended to X-Forwarded-For are believed;
+// whatever the client put in front of them is ignored.
+func TestClientIP(t *testing.T) {
+    t.Setenv("RATE_LIMIT_TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.1/32")
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    cases := []struct {
+        name, remote, xff, want string
+    }{
+        {"direct", "203.0.113.7:5000", "", "203.0.113.7"},
+        {"header from an untrusted peer", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+        {"one proxy", "10.0.0.2:5000", "203.0.113.7", "203.0.113.7"},
+        {"forged entry left of the proxy's", "10.0.0.2:5000", "198.51.100.1, 203.0.113.7", "203.0.113.7"},
+        {"chain of proxies", "10.0.0.2:5000", "203.0.113.7, 192.168.1.1, 10.0.0.3", "203.0.113.7"},
+        {"all proxies", "10.0.0.2:5000", "10.0.0.3", "10.0.0.3"},
+        {"proxy without header", "10.0.0.2:5000", "", "10.0.0.2"},
+        {"garbage before a proxy", "10.0.0.2:5000", "203.0.113.7, not-an-ip, 10.0.0.3", "10.0.0.3"},
+        {"ipv6", "[2001:db8::1]:5000", "198.51.100.1", "2001:db8::1"},
+    }
+    for _, c := range cases {
+        r := httptest.NewRequest("GET", "/", nil)
+        r.RemoteAddr = c.remote
+        if c.xff != "" { r.Header.Set("X-Forwarded-For", c.xff) }
+        if got := clientIP(r); got != c.want { t.Errorf("%s: clientIP = %q, want %q", c.name, got, c.want) }
+    }
+}
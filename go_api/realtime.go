@@ -0,0 +1,95 @@
+package main
+
+import (
+    "context"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+// Per-minute counters live in a Redis hash per minute, realtime:<unix
+// minute>, for a day.
+const realtimeRetention = 24*time.Hour + time.Minute
+
+func realtimeKey(minute time.Time) string { return "realtime:" + strconv.FormatInt(minute.Unix(), 10) }
+
+// countRealtime adds a stored transaction to the current minute's counters.
+// It is one pipelined round trip and skipped while Redis is down.
+func countRealtime(ctx context.Context, isFraud bool, decision string, score float64) {
+    if !cacheUp() { return }
+    key := realtimeKey(time.Now().UTC().Truncate(time.Minute))
+    pipe := rdb.Pipeline()
+    pipe.HIncrBy(ctx, key, "transactions", 1)
+    if isFraud { pipe.HIncrBy(ctx, key, "fraud", 1) }
+    if decision == decisionDecline { pipe.HIncrBy(ctx, key, "declined", 1) }
+    pipe.HIncrByFloat(ctx, key, "score_sum", score)
+    pipe.Expire(ctx, key, realtimeRetention)
+    _, err := pipe.Exec(ctx)
+    noteRedisErr(err)
+}
+
+type realtimeMinute struct {
+    Minute       time.Time `json:"minute"`
+    Transactions int64     `json:"transactions"`
+    Fraud        int64     `json:"fraud"`
+    Declined     int64     `json:"declined"`
+    AvgScore     float64   `json:"avg_score"`
+    scoreSum     float64
+}
+
+// realtimeHandler serves GET /stats/realtime?minutes=60 from the Redis
+// counters alone: totals and rates over the last minutes minutes, the
+// current one included, and the per-minute series oldest first.
+func realtimeHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    minutes := 60
+    if v := r.URL.Query().Get("minutes"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > 1440 { http.Error(w, "minutes must be 1-1440", http.StatusBadRequest); return }
+        minutes = n
+    }
+    if !cacheUp() { http.Error(w, "Realtime counters are unavailable while Redis is down", http.StatusServiceUnavailable); return }
+    now := time.Now().UTC()
+    first := now.Truncate(time.Minute).Add(-time.Duration(minutes-1) * time.Minute)
+    pipe := rdb.Pipeline()
+    cmds := make([]*redis.StringStringMapCmd, minutes)
+    for i := range cmds { cmds[i] = pipe.HGetAll(r.Context(), realtimeKey(first.Add(time.Duration(i)*time.Minute))) }
+    if _, err := pipe.Exec(r.Context()); err != nil { noteRedisErr(err); http.Error(w, err.Error(), http.StatusServiceUnavailable); return }
+
+    series := make([]realtimeMinute, minutes)
+    var total realtimeMinute
+    for i, cmd := range cmds {
+        h := cmd.Val()
+        m := realtimeMinute{Minute: first.Add(time.Duration(i) * time.Minute)}
+        m.Transactions, _ = strconv.ParseInt(h["transactions"], 10, 64)
+        m.Fraud, _ = strconv.ParseInt(h["fraud"], 10, 64)
+        m.Declined, _ = strconv.ParseInt(h["declined"], 10, 64)
+        m.scoreSum, _ = strconv.ParseFloat(h["score_sum"], 64)
+        if m.Transactions > 0 { m.AvgScore = m.scoreSum / float64(m.Transactions) }
+        series[i] = m
+        total.Transactions += m.Transactions
+        total.Fraud += m.Fraud
+        total.Declined += m.Declined
+        total.scoreSum += m.scoreSum
+    }
+    avg := 0.0
+    if total.Transactions > 0 { avg = total.scoreSum / float64(total.Transactions) }
+    elapsed := now.Sub(first).Seconds()
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "minutes": minutes,
+        "from": first,
+        "to": now,
+        "totals": map[string]interface{}{
+            "transactions": total.Transactions,
+            "fraud": total.Fraud,
+            "declined": total.Declined,
+            "fraud_rate": ratio(total.Fraud, total.Transactions),
+            "decline_rate": ratio(total.Declined, total.Transactions),
+            "avg_score": avg,
+            "transactions_per_second": float64(total.Transactions) / elapsed,
+        },
+        "series": series,
+    })
+}
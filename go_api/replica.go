@@ -0,0 +1,59 @@
+package main
+
+import (
+    "context"
+    "log"
+    "sync/atomic"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+)
+
+// pgReplica serves read-only endpoints (transaction lookup, alerts, user
+// risk) when POSTGRES_READ_HOST is set. Writes and the reads on the scoring
+// path always use pg.
+var (
+    pgReplica      *pgxpool.Pool
+    replicaUsable  atomic.Bool
+    replicaLagSecs = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_api_pg_replica_lag_seconds",
+        Help: "Replay lag of the read replica; -1 when it can't be measured.",
+    })
+)
+
+// usableReplica returns the replica while its lag is within
+// READ_REPLICA_MAX_LAG_MS and nil otherwise, so a lagging replica never
+// serves a risk score older than that bound.
+func usableReplica() *pgxpool.Pool {
+    if pgReplica != nil && replicaUsable.Load() { return pgReplica }
+    return nil
+}
+
+func monitorReplicaLag(interval time.Duration) {
+    for {
+        lag, err := replicaLag()
+        switch {
+        case err != nil:
+            replicaLagSecs.Set(-1)
+            if replicaUsable.Swap(false) { log.Printf("read replica unavailable, reading from primary: %v", err) }
+        case lag > config.Get().Postgres.ReplicaMaxLag:
+            replicaLagSecs.Set(lag.Seconds())
+            if replicaUsable.Swap(false) { log.Printf("read replica lag %s exceeds %s, reading from primary", lag, config.Get().Postgres.ReplicaMaxLag) }
+        default:
+            replicaLagSecs.Set(lag.Seconds())
+            if !replicaUsable.Swap(true) { log.Printf("read replica in use (lag %s)", lag) }
+        }
+        time.Sleep(interval)
+    }
+}
+
+func replicaLag() (time.Duration, error) {
+    qctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+    defer cancel()
+    return store.ReplicaLag(qctx, pgReplica)
+}
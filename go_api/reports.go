@@ -0,0 +1,61 @@
+package main
+
+import (
+    "context"
+    "log"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/report"
+    "example.com/fraud/internal/config"
+)
+
+// A report's Redis key is held for reportRetry while it is generated and
+// for reportDone once delivered, so each period is sent once across
+// instances and a failed attempt is retried later.
+const (
+    reportRetry = 15 * time.Minute
+    reportDone  = 9 * 24 * time.Hour
+)
+
+// runReportScheduler checks every minute for a daily or weekly report that
+// is due: its period is over and it is past reports.hour UTC.
+func runReportScheduler() {
+    for {
+        cfg := config.Get().Reports
+        now := time.Now().UTC()
+        for kind, on := range map[string]bool{report.Daily: cfg.Daily, report.Weekly: cfg.Weekly} {
+            if !on || !cacheUp() { continue }
+            from, to := report.Period(kind, now)
+            if now.Before(to.Add(time.Duration(cfg.Hour) * time.Hour)) { continue }
+            key := "report:" + kind + ":" + from.Format("2006-01-02")
+            ok, err := rdb.SetNX(ctx, key, "running", reportRetry).Result()
+            noteRedisErr(err)
+            if !ok { continue }
+            if sendReport(cfg, kind, from, to) { noteRedisErr(rdb.Set(ctx, key, "done", reportDone).Err()) }
+        }
+        time.Sleep(time.Minute)
+    }
+}
+
+// sendReport builds and delivers one report. It reports false only when
+// nothing was delivered, so a destination that failed while another
+// succeeded doesn't cause a resend.
+func sendReport(cfg config.Reports, kind string, from, to time.Time) bool {
+    rctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+    defer cancel()
+    r, err := report.Build(rctx, reportStore, kind, from, to)
+    if err != nil {
+        reportsFailed.WithLabelValues(kind).Inc()
+        log.Printf("%s report for %s failed: %v", kind, from.Format("2006-01-02"), err)
+        return false
+    }
+    sent, err := report.Deliver(rctx, cfg, config.Get().Archive, r)
+    for _, dest := range sent { reportsDelivered.WithLabelValues(kind, dest).Inc() }
+    if err != nil {
+        reportsFailed.WithLabelValues(kind).Inc()
+        log.Printf("%s report for %s: %v", kind, from.Format("2006-01-02"), err)
+    }
+    if len(sent) > 0 { log.Printf("%s report for %s sent via %s", kind, from.Format("2006-01-02"), strings.Join(sent, ", ")) }
+    return len(sent) > 0
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "net/http"
+)
+
+// withRequestID gives every response an X-Request-ID for integrators to
+// quote in support tickets. A caller's own X-Request-ID is kept if it is
+// short printable ASCII, so one ID can follow a request across services.
+func withRequestID(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        id := r.Header.Get("X-Request-ID")
+        if !validRequestID(id) {
+            id = newRequestID()
+            r.Header.Set("X-Request-ID", id)
+        }
+        w.Header().Set("X-Request-ID", id)
+        next.ServeHTTP(w, r)
+    })
+}
+
+func newRequestID() string {
+    b := make([]byte, 16)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+    if id == "" || len(id) > 128 { return false }
+    for i := 0; i < len(id); i++ {
+        if id[i] < 0x21 || id[i] > 0x7e { return false }
+    }
+    return true
+}
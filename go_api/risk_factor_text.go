@@ -0,0 +1,248 @@
+package main
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+
+    "example.com/fraud/internal/config"
+)
+
+// riskFactorText describes risk factors for people, by language and then
+// risk factor. localization.descriptions_file adds to and overrides it at
+// startup.
+var riskFactorText = map[string]map[string]string{
+    "en": {
+        "high_amount":                   "Unusually large amount",
+        "high_merchant_risk":            "Merchant with a high fraud risk",
+        "high_user_risk":                "Account with a high risk score",
+        "unusual_amount_pattern":        "Amount far above the account's usual spending",
+        "amount_zscore_outlier":         "Amount out of line with the account's history",
+        "high_risk_category":            "Merchant category with a high fraud rate",
+        "first_time_high_risk_category": "First purchase in a high-risk merchant category",
+        "card_velocity":                 "Many transactions on the card in a short time",
+        "disposable_email":              "Disposable email address",
+        "new_email":                     "Recently created email address",
+        "voip_phone":                    "Internet (VoIP) phone number",
+        "new_account_high_amount":       "Large amount on a new account",
+        "new_account_velocity":          "Many transactions on a new account",
+        "behavioral_anomaly":            "Unusual behavior during the session",
+        "high_risk_country":             "Country with a high fraud risk",
+        "high_risk_ip_range":            "Connection from a high-risk network",
+        "blocked_country":               "Payments from this country are not accepted",
+        "blocked_ip_range":              "Payments from this network are not accepted",
+        "possible_duplicate":            "Possible duplicate of a recent payment",
+        "limit_exceeded":                "Over the account's spending limit",
+        "kyc_pending":                   "Identity verification not yet complete",
+        "kyc_failed":                    "Identity verification failed",
+    },
+    "es": {
+        "high_amount":                   "Importe inusualmente alto",
+        "high_merchant_risk":            "Comercio con alto riesgo de fraude",
+        "high_user_risk":                "Cuenta con una puntuación de riesgo alta",
+        "unusual_amount_pattern":        "Importe muy superior al gasto habitual de la cuenta",
+        "amount_zscore_outlier":         "Importe fuera de lo habitual según el historial de la cuenta",
+        "high_risk_category":            "Categoría de comercio con alta tasa de fraude",
+        "first_time_high_risk_category": "Primera compra en una categoría de comercio de alto riesgo",
+        "card_velocity":                 "Muchas transacciones con la tarjeta en poco tiempo",
+        "disposable_email":              "Dirección de correo electrónico desechable",
+        "new_email":                     "Dirección de correo electrónico creada recientemente",
+        "voip_phone":                    "Número de teléfono por internet (VoIP)",
+        "new_account_high_amount":       "Importe elevado en una cuenta nueva",
+        "new_account_velocity":          "Muchas transacciones en una cuenta nueva",
+        "behavioral_anomaly":            "Comportamiento inusual durante la sesión",
+        "high_risk_country":             "País con alto riesgo de fraude",
+        "high_risk_ip_range":            "Conexión desde una red de alto riesgo",
+        "blocked_country":               "No se aceptan pagos desde este país",
+        "blocked_ip_range":              "No se aceptan pagos desde esta red",
+        "possible_duplicate":            "Posible duplicado de un pago reciente",
+        "limit_exceeded":                "Supera el límite de gasto de la cuenta",
+        "kyc_pending":                   "Verificación de identidad pendiente",
+        "kyc_failed":                    "Verificación de identidad fallida",
+    },
+    "fr": {
+        "high_amount":                   "Montant inhabituellement élevé",
+        "high_merchant_risk":            "Commerçant présentant un risque de fraude élevé",
+        "high_user_risk":                "Compte présentant un score de risque élevé",
+        "unusual_amount_pattern":        "Montant très supérieur aux dépenses habituelles du compte",
+        "amount_zscore_outlier":         "Montant inhabituel au regard de l'historique du compte",
+        "high_risk_category":            "Catégorie de commerçant à taux de fraude élevé",
+        "first_time_high_risk_category": "Premier achat dans une catégorie de commerçant à risque",
+        "card_velocity":                 "Nombreuses transactions sur la carte en peu de temps",
+        "disposable_email":              "Adresse e-mail jetable",
+        "new_email":                     "Adresse e-mail créée récemment",
+        "voip_phone":                    "Numéro de téléphone internet (VoIP)",
+        "new_account_high_amount":       "Montant élevé sur un compte récent",
+        "new_account_velocity":          "Nombreuses transactions sur un compte récent",
+        "behavioral_anomaly":            "Comportement inhabituel pendant la session",
+        "high_risk_country":             "Pays présentant un risque de fraude élevé",
+        "high_risk_ip_range":            "Connexion depuis un réseau à risque",
+        "blocked_country":               "Les paiements depuis ce pays ne sont pas acceptés",
+        "blocked_ip_range":              "Les paiements depuis ce réseau ne sont pas acceptés",
+        "possible_duplicate":            "Doublon possible d'un paiement récent",
+        "limit_exceeded":                "Plafond de dépenses du compte dépassé",
+        "kyc_pending":                   "Vérification d'identité en attente",
+        "kyc_failed":                    "Échec de la vérification d'identité",
+    },
+    "de": {
+        "high_amount":                   "Ungewöhnlich hoher Betrag",
+        "high_merchant_risk":            "Händler mit hohem Betrugsrisiko",
+        "high_user_risk":                "Konto mit hohem Risikowert",
+        "unusual_amount_pattern":        "Betrag weit über den üblichen Ausgaben des Kontos",
+        "amount_zscore_outlier":         "Betrag passt nicht zum bisherigen Kontoverlauf",
+        "high_risk_category":            "Händlerkategorie mit hoher Betrugsrate",
+        "first_time_high_risk_category": "Erster Kauf in einer Händlerkategorie mit hohem Risiko",
+        "card_velocity":                 "Viele Transaktionen mit der Karte in kurzer Zeit",
+        "disposable_email":              "Wegwerf-E-Mail-Adresse",
+        "new_email":                     "Kürzlich erstellte E-Mail-Adresse",
+        "voip_phone":                    "Internet-Telefonnummer (VoIP)",
+        "new_account_high_amount":       "Hoher Betrag bei einem neuen Konto",
+        "new_account_velocity":          "Viele Transaktionen bei einem neuen Konto",
+        "behavioral_anomaly":            "Ungewöhnliches Verhalten während der Sitzung",
+        "high_risk_country":             "Land mit hohem Betrugsrisiko",
+        "high_risk_ip_range":            "Verbindung aus einem Netz mit hohem Risiko",
+        "blocked_country":               "Zahlungen aus diesem Land werden nicht akzeptiert",
+        "blocked_ip_range":              "Zahlungen aus diesem Netz werden nicht akzeptiert",
+        "possible_duplicate":            "Mögliche Doppelung einer kürzlichen Zahlung",
+        "limit_exceeded":                "Ausgabenlimit des Kontos überschritten",
+        "kyc_pending":                   "Identitätsprüfung noch nicht abgeschlossen",
+        "kyc_failed":                    "Identitätsprüfung fehlgeschlagen",
+    },
+    "pt": {
+        "high_amount":                   "Valor excepcionalmente alto",
+        "high_merchant_risk":            "Estabelecimento com alto risco de fraude",
+        "high_user_risk":                "Conta com pontuação de risco alta",
+        "unusual_amount_pattern":        "Valor muito acima dos gastos habituais da conta",
+        "amount_zscore_outlier":         "Valor fora do padrão do histórico da conta",
+        "high_risk_category":            "Categoria de estabelecimento com alta taxa de fraude",
+        "first_time_high_risk_category": "Primeira compra numa categoria de estabelecimento de alto risco",
+        "card_velocity":                 "Muitas transações com o cartão em pouco tempo",
+        "disposable_email":              "Endereço de e-mail descartável",
+        "new_email":                     "Endereço de e-mail criado recentemente",
+        "voip_phone":                    "Número de telefone via internet (VoIP)",
+        "new_account_high_amount":       "Valor alto numa conta nova",
+        "new_account_velocity":          "Muitas transações numa conta nova",
+        "behavioral_anomaly":            "Comportamento incomum durante a sessão",
+        "high_risk_country":             "País com alto risco de fraude",
+        "high_risk_ip_range":            "Conexão a partir de uma rede de alto risco",
+        "blocked_country":               "Pagamentos deste país não são aceitos",
+        "blocked_ip_range":              "Pagamentos desta rede não são aceitos",
+        "possible_duplicate":            "Possível duplicata de um pagamento recente",
+        "limit_exceeded":                "Acima do limite de gastos da conta",
+        "kyc_pending":                   "Verificação de identidade pendente",
+        "kyc_failed":                    "Falha na verificação de identidade",
+    },
+}
+
+// initRiskFactorText merges localization.descriptions_file into the
+// built-in descriptions. A file that can't be read leaves the built-in
+// ones rather than stopping the API.
+func initRiskFactorText() {
+    cfg := config.Get().Localization
+    if cfg.DescriptionsFile != "" {
+        extra, err := readRiskFactorText(cfg.DescriptionsFile)
+        if err != nil {
+            log.Printf("risk factor descriptions not loaded: %v", err)
+        } else {
+            for lang, texts := range extra {
+                lang = strings.ToLower(lang)
+                if riskFactorText[lang] == nil { riskFactorText[lang] = map[string]string{} }
+                for code, text := range texts { riskFactorText[lang][code] = text }
+            }
+            log.Printf("risk factor descriptions: %d languages from %s", len(extra), cfg.DescriptionsFile)
+        }
+    }
+    if riskFactorText[strings.ToLower(cfg.DefaultLanguage)] == nil {
+        log.Printf("no risk factor descriptions in localization.default_language %q; English is used", cfg.DefaultLanguage)
+    }
+}
+
+func readRiskFactorText(path string) (map[string]map[string]string, error) {
+    b, err := os.ReadFile(path)
+    if err != nil { return nil, err }
+    var out map[string]map[string]string
+    if err := json.Unmarshal(b, &out); err != nil { return nil, err }
+    return out, nil
+}
+
+// riskFactorLanguage is the language to describe risk factors in for r:
+// the one Accept-Language ranks highest by q value that there are
+// descriptions in, by its full tag or else its primary subtag, earliest on
+// a tie, and localization.default_language when it names none. It is ""
+// when r has no Accept-Language, and such requests get no descriptions.
+// The response's Vary and Content-Language headers are set to match.
+func riskFactorLanguage(w http.ResponseWriter, r *http.Request) string {
+    w.Header().Add("Vary", "Accept-Language")
+    header := r.Header.Get("Accept-Language")
+    if strings.TrimSpace(header) == "" { return "" }
+    best, bestQ := "", 0.0
+    for _, part := range strings.Split(header, ",") {
+        tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+        tag = strings.ToLower(strings.TrimSpace(tag))
+        q := 1.0
+        if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+            f, err := strconv.ParseFloat(v, 64)
+            if err != nil { continue }
+            q = f
+        }
+        if q <= bestQ { continue }
+        if tag == "*" { tag = defaultLanguage() }
+        if riskFactorText[tag] == nil { tag, _, _ = strings.Cut(tag, "-") }
+        if riskFactorText[tag] != nil { best, bestQ = tag, q }
+    }
+    if best == "" { best = defaultLanguage() }
+    w.Header().Set("Content-Language", best)
+    return best
+}
+
+func defaultLanguage() string {
+    lang := strings.ToLower(config.Get().Localization.DefaultLanguage)
+    if riskFactorText[lang] == nil { return "en" }
+    return lang
+}
+
+// describeRiskFactors maps each of codes to its description in lang,
+// falling back to the default language, then English, then the code with
+// its underscores as spaces. It is nil when lang is "".
+func describeRiskFactors(codes []string, lang string) map[string]string {
+    if lang == "" { return nil }
+    out := make(map[string]string, len(codes))
+    for _, code := range codes { out[code] = riskFactorDescription(code, lang) }
+    return out
+}
+
+func riskFactorDescription(code, lang string) string {
+    for _, l := range []string{lang, defaultLanguage(), "en"} {
+        if text, ok := riskFactorText[l][code]; ok { return text }
+    }
+    return strings.ReplaceAll(code, "_", " ")
+}
+
+// riskFactorsHandler serves GET /risk-factors, every risk factor with a
+// description in any language, described in the language Accept-Language
+// asks for, for clients that would rather look descriptions up than
+// receive them with each transaction.
+func riskFactorsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    lang := riskFactorLanguage(w, r)
+    if lang == "" {
+        lang = defaultLanguage()
+        w.Header().Set("Content-Language", lang)
+    }
+    seen := map[string]bool{}
+    var codes []string
+    for _, texts := range riskFactorText {
+        for code := range texts {
+            if !seen[code] { seen[code] = true; codes = append(codes, code) }
+        }
+    }
+    sort.Strings(codes)
+    var languages []string
+    for l := range riskFactorText { languages = append(languages, l) }
+    sort.Strings(languages)
+    writeJSON(w, http.StatusOK, map[string]interface{}{"language": lang, "languages": languages, "risk_factors": describeRiskFactors(codes, lang)})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+// Rules are identified by the risk factor they add (high_amount,
+// card_velocity, limit_exceeded, ...).
+var ruleHits = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "fraud_api_rule_hits_total",
+    Help: "Scored transactions per risk factor.",
+}, []string{"rule"})
+
+func countRuleHits(riskFactors []string) {
+    for _, rf := range riskFactors { ruleHits.WithLabelValues(rf).Inc() }
+}
+
+// ruleStatsHandler serves GET /admin/rules/{id}/stats?days=30: how often
+// the rule fired, how much it helped decline, and its precision against
+// the labels recorded so far, over the last days days.
+func ruleStatsHandler(w http.ResponseWriter, r *http.Request) {
+    rule, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/admin/rules/"), "/stats")
+    if !ok || rule == "" || strings.Contains(rule, "/") { http.NotFound(w, r); return }
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    days := 30
+    if v := r.URL.Query().Get("days"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n <= 0 || n > 366 { http.Error(w, "days must be between 1 and 366", http.StatusBadRequest); return }
+        days = n
+    }
+    since := time.Now().UTC().AddDate(0, 0, -days)
+    // The aggregate can cover many partitions; allow it more than the
+    // per-request query timeout.
+    qctx, cancel := context.WithTimeout(store.ReadOnly(r.Context()), 30*time.Second)
+    defer cancel()
+    s, err := ruleStore.RuleStats(qctx, rule, since)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    resp := map[string]interface{}{
+        "rule": rule,
+        "since": since,
+        "hits": s.Hits,
+        "declined": s.Declined,
+        "declined_amount": s.DeclinedAmount,
+        "labeled": s.Labeled,
+        "labeled_fraud": s.LabeledFraud,
+        "precision": nil,
+    }
+    // Precision over the hits that have a label; unlabeled hits are unknown,
+    // not false positives.
+    if s.Labeled > 0 { resp["precision"] = float64(s.LabeledFraud) / float64(s.Labeled) }
+    writeJSON(w, http.StatusOK, resp)
+}
+
+// labelHandler serves POST /transactions/{id}/label, recording what a
+// transaction turned out to be: {"is_fraud": true, "source": "chargeback"}.
+// A later label replaces an earlier one.
+func labelHandler(w http.ResponseWriter, r *http.Request, id string) {
+    var body struct {
+        IsFraud *bool  `json:"is_fraud"`
+        Source  string `json:"source"`
+    }
+    if !decodeBody(w, r, &body, bodyLimit()) { return }
+    if body.IsFraud == nil { http.Error(w, "is_fraud is required", http.StatusBadRequest); return }
+    if len(body.Source) > 50 { http.Error(w, "source must be at most 50 characters", http.StatusBadRequest); return }
+    from, to := transactionTimeWindow(id)
+    qctx, cancel := conn.QueryCtx(r.Context())
+    defer cancel()
+    if _, err := txStore.Get(qctx, id, from, to); errors.Is(err, store.ErrNotFound) {
+        http.Error(w, "Transaction not found", http.StatusNotFound)
+        return
+    } else if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if err := ruleStore.Label(qctx, id, *body.IsFraud, body.Source); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    status, only, reason := store.TxApproved, settleableStatuses, "label"
+    if *body.IsFraud { status = store.TxDeclined }
+    if body.Source == "chargeback" {
+        // A won representment (is_fraud false) undoes the chargeback.
+        reason = "chargeback"
+        if *body.IsFraud { status = store.TxChargedBack } else { only = append([]string{store.TxChargedBack}, only...) }
+    }
+    actor := body.Source
+    if actor == "" { actor = "label" }
+    setTransactionStatus(r.Context(), id, status, only, actor, reason)
+    writeJSON(w, http.StatusOK, map[string]interface{}{"transaction_id": id, "is_fraud": *body.IsFraud, "source": body.Source})
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+    "errors"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+// sarMaxTransactions caps the transactions in one package; Activity says
+// when the lookback held more.
+const sarMaxTransactions = 5000
+
+// sarPackage is what compliance files a suspicious activity report from: the
+// subject, the activity in the review period with its totals, every alert
+// on it, and a chronological trail of how each transaction was decided.
+type sarPackage struct {
+    CaseID        string           `json:"case_id"`
+    GeneratedAt   time.Time        `json:"generated_at"`
+    Trigger       store.Alert      `json:"triggering_alert"`
+    Subject       sarSubject       `json:"subject"`
+    Activity      sarActivity      `json:"activity"`
+    Transactions  []sarTransaction `json:"transactions"`
+    Alerts        []store.Alert    `json:"alerts"`
+    DecisionTrail []sarEvent       `json:"decision_trail"`
+}
+
+type sarSubject struct {
+    UserID             string               `json:"user_id"`
+    RiskScore          float64              `json:"risk_score"`
+    KYCStatus          string               `json:"kyc_status"`
+    CreatedAt          time.Time            `json:"created_at"`
+    FirstTransactionAt *time.Time           `json:"first_transaction_at"`
+    SpendLimits        store.SpendLimits    `json:"spend_limits"`
+    TravelNotices      []store.TravelNotice `json:"travel_notices"`
+}
+
+// sarActivity summarizes the review period. Flagged transactions were
+// scored as fraud; confirmed ones were later labeled fraud.
+type sarActivity struct {
+    From                 time.Time      `json:"from"`
+    To                   time.Time      `json:"to"`
+    Transactions         int            `json:"transactions"`
+    TotalAmount          float64        `json:"total_amount"`
+    Declined             int            `json:"declined"`
+    DeclinedAmount       float64        `json:"declined_amount"`
+    Flagged              int            `json:"flagged"`
+    FlaggedAmount        float64        `json:"flagged_amount"`
+    ConfirmedFraud       int            `json:"confirmed_fraud"`
+    ConfirmedFraudAmount float64        `json:"confirmed_fraud_amount"`
+    Merchants            int            `json:"merchants"`
+    Countries            []string       `json:"countries"`
+    Channels             map[string]int `json:"channels"`
+    RiskFactors          map[string]int `json:"risk_factors"`
+    // Truncated is set when the period held more than sarMaxTransactions.
+    Truncated bool `json:"truncated"`
+}
+
+type sarTransaction struct {
+    TransactionID      string     `json:"transaction_id"`
+    Timestamp          time.Time  `json:"timestamp"`
+    Amount             float64    `json:"amount"`
+    MerchantID         string     `json:"merchant_id,omitempty"`
+    MCC                *string    `json:"mcc,omitempty"`
+    Channel            string     `json:"channel"`
+    Country            *string    `json:"country,omitempty"`
+    SessionID          *string    `json:"session_id,omitempty"`
+    FraudScore         float64    `json:"fraud_score"`
+    IsFraud            bool       `json:"is_fraud"`
+    Decision           *string    `json:"decision"`
+    Status             string     `json:"status"`
+    RiskFactors        []string   `json:"risk_factors"`
+    VerificationMethod *string    `json:"verification_method,omitempty"`
+    VerificationResult *string    `json:"verification_result,omitempty"`
+    Label              *bool      `json:"label_is_fraud"`
+    LabelSource        *string    `json:"label_source,omitempty"`
+    LabeledAt          *time.Time `json:"labeled_at,omitempty"`
+}
+
+// sarEvent is one step of the decision trail: scored, changed (a rescore
+// or verification updated the decision; the record keeps only the latest
+// state), alert, alert_resolved or labeled.
+type sarEvent struct {
+    Time          time.Time `json:"time"`
+    Event         string    `json:"event"`
+    TransactionID string    `json:"transaction_id,omitempty"`
+    AlertID       string    `json:"alert_id,omitempty"`
+    Detail        string    `json:"detail"`
+}
+
+// sarExportHandler serves GET /admin/export/sar?case_id=<alert
+// id>[&days=90]. A case is opened from an alert; the package covers the
+// alerted user's activity from days before the alert until now.
+func sarExportHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    caseID := q.Get("case_id")
+    if caseID == "" { http.Error(w, "case_id is required", http.StatusBadRequest); return }
+    days := 90
+    if v := q.Get("days"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > 365 { http.Error(w, "days must be 1-365", http.StatusBadRequest); return }
+        days = n
+    }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    trigger, err := caseStore.Alert(qctx, caseID)
+    if errors.Is(err, store.ErrNotFound) { http.Error(w, "case not found", http.StatusNotFound); return }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if trigger.UserID == "" { http.Error(w, "the case's alert is not on a stored transaction", http.StatusUnprocessableEntity); return }
+
+    now := time.Now().UTC()
+    pkg := sarPackage{CaseID: caseID, GeneratedAt: now, Trigger: trigger}
+    if pkg.Subject, err = sarSubjectOf(r, trigger.UserID, now); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    from := trigger.CreatedAt.AddDate(0, 0, -days)
+    txs, err := caseStore.UserActivity(qctx, trigger.UserID, from, now, sarMaxTransactions+1)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if pkg.Alerts, err = caseStore.UserAlerts(qctx, trigger.UserID, from, now); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if pkg.Alerts == nil { pkg.Alerts = []store.Alert{} }
+    truncated := len(txs) > sarMaxTransactions
+    if truncated { txs = txs[:sarMaxTransactions] }
+    pkg.Activity = summarizeActivity(txs, from, now)
+    pkg.Activity.Truncated = truncated
+    pkg.Transactions = make([]sarTransaction, len(txs))
+    for i, t := range txs {
+        pkg.Transactions[i] = sarTransaction{
+            TransactionID: t.TransactionID, Timestamp: t.Timestamp, Amount: t.Amount, MerchantID: t.MerchantID, MCC: t.MCC, Channel: t.Channel,
+            Country: t.Country, SessionID: t.SessionID, FraudScore: t.FraudScore, IsFraud: t.IsFraud, Decision: t.Decision, Status: t.Status, RiskFactors: t.RiskFactors,
+            VerificationMethod: t.VerificationMethod, VerificationResult: t.VerificationResult, Label: t.Label, LabelSource: t.LabelSource, LabeledAt: t.LabeledAt,
+        }
+    }
+    pkg.DecisionTrail = decisionTrail(txs, pkg.Alerts)
+    w.Header().Set("Content-Disposition", `attachment; filename="sar-`+sanitizeFilename(caseID)+`.json"`)
+    writeJSON(w, http.StatusOK, pkg)
+}
+
+func sarSubjectOf(r *http.Request, userID string, now time.Time) (sarSubject, error) {
+    s := sarSubject{UserID: userID}
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    var err error
+    if s.RiskScore, err = userStore.RiskScore(qctx, userID); err != nil { return s, err }
+    tenure, err := userStore.Tenure(qctx, userID)
+    if err != nil { return s, err }
+    s.CreatedAt, s.FirstTransactionAt = tenure.CreatedAt, tenure.FirstTransactionAt
+    if s.KYCStatus, err = kycStore.KYCStatus(qctx, userID); errors.Is(err, store.ErrNotFound) {
+        s.KYCStatus = "none"
+    } else if err != nil {
+        return s, err
+    }
+    if s.SpendLimits, err = limitStore.SpendLimits(qctx, userID); err != nil && !errors.Is(err, store.ErrNotFound) { return s, err }
+    if s.TravelNotices, err = travelStore.TravelNotices(qctx, userID, now); err != nil { return s, err }
+    if s.TravelNotices == nil { s.TravelNotices = []store.TravelNotice{} }
+    return s, nil
+}
+
+func summarizeActivity(txs []store.CaseTransaction, from, to time.Time) sarActivity {
+    a := sarActivity{From: from, To: to, Countries: []string{}, Channels: map[string]int{}, RiskFactors: map[string]int{}}
+    merchants, countries := map[string]bool{}, map[string]bool{}
+    for _, t := range txs {
+        a.Transactions++
+        a.TotalAmount += t.Amount
+        if t.Decision != nil && *t.Decision == decisionDecline { a.Declined++; a.DeclinedAmount += t.Amount }
+        if t.IsFraud { a.Flagged++; a.FlaggedAmount += t.Amount }
+        if t.Label != nil && *t.Label { a.ConfirmedFraud++; a.ConfirmedFraudAmount += t.Amount }
+        if t.MerchantID != "" { merchants[t.MerchantID] = true }
+        if t.Country != nil && !countries[*t.Country] {
+            countries[*t.Country] = true
+            a.Countries = append(a.Countries, *t.Country)
+        }
+        a.Channels[t.Channel]++
+        for _, f := range t.RiskFactors { a.RiskFactors[f]++ }
+    }
+    a.Merchants = len(merchants)
+    sort.Strings(a.Countries)
+    return a
+}
+
+func decisionTrail(txs []store.CaseTransaction, alerts []store.Alert) []sarEvent {
+    trail := []sarEvent{}
+    for _, t := range txs {
+        decision := "none"
+        if t.Decision != nil { decision = *t.Decision }
+        detail := "score " + strconv.FormatFloat(t.FraudScore, 'f', 4, 64) + ", decision " + decision
+        if len(t.RiskFactors) > 0 { detail += ", risk factors " + strings.Join(t.RiskFactors, ", ") }
+        trail = append(trail, sarEvent{Time: t.CreatedAt, Event: "scored", TransactionID: t.TransactionID, Detail: detail})
+        if t.UpdatedAt.Sub(t.CreatedAt) > time.Second {
+            d := "decision now " + decision
+            if t.VerificationResult != nil {
+                method := "step-up"
+                if t.VerificationMethod != nil { method = *t.VerificationMethod }
+                d += ", " + method + " verification " + *t.VerificationResult
+            }
+            trail = append(trail, sarEvent{Time: t.UpdatedAt, Event: "changed", TransactionID: t.TransactionID, Detail: d})
+        }
+        if t.LabeledAt != nil && t.Label != nil {
+            d := "labeled legitimate"
+            if *t.Label { d = "labeled fraud" }
+            if t.LabelSource != nil { d += " by " + *t.LabelSource }
+            trail = append(trail, sarEvent{Time: *t.LabeledAt, Event: "labeled", TransactionID: t.TransactionID, Detail: d})
+        }
+    }
+    for _, a := range alerts {
+        trail = append(trail, sarEvent{Time: a.CreatedAt, Event: "alert", TransactionID: a.TransactionID, AlertID: a.AlertID, Detail: a.Severity + " " + a.AlertType + ": " + a.Description})
+        if a.ResolvedAt != nil {
+            d := a.Status
+            if a.Resolution != nil { d = *a.Resolution }
+            if a.ResolvedBy != nil { d += " by " + *a.ResolvedBy }
+            trail = append(trail, sarEvent{Time: *a.ResolvedAt, Event: "alert_resolved", TransactionID: a.TransactionID, AlertID: a.AlertID, Detail: d})
+        }
+    }
+    sort.SliceStable(trail, func(i, j int) bool { return trail[i].Time.Before(trail[j].Time) })
+    return trail
+}
+
+// sanitizeFilename keeps IDs from breaking out of the quoted filename.
+func sanitizeFilename(s string) string {
+    return strings.Map(func(r rune) rune {
+        if r == '-' || r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' { return r }
+        return '_'
+    }, s)
+}
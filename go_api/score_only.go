@@ -0,0 +1,75 @@
+package main
+
+import (
+    "context"
+    "net/http"
+    "time"
+)
+
+type dryRunKey struct{}
+
+// withDryRun marks ctx as scoring a what-if request: enrichers read state
+// but don't record the transaction in it.
+func withDryRun(ctx context.Context) context.Context { return context.WithValue(ctx, dryRunKey{}, true) }
+
+func dryRun(ctx context.Context) bool {
+    d, _ := ctx.Value(dryRunKey{}).(bool)
+    return d
+}
+
+// scoreOnlyHandler serves POST /transactions/score-only: the request is
+// enriched, scored and decided exactly as /transactions/process would, but
+// nothing is stored, published, counted or cached, and no user is created.
+// transaction_id is left empty.
+func scoreOnlyHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    start := time.Now()
+    var req TransactionRequest
+    if !decodeBody(w, r, &req, bodyLimit()) { return }
+    if err := validateRequest(&req); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if ipBlocked(r.Context(), req) {
+        http.Error(w, "ip address temporarily blocked", http.StatusForbidden)
+        return
+    }
+    release, err := acquireRealtime(r.Context())
+    if err != nil { scoringBusy(w); return }
+    defer release()
+    writeScore(w, r, http.StatusOK, scoreOnly(withDryRun(r.Context()), req, r.Header.Get("X-Tenant-ID"), start))
+}
+
+// scoreOnly mirrors processTransaction up to the point where it writes
+// anything, with read-only versions of the duplicate and spend limit checks.
+func scoreOnly(rctx context.Context, req TransactionRequest, tenant string, start time.Time) TransactionResponse {
+    bctx, cancel := withLatencyBudget(rctx, start)
+    defer cancel()
+    if code := merchantMCC(bctx, req); code != "" { req.MCC = &code }
+    fraudScore, confidence, riskFactors, f := scoreTransaction(bctx, req, tenant)
+    isFraud := fraudScore > fraudThreshold(tenant, req.UserID)
+    duplicateOf := peekDuplicate(rctx, req)
+    if duplicateOf != "" { riskFactors = append(riskFactors, "possible_duplicate") }
+    decision, declineReason, gates := decide(req, f, fraudScore, isFraud, duplicateOf != "")
+    riskFactors = append(riskFactors, gates...)
+    if decision != decisionDecline && !withinSpendLimits(rctx, req) {
+        decision, declineReason = decisionDecline, reasonLimitExceeded
+        riskFactors = append(riskFactors, reasonLimitExceeded)
+    }
+    meterScored(rctx)
+    return TransactionResponse{
+        IsFraud:           isFraud,
+        FraudScore:        fraudScore,
+        Confidence:        confidence,
+        RiskFactors:       riskFactors,
+        ProcessingTimeMs:  int(time.Since(start).Milliseconds()),
+        Degraded:          !cacheUp(),
+        DuplicateOf:       duplicateOf,
+        Decision:          decision,
+        ExpectedLoss:      expectedLoss(req.Amount, fraudScore),
+        DeclineReason:     declineReason,
+        PartialEvaluation: len(f.Skipped) > 0,
+        ScoringTier:       f.Tier,
+        FallbackReason:    f.FallbackReason,
+    }
+}
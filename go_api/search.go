@@ -0,0 +1,152 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/search"
+)
+
+// searchClient is nil unless search.url is set.
+var searchClient *search.Client
+
+// facetFields are the fields each index can be faceted on.
+var facetFields = map[string]map[string]bool{
+    search.Transactions: {"user_id": true, "merchant_id": true, "mcc": true, "category": true, "channel": true, "country": true, "is_fraud": true, "device_id": true, "ip_address": true},
+    search.Alerts:       {"alert_type": true, "severity": true, "user_id": true},
+}
+
+func initSearch() {
+    cfg := config.Get().Search
+    if cfg.URL != "" { searchClient = search.NewClient(cfg.URL, cfg.Username, cfg.Password, cfg.IndexPrefix) }
+}
+
+type facetBucket struct {
+    Value interface{} `json:"value"`
+    Count int64       `json:"count"`
+}
+
+type searchResponse struct {
+    Total  int64                    `json:"total"`
+    Hits   []json.RawMessage        `json:"hits"`
+    Facets map[string][]facetBucket `json:"facets,omitempty"`
+}
+
+// searchHandler serves /search/transactions and /search/alerts from the
+// search cluster the processor indexes into. GET takes a query string in
+// Lucene syntax (q=user_id:U1 AND amount:>500), optional since/until
+// (RFC 3339), from/size paging and facets=field,... for value counts; hits
+// are newest first. POST forwards a query DSL body as is and returns the
+// cluster's response, for searches GET can't express.
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+    kind := strings.Trim(strings.TrimPrefix(r.URL.Path, "/search"), "/")
+    if _, ok := facetFields[kind]; !ok { http.NotFound(w, r); return }
+    if searchClient == nil { http.Error(w, "search is not configured", http.StatusServiceUnavailable); return }
+    switch r.Method {
+    case http.MethodGet:
+        query, facets, err := buildSearchQuery(kind, r)
+        if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+        body, err := searchClient.Search(r.Context(), kind, query)
+        if err != nil { http.Error(w, err.Error(), http.StatusBadGateway); return }
+        resp, err := parseSearchResponse(body, facets)
+        if err != nil { http.Error(w, err.Error(), http.StatusBadGateway); return }
+        writeJSON(w, http.StatusOK, resp)
+    case http.MethodPost:
+        query, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+        if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+        if !json.Valid(query) { http.Error(w, "body must be a JSON query", http.StatusBadRequest); return }
+        body, err := searchClient.Search(r.Context(), kind, query)
+        if err != nil { http.Error(w, err.Error(), http.StatusBadGateway); return }
+        w.Header().Set("Content-Type", "application/json")
+        w.Write(body)
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
+
+func buildSearchQuery(kind string, r *http.Request) ([]byte, []string, error) {
+    q := r.URL.Query()
+    from, size := 0, 20
+    if v := q.Get("from"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 { return nil, nil, badParam("from") }
+        from = n
+    }
+    if v := q.Get("size"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 || n > 100 { return nil, nil, badParam("size (0-100)") }
+        size = n
+    }
+    must := []interface{}{map[string]interface{}{"match_all": map[string]interface{}{}}}
+    if s := strings.TrimSpace(q.Get("q")); s != "" {
+        must = []interface{}{map[string]interface{}{"query_string": map[string]interface{}{"query": s, "default_operator": "AND"}}}
+    }
+    rng := map[string]string{}
+    for _, p := range []struct{ param, op string }{{"since", "gte"}, {"until", "lt"}} {
+        v := q.Get(p.param)
+        if v == "" { continue }
+        if _, err := time.Parse(time.RFC3339, v); err != nil { return nil, nil, badParam(p.param + " (RFC 3339)") }
+        rng[p.op] = v
+    }
+    boolQuery := map[string]interface{}{"must": must}
+    if len(rng) > 0 { boolQuery["filter"] = []interface{}{map[string]interface{}{"range": map[string]interface{}{"timestamp": rng}}} }
+    body := map[string]interface{}{
+        "query":            map[string]interface{}{"bool": boolQuery},
+        "sort":             []interface{}{map[string]string{"timestamp": "desc"}},
+        "from":             from,
+        "size":             size,
+        "track_total_hits": true,
+    }
+    var facets []string
+    if v := q.Get("facets"); v != "" {
+        aggs := map[string]interface{}{}
+        for _, f := range strings.Split(v, ",") {
+            f = strings.TrimSpace(f)
+            if !facetFields[kind][f] { return nil, nil, badParam("facets: " + f) }
+            aggs[f] = map[string]interface{}{"terms": map[string]interface{}{"field": f, "size": 20}}
+            facets = append(facets, f)
+        }
+        body["aggs"] = aggs
+    }
+    b, err := json.Marshal(body)
+    return b, facets, err
+}
+
+func badParam(name string) error { return fmt.Errorf("invalid %s", name) }
+
+func parseSearchResponse(body []byte, facets []string) (searchResponse, error) {
+    var raw struct {
+        Hits struct {
+            Total struct{ Value int64 `json:"value"` } `json:"total"`
+            Hits  []struct{ Source json.RawMessage `json:"_source"` } `json:"hits"`
+        } `json:"hits"`
+        Aggregations map[string]struct {
+            Buckets []struct {
+                Key         interface{} `json:"key"`
+                KeyAsString string      `json:"key_as_string"`
+                DocCount    int64       `json:"doc_count"`
+            } `json:"buckets"`
+        } `json:"aggregations"`
+    }
+    if err := json.Unmarshal(body, &raw); err != nil { return searchResponse{}, err }
+    resp := searchResponse{Total: raw.Hits.Total.Value, Hits: make([]json.RawMessage, 0, len(raw.Hits.Hits))}
+    for _, h := range raw.Hits.Hits { resp.Hits = append(resp.Hits, h.Source) }
+    if len(facets) > 0 { resp.Facets = map[string][]facetBucket{} }
+    for _, f := range facets {
+        buckets := []facetBucket{}
+        for _, b := range raw.Aggregations[f].Buckets {
+            // Booleans come back as 0/1 keys with "true"/"false" alongside.
+            var v interface{} = b.Key
+            if b.KeyAsString != "" { v = b.KeyAsString }
+            buckets = append(buckets, facetBucket{Value: v, Count: b.DocCount})
+        }
+        resp.Facets[f] = buckets
+    }
+    return resp, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+    "errors"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// suppressionsHandler serves /alerts/suppressions: GET lists them (only the
+// live ones with ?live=true), POST adds one and DELETE
+// /alerts/suppressions/{id}?author=jdoe ends one early. For example
+//
+//  {"merchant_id": "M123", "severity": "MEDIUM", "starts_at": "2024-05-01T22:00:00Z",
+//   "ends_at": "2024-05-02T04:00:00Z", "reason": "PSP migration", "author": "jdoe"}
+//
+// The processor picks changes up within suppressions.refresh_interval.
+func suppressionsHandler(w http.ResponseWriter, r *http.Request, rest string) {
+    switch {
+    case r.Method == http.MethodGet && rest == "":
+        qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+        defer cancel()
+        out, err := suppressionStore.Suppressions(qctx, r.URL.Query().Get("live") == "true")
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if out == nil { out = []store.Suppression{} }
+        writeJSON(w, http.StatusOK, map[string]interface{}{"suppressions": out})
+    case r.Method == http.MethodPost && rest == "":
+        var body struct {
+            store.Suppression
+            StartsAt *time.Time `json:"starts_at"`
+            Author   string     `json:"author"`
+        }
+        if !decodeBody(w, r, &body, bodyLimit()) { return }
+        s := body.Suppression
+        s.StartsAt, s.CreatedBy = time.Now().UTC(), strings.TrimSpace(body.Author)
+        if body.StartsAt != nil { s.StartsAt = *body.StartsAt }
+        if err := validateSuppression(&s); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        s, err := suppressionStore.AddSuppression(qctx, s)
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        writeJSON(w, http.StatusCreated, s)
+    case r.Method == http.MethodDelete && rest != "":
+        id, err := strconv.ParseInt(rest, 10, 64)
+        if err != nil { http.Error(w, "invalid suppression id", http.StatusBadRequest); return }
+        author := strings.TrimSpace(r.URL.Query().Get("author"))
+        if author == "" || len(author) > 100 { http.Error(w, "author is required, at most 100 characters", http.StatusBadRequest); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        s, err := suppressionStore.EndSuppression(qctx, id, author)
+        if errors.Is(err, store.ErrNotFound) { http.Error(w, "Suppression not found", http.StatusNotFound); return }
+        if errors.Is(err, store.ErrNotOpen) { http.Error(w, "Suppression has already expired", http.StatusConflict); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        writeJSON(w, http.StatusOK, s)
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
+
+func validateSuppression(s *store.Suppression) error {
+    s.AlertType, s.Severity = strings.ToUpper(s.AlertType), strings.ToUpper(s.Severity)
+    if s.MerchantID == "" && s.AlertType == "" && s.Severity == "" { return errors.New("at least one of merchant_id, alert_type and severity is required") }
+    if s.Severity != "" && s.Severity != "LOW" && s.Severity != "MEDIUM" && s.Severity != "HIGH" && s.Severity != "CRITICAL" {
+        return errors.New("severity must be LOW, MEDIUM, HIGH or CRITICAL")
+    }
+    if len(s.MerchantID) > 100 || len(s.AlertType) > 50 { return errors.New("merchant_id or alert_type is too long") }
+    if s.CreatedBy == "" || len(s.CreatedBy) > 100 { return errors.New("author is required, at most 100 characters") }
+    if strings.TrimSpace(s.Reason) == "" { return errors.New("reason is required") }
+    if !s.EndsAt.After(s.StartsAt) { return errors.New("ends_at must be after starts_at") }
+    if !s.EndsAt.After(time.Now()) { return errors.New("ends_at must be in the future") }
+    if max := config.Get().Suppressions.MaxWindow; s.EndsAt.Sub(s.StartsAt) > max { return errors.New("the window may be at most " + max.String()) }
+    return nil
+}
+
+// runSuppressionExpiry expires suppressions as their windows end, which
+// records each in the audit log. Any instance may do it: each suppression
+// is expired by exactly one UPDATE.
+func runSuppressionExpiry() {
+    for {
+        time.Sleep(config.Get().Suppressions.RefreshInterval)
+        qctx, cancel := conn.QueryCtx(ctx)
+        expired, err := suppressionStore.ExpireSuppressions(qctx, time.Now().UTC())
+        cancel()
+        if err != nil { log.Printf("suppression expiry failed: %v", err); continue }
+        for _, s := range expired { log.Printf("alert suppression %d (%s) expired", s.ID, s.Reason) }
+    }
+}
+
+// auditHandler serves GET /audit?entity_type=suppression&entity_id=3&limit=100,
+// newest first, a page at a time.
+func auditHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    page, ok := pageParams(w, r, 100, 1000)
+    if !ok { return }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := auditStore.AuditLog(qctx, q.Get("entity_type"), q.Get("entity_id"), page.query())
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    out, next := pageOf(page, rows, func(e store.AuditEntry) store.Cursor { return store.Cursor{At: e.At, ID: strconv.FormatInt(e.ID, 10)} })
+    writeJSON(w, http.StatusOK, pageBody("entries", out, next))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "context"
+    "strconv"
+    "strings"
+    "time"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// tenureCacheTTL applies once a user's first transaction is known; until then
+// tenure is read from Postgres so the processor's update shows up.
+const tenureCacheTTL = 24 * time.Hour
+
+// tenureEnricher adds the account-age features.
+type tenureEnricher struct{}
+
+func (tenureEnricher) Name() string { return "tenure" }
+
+func (tenureEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    created, first, ok := userTenure(ctx, req.UserID)
+    now := time.Now()
+    if !ok { created = now }
+    // The client's signup time wins when it is earlier than the first time
+    // this service saw the user.
+    if req.AccountCreatedAt != nil && req.AccountCreatedAt.Before(created) { created = *req.AccountCreatedAt }
+    f.AccountAgeHours = now.Sub(created).Hours()
+    f.FirstTransactionHours = -1
+    if !first.IsZero() { f.FirstTransactionHours = now.Sub(first).Hours() }
+    if newAccount(*f, config.Get().Rules) { f.NewAccountTransactions = newAccountTransactions(ctx, req.UserID) }
+}
+
+// userTenure returns when the user was created and made their first
+// transaction (zero if none yet), from user_tenure:<id> or Postgres. ok is
+// false for a user that doesn't exist yet or can't be read.
+func userTenure(ctx context.Context, userID string) (created, first time.Time, ok bool) {
+    key := "user_tenure:" + userID
+    if cacheUp() {
+        v, err := rdb.Get(ctx, key).Result()
+        if err == nil {
+            c, fst, found := strings.Cut(v, ",")
+            cs, _ := strconv.ParseInt(c, 10, 64)
+            fs, _ := strconv.ParseInt(fst, 10, 64)
+            if found { return time.Unix(cs, 0), time.Unix(fs, 0), true }
+        }
+        noteRedisErr(err)
+    }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    t, err := userStore.Tenure(qctx, userID)
+    if err != nil { return time.Time{}, time.Time{}, false }
+    if t.FirstTransactionAt == nil { return t.CreatedAt, time.Time{}, true }
+    if cacheUp() {
+        v := strconv.FormatInt(t.CreatedAt.Unix(), 10) + "," + strconv.FormatInt(t.FirstTransactionAt.Unix(), 10)
+        noteRedisErr(rdb.Set(ctx, key, v, tenureCacheTTL).Err())
+    }
+    return t.CreatedAt, *t.FirstTransactionAt, true
+}
+
+// newAccount reports an account younger than rules.new_account_window.
+func newAccount(f features, rules config.Rules) bool {
+    return f.AccountAgeHours < rules.NewAccountWindow.Hours()
+}
+
+// newAccountTransactions returns how many transactions the user made while
+// their account was new, not counting this one; 0 while Redis is
+// unavailable.
+func newAccountTransactions(ctx context.Context, userID string) int {
+    if !cacheUp() { return 0 }
+    v, err := rdb.Get(ctx, "new_account_tx:"+userID).Result()
+    if err != nil { noteRedisErr(err); return 0 }
+    n, _ := strconv.Atoi(v)
+    return n
+}
+
+// countNewAccountTransaction adds a scored transaction to the counter of a
+// new account. The counter lapses when the account stops being new.
+func countNewAccountTransaction(ctx context.Context, userID string, f features) {
+    rules := config.Get().Rules
+    if !newAccount(f, rules) || !cacheUp() { return }
+    key := "new_account_tx:" + userID
+    n, err := rdb.Incr(ctx, key).Result()
+    if err != nil { noteRedisErr(err); return }
+    if n == 1 {
+        remaining := rules.NewAccountWindow - time.Duration(f.AccountAgeHours*float64(time.Hour))
+        noteRedisErr(rdb.Expire(ctx, key, remaining).Err())
+    }
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log"
+    "math"
+    "net/http"
+    "sync/atomic"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/internal/config"
+)
+
+// The latest analysis is kept in Redis so every instance serves and applies
+// the same one; the lock lets a single instance run each sweep.
+const (
+    thresholdAnalysisKey  = "threshold_analysis"
+    thresholdAnalysisLock = "threshold_analysis:lock"
+    thresholdStep         = 0.05
+)
+
+// thresholdPoint is the outcome of flagging every labeled transaction
+// scoring above Threshold.
+type thresholdPoint struct {
+    Threshold float64 `json:"threshold"`
+    Flagged   int     `json:"flagged"`
+    Precision float64 `json:"precision"`
+    Recall    float64 `json:"recall"`
+    // ExpectedLoss is the amount of fraud let through plus
+    // rules.false_positive_cost per legitimate transaction flagged.
+    ExpectedLoss float64 `json:"expected_loss"`
+}
+
+type thresholdAnalysis struct {
+    GeneratedAt       time.Time        `json:"generated_at"`
+    Since             time.Time        `json:"since"`
+    Labeled           int              `json:"labeled"`
+    Fraud             int              `json:"fraud"`
+    FalsePositiveCost float64          `json:"false_positive_cost"`
+    CurrentThreshold  float64          `json:"current_threshold"`
+    Recommended       float64          `json:"recommended_threshold"`
+    Curve             []thresholdPoint `json:"curve"`
+}
+
+var currentThresholdAnalysis atomic.Pointer[thresholdAnalysis]
+
+// fraudThreshold is the score above which a transaction counts as fraud:
+// rules.fraud_threshold, or the recommended threshold of the latest analysis
+// for tenants and users the threshold_autotune flag is on for, once the
+// analysis rests on thresholds.min_labeled labels.
+func fraudThreshold(tenant, userID string) float64 {
+    t := config.Get().Rules.FraudThreshold
+    if !featureFlags.On(flagThresholdAutotune, false, tenant, userID) { return t }
+    a := currentThresholdAnalysis.Load()
+    if a == nil || a.Labeled < config.Get().Thresholds.MinLabeled || a.Fraud == 0 { return t }
+    return a.Recommended
+}
+
+// analyzeThresholds sweeps thresholds from 0.05 to 0.95 over the labeled
+// transactions of the last thresholds.analysis_days days and recommends the
+// one with the lowest expected loss.
+func analyzeThresholds(ctx context.Context) (*thresholdAnalysis, error) {
+    since := time.Now().UTC().AddDate(0, 0, -config.Get().Thresholds.AnalysisDays)
+    rows, err := ruleStore.LabeledScores(ctx, since)
+    if err != nil { return nil, err }
+    fpCost := config.Get().Rules.FalsePositiveCost
+    a := &thresholdAnalysis{
+        GeneratedAt:       time.Now().UTC(),
+        Since:             since,
+        Labeled:           len(rows),
+        FalsePositiveCost: fpCost,
+        CurrentThreshold:  config.Get().Rules.FraudThreshold,
+        Recommended:       config.Get().Rules.FraudThreshold,
+    }
+    for _, r := range rows {
+        if r.IsFraud { a.Fraud++ }
+    }
+    best := math.Inf(1)
+    for i := 1; i < int(math.Round(1/thresholdStep)); i++ {
+        t := math.Round(float64(i)*thresholdStep*100) / 100
+        var tp, fp int
+        var missed float64
+        for _, r := range rows {
+            switch {
+            case r.Score > t && r.IsFraud:
+                tp++
+            case r.Score > t:
+                fp++
+            case r.IsFraud:
+                missed += r.Amount
+            }
+        }
+        p := thresholdPoint{Threshold: t, Flagged: tp + fp, ExpectedLoss: missed + float64(fp)*fpCost}
+        if tp+fp > 0 { p.Precision = float64(tp) / float64(tp+fp) }
+        if a.Fraud > 0 { p.Recall = float64(tp) / float64(a.Fraud) }
+        a.Curve = append(a.Curve, p)
+        if len(rows) > 0 && p.ExpectedLoss < best { best, a.Recommended = p.ExpectedLoss, t }
+    }
+    return a, nil
+}
+
+// runThresholdAnalysis repeats the analysis every
+// thresholds.analysis_interval on whichever instance takes the lock, and
+// has every instance pick up the latest result.
+func runThresholdAnalysis() {
+    for {
+        interval := config.Get().Thresholds.AnalysisInterval
+        if interval <= 0 { return }
+        if cacheUp() {
+            ok, err := rdb.SetNX(ctx, thresholdAnalysisLock, 1, interval/2).Result()
+            noteRedisErr(err)
+            if ok {
+                if _, err := publishThresholdAnalysis(); err != nil { log.Printf("threshold analysis failed: %v", err) }
+            }
+            loadThresholdAnalysis()
+        }
+        time.Sleep(interval)
+    }
+}
+
+func publishThresholdAnalysis() (*thresholdAnalysis, error) {
+    // The sweep reads months of labeled history; allow it more than the
+    // per-request query timeout.
+    qctx, cancel := context.WithTimeout(ctx, time.Minute)
+    defer cancel()
+    a, err := analyzeThresholds(qctx)
+    if err != nil { return nil, err }
+    b, _ := json.Marshal(a)
+    if err := rdb.Set(ctx, thresholdAnalysisKey, b, 0).Err(); err != nil { noteRedisErr(err); return nil, err }
+    currentThresholdAnalysis.Store(a)
+    log.Printf("threshold analysis: %d labeled, recommended %.2f", a.Labeled, a.Recommended)
+    return a, nil
+}
+
+func loadThresholdAnalysis() *thresholdAnalysis {
+    b, err := rdb.Get(ctx, thresholdAnalysisKey).Bytes()
+    if err != nil {
+        if !errors.Is(err, redis.Nil) { noteRedisErr(err) }
+        return nil
+    }
+    var a thresholdAnalysis
+    if err := json.Unmarshal(b, &a); err != nil { return nil }
+    currentThresholdAnalysis.Store(&a)
+    return &a
+}
+
+// thresholdAnalysisHandler serves GET /thresholds/analysis, the latest
+// sweep, and POST, which runs one now.
+func thresholdAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+    if !cacheUp() { http.Error(w, "Redis unavailable", http.StatusServiceUnavailable); return }
+    var a *thresholdAnalysis
+    switch r.Method {
+    case http.MethodGet:
+        a = loadThresholdAnalysis()
+        if a == nil { http.Error(w, "No analysis yet", http.StatusNotFound); return }
+    case http.MethodPost:
+        var err error
+        if a, err = publishThresholdAnalysis(); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    writeJSON(w, http.StatusOK, a)
+}
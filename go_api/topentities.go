@@ -0,0 +1,107 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log"
+    "net/http"
+    "net/url"
+    "strconv"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// rankedEntity is one row of GET /stats/top.
+type rankedEntity struct {
+    Rank         int     `json:"rank"`
+    EntityID     string  `json:"entity_id"`
+    Transactions int64   `json:"transactions"`
+    Amount       float64 `json:"amount"`
+    Fraud        int64   `json:"fraud"`
+    FraudAmount  float64 `json:"fraud_amount"`
+    FraudRate    float64 `json:"fraud_rate"`
+    Declined     int64   `json:"declined"`
+}
+
+// topEntitiesHandler serves
+// GET /stats/top?entity=merchant&metric=fraud_rate&from=2024-04-29&to=2024-05-05&limit=20
+// from the daily rollups, over the days statsRange reads.
+func topEntitiesHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    entity := q.Get("entity")
+    if entity == "" { entity = "merchant" }
+    if entity != "merchant" && entity != "user" && entity != "device" { http.Error(w, "entity must be merchant, user or device", http.StatusBadRequest); return }
+    metric := q.Get("metric")
+    if metric == "" { metric = store.MetricFraudRate }
+    if metric != store.MetricFraudRate && metric != store.MetricVolume { http.Error(w, "metric must be fraud_rate or volume", http.StatusBadRequest); return }
+    limit := 20
+    if v := q.Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > 100 { http.Error(w, "limit must be 1-100", http.StatusBadRequest); return }
+        limit = n
+    }
+    from, to, err := statsRange(q)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    minTx := int64(1)
+    if metric == store.MetricFraudRate { minTx = int64(config.Get().Stats.MinTransactions) }
+
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := statsStore.TopEntities(qctx, entity, metric, from, to.AddDate(0, 0, 1), minTx, limit)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    out := make([]rankedEntity, len(rows))
+    for i, e := range rows {
+        out[i] = rankedEntity{Rank: i + 1, EntityID: e.EntityID, Transactions: e.Transactions, Amount: e.Amount, Fraud: e.Fraud,
+            FraudAmount: e.FraudAmount, FraudRate: ratio(e.Fraud, e.Transactions), Declined: e.Declined}
+    }
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "entity": entity,
+        "metric": metric,
+        "from": from.Format("2006-01-02"),
+        "to": to.Format("2006-01-02"),
+        "min_transactions": minTx,
+        "entities": out,
+    })
+}
+
+// statsRange reads the from and to query parameters, UTC days that are both
+// included, defaulting to the last seven days up to today.
+func statsRange(q url.Values) (from, to time.Time, err error) {
+    to = time.Now().UTC().Truncate(24 * time.Hour)
+    if v := q.Get("to"); v != "" {
+        if to, err = time.Parse("2006-01-02", v); err != nil { return from, to, errors.New("to must be a date, YYYY-MM-DD") }
+    }
+    from = to.AddDate(0, 0, -6)
+    if v := q.Get("from"); v != "" {
+        if from, err = time.Parse("2006-01-02", v); err != nil { return from, to, errors.New("from must be a date, YYYY-MM-DD") }
+    }
+    if from.After(to) || to.Sub(from) > 366*24*time.Hour { return from, to, errors.New("from must be on or before to, at most 366 days apart") }
+    return from, to, nil
+}
+
+// runRollups recomputes the last stats.rollup_lookback_days days of entity
+// rollups, today included, every stats.rollup_interval. A Redis key lets one
+// instance run per interval; without Redis every instance runs, and the
+// store serializes them.
+func runRollups() {
+    for {
+        cfg := config.Get().Stats
+        run := true
+        if cacheUp() {
+            ok, err := rdb.SetNX(ctx, "stats:rollup", time.Now().Unix(), cfg.RollupInterval).Result()
+            noteRedisErr(err)
+            run = ok || err != nil
+        }
+        if run {
+            to := time.Now().UTC().Truncate(24 * time.Hour).AddDate(0, 0, 1)
+            rctx, cancel := context.WithTimeout(ctx, cfg.RollupInterval)
+            if err := statsStore.RollUp(rctx, to.AddDate(0, 0, -cfg.RollupLookbackDays), to); err != nil { log.Printf("entity rollup failed: %v", err) }
+            cancel()
+        }
+        time.Sleep(cfg.RollupInterval)
+    }
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+var transactionStatuses = map[string]bool{
+    store.TxScored: true, store.TxReviewed: true, store.TxApproved: true, store.TxDeclined: true, store.TxChargedBack: true,
+}
+
+// Where each status may be reached from. A review only picks up a
+// transaction nobody has looked at; reviews, step-up checks and labels may
+// settle it and settle it again, but never undo a chargeback. Only a
+// chargeback label does that (see labelHandler).
+var (
+    reviewableStatuses = []string{store.TxScored}
+    settleableStatuses = []string{store.TxScored, store.TxReviewed, store.TxApproved, store.TxDeclined}
+)
+
+// setTransactionStatus moves the transaction to status if it is in one of
+// the statuses in only. The change that prompted it has already been made,
+// so a failure is logged rather than returned.
+func setTransactionStatus(rctx context.Context, id, status string, only []string, actor, reason string) {
+    from, to := transactionTimeWindow(id)
+    qctx, cancel := conn.QueryCtx(rctx)
+    defer cancel()
+    moved, err := txStore.SetStatus(qctx, id, from, to, status, only, actor, reason)
+    if err != nil && !errors.Is(err, store.ErrNotFound) { log.Printf("transaction %s status not set to %s: %v", id, status, err); return }
+    if moved { transactionStatusChanges.WithLabelValues(status, reason).Inc() }
+}
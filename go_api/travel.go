@@ -0,0 +1,126 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/conn"
+)
+
+// maxTravelNotice bounds a single notice; longer stays should be renewed.
+const maxTravelNotice = 365 * 24 * time.Hour
+
+// travelCacheTTL bounds how stale cached notices can be; the handlers drop
+// the cache on every change anyway.
+const travelCacheTTL = 5 * time.Minute
+
+// validCountry reports an ISO 3166 alpha-2 code, upper case.
+func validCountry(c string) bool {
+    return len(c) == 2 && c[0] >= 'A' && c[0] <= 'Z' && c[1] >= 'A' && c[1] <= 'Z'
+}
+
+// travelEnricher sets TravelNotice when the user registered travel to the
+// transaction's country covering now.
+type travelEnricher struct{}
+
+func (travelEnricher) Name() string { return "travel" }
+
+func (travelEnricher) Enrich(ctx context.Context, req TransactionRequest, f *features) {
+    if req.Country == nil { return }
+    now := time.Now()
+    for _, n := range travelNotices(ctx, req.UserID) {
+        if now.Before(n.StartsAt) || !now.Before(n.EndsAt) { continue }
+        for _, c := range n.Countries {
+            if c == *req.Country { f.TravelNotice = true; return }
+        }
+    }
+}
+
+// travelNotices returns the user's current and upcoming notices from
+// travel_notices:<id>, falling back to Postgres; nil if they can't be read.
+func travelNotices(ctx context.Context, userID string) []store.TravelNotice {
+    key := "travel_notices:" + userID
+    if cacheUp() {
+        v, err := rdb.Get(ctx, key).Bytes()
+        if err == nil {
+            var ns []store.TravelNotice
+            if json.Unmarshal(v, &ns) == nil { return ns }
+        }
+        noteRedisErr(err)
+    }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    ns, err := travelStore.TravelNotices(qctx, userID, time.Now().UTC())
+    if err != nil { return nil }
+    if cacheUp() {
+        b, _ := json.Marshal(ns)
+        noteRedisErr(rdb.Set(ctx, key, b, travelCacheTTL).Err())
+    }
+    return ns
+}
+
+// travelNoticesHandler serves /users/{id}/travel-notices: GET lists current
+// and upcoming notices, POST registers one and DELETE
+// /users/{id}/travel-notices/{notice_id} cancels one.
+func travelNoticesHandler(w http.ResponseWriter, r *http.Request) {
+    userID, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/users/"), "/travel-notices")
+    rest = strings.TrimPrefix(rest, "/")
+    switch {
+    case r.Method == http.MethodGet && rest == "":
+        qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+        defer cancel()
+        ns, err := travelStore.TravelNotices(qctx, userID, time.Now().UTC())
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if ns == nil { ns = []store.TravelNotice{} }
+        writeJSONConditional(w, r, map[string]interface{}{"user_id": userID, "travel_notices": ns}, time.Time{})
+    case r.Method == http.MethodPost && rest == "":
+        var n store.TravelNotice
+        if !decodeBody(w, r, &n, bodyLimit()) { return }
+        n.UserID = userID
+        if err := validateTravelNotice(&n); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+        if err := ensureUserExists(r.Context(), userID, nil); err != nil { http.Error(w, "Failed to prepare user", http.StatusInternalServerError); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        n, err := travelStore.AddTravelNotice(qctx, n)
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        dropTravelCache(userID)
+        writeJSON(w, http.StatusCreated, n)
+    case r.Method == http.MethodDelete && rest != "":
+        id, err := strconv.ParseInt(rest, 10, 64)
+        if err != nil { http.Error(w, "invalid notice id", http.StatusBadRequest); return }
+        qctx, cancel := conn.QueryCtx(r.Context())
+        defer cancel()
+        err = travelStore.DeleteTravelNotice(qctx, userID, id)
+        if errors.Is(err, store.ErrNotFound) { http.Error(w, "Travel notice not found", http.StatusNotFound); return }
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        dropTravelCache(userID)
+        w.WriteHeader(http.StatusNoContent)
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
+
+func validateTravelNotice(n *store.TravelNotice) error {
+    if n.StartsAt.IsZero() || n.EndsAt.IsZero() { return errors.New("starts_at and ends_at are required") }
+    if !n.EndsAt.After(n.StartsAt) { return errors.New("ends_at must be after starts_at") }
+    if !n.EndsAt.After(time.Now()) { return errors.New("ends_at is in the past") }
+    if n.EndsAt.Sub(n.StartsAt) > maxTravelNotice { return errors.New("travel notices can cover at most a year") }
+    if len(n.Countries) == 0 { return errors.New("countries is required") }
+    for i, c := range n.Countries {
+        c = strings.ToUpper(c)
+        if !validCountry(c) { return errors.New("countries must be ISO 3166 alpha-2 codes") }
+        n.Countries[i] = c
+    }
+    n.StartsAt, n.EndsAt = n.StartsAt.UTC(), n.EndsAt.UTC()
+    return nil
+}
+
+func dropTravelCache(userID string) {
+    if cacheUp() { noteRedisErr(rdb.Del(ctx, "travel_notices:"+userID).Err()) }
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/csv"
+    "log"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// anonymousKey is what requests without a configured X-API-Key are metered
+// under.
+const anonymousKey = "anonymous"
+
+type usageKey struct {
+    day    time.Time
+    apiKey string
+}
+
+// apiCaller is who withUsage found a request came from: the name its API
+// key is metered under, and the key's fingerprint for the caller checks.
+// Requests without a configured key are anonymousKey in both.
+type apiCaller struct {
+    name        string
+    fingerprint string
+}
+
+type apiCallerCtxKey struct{}
+
+// Usage counted since the last flush to api_usage_daily.
+var (
+    usageMu     sync.Mutex
+    usageCounts = map[usageKey]*store.APIKeyUsage{}
+)
+
+// withUsage meters each request for GET /admin/usage and the billing
+// export under the api_keys name of its X-API-Key. Requests with no key or
+// one that isn't configured are metered as anonymous, so neither the keys
+// nor made-up values reach the table. /health and /metrics are not metered.
+func withUsage(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/health" || r.URL.Path == "/metrics" { next.ServeHTTP(w, r); return }
+        c := apiCaller{name: anonymousKey, fingerprint: anonymousKey}
+        if name := apiKeyName(r); name != "" { c = apiCaller{name: name, fingerprint: apiKeyFingerprint(r.Header.Get("X-API-Key"))} }
+        meterUsage(c.name, 1, 0)
+        observeCaller(r, c.fingerprint)
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiCallerCtxKey{}, c)))
+    })
+}
+
+// meterScored counts a scored transaction against the API key of the
+// request rctx belongs to.
+func meterScored(rctx context.Context) {
+    if c, ok := rctx.Value(apiCallerCtxKey{}).(apiCaller); ok { meterUsage(c.name, 0, 1) }
+}
+
+func meterUsage(apiKey string, requests, transactions int64) {
+    addUsage(store.APIKeyUsage{Day: time.Now().UTC().Truncate(24 * time.Hour), APIKey: apiKey, Requests: requests, Transactions: transactions})
+}
+
+// addUsage adds u to the counts for the next flush. Past usage.max_keys
+// distinct keys and days, a new one is counted as anonymous instead, so a
+// flush never grows beyond that many rows.
+func addUsage(u store.APIKeyUsage) {
+    k := usageKey{u.Day, u.APIKey}
+    usageMu.Lock()
+    defer usageMu.Unlock()
+    if usageCounts[k] == nil && len(usageCounts) >= config.Get().Usage.MaxKeys {
+        k.apiKey, u.APIKey = anonymousKey, anonymousKey
+    }
+    if cur := usageCounts[k]; cur != nil {
+        cur.Requests += u.Requests
+        cur.Transactions += u.Transactions
+        return
+    }
+    usageCounts[k] = &u
+}
+
+// runUsageFlusher writes metered usage to Postgres every
+// usage.flush_interval.
+func runUsageFlusher() {
+    for {
+        time.Sleep(config.Get().Usage.FlushInterval)
+        flushUsage()
+    }
+}
+
+// flushUsage adds the usage counted since the last flush to
+// api_usage_daily. Counts that fail to write are kept for the next flush.
+func flushUsage() {
+    usageMu.Lock()
+    pending := usageCounts
+    usageCounts = map[usageKey]*store.APIKeyUsage{}
+    usageMu.Unlock()
+    if len(pending) == 0 { return }
+    batch := make([]store.APIKeyUsage, 0, len(pending))
+    for _, u := range pending { batch = append(batch, *u) }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    if err := usageStore.AddUsage(qctx, batch); err != nil {
+        log.Printf("usage flush failed, will retry: %v", err)
+        for _, u := range batch { addUsage(u) }
+    }
+}
+
+// usageDay is one row of GET /admin/usage.
+type usageDay struct {
+    Day          string `json:"day"`
+    APIKey       string `json:"api_key"`
+    Requests     int64  `json:"requests"`
+    Transactions int64  `json:"transactions"`
+}
+
+// usageHandler serves
+// GET /admin/usage?api_key=team-a&from=2024-05-01&to=2024-05-07, each key's
+// requests and scored transactions per day over the days statsRange reads,
+// for every key when api_key is unset. Today's counts lag by up to
+// usage.flush_interval.
+func usageHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    q := r.URL.Query()
+    from, to, err := statsRange(q)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := usageStore.Usage(qctx, q.Get("api_key"), from, to.AddDate(0, 0, 1))
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    out := make([]usageDay, len(rows))
+    for i, u := range rows { out[i] = usageDay{u.Day.Format("2006-01-02"), u.APIKey, u.Requests, u.Transactions} }
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "from": from.Format("2006-01-02"),
+        "to": to.Format("2006-01-02"),
+        "usage": out,
+    })
+}
+
+// usageExportHandler serves GET /admin/usage/export?month=2024-05, a CSV of
+// each key's requests and scored transactions in that UTC month, busiest
+// first, for billing and chargeback. month defaults to the last full month.
+func usageExportHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    now := time.Now().UTC()
+    month := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+    if v := r.URL.Query().Get("month"); v != "" {
+        m, err := time.Parse("2006-01", v)
+        if err != nil { http.Error(w, "month must be YYYY-MM", http.StatusBadRequest); return }
+        month = m
+    }
+    qctx, cancel := conn.QueryCtx(store.ReadOnly(r.Context()))
+    defer cancel()
+    rows, err := usageStore.UsageByKey(qctx, month, month.AddDate(0, 1, 0))
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+
+    var buf bytes.Buffer
+    cw := csv.NewWriter(&buf)
+    cw.Write([]string{"month", "api_key", "requests", "transactions"})
+    for _, u := range rows {
+        cw.Write([]string{month.Format("2006-01"), u.APIKey, strconv.FormatInt(u.Requests, 10), strconv.FormatInt(u.Transactions, 10)})
+    }
+    cw.Flush()
+    w.Header().Set("Content-Type", "text/csv")
+    w.Header().Set("Content-Disposition", `attachment; filename="usage-`+month.Format("2006-01")+`.csv"`)
+    w.Write(buf.Bytes())
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "strconv"
+
+    "golang.org/x/sync/singleflight"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// The processor keeps user_risk:<id> current after every scored transaction;
+// the API only fills it on a miss.
+var riskLoads singleflight.Group
+
+// userRiskMiss marks a user with no row yet, so repeated lookups for a new
+// user don't each reach Postgres.
+const userRiskMiss = "none"
+
+// UserRisk reads the user's risk from Redis, falling back to Postgres.
+// Concurrent misses for the same user share one query. That query runs
+// detached from any single request so one caller giving up doesn't fail the
+// others waiting on it; ctx only bounds how long this caller waits.
+func (liveSource) UserRisk(ctx context.Context, userID string) (float64, bool) {
+    key := "user_risk:" + userID
+    if cacheUp() {
+        v, err := rdb.Get(ctx, key).Result()
+        if err == nil {
+            if v == userRiskMiss { return 0, false }
+            if risk, err := strconv.ParseFloat(v, 64); err == nil { return risk, true }
+        }
+        noteRedisErr(err)
+    }
+    ch := riskLoads.DoChan(userID, func() (interface{}, error) {
+        qctx, cancel := conn.QueryCtx(context.Background())
+        defer cancel()
+        risk, err := userStore.RiskScore(qctx, userID)
+        if errors.Is(err, store.ErrNotFound) && cacheUp() { noteRedisErr(rdb.SetNX(qctx, key, userRiskMiss, config.Get().API.UserRiskNegativeTTL).Err()) }
+        if err != nil { return nil, err }
+        // SETNX so a fresher value written by the processor isn't clobbered.
+        if cacheUp() { noteRedisErr(rdb.SetNX(qctx, key, risk, config.Get().API.UserRiskCacheTTL).Err()) }
+        return risk, nil
+    })
+    select {
+    case res := <-ch:
+        if res.Err != nil { return 0, false }
+        return res.Val.(float64), true
+    case <-ctx.Done():
+        return 0, false
+    }
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "io"
+    "net/http"
+    "time"
+
+    "example.com/fraud/go_api/internal/store"
+    "example.com/fraud/go_api/internal/webhook"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+const (
+    verificationSuccess = "success"
+    verificationFailure = "failure"
+)
+
+var verificationMethods = map[string]bool{"otp": true, "3ds": true}
+
+// verificationHandler serves POST /transactions/{id}/verification, where the
+// auth system reports the outcome of an OTP or 3-D Secure challenge:
+// {"method": "otp"|"3ds", "result": "success"|"failure"}. Success approves a
+// transaction held for review. Failure declines it, escalates its open
+// alerts to CRITICAL (or opens a VERIFICATION_FAILED alert) and raises the
+// user's risk score by rules.verification_failure_risk. A transaction takes
+// one report; later ones get 409. Reports must be signed with
+// webhooks.verification_secret; see verifyCallback.
+func verificationHandler(w http.ResponseWriter, r *http.Request, id string) {
+    if !verifyCallback(w, r) { return }
+    var body struct {
+        Method string `json:"method"`
+        Result string `json:"result"`
+    }
+    if !decodeBody(w, r, &body, bodyLimit()) { return }
+    if !verificationMethods[body.Method] { http.Error(w, "method must be otp or 3ds", http.StatusBadRequest); return }
+    if body.Result != verificationSuccess && body.Result != verificationFailure { http.Error(w, "result must be success or failure", http.StatusBadRequest); return }
+
+    from, to := transactionTimeWindow(id)
+    qctx, cancel := conn.QueryCtx(r.Context())
+    t, err := txStore.Get(qctx, id, from, to)
+    cancel()
+    if errors.Is(err, store.ErrNotFound) { http.Error(w, "Transaction not found", http.StatusNotFound); return }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if t.VerificationResult != nil { http.Error(w, "Verification already recorded", http.StatusConflict); return }
+
+    previous := ""
+    if t.Decision != nil { previous = *t.Decision }
+    decision := previous
+    if previous == decisionReview {
+        decision = decisionApprove
+        if body.Result == verificationFailure { decision = decisionDecline }
+    }
+    qctx, cancel = conn.QueryCtx(r.Context())
+    defer cancel()
+    err = txStore.RecordVerification(qctx, id, from, to, body.Method, body.Result, decision)
+    if errors.Is(err, store.ErrNotFound) { http.Error(w, "Verification already recorded", http.StatusConflict); return }
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if previous == decisionReview {
+        status := store.TxApproved
+        if decision == decisionDecline { status = store.TxDeclined }
+        setTransactionStatus(r.Context(), id, status, settleableStatuses, "system", "verification")
+    }
+
+    resp := map[string]interface{}{
+        "transaction_id": id,
+        "result": body.Result,
+        "previous_decision": previous,
+        "decision": decision,
+    }
+    if body.Result == verificationFailure {
+        if err := alertStore.Escalate(qctx, store.Alert{
+            TransactionID: id,
+            AlertType:     "VERIFICATION_FAILED",
+            Severity:      "HIGH",
+            Description:   "Step-up verification (" + body.Method + ") failed for transaction " + id,
+            Confidence:    1,
+            Status:        "OPEN",
+        }, t.Timestamp); err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        risk, err := userStore.AdjustRisk(qctx, t.UserID, config.Get().Rules.VerificationFailureRisk)
+        if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+        if cacheUp() { noteRedisErr(rdb.Set(ctx, "user_risk:"+t.UserID, risk, config.Get().API.UserRiskCacheTTL).Err()) }
+        resp["user_risk_score"] = risk
+    }
+    writeJSON(w, http.StatusOK, resp)
+}
+
+// verifyCallback reads the body and checks its X-Fraud-Signature
+// against webhooks.verification_secret, answering 404 when no secret is
+// configured and 401 for a missing or wrong signature. On success the body
+// is put back for the handler to decode.
+func verifyCallback(w http.ResponseWriter, r *http.Request) bool {
+    cfg := config.Get().Webhooks
+    if cfg.VerificationSecret == "" { http.NotFound(w, r); return false }
+    body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, bodyLimit()))
+    if err != nil { bodyError(w, err); return false }
+    if err := (webhook.Callback{Secret: cfg.VerificationSecret, Tolerance: cfg.Tolerance}).Verify(r.Header, body, time.Now()); err != nil {
+        http.Error(w, "invalid signature", http.StatusUnauthorized)
+        return false
+    }
+    r.Body = io.NopCloser(bytes.NewReader(body))
+    return true
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "example.com/fraud/go_api/internal/warehouse"
+    "example.com/fraud/internal/config"
+)
+
+const warehouseExportLock = "warehouse_export:lock"
+
+// runWarehouseExport exports to the configured warehouse every
+// warehouse.interval on whichever instance takes the lock. The export is
+// idempotent, so an overlapping run only repeats work.
+func runWarehouseExport() {
+    cfg := config.Get().Warehouse
+    wh, err := warehouse.New(cfg)
+    if err != nil { log.Printf("warehouse export disabled: %v", err); return }
+    if wh == nil { return }
+    for {
+        cfg := config.Get().Warehouse
+        if cfg.Interval <= 0 { return }
+        if cacheUp() {
+            ok, err := rdb.SetNX(ctx, warehouseExportLock, 1, cfg.Interval/2).Result()
+            noteRedisErr(err)
+            if ok { exportToWarehouse(wh, cfg) }
+        }
+        time.Sleep(cfg.Interval)
+    }
+}
+
+func exportToWarehouse(wh warehouse.Warehouse, cfg config.Warehouse) {
+    ectx, cancel := context.WithTimeout(ctx, cfg.Interval)
+    defer cancel()
+    loaded, err := warehouse.Export(ectx, exportStore, wh, cfg.BatchSize, cfg.Lag)
+    for table, n := range loaded {
+        warehouseExported.WithLabelValues(table).Add(float64(n))
+        log.Printf("warehouse export to %s: %d %s rows", wh.Name(), n, table)
+    }
+    if err != nil { log.Printf("warehouse export to %s failed: %v", wh.Name(), err); return }
+    warehouseLastSuccess.SetToCurrentTime()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "io"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/go_api/internal/webhook"
+    "example.com/fraud/internal/config"
+)
+
+// webhookEventTTL is how long a provider's event ID is remembered; providers
+// stop retrying well before.
+const webhookEventTTL = 72 * time.Hour
+
+const maxWebhookBytes = 1 << 20
+
+type webhookJob struct {
+    provider string
+    payment  webhook.Payment
+}
+
+var (
+    webhookQueue chan webhookJob
+
+    webhookEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_api_webhook_events_total",
+        Help: "Webhook requests by provider and outcome (queued, ignored, duplicate, rejected, full).",
+    }, []string{"provider", "outcome"})
+)
+
+// webhookProvider returns the configured provider for name, or nil when it
+// is unknown or has no secret.
+func webhookProvider(name string) webhook.Provider {
+    cfg := config.Get().Webhooks
+    switch name {
+    case "stripe":
+        if cfg.StripeSecret != "" { return webhook.Stripe{Secret: cfg.StripeSecret, Tolerance: cfg.Tolerance} }
+    case "adyen":
+        if cfg.AdyenHMACKey != "" { return webhook.Adyen{HMACKey: cfg.AdyenHMACKey} }
+    }
+    return nil
+}
+
+// webhookHandler serves POST /webhooks/{provider}. It verifies the
+// signature, queues the payments the event describes and acknowledges at
+// once; scoring happens in runWebhookWorkers. Redelivered events are
+// recognised by their event ID and acknowledged without scoring again.
+func webhookHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost { http.Error(w, "method not allowed", http.StatusMethodNotAllowed); return }
+    name := strings.TrimPrefix(r.URL.Path, "/webhooks/")
+    p := webhookProvider(name)
+    if p == nil { http.NotFound(w, r); return }
+    body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+    if err != nil { bodyError(w, err); return }
+    if err := p.Verify(r.Header, body, time.Now()); err != nil {
+        webhookEvents.WithLabelValues(name, "rejected").Inc()
+        if !errors.Is(err, webhook.ErrSignature) { log.Printf("webhook %s: %v", name, err) }
+        http.Error(w, "invalid signature", http.StatusUnauthorized)
+        return
+    }
+    payments, err := p.Payments(body)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if len(payments) == 0 { webhookEvents.WithLabelValues(name, "ignored").Inc() }
+    for _, pay := range payments {
+        if !firstDelivery(r.Context(), name, pay.EventID) {
+            webhookEvents.WithLabelValues(name, "duplicate").Inc()
+            continue
+        }
+        select {
+        case webhookQueue <- webhookJob{provider: name, payment: pay}:
+            webhookEvents.WithLabelValues(name, "queued").Inc()
+        default:
+            // Forget the event so the provider's retry is accepted.
+            if cacheUp() { noteRedisErr(rdb.Del(ctx, webhookEventKey(name, pay.EventID)).Err()) }
+            webhookEvents.WithLabelValues(name, "full").Inc()
+            http.Error(w, "scoring queue full", http.StatusServiceUnavailable)
+            return
+        }
+    }
+    // Adyen requires this exact body; Stripe only looks at the status.
+    if name == "adyen" { w.Write([]byte("[accepted]")); return }
+    w.WriteHeader(http.StatusOK)
+}
+
+func webhookEventKey(provider, eventID string) string { return "webhook_event:" + provider + ":" + eventID }
+
+// firstDelivery records the event and reports whether it is new. While
+// Redis is unavailable every delivery counts as new.
+func firstDelivery(rctx context.Context, provider, eventID string) bool {
+    if eventID == "" || !cacheUp() { return true }
+    ok, err := rdb.SetNX(rctx, webhookEventKey(provider, eventID), 1, webhookEventTTL).Result()
+    if err != nil { noteRedisErr(err); return true }
+    return ok
+}
+
+// runWebhookWorkers scores queued webhook payments like POST
+// /transactions/process would, as bulk work.
+func runWebhookWorkers() {
+    cfg := config.Get().Webhooks
+    webhookQueue = make(chan webhookJob, cfg.Queue)
+    for i := 0; i < cfg.Workers; i++ {
+        go func() {
+            for job := range webhookQueue {
+                p := job.payment
+                req := TransactionRequest{UserID: p.UserID, Amount: p.Amount, MerchantID: p.MerchantID, Channel: p.Channel}
+                if p.CardFingerprint != "" { req.CardFingerprint = &p.CardFingerprint }
+                if _, err := scoreBulk(ctx, req, ""); err != nil {
+                    log.Printf("webhook %s event %s: %v", job.provider, p.EventID, err)
+                }
+            }
+        }()
+    }
+}
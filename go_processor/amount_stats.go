@@ -0,0 +1,43 @@
+package main
+
+import (
+    "time"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+// amountStatsTTL drops the profile of a user who stopped transacting.
+const amountStatsTTL = 90 * 24 * time.Hour
+
+// amountStatsScript applies Welford's update to the hash KEYS[1] (fields n,
+// mean and m2; the variance is m2/(n-1)) for amount ARGV[1]. Once n reaches
+// the window ARGV[2], the old state is first scaled down to window-1
+// samples, so the profile keeps following a user whose spending changes.
+// Running it in Redis keeps concurrent workers from interleaving updates.
+var amountStatsScript = redis.NewScript(`
+local s = redis.call('HMGET', KEYS[1], 'n', 'mean', 'm2')
+local n, mean, m2 = tonumber(s[1]) or 0, tonumber(s[2]) or 0, tonumber(s[3]) or 0
+local x, window = tonumber(ARGV[1]), tonumber(ARGV[2])
+if n >= window then
+  m2 = m2 * (window - 1) / n
+  n = window - 1
+end
+n = n + 1
+local d = x - mean
+mean = mean + d / n
+m2 = m2 + d * (x - mean)
+redis.call('HSET', KEYS[1], 'n', n, 'mean', tostring(mean), 'm2', tostring(m2))
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return n
+`)
+
+// updateAmountStats folds tx's amount into the user's profile, which the
+// API reads for its amount_zscore feature. Callers must apply each
+// transaction once; a replayed message would count twice.
+func updateAmountStats(pipe redis.Pipeliner, tx events.TransactionEvent) {
+    amountStatsScript.Eval(ctx, pipe, []string{"user_amount_stats:" + tx.UserID},
+        tx.Amount, config.Get().Processor.AmountStatsWindow, amountStatsTTL.Milliseconds())
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+    "bytes"
+    "compress/gzip"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "sync/atomic"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/s3"
+)
+
+var (
+    messagesArchived = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_processor_archived_messages_total",
+        Help: "Consumed messages written to the S3 archive.",
+    })
+    archiveDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_archive_dropped_total",
+        Help: "Consumed messages missing from the S3 archive, by reason (queue_full or upload_failed).",
+    }, []string{"reason"})
+)
+
+// archiveRecord is one line of an archive object: the message exactly as
+// consumed, with key and value base64-encoded.
+type archiveRecord struct {
+    Topic       string    `json:"topic"`
+    Partition   int       `json:"partition"`
+    Offset      int64     `json:"offset"`
+    ID          string    `json:"id,omitempty"`
+    ContentType string    `json:"content_type,omitempty"`
+    Key         []byte    `json:"key"`
+    Value       []byte    `json:"value"`
+    Timestamp   time.Time `json:"timestamp"`
+    ReceivedAt  time.Time `json:"received_at"`
+}
+
+// archivePart describes one object in a manifest. Offsets are the first
+// and last of each Kafka partition; Redis stream entries have IDs instead.
+type archivePart struct {
+    Key       string           `json:"key"`
+    Records   int              `json:"records"`
+    Bytes     int              `json:"bytes"`
+    SHA256    string           `json:"sha256"`
+    Offsets   map[int][2]int64 `json:"offsets,omitempty"`
+    FirstID   string           `json:"first_id,omitempty"`
+    LastID    string           `json:"last_id,omitempty"`
+    FirstTime time.Time        `json:"first_timestamp"`
+    LastTime  time.Time        `json:"last_timestamp"`
+}
+
+// archiveManifest is written once an hour is over and lists the parts this
+// instance wrote for it. Dropped counts the messages received in the hour
+// that are in none of them.
+type archiveManifest struct {
+    Topic       string        `json:"topic"`
+    Hour        time.Time     `json:"hour"`
+    Instance    string        `json:"instance"`
+    Records     int           `json:"records"`
+    Dropped     int64         `json:"dropped"`
+    Parts       []archivePart `json:"parts"`
+    CompletedAt time.Time     `json:"completed_at"`
+}
+
+// archiver writes consumed messages to S3 under
+// <prefix><topic>/dt=YYYY-MM-DD/hour=HH/, by the UTC hour they were
+// received: parts named <instance>-NNNNN.jsonl.gz and, when the hour is
+// over, manifest-<instance>.json. The instance name includes the start
+// time, so no object is ever overwritten.
+type archiver struct {
+    client   *s3.Client
+    prefix   string
+    topic    string
+    instance string
+    queue    chan archiveRecord
+    dropped  atomic.Int64
+
+    hour  time.Time
+    parts []archivePart
+    seq   int
+
+    // The open part.
+    buf    bytes.Buffer
+    gz     *gzip.Writer
+    raw    int
+    opened time.Time
+    part   archivePart
+}
+
+// archive is nil unless archive.bucket is set.
+var archive *archiver
+
+func initArchive(topic string) {
+    cfg := config.Get().Archive
+    if cfg.Bucket == "" { return }
+    host, _ := os.Hostname()
+    a := &archiver{
+        client:   s3.NewClient(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken),
+        prefix:   cfg.Prefix,
+        topic:    topic,
+        instance: fmt.Sprintf("%s-%d", host, time.Now().Unix()),
+        queue:    make(chan archiveRecord, cfg.QueueSize),
+    }
+    archive = a
+    go a.run()
+}
+
+// archiveMessage queues m for the archive. Like the other sinks it never
+// blocks processing; messages it can't keep are counted as dropped.
+func archiveMessage(m busMessage) {
+    if archive == nil { return }
+    rec := archiveRecord{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, ID: m.ID, ContentType: m.ContentType, Key: m.Key, Value: m.Value, Timestamp: m.Time.UTC(), ReceivedAt: time.Now().UTC()}
+    select {
+    case archive.queue <- rec:
+    default:
+        archive.dropped.Add(1)
+        archiveDropped.WithLabelValues("queue_full").Inc()
+    }
+}
+
+func (a *archiver) run() {
+    t := time.NewTicker(10 * time.Second)
+    defer t.Stop()
+    for {
+        select {
+        case rec := <-a.queue:
+            if hour := rec.ReceivedAt.Truncate(time.Hour); !hour.Equal(a.hour) {
+                a.closeHour()
+                a.hour = hour
+            }
+            a.add(rec)
+            if a.raw >= config.Get().Archive.MaxObjectBytes { a.flushPart() }
+        case now := <-t.C:
+            if a.gz != nil && now.Sub(a.opened) >= config.Get().Archive.FlushInterval { a.flushPart() }
+            if !a.hour.IsZero() && now.UTC().Truncate(time.Hour).After(a.hour) {
+                a.closeHour()
+                a.hour = time.Time{}
+            }
+        }
+    }
+}
+
+func (a *archiver) add(rec archiveRecord) {
+    if a.gz == nil {
+        a.buf.Reset()
+        a.gz = gzip.NewWriter(&a.buf)
+        a.raw, a.opened = 0, time.Now()
+        a.part = archivePart{FirstTime: rec.Timestamp}
+    }
+    line, _ := json.Marshal(rec)
+    line = append(line, '\n')
+    a.gz.Write(line)
+    a.raw += len(line)
+
+    p := &a.part
+    p.Records++
+    p.LastTime = rec.Timestamp
+    if rec.ID != "" {
+        if p.FirstID == "" { p.FirstID = rec.ID }
+        p.LastID = rec.ID
+    } else {
+        if p.Offsets == nil { p.Offsets = map[int][2]int64{} }
+        r, ok := p.Offsets[rec.Partition]
+        if !ok { r = [2]int64{rec.Offset, rec.Offset} }
+        if rec.Offset < r[0] { r[0] = rec.Offset }
+        if rec.Offset > r[1] { r[1] = rec.Offset }
+        p.Offsets[rec.Partition] = r
+    }
+}
+
+// flushPart uploads the open part. Uploads are retried for a while; a part
+// that still can't be written is dropped, and its hour's manifest says so.
+func (a *archiver) flushPart() {
+    if a.gz == nil { return }
+    a.gz.Close()
+    a.gz = nil
+    a.seq++
+    body := a.buf.Bytes()
+    sum := sha256.Sum256(body)
+    a.part.Key = fmt.Sprintf("%s%s-%05d.jsonl.gz", a.hourPrefix(), a.instance, a.seq)
+    a.part.Bytes = len(body)
+    a.part.SHA256 = hex.EncodeToString(sum[:])
+    if err := a.put(a.part.Key, "application/gzip", body); err != nil {
+        log.Printf("archive upload of %d messages failed: %v", a.part.Records, err)
+        a.dropped.Add(int64(a.part.Records))
+        archiveDropped.WithLabelValues("upload_failed").Add(float64(a.part.Records))
+        return
+    }
+    messagesArchived.Add(float64(a.part.Records))
+    a.parts = append(a.parts, a.part)
+}
+
+// closeHour flushes the open part and writes the hour's manifest.
+func (a *archiver) closeHour() {
+    if a.hour.IsZero() { return }
+    a.flushPart()
+    m := archiveManifest{Topic: a.topic, Hour: a.hour, Instance: a.instance, Dropped: a.dropped.Swap(0), Parts: a.parts, CompletedAt: time.Now().UTC()}
+    if m.Parts == nil { m.Parts = []archivePart{} }
+    for _, p := range a.parts { m.Records += p.Records }
+    a.parts = nil
+    b, _ := json.MarshalIndent(m, "", "  ")
+    if err := a.put(a.hourPrefix()+"manifest-"+a.instance+".json", "application/json", b); err != nil {
+        log.Printf("archive manifest for %s failed: %v", a.hour.Format(time.RFC3339), err)
+    }
+}
+
+func (a *archiver) hourPrefix() string {
+    return a.prefix + a.topic + "/dt=" + a.hour.Format("2006-01-02") + "/hour=" + a.hour.Format("15") + "/"
+}
+
+func (a *archiver) put(key, contentType string, body []byte) error {
+    var err error
+    for attempt := 0; attempt < 5; attempt++ {
+        if attempt > 0 { time.Sleep(time.Duration(1<<attempt) * time.Second) }
+        pctx, cancel := context.WithTimeout(ctx, time.Minute)
+        err = a.client.Put(pctx, key, contentType, body)
+        cancel()
+        if err == nil { return nil }
+    }
+    return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+    "log"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+)
+
+// writeBatch collects the Redis writes, feature_store rows and merchants
+// produced by a batch of messages, so each batch costs one Redis round trip
+// and a multi-row upsert per table instead of several statements per message.
+type writeBatch struct {
+    pipe      redis.Pipeliner
+    features  []store.Feature
+    merchants []store.Merchant
+}
+
+func newWriteBatch() *writeBatch { return &writeBatch{pipe: rdb.Pipeline()} }
+
+func (b *writeBatch) addFeature(tx events.TransactionEvent, name string, value float64) {
+    b.features = append(b.features, store.Feature{UserID: tx.UserID, TransactionID: tx.TransactionID, Name: name, Value: value, Timestamp: time.Unix(tx.Timestamp, 0)})
+}
+
+// flush writes everything collected; messages is the batch size for the
+// failure metrics.
+func (b *writeBatch) flush(messages int) {
+    if err := b.flushFeatures(); err != nil {
+        log.Printf("feature store write error: %v", err)
+        messagesFailed.WithLabelValues("features").Add(float64(messages))
+    }
+    if err := b.flushMerchants(); err != nil { log.Printf("merchant write error: %v", err) }
+    if _, err := b.pipe.Exec(ctx); err != nil {
+        log.Printf("redis pipeline error: %v", err)
+        messagesFailed.WithLabelValues("cache").Add(float64(messages))
+    }
+}
+
+// flushFeatures writes the batch's features keyed per transaction, so
+// reprocessing a message rewrites its features instead of duplicating them.
+func (b *writeBatch) flushFeatures() error {
+    // A redelivered message can appear twice in one batch; ON CONFLICT can't
+    // touch the same row twice in a statement, so keep the last copy.
+    seen := make(map[[2]string]int, len(b.features))
+    rows := b.features[:0:0]
+    for _, f := range b.features {
+        k := [2]string{f.TransactionID, f.Name}
+        if i, ok := seen[k]; ok { rows[i] = f; continue }
+        seen[k] = len(rows)
+        rows = append(rows, f)
+    }
+    if len(rows) == 0 { return nil }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    return featureStore.UpsertFeatures(qctx, rows)
+}
+
+func (b *writeBatch) flushMerchants() error {
+    seen := make(map[string]int, len(b.merchants))
+    rows := b.merchants[:0:0]
+    for _, m := range b.merchants {
+        if i, ok := seen[m.MerchantID]; ok { rows[i] = m; continue }
+        seen[m.MerchantID] = len(rows)
+        rows = append(rows, m)
+    }
+    if len(rows) == 0 { return nil }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    return merchantStore.UpsertMerchants(qctx, rows)
+}
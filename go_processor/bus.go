@@ -0,0 +1,62 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "example.com/fraud/internal/config"
+)
+
+// busMessage is a transport-neutral event envelope.
+type busMessage struct {
+    Topic       string
+    Key         []byte
+    Value       []byte
+    ContentType string
+    // Position in the transport: Kafka partition/offset, or the Redis
+    // stream entry ID.
+    Partition int
+    Offset    int64
+    ID        string
+    // Time the message was written to the transport.
+    Time time.Time
+}
+
+// publisher writes events to one topic (or stream).
+type publisher interface {
+    Publish(key, value []byte, contentType string) error
+    Close() error
+}
+
+// subscriber reads events for a consumer group. Commit acknowledges a
+// processed message; messages may be committed out of fetch order.
+type subscriber interface {
+    Fetch(ctx context.Context) (busMessage, error)
+    Commit(m busMessage) error
+    Close() error
+}
+
+// eventBus builds publishers and subscribers for the transport selected by
+// EVENT_BUS: kafka (default) or redis for small deployments without a Kafka
+// cluster. Replay, the risk-state topic and per-partition lag are Kafka-only.
+type eventBus interface {
+    Publisher(topic string) publisher
+    Subscriber(topic, groupID string) subscriber
+    // Lag reports the group's backlog, per partition for Kafka and as a
+    // single pending count for Redis Streams.
+    Lag(topic, groupID string) ([]PartitionLag, error)
+    Name() string
+}
+
+func newEventBus(brokers []string) (eventBus, error) {
+    switch t := strings.ToLower(config.Get().EventBus); t {
+    case "kafka":
+        return newKafkaBus(brokers), nil
+    case "redis":
+        return redisBus{maxLen: int64(config.Get().Redis.StreamMaxLen)}, nil
+    default:
+        return nil, fmt.Errorf("unknown EVENT_BUS %q", t)
+    }
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+    "context"
+    "sync"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+type kafkaBus struct {
+    brokers []string
+    client  *kafka.Client
+}
+
+func newKafkaBus(brokers []string) kafkaBus {
+    return kafkaBus{brokers: brokers, client: conn.KafkaClient(brokers, 5*time.Second)}
+}
+
+func (kafkaBus) Name() string { return "kafka" }
+
+func (b kafkaBus) Publisher(topic string) publisher {
+    return kafkaPublisher{w: conn.NewKafkaWriter(b.brokers, topic)}
+}
+
+func (b kafkaBus) Subscriber(topic, groupID string) subscriber {
+    r := kafka.NewReader(kafka.ReaderConfig{
+        Brokers:  b.brokers,
+        Dialer:   conn.KafkaDialer(),
+        GroupID:  groupID,
+        Topic:    topic,
+        MinBytes: 1,
+        MaxBytes: 10e6,
+        MaxWait:  config.Get().Processor.MaxWait,
+        // Offsets are committed once processed (see offsetTracker); flush
+        // them to the broker every second.
+        CommitInterval: time.Second,
+    })
+    return &kafkaSubscriber{r: r, offsets: newOffsetTracker()}
+}
+
+type kafkaPublisher struct{ w *kafka.Writer }
+
+func (p kafkaPublisher) Publish(key, value []byte, contentType string) error {
+    return p.w.WriteMessages(ctx, kafka.Message{Key: key, Value: value, Headers: []kafka.Header{{Key: "content-type", Value: []byte(contentType)}}})
+}
+
+func (p kafkaPublisher) Close() error { return p.w.Close() }
+
+type kafkaSubscriber struct {
+    r       *kafka.Reader
+    offsets *offsetTracker
+}
+
+func (s *kafkaSubscriber) Fetch(ctx context.Context) (busMessage, error) {
+    if err := conn.Fault(ctx, "kafka"); err != nil { return busMessage{}, err }
+    m, err := s.r.FetchMessage(ctx)
+    if err != nil { return busMessage{}, err }
+    s.offsets.track(m.Partition, m.Offset)
+    return busMessage{Topic: m.Topic, Key: m.Key, Value: m.Value, ContentType: conn.Header(m, "content-type"), Partition: m.Partition, Offset: m.Offset, Time: m.Time}, nil
+}
+
+func (s *kafkaSubscriber) Commit(m busMessage) error {
+    commit := s.offsets.complete(m.Partition, m.Offset)
+    if commit < 0 { return nil }
+    return s.r.CommitMessages(ctx, kafka.Message{Topic: m.Topic, Partition: m.Partition, Offset: commit})
+}
+
+func (s *kafkaSubscriber) Close() error { return s.r.Close() }
+
+// offsetTracker finds, per partition, the highest offset below which every
+// message has completed. Workers finish out of order, so committing a
+// message's own offset could skip an earlier one still in flight.
+type offsetTracker struct {
+    mu         sync.Mutex
+    partitions map[int]*partitionOffsets
+}
+
+type partitionOffsets struct {
+    pending []int64 // tracked offsets in fetch order
+    done    map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+    return &offsetTracker{partitions: map[int]*partitionOffsets{}}
+}
+
+func (t *offsetTracker) track(partition int, offset int64) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    po, ok := t.partitions[partition]
+    if !ok {
+        po = &partitionOffsets{done: map[int64]bool{}}
+        t.partitions[partition] = po
+    }
+    po.pending = append(po.pending, offset)
+}
+
+// complete marks offset done and returns the offset that is now safe to
+// commit, or -1 if an earlier message is still in flight.
+func (t *offsetTracker) complete(partition int, offset int64) int64 {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    po := t.partitions[partition]
+    po.done[offset] = true
+    commit := int64(-1)
+    for len(po.pending) > 0 && po.done[po.pending[0]] {
+        commit = po.pending[0]
+        delete(po.done, commit)
+        po.pending = po.pending[1:]
+    }
+    return commit
+}
+
+// Lag compares the group's committed offsets with each partition's high
+// watermark. A partition with nothing committed yet counts its whole backlog
+// as lag.
+func (b kafkaBus) Lag(topic, groupID string) ([]PartitionLag, error) {
+    meta, err := b.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+    if err != nil { return nil, err }
+    var ids []int
+    var reqs []kafka.OffsetRequest
+    for _, t := range meta.Topics {
+        for _, p := range t.Partitions {
+            ids = append(ids, p.ID)
+            reqs = append(reqs, kafka.LastOffsetOf(p.ID), kafka.FirstOffsetOf(p.ID))
+        }
+    }
+    committed, err := b.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: groupID, Topics: map[string][]int{topic: ids}})
+    if err != nil { return nil, err }
+    offsets, err := b.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{topic: reqs}})
+    if err != nil { return nil, err }
+
+    byPartition := map[int]kafka.PartitionOffsets{}
+    for _, o := range offsets.Topics[topic] { byPartition[o.Partition] = o }
+    out := make([]PartitionLag, 0, len(ids))
+    for _, c := range committed.Topics[topic] {
+        o := byPartition[c.Partition]
+        pos := c.CommittedOffset
+        if pos < 0 { pos = o.FirstOffset }
+        lag := o.LastOffset - pos
+        if lag < 0 { lag = 0 }
+        out = append(out, PartitionLag{Partition: c.Partition, Committed: c.CommittedOffset, HighWatermark: o.LastOffset, Lag: lag})
+    }
+    return out, nil
+}
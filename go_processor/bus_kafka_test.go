@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+// A partition's commit point only moves past messages that have all
+// completed, whatever order the workers finish them in.
+func TestOffsetTracker(t *testing.T) {
+    ot := newOffsetTracker()
+    for _, o := range []int64{10, 11, 12, 13} { ot.track(0, o) }
+    for _, o := range []int64{5, 6} { ot.track(1, o) }
+    steps := []struct {
+        name      string
+        partition int
+        offset    int64
+        want      int64
+    }{
+        {"after an earlier one still in flight", 0, 11, -1},
+        {"first of its partition", 1, 5, 5},
+        {"the gap closes", 0, 10, 11},
+        {"past a gap again", 0, 13, -1},
+        {"other partition unaffected", 1, 6, 6},
+        {"the last gap closes", 0, 12, 13},
+    }
+    for _, s := range steps {
+        if got := ot.complete(s.partition, s.offset); got != s.want { t.Errorf("%s: commit %d, want %d", s.name, got, s.want) }
+    }
+    for p, po := range ot.partitions {
+        if len(po.pending) != 0 || len(po.done) != 0 { t.Errorf("partition %d: %d pending, %d done left over", p, len(po.pending), len(po.done)) }
+    }
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+    "context"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+// redisBus maps each topic onto a Redis stream of the same name. Entries
+// carry key, value and content_type fields; consumer groups and XACK give the
+// same at-least-once delivery the Kafka consumer group provides.
+type redisBus struct {
+    maxLen int64
+}
+
+func (redisBus) Name() string { return "redis" }
+
+func (b redisBus) Publisher(topic string) publisher {
+    return redisPublisher{stream: topic, maxLen: b.maxLen}
+}
+
+func (redisBus) Subscriber(topic, groupID string) subscriber {
+    consumer, _ := os.Hostname()
+    return &redisSubscriber{stream: topic, group: groupID, consumer: consumer, lastID: "0"}
+}
+
+// Lag reports entries delivered to the group but not yet acknowledged.
+func (redisBus) Lag(topic, groupID string) ([]PartitionLag, error) {
+    groups, err := rdb.XInfoGroups(ctx, topic).Result()
+    if err != nil { return nil, err }
+    length, err := rdb.XLen(ctx, topic).Result()
+    if err != nil { return nil, err }
+    for _, g := range groups {
+        if g.Name == groupID { return []PartitionLag{{HighWatermark: length, Lag: g.Pending}}, nil }
+    }
+    return nil, nil
+}
+
+type redisPublisher struct {
+    stream string
+    maxLen int64
+}
+
+func (p redisPublisher) Publish(key, value []byte, contentType string) error {
+    return rdb.XAdd(ctx, &redis.XAddArgs{
+        Stream: p.stream,
+        MaxLen: p.maxLen,
+        Approx: true,
+        Values: map[string]interface{}{"key": key, "value": value, "content_type": contentType},
+    }).Err()
+}
+
+func (redisPublisher) Close() error { return nil }
+
+// redisSubscriber first drains this consumer's pending entries (delivered
+// before a restart but never acknowledged), then reads new ones. Pending
+// entries are read in pages after the last ID returned, since they stay
+// pending until acknowledged and reading from "0" again would hand the
+// same ones back.
+type redisSubscriber struct {
+    stream   string
+    group    string
+    consumer string
+    lastID   string // the last pending entry read while draining, then ">"
+    buf      []redis.XMessage
+    created  bool
+}
+
+func (s *redisSubscriber) Fetch(ctx context.Context) (busMessage, error) {
+    if !s.created {
+        err := rdb.XGroupCreateMkStream(ctx, s.stream, s.group, "0").Err()
+        if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") { return busMessage{}, err }
+        s.created = true
+    }
+    for len(s.buf) == 0 {
+        res, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+            Group:    s.group,
+            Consumer: s.consumer,
+            Streams:  []string{s.stream, s.lastID},
+            Count:    100,
+            Block:    5 * time.Second,
+        }).Result()
+        if err == redis.Nil { continue }
+        if err != nil { return busMessage{}, err }
+        for _, st := range res { s.buf = append(s.buf, st.Messages...) }
+        if s.lastID == ">" { continue }
+        if len(s.buf) == 0 { s.lastID = ">"; continue }
+        s.lastID = s.buf[len(s.buf)-1].ID
+    }
+    x := s.buf[0]
+    s.buf = s.buf[1:]
+    m := busMessage{Topic: s.stream, ID: x.ID}
+    if v, ok := x.Values["key"].(string); ok { m.Key = []byte(v) }
+    if v, ok := x.Values["value"].(string); ok { m.Value = []byte(v) }
+    if v, ok := x.Values["content_type"].(string); ok { m.ContentType = v }
+    // Entry IDs start with the millisecond they were added.
+    if ms, err := strconv.ParseInt(strings.SplitN(x.ID, "-", 2)[0], 10, 64); err == nil { m.Time = time.UnixMilli(ms) }
+    return m, nil
+}
+
+func (s *redisSubscriber) Commit(m busMessage) error {
+    return rdb.XAck(ctx, s.stream, s.group, m.ID).Err()
+}
+
+func (redisSubscriber) Close() error { return nil }
@@ -0,0 +1,51 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "strconv"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+// cardTestingScript records user ARGV[2] at time ARGV[1] (ms) in the sorted
+// set KEYS[1], drops entries older than the window ARGV[3] and returns how
+// many distinct users remain. A redelivered message re-adds the same member,
+// so it doesn't inflate the count.
+var cardTestingScript = redis.NewScript(`
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tonumber(ARGV[1]) - tonumber(ARGV[3]))
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return redis.call('ZCARD', KEYS[1])
+`)
+
+// checkCardTesting counts small transactions per IP and per device across
+// users. When one source reaches card_testing.users distinct users within
+// the window it raises a CARD_TESTING alert, once per source and window,
+// and blocks the IP at the API for card_testing.block_duration.
+func checkCardTesting(tx events.TransactionEvent, alerts publisher) {
+    cfg := config.Get().CardTesting
+    if tx.Amount > cfg.MaxAmount { return }
+    if tx.IPAddress != nil && *tx.IPAddress != "" { checkCardTestingSource(tx, alerts, "ip", *tx.IPAddress, cfg) }
+    if tx.DeviceID != nil && *tx.DeviceID != "" { checkCardTestingSource(tx, alerts, "device", *tx.DeviceID, cfg) }
+}
+
+func checkCardTestingSource(tx events.TransactionEvent, alerts publisher, kind, id string, cfg config.CardTesting) {
+    key := "card_testing:" + kind + ":" + id
+    users, err := cardTestingScript.Run(ctx, rdb, []string{key}, tx.Timestamp*1000, tx.UserID, cfg.Window.Milliseconds()).Int()
+    if err != nil { log.Printf("card testing check: %v", err); return }
+    if users < cfg.Users { return }
+    first, err := rdb.SetNX(ctx, key+":alerted", 1, cfg.Window).Result()
+    if err != nil { log.Printf("card testing check: %v", err); return }
+    if kind == "ip" && cfg.BlockDuration > 0 {
+        if err := rdb.Set(ctx, "blocked_ip:"+id, tx.TransactionID, cfg.BlockDuration).Err(); err != nil { log.Printf("block ip %s: %v", id, err) }
+    }
+    if !first { return }
+    cardTestingDetected.WithLabelValues(kind).Inc()
+    desc := fmt.Sprintf("Possible card testing: %d users with transactions up to %s from %s %s within %s", users, strconv.FormatFloat(cfg.MaxAmount, 'f', -1, 64), kind, id, cfg.Window)
+    if kind == "ip" && cfg.BlockDuration > 0 { desc += fmt.Sprintf("; IP blocked for %s", cfg.BlockDuration) }
+    raiseAlert(tx, alerts, "CARD_TESTING", "HIGH", desc, 1)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+    "log"
+    "strings"
+    "time"
+
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+)
+
+// groupAlert files a newly raised alert under a case with the other recent
+// alerts on its user, device or card, so analysts investigate them together.
+func groupAlert(tx events.TransactionEvent, alertID string) {
+    cfg := config.Get().Cases
+    if cfg.Window <= 0 { return }
+    entities := caseEntities(tx, cfg.By)
+    if len(entities) == 0 { return }
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    _, opened, err := alertStore.GroupIntoCase(qctx, alertID, entities, time.Now().Add(-cfg.Window))
+    if err != nil { log.Printf("group alert %s into a case: %v", alertID, err); return }
+    if opened {
+        alertsGrouped.WithLabelValues("opened").Inc()
+    } else {
+        alertsGrouped.WithLabelValues("appended").Inc()
+    }
+}
+
+// caseEntities returns the transaction's entities of the kinds in by that
+// it has.
+func caseEntities(tx events.TransactionEvent, by []string) []store.CaseEntity {
+    var out []store.CaseEntity
+    for _, kind := range by {
+        kind = strings.ToLower(kind)
+        var v *string
+        switch kind {
+        case "user":
+            v = &tx.UserID
+        case "device":
+            v = tx.DeviceID
+        case "card":
+            v = tx.CardFingerprint
+        }
+        if v != nil && *v != "" { out = append(out, store.CaseEntity{Type: kind, Value: *v}) }
+    }
+    return out
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+    "time"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/internal/events"
+    "example.com/fraud/internal/mcc"
+)
+
+// categoryTTL drops the category counts of a user who stopped transacting.
+const categoryTTL = 90 * 24 * time.Hour
+
+// updateCategoryCounts counts tx in user_categories:<id>, one field per
+// merchant category plus _total, which the API reads for its category
+// features. Like updateAmountStats it must run once per transaction.
+func updateCategoryCounts(pipe redis.Pipeliner, tx events.TransactionEvent) {
+    if tx.MCC == nil || *tx.MCC == "" { return }
+    key := "user_categories:" + tx.UserID
+    pipe.HIncrBy(ctx, key, mcc.Category(*tx.MCC), 1)
+    pipe.HIncrBy(ctx, key, "_total", 1)
+    pipe.PExpire(ctx, key, categoryTTL)
+}
+
+// addMerchant records the merchant's MCC so later requests that omit it can
+// be scored with the merchant's category.
+func (b *writeBatch) addMerchant(tx events.TransactionEvent) {
+    if tx.MerchantID == nil || *tx.MerchantID == "" || tx.MCC == nil || !mcc.Valid(*tx.MCC) { return }
+    b.merchants = append(b.merchants, store.Merchant{MerchantID: *tx.MerchantID, MCC: *tx.MCC})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+    "example.com/fraud/internal/mcc"
+)
+
+var (
+    clickhouseInserted = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_processor_clickhouse_inserted_total",
+        Help: "Transactions inserted into ClickHouse.",
+    })
+    clickhouseDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_clickhouse_dropped_total",
+        Help: "Transactions not inserted into ClickHouse, by reason (queue_full or insert_failed).",
+    }, []string{"reason"})
+)
+
+// clickhouseTable is the analytics table, one row per scored transaction.
+// Replays insert a transaction again; ReplacingMergeTree keeps the latest
+// row per sorting key once parts merge, and queries that must not count a
+// transaction twice read the table with FINAL.
+const clickhouseTable = `CREATE TABLE IF NOT EXISTS %s (
+    transaction_id String,
+    user_id        String,
+    amount         Decimal(18, 2),
+    fraud_score    Float32,
+    is_fraud       Bool,
+    timestamp      DateTime('UTC'),
+    merchant_id    String,
+    mcc            LowCardinality(String),
+    category       LowCardinality(String),
+    channel        LowCardinality(String),
+    country        LowCardinality(String),
+    device_id      String,
+    ip_address     String,
+    duplicate_of   String,
+    inserted_at    DateTime64(3, 'UTC') DEFAULT now64(3)
+) ENGINE = ReplacingMergeTree(inserted_at)
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (toDate(timestamp), merchant_id, transaction_id)`
+
+// clickhouseRow is one JSONEachRow line. Missing optional fields are empty
+// strings rather than NULLs, which ClickHouse stores and groups faster.
+type clickhouseRow struct {
+    TransactionID string  `json:"transaction_id"`
+    UserID        string  `json:"user_id"`
+    Amount        float64 `json:"amount"`
+    FraudScore    float64 `json:"fraud_score"`
+    IsFraud       bool    `json:"is_fraud"`
+    Timestamp     int64   `json:"timestamp"`
+    MerchantID    string  `json:"merchant_id"`
+    MCC           string  `json:"mcc"`
+    Category      string  `json:"category"`
+    Channel       string  `json:"channel"`
+    Country       string  `json:"country"`
+    DeviceID      string  `json:"device_id"`
+    IPAddress     string  `json:"ip_address"`
+    DuplicateOf   string  `json:"duplicate_of"`
+}
+
+// clickhouseSink inserts transactions in batches over ClickHouse's HTTP
+// interface. Like search, it is a secondary copy: rows that can't be
+// inserted are dropped and counted, and a replay fills the gap.
+type clickhouseSink struct {
+    url, table, username, password string
+    client                         *http.Client
+    queue                          chan clickhouseRow
+}
+
+// analytics is nil unless clickhouse.url is set.
+var analytics *clickhouseSink
+
+func initClickHouse() {
+    cfg := config.Get().ClickHouse
+    if cfg.URL == "" { return }
+    s := &clickhouseSink{
+        url:      strings.TrimSuffix(cfg.URL, "/"),
+        table:    cfg.Database + "." + cfg.Table,
+        username: cfg.Username,
+        password: cfg.Password,
+        client:   &http.Client{Timeout: 30 * time.Second},
+        queue:    make(chan clickhouseRow, cfg.QueueSize),
+    }
+    // Inserts fail until the table exists, so a failure here is only
+    // logged: the sink keeps trying and counts what it drops.
+    tctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+    defer cancel()
+    for _, q := range []string{"CREATE DATABASE IF NOT EXISTS " + cfg.Database, fmt.Sprintf(clickhouseTable, s.table)} {
+        if err := s.exec(tctx, q, nil); err != nil { log.Printf("clickhouse setup: %v", err); break }
+    }
+    analytics = s
+    go s.run(cfg.BatchSize, cfg.FlushInterval)
+}
+
+func recordAnalytics(tx events.TransactionEvent) {
+    if analytics == nil { return }
+    row := clickhouseRow{
+        TransactionID: tx.TransactionID,
+        UserID:        tx.UserID,
+        Amount:        tx.Amount,
+        FraudScore:    tx.FraudScore,
+        IsFraud:       tx.IsFraud,
+        Timestamp:     tx.Timestamp,
+        MerchantID:    deref(tx.MerchantID),
+        MCC:           deref(tx.MCC),
+        Channel:       deref(tx.Channel),
+        Country:       deref(tx.Country),
+        DeviceID:      deref(tx.DeviceID),
+        IPAddress:     deref(tx.IPAddress),
+        DuplicateOf:   deref(tx.DuplicateOf),
+    }
+    if row.MCC != "" { row.Category = mcc.Category(row.MCC) }
+    select {
+    case analytics.queue <- row:
+    default:
+        clickhouseDropped.WithLabelValues("queue_full").Inc()
+    }
+}
+
+func deref(s *string) string {
+    if s == nil { return "" }
+    return *s
+}
+
+// run inserts whenever batchSize rows are queued or interval has passed
+// since the last insert.
+func (s *clickhouseSink) run(batchSize int, interval time.Duration) {
+    batch := make([]clickhouseRow, 0, batchSize)
+    t := time.NewTicker(interval)
+    defer t.Stop()
+    for {
+        select {
+        case row := <-s.queue:
+            batch = append(batch, row)
+            if len(batch) < batchSize { continue }
+        case <-t.C:
+            if len(batch) == 0 { continue }
+        }
+        s.insert(batch)
+        batch = batch[:0]
+    }
+}
+
+func (s *clickhouseSink) insert(batch []clickhouseRow) {
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    for _, row := range batch { enc.Encode(row) }
+    ictx, cancel := context.WithTimeout(ctx, 30*time.Second)
+    defer cancel()
+    if err := s.exec(ictx, "INSERT INTO "+s.table+" FORMAT JSONEachRow", buf.Bytes()); err != nil {
+        log.Printf("clickhouse insert failed for %d rows: %v", len(batch), err)
+        clickhouseDropped.WithLabelValues("insert_failed").Add(float64(len(batch)))
+        return
+    }
+    clickhouseInserted.Add(float64(len(batch)))
+}
+
+// exec runs query, with data as its input when there is any. Without data
+// the query itself is the request body.
+func (s *clickhouseSink) exec(ctx context.Context, query string, data []byte) error {
+    target, body := s.url+"/", []byte(query)
+    if data != nil { target, body = s.url+"/?query="+url.QueryEscape(query), data }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+    if err != nil { return err }
+    if s.username != "" { req.SetBasicAuth(s.username, s.password) }
+    resp, err := s.client.Do(req)
+    if err != nil { return err }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 {
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+    }
+    return nil
+}
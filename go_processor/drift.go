@@ -0,0 +1,267 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "math"
+    "net/http"
+    "strconv"
+    "sync/atomic"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+// Scores are counted in Redis hashes per 5-minute slot (for the current
+// window) and per hour (for the reference period), with one field per
+// 0.01-wide score bin plus their sum, so every processor instance sees the
+// same distribution and a restart loses nothing.
+const (
+    driftBins       = 100
+    driftPSIBins    = 10
+    driftSlot       = 5 * time.Minute
+    driftAlertedKey = "score_drift:alerted"
+)
+
+const (
+    driftOK           = "ok"
+    driftWarn         = "warn"
+    driftAlert        = "alert"
+    driftInsufficient = "insufficient_data"
+)
+
+var (
+    scorePSI = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_processor_score_psi",
+        Help: "Population stability index of recent fraud scores against the reference period.",
+    })
+    scoreMean = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "fraud_processor_score_mean",
+        Help: "Mean fraud score, by window (current or reference).",
+    }, []string{"window"})
+    scoreQuantile = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "fraud_processor_score_quantile",
+        Help: "Fraud score quantiles, by window (current or reference).",
+    }, []string{"window", "quantile"})
+    scoreDriftLevel = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_processor_score_drift_level",
+        Help: "Score drift: 0 ok or too few scores, 1 warn, 2 alert.",
+    })
+)
+
+// ScoreStats summarizes the fraud scores of one window.
+type ScoreStats struct {
+    Count int64   `json:"count"`
+    Mean  float64 `json:"mean"`
+    P50   float64 `json:"p50"`
+    P90   float64 `json:"p90"`
+    P95   float64 `json:"p95"`
+    P99   float64 `json:"p99"`
+}
+
+// DriftReport is served by /drift.
+type DriftReport struct {
+    Level           string     `json:"level"`
+    PSI             float64    `json:"psi"`
+    MeanShift       float64    `json:"mean_shift"`
+    Current         ScoreStats `json:"current"`
+    Reference       ScoreStats `json:"reference"`
+    Window          string     `json:"window"`
+    ReferencePeriod string     `json:"reference_period"`
+    UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+var currentDrift atomic.Pointer[DriftReport]
+
+type scoreHistogram struct {
+    bins [driftBins]int64
+    n    int64
+    sum  float64
+}
+
+func scoreBin(score float64) int {
+    b := int(score * driftBins)
+    if b < 0 { return 0 }
+    if b >= driftBins { return driftBins - 1 }
+    return b
+}
+
+func driftKey(granularity string, slot int64) string {
+    return "score_hist:" + granularity + ":" + strconv.FormatInt(slot, 10)
+}
+
+// recordScore counts tx's score in its 5-minute and hourly slots. Callers
+// must apply each transaction once; a replayed message would count twice.
+func recordScore(pipe redis.Pipeliner, tx events.TransactionEvent) {
+    cfg := config.Get().Drift
+    bin := strconv.Itoa(scoreBin(tx.FraudScore))
+    for _, s := range []struct {
+        key string
+        ttl time.Duration
+    }{
+        {driftKey("5m", tx.Timestamp/int64(driftSlot.Seconds())), cfg.Window + time.Hour},
+        {driftKey("1h", tx.Timestamp/3600), cfg.Window + cfg.Reference + 24*time.Hour},
+    } {
+        pipe.HIncrBy(ctx, s.key, bin, 1)
+        pipe.HIncrByFloat(ctx, s.key, "sum", tx.FraudScore)
+        pipe.Expire(ctx, s.key, s.ttl)
+    }
+}
+
+// loadHistogram adds up the slots in keys; missing slots count as empty.
+func loadHistogram(keys []string) (scoreHistogram, error) {
+    var h scoreHistogram
+    pipe := rdb.Pipeline()
+    cmds := make([]*redis.StringStringMapCmd, len(keys))
+    for i, k := range keys { cmds[i] = pipe.HGetAll(ctx, k) }
+    if _, err := pipe.Exec(ctx); err != nil { return h, err }
+    for _, c := range cmds {
+        for f, v := range c.Val() {
+            if f == "sum" {
+                s, _ := strconv.ParseFloat(v, 64)
+                h.sum += s
+                continue
+            }
+            b, err := strconv.Atoi(f)
+            if err != nil || b < 0 || b >= driftBins { continue }
+            n, _ := strconv.ParseInt(v, 10, 64)
+            h.bins[b] += n
+            h.n += n
+        }
+    }
+    return h, nil
+}
+
+// quantile interpolates linearly within the bin holding the q-th score.
+func (h scoreHistogram) quantile(q float64) float64 {
+    if h.n == 0 { return 0 }
+    target := q * float64(h.n)
+    var cum float64
+    for i, c := range h.bins {
+        if c == 0 { continue }
+        if cum+float64(c) >= target { return (float64(i) + (target-cum)/float64(c)) / driftBins }
+        cum += float64(c)
+    }
+    return 1
+}
+
+func (h scoreHistogram) stats() ScoreStats {
+    s := ScoreStats{Count: h.n}
+    if h.n == 0 { return s }
+    s.Mean = h.sum / float64(h.n)
+    s.P50, s.P90, s.P95, s.P99 = h.quantile(0.50), h.quantile(0.90), h.quantile(0.95), h.quantile(0.99)
+    return s
+}
+
+// psi is the population stability index of cur against ref over ten
+// equal-width score bins. Empty bins are floored so the log stays finite.
+func psi(cur, ref scoreHistogram) float64 {
+    const floor = 1e-4
+    per := driftBins / driftPSIBins
+    var total float64
+    for i := 0; i < driftPSIBins; i++ {
+        var c, r int64
+        for j := i * per; j < (i+1)*per; j++ { c, r = c+cur.bins[j], r+ref.bins[j] }
+        p := math.Max(float64(c)/float64(cur.n), floor)
+        q := math.Max(float64(r)/float64(ref.n), floor)
+        total += (p - q) * math.Log(p/q)
+    }
+    return total
+}
+
+// analyzeDrift compares the last drift.window, up to now, with the
+// drift.reference hours before it.
+func analyzeDrift(now time.Time) (*DriftReport, error) {
+    cfg := config.Get().Drift
+    var curKeys, refKeys []string
+    slot := int64(driftSlot.Seconds())
+    for s := now.Add(-cfg.Window).Unix()/slot + 1; s <= now.Unix()/slot; s++ { curKeys = append(curKeys, driftKey("5m", s)) }
+    refEnd := now.Add(-cfg.Window).Unix() / 3600
+    for h := now.Add(-cfg.Window-cfg.Reference).Unix() / 3600; h < refEnd; h++ { refKeys = append(refKeys, driftKey("1h", h)) }
+    cur, err := loadHistogram(curKeys)
+    if err != nil { return nil, err }
+    ref, err := loadHistogram(refKeys)
+    if err != nil { return nil, err }
+
+    r := &DriftReport{Level: driftInsufficient, Current: cur.stats(), Reference: ref.stats(), Window: cfg.Window.String(), ReferencePeriod: cfg.Reference.String(), UpdatedAt: now}
+    if cur.n < int64(cfg.MinSamples) || ref.n < int64(cfg.MinSamples) { return r, nil }
+    r.PSI = psi(cur, ref)
+    r.MeanShift = r.Current.Mean - r.Reference.Mean
+    switch {
+    case r.PSI >= cfg.PSIAlert, cfg.MeanShift > 0 && math.Abs(r.MeanShift) >= cfg.MeanShift:
+        r.Level = driftAlert
+    case r.PSI >= cfg.PSIWarn:
+        r.Level = driftWarn
+    default:
+        r.Level = driftOK
+    }
+    return r, nil
+}
+
+// runDriftMonitor refreshes the drift report every drift.interval. Each
+// instance computes it, since the histograms are shared; the SCORE_DRIFT
+// alert is raised by whichever instance first sees the alert level, at most
+// once per drift.window while it lasts.
+func runDriftMonitor(alerts publisher) {
+    last := driftInsufficient
+    for {
+        interval := config.Get().Drift.Interval
+        if interval <= 0 { return }
+        r, err := analyzeDrift(time.Now())
+        if err != nil {
+            log.Printf("score drift check failed: %v", err)
+        } else {
+            currentDrift.Store(r)
+            setDriftMetrics(r)
+            if r.Level != last && (r.Level == driftWarn || r.Level == driftAlert || last == driftWarn || last == driftAlert) {
+                log.Printf("score drift %s: psi %.3f, mean %.3f -> %.3f", r.Level, r.PSI, r.Reference.Mean, r.Current.Mean)
+            }
+            last = r.Level
+            if r.Level == driftAlert { raiseDriftAlert(r, alerts) }
+        }
+        time.Sleep(interval)
+    }
+}
+
+func setDriftMetrics(r *DriftReport) {
+    scorePSI.Set(r.PSI)
+    level := 0.0
+    if r.Level == driftWarn { level = 1 } else if r.Level == driftAlert { level = 2 }
+    scoreDriftLevel.Set(level)
+    for window, s := range map[string]ScoreStats{"current": r.Current, "reference": r.Reference} {
+        scoreMean.WithLabelValues(window).Set(s.Mean)
+        scoreQuantile.WithLabelValues(window, "0.5").Set(s.P50)
+        scoreQuantile.WithLabelValues(window, "0.9").Set(s.P90)
+        scoreQuantile.WithLabelValues(window, "0.95").Set(s.P95)
+        scoreQuantile.WithLabelValues(window, "0.99").Set(s.P99)
+    }
+}
+
+// raiseDriftAlert publishes a SCORE_DRIFT alert. It concerns no single
+// transaction, so it goes to the alerts topic only, not fraud_alerts.
+func raiseDriftAlert(r *DriftReport, alerts publisher) {
+    first, err := rdb.SetNX(ctx, driftAlertedKey, 1, config.Get().Drift.Window).Result()
+    if err != nil { log.Printf("score drift alert: %v", err); return }
+    if !first { return }
+    desc := fmt.Sprintf("Fraud score drift over the last %s against the previous %s: PSI %.3f, mean %.3f -> %.3f, p95 %.3f -> %.3f",
+        r.Window, r.ReferencePeriod, r.PSI, r.Reference.Mean, r.Current.Mean, r.Reference.P95, r.Current.P95)
+    publishAlert(alerts, "score-drift", events.AlertEvent{
+        AlertID:     "DRIFT_" + strconvFormat(r.UpdatedAt.Unix()),
+        AlertType:   "SCORE_DRIFT",
+        Severity:    "HIGH",
+        Description: desc,
+        FraudScore:  r.Current.Mean,
+        Timestamp:   r.UpdatedAt.Unix(),
+    })
+}
+
+func driftHandler(w http.ResponseWriter, r *http.Request) {
+    d := currentDrift.Load()
+    if d == nil { http.Error(w, "no drift report yet", http.StatusServiceUnavailable); return }
+    writeJSON(w, http.StatusOK, d)
+}
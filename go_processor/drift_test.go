@@ -0,0 +1,102 @@
+package main
+
+import (
+    "bytes"
+    "math"
+    "testing"
+    "time"
+
+    "example.com/fraud/internal/events"
+)
+
+func TestScoreBin(t *testing.T) {
+    cases := []struct {
+        score float64
+        want  int
+    }{
+        {-0.1, 0}, {0, 0}, {0.009, 0}, {0.456, 45}, {0.999, 99}, {1, 99}, {1.5, 99},
+    }
+    for _, c := range cases {
+        if got := scoreBin(c.score); got != c.want { t.Errorf("scoreBin(%v) = %d, want %d", c.score, got, c.want) }
+    }
+}
+
+func TestScoreHistogramStats(t *testing.T) {
+    // One score in the middle of each bin.
+    var h scoreHistogram
+    for i := range h.bins {
+        h.bins[i] = 1
+        h.n++
+        h.sum += (float64(i) + 0.5) / driftBins
+    }
+    s := h.stats()
+    want := ScoreStats{Count: 100, Mean: 0.5, P50: 0.5, P90: 0.9, P95: 0.95, P99: 0.99}
+    for _, c := range []struct {
+        name      string
+        got, want float64
+    }{{"mean", s.Mean, want.Mean}, {"p50", s.P50, want.P50}, {"p90", s.P90, want.P90}, {"p95", s.P95, want.P95}, {"p99", s.P99, want.P99}} {
+        if math.Abs(c.got-c.want) > 1e-9 { t.Errorf("%s: %v, want %v", c.name, c.got, c.want) }
+    }
+    if s.Count != want.Count { t.Errorf("count %d, want %d", s.Count, want.Count) }
+    if empty := (scoreHistogram{}).stats(); empty != (ScoreStats{}) { t.Errorf("empty histogram: %+v", empty) }
+}
+
+func TestPSI(t *testing.T) {
+    var low, high, mixed scoreHistogram
+    for i := 0; i < 50; i++ {
+        low.bins[i%30]++
+        high.bins[70+i%30]++
+        mixed.bins[i%30]++
+        mixed.bins[70+i%30]++
+    }
+    low.n, high.n, mixed.n = 50, 50, 100
+    if got := psi(low, low); got != 0 { t.Errorf("identical: psi %v, want 0", got) }
+    if got := psi(high, low); got < 0.25 { t.Errorf("disjoint: psi %v, want at least 0.25", got) }
+    if a, b := psi(mixed, low), psi(low, mixed); math.Abs(a-b) > 1e-9 { t.Errorf("not symmetric: %v and %v", a, b) }
+    if got := psi(mixed, low); got <= 0 || got >= psi(high, low) { t.Errorf("partial shift: psi %v, want between 0 and %v", got, psi(high, low)) }
+}
+
+// analyzeDrift reads the histograms recordScore writes, comparing the last
+// hour with the week before it.
+func TestAnalyzeDrift(t *testing.T) {
+    _, mr := processorEnv(t, map[string]string{"SCORE_DRIFT_MIN_SAMPLES": "20"})
+    now := time.Now()
+    record := func(at time.Time, n int, score func(i int) float64) {
+        pipe := rdb.Pipeline()
+        for i := 0; i < n; i++ { recordScore(pipe, events.TransactionEvent{FraudScore: score(i), Timestamp: at.Unix()}) }
+        if _, err := pipe.Exec(ctx); err != nil { t.Fatal(err) }
+    }
+    spread := func(lo float64) func(int) float64 { return func(i int) float64 { return lo + float64(i%20)/100 } }
+    cases := []struct {
+        name      string
+        reference func(int) float64
+        current   func(int) float64
+        n         int
+        want      string
+    }{
+        {"same scores", spread(0.1), spread(0.1), 40, driftOK},
+        {"scores moved up", spread(0.1), spread(0.6), 40, driftAlert},
+        {"too few scores", spread(0.1), spread(0.6), 10, driftInsufficient},
+    }
+    for _, c := range cases {
+        mr.FlushAll()
+        record(now.Add(-3*time.Hour), c.n, c.reference)
+        record(now.Add(-time.Minute), c.n, c.current)
+        r, err := analyzeDrift(now)
+        if err != nil { t.Fatalf("%s: %v", c.name, err) }
+        if r.Level != c.want { t.Errorf("%s: level %s (psi %.3f, mean shift %.3f), want %s", c.name, r.Level, r.PSI, r.MeanShift, c.want) }
+        if r.Current.Count != int64(c.n) || r.Reference.Count != int64(c.n) { t.Errorf("%s: counted %d current and %d reference scores, want %d", c.name, r.Current.Count, r.Reference.Count, c.n) }
+    }
+}
+
+// The SCORE_DRIFT alert goes out once per drift.window however many
+// instances or checks see the alert level.
+func TestRaiseDriftAlert(t *testing.T) {
+    processorEnv(t, nil)
+    alerts := &recordingPublisher{}
+    r := &DriftReport{Level: driftAlert, PSI: 0.4, Window: "1h0m0s", ReferencePeriod: "168h0m0s", UpdatedAt: time.Now()}
+    raiseDriftAlert(r, alerts)
+    raiseDriftAlert(r, alerts)
+    if len(alerts.msgs) != 1 { t.Fatalf("published %d drift alerts, want 1", len(alerts.msgs)) }
+    if string(alerts.msgs[0].key) != "score-drift" || !bytes.Contains(alerts.msgs[0].value, []byte("SCORE_DRIFT")) { t.Errorf("published %q %q", alerts.msgs[0].key, alerts.msgs[0].value) }
+}
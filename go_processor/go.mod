@@ -1,11 +1,15 @@
-module github.com/yourorg/fraud/go_processor
+module example.com/fraud/go_processor
 
 go 1.22
 
 require (
+    example.com/fraud/internal v0.0.0
+    github.com/alicebob/miniredis/v2 v2.33.0
     github.com/go-redis/redis/v8 v8.11.5
-    github.com/lib/pq v1.10.9
+    github.com/jackc/pgx/v5 v5.6.0
+    github.com/prometheus/client_golang v1.19.1
     github.com/segmentio/kafka-go v0.4.47
+    go.uber.org/mock v0.4.0
 )
 
-
+replace example.com/fraud/internal => ../internal
@@ -0,0 +1,253 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store.go -destination=mocks/mock_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	store "example.com/fraud/go_processor/internal/store"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserStore is a mock of UserStore interface.
+type MockUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStoreMockRecorder
+}
+
+// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
+type MockUserStoreMockRecorder struct {
+	mock *MockUserStore
+}
+
+// NewMockUserStore creates a new mock instance.
+func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
+	mock := &MockUserStore{ctrl: ctrl}
+	mock.recorder = &MockUserStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
+	return m.recorder
+}
+
+// ApplyRiskAdjustment mocks base method.
+func (m *MockUserStore) ApplyRiskAdjustment(ctx context.Context, transactionID, userID string, adjustment float64, at time.Time, reapply bool) (float64, bool, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyRiskAdjustment", ctx, transactionID, userID, adjustment, at, reapply)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(bool)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// ApplyRiskAdjustment indicates an expected call of ApplyRiskAdjustment.
+func (mr *MockUserStoreMockRecorder) ApplyRiskAdjustment(ctx, transactionID, userID, adjustment, at, reapply any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyRiskAdjustment", reflect.TypeOf((*MockUserStore)(nil).ApplyRiskAdjustment), ctx, transactionID, userID, adjustment, at, reapply)
+}
+
+// MockTransactionStore is a mock of TransactionStore interface.
+type MockTransactionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionStoreMockRecorder
+}
+
+// MockTransactionStoreMockRecorder is the mock recorder for MockTransactionStore.
+type MockTransactionStoreMockRecorder struct {
+	mock *MockTransactionStore
+}
+
+// NewMockTransactionStore creates a new mock instance.
+func NewMockTransactionStore(ctrl *gomock.Controller) *MockTransactionStore {
+	mock := &MockTransactionStore{ctrl: ctrl}
+	mock.recorder = &MockTransactionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionStore) EXPECT() *MockTransactionStoreMockRecorder {
+	return m.recorder
+}
+
+// UpdateMetadata mocks base method.
+func (m *MockTransactionStore) UpdateMetadata(ctx context.Context, transactionID string, from, to time.Time, deviceID, ipAddress *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMetadata", ctx, transactionID, from, to, deviceID, ipAddress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMetadata indicates an expected call of UpdateMetadata.
+func (mr *MockTransactionStoreMockRecorder) UpdateMetadata(ctx, transactionID, from, to, deviceID, ipAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMetadata", reflect.TypeOf((*MockTransactionStore)(nil).UpdateMetadata), ctx, transactionID, from, to, deviceID, ipAddress)
+}
+
+// MockFeatureStore is a mock of FeatureStore interface.
+type MockFeatureStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeatureStoreMockRecorder
+}
+
+// MockFeatureStoreMockRecorder is the mock recorder for MockFeatureStore.
+type MockFeatureStoreMockRecorder struct {
+	mock *MockFeatureStore
+}
+
+// NewMockFeatureStore creates a new mock instance.
+func NewMockFeatureStore(ctrl *gomock.Controller) *MockFeatureStore {
+	mock := &MockFeatureStore{ctrl: ctrl}
+	mock.recorder = &MockFeatureStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeatureStore) EXPECT() *MockFeatureStoreMockRecorder {
+	return m.recorder
+}
+
+// UpsertFeatures mocks base method.
+func (m *MockFeatureStore) UpsertFeatures(ctx context.Context, rows []store.Feature) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertFeatures", ctx, rows)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertFeatures indicates an expected call of UpsertFeatures.
+func (mr *MockFeatureStoreMockRecorder) UpsertFeatures(ctx, rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertFeatures", reflect.TypeOf((*MockFeatureStore)(nil).UpsertFeatures), ctx, rows)
+}
+
+// MockMerchantStore is a mock of MerchantStore interface.
+type MockMerchantStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMerchantStoreMockRecorder
+}
+
+// MockMerchantStoreMockRecorder is the mock recorder for MockMerchantStore.
+type MockMerchantStoreMockRecorder struct {
+	mock *MockMerchantStore
+}
+
+// NewMockMerchantStore creates a new mock instance.
+func NewMockMerchantStore(ctrl *gomock.Controller) *MockMerchantStore {
+	mock := &MockMerchantStore{ctrl: ctrl}
+	mock.recorder = &MockMerchantStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMerchantStore) EXPECT() *MockMerchantStoreMockRecorder {
+	return m.recorder
+}
+
+// UpsertMerchants mocks base method.
+func (m *MockMerchantStore) UpsertMerchants(ctx context.Context, rows []store.Merchant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertMerchants", ctx, rows)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertMerchants indicates an expected call of UpsertMerchants.
+func (mr *MockMerchantStoreMockRecorder) UpsertMerchants(ctx, rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertMerchants", reflect.TypeOf((*MockMerchantStore)(nil).UpsertMerchants), ctx, rows)
+}
+
+// MockAlertStore is a mock of AlertStore interface.
+type MockAlertStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlertStoreMockRecorder
+}
+
+// MockAlertStoreMockRecorder is the mock recorder for MockAlertStore.
+type MockAlertStoreMockRecorder struct {
+	mock *MockAlertStore
+}
+
+// NewMockAlertStore creates a new mock instance.
+func NewMockAlertStore(ctrl *gomock.Controller) *MockAlertStore {
+	mock := &MockAlertStore{ctrl: ctrl}
+	mock.recorder = &MockAlertStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlertStore) EXPECT() *MockAlertStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateOnce mocks base method.
+func (m *MockAlertStore) CreateOnce(ctx context.Context, a store.Alert, since time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOnce", ctx, a, since)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOnce indicates an expected call of CreateOnce.
+func (mr *MockAlertStoreMockRecorder) CreateOnce(ctx, a, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOnce", reflect.TypeOf((*MockAlertStore)(nil).CreateOnce), ctx, a, since)
+}
+
+// GroupIntoCase mocks base method.
+func (m *MockAlertStore) GroupIntoCase(ctx context.Context, alertID string, entities []store.CaseEntity, since time.Time) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupIntoCase", ctx, alertID, entities, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GroupIntoCase indicates an expected call of GroupIntoCase.
+func (mr *MockAlertStoreMockRecorder) GroupIntoCase(ctx, alertID, entities, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupIntoCase", reflect.TypeOf((*MockAlertStore)(nil).GroupIntoCase), ctx, alertID, entities, since)
+}
+
+// LiveSuppressions mocks base method.
+func (m *MockAlertStore) LiveSuppressions(ctx context.Context) ([]store.Suppression, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LiveSuppressions", ctx)
+	ret0, _ := ret[0].([]store.Suppression)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LiveSuppressions indicates an expected call of LiveSuppressions.
+func (mr *MockAlertStoreMockRecorder) LiveSuppressions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiveSuppressions", reflect.TypeOf((*MockAlertStore)(nil).LiveSuppressions), ctx)
+}
+
+// RecordActivity mocks base method.
+func (m *MockAlertStore) RecordActivity(ctx context.Context, alertID, action string, details map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordActivity", ctx, alertID, action, details)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordActivity indicates an expected call of RecordActivity.
+func (mr *MockAlertStoreMockRecorder) RecordActivity(ctx, alertID, action, details any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordActivity", reflect.TypeOf((*MockAlertStore)(nil).RecordActivity), ctx, alertID, action, details)
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres implements every store interface on a pgx pool.
+type Postgres struct {
+    db *pgxpool.Pool
+}
+
+func NewPostgres(db *pgxpool.Pool) *Postgres { return &Postgres{db: db} }
+
+// The processed_transactions ledger row, with the adjustment applied, is
+// inserted in the same DB transaction as the update, so redelivered or
+// replayed messages leave the score alone unless they reapply. A single
+// UPDATE applies and clamps the adjustment, so concurrent messages for one
+// user can't overwrite each other's changes.
+func (p *Postgres) ApplyRiskAdjustment(ctx context.Context, transactionID, userID string, adjustment float64, at time.Time, reapply bool) (float64, bool, bool, error) {
+    dbtx, err := p.db.Begin(ctx)
+    if err != nil { return 0, false, false, err }
+    defer dbtx.Rollback(context.Background())
+    res, err := dbtx.Exec(ctx, `INSERT INTO processed_transactions (transaction_id, adjustment) VALUES ($1, $2) ON CONFLICT (transaction_id) DO NOTHING`, transactionID, adjustment)
+    if err != nil { return 0, false, false, err }
+    first, delta := res.RowsAffected() == 1, adjustment
+    if !first {
+        if !reapply { return 0, false, false, nil }
+        // Entries from before adjustments were recorded have none to take
+        // the difference to, and are left as they are.
+        var prev *float64
+        if err := dbtx.QueryRow(ctx, `SELECT adjustment FROM processed_transactions WHERE transaction_id = $1 FOR UPDATE`, transactionID).Scan(&prev); err != nil { return 0, false, false, err }
+        if prev == nil || *prev == adjustment { return 0, false, false, nil }
+        delta = adjustment - *prev
+        if _, err := dbtx.Exec(ctx, `UPDATE processed_transactions SET adjustment = $2, processed_at = CURRENT_TIMESTAMP WHERE transaction_id = $1`, transactionID, adjustment); err != nil { return 0, false, false, err }
+    }
+    var risk float64
+    err = dbtx.QueryRow(ctx, `UPDATE users SET risk_score = LEAST(1, GREATEST(0, COALESCE(risk_score, 0.5) + $1)), updated_at = CURRENT_TIMESTAMP,
+                                  first_transaction_at = LEAST(COALESCE(first_transaction_at, $3), $3)
+                              WHERE user_id = $2 RETURNING risk_score`, delta, userID, at).Scan(&risk)
+    if errors.Is(err, pgx.ErrNoRows) {
+        // Unknown user: record the transaction as processed, nothing to apply.
+        return 0, false, false, dbtx.Commit(ctx)
+    }
+    if err != nil { return 0, false, false, err }
+    if err := dbtx.Commit(ctx); err != nil { return 0, false, false, err }
+    return risk, first, true, nil
+}
+
+func (p *Postgres) UpdateMetadata(ctx context.Context, transactionID string, from, to time.Time, deviceID, ipAddress *string) error {
+    _, err := p.db.Exec(ctx, `UPDATE transactions SET device_id = $1, ip_address = $2 WHERE transaction_id = $3 AND timestamp BETWEEN $4 AND $5`,
+        deviceID, ipAddress, transactionID, from, to)
+    return err
+}
+
+// featureUpsertRows keeps each statement well under Postgres' 65535
+// parameter limit.
+const featureUpsertRows = 1000
+
+func (p *Postgres) UpsertFeatures(ctx context.Context, rows []Feature) error {
+    for len(rows) > 0 {
+        n := len(rows)
+        if n > featureUpsertRows { n = featureUpsertRows }
+        var q strings.Builder
+        q.WriteString(`INSERT INTO feature_store (user_id, transaction_id, feature_name, feature_value, feature_timestamp) VALUES `)
+        args := make([]interface{}, 0, n*5)
+        for i, f := range rows[:n] {
+            if i > 0 { q.WriteString(",") }
+            fmt.Fprintf(&q, "($%d,$%d,$%d,$%d,$%d)", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
+            args = append(args, f.UserID, f.TransactionID, f.Name, f.Value, f.Timestamp)
+        }
+        q.WriteString(` ON CONFLICT (transaction_id, feature_name) DO UPDATE SET feature_value = EXCLUDED.feature_value, feature_timestamp = EXCLUDED.feature_timestamp`)
+        if _, err := p.db.Exec(ctx, q.String(), args...); err != nil { return err }
+        rows = rows[n:]
+    }
+    return nil
+}
+
+func (p *Postgres) UpsertMerchants(ctx context.Context, rows []Merchant) error {
+    for len(rows) > 0 {
+        n := len(rows)
+        if n > featureUpsertRows { n = featureUpsertRows }
+        var q strings.Builder
+        q.WriteString(`INSERT INTO merchants (merchant_id, mcc) VALUES `)
+        args := make([]interface{}, 0, n*2)
+        for i, m := range rows[:n] {
+            if i > 0 { q.WriteString(",") }
+            fmt.Fprintf(&q, "($%d,$%d)", i*2+1, i*2+2)
+            args = append(args, m.MerchantID, m.MCC)
+        }
+        q.WriteString(` ON CONFLICT (merchant_id) DO UPDATE SET mcc = EXCLUDED.mcc, updated_at = now() WHERE merchants.mcc <> EXCLUDED.mcc`)
+        if _, err := p.db.Exec(ctx, q.String(), args...); err != nil { return err }
+        rows = rows[n:]
+    }
+    return nil
+}
+
+// fraud_alerts is partitioned by created_at, which rules out a unique index
+// on (transaction_id, alert_type), so the check runs under a per-transaction
+// advisory lock instead.
+func (p *Postgres) CreateOnce(ctx context.Context, a Alert, since time.Time) (bool, error) {
+    dbtx, err := p.db.Begin(ctx)
+    if err != nil { return false, err }
+    defer dbtx.Rollback(context.Background())
+    if _, err := dbtx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, a.TransactionID); err != nil { return false, err }
+    res, err := dbtx.Exec(ctx, `INSERT INTO fraud_alerts (alert_id, transaction_id, alert_type, severity, description, confidence_score, status, suppressed_by)
+                                SELECT $1,$2,$3,$4,$5,$6,$7,$9
+                                WHERE NOT EXISTS (SELECT 1 FROM fraud_alerts WHERE transaction_id = $2 AND alert_type = $3 AND created_at >= $8)`,
+        a.AlertID, a.TransactionID, a.AlertType, a.Severity, a.Description, a.Confidence, a.Status, since, a.SuppressedBy)
+    if err != nil { return false, err }
+    if res.RowsAffected() == 0 { return false, nil }
+    details := map[string]string{"status": a.Status, "severity": a.Severity}
+    if a.SuppressedBy != nil { details["suppressed_by"] = fmt.Sprint(*a.SuppressedBy) }
+    if err := recordActivity(ctx, dbtx, a.AlertID, "alert.created", details); err != nil { return false, err }
+    return true, dbtx.Commit(ctx)
+}
+
+func (p *Postgres) RecordActivity(ctx context.Context, alertID, action string, details map[string]string) error {
+    return recordActivity(ctx, p.db, alertID, action, details)
+}
+
+// GroupIntoCase locks each entity, in a fixed order so concurrent alerts
+// can't deadlock, before choosing the case, so two alerts on one entity
+// never open two cases.
+func (p *Postgres) GroupIntoCase(ctx context.Context, alertID string, entities []CaseEntity, since time.Time) (int64, bool, error) {
+    keys := make([]string, len(entities))
+    types, values := make([]string, len(entities)), make([]string, len(entities))
+    for i, e := range entities {
+        keys[i] = "case:" + e.Type + ":" + e.Value
+        types[i], values[i] = e.Type, e.Value
+    }
+    sort.Strings(keys)
+    dbtx, err := p.db.Begin(ctx)
+    if err != nil { return 0, false, err }
+    defer dbtx.Rollback(context.Background())
+    for _, k := range keys {
+        if _, err := dbtx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, k); err != nil { return 0, false, err }
+    }
+    var caseID int64
+    created := false
+    err = dbtx.QueryRow(ctx, `SELECT c.id FROM fraud_cases c JOIN case_entities e ON e.case_id = c.id
+                              WHERE c.status = 'OPEN' AND c.last_alert_at >= $1
+                                AND (e.entity_type, e.entity_value) IN (SELECT * FROM unnest($2::text[], $3::text[]))
+                              ORDER BY c.id LIMIT 1`, since, types, values).Scan(&caseID)
+    if errors.Is(err, pgx.ErrNoRows) {
+        created = true
+        err = dbtx.QueryRow(ctx, `INSERT INTO fraud_cases DEFAULT VALUES RETURNING id`).Scan(&caseID)
+    }
+    if err != nil { return 0, false, err }
+    if _, err := dbtx.Exec(ctx, `UPDATE fraud_cases SET alert_count = alert_count + 1, last_alert_at = now() WHERE id = $1`, caseID); err != nil { return 0, false, err }
+    if _, err := dbtx.Exec(ctx, `INSERT INTO case_entities (case_id, entity_type, entity_value) SELECT $1, * FROM unnest($2::text[], $3::text[])
+                                 ON CONFLICT DO NOTHING`, caseID, types, values); err != nil { return 0, false, err }
+    if _, err := dbtx.Exec(ctx, `UPDATE fraud_alerts SET case_id = $2 WHERE alert_id = $1`, alertID, caseID); err != nil { return 0, false, err }
+    details := map[string]string{"case_id": fmt.Sprint(caseID)}
+    if created { details["opened"] = "true" }
+    if err := recordActivity(ctx, dbtx, alertID, "alert.grouped", details); err != nil { return 0, false, err }
+    return caseID, created, dbtx.Commit(ctx)
+}
+
+// recordActivity writes an alert's audit log entry as the processor, on the
+// pool or inside a transaction.
+func recordActivity(ctx context.Context, db interface {
+    Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}, alertID, action string, details map[string]string) error {
+    b, err := json.Marshal(details)
+    if err != nil { return err }
+    _, err = db.Exec(ctx, `INSERT INTO audit_log (actor, action, entity_type, entity_id, details) VALUES ('processor', $1, 'alert', $2, $3)`, action, alertID, string(b))
+    return err
+}
+
+func (p *Postgres) LiveSuppressions(ctx context.Context) ([]Suppression, error) {
+    rows, err := p.db.Query(ctx, `SELECT id, COALESCE(merchant_id, ''), COALESCE(alert_type, ''), COALESCE(severity, ''), starts_at, ends_at
+                                  FROM alert_suppressions WHERE expired_at IS NULL`)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []Suppression
+    for rows.Next() {
+        var s Suppression
+        if err := rows.Scan(&s.ID, &s.MerchantID, &s.AlertType, &s.Severity, &s.StartsAt, &s.EndsAt); err != nil { return nil, err }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
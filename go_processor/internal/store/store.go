@@ -0,0 +1,101 @@
+// Package store holds the processor's SQL behind small interfaces, so the
+// processing logic can be exercised against the generated mocks in
+// store/mocks instead of a live Postgres.
+package store
+
+import (
+    "context"
+    "time"
+)
+
+//go:generate mockgen -source=store.go -destination=mocks/mock_store.go -package=mocks
+
+type Feature struct {
+    UserID        string
+    TransactionID string
+    Name          string
+    Value         float64
+    Timestamp     time.Time
+}
+
+type Merchant struct {
+    MerchantID string
+    MCC        string
+}
+
+type Alert struct {
+    AlertID       string
+    TransactionID string
+    AlertType     string
+    Severity      string
+    Description   string
+    Confidence    float64
+    Status        string
+    // SuppressedBy is the suppression an alert stored as SUPPRESSED matched.
+    SuppressedBy *int64
+}
+
+// Suppression silences matching alerts between StartsAt and EndsAt; empty
+// criteria match any alert.
+type Suppression struct {
+    ID         int64
+    MerchantID string
+    AlertType  string
+    Severity   string
+    StartsAt   time.Time
+    EndsAt     time.Time
+}
+
+// CaseEntity is something alerts are grouped into cases by: a user, device
+// or card, with its ID.
+type CaseEntity struct {
+    Type  string
+    Value string
+}
+
+type UserStore interface {
+    // ApplyRiskAdjustment adds adjustment to the user's risk score (clamped
+    // to [0, 1]) and records at as their first transaction time if it is
+    // earlier, unless transactionID has already been applied. With reapply,
+    // an applied transaction instead moves the score by the difference
+    // between adjustment and the one applied before. first reports the
+    // transaction's first application and changed any change to the score;
+    // both are false for a repeat or an unknown user.
+    ApplyRiskAdjustment(ctx context.Context, transactionID, userID string, adjustment float64, at time.Time, reapply bool) (risk float64, first, changed bool, err error)
+}
+
+type TransactionStore interface {
+    // UpdateMetadata sets device and IP on a transaction whose timestamp lies
+    // in [from, to].
+    UpdateMetadata(ctx context.Context, transactionID string, from, to time.Time, deviceID, ipAddress *string) error
+}
+
+type FeatureStore interface {
+    // UpsertFeatures writes rows keyed by (transaction, feature name); the
+    // slice must not repeat a key.
+    UpsertFeatures(ctx context.Context, rows []Feature) error
+}
+
+type MerchantStore interface {
+    // UpsertMerchants records each merchant's latest MCC; the slice must not
+    // repeat a merchant.
+    UpsertMerchants(ctx context.Context, rows []Merchant) error
+}
+
+type AlertStore interface {
+    // CreateOnce inserts a unless an alert of the same type already exists
+    // for the transaction since the given time, and reports whether it did.
+    // The creation is written to the audit log with the alert. An alert ID
+    // already taken fails as a unique violation (see fraud_alert_ids).
+    CreateOnce(ctx context.Context, a Alert, since time.Time) (bool, error)
+    // RecordActivity adds an entry to the alert's timeline in the audit log.
+    RecordActivity(ctx context.Context, alertID, action string, details map[string]string) error
+    // GroupIntoCase adds the alert to the oldest open case linked by one of
+    // the entities whose last alert is at or after since, or opens a case,
+    // and links the case to every entity. It returns the case and whether it
+    // was opened.
+    GroupIntoCase(ctx context.Context, alertID string, entities []CaseEntity, since time.Time) (int64, bool, error)
+    // LiveSuppressions returns the suppressions that have not expired,
+    // including those not started yet.
+    LiveSuppressions(ctx context.Context) ([]Suppression, error)
+}
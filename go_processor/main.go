@@ -2,162 +2,333 @@ package main
 
 import (
     "context"
-    "database/sql"
     "encoding/json"
+    "flag"
     "fmt"
     "log"
     "os"
-    "strings"
+    "runtime"
+    "sync/atomic"
     "time"
 
-    _ "github.com/lib/pq"
-    "github.com/segmentio/kafka-go"
     "github.com/go-redis/redis/v8"
-)
+    "github.com/jackc/pgx/v5/pgxpool"
 
-type TransactionMessage struct {
-    TransactionID string  `json:"transaction_id"`
-    UserID        string  `json:"user_id"`
-    Amount        float64 `json:"amount"`
-    FraudScore    float64 `json:"fraud_score"`
-    IsFraud       bool    `json:"is_fraud"`
-    Timestamp     int64   `json:"timestamp"`
-    DeviceID      *string `json:"device_id,omitempty"`
-    IPAddress     *string `json:"ip_address,omitempty"`
-}
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+)
 
 var (
-    ctx = context.Background()
-    pg  *sql.DB
-    rdb *redis.Client
-)
+    ctx        = context.Background()
+    pg         *pgxpool.Pool
+    rdb        *redis.Client
+    registry   *events.Registry
+    alertCodec events.Codec
 
-func getenv(key, def string) string {
-    if v := os.Getenv(key); v != "" { return v }
-    return def
-}
+    // kafkaReady is set once the Kafka side (brokers, alertCodec, the risk
+    // state writer) is set up; until then, only with startup.lazy_kafka,
+    // alerts are encoded as protobuf and no risk snapshots are published.
+    kafkaReady atomic.Bool
+
+    userStore    store.UserStore
+    txStore      store.TransactionStore
+    featureStore store.FeatureStore
+    merchantStore store.MerchantStore
+    alertStore   store.AlertStore
+)
 
 func initConnections() error {
     // Postgres
-    pgHost := getenv("POSTGRES_HOST", "localhost")
-    pgDB := getenv("POSTGRES_DB", "fraud_detection")
-    pgUser := getenv("POSTGRES_USER", "fraud_user")
-    pgPass := getenv("POSTGRES_PASSWORD", "fraud_password")
-    dsn := "host=" + pgHost + " dbname=" + pgDB + " user=" + pgUser + " password=" + pgPass + " sslmode=disable"
-    var err error
-    pg, err = sql.Open("postgres", dsn)
+    attempts := config.Get().Startup.RetryAttempts
+    err := conn.Retry(ctx, "postgres", attempts, func() (err error) {
+        pg, err = conn.NewPgPool(ctx, config.Get().Postgres.Host, "fraud_processor_pg_pool")
+        return err
+    })
     if err != nil { return err }
-    if err = pg.Ping(); err != nil { return err }
+    db := store.NewPostgres(pg)
+    userStore, txStore, featureStore, merchantStore, alertStore = db, db, db, db, db
 
     // Redis
-    redisHost := getenv("REDIS_HOST", "localhost")
-    redisPort := getenv("REDIS_PORT", "6379")
-    rdb = redis.NewClient(&redis.Options{ Addr: redisHost+":"+redisPort })
-    if err := rdb.Ping(ctx).Err(); err != nil { return err }
+    if err := conn.Retry(ctx, "redis", attempts, func() (err error) { rdb, err = conn.NewRedis(ctx, "fraud_processor"); return err }); err != nil { return err }
+
+    // Schema Registry (only contacted for Avro payloads)
+    registry = events.NewRegistry(config.Get().Kafka.SchemaRegistryURL)
+
+    // Search and analytics sinks (optional)
+    initSearch()
+    initClickHouse()
+
+    initSuppressions()
+    return nil
+}
+
+// initKafka waits for the brokers and builds alertCodec, then, unless
+// replaying, sets up the risk state topic; a failure there only disables
+// snapshots. attempts bounds each wait as in conn.Retry.
+func initKafka(bus eventBus, brokers []string, attempts int, replay bool) error {
+    if bus.Name() == "kafka" {
+        if err := conn.Retry(ctx, "kafka", attempts, func() error { return conn.PingKafka(ctx, brokers) }); err != nil { return err }
+    }
+    err := conn.Retry(ctx, "schema registry", attempts, func() (err error) {
+        alertCodec, err = events.NewCodec(registry, "fraud-alerts", events.AlertEventSchema)
+        return err
+    })
+    if err != nil { return err }
+    if bus.Name() != "kafka" || replay { kafkaReady.Store(true); return nil }
+
+    // Bounded even in lazy mode: a topic the cluster refuses to create
+    // (e.g. too few brokers for the replication factor) won't appear later.
+    if err := conn.Retry(ctx, "risk state topic", config.Get().Startup.RetryAttempts, func() error { return initRiskState(brokers) }); err != nil {
+        log.Printf("risk state topic unavailable, snapshots disabled: %v", err)
+        kafkaReady.Store(true)
+        return nil
+    }
+    kafkaReady.Store(true)
+    if config.Get().Processor.RiskStateBootstrap {
+        if err := bootstrapRiskState(brokers); err != nil { log.Printf("risk state bootstrap failed: %v", err) }
+    }
     return nil
 }
 
 func main() {
+    replayFrom := flag.String("replay-from", "", "reprocess messages from this RFC3339 timestamp, then exit")
+    replayOffset := flag.Int64("replay-offset", -1, "reprocess messages from this offset, then exit")
+    replayPartition := flag.Int("replay-partition", -1, "limit replay to one partition (default all)")
+    flag.BoolVar(&reapplyRisk, "reapply-risk", false, "on replay, correct risk adjustments already applied instead of skipping them")
+    configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file; environment variables override it")
+    flag.Parse()
+
+    if err := config.Init(*configFile); err != nil { log.Fatalf("invalid configuration: %v", err) }
+    config.Watch(*configFile)
+
     if err := initConnections(); err != nil {
         log.Fatalf("startup error: %v", err)
     }
 
-    brokers := strings.Split(getenv("KAFKA_BOOTSTRAP_SERVERS", "localhost:9092"), ",")
-    reader := kafka.NewReader(kafka.ReaderConfig{
-        Brokers:  brokers,
-        GroupID:  "fraud-processor-group-go",
-        Topic:    "fraud-transactions",
-        MinBytes: 1,
-        MaxBytes: 10e6,
-    })
-    defer reader.Close()
+    cfg := config.Get()
+    brokers := cfg.Kafka.Brokers
+    // A second instance with PROCESSOR_TOPIC=fraud-transactions-priority
+    // and PROCESSOR_ALERTS_ONLY serves the high-risk fast lane.
+    groupID := cfg.Processor.GroupID
+    topic := cfg.Processor.Topic
+    bus, err := newEventBus(brokers)
+    if err != nil { log.Fatalf("startup error: %v", err) }
+    alerts := bus.Publisher("fraud-alerts")
+    defer alerts.Close()
+
+    replay := *replayFrom != "" || *replayOffset >= 0
+    if cfg.Startup.LazyKafka && !replay {
+        go func() {
+            if err := initKafka(bus, brokers, 0, false); err != nil { log.Printf("kafka init: %v", err) }
+        }()
+    } else if err := initKafka(bus, brokers, cfg.Startup.RetryAttempts, replay); err != nil {
+        log.Fatalf("startup error: %v", err)
+    }
+
+    if reapplyRisk && !replay { log.Fatalf("-reapply-risk only applies to a replay") }
+    if replay {
+        if bus.Name() != "kafka" { log.Fatalf("replay requires EVENT_BUS=kafka") }
+        opts := replayOptions{Offset: *replayOffset, Partition: *replayPartition}
+        if *replayOffset < 0 {
+            from, err := time.Parse(time.RFC3339, *replayFrom)
+            if err != nil { log.Fatalf("invalid -replay-from: %v", err) }
+            opts.From = from
+        }
+        if err := runReplay(brokers, topic, opts, alerts); err != nil { log.Fatalf("replay failed: %v", err) }
+        return
+    }
+
+    sub := bus.Subscriber(topic, groupID)
+    defer sub.Close()
+    // Replays re-read messages the archive already has.
+    initArchive(topic)
+
+    status = newStatusTracker(bus, groupID, topic)
+    go status.run(15 * time.Second)
+    go serveStatus()
+    go runDriftMonitor(alerts)
 
-    alertWriter := &kafka.Writer{ Addr: kafka.TCP(brokers...), Topic: "fraud-alerts", Balancer: &kafka.LeastBytes{} }
-    defer alertWriter.Close()
+    workers := cfg.Processor.Workers
+    if workers == 0 { workers = runtime.NumCPU() }
+    pool := newWorkerPool(workers, cfg.Processor.MaxInFlight, cfg.Processor.BatchSize, cfg.Processor.BatchLinger, sub, alerts)
+    defer pool.close()
+    var dlq publisher
+    if cfg.Processor.DLQTopic != "" {
+        dlq = bus.Publisher(cfg.Processor.DLQTopic)
+        defer dlq.Close()
+    }
 
     log.Println("Go Transaction Processor started")
     for {
-        m, err := reader.ReadMessage(ctx)
+        m, err := sub.Fetch(ctx)
         if err != nil { log.Printf("read error: %v", err); time.Sleep(time.Second); continue }
-        var tx TransactionMessage
-        if err := json.Unmarshal(m.Value, &tx); err != nil { log.Printf("decode error: %v", err); continue }
-        process(tx, alertWriter)
+        archiveMessage(m)
+        var tx events.TransactionEvent
+        if err := events.DecodeTransaction(registry, m.ContentType, m.Value, &tx); err != nil {
+            log.Printf("decode error: %v", err)
+            messagesFailed.WithLabelValues("decode").Inc()
+            sendToDLQ(dlq, m, "", "undecodable", err)
+            pool.skip(m)
+            continue
+        }
+        if bad := checkMessage(m, tx); bad != nil {
+            messagesFailed.WithLabelValues("validation").Inc()
+            sendToDLQ(dlq, m, bad.field, bad.reason, nil)
+            pool.skip(m)
+            continue
+        }
+        pool.submit(m, tx)
     }
 }
 
-func process(tx TransactionMessage, alertWriter *kafka.Writer) {
-    // Update user risk score
-    updateUserRiskScore(tx)
+// process applies tx. Feature rows and Redis cache writes are queued on b;
+// the caller flushes it, typically once for a batch of messages.
+func process(tx events.TransactionEvent, alerts publisher, b *writeBatch) {
+    // The fast lane's events are copies; the main processor applies them to
+    // user state, in each user's order, and its alert for the same
+    // transaction is a no-op once the fast lane has raised it.
+    if config.Get().Processor.AlertsOnly {
+        if tx.IsFraud { generateAlert(tx, alerts) }
+        return
+    }
+    // Update user risk score; the amount profile and category counts follow
+    // it so a replayed message doesn't count twice.
+    if updateUserRiskScore(tx) {
+        updateAmountStats(b.pipe, tx)
+        updateCategoryCounts(b.pipe, tx)
+        recordScore(b.pipe, tx)
+    }
+    b.addMerchant(tx)
     // Store metadata
     storeMetadata(tx)
     // Update feature store
-    updateFeatureStore(tx)
+    updateFeatureStore(b, tx)
     // Cache recent transaction
-    cacheRecent(tx)
+    cacheRecent(b.pipe, tx)
     // Generate alert if needed
-    if tx.IsFraud { generateAlert(tx, alertWriter) }
+    if tx.IsFraud { generateAlert(tx, alerts) }
+    checkCardTesting(tx, alerts)
+    if tx.DuplicateOf != nil && config.Get().Duplicates.Review {
+        raiseAlert(tx, alerts, "POSSIBLE_DUPLICATE", "LOW", "Transaction "+tx.TransactionID+" may duplicate "+*tx.DuplicateOf, 1)
+    }
+    indexTransaction(tx)
+    recordAnalytics(tx)
 }
 
-func updateUserRiskScore(tx TransactionMessage) {
-    var current float64 = 0.5
-    _ = pg.QueryRow(`SELECT risk_score FROM users WHERE user_id = $1`, tx.UserID).Scan(&current)
+// reapplyRisk is set by -reapply-risk: a replay then corrects the risk
+// adjustments already applied to what the current logic gives, instead of
+// leaving them alone.
+var reapplyRisk bool
+
+// updateUserRiskScore applies the transaction's risk adjustment at most once,
+// or re-applies it with reapplyRisk (see store.UserStore), and refreshes the
+// cached score when it changed. It reports whether this was the
+// transaction's first application.
+func updateUserRiskScore(tx events.TransactionEvent) bool {
     adjustment := 0.0
     if tx.IsFraud { adjustment += 0.1 }
     if tx.FraudScore > 0.8 { adjustment += 0.05 }
     if tx.Amount > 5000 { adjustment += 0.03 }
     if !tx.IsFraud && tx.FraudScore < 0.3 { adjustment -= 0.02 }
-    newRisk := current + adjustment
-    if newRisk < 0 { newRisk = 0 }
-    if newRisk > 1 { newRisk = 1 }
-    _, _ = pg.Exec(`UPDATE users SET risk_score = $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2`, newRisk, tx.UserID)
+    // One deadline covers the whole DB transaction.
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    newRisk, first, changed, err := userStore.ApplyRiskAdjustment(qctx, tx.TransactionID, tx.UserID, adjustment, time.Unix(tx.Timestamp, 0).UTC(), reapplyRisk)
+    if err != nil || !changed { return false }
     _ = rdb.Set(ctx, "user_risk:"+tx.UserID, newRisk, time.Hour).Err()
+    publishRiskSnapshot(tx.UserID, newRisk)
+    return first
 }
 
-func storeMetadata(tx TransactionMessage) {
-    _, _ = pg.Exec(`UPDATE transactions SET device_id = $1, ip_address = $2 WHERE transaction_id = $3`, tx.DeviceID, tx.IPAddress, tx.TransactionID)
+// txWindow brackets the event time so lookups only touch the monthly
+// partitions the transaction can be in; the API stores the row moments
+// before publishing the event.
+func txWindow(tx events.TransactionEvent) (time.Time, time.Time) {
+    ts := time.Unix(tx.Timestamp, 0).UTC()
+    return ts.Add(-time.Hour), ts.Add(time.Hour)
 }
 
-func updateFeatureStore(tx TransactionMessage) {
-    now := time.Now()
-    _, _ = pg.Exec(`INSERT INTO feature_store (user_id, feature_name, feature_value, feature_timestamp) VALUES ($1,$2,$3,$4)`, tx.UserID, "transaction_amount", tx.Amount, now)
-    _, _ = pg.Exec(`INSERT INTO feature_store (user_id, feature_name, feature_value, feature_timestamp) VALUES ($1,$2,$3,$4)`, tx.UserID, "fraud_score", tx.FraudScore, now)
+func storeMetadata(tx events.TransactionEvent) {
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    from, to := txWindow(tx)
+    _ = txStore.UpdateMetadata(qctx, tx.TransactionID, from, to, tx.DeviceID, tx.IPAddress)
 }
 
-func cacheRecent(tx TransactionMessage) {
+func updateFeatureStore(b *writeBatch, tx events.TransactionEvent) {
+    b.addFeature(tx, "transaction_amount", tx.Amount)
+    b.addFeature(tx, "fraud_score", tx.FraudScore)
+}
+
+func cacheRecent(pipe redis.Pipeliner, tx events.TransactionEvent) {
     key := "recent_transaction:" + tx.TransactionID
     b, _ := json.Marshal(tx)
-    _ = rdb.Set(ctx, key, string(b), 30*time.Minute).Err()
+    pipe.Set(ctx, key, string(b), 30*time.Minute)
     listKey := "user_recent_transactions:" + tx.UserID
-    // Prepend tx id, trim to last 10
-    _ = rdb.LPush(ctx, listKey, tx.TransactionID).Err()
-    _ = rdb.LTrim(ctx, listKey, 0, 9).Err()
-    _ = rdb.Expire(ctx, listKey, time.Hour).Err()
+    // Prepend tx id (dropping any earlier copy), trim to last 10
+    pipe.LRem(ctx, listKey, 0, tx.TransactionID)
+    pipe.LPush(ctx, listKey, tx.TransactionID)
+    pipe.LTrim(ctx, listKey, 0, 9)
+    pipe.Expire(ctx, listKey, time.Hour)
 }
 
-func generateAlert(tx TransactionMessage, alertWriter *kafka.Writer) {
+func generateAlert(tx events.TransactionEvent, alerts publisher) {
     severity := "MEDIUM"
     if tx.FraudScore > 0.9 { severity = "CRITICAL" } else if tx.FraudScore > 0.8 { severity = "HIGH" }
-    alertID := "ALERT_" + strconvFormat(time.Now().Unix()) + "_" + shortID(tx.TransactionID)
-    description := "Fraud detected for transaction " + tx.TransactionID
-    _, _ = pg.Exec(`INSERT INTO fraud_alerts (alert_id, transaction_id, alert_type, severity, description, confidence_score, status) VALUES ($1,$2,$3,$4,$5,$6,$7)`,
-        alertID, tx.TransactionID, "FRAUD_DETECTED", severity, description, tx.FraudScore, "OPEN")
-    payload := map[string]interface{}{
-        "alert_id": alertID,
-        "transaction_id": tx.TransactionID,
-        "user_id": tx.UserID,
-        "alert_type": "FRAUD_DETECTED",
-        "severity": severity,
-        "description": description,
-        "fraud_score": tx.FraudScore,
-        "timestamp": time.Now().Unix(),
+    raiseAlert(tx, alerts, "FRAUD_DETECTED", severity, "Fraud detected for transaction "+tx.TransactionID, tx.FraudScore)
+}
+
+// raiseAlert stores an alert on tx and publishes it to the alerts topic. An
+// alert matching a suppression is stored as SUPPRESSED and not published.
+func raiseAlert(tx events.TransactionEvent, alerts publisher, alertType, severity, description string, confidence float64) {
+    alertID := events.NewAlertID()
+    a := store.Alert{
+        AlertID:       alertID,
+        TransactionID: tx.TransactionID,
+        AlertType:     alertType,
+        Severity:      severity,
+        Description:   description,
+        Confidence:    confidence,
+        Status:        "OPEN",
     }
-    b, _ := json.Marshal(payload)
-    _ = alertWriter.WriteMessages(ctx, kafka.Message{Value: b})
+    if a.SuppressedBy = suppressedBy(tx.MerchantID, alertType, severity, time.Now()); a.SuppressedBy != nil { a.Status = "SUPPRESSED" }
+    // One alert per transaction and type; a replayed message doesn't re-alert.
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    from, _ := txWindow(tx)
+    created, err := alertStore.CreateOnce(qctx, a, from)
+    if err != nil { log.Printf("store alert: %v", err); return }
+    if !created { return }
+    if a.SuppressedBy != nil { alertsSuppressed.WithLabelValues(alertType).Inc(); return }
+    groupAlert(tx, alertID)
+    published := publishAlert(alerts, tx.UserID, events.AlertEvent{
+        AlertID:       alertID,
+        TransactionID: tx.TransactionID,
+        UserID:        tx.UserID,
+        AlertType:     alertType,
+        Severity:      severity,
+        Description:   description,
+        FraudScore:    tx.FraudScore,
+        Timestamp:     time.Now().Unix(),
+    })
+    if !published { return }
+    qctx, cancel = conn.QueryCtx(ctx)
+    defer cancel()
+    if err := alertStore.RecordActivity(qctx, alertID, "alert.notified", map[string]string{"channel": "fraud-alerts"}); err != nil { log.Printf("record alert activity: %v", err) }
 }
 
-func shortID(id string) string {
-    if len(id) <= 8 { return id }
-    return id[:8]
+// publishAlert indexes ev and sends it to the alerts topic, reporting
+// whether it was sent.
+func publishAlert(alerts publisher, key string, ev events.AlertEvent) bool {
+    indexAlert(ev)
+    codec := events.ProtobufCodec
+    if kafkaReady.Load() { codec = alertCodec }
+    b, err := codec.Encode(ev)
+    if err != nil { log.Printf("encode alert event: %v", err); return false }
+    if err := alerts.Publish([]byte(key), b, codec.ContentType()); err != nil { log.Printf("publish alert: %v", err); return false }
+    return true
 }
 
 func strconvFormat(v int64) string { return fmt.Sprintf("%d", v) }
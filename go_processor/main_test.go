@@ -0,0 +1,271 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "math"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/go-redis/redis/v8"
+    "go.uber.org/mock/gomock"
+
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/go_processor/internal/store/mocks"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+func str(s string) *string { return &s }
+
+// swap sets *p to v for the rest of the test.
+func swap[T any](t *testing.T, p *T, v T) {
+    old := *p
+    *p = v
+    t.Cleanup(func() { *p = old })
+}
+
+type mockStores struct {
+    users     *mocks.MockUserStore
+    txs       *mocks.MockTransactionStore
+    features  *mocks.MockFeatureStore
+    merchants *mocks.MockMerchantStore
+    alerts    *mocks.MockAlertStore
+}
+
+// processorEnv loads the default configuration on top of env, points the
+// Redis client at an in-memory server and the stores at mocks. Every store
+// call a test makes must be expected.
+func processorEnv(t *testing.T, env map[string]string) (mockStores, *miniredis.Miniredis) {
+    t.Helper()
+    for k, v := range env { t.Setenv(k, v) }
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    mr := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+    t.Cleanup(func() { client.Close() })
+    swap(t, &rdb, client)
+
+    ctrl := gomock.NewController(t)
+    s := mockStores{
+        users:     mocks.NewMockUserStore(ctrl),
+        txs:       mocks.NewMockTransactionStore(ctrl),
+        features:  mocks.NewMockFeatureStore(ctrl),
+        merchants: mocks.NewMockMerchantStore(ctrl),
+        alerts:    mocks.NewMockAlertStore(ctrl),
+    }
+    swap[store.UserStore](t, &userStore, s.users)
+    swap[store.TransactionStore](t, &txStore, s.txs)
+    swap[store.FeatureStore](t, &featureStore, s.features)
+    swap[store.MerchantStore](t, &merchantStore, s.merchants)
+    swap[store.AlertStore](t, &alertStore, s.alerts)
+    prev := suppressions.Swap(nil)
+    t.Cleanup(func() { suppressions.Store(prev) })
+    return s, mr
+}
+
+type published struct {
+    key, value  []byte
+    contentType string
+}
+
+// recordingPublisher keeps what is published to it, or fails with err.
+type recordingPublisher struct {
+    mu   sync.Mutex
+    msgs []published
+    err  error
+}
+
+func (p *recordingPublisher) Publish(key, value []byte, contentType string) error {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.err != nil { return p.err }
+    p.msgs = append(p.msgs, published{key, value, contentType})
+    return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// A fraudulent transaction raises the user's risk, caches it, queues its
+// features and merchant, and raises a published, grouped alert.
+func TestProcessFraud(t *testing.T) {
+    s, mr := processorEnv(t, nil)
+    now := time.Now()
+    tx := events.TransactionEvent{TransactionID: "tx-1", UserID: "user-1", Amount: 6000, FraudScore: 0.95, IsFraud: true, Timestamp: now.Unix(),
+        DeviceID: str("dev-1"), IPAddress: str("203.0.113.7"), MerchantID: str("m-1"), MCC: str("5411")}
+    from, to := txWindow(tx)
+
+    var alert store.Alert
+    s.users.EXPECT().ApplyRiskAdjustment(gomock.Any(), "tx-1", "user-1", gomock.Any(), time.Unix(tx.Timestamp, 0).UTC(), false).Return(0.68, true, true, nil)
+    s.txs.EXPECT().UpdateMetadata(gomock.Any(), "tx-1", from, to, tx.DeviceID, tx.IPAddress).Return(nil)
+    s.alerts.EXPECT().CreateOnce(gomock.Any(), gomock.Any(), from).DoAndReturn(func(_ context.Context, a store.Alert, _ time.Time) (bool, error) {
+        alert = a
+        return true, nil
+    })
+    s.alerts.EXPECT().GroupIntoCase(gomock.Any(), gomock.Any(), []store.CaseEntity{{Type: "user", Value: "user-1"}, {Type: "device", Value: "dev-1"}}, gomock.Any()).
+        DoAndReturn(func(_ context.Context, alertID string, _ []store.CaseEntity, _ time.Time) (int64, bool, error) {
+            if alertID != alert.AlertID { t.Errorf("grouped alert %s, stored %s", alertID, alert.AlertID) }
+            return 1, true, nil
+        })
+    s.alerts.EXPECT().RecordActivity(gomock.Any(), gomock.Any(), "alert.notified", map[string]string{"channel": "fraud-alerts"}).Return(nil)
+    s.features.EXPECT().UpsertFeatures(gomock.Any(), []store.Feature{
+        {UserID: "user-1", TransactionID: "tx-1", Name: "transaction_amount", Value: 6000, Timestamp: time.Unix(tx.Timestamp, 0)},
+        {UserID: "user-1", TransactionID: "tx-1", Name: "fraud_score", Value: 0.95, Timestamp: time.Unix(tx.Timestamp, 0)},
+    }).Return(nil)
+    s.merchants.EXPECT().UpsertMerchants(gomock.Any(), []store.Merchant{{MerchantID: "m-1", MCC: "5411"}}).Return(nil)
+
+    alerts := &recordingPublisher{}
+    b := newWriteBatch()
+    process(tx, alerts, b)
+    b.flush(1)
+
+    if alert.TransactionID != "tx-1" || alert.AlertType != "FRAUD_DETECTED" || alert.Severity != "CRITICAL" || alert.Status != "OPEN" || alert.SuppressedBy != nil { t.Errorf("stored alert %+v", alert) }
+    if len(alerts.msgs) != 1 { t.Fatalf("published %d alerts, want 1", len(alerts.msgs)) }
+    m := alerts.msgs[0]
+    if string(m.key) != "user-1" || m.contentType != events.ContentTypeProtobuf || !bytes.Contains(m.value, []byte(alert.AlertID)) { t.Errorf("published %q %s %q", m.key, m.contentType, m.value) }
+
+    if risk, _ := mr.Get("user_risk:user-1"); risk != "0.68" { t.Errorf("cached risk %q, want 0.68", risk) }
+    if !mr.Exists("recent_transaction:tx-1") { t.Error("recent transaction not cached") }
+    if recent, _ := mr.List("user_recent_transactions:user-1"); len(recent) != 1 || recent[0] != "tx-1" { t.Errorf("recent transactions %v", recent) }
+    if n := mr.HGet("user_amount_stats:user-1", "n"); n != "1" { t.Errorf("amount stats n %q, want 1", n) }
+    if n := mr.HGet("user_categories:user-1", "_total"); n != "1" { t.Errorf("category total %q, want 1", n) }
+}
+
+// A redelivered message changes nothing it already changed: no risk cache
+// write, no profile counts and no second alert.
+func TestProcessRedelivered(t *testing.T) {
+    s, mr := processorEnv(t, nil)
+    tx := events.TransactionEvent{TransactionID: "tx-1", UserID: "user-1", Amount: 50, FraudScore: 0.85, IsFraud: true, Timestamp: time.Now().Unix(), MCC: str("5411")}
+    s.users.EXPECT().ApplyRiskAdjustment(gomock.Any(), "tx-1", "user-1", gomock.Any(), gomock.Any(), false).Return(0.5, false, false, nil)
+    s.txs.EXPECT().UpdateMetadata(gomock.Any(), "tx-1", gomock.Any(), gomock.Any(), nil, nil).Return(nil)
+    s.alerts.EXPECT().CreateOnce(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+    s.features.EXPECT().UpsertFeatures(gomock.Any(), gomock.Len(2)).Return(nil)
+
+    alerts := &recordingPublisher{}
+    b := newWriteBatch()
+    process(tx, alerts, b)
+    b.flush(1)
+
+    if len(alerts.msgs) != 0 { t.Errorf("published %d alerts for a redelivered message", len(alerts.msgs)) }
+    if mr.Exists("user_risk:user-1") { t.Error("risk cached though unchanged") }
+    if mr.Exists("user_amount_stats:user-1") || mr.Exists("user_categories:user-1") || mr.Exists(driftKey("1h", tx.Timestamp/3600)) { t.Error("profile counted twice") }
+}
+
+// The fast lane only raises alerts; user state is the main processor's.
+func TestProcessAlertsOnly(t *testing.T) {
+    s, _ := processorEnv(t, map[string]string{"PROCESSOR_ALERTS_ONLY": "true"})
+    now := time.Now().Unix()
+    s.alerts.EXPECT().CreateOnce(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+    s.alerts.EXPECT().GroupIntoCase(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), true, nil)
+    s.alerts.EXPECT().RecordActivity(gomock.Any(), gomock.Any(), "alert.notified", gomock.Any()).Return(nil)
+
+    alerts := &recordingPublisher{}
+    b := newWriteBatch()
+    process(events.TransactionEvent{TransactionID: "tx-1", UserID: "user-1", Amount: 50, FraudScore: 0.2, Timestamp: now}, alerts, b)
+    process(events.TransactionEvent{TransactionID: "tx-2", UserID: "user-1", Amount: 50, FraudScore: 0.85, IsFraud: true, Timestamp: now}, alerts, b)
+    b.flush(2)
+    if len(alerts.msgs) != 1 { t.Errorf("published %d alerts, want 1", len(alerts.msgs)) }
+}
+
+func TestUpdateUserRiskScore(t *testing.T) {
+    s, mr := processorEnv(t, nil)
+    cases := []struct {
+        name    string
+        isFraud bool
+        score   float64
+        amount  float64
+        changed bool
+        want    float64
+    }{
+        {"flagged, high score, large amount", true, 0.95, 6000, true, 0.18},
+        {"flagged", true, 0.7, 100, true, 0.1},
+        {"high score, not flagged", false, 0.85, 100, true, 0.05},
+        {"large amount", false, 0.5, 6000, true, 0.03},
+        {"low score", false, 0.1, 100, true, -0.02},
+        {"low score, large amount", false, 0.1, 6000, true, 0.01},
+        {"unchanged", false, 0.5, 100, false, 0},
+    }
+    for _, c := range cases {
+        mr.FlushAll()
+        tx := events.TransactionEvent{TransactionID: "tx", UserID: "user-1", Amount: c.amount, FraudScore: c.score, IsFraud: c.isFraud, Timestamp: time.Now().Unix()}
+        var got float64
+        s.users.EXPECT().ApplyRiskAdjustment(gomock.Any(), "tx", "user-1", gomock.Any(), gomock.Any(), false).
+            DoAndReturn(func(_ context.Context, _, _ string, adjustment float64, _ time.Time, _ bool) (float64, bool, bool, error) {
+                got = adjustment
+                return 0.5 + adjustment, c.changed, c.changed, nil
+            })
+        first := updateUserRiskScore(tx)
+        if math.Abs(got-c.want) > 1e-9 { t.Errorf("%s: adjustment %v, want %v", c.name, got, c.want) }
+        if first != c.changed { t.Errorf("%s: first %v, want %v", c.name, first, c.changed) }
+        if mr.Exists("user_risk:user-1") != c.changed { t.Errorf("%s: risk cached %v, want %v", c.name, !c.changed, c.changed) }
+    }
+
+    // A failed update is not the first application and caches nothing.
+    mr.FlushAll()
+    s.users.EXPECT().ApplyRiskAdjustment(gomock.Any(), "tx", "user-1", gomock.Any(), gomock.Any(), false).Return(0.0, false, false, errors.New("connection reset"))
+    if updateUserRiskScore(events.TransactionEvent{TransactionID: "tx", UserID: "user-1", Timestamp: time.Now().Unix()}) { t.Error("failed update reported as first") }
+    if mr.Exists("user_risk:user-1") { t.Error("risk cached after a failed update") }
+}
+
+func TestGenerateAlertSeverity(t *testing.T) {
+    s, _ := processorEnv(t, map[string]string{"CASE_WINDOW_HOURS": "0"})
+    cases := []struct {
+        score float64
+        want  string
+    }{
+        {0.95, "CRITICAL"},
+        {0.85, "HIGH"},
+        {0.8, "MEDIUM"},
+        {0.6, "MEDIUM"},
+    }
+    for _, c := range cases {
+        var got string
+        s.alerts.EXPECT().CreateOnce(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a store.Alert, _ time.Time) (bool, error) {
+            got = a.Severity
+            return false, nil
+        })
+        generateAlert(events.TransactionEvent{TransactionID: "tx", UserID: "user-1", FraudScore: c.score, IsFraud: true, Timestamp: time.Now().Unix()}, &recordingPublisher{})
+        if got != c.want { t.Errorf("score %v: severity %s, want %s", c.score, got, c.want) }
+    }
+}
+
+// An alert matching a suppression is stored as SUPPRESSED and goes no
+// further; one that fails to publish is not recorded as notified.
+func TestRaiseAlert(t *testing.T) {
+    s, _ := processorEnv(t, nil)
+    now := time.Now()
+    suppressions.Store(&[]store.Suppression{
+        {ID: 9, MerchantID: "m-1", AlertType: "fraud_detected", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+        {ID: 10, MerchantID: "m-2", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+    })
+    cases := []struct {
+        name       string
+        merchant   string
+        publishErr error
+        wantStatus string
+        published  bool
+        notified   bool
+    }{
+        {"open", "m-2", nil, "OPEN", true, true},
+        {"suppressed", "m-1", nil, "SUPPRESSED", false, false},
+        {"publish fails", "m-2", errors.New("broker down"), "OPEN", false, false},
+    }
+    for _, c := range cases {
+        var stored store.Alert
+        s.alerts.EXPECT().CreateOnce(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, a store.Alert, _ time.Time) (bool, error) {
+            stored = a
+            return true, nil
+        })
+        if c.wantStatus == "OPEN" { s.alerts.EXPECT().GroupIntoCase(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), false, nil) }
+        if c.notified { s.alerts.EXPECT().RecordActivity(gomock.Any(), gomock.Any(), "alert.notified", gomock.Any()).Return(nil) }
+
+        alerts := &recordingPublisher{err: c.publishErr}
+        tx := events.TransactionEvent{TransactionID: "tx", UserID: "user-1", MerchantID: str(c.merchant), FraudScore: 0.85, IsFraud: true, Timestamp: now.Unix()}
+        raiseAlert(tx, alerts, "FRAUD_DETECTED", "HIGH", "test", 0.85)
+        if stored.Status != c.wantStatus { t.Errorf("%s: stored as %s, want %s", c.name, stored.Status, c.wantStatus) }
+        if (stored.SuppressedBy != nil) != (c.wantStatus == "SUPPRESSED") || (stored.SuppressedBy != nil && *stored.SuppressedBy != 9) { t.Errorf("%s: suppressed by %v", c.name, stored.SuppressedBy) }
+        if (len(alerts.msgs) == 1) != c.published { t.Errorf("%s: published %d alerts", c.name, len(alerts.msgs)) }
+    }
+}
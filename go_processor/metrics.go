@@ -0,0 +1,47 @@
+package main
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+    messagesProcessed = promauto.NewCounter(prometheus.CounterOpts{
+        Name: "fraud_processor_messages_processed_total",
+        Help: "Transaction messages processed.",
+    })
+    messagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_messages_failed_total",
+        Help: "Transaction messages that could not be processed, by stage.",
+    }, []string{"stage"})
+    processingLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+        Name:    "fraud_processor_processing_seconds",
+        Help:    "Time spent processing one transaction message, not counting the batch flush.",
+        Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+    })
+    batchFlushLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+        Name:    "fraud_processor_batch_flush_seconds",
+        Help:    "Time spent writing one batch's feature_store rows and Redis updates.",
+        Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+    })
+    messagesPerSecond = promauto.NewGauge(prometheus.GaugeOpts{
+        Name: "fraud_processor_messages_per_second",
+        Help: "Processing throughput over the last status interval.",
+    })
+    consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "fraud_processor_consumer_lag",
+        Help: "Messages between the group's committed offset and the high watermark, by partition.",
+    }, []string{"topic", "partition"})
+    cardTestingDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_card_testing_detected_total",
+        Help: "Card-testing bursts detected, by source (ip or device).",
+    }, []string{"source"})
+    alertsSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_alerts_suppressed_total",
+        Help: "Alerts stored as SUPPRESSED instead of raised, by alert type.",
+    }, []string{"alert_type"})
+    alertsGrouped = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_alerts_grouped_total",
+        Help: "Alerts filed under a case, by whether they opened it.",
+    }, []string{"case"})
+)
@@ -0,0 +1,115 @@
+package main
+
+import (
+    "hash/fnv"
+    "log"
+    "sync"
+    "time"
+
+    "example.com/fraud/internal/events"
+)
+
+type job struct {
+    msg busMessage
+    tx  events.TransactionEvent
+}
+
+// workerPool processes messages concurrently while preserving per-user order:
+// each user_id hashes to one worker, and a worker handles its queue serially.
+// The in-flight semaphore bounds how far the reader can run ahead of the
+// slowest worker.
+//
+// A worker takes up to batchSize queued messages, waiting at most linger for
+// more to arrive, and flushes their feature_store rows and Redis writes
+// together (see writeBatch). Offsets are committed only after the flush.
+type workerPool struct {
+    queues    []chan job
+    inflight  chan struct{}
+    sub       subscriber
+    batchSize int
+    linger    time.Duration
+    wg        sync.WaitGroup
+}
+
+func newWorkerPool(workers, maxInFlight, batchSize int, linger time.Duration, sub subscriber, alerts publisher) *workerPool {
+    if workers < 1 { workers = 1 }
+    if maxInFlight < workers { maxInFlight = workers }
+    if batchSize < 1 { batchSize = 1 }
+    p := &workerPool{
+        queues:    make([]chan job, workers),
+        inflight:  make(chan struct{}, maxInFlight),
+        sub:       sub,
+        batchSize: batchSize,
+        linger:    linger,
+    }
+    for i := range p.queues {
+        p.queues[i] = make(chan job, maxInFlight/workers+1)
+        p.wg.Add(1)
+        go p.work(p.queues[i], alerts)
+    }
+    return p
+}
+
+func (p *workerPool) work(queue chan job, alerts publisher) {
+    defer p.wg.Done()
+    batch := make([]job, 0, p.batchSize)
+    for j := range queue {
+        batch = append(batch[:0], j)
+        deadline := time.After(p.linger)
+    fill:
+        for len(batch) < p.batchSize {
+            // Take whatever is already queued, then wait out the linger.
+            select {
+            case j, ok := <-queue:
+                if !ok { break fill }
+                batch = append(batch, j)
+                continue
+            default:
+            }
+            if p.linger <= 0 { break }
+            select {
+            case j, ok := <-queue:
+                if !ok { break fill }
+                batch = append(batch, j)
+            case <-deadline:
+                break fill
+            }
+        }
+        b := newWriteBatch()
+        for _, j := range batch {
+            start := time.Now()
+            process(j.tx, alerts, b)
+            status.observe(time.Since(start))
+        }
+        start := time.Now()
+        b.flush(len(batch))
+        batchFlushLatency.Observe(time.Since(start).Seconds())
+        for _, j := range batch { p.done(j.msg) }
+    }
+}
+
+// submit blocks while maxInFlight messages are outstanding.
+func (p *workerPool) submit(m busMessage, tx events.TransactionEvent) {
+    p.inflight <- struct{}{}
+    h := fnv.New32a()
+    h.Write([]byte(tx.UserID))
+    p.queues[h.Sum32()%uint32(len(p.queues))] <- job{msg: m, tx: tx}
+}
+
+// skip acknowledges a message that won't be processed (e.g. undecodable) so
+// it doesn't hold back the partition's committed offset.
+func (p *workerPool) skip(m busMessage) {
+    p.inflight <- struct{}{}
+    p.done(m)
+}
+
+func (p *workerPool) done(m busMessage) {
+    if err := p.sub.Commit(m); err != nil { log.Printf("commit error: %v", err) }
+    <-p.inflight
+}
+
+// close drains the queues and waits for in-flight work to finish.
+func (p *workerPool) close() {
+    for _, q := range p.queues { close(q) }
+    p.wg.Wait()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+
+    "go.uber.org/mock/gomock"
+
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/internal/events"
+)
+
+// recordingSubscriber keeps the messages committed to it.
+type recordingSubscriber struct {
+    mu        sync.Mutex
+    committed map[string]bool
+}
+
+func (s *recordingSubscriber) Fetch(context.Context) (busMessage, error) { return busMessage{}, errors.New("not fetched in tests") }
+
+func (s *recordingSubscriber) Commit(m busMessage) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.committed[m.ID] = true
+    return nil
+}
+
+func (s *recordingSubscriber) Close() error { return nil }
+
+func (s *recordingSubscriber) isCommitted(id string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.committed[id]
+}
+
+// Each user's messages are processed in order, a batch's offsets are
+// committed only once it is flushed, and skipped messages are committed
+// without processing.
+func TestWorkerPool(t *testing.T) {
+    s, _ := processorEnv(t, nil)
+    swap(t, &status, newStatusTracker(nil, "group", "topic"))
+    sub := &recordingSubscriber{committed: map[string]bool{}}
+
+    var mu sync.Mutex
+    seen := map[string][]int{}
+    flushed := 0
+    s.users.EXPECT().ApplyRiskAdjustment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), false).AnyTimes().
+        DoAndReturn(func(_ context.Context, transactionID, userID string, _ float64, _ time.Time, _ bool) (float64, bool, bool, error) {
+            seq, _ := strconv.Atoi(transactionID[len(userID)+1:])
+            mu.Lock()
+            seen[userID] = append(seen[userID], seq)
+            mu.Unlock()
+            return 0.5, false, false, nil
+        })
+    s.txs.EXPECT().UpdateMetadata(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), nil, nil).AnyTimes().Return(nil)
+    s.features.EXPECT().UpsertFeatures(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, rows []store.Feature) error {
+        for _, f := range rows {
+            if sub.isCommitted(f.TransactionID) { t.Errorf("%s committed before its batch was flushed", f.TransactionID) }
+        }
+        mu.Lock()
+        flushed += len(rows)
+        mu.Unlock()
+        return nil
+    })
+
+    const users, perUser = 5, 6
+    p := newWorkerPool(3, 8, 4, 10*time.Millisecond, sub, &recordingPublisher{})
+    for i := 0; i < perUser; i++ {
+        for u := 0; u < users; u++ {
+            userID := "user" + strconv.Itoa(u)
+            id := userID + "-" + strconv.Itoa(i)
+            p.submit(busMessage{ID: id}, events.TransactionEvent{TransactionID: id, UserID: userID, Amount: 50, FraudScore: 0.5, Timestamp: time.Now().Unix()})
+        }
+    }
+    p.skip(busMessage{ID: "undecodable"})
+    p.close()
+
+    for u := 0; u < users; u++ {
+        userID := "user" + strconv.Itoa(u)
+        got := seen[userID]
+        if len(got) != perUser { t.Errorf("%s: processed %d messages, want %d", userID, len(got), perUser); continue }
+        for i, seq := range got {
+            if seq != i { t.Errorf("%s: processed in order %v", userID, got); break }
+        }
+    }
+    if flushed != 2*users*perUser { t.Errorf("flushed %d features, want %d", flushed, 2*users*perUser) }
+    if len(sub.committed) != users*perUser+1 || !sub.committed["undecodable"] { t.Errorf("committed %d messages, want %d", len(sub.committed), users*perUser+1) }
+    if n := status.processed.Load(); n != users*perUser { t.Errorf("observed %d messages, want %d", n, users*perUser) }
+    if len(p.inflight) != 0 { t.Errorf("%d messages still in flight", len(p.inflight)) }
+}
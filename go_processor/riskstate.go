@@ -0,0 +1,82 @@
+package main
+
+import (
+    "errors"
+    "log"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+    "example.com/fraud/internal/events"
+)
+
+const riskStateTopic = "user-risk-state"
+
+var (
+    riskStateWriter *kafka.Writer
+    riskStateCodec  events.Codec
+)
+
+// initRiskState creates the compacted topic if it doesn't exist yet and
+// prepares the snapshot writer.
+func initRiskState(brokers []string) error {
+    client := conn.KafkaClient(brokers, 10*time.Second)
+    resp, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: []kafka.TopicConfig{{
+        Topic:             riskStateTopic,
+        NumPartitions:     config.Get().Processor.RiskStatePartitions,
+        ReplicationFactor: config.Get().Processor.RiskStateReplication,
+        ConfigEntries:     []kafka.ConfigEntry{{ConfigName: "cleanup.policy", ConfigValue: "compact"}},
+    }}})
+    if err != nil { return err }
+    if err := resp.Errors[riskStateTopic]; err != nil && !errors.Is(err, kafka.TopicAlreadyExists) { return err }
+
+    riskStateCodec, err = events.NewCodec(registry, riskStateTopic, events.UserRiskSnapshotSchema)
+    if err != nil { return err }
+    riskStateWriter = conn.NewKafkaWriter(brokers, riskStateTopic)
+    return nil
+}
+
+func publishRiskSnapshot(userID string, risk float64) {
+    if !kafkaReady.Load() || riskStateWriter == nil { return }
+    b, err := riskStateCodec.Encode(events.UserRiskSnapshot{UserID: userID, RiskScore: risk, UpdatedAt: time.Now().Unix()})
+    if err != nil { log.Printf("encode risk snapshot: %v", err); return }
+    _ = riskStateWriter.WriteMessages(ctx, kafka.Message{Key: []byte(userID), Value: b, Headers: []kafka.Header{{Key: "content-type", Value: []byte(riskStateCodec.ContentType())}}})
+}
+
+// bootstrapRiskState reads the compacted topic from the beginning up to its
+// current end and loads every user's latest score into the Redis risk cache,
+// so a fresh instance starts warm without scanning the users table.
+func bootstrapRiskState(brokers []string) error {
+    client := conn.KafkaClient(brokers, 10*time.Second)
+    meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{riskStateTopic}})
+    if err != nil { return err }
+    var reqs []kafka.OffsetRequest
+    for _, t := range meta.Topics {
+        for _, p := range t.Partitions { reqs = append(reqs, kafka.FirstOffsetOf(p.ID), kafka.LastOffsetOf(p.ID)) }
+    }
+    offsets, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{riskStateTopic: reqs}})
+    if err != nil { return err }
+
+    loaded := 0
+    for _, o := range offsets.Topics[riskStateTopic] {
+        if o.Error != nil { return o.Error }
+        if o.FirstOffset >= o.LastOffset { continue }
+        r := kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, Dialer: conn.KafkaDialer(), Topic: riskStateTopic, Partition: o.Partition, MinBytes: 1, MaxBytes: 10e6})
+        if err := r.SetOffset(o.FirstOffset); err != nil { r.Close(); return err }
+        for {
+            m, err := r.FetchMessage(ctx)
+            if err != nil { r.Close(); return err }
+            var snap events.UserRiskSnapshot
+            if err := events.DecodeRiskSnapshot(registry, conn.Header(m, "content-type"), m.Value, &snap); err == nil && snap.UserID != "" {
+                _ = rdb.Set(ctx, "user_risk:"+snap.UserID, snap.RiskScore, time.Hour).Err()
+                loaded++
+            }
+            if m.Offset >= o.LastOffset-1 { break }
+        }
+        r.Close()
+    }
+    log.Printf("bootstrapped %d user risk snapshots from %s", loaded, riskStateTopic)
+    return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+    "example.com/fraud/internal/mcc"
+    "example.com/fraud/internal/search"
+)
+
+var (
+    searchIndexed = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_search_indexed_total",
+        Help: "Documents indexed into the search cluster, by kind.",
+    }, []string{"kind"})
+    searchDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_search_dropped_total",
+        Help: "Documents not indexed, by reason (queue_full or bulk_failed).",
+    }, []string{"reason"})
+)
+
+var transactionMappings = map[string]interface{}{
+    "transaction_id": map[string]string{"type": "keyword"},
+    "user_id":        map[string]string{"type": "keyword"},
+    "amount":         map[string]string{"type": "double"},
+    "fraud_score":    map[string]string{"type": "float"},
+    "is_fraud":       map[string]string{"type": "boolean"},
+    "timestamp":      map[string]string{"type": "date"},
+    "device_id":      map[string]string{"type": "keyword"},
+    "ip_address":     map[string]string{"type": "ip"},
+    "merchant_id":    map[string]string{"type": "keyword"},
+    "mcc":            map[string]string{"type": "keyword"},
+    "category":       map[string]string{"type": "keyword"},
+    "channel":        map[string]string{"type": "keyword"},
+    "country":        map[string]string{"type": "keyword"},
+    "duplicate_of":   map[string]string{"type": "keyword"},
+}
+
+var alertMappings = map[string]interface{}{
+    "alert_id":       map[string]string{"type": "keyword"},
+    "transaction_id": map[string]string{"type": "keyword"},
+    "user_id":        map[string]string{"type": "keyword"},
+    "alert_type":     map[string]string{"type": "keyword"},
+    "severity":       map[string]string{"type": "keyword"},
+    "description":    map[string]string{"type": "text"},
+    "fraud_score":    map[string]string{"type": "float"},
+    "timestamp":      map[string]string{"type": "date"},
+}
+
+type transactionDoc struct {
+    TransactionID string    `json:"transaction_id"`
+    UserID        string    `json:"user_id"`
+    Amount        float64   `json:"amount"`
+    FraudScore    float64   `json:"fraud_score"`
+    IsFraud       bool      `json:"is_fraud"`
+    Timestamp     time.Time `json:"timestamp"`
+    DeviceID      *string   `json:"device_id,omitempty"`
+    IPAddress     *string   `json:"ip_address,omitempty"`
+    MerchantID    *string   `json:"merchant_id,omitempty"`
+    MCC           *string   `json:"mcc,omitempty"`
+    Category      string    `json:"category,omitempty"`
+    Channel       *string   `json:"channel,omitempty"`
+    Country       *string   `json:"country,omitempty"`
+    DuplicateOf   *string   `json:"duplicate_of,omitempty"`
+}
+
+type alertDoc struct {
+    AlertID       string    `json:"alert_id"`
+    TransactionID string    `json:"transaction_id,omitempty"`
+    UserID        string    `json:"user_id,omitempty"`
+    AlertType     string    `json:"alert_type"`
+    Severity      string    `json:"severity"`
+    Description   string    `json:"description"`
+    FraudScore    float64   `json:"fraud_score"`
+    Timestamp     time.Time `json:"timestamp"`
+}
+
+// searchIndexer indexes documents in bulk in the background. Search is a
+// secondary copy for investigations: documents that can't be indexed are
+// dropped and counted, and a replay indexes them again (by the same id).
+type searchIndexer struct {
+    client *search.Client
+    queue  chan search.BulkItem
+}
+
+// indexer is nil unless search.url is set.
+var indexer *searchIndexer
+
+func initSearch() {
+    cfg := config.Get().Search
+    if cfg.URL == "" { return }
+    x := &searchIndexer{
+        client: search.NewClient(cfg.URL, cfg.Username, cfg.Password, cfg.IndexPrefix),
+        queue:  make(chan search.BulkItem, cfg.QueueSize),
+    }
+    // Without the templates the cluster guesses mappings from the first
+    // document, e.g. ip_address as text; indexing still works.
+    tctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+    defer cancel()
+    for kind, props := range map[string]map[string]interface{}{search.Transactions: transactionMappings, search.Alerts: alertMappings} {
+        if err := x.client.PutTemplate(tctx, kind, props); err != nil { log.Printf("search template %s: %v", kind, err) }
+    }
+    indexer = x
+    go x.run(cfg.BatchSize, cfg.FlushInterval)
+}
+
+func indexTransaction(tx events.TransactionEvent) {
+    if indexer == nil { return }
+    ts := time.Unix(tx.Timestamp, 0).UTC()
+    doc := transactionDoc{
+        TransactionID: tx.TransactionID,
+        UserID:        tx.UserID,
+        Amount:        tx.Amount,
+        FraudScore:    tx.FraudScore,
+        IsFraud:       tx.IsFraud,
+        Timestamp:     ts,
+        DeviceID:      nonEmpty(tx.DeviceID),
+        IPAddress:     nonEmpty(tx.IPAddress),
+        MerchantID:    nonEmpty(tx.MerchantID),
+        MCC:           nonEmpty(tx.MCC),
+        Channel:       nonEmpty(tx.Channel),
+        Country:       nonEmpty(tx.Country),
+        DuplicateOf:   nonEmpty(tx.DuplicateOf),
+    }
+    if doc.MCC != nil { doc.Category = mcc.Category(*doc.MCC) }
+    indexer.enqueue(search.BulkItem{Index: indexer.client.Index(search.Transactions, ts), ID: tx.TransactionID, Doc: doc})
+}
+
+func indexAlert(ev events.AlertEvent) {
+    if indexer == nil { return }
+    ts := time.Unix(ev.Timestamp, 0).UTC()
+    doc := alertDoc{AlertID: ev.AlertID, TransactionID: ev.TransactionID, UserID: ev.UserID, AlertType: ev.AlertType, Severity: ev.Severity, Description: ev.Description, FraudScore: ev.FraudScore, Timestamp: ts}
+    indexer.enqueue(search.BulkItem{Index: indexer.client.Index(search.Alerts, ts), ID: ev.AlertID, Doc: doc})
+}
+
+func nonEmpty(s *string) *string {
+    if s == nil || *s == "" { return nil }
+    return s
+}
+
+// enqueue never blocks message processing on the search cluster.
+func (x *searchIndexer) enqueue(it search.BulkItem) {
+    select {
+    case x.queue <- it:
+    default:
+        searchDropped.WithLabelValues("queue_full").Inc()
+    }
+}
+
+// run sends a bulk request whenever batchSize documents are queued or
+// interval has passed since the last one.
+func (x *searchIndexer) run(batchSize int, interval time.Duration) {
+    batch := make([]search.BulkItem, 0, batchSize)
+    t := time.NewTicker(interval)
+    defer t.Stop()
+    for {
+        select {
+        case it := <-x.queue:
+            batch = append(batch, it)
+            if len(batch) < batchSize { continue }
+        case <-t.C:
+            if len(batch) == 0 { continue }
+        }
+        x.flush(batch)
+        batch = batch[:0]
+    }
+}
+
+func (x *searchIndexer) flush(batch []search.BulkItem) {
+    bctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+    defer cancel()
+    if err := x.client.Bulk(bctx, batch); err != nil {
+        log.Printf("search indexing failed for %d documents: %v", len(batch), err)
+        searchDropped.WithLabelValues("bulk_failed").Add(float64(len(batch)))
+        return
+    }
+    for _, it := range batch {
+        kind := search.Transactions
+        if _, ok := it.Doc.(alertDoc); ok { kind = search.Alerts }
+        searchIndexed.WithLabelValues(kind).Inc()
+    }
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "strconv"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+
+    "example.com/fraud/internal/config"
+)
+
+// PartitionLag is the consumer group's position on one partition.
+type PartitionLag struct {
+    Partition     int   `json:"partition"`
+    Committed     int64 `json:"committed_offset"`
+    HighWatermark int64 `json:"high_watermark"`
+    Lag           int64 `json:"lag"`
+}
+
+// ProcessorStatus is served by /status.
+type ProcessorStatus struct {
+    GroupID           string         `json:"group_id"`
+    Topic             string         `json:"topic"`
+    Partitions        []PartitionLag `json:"partitions"`
+    TotalLag          int64          `json:"total_lag"`
+    ProcessedTotal    int64          `json:"processed_total"`
+    MessagesPerSecond float64        `json:"messages_per_second"`
+    AvgProcessingMs   float64        `json:"avg_processing_ms"`
+    UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+// statusTracker keeps counters updated from the consume loop and a snapshot
+// of consumer lag refreshed in the background.
+type statusTracker struct {
+    bus     eventBus
+    groupID string
+    topic   string
+
+    processed   atomic.Int64
+    latencyNs   atomic.Int64
+    mu          sync.Mutex
+    snapshot    ProcessorStatus
+    lastCount   int64
+    lastSampled time.Time
+}
+
+var status *statusTracker
+
+func newStatusTracker(bus eventBus, groupID, topic string) *statusTracker {
+    return &statusTracker{
+        bus:         bus,
+        groupID:     groupID,
+        topic:       topic,
+        snapshot:    ProcessorStatus{GroupID: groupID, Topic: topic},
+        lastSampled: time.Now(),
+    }
+}
+
+// observe records one processed message.
+func (s *statusTracker) observe(d time.Duration) {
+    s.processed.Add(1)
+    s.latencyNs.Add(int64(d))
+    messagesProcessed.Inc()
+    processingLatency.Observe(d.Seconds())
+}
+
+// run refreshes lag and throughput every interval until the process exits.
+func (s *statusTracker) run(interval time.Duration) {
+    t := time.NewTicker(interval)
+    defer t.Stop()
+    for range t.C {
+        s.refresh()
+    }
+}
+
+func (s *statusTracker) refresh() {
+    now := time.Now()
+    count := s.processed.Load()
+    partitions, err := s.bus.Lag(s.topic, s.groupID)
+    if err != nil { log.Printf("consumer lag check failed: %v", err) }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    rate := float64(count-s.lastCount) / now.Sub(s.lastSampled).Seconds()
+    s.lastCount, s.lastSampled = count, now
+    messagesPerSecond.Set(rate)
+
+    s.snapshot.ProcessedTotal = count
+    s.snapshot.MessagesPerSecond = rate
+    if count > 0 { s.snapshot.AvgProcessingMs = float64(s.latencyNs.Load()) / float64(count) / 1e6 }
+    if err == nil {
+        s.snapshot.Partitions = partitions
+        s.snapshot.TotalLag = 0
+        for _, p := range partitions {
+            s.snapshot.TotalLag += p.Lag
+            consumerLag.WithLabelValues(s.topic, strconv.Itoa(p.Partition)).Set(float64(p.Lag))
+        }
+    }
+    s.snapshot.UpdatedAt = now
+}
+
+func (s *statusTracker) handler(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    snap := s.snapshot
+    s.mu.Unlock()
+    writeJSON(w, http.StatusOK, snap)
+}
+
+// serveStatus exposes /metrics and /status on processor.http_addr.
+func serveStatus() {
+    mux := http.NewServeMux()
+    mux.Handle("/metrics", promhttp.Handler())
+    mux.HandleFunc("/status", status.handler)
+    mux.HandleFunc("/drift", driftHandler)
+    addr := config.Get().Processor.HTTPAddr
+    log.Printf("processor status listening on %s", addr)
+    if err := http.ListenAndServe(addr, mux); err != nil { log.Printf("status server error: %v", err) }
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    _ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+    "log"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "example.com/fraud/go_processor/internal/store"
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/conn"
+)
+
+// suppressions holds the live alert suppressions, swapped in whole by
+// refreshSuppressions.
+var suppressions atomic.Pointer[[]store.Suppression]
+
+// initSuppressions loads the suppressions and reloads them every
+// suppressions.refresh_interval, so new and ended windows apply within it.
+func initSuppressions() {
+    refreshSuppressions()
+    go func() {
+        for {
+            time.Sleep(config.Get().Suppressions.RefreshInterval)
+            refreshSuppressions()
+        }
+    }()
+}
+
+// refreshSuppressions keeps the previous list if the table can't be read.
+func refreshSuppressions() {
+    qctx, cancel := conn.QueryCtx(ctx)
+    defer cancel()
+    live, err := alertStore.LiveSuppressions(qctx)
+    if err != nil { log.Printf("suppression refresh failed: %v", err); return }
+    suppressions.Store(&live)
+}
+
+// suppressedBy returns the ID of a suppression in force at now that matches
+// the alert, or nil.
+func suppressedBy(merchantID *string, alertType, severity string, now time.Time) *int64 {
+    live := suppressions.Load()
+    if live == nil { return nil }
+    for _, s := range *live {
+        if now.Before(s.StartsAt) || !now.Before(s.EndsAt) { continue }
+        if s.MerchantID != "" && (merchantID == nil || *merchantID != s.MerchantID) { continue }
+        if s.AlertType != "" && !strings.EqualFold(s.AlertType, alertType) { continue }
+        if s.Severity != "" && !strings.EqualFold(s.Severity, severity) { continue }
+        id := s.ID
+        return &id
+    }
+    return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "math"
+    "net/netip"
+    "reflect"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+    "example.com/fraud/internal/mcc"
+)
+
+var (
+    invalidMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_invalid_messages_total",
+        Help: "Transaction messages rejected by validation, by field and reason.",
+    }, []string{"field", "reason"})
+    missingFields = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_missing_fields_total",
+        Help: "Valid transaction messages without an optional field, by field.",
+    }, []string{"field"})
+    deadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_processor_dead_lettered_total",
+        Help: "Messages sent to the dead-letter topic, by reason.",
+    }, []string{"reason"})
+)
+
+// channels are the payment rails go_api normalizes the channel field to.
+var channels = map[string]bool{"card": true, "ach": true, "wire": true, "p2p": true}
+
+// knownFields are the JSON names of TransactionEvent's fields.
+var knownFields = func() map[string]bool {
+    m := map[string]bool{}
+    t := reflect.TypeOf(events.TransactionEvent{})
+    for i := 0; i < t.NumField(); i++ {
+        name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+        m[name] = true
+    }
+    return m
+}()
+
+// invalidEvent names the first field of a message that failed validation.
+type invalidEvent struct {
+    field, reason string
+}
+
+// validateEvent checks what decoding can't: required fields, ranges and
+// formats. checkAge is off for replays, which read old events on purpose.
+func validateEvent(tx events.TransactionEvent, now time.Time, checkAge bool) *invalidEvent {
+    cfg := config.Get().Processor
+    switch {
+    case tx.TransactionID == "":
+        return &invalidEvent{"transaction_id", "missing"}
+    case tx.UserID == "":
+        return &invalidEvent{"user_id", "missing"}
+    case math.IsNaN(tx.Amount) || math.IsInf(tx.Amount, 0):
+        return &invalidEvent{"amount", "not_finite"}
+    case tx.Amount < 0:
+        return &invalidEvent{"amount", "negative"}
+    case math.IsNaN(tx.FraudScore) || tx.FraudScore < 0 || tx.FraudScore > 1:
+        return &invalidEvent{"fraud_score", "out_of_range"}
+    case tx.Timestamp <= 0:
+        return &invalidEvent{"timestamp", "missing"}
+    case time.Unix(tx.Timestamp, 0).After(now.Add(cfg.MaxClockSkew)):
+        return &invalidEvent{"timestamp", "future"}
+    case checkAge && cfg.MaxEventAge > 0 && time.Unix(tx.Timestamp, 0).Before(now.Add(-cfg.MaxEventAge)):
+        return &invalidEvent{"timestamp", "too_old"}
+    case tx.IPAddress != nil && *tx.IPAddress != "" && !validAddr(*tx.IPAddress):
+        return &invalidEvent{"ip_address", "invalid"}
+    case tx.MCC != nil && *tx.MCC != "" && !mcc.Valid(*tx.MCC):
+        return &invalidEvent{"mcc", "invalid"}
+    case tx.Channel != nil && *tx.Channel != "" && !channels[*tx.Channel]:
+        return &invalidEvent{"channel", "unknown"}
+    }
+    return nil
+}
+
+func validAddr(s string) bool {
+    _, err := netip.ParseAddr(s)
+    return err == nil
+}
+
+// unknownField returns the first top-level field of a JSON payload that
+// TransactionEvent doesn't have, which points at a producer writing a
+// different schema. Protobuf and Avro payloads are decoded against a schema
+// and are not checked.
+func unknownField(contentType string, value []byte) string {
+    if contentType == events.ContentTypeProtobuf || contentType == events.ContentTypeAvro { return "" }
+    var fields map[string]json.RawMessage
+    if json.Unmarshal(value, &fields) != nil { return "" }
+    for name := range fields {
+        if !knownFields[name] { return name }
+    }
+    return ""
+}
+
+// checkMessage validates a decoded message and counts its data-quality
+// metrics: the rejected field, or else each optional field it lacks.
+func checkMessage(m busMessage, tx events.TransactionEvent) *invalidEvent {
+    bad := validateEvent(tx, time.Now(), true)
+    if bad == nil {
+        if name := unknownField(m.ContentType, m.Value); name != "" { bad = &invalidEvent{name, "unknown_field"} }
+    }
+    if bad != nil {
+        // Unknown field names come from the payload; don't let them
+        // create unbounded label values.
+        field := bad.field
+        if bad.reason == "unknown_field" { field = "other" }
+        invalidMessages.WithLabelValues(field, bad.reason).Inc()
+        return bad
+    }
+    for field, v := range map[string]*string{"device_id": tx.DeviceID, "ip_address": tx.IPAddress, "merchant_id": tx.MerchantID, "mcc": tx.MCC, "channel": tx.Channel} {
+        if v == nil || *v == "" { missingFields.WithLabelValues(field).Inc() }
+    }
+    return nil
+}
+
+// deadLetter is published to processor.dlq_topic as JSON. It carries the
+// original message unchanged, so a fixed message can be re-published from
+// it, and where it came from.
+type deadLetter struct {
+    Reason      string    `json:"reason"`
+    Field       string    `json:"field,omitempty"`
+    Error       string    `json:"error,omitempty"`
+    Topic       string    `json:"topic"`
+    Partition   int       `json:"partition"`
+    Offset      int64     `json:"offset"`
+    ID          string    `json:"id,omitempty"`
+    ContentType string    `json:"content_type,omitempty"`
+    Key         []byte    `json:"key"`
+    Value       []byte    `json:"value"`
+    FailedAt    time.Time `json:"failed_at"`
+}
+
+// sendToDLQ publishes m with the reason it was rejected. The message is
+// acknowledged either way; a publish failure is only logged, since holding
+// back the partition for a message that will never process is worse.
+func sendToDLQ(dlq publisher, m busMessage, field, reason string, cause error) {
+    pos := m.ID
+    if pos == "" { pos = fmt.Sprintf("%d/%d", m.Partition, m.Offset) }
+    log.Printf("rejected message %s/%s: %s %s", m.Topic, pos, field, reason)
+    if dlq == nil { return }
+    d := deadLetter{Reason: reason, Field: field, Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, ID: m.ID, ContentType: m.ContentType, Key: m.Key, Value: m.Value, FailedAt: time.Now().UTC()}
+    if cause != nil { d.Error = cause.Error() }
+    b, _ := json.Marshal(d)
+    if err := dlq.Publish(m.Key, b, events.ContentTypeJSON); err != nil {
+        log.Printf("dead-letter publish failed: %v", err)
+        messagesFailed.WithLabelValues("dlq").Inc()
+        return
+    }
+    deadLettered.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "math"
+    "testing"
+    "time"
+
+    "example.com/fraud/internal/config"
+    "example.com/fraud/internal/events"
+)
+
+func TestValidateEvent(t *testing.T) {
+    if err := config.Init(""); err != nil { t.Fatal(err) }
+    now := time.Now()
+    cases := []struct {
+        name     string
+        edit     func(*events.TransactionEvent)
+        checkAge bool
+        field    string
+        reason   string
+    }{
+        {"valid", func(*events.TransactionEvent) {}, true, "", ""},
+        {"no transaction id", func(tx *events.TransactionEvent) { tx.TransactionID = "" }, true, "transaction_id", "missing"},
+        {"no user", func(tx *events.TransactionEvent) { tx.UserID = "" }, true, "user_id", "missing"},
+        {"NaN amount", func(tx *events.TransactionEvent) { tx.Amount = math.NaN() }, true, "amount", "not_finite"},
+        {"infinite amount", func(tx *events.TransactionEvent) { tx.Amount = math.Inf(1) }, true, "amount", "not_finite"},
+        {"negative amount", func(tx *events.TransactionEvent) { tx.Amount = -1 }, true, "amount", "negative"},
+        {"zero amount", func(tx *events.TransactionEvent) { tx.Amount = 0 }, true, "", ""},
+        {"score above 1", func(tx *events.TransactionEvent) { tx.FraudScore = 1.2 }, true, "fraud_score", "out_of_range"},
+        {"NaN score", func(tx *events.TransactionEvent) { tx.FraudScore = math.NaN() }, true, "fraud_score", "out_of_range"},
+        {"no timestamp", func(tx *events.TransactionEvent) { tx.Timestamp = 0 }, true, "timestamp", "missing"},
+        {"within the clock skew", func(tx *events.TransactionEvent) { tx.Timestamp = now.Add(time.Minute).Unix() }, true, "", ""},
+        {"in the future", func(tx *events.TransactionEvent) { tx.Timestamp = now.Add(time.Hour).Unix() }, true, "timestamp", "future"},
+        {"too old", func(tx *events.TransactionEvent) { tx.Timestamp = now.Add(-8 * 24 * time.Hour).Unix() }, true, "timestamp", "too_old"},
+        {"old, replayed", func(tx *events.TransactionEvent) { tx.Timestamp = now.Add(-8 * 24 * time.Hour).Unix() }, false, "", ""},
+        {"bad IP", func(tx *events.TransactionEvent) { tx.IPAddress = str("203.0.113") }, true, "ip_address", "invalid"},
+        {"IPv6", func(tx *events.TransactionEvent) { tx.IPAddress = str("2001:db8::1") }, true, "", ""},
+        {"empty IP", func(tx *events.TransactionEvent) { tx.IPAddress = str("") }, true, "", ""},
+        {"bad MCC", func(tx *events.TransactionEvent) { tx.MCC = str("54a1") }, true, "mcc", "invalid"},
+        {"unknown channel", func(tx *events.TransactionEvent) { tx.Channel = str("ecom") }, true, "channel", "unknown"},
+        {"known channel", func(tx *events.TransactionEvent) { tx.Channel = str("ach") }, true, "", ""},
+    }
+    for _, c := range cases {
+        tx := events.TransactionEvent{TransactionID: "tx-1", UserID: "user-1", Amount: 10, FraudScore: 0.5, Timestamp: now.Unix(), MCC: str("5411"), Channel: str("card")}
+        c.edit(&tx)
+        bad := validateEvent(tx, now, c.checkAge)
+        switch {
+        case c.field == "" && bad != nil:
+            t.Errorf("%s: rejected %s %s", c.name, bad.field, bad.reason)
+        case c.field != "" && bad == nil:
+            t.Errorf("%s: accepted, want %s %s", c.name, c.field, c.reason)
+        case bad != nil && (bad.field != c.field || bad.reason != c.reason):
+            t.Errorf("%s: rejected %s %s, want %s %s", c.name, bad.field, bad.reason, c.field, c.reason)
+        }
+    }
+}
+
+func TestUnknownField(t *testing.T) {
+    cases := []struct {
+        name, contentType, value, want string
+    }{
+        {"known fields", events.ContentTypeJSON, `{"transaction_id":"tx-1","user_id":"user-1","amount":10}`, ""},
+        {"unknown field", events.ContentTypeJSON, `{"transaction_id":"tx-1","merchant_name":"Shop"}`, "merchant_name"},
+        {"no content type", "", `{"txid":"tx-1"}`, "txid"},
+        {"not JSON", events.ContentTypeJSON, `not json`, ""},
+        {"protobuf", events.ContentTypeProtobuf, `{"txid":"tx-1"}`, ""},
+    }
+    for _, c := range cases {
+        if got := unknownField(c.contentType, []byte(c.value)); got != c.want { t.Errorf("%s: got %q, want %q", c.name, got, c.want) }
+    }
+}
+
+// A dead letter carries the original message unchanged and where it came
+// from; without a DLQ, or when publishing fails, it is only logged.
+func TestSendToDLQ(t *testing.T) {
+    m := busMessage{Topic: "fraud-transactions", Key: []byte("user-1"), Value: []byte(`{"amount":`), ContentType: events.ContentTypeJSON, Partition: 2, Offset: 7}
+    dlq := &recordingPublisher{}
+    sendToDLQ(dlq, m, "", "undecodable", errors.New("unexpected end of JSON input"))
+    if len(dlq.msgs) != 1 { t.Fatalf("published %d dead letters, want 1", len(dlq.msgs)) }
+    if string(dlq.msgs[0].key) != "user-1" || dlq.msgs[0].contentType != events.ContentTypeJSON { t.Errorf("dead letter key %q, content type %s", dlq.msgs[0].key, dlq.msgs[0].contentType) }
+    var d deadLetter
+    if err := json.Unmarshal(dlq.msgs[0].value, &d); err != nil { t.Fatal(err) }
+    if d.Reason != "undecodable" || d.Error != "unexpected end of JSON input" || d.Topic != m.Topic || d.Partition != 2 || d.Offset != 7 { t.Errorf("dead letter %+v", d) }
+    if string(d.Value) != string(m.Value) || string(d.Key) != string(m.Key) || d.ContentType != m.ContentType { t.Errorf("dead letter changed the message: %+v", d) }
+
+    sendToDLQ(nil, m, "amount", "negative", nil)
+    sendToDLQ(&recordingPublisher{err: errors.New("broker down")}, m, "amount", "negative", nil)
+}
@@ -0,0 +1,1203 @@
+// Package config holds the settings go_api and go_processor read. Values
+// come from the `default` tags below, then an optional YAML file, then
+// environment variables, so the variables set in docker-compose keep
+// working and always win over the file.
+//
+// Durations are written in YAML as Go duration strings ("250ms", "5m"); in
+// the environment they are plain numbers in the unit the variable name
+// carries. Fields tagged reload:"true" are re-read on SIGHUP (see Watch); the
+// rest need a restart.
+package config
+
+import (
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "net/netip"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+)
+
+type Config struct {
+    // EventBus selects the transport for events: kafka or redis (Redis
+    // Streams).
+    EventBus string `yaml:"event_bus" env:"EVENT_BUS" default:"kafka"`
+
+    Postgres     Postgres     `yaml:"postgres"`
+    Redis        Redis        `yaml:"redis"`
+    Kafka        Kafka        `yaml:"kafka"`
+    Partitions   Partitions   `yaml:"partitions"`
+    API          API          `yaml:"api"`
+    MLTLS        MLTLS        `yaml:"ml_tls"`
+    MLEndpoints  MLEndpoints  `yaml:"ml_endpoints"`
+    HTTP         HTTP         `yaml:"http"`
+    APIKeys      APIKeys      `yaml:"api_keys"`
+    RateLimit    RateLimit    `yaml:"rate_limit"`
+    Usage        Usage        `yaml:"usage"`
+    Admin        Admin        `yaml:"admin"`
+    Abuse        Abuse        `yaml:"abuse"`
+    Callers      Callers      `yaml:"callers"`
+    Rules        Rules        `yaml:"rules"`
+    Processor    Processor    `yaml:"processor"`
+    CardTesting  CardTesting  `yaml:"card_testing"`
+    Drift        Drift        `yaml:"drift"`
+    Duplicates   Duplicates   `yaml:"duplicates"`
+    Webhooks     Webhooks     `yaml:"webhooks"`
+    Scoring      Scoring      `yaml:"scoring"`
+    Fallback     Fallback     `yaml:"fallback"`
+    Mirror       Mirror       `yaml:"mirror"`
+    FeatureLog   FeatureLog   `yaml:"feature_log"`
+    Limits       Limits       `yaml:"limits"`
+    Lifecycle    Lifecycle    `yaml:"lifecycle"`
+    Geo          Geo          `yaml:"geo"`
+    Localization Localization `yaml:"localization"`
+    Thresholds   Thresholds   `yaml:"thresholds"`
+    Search       Search       `yaml:"search"`
+    ClickHouse   ClickHouse   `yaml:"clickhouse"`
+    Archive      Archive      `yaml:"archive"`
+    Warehouse    Warehouse    `yaml:"warehouse"`
+    Reports      Reports      `yaml:"reports"`
+    Suppressions Suppressions `yaml:"suppressions"`
+    AutoClose    AutoClose    `yaml:"auto_close"`
+    AlertSLA     AlertSLA     `yaml:"alert_sla"`
+    Cases        Cases        `yaml:"cases"`
+    Stats        Stats        `yaml:"stats"`
+    Enrichment   Enrichment   `yaml:"enrichment"`
+    Feast        Feast        `yaml:"feast"`
+    Precomputed  Precomputed  `yaml:"precomputed"`
+    Flags        Flags        `yaml:"flags"`
+    Startup      Startup      `yaml:"startup"`
+    Faults       Faults       `yaml:"faults"`
+    Secrets      Secrets      `yaml:"secrets"`
+}
+
+type Postgres struct {
+    Host     string `yaml:"host" env:"POSTGRES_HOST" default:"localhost"`
+    ReadHost string `yaml:"read_host" env:"POSTGRES_READ_HOST"`
+    // Port applies to Host and ReadHost alike.
+    Port     string `yaml:"port" env:"POSTGRES_PORT" default:"5432"`
+    DB       string `yaml:"db" env:"POSTGRES_DB" default:"fraud_detection"`
+    User     string `yaml:"user" env:"POSTGRES_USER" default:"fraud_user" secret:"true"`
+    Password string `yaml:"password" env:"POSTGRES_PASSWORD" default:"fraud_password" secret:"true"`
+
+    MaxConns          int           `yaml:"max_conns" env:"PG_MAX_CONNS" default:"20"`
+    MinConns          int           `yaml:"min_conns" env:"PG_MIN_CONNS" default:"2"`
+    MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"PG_MAX_CONN_LIFETIME_SECONDS" unit:"s" default:"3600"`
+    MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"PG_MAX_CONN_IDLE_SECONDS" unit:"s" default:"300"`
+    HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"PG_HEALTH_CHECK_PERIOD_SECONDS" unit:"s" default:"30"`
+
+    QueryTimeout  time.Duration `yaml:"query_timeout" env:"PG_QUERY_TIMEOUT_MS" unit:"ms" default:"2000" reload:"true"`
+    ReplicaMaxLag time.Duration `yaml:"replica_max_lag" env:"READ_REPLICA_MAX_LAG_MS" unit:"ms" default:"1000" reload:"true"`
+}
+
+// DSN returns a connection string for host, which is Host for the primary
+// or ReadHost for the replica.
+func (p Postgres) DSN(host string) string {
+    return fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=disable", host, p.Port, p.DB, p.User, p.Password)
+}
+
+type Redis struct {
+    Host         string `yaml:"host" env:"REDIS_HOST" default:"localhost"`
+    Port         string `yaml:"port" env:"REDIS_PORT" default:"6379"`
+    StreamMaxLen int    `yaml:"stream_max_len" env:"REDIS_STREAM_MAXLEN" default:"1000000"`
+
+    // Username is an ACL user; with only Password set the default user
+    // authenticates, as with requirepass.
+    Username string `yaml:"username" env:"REDIS_USERNAME" secret:"true"`
+    Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+    DB       int    `yaml:"db" env:"REDIS_DB" default:"0"`
+    // TLS is checked against TLSCAFile or the system roots; TLSCertFile and
+    // TLSKeyFile add a client certificate.
+    TLS         bool   `yaml:"tls" env:"REDIS_TLS" default:"false"`
+    TLSCAFile   string `yaml:"tls_ca_file" env:"REDIS_TLS_CA_FILE"`
+    TLSCertFile string `yaml:"tls_cert_file" env:"REDIS_TLS_CERT_FILE"`
+    TLSKeyFile  string `yaml:"tls_key_file" env:"REDIS_TLS_KEY_FILE"`
+    // ClientName is what CLIENT LIST shows for each connection; by default
+    // the service and host name, e.g. fraud_api@go-api-7f9c.
+    ClientName string `yaml:"client_name" env:"REDIS_CLIENT_NAME"`
+}
+
+func (r Redis) Addr() string { return r.Host + ":" + r.Port }
+
+type Kafka struct {
+    Brokers           []string      `yaml:"brokers" env:"KAFKA_BOOTSTRAP_SERVERS" default:"localhost:9092"`
+    Encoding          string        `yaml:"encoding" env:"KAFKA_ENCODING" default:"protobuf"`
+    SchemaRegistryURL string        `yaml:"schema_registry_url" env:"SCHEMA_REGISTRY_URL" default:"http://localhost:8081"`
+    BatchSize         int           `yaml:"batch_size" env:"KAFKA_BATCH_SIZE" default:"100"`
+    Linger            time.Duration `yaml:"linger" env:"KAFKA_LINGER_MS" unit:"ms" default:"10"`
+
+    // TLS encrypts connections to the brokers, checked against TLSCAFile or
+    // the system roots; TLSCertFile and TLSKeyFile add a client
+    // certificate. SASLMechanism, plain, scram-sha-256 or scram-sha-512,
+    // authenticates as SASLUsername.
+    TLS           bool   `yaml:"tls" env:"KAFKA_TLS" default:"false"`
+    TLSCAFile     string `yaml:"tls_ca_file" env:"KAFKA_TLS_CA_FILE"`
+    TLSCertFile   string `yaml:"tls_cert_file" env:"KAFKA_TLS_CERT_FILE"`
+    TLSKeyFile    string `yaml:"tls_key_file" env:"KAFKA_TLS_KEY_FILE"`
+    SASLMechanism string `yaml:"sasl_mechanism" env:"KAFKA_SASL_MECHANISM"`
+    SASLUsername  string `yaml:"sasl_username" env:"KAFKA_SASL_USERNAME" secret:"true"`
+    SASLPassword  string `yaml:"sasl_password" env:"KAFKA_SASL_PASSWORD" secret:"true"`
+}
+
+type Partitions struct {
+    MonthsAhead         int           `yaml:"months_ahead" env:"PARTITION_MONTHS_AHEAD" default:"2" reload:"true"`
+    RetentionMonths     int           `yaml:"retention_months" env:"RETENTION_MONTHS" default:"12" reload:"true"`
+    MaintenanceInterval time.Duration `yaml:"maintenance_interval" env:"PARTITION_MAINTENANCE_INTERVAL_MINUTES" unit:"m" default:"360"`
+}
+
+type API struct {
+    UseMLGRPC              bool          `yaml:"use_ml_grpc" env:"USE_ML_GRPC" default:"false" reload:"true"`
+    MLGRPCAddr             string        `yaml:"ml_grpc_addr" env:"ML_GRPC_ADDR" default:"fraud_ml:50051" reload:"true"`
+    PriorityScoreThreshold float64       `yaml:"priority_score_threshold" env:"PRIORITY_SCORE_THRESHOLD" default:"0.9" reload:"true"`
+    ResponseCacheTTL       time.Duration `yaml:"response_cache_ttl" env:"RESPONSE_CACHE_TTL_SECONDS" unit:"s" default:"60" reload:"true"`
+    UserRiskCacheTTL       time.Duration `yaml:"user_risk_cache_ttl" env:"USER_RISK_CACHE_TTL_SECONDS" unit:"s" default:"300" reload:"true"`
+    UserRiskNegativeTTL    time.Duration `yaml:"user_risk_negative_ttl" env:"USER_RISK_NEGATIVE_TTL_SECONDS" unit:"s" default:"30" reload:"true"`
+    UserHistoryDays        int           `yaml:"user_history_days" env:"USER_HISTORY_DAYS" default:"90" reload:"true"`
+    CacheWarmUsers         int           `yaml:"cache_warm_users" env:"CACHE_WARM_USERS" default:"1000" reload:"true"`
+    CacheWarmInterval      time.Duration `yaml:"cache_warm_interval" env:"CACHE_WARM_INTERVAL_SECONDS" unit:"s" default:"300"`
+    OutboxRelayInterval    time.Duration `yaml:"outbox_relay_interval" env:"OUTBOX_RELAY_INTERVAL_SECONDS" unit:"s" default:"30" reload:"true"`
+    // LatencyBudget bounds enrichment and the ML call per transaction,
+    // counted from the request's arrival; 0 is no budget.
+    LatencyBudget time.Duration `yaml:"latency_budget" env:"LATENCY_BUDGET_MS" unit:"ms" default:"0" reload:"true"`
+    // Responses of at least CompressMinBytes are gzip or deflate compressed
+    // for clients that accept it. Request bodies may be sent compressed and
+    // are refused beyond MaxDecompressedMB once inflated.
+    CompressMinBytes  int `yaml:"compress_min_bytes" env:"COMPRESS_MIN_BYTES" default:"1024" reload:"true"`
+    MaxDecompressedMB int `yaml:"max_decompressed_mb" env:"MAX_DECOMPRESSED_MB" default:"64" reload:"true"`
+    // MaxBodyKB caps JSON request bodies; /transactions/batch and
+    // /features/batch allow MaxBatchBodyMB and ISO 20022 messages MaxImportMB. Larger bodies are
+    // answered 413.
+    MaxBodyKB      int `yaml:"max_body_kb" env:"MAX_BODY_KB" default:"64" reload:"true"`
+    MaxBatchBodyMB int `yaml:"max_batch_body_mb" env:"MAX_BATCH_BODY_MB" default:"16" reload:"true"`
+    MaxImportMB    int `yaml:"max_import_mb" env:"MAX_IMPORT_MB" default:"10" reload:"true"`
+    // StrictJSON rejects JSON request bodies with fields the endpoint
+    // doesn't know, rather than ignoring them.
+    StrictJSON bool `yaml:"strict_json" env:"STRICT_JSON" default:"true" reload:"true"`
+}
+
+// MLEndpoints spreads calls to the ML service over several instances. Addrs,
+// when set, replaces api.ml_grpc_addr. Each endpoint gets a grpc.health.v1
+// Check every HealthInterval and takes no calls after HealthFailures failed
+// checks in a row, until one passes. Policy pick_first sends every call to
+// the first healthy endpoint, keeping the rest as warm standbys;
+// round_robin spreads calls over all the healthy ones.
+type MLEndpoints struct {
+    Addrs          []string      `yaml:"addrs" env:"ML_GRPC_ADDRS" reload:"true"`
+    Policy         string        `yaml:"policy" env:"ML_LB_POLICY" default:"pick_first" reload:"true"`
+    HealthInterval time.Duration `yaml:"health_interval" env:"ML_HEALTH_INTERVAL_SECONDS" unit:"s" default:"5" reload:"true"`
+    HealthTimeout  time.Duration `yaml:"health_timeout" env:"ML_HEALTH_TIMEOUT_MS" unit:"ms" default:"1000" reload:"true"`
+    HealthFailures int           `yaml:"health_failures" env:"ML_HEALTH_FAILURES" default:"2" reload:"true"`
+}
+
+// MLTLS secures the API's gRPC connection to the ML service. Mode is one of
+// insecure (plaintext), tls (the service's certificate is checked against
+// CAFile, or the system roots, and ServerName, or the host of
+// api.ml_grpc_addr), mtls (tls, presenting CertFile and KeyFile), or spiffe
+// (mtls with SVIDs, say as written by the SPIFFE helper, checking the
+// service's SPIFFE ID instead of its host name). The files are re-read when
+// they change, checked every ReloadInterval.
+type MLTLS struct {
+    Mode           string        `yaml:"mode" env:"ML_TLS_MODE" default:"insecure"`
+    CAFile         string        `yaml:"ca_file" env:"ML_TLS_CA_FILE"`
+    CertFile       string        `yaml:"cert_file" env:"ML_TLS_CERT_FILE"`
+    KeyFile        string        `yaml:"key_file" env:"ML_TLS_KEY_FILE"`
+    ServerName     string        `yaml:"server_name" env:"ML_TLS_SERVER_NAME"`
+    SPIFFEID       string        `yaml:"spiffe_id" env:"ML_TLS_SPIFFE_ID"`
+    ReloadInterval time.Duration `yaml:"reload_interval" env:"ML_TLS_RELOAD_INTERVAL_SECONDS" unit:"s" default:"30"`
+}
+
+// HTTP sets the headers the API sends browsers: which origins may call it
+// cross-origin, such as the analyst web UI, and the security headers.
+type HTTP struct {
+    // AllowedOrigins are exact scheme://host[:port] origins; "*" allows any
+    // origin. Empty allows none, for a UI served from the API's own origin.
+    AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" default:"http://localhost:3000" reload:"true"`
+    AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"false" reload:"true"`
+    PreflightMaxAge  time.Duration `yaml:"preflight_max_age" env:"CORS_MAX_AGE_SECONDS" unit:"s" default:"600" reload:"true"`
+    // HSTSMaxAge is sent as Strict-Transport-Security; 0 leaves the header
+    // out.
+    HSTSMaxAge time.Duration `yaml:"hsts_max_age" env:"HSTS_MAX_AGE_HOURS" unit:"h" default:"8760" reload:"true"`
+}
+
+// APIKeys are the keys callers identify themselves with in X-API-Key, as
+// name=hash entries where hash is the key's SHA-256 in hex, so the keys
+// themselves are never configured. A request whose key is listed counts
+// under the name; any other request is anonymous.
+type APIKeys struct {
+    Keys []string `yaml:"keys" env:"API_KEYS" reload:"true"`
+}
+
+// Name is the name of the key whose SHA-256 is hash, or "" when no key has
+// it.
+func (a APIKeys) Name(hash string) string {
+    for _, k := range a.Keys {
+        name, h, _ := strings.Cut(k, "=")
+        if strings.EqualFold(strings.TrimSpace(h), hash) { return strings.TrimSpace(name) }
+    }
+    return ""
+}
+
+// RateLimit caps each API client, the name of its API key or else its IP,
+// at Requests per Window, counted in Redis; 0 Requests turns it off.
+type RateLimit struct {
+    Requests int           `yaml:"requests" env:"RATE_LIMIT_REQUESTS" default:"60000" reload:"true"`
+    Window   time.Duration `yaml:"window" env:"RATE_LIMIT_WINDOW_SECONDS" unit:"s" default:"60" reload:"true"`
+    // TrustedProxies are the CIDR ranges of the proxies in front of the API.
+    // A request arriving from one is attributed to the rightmost
+    // X-Forwarded-For address outside them; entries further left are
+    // whatever the client sent and aren't believed.
+    TrustedProxies []string `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES" reload:"true"`
+}
+
+// Admin protects the /admin endpoints with OpenID Connect: requests need a
+// bearer token from OIDCIssuer issued for OIDCAudience, usually the admin
+// client's ID. The token's GroupsClaim or email must also be in
+// AllowedGroups or AllowedEmails, at least one of which must be set. An
+// empty OIDCIssuer turns the admin API off.
+type Admin struct {
+    OIDCIssuer    string   `yaml:"oidc_issuer" env:"ADMIN_OIDC_ISSUER"`
+    OIDCAudience  string   `yaml:"oidc_audience" env:"ADMIN_OIDC_AUDIENCE"`
+    GroupsClaim   string   `yaml:"groups_claim" env:"ADMIN_OIDC_GROUPS_CLAIM" default:"groups" reload:"true"`
+    AllowedGroups []string `yaml:"allowed_groups" env:"ADMIN_ALLOWED_GROUPS" reload:"true"`
+    AllowedEmails []string `yaml:"allowed_emails" env:"ADMIN_ALLOWED_EMAILS" reload:"true"`
+    // AnalystGroups and AnalystEmails may also use the analyst endpoints
+    // (alert and case actions, KYC and limits, audit and search), but not
+    // the rest of the admin API.
+    AnalystGroups []string `yaml:"analyst_groups" env:"ADMIN_ANALYST_GROUPS" reload:"true"`
+    AnalystEmails []string `yaml:"analyst_emails" env:"ADMIN_ANALYST_EMAILS" reload:"true"`
+}
+
+// Abuse slows down and fools clients that keep tripping the rate limit or
+// failing admin authentication. Each 429, each 401 and each request for one
+// of HoneypotPaths, which no real client asks for, is a strike against the
+// caller's IP; strikes expire Window after the last one. From Threshold
+// strikes on, the IP's requests are held for Tarpit and, with Decoy, answered
+// with made-up responses instead of reaching the API. At BlockAt strikes
+// the IP is added to the blocklist with BlockAction, so transactions from
+// it are declined or flagged; 0 never adds it. Only IPs that connected
+// directly are blocklisted, never ones read from X-Forwarded-For.
+type Abuse struct {
+    Enabled       bool          `yaml:"enabled" env:"ABUSE_ENABLED" default:"false" reload:"true"`
+    Threshold     int           `yaml:"threshold" env:"ABUSE_THRESHOLD" default:"20" reload:"true"`
+    Window        time.Duration `yaml:"window" env:"ABUSE_WINDOW_SECONDS" unit:"s" default:"600" reload:"true"`
+    Tarpit        time.Duration `yaml:"tarpit" env:"ABUSE_TARPIT_MS" unit:"ms" default:"5000" reload:"true"`
+    Decoy         bool          `yaml:"decoy" env:"ABUSE_DECOY" default:"false" reload:"true"`
+    HoneypotPaths []string      `yaml:"honeypot_paths" env:"ABUSE_HONEYPOT_PATHS" default:"/.env,/wp-login.php,/phpmyadmin,/admin/config" reload:"true"`
+    BlockAt       int           `yaml:"block_at" env:"ABUSE_BLOCK_AT" default:"0" reload:"true"`
+    BlockAction   string        `yaml:"block_action" env:"ABUSE_BLOCK_ACTION" default:"risk" reload:"true"`
+}
+
+// Callers watches each API key's traffic, minute by minute, for signs its
+// credentials are in the wrong hands, and raises an API_CALLER_ANOMALY
+// alert on the fraud-alerts topic, at most once per Cooldown per key and
+// kind. A volume_spike is a minute of at least SpikeMinRequests requests
+// and SpikeFactor times the key's average over the Baseline before it; an
+// enumeration is a minute with lookups of EnumerationMisses or more
+// distinct transaction IDs that don't exist; user_agent_churn is a minute
+// with UserAgents or more distinct User-Agents.
+type Callers struct {
+    Enabled           bool          `yaml:"enabled" env:"CALLER_ANOMALIES_ENABLED" default:"false" reload:"true"`
+    SpikeFactor       float64       `yaml:"spike_factor" env:"CALLER_SPIKE_FACTOR" default:"5" reload:"true"`
+    SpikeMinRequests  int           `yaml:"spike_min_requests" env:"CALLER_SPIKE_MIN_REQUESTS" default:"600" reload:"true"`
+    Baseline          time.Duration `yaml:"baseline" env:"CALLER_BASELINE_MINUTES" unit:"m" default:"60"`
+    EnumerationMisses int           `yaml:"enumeration_misses" env:"CALLER_ENUMERATION_MISSES" default:"30" reload:"true"`
+    UserAgents        int           `yaml:"user_agents" env:"CALLER_USER_AGENTS" default:"10" reload:"true"`
+    Cooldown          time.Duration `yaml:"cooldown" env:"CALLER_ALERT_COOLDOWN_MINUTES" unit:"m" default:"60" reload:"true"`
+}
+
+// Usage controls the per-API-key usage metering behind GET /admin/usage.
+type Usage struct {
+    // FlushInterval is how often each API instance writes the usage it has
+    // counted to Postgres.
+    FlushInterval time.Duration `yaml:"flush_interval" env:"USAGE_FLUSH_INTERVAL_SECONDS" unit:"s" default:"10" reload:"true"`
+    // MaxKeys caps the distinct API keys (per day) counted between flushes;
+    // further keys are counted as anonymous.
+    MaxKeys int `yaml:"max_keys" env:"USAGE_MAX_KEYS" default:"1000" reload:"true"`
+}
+
+// Rules are the thresholds of the built-in scorer the API falls back to
+// when the ML service is disabled or unreachable.
+type Rules struct {
+    FraudThreshold     float64 `yaml:"fraud_threshold" env:"RULE_FRAUD_THRESHOLD" default:"0.7" reload:"true"`
+    HighAmount         float64 `yaml:"high_amount" env:"RULE_HIGH_AMOUNT" default:"5000" reload:"true"` // card payments
+    ACHHighAmount      float64 `yaml:"ach_high_amount" env:"RULE_ACH_HIGH_AMOUNT" default:"25000" reload:"true"`
+    WireHighAmount     float64 `yaml:"wire_high_amount" env:"RULE_WIRE_HIGH_AMOUNT" default:"10000" reload:"true"`
+    P2PHighAmount      float64 `yaml:"p2p_high_amount" env:"RULE_P2P_HIGH_AMOUNT" default:"1000" reload:"true"`
+    HighMerchantRisk   float64 `yaml:"high_merchant_risk" env:"RULE_HIGH_MERCHANT_RISK" default:"0.8" reload:"true"`
+    HighUserRisk       float64 `yaml:"high_user_risk" env:"RULE_HIGH_USER_RISK" default:"0.7" reload:"true"`
+    UnusualAmountRatio float64 `yaml:"unusual_amount_ratio" env:"RULE_UNUSUAL_AMOUNT_RATIO" default:"5" reload:"true"`
+    AmountZScore       float64 `yaml:"amount_zscore" env:"RULE_AMOUNT_ZSCORE" default:"3" reload:"true"`
+    // HighRiskCategories are merchant categories (see internal/mcc) scored
+    // up the first time a user pays in them.
+    HighRiskCategories []string `yaml:"high_risk_categories" env:"RULE_HIGH_RISK_CATEGORIES" default:"gambling,crypto,gift_cards" reload:"true"`
+    // CardVelocity is how many card transactions a user may make within
+    // CardVelocityWindow before further ones are scored up.
+    CardVelocity       int           `yaml:"card_velocity" env:"RULE_CARD_VELOCITY" default:"10" reload:"true"`
+    CardVelocityWindow time.Duration `yaml:"card_velocity_window" env:"RULE_CARD_VELOCITY_WINDOW_SECONDS" unit:"s" default:"60" reload:"true"`
+    // DisposableEmailDomains extends the built-in list of throwaway email
+    // providers.
+    DisposableEmailDomains []string `yaml:"disposable_email_domains" env:"RULE_DISPOSABLE_EMAIL_DOMAINS" reload:"true"`
+    // NewEmailDays is how long after its first sighting an email counts as
+    // new.
+    NewEmailDays int `yaml:"new_email_days" env:"RULE_NEW_EMAIL_DAYS" default:"7" reload:"true"`
+    // KYCAmount is the amount from which users with pending KYC are sent
+    // to review and users with failed KYC declined.
+    KYCAmount float64 `yaml:"kyc_amount" env:"RULE_KYC_AMOUNT" default:"1000" reload:"true"`
+    // Accounts younger than NewAccountWindow are new: amounts above
+    // NewAccountHighAmount, and transactions beyond NewAccountVelocity
+    // within the window, are scored up.
+    NewAccountWindow     time.Duration `yaml:"new_account_window" env:"RULE_NEW_ACCOUNT_HOURS" unit:"h" default:"72" reload:"true"`
+    NewAccountHighAmount float64       `yaml:"new_account_high_amount" env:"RULE_NEW_ACCOUNT_HIGH_AMOUNT" default:"500" reload:"true"`
+    NewAccountVelocity   int           `yaml:"new_account_velocity" env:"RULE_NEW_ACCOUNT_VELOCITY" default:"5" reload:"true"`
+    // BehavioralWeight scales the SDK's behavioral_score into the rules'
+    // score; scores above HighBehavioralScore are a risk factor.
+    BehavioralWeight    float64 `yaml:"behavioral_weight" env:"RULE_BEHAVIORAL_WEIGHT" default:"0.2" reload:"true"`
+    HighBehavioralScore float64 `yaml:"high_behavioral_score" env:"RULE_HIGH_BEHAVIORAL_SCORE" default:"0.8" reload:"true"`
+    // VerificationFailureRisk is added to a user's risk score when they
+    // fail a step-up check.
+    VerificationFailureRisk float64 `yaml:"verification_failure_risk" env:"RULE_VERIFICATION_FAILURE_RISK" default:"0.1" reload:"true"`
+    // FalsePositiveCost is what flagging a legitimate transaction costs
+    // (support, lost sales, churn), in the same currency as amounts.
+    FalsePositiveCost float64 `yaml:"false_positive_cost" env:"RULE_FALSE_POSITIVE_COST" default:"15" reload:"true"`
+    // CostSensitive decides by expected loss rather than by the threshold
+    // alone; ReviewExpectedLoss is the expected loss from which transactions
+    // under the threshold are reviewed (0: never).
+    CostSensitive      bool    `yaml:"cost_sensitive" env:"RULE_COST_SENSITIVE" default:"true" reload:"true"`
+    ReviewExpectedLoss float64 `yaml:"review_expected_loss" env:"RULE_REVIEW_EXPECTED_LOSS" default:"500" reload:"true"`
+}
+
+type Processor struct {
+    GroupID     string        `yaml:"group_id" env:"PROCESSOR_GROUP_ID" default:"fraud-processor-group-go"`
+    Topic       string        `yaml:"topic" env:"PROCESSOR_TOPIC" default:"fraud-transactions"`
+    // AlertsOnly makes the processor raise alerts without touching user
+    // state, for the fast lane reading the priority topic: its events are
+    // copies of ones on the main topic, which applies them in order.
+    AlertsOnly  bool          `yaml:"alerts_only" env:"PROCESSOR_ALERTS_ONLY"`
+    HTTPAddr    string        `yaml:"http_addr" env:"PROCESSOR_HTTP_ADDR" default:":8001"`
+    Workers     int           `yaml:"workers" env:"PROCESSOR_WORKERS" default:"0"` // 0: one per CPU
+    MaxInFlight int           `yaml:"max_inflight" env:"PROCESSOR_MAX_INFLIGHT" default:"1000"`
+    BatchSize   int           `yaml:"batch_size" env:"PROCESSOR_BATCH_SIZE" default:"50"`
+    BatchLinger time.Duration `yaml:"batch_linger" env:"PROCESSOR_BATCH_LINGER_MS" unit:"ms" default:"5"`
+    MaxWait     time.Duration `yaml:"max_wait" env:"PROCESSOR_MAX_WAIT_MS" unit:"ms" default:"10000"`
+
+    // AmountStatsWindow is roughly how many recent transactions a user's
+    // amount profile reflects.
+    AmountStatsWindow int `yaml:"amount_stats_window" env:"AMOUNT_STATS_WINDOW" default:"200" reload:"true"`
+
+    // DLQTopic receives messages that can't be decoded or fail validation;
+    // empty drops them after counting.
+    DLQTopic string `yaml:"dlq_topic" env:"PROCESSOR_DLQ_TOPIC" default:"fraud-transactions-dlq"`
+    // Events timestamped more than MaxClockSkew ahead or MaxEventAge behind
+    // the processor's clock are invalid; MaxEventAge 0 accepts any age.
+    MaxClockSkew time.Duration `yaml:"max_clock_skew" env:"PROCESSOR_MAX_CLOCK_SKEW_SECONDS" unit:"s" default:"300" reload:"true"`
+    MaxEventAge  time.Duration `yaml:"max_event_age" env:"PROCESSOR_MAX_EVENT_AGE_HOURS" unit:"h" default:"168" reload:"true"`
+
+    RiskStateBootstrap   bool `yaml:"risk_state_bootstrap" env:"RISK_STATE_BOOTSTRAP" default:"true"`
+    RiskStatePartitions  int  `yaml:"risk_state_partitions" env:"RISK_STATE_PARTITIONS" default:"6"`
+    RiskStateReplication int  `yaml:"risk_state_replication" env:"RISK_STATE_REPLICATION" default:"1"`
+}
+
+// CardTesting configures the processor's detection of card-testing bursts:
+// many users paying small amounts from one IP or device within Window.
+type CardTesting struct {
+    MaxAmount     float64       `yaml:"max_amount" env:"CARD_TESTING_MAX_AMOUNT" default:"10" reload:"true"`
+    Users         int           `yaml:"users" env:"CARD_TESTING_USERS" default:"5" reload:"true"`
+    Window        time.Duration `yaml:"window" env:"CARD_TESTING_WINDOW_SECONDS" unit:"s" default:"600" reload:"true"`
+    // BlockDuration is how long the API rejects requests from an IP caught
+    // card testing; 0 only raises the alert.
+    BlockDuration time.Duration `yaml:"block_duration" env:"CARD_TESTING_BLOCK_SECONDS" unit:"s" default:"3600" reload:"true"`
+}
+
+// Drift configures the processor's score drift monitor, which compares the
+// fraud scores of the last Window with those of the Reference period before
+// it.
+type Drift struct {
+    Interval  time.Duration `yaml:"interval" env:"SCORE_DRIFT_INTERVAL_MINUTES" unit:"m" default:"5"` // 0: off
+    Window    time.Duration `yaml:"window" env:"SCORE_DRIFT_WINDOW_MINUTES" unit:"m" default:"60" reload:"true"`
+    Reference time.Duration `yaml:"reference" env:"SCORE_DRIFT_REFERENCE_HOURS" unit:"h" default:"168" reload:"true"`
+    // MinSamples is how many scores each window needs before drift is
+    // judged at all.
+    MinSamples int     `yaml:"min_samples" env:"SCORE_DRIFT_MIN_SAMPLES" default:"500" reload:"true"`
+    PSIWarn    float64 `yaml:"psi_warn" env:"SCORE_DRIFT_PSI_WARN" default:"0.1" reload:"true"`
+    PSIAlert   float64 `yaml:"psi_alert" env:"SCORE_DRIFT_PSI_ALERT" default:"0.25" reload:"true"`
+    // MeanShift alerts on a change of the mean score by at least this much,
+    // whatever the PSI; 0 disables it.
+    MeanShift float64 `yaml:"mean_shift" env:"SCORE_DRIFT_MEAN_SHIFT" default:"0.1" reload:"true"`
+}
+
+// Duplicates configures the detection of repeated transactions: same user,
+// merchant and amount within Window. While it is on, the response cache only
+// answers requests with an Idempotency-Key, so an identical request without
+// one is scored again and flagged.
+type Duplicates struct {
+    Window time.Duration `yaml:"window" env:"DUPLICATE_WINDOW_SECONDS" unit:"s" default:"120" reload:"true"` // 0: off
+    // Review has the processor open a POSSIBLE_DUPLICATE alert for each
+    // flagged transaction.
+    Review bool `yaml:"review" env:"DUPLICATE_REVIEW" default:"false" reload:"true"`
+}
+
+// Webhooks configures the payment processor endpoints under /webhooks/. A
+// provider without a secret is disabled.
+type Webhooks struct {
+    StripeSecret string        `yaml:"stripe_secret" env:"WEBHOOK_STRIPE_SECRET" reload:"true" secret:"true"`
+    AdyenHMACKey string        `yaml:"adyen_hmac_key" env:"WEBHOOK_ADYEN_HMAC_KEY" reload:"true" secret:"true"`
+    Tolerance    time.Duration `yaml:"tolerance" env:"WEBHOOK_TOLERANCE_SECONDS" unit:"s" default:"300" reload:"true"`
+    // VerificationSecret signs the auth system's step-up verification
+    // callbacks; without it POST /transactions/{id}/verification is off.
+    VerificationSecret string `yaml:"verification_secret" env:"WEBHOOK_VERIFICATION_SECRET" reload:"true" secret:"true"`
+    // Queue is how many payments may wait for scoring; beyond it the API
+    // answers 503 and leaves the retry to the provider.
+    Queue   int `yaml:"queue" env:"WEBHOOK_QUEUE" default:"1000"`
+    Workers int `yaml:"workers" env:"WEBHOOK_WORKERS" default:"4"`
+}
+
+// Scoring shares the API's scoring slots between real-time requests
+// (/transactions/process and score-only) and bulk work: batch items, ISO
+// 20022 messages and webhook imports. Bulk holds at most BulkSlots and only
+// takes a free slot when no real-time request is waiting. A real-time
+// request that waits QueueTimeout for a slot is answered 503.
+type Scoring struct {
+    Slots        int           `yaml:"slots" env:"SCORING_SLOTS" default:"64" reload:"true"`
+    BulkSlots    int           `yaml:"bulk_slots" env:"SCORING_BULK_SLOTS" default:"16" reload:"true"`
+    QueueTimeout time.Duration `yaml:"queue_timeout" env:"SCORING_QUEUE_TIMEOUT_MS" unit:"ms" default:"1000" reload:"true"`
+    // Adaptive cuts the slot limit, never below MinSlots, while the p90
+    // time to score a transaction (Postgres, Redis and the ML service)
+    // exceeds LatencyTarget, and grows it back toward Slots once latency
+    // recovers. Bulk slots shrink in proportion.
+    Adaptive      bool          `yaml:"adaptive" env:"SCORING_ADAPTIVE" default:"true" reload:"true"`
+    MinSlots      int           `yaml:"min_slots" env:"SCORING_MIN_SLOTS" default:"4" reload:"true"`
+    LatencyTarget time.Duration `yaml:"latency_target" env:"SCORING_LATENCY_TARGET_MS" unit:"ms" default:"250" reload:"true"`
+    // BatchConcurrency is how many items of one /transactions/batch
+    // request are scored at once.
+    BatchConcurrency int `yaml:"batch_concurrency" env:"BATCH_CONCURRENCY" default:"8" reload:"true"`
+    MaxBatch         int `yaml:"max_batch" env:"BATCH_MAX_TRANSACTIONS" default:"1000" reload:"true"`
+}
+
+// Fallback configures the scoring tiers below the ML service. After
+// MLFailures consecutive failed calls the ML service is skipped for
+// MLCooldown and then tried again by a single request. LocalModel is a JSON
+// logistic regression over the ML service's features, read at startup,
+// that scores in its place; without one the rules do.
+type Fallback struct {
+    LocalModel string        `yaml:"local_model" env:"LOCAL_MODEL_PATH"`
+    MLFailures int           `yaml:"ml_failures" env:"ML_FAILURES_BEFORE_FALLBACK" default:"5" reload:"true"`
+    MLCooldown time.Duration `yaml:"ml_cooldown" env:"ML_COOLDOWN_SECONDS" unit:"s" default:"30" reload:"true"`
+}
+
+// Mirror copies Percent of /transactions/process requests to a canary
+// deployment at URL and compares its answers with the live ones. Copies are
+// sent after the live response, from a queue of Queue; when it is full they
+// are dropped, so mirroring never slows a live request. The canary's answer
+// is only logged and counted.
+type Mirror struct {
+    URL     string        `yaml:"url" env:"MIRROR_URL" reload:"true"`
+    Percent float64       `yaml:"percent" env:"MIRROR_PERCENT" default:"0" reload:"true"`
+    Timeout time.Duration `yaml:"timeout" env:"MIRROR_TIMEOUT_MS" unit:"ms" default:"2000" reload:"true"`
+    // Path is where on the canary copies are posted. A canary sharing the
+    // live Postgres and Redis should get /transactions/score-only, which
+    // stores nothing.
+    Path    string `yaml:"path" env:"MIRROR_PATH" default:"/transactions/process" reload:"true"`
+    Queue   int    `yaml:"queue" env:"MIRROR_QUEUE" default:"1000"`
+    Workers int    `yaml:"workers" env:"MIRROR_WORKERS" default:"4"`
+}
+
+// FeatureLog publishes the features and outcome of Percent of scoring
+// decisions to Topic, for the ML team to train on what the model saw
+// online. An empty Topic turns it off.
+type FeatureLog struct {
+    Topic   string  `yaml:"topic" env:"FEATURE_LOG_TOPIC" default:"fraud-features"`
+    Percent float64 `yaml:"percent" env:"FEATURE_LOG_PERCENT" default:"100" reload:"true"`
+}
+
+// Limits are the default per-user spend limits, over the UTC day and ISO
+// week; 0 is no limit. Users can have their own via PUT /users/{id}/limits.
+type Limits struct {
+    Daily  float64 `yaml:"daily" env:"LIMIT_DAILY" default:"0" reload:"true"`
+    Weekly float64 `yaml:"weekly" env:"LIMIT_WEEKLY" default:"0" reload:"true"`
+}
+
+// Lifecycle configures the API's consumer of post-authorization events
+// (captures, refunds, voids and reversals) on Topic, in consumer group
+// GroupID. An empty Topic leaves POST /transactions/{id}/events as the only
+// way in.
+type Lifecycle struct {
+    Topic   string `yaml:"topic" env:"LIFECYCLE_TOPIC" default:"fraud-transaction-events"`
+    GroupID string `yaml:"group_id" env:"LIFECYCLE_GROUP_ID" default:"fraud-api-lifecycle"`
+}
+
+// Geo configures the country and IP range rules. Entries added through the
+// /blocklist API apply on top of these lists. Countries are ISO 3166
+// alpha-2 codes, matched against both the transaction's country and the
+// GeoIP country of its IP.
+type Geo struct {
+    // Database is a CSV of network,country_code lines for GeoIP; empty
+    // turns GeoIP off.
+    Database          string   `yaml:"database" env:"GEOIP_DATABASE"`
+    BlockedCountries  []string `yaml:"blocked_countries" env:"GEO_BLOCKED_COUNTRIES" reload:"true"`
+    HighRiskCountries []string `yaml:"high_risk_countries" env:"GEO_HIGH_RISK_COUNTRIES" reload:"true"`
+    BlockedCIDRs      []string `yaml:"blocked_cidrs" env:"GEO_BLOCKED_CIDRS" reload:"true"`
+    HighRiskCIDRs     []string `yaml:"high_risk_cidrs" env:"GEO_HIGH_RISK_CIDRS" reload:"true"`
+    // RefreshInterval is how often the lists and blocklist are recompiled.
+    RefreshInterval time.Duration `yaml:"refresh_interval" env:"GEO_REFRESH_SECONDS" unit:"s" default:"30"`
+}
+
+// Localization configures the human-readable risk factor descriptions the
+// API adds for clients that send Accept-Language.
+type Localization struct {
+    // DefaultLanguage is used when Accept-Language names no language the
+    // API has descriptions in.
+    DefaultLanguage string `yaml:"default_language" env:"DEFAULT_LANGUAGE" default:"en"`
+    // DescriptionsFile is a JSON object of language to risk factor to
+    // description, read at startup, that adds to and overrides the
+    // built-in descriptions, say for plugins' risk factors.
+    DescriptionsFile string `yaml:"descriptions_file" env:"RISK_FACTOR_DESCRIPTIONS_FILE"`
+}
+
+// Thresholds configures the threshold analysis, which sweeps candidate
+// fraud thresholds against labeled history.
+type Thresholds struct {
+    AnalysisInterval time.Duration `yaml:"analysis_interval" env:"THRESHOLD_ANALYSIS_INTERVAL_MINUTES" unit:"m" default:"60"` // 0: off
+    AnalysisDays     int           `yaml:"analysis_days" env:"THRESHOLD_ANALYSIS_DAYS" default:"90" reload:"true"`
+    // MinLabeled is how many labels an analysis needs before the
+    // threshold_autotune flag applies its recommendation.
+    MinLabeled int `yaml:"min_labeled" env:"THRESHOLD_MIN_LABELED" default:"100" reload:"true"`
+}
+
+// Search configures the optional Elasticsearch/OpenSearch sink: the
+// processor indexes transactions and alerts into it and the API's /search
+// endpoints query it. An empty URL disables both.
+type Search struct {
+    URL           string        `yaml:"url" env:"SEARCH_URL"`
+    Username      string        `yaml:"username" env:"SEARCH_USERNAME"`
+    Password      string        `yaml:"password" env:"SEARCH_PASSWORD"`
+    IndexPrefix   string        `yaml:"index_prefix" env:"SEARCH_INDEX_PREFIX" default:"fraud-"`
+    BatchSize     int           `yaml:"batch_size" env:"SEARCH_BATCH_SIZE" default:"500"`
+    FlushInterval time.Duration `yaml:"flush_interval" env:"SEARCH_FLUSH_INTERVAL_MS" unit:"ms" default:"1000"`
+    // QueueSize bounds the documents waiting to be indexed; more are
+    // dropped while the cluster is slow or down.
+    QueueSize int `yaml:"queue_size" env:"SEARCH_QUEUE_SIZE" default:"10000"`
+}
+
+// ClickHouse configures the optional analytics sink the processor streams
+// scored transactions into. An empty URL disables it.
+type ClickHouse struct {
+    URL           string        `yaml:"url" env:"CLICKHOUSE_URL"`
+    Database      string        `yaml:"database" env:"CLICKHOUSE_DATABASE" default:"fraud"`
+    Table         string        `yaml:"table" env:"CLICKHOUSE_TABLE" default:"transactions"`
+    Username      string        `yaml:"username" env:"CLICKHOUSE_USERNAME" default:"default"`
+    Password      string        `yaml:"password" env:"CLICKHOUSE_PASSWORD"`
+    BatchSize     int           `yaml:"batch_size" env:"CLICKHOUSE_BATCH_SIZE" default:"5000"`
+    FlushInterval time.Duration `yaml:"flush_interval" env:"CLICKHOUSE_FLUSH_INTERVAL_MS" unit:"ms" default:"2000"`
+    QueueSize     int           `yaml:"queue_size" env:"CLICKHOUSE_QUEUE_SIZE" default:"50000"`
+}
+
+// Archive configures the processor's S3 archive of the raw messages it
+// consumes. An empty Bucket disables it. Endpoint defaults to AWS's
+// regional endpoint; set it for S3-compatible stores.
+type Archive struct {
+    Bucket          string `yaml:"bucket" env:"ARCHIVE_S3_BUCKET"`
+    Prefix          string `yaml:"prefix" env:"ARCHIVE_S3_PREFIX" default:"raw/"`
+    Region          string `yaml:"region" env:"AWS_REGION" default:"us-east-1"`
+    Endpoint        string `yaml:"endpoint" env:"ARCHIVE_S3_ENDPOINT"`
+    AccessKeyID     string `yaml:"access_key_id" env:"AWS_ACCESS_KEY_ID"`
+    SecretAccessKey string `yaml:"secret_access_key" env:"AWS_SECRET_ACCESS_KEY"`
+    SessionToken    string `yaml:"session_token" env:"AWS_SESSION_TOKEN"`
+    // An hour's messages are written in parts: one is closed every
+    // FlushInterval or once it holds MaxObjectBytes of uncompressed data.
+    FlushInterval  time.Duration `yaml:"flush_interval" env:"ARCHIVE_FLUSH_INTERVAL_SECONDS" unit:"s" default:"300"`
+    MaxObjectBytes int           `yaml:"max_object_bytes" env:"ARCHIVE_MAX_OBJECT_BYTES" default:"67108864"`
+    QueueSize      int           `yaml:"queue_size" env:"ARCHIVE_QUEUE_SIZE" default:"50000"`
+}
+
+// Warehouse configures the API's scheduled export of transactions, alerts
+// and labels to BigQuery or Snowflake. An empty Kind disables it.
+type Warehouse struct {
+    Kind     string        `yaml:"kind" env:"WAREHOUSE_KIND"` // bigquery or snowflake
+    Interval time.Duration `yaml:"interval" env:"WAREHOUSE_EXPORT_INTERVAL_MINUTES" unit:"m" default:"15" reload:"true"`
+    // BatchSize rows are loaded per statement.
+    BatchSize int `yaml:"batch_size" env:"WAREHOUSE_BATCH_SIZE" default:"5000" reload:"true"`
+    // Lag holds back rows changed this recently, so a row written by a
+    // transaction that commits late isn't skipped by the watermark.
+    Lag       time.Duration `yaml:"lag" env:"WAREHOUSE_EXPORT_LAG_SECONDS" unit:"s" default:"120" reload:"true"`
+    BigQuery  BigQuery      `yaml:"bigquery"`
+    Snowflake Snowflake     `yaml:"snowflake"`
+}
+
+type BigQuery struct {
+    Project  string `yaml:"project" env:"BIGQUERY_PROJECT"`
+    Dataset  string `yaml:"dataset" env:"BIGQUERY_DATASET" default:"fraud"`
+    Location string `yaml:"location" env:"BIGQUERY_LOCATION" default:"US"`
+    // CredentialsFile is a service account key (JSON).
+    CredentialsFile string `yaml:"credentials_file" env:"GOOGLE_APPLICATION_CREDENTIALS"`
+}
+
+// Snowflake authenticates with key-pair authentication: PrivateKeyFile is
+// the user's unencrypted RSA key in PEM.
+type Snowflake struct {
+    Account        string `yaml:"account" env:"SNOWFLAKE_ACCOUNT"`
+    User           string `yaml:"user" env:"SNOWFLAKE_USER"`
+    PrivateKeyFile string `yaml:"private_key_file" env:"SNOWFLAKE_PRIVATE_KEY_FILE"`
+    Database       string `yaml:"database" env:"SNOWFLAKE_DATABASE" default:"FRAUD"`
+    Schema         string `yaml:"schema" env:"SNOWFLAKE_SCHEMA" default:"PUBLIC"`
+    Warehouse      string `yaml:"warehouse" env:"SNOWFLAKE_WAREHOUSE"`
+    Role           string `yaml:"role" env:"SNOWFLAKE_ROLE"`
+}
+
+// Reports configures the API's scheduled fraud summaries. The daily report
+// covers the previous UTC day and the weekly one the previous week, Monday
+// to Monday; each is generated at Hour UTC and delivered by email, to S3, or
+// both.
+type Reports struct {
+    Daily   bool        `yaml:"daily" env:"REPORT_DAILY" default:"false" reload:"true"`
+    Weekly  bool        `yaml:"weekly" env:"REPORT_WEEKLY" default:"false" reload:"true"`
+    Hour    int         `yaml:"hour" env:"REPORT_HOUR_UTC" default:"6" reload:"true"`
+    Formats []string    `yaml:"formats" env:"REPORT_FORMATS" default:"csv,pdf" reload:"true"`
+    Email   ReportEmail `yaml:"email"`
+    S3      ReportS3    `yaml:"s3"`
+}
+
+// ReportEmail sends reports through an SMTP relay; an empty To disables it.
+type ReportEmail struct {
+    SMTPAddr string   `yaml:"smtp_addr" env:"REPORT_SMTP_ADDR"` // host:port
+    Username string   `yaml:"username" env:"REPORT_SMTP_USERNAME"`
+    Password string   `yaml:"password" env:"REPORT_SMTP_PASSWORD"`
+    From     string   `yaml:"from" env:"REPORT_EMAIL_FROM"`
+    To       []string `yaml:"to" env:"REPORT_EMAIL_TO"`
+}
+
+// ReportS3 uploads reports with the archive's region and credentials; an
+// empty Bucket disables it.
+type ReportS3 struct {
+    Bucket   string `yaml:"bucket" env:"REPORT_S3_BUCKET"`
+    Prefix   string `yaml:"prefix" env:"REPORT_S3_PREFIX" default:"reports/"`
+    Endpoint string `yaml:"endpoint" env:"REPORT_S3_ENDPOINT"`
+}
+
+// Suppressions bounds alert suppression windows. The processor reloads the
+// live ones, and the API expires finished ones, every RefreshInterval.
+type Suppressions struct {
+    MaxWindow       time.Duration `yaml:"max_window" env:"SUPPRESSION_MAX_WINDOW_HOURS" unit:"h" default:"720" reload:"true"`
+    RefreshInterval time.Duration `yaml:"refresh_interval" env:"SUPPRESSION_REFRESH_SECONDS" unit:"s" default:"30" reload:"true"`
+}
+
+// AutoClose closes open alerts of Severities that no analyst has been
+// assigned MaxAge after they were raised, checked every Interval, so the
+// queue isn't clogged by alerts nobody will get to. A zero MaxAge disables
+// it.
+type AutoClose struct {
+    MaxAge     time.Duration `yaml:"max_age" env:"ALERT_AUTO_CLOSE_AGE_HOURS" unit:"h" default:"168" reload:"true"`
+    Severities []string      `yaml:"severities" env:"ALERT_AUTO_CLOSE_SEVERITIES" default:"LOW" reload:"true"`
+    Interval   time.Duration `yaml:"interval" env:"ALERT_AUTO_CLOSE_INTERVAL_MINUTES" unit:"m" default:"60" reload:"true"`
+}
+
+// AlertSLA sets how soon alerts must be acknowledged, by being assigned or
+// resolved, as severity=duration Targets ("CRITICAL=15m"). Severities
+// without one have no SLA. Open alerts are checked every Interval, and
+// each one found past its target is marked breached and announced on the
+// fraud-alerts topic once.
+type AlertSLA struct {
+    Targets  []string      `yaml:"targets" env:"ALERT_SLA_TARGETS" default:"CRITICAL=15m,HIGH=1h" reload:"true"`
+    Interval time.Duration `yaml:"interval" env:"ALERT_SLA_CHECK_SECONDS" unit:"s" default:"60" reload:"true"`
+}
+
+// Target is the severity's acknowledgement target, or 0 when it has none.
+func (a AlertSLA) Target(severity string) time.Duration {
+    for _, t := range a.Targets {
+        name, v, _ := strings.Cut(t, "=")
+        if !strings.EqualFold(strings.TrimSpace(name), severity) { continue }
+        if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil { return d }
+    }
+    return 0
+}
+
+// Cases controls how the processor groups alerts into cases: an alert joins
+// the open case sharing one of its entities (user, device, card) whose last
+// alert is within Window, or opens a new one. A zero Window disables it.
+type Cases struct {
+    Window time.Duration `yaml:"window" env:"CASE_WINDOW_HOURS" unit:"h" default:"72" reload:"true"`
+    By     []string      `yaml:"by" env:"CASE_GROUP_BY" default:"user,device,card" reload:"true"`
+}
+
+// Stats maintains the daily per-merchant, user and device rollups behind
+// GET /stats/top. Every RollupInterval the API recomputes the last
+// RollupLookbackDays days, so labels that arrive later are counted.
+// Rankings by fraud rate leave out entities with fewer than
+// MinTransactions.
+type Stats struct {
+    RollupInterval     time.Duration `yaml:"rollup_interval" env:"STATS_ROLLUP_INTERVAL_MINUTES" unit:"m" default:"15" reload:"true"`
+    RollupLookbackDays int           `yaml:"rollup_lookback_days" env:"STATS_ROLLUP_LOOKBACK_DAYS" default:"7" reload:"true"`
+    MinTransactions    int           `yaml:"min_transactions" env:"STATS_MIN_TRANSACTIONS" default:"20" reload:"true"`
+}
+
+// Enrichment controls the API's feature enrichment stages (reputation,
+// history, category, velocity, contact, kyc, tenure, travel, geo,
+// precomputed): which run and how long each may take.
+type Enrichment struct {
+    Disabled []string      `yaml:"disabled" env:"ENRICHERS_DISABLED" reload:"true"`
+    Timeout  time.Duration `yaml:"timeout" env:"ENRICHER_TIMEOUT_MS" unit:"ms" default:"500" reload:"true"`
+    // Timeouts overrides Timeout for single stages, as stage=duration
+    // entries ("history=1s").
+    Timeouts []string `yaml:"timeouts" env:"ENRICHER_TIMEOUTS" reload:"true"`
+    // Plugins are external enrichment stages, as name=host:port entries of
+    // gRPC servers implementing protos/enricher.proto.
+    Plugins []string `yaml:"plugins" env:"ENRICHER_PLUGINS"`
+}
+
+func (e Enrichment) Enabled(stage string) bool {
+    for _, d := range e.Disabled {
+        if strings.EqualFold(d, stage) { return false }
+    }
+    return true
+}
+
+func (e Enrichment) StageTimeout(stage string) time.Duration {
+    for _, t := range e.Timeouts {
+        name, v, _ := strings.Cut(t, "=")
+        if !strings.EqualFold(strings.TrimSpace(name), stage) { continue }
+        if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil { return d }
+    }
+    return e.Timeout
+}
+
+// Feast reads features from a Feast feature server (feast serve, in front
+// of its Redis online store) at URL, so the data platform owns their
+// definitions, instead of from the API's own Redis keys and Postgres
+// queries. Features maps lookups (FeastLookups) to feature references, as
+// lookup=view:feature entries, for the entity Entity keyed by user ID. A
+// lookup without an entry, or one Feast has no value for, is read as
+// before.
+type Feast struct {
+    URL      string        `yaml:"url" env:"FEAST_URL"`
+    Entity   string        `yaml:"entity" env:"FEAST_ENTITY" default:"user_id"`
+    Features []string      `yaml:"features" env:"FEAST_FEATURES" reload:"true"`
+    Timeout  time.Duration `yaml:"timeout" env:"FEAST_TIMEOUT_MS" unit:"ms" default:"100" reload:"true"`
+}
+
+// Precomputed configures POST /features/batch, through which the offline
+// pipeline pushes per-user aggregates for scoring. A user's values expire
+// TTL after their last write, so a pipeline that stops leaves no stale
+// aggregates behind. MaxBatch caps the users in one request.
+type Precomputed struct {
+    TTL      time.Duration `yaml:"ttl" env:"PRECOMPUTED_FEATURES_TTL_HOURS" unit:"h" default:"48" reload:"true"`
+    MaxBatch int           `yaml:"max_batch" env:"PRECOMPUTED_FEATURES_MAX_BATCH" default:"10000" reload:"true"`
+}
+
+// FeastLookups are the lookups Feast can serve: the inputs of the user
+// risk, history and velocity features.
+var FeastLookups = []string{"user_risk", "average_amount", "amount_count", "amount_mean", "amount_m2", "card_velocity"}
+
+// Feature returns the feature reference lookup is mapped to, "" if none.
+func (f Feast) Feature(lookup string) string {
+    for _, e := range f.Features {
+        name, ref, _ := strings.Cut(e, "=")
+        if strings.EqualFold(strings.TrimSpace(name), lookup) { return strings.TrimSpace(ref) }
+    }
+    return ""
+}
+
+type Flags struct {
+    // RefreshInterval is how often services re-read the feature flags from
+    // Redis, i.e. how long a change takes to apply.
+    RefreshInterval time.Duration `yaml:"refresh_interval" env:"FEATURE_FLAGS_REFRESH_SECONDS" unit:"s" default:"10"`
+}
+
+// Faults injects latency and errors into the services' calls to Postgres,
+// Redis, Kafka and gRPC services (the ML service and enrichment plugins), to
+// check that timeouts, fallbacks and retries hold up. It is for development
+// and resilience testing only; never enable it in production.
+type Faults struct {
+    Enabled bool `yaml:"enabled" env:"FAULTS_ENABLED" default:"false" reload:"true"`
+    // Latency and ErrorRate are target=value entries, the targets being
+    // postgres, redis, kafka and grpc: "postgres=200ms" delays every query
+    // by 200ms, "redis=0.5" fails half of all Redis commands.
+    Latency   []string `yaml:"latency" env:"FAULT_LATENCY" reload:"true"`
+    ErrorRate []string `yaml:"error_rate" env:"FAULT_ERROR_RATE" reload:"true"`
+}
+
+// FaultTargets are the calls Faults can target.
+var FaultTargets = []string{"postgres", "redis", "kafka", "grpc"}
+
+// For returns the latency and error rate configured for target.
+func (f Faults) For(target string) (latency time.Duration, errorRate float64) {
+    for _, l := range f.Latency {
+        name, v, _ := strings.Cut(l, "=")
+        if !strings.EqualFold(strings.TrimSpace(name), target) { continue }
+        if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil { latency = d }
+    }
+    for _, r := range f.ErrorRate {
+        name, v, _ := strings.Cut(r, "=")
+        if !strings.EqualFold(strings.TrimSpace(name), target) { continue }
+        if p, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil { errorRate = p }
+    }
+    return latency, errorRate
+}
+
+// Startup controls how long services wait for Postgres, Redis and Kafka to
+// come up, so they can be started in any order.
+type Startup struct {
+    RetryAttempts  int           `yaml:"retry_attempts" env:"STARTUP_RETRY_ATTEMPTS" default:"10"` // 0: retry forever
+    InitialBackoff time.Duration `yaml:"initial_backoff" env:"STARTUP_INITIAL_BACKOFF_MS" unit:"ms" default:"500"`
+    MaxBackoff     time.Duration `yaml:"max_backoff" env:"STARTUP_MAX_BACKOFF_MS" unit:"ms" default:"15000"`
+    // LazyKafka starts serving without waiting for Kafka and the schema
+    // registry; the Kafka-side setup keeps retrying in the background.
+    LazyKafka bool `yaml:"lazy_kafka" env:"KAFKA_LAZY_INIT" default:"false"`
+}
+
+// Secrets names a secret in Vault or AWS Secrets Manager to read the
+// settings tagged secret:"true" from: the Postgres, Redis and Kafka
+// credentials and the webhook signing keys. The secret's keys are the
+// settings' environment variable names, e.g. POSTGRES_PASSWORD, and win
+// over the file and the environment. It is read again every
+// RefreshInterval and changed credentials are applied without a restart.
+type Secrets struct {
+    Provider string `yaml:"provider" env:"SECRETS_PROVIDER"` // vault or aws; empty: off
+    // VaultPath is the secret's API path, e.g. secret/data/fraud for a KV
+    // version 2 engine mounted at secret/. TokenFile, re-read on every
+    // refresh, wins over Token.
+    VaultAddr      string `yaml:"vault_addr" env:"VAULT_ADDR"`
+    VaultToken     string `yaml:"vault_token" env:"VAULT_TOKEN"`
+    VaultTokenFile string `yaml:"vault_token_file" env:"VAULT_TOKEN_FILE"`
+    VaultPath      string `yaml:"vault_path" env:"SECRETS_VAULT_PATH"`
+    // AWSSecretID is read with archive.region and the archive's AWS
+    // credentials.
+    AWSSecretID     string        `yaml:"aws_secret_id" env:"SECRETS_AWS_SECRET_ID"`
+    RefreshInterval time.Duration `yaml:"refresh_interval" env:"SECRETS_REFRESH_SECONDS" unit:"s" default:"300" reload:"true"` // 0: never
+}
+
+// Validate reports every invalid setting at once, so a bad file or
+// environment fails startup (or a reload) with the full list.
+func (c *Config) Validate() error {
+    var errs []error
+    check := func(ok bool, format string, args ...interface{}) {
+        if !ok { errs = append(errs, fmt.Errorf(format, args...)) }
+    }
+    unit := func(v float64) bool { return v >= 0 && v <= 1 }
+
+    check(oneOf(c.EventBus, "kafka", "redis"), "event_bus: unknown transport %q", c.EventBus)
+
+    check(c.Postgres.Host != "", "postgres.host is required")
+    check(c.Postgres.MaxConns > 0, "postgres.max_conns must be positive")
+    check(c.Postgres.MinConns >= 0 && c.Postgres.MinConns <= c.Postgres.MaxConns, "postgres.min_conns must be between 0 and max_conns")
+    check(c.Postgres.QueryTimeout > 0, "postgres.query_timeout must be positive")
+    check(c.Postgres.ReplicaMaxLag > 0, "postgres.replica_max_lag must be positive")
+
+    check(c.Redis.Host != "", "redis.host is required")
+    r := c.Redis
+    check(r.Username == "" || r.Password != "", "redis.username needs redis.password")
+    check(r.DB >= 0, "redis.db must not be negative")
+    check(r.TLS || (r.TLSCAFile == "" && r.TLSCertFile == ""), "redis.tls_ca_file and redis.tls_cert_file need redis.tls")
+    check((r.TLSCertFile == "") == (r.TLSKeyFile == ""), "redis.tls_cert_file and redis.tls_key_file go together")
+    check(!strings.ContainsAny(r.ClientName, " \n"), "redis.client_name must not contain spaces")
+    check(c.Redis.StreamMaxLen > 0, "redis.stream_max_len must be positive")
+
+    check(len(c.Kafka.Brokers) > 0, "kafka.brokers is required")
+    check(oneOf(c.Kafka.Encoding, "protobuf", "json", "avro"), "kafka.encoding: unknown encoding %q", c.Kafka.Encoding)
+    check(c.Kafka.BatchSize > 0, "kafka.batch_size must be positive")
+    k := c.Kafka
+    check(k.TLS || (k.TLSCAFile == "" && k.TLSCertFile == ""), "kafka.tls_ca_file and kafka.tls_cert_file need kafka.tls")
+    check((k.TLSCertFile == "") == (k.TLSKeyFile == ""), "kafka.tls_cert_file and kafka.tls_key_file go together")
+    check(oneOf(k.SASLMechanism, "", "plain", "scram-sha-256", "scram-sha-512"), "kafka.sasl_mechanism: unknown mechanism %q", k.SASLMechanism)
+    check(k.SASLMechanism == "" || k.SASLUsername != "", "kafka.sasl_mechanism needs sasl_username and sasl_password")
+    check(k.SASLMechanism != "plain" || k.TLS, "kafka.sasl_mechanism plain sends the password in the clear; enable kafka.tls")
+
+    check(c.Partitions.MonthsAhead >= 0, "partitions.months_ahead must not be negative")
+    check(c.Partitions.RetentionMonths >= 0, "partitions.retention_months must not be negative")
+    check(c.Partitions.MaintenanceInterval > 0, "partitions.maintenance_interval must be positive")
+
+    check(unit(c.API.PriorityScoreThreshold), "api.priority_score_threshold must be between 0 and 1")
+    check(c.API.ResponseCacheTTL >= 0, "api.response_cache_ttl must not be negative")
+    check(c.API.UserRiskCacheTTL > 0, "api.user_risk_cache_ttl must be positive")
+    check(c.API.UserRiskNegativeTTL > 0, "api.user_risk_negative_ttl must be positive")
+    check(c.API.UserHistoryDays > 0, "api.user_history_days must be positive")
+    check(c.API.CacheWarmUsers >= 0, "api.cache_warm_users must not be negative")
+    check(c.API.CacheWarmInterval >= 0, "api.cache_warm_interval must not be negative")
+    check(c.API.OutboxRelayInterval > 0, "api.outbox_relay_interval must be positive")
+
+    check(unit(c.Rules.FraudThreshold), "rules.fraud_threshold must be between 0 and 1")
+    check(c.Rules.HighAmount > 0, "rules.high_amount must be positive")
+    check(c.Rules.ACHHighAmount > 0, "rules.ach_high_amount must be positive")
+    check(c.Rules.WireHighAmount > 0, "rules.wire_high_amount must be positive")
+    check(c.Rules.P2PHighAmount > 0, "rules.p2p_high_amount must be positive")
+    check(unit(c.Rules.HighMerchantRisk), "rules.high_merchant_risk must be between 0 and 1")
+    check(unit(c.Rules.HighUserRisk), "rules.high_user_risk must be between 0 and 1")
+    check(c.Rules.UnusualAmountRatio > 0, "rules.unusual_amount_ratio must be positive")
+    check(c.Rules.AmountZScore > 0, "rules.amount_zscore must be positive")
+    for _, cat := range c.Rules.HighRiskCategories { check(cat != "", "rules.high_risk_categories must not contain empty entries") }
+    check(c.Rules.CardVelocity > 0, "rules.card_velocity must be positive")
+    check(c.Rules.CardVelocityWindow > 0, "rules.card_velocity_window must be positive")
+    check(c.Rules.NewEmailDays >= 0, "rules.new_email_days must not be negative")
+    check(c.Rules.KYCAmount >= 0, "rules.kyc_amount must not be negative")
+    check(c.Rules.NewAccountWindow >= 0, "rules.new_account_window must not be negative")
+    check(c.Rules.NewAccountHighAmount > 0, "rules.new_account_high_amount must be positive")
+    check(c.Rules.NewAccountVelocity > 0, "rules.new_account_velocity must be positive")
+    check(unit(c.Rules.BehavioralWeight), "rules.behavioral_weight must be between 0 and 1")
+    check(unit(c.Rules.HighBehavioralScore), "rules.high_behavioral_score must be between 0 and 1")
+    check(unit(c.Rules.VerificationFailureRisk), "rules.verification_failure_risk must be between 0 and 1")
+    check(c.Rules.FalsePositiveCost >= 0, "rules.false_positive_cost must not be negative")
+    check(c.Rules.ReviewExpectedLoss >= 0, "rules.review_expected_loss must not be negative")
+
+    check(c.Processor.Workers >= 0, "processor.workers must not be negative")
+    check(c.Processor.MaxInFlight > 0, "processor.max_inflight must be positive")
+    check(c.Processor.BatchSize > 0, "processor.batch_size must be positive")
+    check(c.Processor.BatchLinger >= 0, "processor.batch_linger must not be negative")
+    check(c.Processor.AmountStatsWindow >= 2, "processor.amount_stats_window must be at least 2")
+    check(c.Processor.MaxClockSkew >= 0, "processor.max_clock_skew must not be negative")
+    check(c.Processor.MaxEventAge >= 0, "processor.max_event_age must not be negative")
+    check(c.Processor.RiskStatePartitions > 0, "processor.risk_state_partitions must be positive")
+    check(c.Processor.RiskStateReplication > 0, "processor.risk_state_replication must be positive")
+
+    check(c.CardTesting.MaxAmount > 0, "card_testing.max_amount must be positive")
+    check(c.CardTesting.Users >= 2, "card_testing.users must be at least 2")
+    check(c.CardTesting.Window > 0, "card_testing.window must be positive")
+    check(c.CardTesting.BlockDuration >= 0, "card_testing.block_duration must not be negative")
+
+    check(c.Drift.Interval >= 0, "drift.interval must not be negative")
+    check(c.Drift.Window >= 5*time.Minute, "drift.window must be at least 5m")
+    check(c.Drift.Reference >= time.Hour, "drift.reference must be at least 1h")
+    check(c.Drift.MinSamples > 0, "drift.min_samples must be positive")
+    check(c.Drift.PSIWarn > 0 && c.Drift.PSIAlert >= c.Drift.PSIWarn, "drift.psi_alert must be at least psi_warn, both positive")
+    check(c.Drift.MeanShift >= 0, "drift.mean_shift must not be negative")
+
+    check(c.Duplicates.Window >= 0, "duplicates.window must not be negative")
+
+    check(c.Webhooks.Tolerance > 0, "webhooks.tolerance must be positive")
+    check(c.Webhooks.Queue > 0, "webhooks.queue must be positive")
+    check(c.Webhooks.Workers > 0, "webhooks.workers must be positive")
+
+    check(c.API.LatencyBudget >= 0, "api.latency_budget must not be negative")
+    check(c.API.CompressMinBytes >= 0, "api.compress_min_bytes must not be negative")
+    check(c.API.MaxDecompressedMB > 0, "api.max_decompressed_mb must be positive")
+    check(c.API.MaxBodyKB > 0, "api.max_body_kb must be positive")
+    check(c.API.MaxBatchBodyMB > 0, "api.max_batch_body_mb must be positive")
+    check(c.API.MaxImportMB > 0, "api.max_import_mb must be positive")
+
+    m := c.MLTLS
+    check(oneOf(m.Mode, "insecure", "tls", "mtls", "spiffe"), "ml_tls.mode: unknown mode %q", m.Mode)
+    check((m.CertFile == "") == (m.KeyFile == ""), "ml_tls.cert_file and ml_tls.key_file go together")
+    check(!oneOf(m.Mode, "mtls", "spiffe") || m.CertFile != "", "ml_tls.mode %s needs cert_file and key_file", m.Mode)
+    check(m.Mode != "spiffe" || (m.CAFile != "" && strings.HasPrefix(m.SPIFFEID, "spiffe://")), "ml_tls.mode spiffe needs ca_file, the trust bundle, and a spiffe:// spiffe_id")
+    check(m.ReloadInterval >= time.Second, "ml_tls.reload_interval must be at least 1s")
+
+    e := c.MLEndpoints
+    check(e.Policy == "pick_first" || e.Policy == "round_robin", "ml_endpoints.policy must be pick_first or round_robin")
+    check(e.HealthInterval > 0, "ml_endpoints.health_interval must be positive")
+    check(e.HealthTimeout > 0, "ml_endpoints.health_timeout must be positive")
+    check(e.HealthFailures > 0, "ml_endpoints.health_failures must be positive")
+
+    s := c.Secrets
+    check(s.Provider == "" || s.Provider == "vault" || s.Provider == "aws", "secrets.provider: unknown provider %q", s.Provider)
+    check(s.Provider != "vault" || (s.VaultAddr != "" && s.VaultPath != "" && (s.VaultToken != "" || s.VaultTokenFile != "")), "secrets.provider vault needs vault_addr, vault_path and vault_token or vault_token_file")
+    check(s.Provider != "aws" || (s.AWSSecretID != "" && c.Archive.Region != ""), "secrets.provider aws needs aws_secret_id and archive.region")
+    check(s.RefreshInterval >= 0, "secrets.refresh_interval must not be negative")
+
+    for _, o := range c.HTTP.AllowedOrigins {
+        u, err := url.Parse(o)
+        check(o == "*" || (err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/")), "http.allowed_origins: %q is not an origin like https://ui.example.com", o)
+    }
+    check(!c.HTTP.AllowCredentials || !oneOf("*", c.HTTP.AllowedOrigins...), "http.allow_credentials can't be combined with a \"*\" origin")
+    check(c.HTTP.PreflightMaxAge >= 0, "http.preflight_max_age must not be negative")
+    check(c.HTTP.HSTSMaxAge >= 0, "http.hsts_max_age must not be negative")
+
+    names := map[string]bool{}
+    for _, k := range c.APIKeys.Keys {
+        name, h, ok := strings.Cut(k, "=")
+        name, h = strings.TrimSpace(name), strings.TrimSpace(h)
+        _, err := hex.DecodeString(h)
+        check(ok && name != "" && len(h) == 64 && err == nil, "api_keys.keys: %q is not name=sha256 hex", k)
+        check(len(name) <= 100 && !strings.EqualFold(name, "anonymous"), "api_keys.keys: %q can't be a key name", name)
+        check(!names[name], "api_keys.keys: %q is listed twice", name)
+        names[name] = true
+    }
+    check(c.RateLimit.Requests >= 0, "rate_limit.requests must not be negative")
+    check(c.RateLimit.Window >= time.Second, "rate_limit.window must be at least 1s")
+    for _, n := range c.RateLimit.TrustedProxies {
+        _, err := netip.ParsePrefix(n)
+        check(err == nil, "rate_limit: %q is not a CIDR range", n)
+    }
+    check(c.Callers.SpikeFactor > 1, "callers.spike_factor must be above 1")
+    check(c.Callers.SpikeMinRequests > 0, "callers.spike_min_requests must be positive")
+    check(c.Callers.Baseline >= 5*time.Minute && c.Callers.Baseline <= 24*time.Hour, "callers.baseline must be between 5m and 24h")
+    check(c.Callers.EnumerationMisses > 0, "callers.enumeration_misses must be positive")
+    check(c.Callers.UserAgents > 1, "callers.user_agents must be at least 2")
+    check(c.Callers.Cooldown >= time.Minute, "callers.cooldown must be at least 1m")
+    check(c.Lifecycle.Topic == "" || c.Lifecycle.GroupID != "", "lifecycle.group_id is required with a topic")
+    check(c.Localization.DefaultLanguage != "", "localization.default_language is required")
+    check(c.Abuse.Threshold > 0, "abuse.threshold must be positive")
+    check(c.Abuse.Window >= time.Second, "abuse.window must be at least 1s")
+    check(c.Abuse.Tarpit >= 0 && c.Abuse.Tarpit <= 10*time.Second, "abuse.tarpit must be between 0 and 10s, under the API's write timeout")
+    check(c.Abuse.BlockAt >= 0, "abuse.block_at must not be negative")
+    check(c.Abuse.BlockAction == "block" || c.Abuse.BlockAction == "risk", "abuse.block_action must be block or risk")
+    for _, p := range c.Abuse.HoneypotPaths {
+        check(strings.HasPrefix(p, "/") && p != "/" && p != "/health" && p != "/metrics", "abuse.honeypot_paths: %q must be a path other than /, /health and /metrics", p)
+    }
+    if c.Admin.OIDCIssuer != "" {
+        u, err := url.Parse(c.Admin.OIDCIssuer)
+        local := err == nil && u.Scheme == "http" && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1")
+        check(err == nil && (u.Scheme == "https" || local) && u.Host != "", "admin.oidc_issuer must be an https URL")
+        check(c.Admin.OIDCAudience != "", "admin.oidc_issuer needs oidc_audience")
+        check(c.Admin.GroupsClaim != "", "admin.groups_claim must not be empty")
+        check(len(c.Admin.AllowedGroups)+len(c.Admin.AllowedEmails) > 0, "admin.oidc_issuer needs allowed_groups or allowed_emails")
+    }
+    check(c.Usage.FlushInterval >= time.Second, "usage.flush_interval must be at least 1s")
+    check(c.Usage.MaxKeys >= 1, "usage.max_keys must be at least 1")
+
+    check(c.Scoring.Slots > 0, "scoring.slots must be positive")
+    check(c.Scoring.BulkSlots > 0 && c.Scoring.BulkSlots < c.Scoring.Slots, "scoring.bulk_slots must be between 1 and slots-1")
+    check(c.Scoring.QueueTimeout > 0, "scoring.queue_timeout must be positive")
+    check(c.Scoring.MinSlots > 0 && c.Scoring.MinSlots <= c.Scoring.Slots, "scoring.min_slots must be between 1 and slots")
+    check(c.Scoring.LatencyTarget > 0, "scoring.latency_target must be positive")
+    check(c.Scoring.BatchConcurrency > 0, "scoring.batch_concurrency must be positive")
+    check(c.Fallback.MLFailures > 0, "fallback.ml_failures must be positive")
+    check(c.Fallback.MLCooldown > 0, "fallback.ml_cooldown must be positive")
+
+    if c.Mirror.URL != "" {
+        u, err := url.Parse(c.Mirror.URL)
+        check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "mirror.url must be an http(s) URL")
+    }
+    check(c.Mirror.Percent >= 0 && c.Mirror.Percent <= 100, "mirror.percent must be between 0 and 100")
+    check(c.Mirror.Timeout > 0, "mirror.timeout must be positive")
+    check(strings.HasPrefix(c.Mirror.Path, "/"), "mirror.path must start with /")
+    check(c.Mirror.Queue > 0, "mirror.queue must be positive")
+    check(c.Mirror.Workers > 0, "mirror.workers must be positive")
+    check(c.Scoring.MaxBatch > 0, "scoring.max_batch must be positive")
+    check(c.FeatureLog.Percent >= 0 && c.FeatureLog.Percent <= 100, "feature_log.percent must be between 0 and 100")
+
+    check(c.Limits.Daily >= 0, "limits.daily must not be negative")
+    check(c.Limits.Weekly >= 0, "limits.weekly must not be negative")
+
+    for _, cc := range append(append([]string{}, c.Geo.BlockedCountries...), c.Geo.HighRiskCountries...) {
+        check(len(cc) == 2, "geo: %q is not a two-letter country code", cc)
+    }
+    for _, n := range append(append([]string{}, c.Geo.BlockedCIDRs...), c.Geo.HighRiskCIDRs...) {
+        _, err := netip.ParsePrefix(n)
+        check(err == nil, "geo: %q is not a CIDR range", n)
+    }
+    check(c.Geo.RefreshInterval > 0, "geo.refresh_interval must be positive")
+
+    check(c.Thresholds.AnalysisInterval >= 0, "thresholds.analysis_interval must not be negative")
+    check(c.Thresholds.AnalysisDays > 0, "thresholds.analysis_days must be positive")
+    check(c.Thresholds.MinLabeled > 0, "thresholds.min_labeled must be positive")
+
+    check(c.Search.BatchSize > 0, "search.batch_size must be positive")
+    check(c.Search.FlushInterval > 0, "search.flush_interval must be positive")
+    check(c.Search.QueueSize >= c.Search.BatchSize, "search.queue_size must be at least batch_size")
+
+    check(isIdentifier(c.ClickHouse.Database), "clickhouse.database must be a plain identifier")
+    check(isIdentifier(c.ClickHouse.Table), "clickhouse.table must be a plain identifier")
+    check(c.ClickHouse.BatchSize > 0, "clickhouse.batch_size must be positive")
+    check(c.ClickHouse.FlushInterval > 0, "clickhouse.flush_interval must be positive")
+    check(c.ClickHouse.QueueSize >= c.ClickHouse.BatchSize, "clickhouse.queue_size must be at least batch_size")
+
+    check(c.Archive.Bucket == "" || c.Archive.Region != "", "archive.region is required with a bucket")
+    check(c.Archive.FlushInterval > 0 && c.Archive.FlushInterval <= time.Hour, "archive.flush_interval must be between 1s and 1h")
+    check(c.Archive.MaxObjectBytes > 0, "archive.max_object_bytes must be positive")
+    check(c.Archive.QueueSize > 0, "archive.queue_size must be positive")
+
+    check(c.Warehouse.Kind == "" || oneOf(c.Warehouse.Kind, "bigquery", "snowflake"), "warehouse.kind must be bigquery or snowflake")
+    check(c.Warehouse.Interval >= 0, "warehouse.interval must not be negative")
+    check(c.Warehouse.BatchSize > 0, "warehouse.batch_size must be positive")
+    check(c.Warehouse.Lag >= 0, "warehouse.lag must not be negative")
+    switch strings.ToLower(c.Warehouse.Kind) {
+    case "bigquery":
+        bq := c.Warehouse.BigQuery
+        check(bq.Project != "" && bq.CredentialsFile != "", "warehouse.bigquery needs project and credentials_file")
+        check(isIdentifier(bq.Dataset), "warehouse.bigquery.dataset must be a plain identifier")
+    case "snowflake":
+        sf := c.Warehouse.Snowflake
+        check(sf.Account != "" && sf.User != "" && sf.PrivateKeyFile != "", "warehouse.snowflake needs account, user and private_key_file")
+        check(isIdentifier(sf.Database) && isIdentifier(sf.Schema), "warehouse.snowflake database and schema must be plain identifiers")
+    }
+
+    check(c.Reports.Hour >= 0 && c.Reports.Hour < 24, "reports.hour must be between 0 and 23")
+    for _, f := range c.Reports.Formats { check(oneOf(f, "csv", "pdf"), "reports.formats: unknown format %q", f) }
+    if c.Reports.Daily || c.Reports.Weekly {
+        check(len(c.Reports.Formats) > 0, "reports.formats is required when reports are on")
+        check(len(c.Reports.Email.To) > 0 || c.Reports.S3.Bucket != "", "reports need email.to or s3.bucket")
+        check(len(c.Reports.Email.To) == 0 || c.Reports.Email.SMTPAddr != "" && c.Reports.Email.From != "", "reports.email needs smtp_addr and from")
+    }
+
+    check(c.Suppressions.MaxWindow > 0, "suppressions.max_window must be positive")
+    check(c.Suppressions.RefreshInterval > 0, "suppressions.refresh_interval must be positive")
+
+    check(c.Cases.Window >= 0, "cases.window must not be negative")
+    for _, b := range c.Cases.By { check(oneOf(b, "user", "device", "card"), "cases.by: unknown entity %q", b) }
+
+    check(c.AutoClose.MaxAge >= 0, "auto_close.max_age must not be negative")
+    check(c.AutoClose.Interval > 0, "auto_close.interval must be positive")
+    for _, s := range c.AutoClose.Severities {
+        check(oneOf(s, "LOW", "MEDIUM", "HIGH", "CRITICAL"), "auto_close.severities: %q is not LOW, MEDIUM, HIGH or CRITICAL", s)
+    }
+    check(c.AutoClose.MaxAge == 0 || len(c.AutoClose.Severities) > 0, "auto_close.severities is required")
+
+    check(c.AlertSLA.Interval > 0, "alert_sla.interval must be positive")
+    for _, t := range c.AlertSLA.Targets {
+        sev, v, ok := strings.Cut(t, "=")
+        d, err := time.ParseDuration(strings.TrimSpace(v))
+        check(ok && oneOf(strings.TrimSpace(sev), "LOW", "MEDIUM", "HIGH", "CRITICAL") && err == nil && d > 0, "alert_sla.targets: %q is not severity=duration", t)
+    }
+
+    check(c.Stats.RollupInterval > 0, "stats.rollup_interval must be positive")
+    check(c.Stats.RollupLookbackDays >= 1, "stats.rollup_lookback_days must be at least 1")
+    check(c.Stats.MinTransactions >= 1, "stats.min_transactions must be at least 1")
+
+    check(c.Enrichment.Timeout > 0, "enrichment.timeout must be positive")
+    for _, t := range c.Enrichment.Timeouts {
+        name, v, ok := strings.Cut(t, "=")
+        d, err := time.ParseDuration(strings.TrimSpace(v))
+        check(ok && strings.TrimSpace(name) != "" && err == nil && d > 0, "enrichment.timeouts: %q is not stage=duration", t)
+    }
+    for _, p := range c.Enrichment.Plugins {
+        name, addr, ok := strings.Cut(p, "=")
+        check(ok && strings.TrimSpace(name) != "" && strings.TrimSpace(addr) != "", "enrichment.plugins: %q is not name=host:port", p)
+    }
+
+    if c.Feast.URL != "" {
+        u, err := url.Parse(c.Feast.URL)
+        check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "feast.url must be an http(s) URL")
+    }
+    check(c.Feast.Entity != "", "feast.entity is required")
+    for _, e := range c.Feast.Features {
+        name, ref, ok := strings.Cut(e, "=")
+        view, feat, ok2 := strings.Cut(strings.TrimSpace(ref), ":")
+        check(ok && ok2 && oneOf(strings.ToLower(strings.TrimSpace(name)), FeastLookups...) && view != "" && feat != "", "feast.features: %q is not lookup=view:feature", e)
+    }
+    check(c.Feast.Timeout > 0, "feast.timeout must be positive")
+    check(c.Precomputed.TTL > 0, "precomputed.ttl must be positive")
+    check(c.Precomputed.MaxBatch > 0, "precomputed.max_batch must be positive")
+
+    check(c.Flags.RefreshInterval > 0, "flags.refresh_interval must be positive")
+
+    check(c.Startup.RetryAttempts >= 0, "startup.retry_attempts must not be negative")
+    check(c.Startup.InitialBackoff > 0, "startup.initial_backoff must be positive")
+    check(c.Startup.MaxBackoff >= c.Startup.InitialBackoff, "startup.max_backoff must not be less than initial_backoff")
+
+    for _, l := range c.Faults.Latency {
+        name, v, ok := strings.Cut(l, "=")
+        d, err := time.ParseDuration(strings.TrimSpace(v))
+        check(ok && oneOf(strings.ToLower(strings.TrimSpace(name)), FaultTargets...) && err == nil && d >= 0, "faults.latency: %q is not target=duration", l)
+    }
+    for _, r := range c.Faults.ErrorRate {
+        name, v, ok := strings.Cut(r, "=")
+        p, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+        check(ok && oneOf(strings.ToLower(strings.TrimSpace(name)), FaultTargets...) && err == nil && p >= 0 && p <= 1, "faults.error_rate: %q is not target=rate between 0 and 1", r)
+    }
+    return errors.Join(errs...)
+}
+
+func oneOf(v string, allowed ...string) bool {
+    for _, a := range allowed {
+        if strings.EqualFold(v, a) { return true }
+    }
+    return false
+}
+
+// isIdentifier reports whether s can be used unquoted as a SQL name.
+func isIdentifier(s string) bool {
+    for i, r := range s {
+        if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' { continue }
+        return false
+    }
+    return s != ""
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "os/signal"
+    "reflect"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "syscall"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+var current atomic.Pointer[Config]
+
+// Get returns the active configuration. Read fields where they are used
+// rather than copying them at startup, so a reload takes effect. Before Init
+// it loads from the environment alone.
+func Get() *Config {
+    if c := current.Load(); c != nil { return c }
+    c, err := Load("")
+    if err != nil { panic(fmt.Sprintf("config: %v", err)) }
+    current.CompareAndSwap(nil, c)
+    return current.Load()
+}
+
+// Init loads path (empty for environment only), validates it and makes it
+// the configuration Get returns.
+func Init(path string) error {
+    c, err := Load(path)
+    if err != nil { return err }
+    current.Store(c)
+    return nil
+}
+
+// Load builds a Config from defaults, the YAML file at path (if any), the
+// environment and the secrets manager (see Secrets), and validates it.
+// Unknown keys in the file are an error so typos don't go unnoticed.
+func Load(path string) (*Config, error) {
+    c := &Config{}
+    fs := fields(reflect.ValueOf(c).Elem(), "")
+    for _, f := range fs {
+        if d, ok := f.tag.Lookup("default"); ok {
+            if err := f.set(d); err != nil { return nil, err }
+        }
+    }
+    if path != "" {
+        b, err := os.ReadFile(path)
+        if err != nil { return nil, err }
+        dec := yaml.NewDecoder(bytes.NewReader(b))
+        dec.KnownFields(true)
+        if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) { return nil, fmt.Errorf("%s: %w", path, err) }
+    }
+    for _, f := range fs {
+        env := f.tag.Get("env")
+        if v := os.Getenv(env); env != "" && v != "" {
+            if err := f.set(v); err != nil { return nil, fmt.Errorf("%s: %w", env, err) }
+        }
+    }
+    if err := c.loadSecrets(); err != nil { return nil, fmt.Errorf("secrets: %w", err) }
+    if err := c.Validate(); err != nil { return nil, err }
+    return c, nil
+}
+
+// Watch reloads path whenever the process gets SIGHUP, and re-reads the
+// secrets manager every secrets.refresh_interval.
+func Watch(path string) {
+    go refreshSecrets()
+    ch := make(chan os.Signal, 1)
+    signal.Notify(ch, syscall.SIGHUP)
+    go func() {
+        for range ch {
+            if err := Reload(path); err != nil { log.Printf("config reload failed, keeping the running config: %v", err) }
+        }
+    }()
+}
+
+// Reload applies the reloadable fields from path. Changes to the others
+// (connection settings, pool sizes, ...) are logged and kept at their running
+// values until a restart. Credentials count as reloadable: connections
+// opened by package conn pick new ones up through OnReload.
+func Reload(path string) error {
+    next, err := Load(path)
+    if err != nil { return err }
+    apply(next)
+    return nil
+}
+
+var (
+    hooksMu sync.Mutex
+    hooks   []func(prev, next *Config)
+)
+
+// OnReload registers f to be called after each reload that changed
+// something, with the configurations before and after.
+func OnReload(f func(prev, next *Config)) {
+    hooksMu.Lock()
+    hooks = append(hooks, f)
+    hooksMu.Unlock()
+}
+
+func apply(next *Config) {
+    cur := Get()
+    prev := fields(reflect.ValueOf(cur).Elem(), "")
+    var applied, ignored []string
+    for i, f := range fields(reflect.ValueOf(next).Elem(), "") {
+        if reflect.DeepEqual(f.v.Interface(), prev[i].v.Interface()) { continue }
+        if f.tag.Get("reload") == "true" || f.tag.Get("secret") == "true" { applied = append(applied, f.path); continue }
+        ignored = append(ignored, f.path)
+        f.v.Set(prev[i].v)
+    }
+    current.Store(next)
+    log.Printf("config reloaded: %d setting(s) changed %v", len(applied), applied)
+    if len(ignored) > 0 { log.Printf("config reload: restart required to apply %v", ignored) }
+    if len(applied) == 0 { return }
+    hooksMu.Lock()
+    fs := hooks
+    hooksMu.Unlock()
+    for _, f := range fs { f(cur, next) }
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var units = map[string]time.Duration{"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// field is one leaf setting; path is its dotted YAML key.
+type field struct {
+    path string
+    v    reflect.Value
+    tag  reflect.StructTag
+}
+
+func fields(v reflect.Value, prefix string) []field {
+    var out []field
+    t := v.Type()
+    for i := 0; i < t.NumField(); i++ {
+        sf := t.Field(i)
+        path := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+        if prefix != "" { path = prefix + "." + path }
+        if sf.Type.Kind() == reflect.Struct {
+            out = append(out, fields(v.Field(i), path)...)
+            continue
+        }
+        out = append(out, field{path: path, v: v.Field(i), tag: sf.Tag})
+    }
+    return out
+}
+
+// set parses s as written in a default tag or environment variable:
+// durations are numbers in the field's unit (Go duration strings also work)
+// and lists are comma-separated.
+func (f field) set(s string) error {
+    switch {
+    case f.v.Type() == durationType:
+        if n, err := strconv.ParseFloat(s, 64); err == nil {
+            f.v.SetInt(int64(n * float64(units[f.tag.Get("unit")])))
+            return nil
+        }
+        d, err := time.ParseDuration(s)
+        if err != nil { return err }
+        f.v.SetInt(int64(d))
+    case f.v.Kind() == reflect.String:
+        f.v.SetString(s)
+    case f.v.Kind() == reflect.Int:
+        n, err := strconv.Atoi(s)
+        if err != nil { return err }
+        f.v.SetInt(int64(n))
+    case f.v.Kind() == reflect.Float64:
+        n, err := strconv.ParseFloat(s, 64)
+        if err != nil { return err }
+        f.v.SetFloat(n)
+    case f.v.Kind() == reflect.Bool:
+        b, err := strconv.ParseBool(s)
+        if err != nil { return err }
+        f.v.SetBool(b)
+    case f.v.Kind() == reflect.Slice && f.v.Type().Elem().Kind() == reflect.String:
+        parts := strings.Split(s, ",")
+        for i := range parts { parts[i] = strings.TrimSpace(parts[i]) }
+        f.v.Set(reflect.ValueOf(parts))
+    default:
+        return fmt.Errorf("%s: unsupported type %s", f.path, f.v.Type())
+    }
+    return nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+    "context"
+    "log"
+    "reflect"
+    "time"
+
+    "example.com/fraud/internal/secrets"
+    "example.com/fraud/internal/sigv4"
+)
+
+// secretSource is where secrets.provider says to read secrets from, or nil
+// when it is off or incomplete; Validate reports the latter.
+func (c *Config) secretSource() secrets.Source {
+    s := c.Secrets
+    switch {
+    case s.Provider == "vault" && s.VaultAddr != "" && s.VaultPath != "":
+        return secrets.Vault{Addr: s.VaultAddr, Token: s.VaultToken, TokenFile: s.VaultTokenFile, Path: s.VaultPath}
+    case s.Provider == "aws" && s.AWSSecretID != "" && c.Archive.Region != "":
+        a := c.Archive
+        return secrets.AWS{Region: a.Region, SecretID: s.AWSSecretID, Credentials: sigv4.Credentials{AccessKeyID: a.AccessKeyID, SecretAccessKey: a.SecretAccessKey, SessionToken: a.SessionToken}}
+    }
+    return nil
+}
+
+// loadSecrets sets c's secret fields from the secrets manager, each from
+// the key named after its environment variable. Fields the secret doesn't
+// have keep their value.
+func (c *Config) loadSecrets() error {
+    src := c.secretSource()
+    if src == nil { return nil }
+    ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+    defer cancel()
+    vals, err := src.Fetch(ctx)
+    if err != nil { return err }
+    for _, f := range fields(reflect.ValueOf(c).Elem(), "") {
+        if f.tag.Get("secret") != "true" { continue }
+        if v, ok := vals[f.tag.Get("env")]; ok {
+            if err := f.set(v); err != nil { return err }
+        }
+    }
+    return nil
+}
+
+// refreshSecrets reads the secrets again every secrets.refresh_interval and
+// applies them when any has changed, so rotated credentials are used
+// without a restart or a SIGHUP. A failed read keeps the current ones.
+func refreshSecrets() {
+    for {
+        interval := Get().Secrets.RefreshInterval
+        if interval <= 0 { interval = time.Minute }
+        time.Sleep(interval)
+        cur := Get()
+        if cur.Secrets.Provider == "" || cur.Secrets.RefreshInterval <= 0 { continue }
+        next := *cur
+        if err := next.loadSecrets(); err != nil { log.Printf("secrets refresh failed, keeping the current credentials: %v", err); continue }
+        if reflect.DeepEqual(&next, cur) { continue }
+        if err := next.Validate(); err != nil { log.Printf("secrets refresh: %v", err); continue }
+        apply(&next)
+    }
+}
@@ -0,0 +1,104 @@
+package conn
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "math/rand"
+    "net"
+    "sync"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/jackc/pgx/v5"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+    "github.com/segmentio/kafka-go"
+    "github.com/segmentio/kafka-go/protocol"
+
+    "example.com/fraud/internal/config"
+)
+
+// ErrInjected is the error a call fails with when faults.error_rate picks
+// it.
+var ErrInjected = errors.New("injected fault")
+
+var (
+    injectedFaults = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name: "fraud_injected_faults_total",
+        Help: "Latency and errors injected into calls by faults.enabled, by target and kind.",
+    }, []string{"target", "kind"})
+    faultsWarning sync.Once
+)
+
+// Fault applies the faults configured for target (postgres, redis, kafka or
+// grpc) to one call: it waits faults.latency, cut short if ctx ends, then
+// fails with ErrInjected at faults.error_rate. With faults disabled it
+// returns nil at once.
+func Fault(ctx context.Context, target string) error {
+    f := config.Get().Faults
+    if !f.Enabled { return nil }
+    faultsWarning.Do(func() { log.Printf("faults.enabled is set: injecting latency and errors into calls; never run this in production") })
+    latency, rate := f.For(target)
+    if latency > 0 {
+        injectedFaults.WithLabelValues(target, "latency").Inc()
+        t := time.NewTimer(latency)
+        select {
+        case <-t.C:
+        case <-ctx.Done():
+            t.Stop()
+            return ctx.Err()
+        }
+    }
+    if rate > 0 && rand.Float64() < rate {
+        injectedFaults.WithLabelValues(target, "error").Inc()
+        return fmt.Errorf("%s: %w", target, ErrInjected)
+    }
+    return nil
+}
+
+// faultTracer injects postgres faults before each query. A tracer can't
+// fail a query outright, so an injected error cancels the query's context:
+// the caller sees context.Canceled, with ErrInjected as the cause.
+type faultTracer struct{}
+
+func (faultTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+    if err := Fault(ctx, "postgres"); err != nil {
+        cctx, cancel := context.WithCancelCause(ctx)
+        cancel(err)
+        return cctx
+    }
+    return ctx
+}
+
+func (faultTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+// faultHook injects redis faults before each command and pipeline.
+type faultHook struct{}
+
+func (faultHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+    return ctx, Fault(ctx, "redis")
+}
+
+func (faultHook) AfterProcess(context.Context, redis.Cmder) error { return nil }
+
+func (faultHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+    return ctx, Fault(ctx, "redis")
+}
+
+func (faultHook) AfterProcessPipeline(context.Context, []redis.Cmder) error { return nil }
+
+// KafkaTransport is the transport for Kafka writers and clients:
+// kafka.DefaultTransport, or one with kafka.tls and kafka.sasl_mechanism
+// applied, with kafka faults injected before each request to a broker.
+func KafkaTransport() kafka.RoundTripper {
+    return faultTransport{kafkaSecurity().transport}
+}
+
+type faultTransport struct{ kafka.RoundTripper }
+
+func (t faultTransport) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+    if err := Fault(ctx, "kafka"); err != nil { return nil, err }
+    return t.RoundTripper.RoundTrip(ctx, addr, req)
+}
@@ -0,0 +1,128 @@
+package conn
+
+import (
+    "context"
+    "crypto/tls"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+    "github.com/segmentio/kafka-go/sasl"
+    "github.com/segmentio/kafka-go/sasl/plain"
+    "github.com/segmentio/kafka-go/sasl/scram"
+
+    "example.com/fraud/internal/config"
+)
+
+// NewKafkaWriter returns a synchronous writer for topic. Messages are keyed
+// by user_id and the hash balancer keeps each user's events on one
+// partition, so consumers see them in order.
+func NewKafkaWriter(brokers []string, topic string) *kafka.Writer {
+    return &kafka.Writer{Addr: kafka.TCP(brokers...), Topic: topic, Balancer: &kafka.Hash{}, Transport: KafkaTransport()}
+}
+
+// Header returns the value of the message header key, or "" if unset.
+func Header(m kafka.Message, key string) string {
+    for _, h := range m.Headers {
+        if h.Key == key { return string(h.Value) }
+    }
+    return ""
+}
+
+// PingKafka succeeds once any of brokers accepts a connection, TLS
+// handshake and SASL authentication included.
+func PingKafka(ctx context.Context, brokers []string) error {
+    if err := kafkaSecurity().err; err != nil { return err }
+    dctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+    defer cancel()
+    var err error
+    for _, b := range brokers {
+        var c *kafka.Conn
+        if c, err = KafkaDialer().DialContext(dctx, "tcp", b); err == nil { return c.Close() }
+    }
+    return err
+}
+
+// KafkaDialer is the dialer for Kafka readers: kafka.DefaultDialer with
+// kafka.tls and kafka.sasl_mechanism applied.
+func KafkaDialer() *kafka.Dialer {
+    sec := kafkaSecurity()
+    return &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, TLS: sec.tls, SASLMechanism: sec.mechanism}
+}
+
+// KafkaClient returns an admin client for brokers that gives up on a
+// request after timeout.
+func KafkaClient(brokers []string, timeout time.Duration) *kafka.Client {
+    return &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: timeout, Transport: KafkaTransport()}
+}
+
+type kafkaSec struct {
+    tls       *tls.Config
+    mechanism sasl.Mechanism
+    // transport is shared by writers and clients so they share connections,
+    // as they would kafka.DefaultTransport.
+    transport kafka.RoundTripper
+    err       error
+}
+
+// kafkaSecurity is the TLS config and SASL mechanism every Kafka connection
+// uses, built once. If they can't be built, say for a missing CA file,
+// err says why and every connection fails with it rather than falling back
+// to plaintext.
+var kafkaSecurity = sync.OnceValue(func() kafkaSec {
+    sec, err := loadKafkaSecurity(config.Get().Kafka)
+    if err != nil {
+        err = fmt.Errorf("kafka security: %w", err)
+        sec = kafkaSec{tls: &tls.Config{InsecureSkipVerify: true, VerifyConnection: func(tls.ConnectionState) error { return err }}, mechanism: failedMechanism{err}, err: err}
+    }
+    sec.transport = kafka.DefaultTransport
+    if sec.tls != nil || sec.mechanism != nil { sec.transport = &kafka.Transport{TLS: sec.tls, SASL: sec.mechanism} }
+    return sec
+})
+
+func loadKafkaSecurity(k config.Kafka) (kafkaSec, error) {
+    var sec kafkaSec
+    var err error
+    if k.TLS {
+        if sec.tls, err = clientTLS(k.TLSCAFile, k.TLSCertFile, k.TLSKeyFile); err != nil { return sec, err }
+    }
+    if k.SASLMechanism == "" { return sec, nil }
+    m, err := saslMechanism(k)
+    if err != nil { return sec, err }
+    sec.mechanism = currentMechanism{m.Name()}
+    return sec, nil
+}
+
+func saslMechanism(k config.Kafka) (sasl.Mechanism, error) {
+    switch k.SASLMechanism {
+    case "plain":
+        return plain.Mechanism{Username: k.SASLUsername, Password: k.SASLPassword}, nil
+    case "scram-sha-256":
+        return scram.Mechanism(scram.SHA256, k.SASLUsername, k.SASLPassword)
+    case "scram-sha-512":
+        return scram.Mechanism(scram.SHA512, k.SASLUsername, k.SASLPassword)
+    }
+    return nil, fmt.Errorf("unknown SASL mechanism %q", k.SASLMechanism)
+}
+
+// currentMechanism authenticates each new connection with the current
+// kafka.sasl_username and sasl_password, so rotated credentials are used
+// without a restart.
+type currentMechanism struct{ name string }
+
+func (m currentMechanism) Name() string { return m.name }
+
+func (m currentMechanism) Start(ctx context.Context) (sasl.StateMachine, []byte, error) {
+    mech, err := saslMechanism(config.Get().Kafka)
+    if err != nil { return nil, nil, err }
+    return mech.Start(ctx)
+}
+
+// failedMechanism fails authentication with the error that kept the real
+// mechanism from being built.
+type failedMechanism struct{ err error }
+
+func (failedMechanism) Name() string { return "PLAIN" }
+
+func (m failedMechanism) Start(context.Context) (sasl.StateMachine, []byte, error) { return nil, nil, m.err }
@@ -0,0 +1,93 @@
+// Package conn opens the connections both services need: Postgres pools,
+// the Redis client and Kafka writers. With faults.enabled, calls through
+// them are slowed down and failed on purpose (see Fault).
+package conn
+
+import (
+    "context"
+    "log"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "github.com/prometheus/client_golang/prometheus"
+
+    "example.com/fraud/internal/config"
+)
+
+// NewPgPool opens a pgx pool to host sized and recycled per the postgres
+// settings (max_conns, min_conns, max_conn_lifetime, max_conn_idle_time,
+// health_check_period). The pool's stats are registered as metrics under
+// metricsPrefix. New connections log in with the current postgres.user and
+// password, and when a reload changes them the pool's connections are
+// replaced.
+func NewPgPool(ctx context.Context, host, metricsPrefix string) (*pgxpool.Pool, error) {
+    pc := config.Get().Postgres
+    cfg, err := pgxpool.ParseConfig(pc.DSN(host))
+    if err != nil { return nil, err }
+    cfg.MaxConns = int32(pc.MaxConns)
+    cfg.MinConns = int32(pc.MinConns)
+    cfg.MaxConnLifetime = pc.MaxConnLifetime
+    cfg.MaxConnIdleTime = pc.MaxConnIdleTime
+    cfg.HealthCheckPeriod = pc.HealthCheckPeriod
+    cfg.ConnConfig.Tracer = faultTracer{}
+    cfg.BeforeConnect = func(_ context.Context, cc *pgx.ConnConfig) error {
+        pc := config.Get().Postgres
+        cc.User, cc.Password = pc.User, pc.Password
+        return nil
+    }
+    p, err := pgxpool.NewWithConfig(ctx, cfg)
+    if err != nil { return nil, err }
+    if err := p.Ping(ctx); err != nil { p.Close(); return nil, err }
+    config.OnReload(func(prev, next *config.Config) {
+        if prev.Postgres.User == next.Postgres.User && prev.Postgres.Password == next.Postgres.Password { return }
+        log.Printf("postgres credentials changed, reconnecting %s", metricsPrefix)
+        p.Reset()
+    })
+    prometheus.MustRegister(newPoolCollector(metricsPrefix, p))
+    return p, nil
+}
+
+// QueryCtx derives a per-query context from parent, capped at
+// postgres.query_timeout so a slow Postgres releases the caller and its pool
+// connection instead of pinning both. Cancelling parent (e.g. the client
+// going away) cancels the query too.
+func QueryCtx(parent context.Context) (context.Context, context.CancelFunc) {
+    return context.WithTimeout(parent, config.Get().Postgres.QueryTimeout)
+}
+
+// poolCollector exports pgxpool.Stat on every scrape.
+type poolCollector struct {
+    pool *pgxpool.Pool
+
+    acquired, idle, total, max               *prometheus.Desc
+    acquires, emptyAcquires, acquireDuration *prometheus.Desc
+}
+
+func newPoolCollector(prefix string, p *pgxpool.Pool) *poolCollector {
+    d := func(name, help string) *prometheus.Desc { return prometheus.NewDesc(prefix+"_"+name, help, nil, nil) }
+    return &poolCollector{
+        pool:            p,
+        acquired:        d("acquired_conns", "Connections currently checked out."),
+        idle:            d("idle_conns", "Idle connections in the pool."),
+        total:           d("total_conns", "Open connections, including ones being established."),
+        max:             d("max_conns", "Configured pool size."),
+        acquires:        d("acquires_total", "Successful connection acquisitions."),
+        emptyAcquires:   d("empty_acquires_total", "Acquisitions that had to wait because no connection was idle."),
+        acquireDuration: d("acquire_seconds_total", "Total time spent waiting to acquire connections."),
+    }
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+    for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.max, c.acquires, c.emptyAcquires, c.acquireDuration} { ch <- d }
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+    s := c.pool.Stat()
+    ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+    ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+    ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns()))
+    ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns()))
+    ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+    ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+    ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
@@ -0,0 +1,54 @@
+package conn
+
+import (
+    "context"
+    "fmt"
+    "os"
+
+    "github.com/go-redis/redis/v8"
+
+    "example.com/fraud/internal/config"
+)
+
+// NewRedis connects to the configured Redis, authenticating and using TLS
+// and the database index as set, and fails if it doesn't answer a PING.
+// Connections are named redis.client_name, or service and the host name,
+// so CLIENT LIST shows where each comes from. Each new connection
+// authenticates with the current redis.username and password, so rotated
+// credentials are used without a restart; open connections stay
+// authenticated as they were.
+func NewRedis(ctx context.Context, service string) (*redis.Client, error) {
+    c := config.Get().Redis
+    name := c.ClientName
+    if name == "" {
+        host, _ := os.Hostname()
+        name = service + "@" + host
+    }
+    // AUTH and SELECT are sent here rather than through Options, which
+    // would fix the credentials for the client's lifetime.
+    opts := &redis.Options{
+        Addr: c.Addr(),
+        OnConnect: func(ctx context.Context, cn *redis.Conn) error {
+            c := config.Get().Redis
+            _, err := cn.Pipelined(ctx, func(p redis.Pipeliner) error {
+                if c.Username != "" {
+                    p.AuthACL(ctx, c.Username, c.Password)
+                } else if c.Password != "" {
+                    p.Auth(ctx, c.Password)
+                }
+                if c.DB > 0 { p.Select(ctx, c.DB) }
+                p.ClientSetName(ctx, name)
+                return nil
+            })
+            return err
+        },
+    }
+    if c.TLS {
+        var err error
+        if opts.TLSConfig, err = clientTLS(c.TLSCAFile, c.TLSCertFile, c.TLSKeyFile); err != nil { return nil, fmt.Errorf("redis tls: %w", err) }
+    }
+    rdb := redis.NewClient(opts)
+    rdb.AddHook(faultHook{})
+    if err := rdb.Ping(ctx).Err(); err != nil { rdb.Close(); return nil, err }
+    return rdb, nil
+}
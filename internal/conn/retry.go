@@ -0,0 +1,38 @@
+package conn
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math/rand"
+    "time"
+
+    "example.com/fraud/internal/config"
+)
+
+// Retry calls fn until it succeeds, waiting startup.initial_backoff after
+// the first failure and doubling the wait up to startup.max_backoff. It
+// gives up after attempts calls (0: never) with fn's last error, or returns
+// ctx's error once ctx is done. what names the dependency in the log.
+func Retry(ctx context.Context, what string, attempts int, fn func() error) error {
+    sc := config.Get().Startup
+    backoff := sc.InitialBackoff
+    for i := 1; ; i++ {
+        err := fn()
+        if err == nil {
+            if i > 1 { log.Printf("%s: connected after %d attempts", what, i) }
+            return nil
+        }
+        if attempts > 0 && i >= attempts { return fmt.Errorf("%s: giving up after %d attempts: %w", what, i, err) }
+        // Jitter keeps replicas that started together from retrying in
+        // lockstep.
+        wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+        log.Printf("%s unavailable (attempt %d): %v; retrying in %s", what, i, err, wait.Round(time.Millisecond))
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case <-time.After(wait):
+        }
+        if backoff *= 2; backoff > sc.MaxBackoff { backoff = sc.MaxBackoff }
+    }
+}
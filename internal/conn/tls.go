@@ -0,0 +1,176 @@
+package conn
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "log"
+    "net"
+    "os"
+    "sync"
+    "time"
+
+    "example.com/fraud/internal/config"
+)
+
+// ErrIdentity is what a connection to the ML service fails with when the
+// service's certificate doesn't prove it is the configured endpoint.
+var ErrIdentity = errors.New("ML service identity not verified")
+
+// MLTLS is the client TLS config for the ML gRPC service per ml_tls, or nil
+// when ml_tls.mode is insecure. The CA bundle and client certificate are
+// re-read whenever their files change, checked every
+// ml_tls.reload_interval, so rotated certificates are picked up by new
+// connections without a restart.
+func MLTLS() (*tls.Config, error) {
+    c := config.Get().MLTLS
+    if c.Mode == "insecure" { return nil, nil }
+    w := &certWatcher{cfg: c}
+    if err := w.load(); err != nil { return nil, err }
+    go w.watch()
+    return &tls.Config{
+        MinVersion: tls.VersionTLS12,
+        ServerName: c.ServerName,
+        // Verification is done by verify, against the current roots, since
+        // RootCAs can't be swapped on rotation.
+        InsecureSkipVerify:   true,
+        VerifyConnection:     w.verify,
+        GetClientCertificate: w.clientCert,
+    }, nil
+}
+
+// CheckMLIdentity connects to the ML service at addr and completes a TLS
+// handshake with cfg, so a misconfigured identity is caught at startup
+// rather than on the first scored transaction. Identity failures wrap
+// ErrIdentity; any other error means the service couldn't be reached.
+func CheckMLIdentity(ctx context.Context, addr string, cfg *tls.Config) error {
+    cfg = cfg.Clone()
+    cfg.NextProtos = []string{"h2"}
+    if cfg.ServerName == "" {
+        host, _, err := net.SplitHostPort(addr)
+        if err != nil { return err }
+        cfg.ServerName = host
+    }
+    cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+    defer cancel()
+    c, err := (&tls.Dialer{Config: cfg}).DialContext(cctx, "tcp", addr)
+    if err != nil { return err }
+    return c.Close()
+}
+
+// clientTLS is a client TLS config that checks servers against the
+// certificates in caFile, or the system roots when it is empty, and
+// presents certFile and keyFile when they are set.
+func clientTLS(caFile, certFile, keyFile string) (*tls.Config, error) {
+    cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+    if caFile != "" {
+        pem, err := os.ReadFile(caFile)
+        if err != nil { return nil, err }
+        cfg.RootCAs = x509.NewCertPool()
+        if !cfg.RootCAs.AppendCertsFromPEM(pem) { return nil, fmt.Errorf("no certificates in %s", caFile) }
+    }
+    if certFile != "" {
+        cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+        if err != nil { return nil, err }
+        cfg.Certificates = []tls.Certificate{cert}
+    }
+    return cfg, nil
+}
+
+type certWatcher struct {
+    cfg config.MLTLS
+
+    mu     sync.RWMutex
+    roots  *x509.CertPool
+    cert   *tls.Certificate
+    mtimes map[string]time.Time
+}
+
+func (w *certWatcher) files() []string {
+    var out []string
+    for _, f := range []string{w.cfg.CAFile, w.cfg.CertFile, w.cfg.KeyFile} {
+        if f != "" { out = append(out, f) }
+    }
+    return out
+}
+
+func (w *certWatcher) load() error {
+    mtimes := map[string]time.Time{}
+    for _, f := range w.files() {
+        st, err := os.Stat(f)
+        if err != nil { return fmt.Errorf("ml_tls: %w", err) }
+        mtimes[f] = st.ModTime()
+    }
+    roots, err := x509.SystemCertPool()
+    if err != nil { return fmt.Errorf("ml_tls: system roots: %w", err) }
+    if w.cfg.CAFile != "" {
+        pem, err := os.ReadFile(w.cfg.CAFile)
+        if err != nil { return fmt.Errorf("ml_tls: %w", err) }
+        roots = x509.NewCertPool()
+        if !roots.AppendCertsFromPEM(pem) { return fmt.Errorf("ml_tls: no certificates in %s", w.cfg.CAFile) }
+    }
+    var cert *tls.Certificate
+    if w.cfg.CertFile != "" {
+        c, err := tls.LoadX509KeyPair(w.cfg.CertFile, w.cfg.KeyFile)
+        if err != nil { return fmt.Errorf("ml_tls: client certificate: %w", err) }
+        cert = &c
+    }
+    w.mu.Lock()
+    w.roots, w.cert, w.mtimes = roots, cert, mtimes
+    w.mu.Unlock()
+    return nil
+}
+
+// watch reloads the files when any of their modification times changes. A
+// reload that fails, say halfway through a rotation, keeps the previous
+// certificates and is retried every interval until it succeeds.
+func (w *certWatcher) watch() {
+    for {
+        time.Sleep(w.cfg.ReloadInterval)
+        w.mu.RLock()
+        changed := false
+        for _, f := range w.files() {
+            if st, err := os.Stat(f); err == nil && !st.ModTime().Equal(w.mtimes[f]) { changed = true }
+        }
+        w.mu.RUnlock()
+        if !changed { continue }
+        if err := w.load(); err != nil { log.Printf("ML TLS certificates not reloaded: %v", err); continue }
+        log.Printf("ML TLS certificates reloaded")
+    }
+}
+
+func (w *certWatcher) clientCert(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+    w.mu.RLock()
+    defer w.mu.RUnlock()
+    // An empty certificate tells the server we have none.
+    if w.cert == nil { return &tls.Certificate{}, nil }
+    return w.cert, nil
+}
+
+// verify checks the server's chain against the current roots, then its
+// identity: the SPIFFE ID in spiffe mode, the host name otherwise.
+func (w *certWatcher) verify(cs tls.ConnectionState) error {
+    if len(cs.PeerCertificates) == 0 { return fmt.Errorf("%w: no certificate", ErrIdentity) }
+    w.mu.RLock()
+    roots := w.roots
+    w.mu.RUnlock()
+    opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+    for _, c := range cs.PeerCertificates[1:] { opts.Intermediates.AddCert(c) }
+    leaf := cs.PeerCertificates[0]
+    if w.cfg.Mode != "spiffe" {
+        // No SNI is sent for an IP address, so cs.ServerName is empty then.
+        opts.DNSName = w.cfg.ServerName
+        if opts.DNSName == "" { opts.DNSName = cs.ServerName }
+        if opts.DNSName == "" { return fmt.Errorf("%w: no server name to check; set ml_tls.server_name", ErrIdentity) }
+    }
+    if _, err := leaf.Verify(opts); err != nil { return fmt.Errorf("%w: %v", ErrIdentity, err) }
+    if w.cfg.Mode == "spiffe" {
+        for _, u := range leaf.URIs {
+            if u.String() == w.cfg.SPIFFEID { return nil }
+        }
+        return fmt.Errorf("%w: certificate is not for %s", ErrIdentity, w.cfg.SPIFFEID)
+    }
+    return nil
+}
@@ -0,0 +1,164 @@
+package events
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "sort"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/hamba/avro/v2"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protoreflect"
+
+    "example.com/fraud/internal/events/pb"
+)
+
+func str(s string) *string { return &s }
+
+var (
+    fullTransaction = TransactionEvent{
+        TransactionID:   "tx-1",
+        UserID:          "user-1",
+        Amount:          1234.56,
+        FraudScore:      0.87,
+        IsFraud:         true,
+        Timestamp:       1718000000,
+        DeviceID:        str("dev-1"),
+        IPAddress:       str("203.0.113.7"),
+        MerchantID:      str("m-1"),
+        MCC:             str("7995"),
+        DuplicateOf:     str("tx-0"),
+        Channel:         str("card"),
+        Country:         str("GB"),
+        CardFingerprint: str("fp-1"),
+    }
+    // bareTransaction leaves every optional field unset, which each encoding
+    // must keep distinct from an empty string.
+    bareTransaction = TransactionEvent{TransactionID: "tx-2", UserID: "user-2", Amount: 10, Timestamp: 1718000001}
+    snapshot        = UserRiskSnapshot{UserID: "user-1", RiskScore: 0.42, UpdatedAt: 1718000002}
+    alert           = AlertEvent{AlertID: "ALERT_1", TransactionID: "tx-1", UserID: "user-1", AlertType: "FRAUD_DETECTED", Severity: "HIGH", Description: "score 0.87", FraudScore: 0.87, Timestamp: 1718000003}
+)
+
+// avroRegistry is a Registry backed by a stand-in for the schema registry
+// that serves schemas by id.
+func avroRegistry(t *testing.T, schemas map[int]string) *Registry {
+    t.Helper()
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        for id, schema := range schemas {
+            if r.URL.Path == "/schemas/ids/"+strconv.Itoa(id) { json.NewEncoder(w).Encode(map[string]string{"schema": schema}); return }
+        }
+        http.NotFound(w, r)
+    }))
+    t.Cleanup(srv.Close)
+    return NewRegistry(srv.URL)
+}
+
+func avroCodecFor(t *testing.T, id int, schema string) Codec {
+    t.Helper()
+    parsed, err := avro.Parse(schema)
+    if err != nil { t.Fatal(err) }
+    return avroCodec{schema: parsed, id: id}
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+    reg := avroRegistry(t, map[int]string{1: TransactionEventSchema})
+    codecs := []Codec{jsonCodec{}, protoCodec{}, avroCodecFor(t, 1, TransactionEventSchema)}
+    for _, c := range codecs {
+        for _, want := range []TransactionEvent{fullTransaction, bareTransaction} {
+            b, err := c.Encode(want)
+            if err != nil { t.Fatalf("%s: encode: %v", c.ContentType(), err) }
+            var got TransactionEvent
+            if err := DecodeTransaction(reg, c.ContentType(), b, &got); err != nil { t.Fatalf("%s: decode: %v", c.ContentType(), err) }
+            if !reflect.DeepEqual(got, want) { t.Errorf("%s: got %+v, want %+v", c.ContentType(), got, want) }
+        }
+    }
+}
+
+func TestRiskSnapshotRoundTrip(t *testing.T) {
+    reg := avroRegistry(t, map[int]string{2: UserRiskSnapshotSchema})
+    codecs := []Codec{jsonCodec{}, protoCodec{}, avroCodecFor(t, 2, UserRiskSnapshotSchema)}
+    for _, c := range codecs {
+        b, err := c.Encode(snapshot)
+        if err != nil { t.Fatalf("%s: encode: %v", c.ContentType(), err) }
+        var got UserRiskSnapshot
+        if err := DecodeRiskSnapshot(reg, c.ContentType(), b, &got); err != nil { t.Fatalf("%s: decode: %v", c.ContentType(), err) }
+        if got != snapshot { t.Errorf("%s: got %+v, want %+v", c.ContentType(), got, snapshot) }
+    }
+}
+
+// Nothing in the services decodes alerts, so they are read back the way
+// downstream consumers would: protobuf into the generated type, Avro with
+// the schema and JSON by field name.
+func TestAlertRoundTrip(t *testing.T) {
+    b, err := protoCodec{}.Encode(alert)
+    if err != nil { t.Fatal(err) }
+    var msg pb.AlertEvent
+    if err := proto.Unmarshal(b, &msg); err != nil { t.Fatal(err) }
+    if !proto.Equal(&msg, alert.toProto()) { t.Errorf("protobuf: got %v, want %v", &msg, alert.toProto()) }
+
+    b, err = avroCodecFor(t, 3, AlertEventSchema).Encode(alert)
+    if err != nil { t.Fatal(err) }
+    id, payload, ok := wireDecode(b)
+    if !ok || id != 3 { t.Fatalf("avro: wire header %v, id %d", ok, id) }
+    var fromAvro AlertEvent
+    if err := avro.Unmarshal(avro.MustParse(AlertEventSchema), payload, &fromAvro); err != nil { t.Fatal(err) }
+    if fromAvro != alert { t.Errorf("avro: got %+v, want %+v", fromAvro, alert) }
+
+    b, err = jsonCodec{}.Encode(alert)
+    if err != nil { t.Fatal(err) }
+    var fromJSON AlertEvent
+    if err := json.Unmarshal(b, &fromJSON); err != nil { t.Fatal(err) }
+    if fromJSON != alert { t.Errorf("json: got %+v, want %+v", fromJSON, alert) }
+}
+
+// Messages produced before the content-type header existed are told apart
+// by the Avro magic byte.
+func TestDecodeWithoutContentType(t *testing.T) {
+    reg := avroRegistry(t, map[int]string{1: TransactionEventSchema})
+    for _, c := range []Codec{jsonCodec{}, avroCodecFor(t, 1, TransactionEventSchema)} {
+        b, err := c.Encode(fullTransaction)
+        if err != nil { t.Fatal(err) }
+        var got TransactionEvent
+        if err := DecodeTransaction(reg, "", b, &got); err != nil { t.Fatalf("%s: %v", c.ContentType(), err) }
+        if !reflect.DeepEqual(got, fullTransaction) { t.Errorf("%s: got %+v, want %+v", c.ContentType(), got, fullTransaction) }
+    }
+}
+
+// The Avro schemas and JSON tags follow protos/events.proto field for field,
+// so a field added to one encoding and not the others fails here.
+func TestEncodingsShareFieldNames(t *testing.T) {
+    cases := []struct {
+        msg    protoreflect.MessageDescriptor
+        schema string
+        event  interface{}
+    }{
+        {(&pb.TransactionEvent{}).ProtoReflect().Descriptor(), TransactionEventSchema, TransactionEvent{}},
+        {(&pb.AlertEvent{}).ProtoReflect().Descriptor(), AlertEventSchema, AlertEvent{}},
+        {(&pb.UserRiskSnapshot{}).ProtoReflect().Descriptor(), UserRiskSnapshotSchema, UserRiskSnapshot{}},
+    }
+    for _, c := range cases {
+        var fromProto []string
+        fields := c.msg.Fields()
+        for i := 0; i < fields.Len(); i++ { fromProto = append(fromProto, string(fields.Get(i).Name())) }
+
+        var fromAvro []string
+        for _, f := range avro.MustParse(c.schema).(*avro.RecordSchema).Fields() { fromAvro = append(fromAvro, f.Name()) }
+
+        var fromJSON []string
+        typ := reflect.TypeOf(c.event)
+        for i := 0; i < typ.NumField(); i++ {
+            name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+            fromJSON = append(fromJSON, name)
+        }
+
+        sort.Strings(fromProto)
+        sort.Strings(fromAvro)
+        sort.Strings(fromJSON)
+        if !reflect.DeepEqual(fromAvro, fromProto) { t.Errorf("%s: avro fields %v, proto fields %v", c.msg.Name(), fromAvro, fromProto) }
+        if !reflect.DeepEqual(fromJSON, fromProto) { t.Errorf("%s: json fields %v, proto fields %v", c.msg.Name(), fromJSON, fromProto) }
+    }
+}
@@ -0,0 +1,180 @@
+// Package events defines the Kafka event payloads shared by go_api
+// (producer) and go_processor (consumer), and their encodings. Field names
+// follow protos/events.proto.
+package events
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+
+    "google.golang.org/protobuf/proto"
+
+    "example.com/fraud/internal/events/pb"
+)
+
+// TransactionEvent is published to fraud-transactions (and
+// fraud-transactions-priority) after a transaction is scored.
+type TransactionEvent struct {
+    TransactionID string  `json:"transaction_id" avro:"transaction_id"`
+    UserID        string  `json:"user_id" avro:"user_id"`
+    Amount        float64 `json:"amount" avro:"amount"`
+    FraudScore    float64 `json:"fraud_score" avro:"fraud_score"`
+    IsFraud       bool    `json:"is_fraud" avro:"is_fraud"`
+    Timestamp     int64   `json:"timestamp" avro:"timestamp"`
+    DeviceID      *string `json:"device_id,omitempty" avro:"device_id"`
+    IPAddress     *string `json:"ip_address,omitempty" avro:"ip_address"`
+    MerchantID    *string `json:"merchant_id,omitempty" avro:"merchant_id"`
+    MCC           *string `json:"mcc,omitempty" avro:"mcc"`
+    DuplicateOf   *string `json:"duplicate_of,omitempty" avro:"duplicate_of"`
+    Channel       *string `json:"channel,omitempty" avro:"channel"`
+    Country       *string `json:"country,omitempty" avro:"country"`
+    // CardFingerprint is an opaque token for the card, never its number.
+    CardFingerprint *string `json:"card_fingerprint,omitempty" avro:"card_fingerprint"`
+}
+
+// New fields must be optional (nullable with a default) so the registry's
+// BACKWARD compatibility check keeps passing.
+const TransactionEventSchema = `{
+  "type": "record",
+  "name": "TransactionEvent",
+  "namespace": "fraud_detection",
+  "fields": [
+    {"name": "transaction_id", "type": "string"},
+    {"name": "user_id", "type": "string"},
+    {"name": "amount", "type": "double"},
+    {"name": "fraud_score", "type": "double"},
+    {"name": "is_fraud", "type": "boolean"},
+    {"name": "timestamp", "type": "long"},
+    {"name": "device_id", "type": ["null", "string"], "default": null},
+    {"name": "ip_address", "type": ["null", "string"], "default": null},
+    {"name": "merchant_id", "type": ["null", "string"], "default": null},
+    {"name": "mcc", "type": ["null", "string"], "default": null},
+    {"name": "duplicate_of", "type": ["null", "string"], "default": null},
+    {"name": "channel", "type": ["null", "string"], "default": null},
+    {"name": "country", "type": ["null", "string"], "default": null},
+    {"name": "card_fingerprint", "type": ["null", "string"], "default": null}
+  ]
+}`
+
+func (e TransactionEvent) toProto() proto.Message {
+    return &pb.TransactionEvent{
+        TransactionId:   e.TransactionID,
+        UserId:          e.UserID,
+        Amount:          e.Amount,
+        FraudScore:      e.FraudScore,
+        IsFraud:         e.IsFraud,
+        Timestamp:       e.Timestamp,
+        DeviceId:        e.DeviceID,
+        IpAddress:       e.IPAddress,
+        MerchantId:      e.MerchantID,
+        Mcc:             e.MCC,
+        DuplicateOf:     e.DuplicateOf,
+        Channel:         e.Channel,
+        Country:         e.Country,
+        CardFingerprint: e.CardFingerprint,
+    }
+}
+
+func (e *TransactionEvent) fromProto(b []byte) error {
+    var ev pb.TransactionEvent
+    if err := proto.Unmarshal(b, &ev); err != nil { return err }
+    *e = TransactionEvent{
+        TransactionID:   ev.GetTransactionId(),
+        UserID:          ev.GetUserId(),
+        Amount:          ev.GetAmount(),
+        FraudScore:      ev.GetFraudScore(),
+        IsFraud:         ev.GetIsFraud(),
+        Timestamp:       ev.GetTimestamp(),
+        DeviceID:        ev.DeviceId,
+        IPAddress:       ev.IpAddress,
+        MerchantID:      ev.MerchantId,
+        MCC:             ev.Mcc,
+        DuplicateOf:     ev.DuplicateOf,
+        Channel:         ev.Channel,
+        Country:         ev.Country,
+        CardFingerprint: ev.CardFingerprint,
+    }
+    return nil
+}
+
+// NewAlertID returns a random ID for an alert on a transaction. Nothing about
+// the transaction, alert type or time goes into it, so alerts raised on one
+// transaction, or on transactions with similar IDs, in the same second
+// can't share one.
+func NewAlertID() string {
+    b := make([]byte, 16)
+    rand.Read(b)
+    return "ALERT_" + hex.EncodeToString(b)
+}
+
+// AlertEvent is published to fraud-alerts when the processor raises an alert.
+type AlertEvent struct {
+    AlertID       string  `json:"alert_id" avro:"alert_id"`
+    TransactionID string  `json:"transaction_id" avro:"transaction_id"`
+    UserID        string  `json:"user_id" avro:"user_id"`
+    AlertType     string  `json:"alert_type" avro:"alert_type"`
+    Severity      string  `json:"severity" avro:"severity"`
+    Description   string  `json:"description" avro:"description"`
+    FraudScore    float64 `json:"fraud_score" avro:"fraud_score"`
+    Timestamp     int64   `json:"timestamp" avro:"timestamp"`
+}
+
+const AlertEventSchema = `{
+  "type": "record",
+  "name": "AlertEvent",
+  "namespace": "fraud_detection",
+  "fields": [
+    {"name": "alert_id", "type": "string"},
+    {"name": "transaction_id", "type": "string"},
+    {"name": "user_id", "type": "string"},
+    {"name": "alert_type", "type": "string"},
+    {"name": "severity", "type": "string"},
+    {"name": "description", "type": "string"},
+    {"name": "fraud_score", "type": "double"},
+    {"name": "timestamp", "type": "long"}
+  ]
+}`
+
+func (e AlertEvent) toProto() proto.Message {
+    return &pb.AlertEvent{
+        AlertId:       e.AlertID,
+        TransactionId: e.TransactionID,
+        UserId:        e.UserID,
+        AlertType:     e.AlertType,
+        Severity:      e.Severity,
+        Description:   e.Description,
+        FraudScore:    e.FraudScore,
+        Timestamp:     e.Timestamp,
+    }
+}
+
+// UserRiskSnapshot is the latest risk score for a user. The user-risk-state
+// topic is log-compacted and keyed by user_id, so it always holds at least
+// the newest snapshot per user.
+type UserRiskSnapshot struct {
+    UserID    string  `json:"user_id" avro:"user_id"`
+    RiskScore float64 `json:"risk_score" avro:"risk_score"`
+    UpdatedAt int64   `json:"updated_at" avro:"updated_at"`
+}
+
+const UserRiskSnapshotSchema = `{
+  "type": "record",
+  "name": "UserRiskSnapshot",
+  "namespace": "fraud_detection",
+  "fields": [
+    {"name": "user_id", "type": "string"},
+    {"name": "risk_score", "type": "double"},
+    {"name": "updated_at", "type": "long"}
+  ]
+}`
+
+func (e UserRiskSnapshot) toProto() proto.Message {
+    return &pb.UserRiskSnapshot{UserId: e.UserID, RiskScore: e.RiskScore, UpdatedAt: e.UpdatedAt}
+}
+
+func (e *UserRiskSnapshot) fromProto(b []byte) error {
+    var ev pb.UserRiskSnapshot
+    if err := proto.Unmarshal(b, &ev); err != nil { return err }
+    *e = UserRiskSnapshot{UserID: ev.GetUserId(), RiskScore: ev.GetRiskScore(), UpdatedAt: ev.GetUpdatedAt()}
+    return nil
+}
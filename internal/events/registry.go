@@ -0,0 +1,90 @@
+package events
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/hamba/avro/v2"
+)
+
+// Registry is a minimal Confluent Schema Registry client covering the calls
+// the services need: compatibility checks, registration and lookup by id.
+type Registry struct {
+    url    string
+    client *http.Client
+
+    mu      sync.Mutex
+    schemas map[int]avro.Schema
+}
+
+func NewRegistry(url string) *Registry {
+    return &Registry{url: url, client: &http.Client{Timeout: 5 * time.Second}, schemas: map[int]avro.Schema{}}
+}
+
+func (s *Registry) do(method, path string, body interface{}, out interface{}) (int, error) {
+    var buf bytes.Buffer
+    if body != nil {
+        if err := json.NewEncoder(&buf).Encode(body); err != nil { return 0, err }
+    }
+    req, err := http.NewRequest(method, s.url+path, &buf)
+    if err != nil { return 0, err }
+    req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+    resp, err := s.client.Do(req)
+    if err != nil { return 0, err }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 { return resp.StatusCode, fmt.Errorf("schema registry %s %s: %s", method, path, resp.Status) }
+    if out != nil {
+        if err := json.NewDecoder(resp.Body).Decode(out); err != nil { return resp.StatusCode, err }
+    }
+    return resp.StatusCode, nil
+}
+
+// CheckCompatibility reports whether schema can be registered under subject
+// without breaking the subject's configured compatibility level. A subject
+// with no versions yet is always compatible.
+func (s *Registry) CheckCompatibility(subject, schema string) (bool, error) {
+    var out struct{ IsCompatible bool `json:"is_compatible"` }
+    status, err := s.do(http.MethodPost, "/compatibility/subjects/"+subject+"/versions/latest", map[string]string{"schema": schema}, &out)
+    if status == http.StatusNotFound { return true, nil }
+    if err != nil { return false, err }
+    return out.IsCompatible, nil
+}
+
+// Register registers schema under subject and returns its global id.
+func (s *Registry) Register(subject, schema string) (int, error) {
+    var out struct{ ID int `json:"id"` }
+    if _, err := s.do(http.MethodPost, "/subjects/"+subject+"/versions", map[string]string{"schema": schema}, &out); err != nil {
+        return 0, err
+    }
+    return out.ID, nil
+}
+
+// SchemaByID fetches (and caches) the writer schema for a registry id.
+func (s *Registry) SchemaByID(id int) (avro.Schema, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if sc, ok := s.schemas[id]; ok { return sc, nil }
+    var out struct{ Schema string `json:"schema"` }
+    if _, err := s.do(http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &out); err != nil { return nil, err }
+    sc, err := avro.Parse(out.Schema)
+    if err != nil { return nil, err }
+    s.schemas[id] = sc
+    return sc, nil
+}
+
+// Confluent wire format: magic byte 0, 4-byte big-endian schema id, payload.
+func wireEncode(id int, payload []byte) []byte {
+    b := make([]byte, 5, 5+len(payload))
+    binary.BigEndian.PutUint32(b[1:5], uint32(id))
+    return append(b, payload...)
+}
+
+func wireDecode(b []byte) (int, []byte, bool) {
+    if len(b) < 5 || b[0] != 0 { return 0, nil, false }
+    return int(binary.BigEndian.Uint32(b[1:5])), b[5:], true
+}
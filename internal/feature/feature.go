@@ -0,0 +1,115 @@
+// Package feature computes the transaction features the services score
+// with: user risk, the amount against the user's history, merchant
+// category, card velocity and the geo rules. The state they are computed
+// from is read through a Source, so the API can read Redis and Postgres
+// while an offline tool, such as a backtest, replays a snapshot of the same
+// state and gets the same numbers.
+package feature
+
+import (
+    "context"
+    "math"
+
+    "example.com/fraud/internal/mcc"
+)
+
+const (
+    // DefaultUserRisk is the risk of a user with no score yet.
+    DefaultUserRisk = 0.5
+    // DefaultAverageAmount stands in for the average of a user with no
+    // history when the amount is compared with it.
+    DefaultAverageAmount = 100.0
+    // AmountStatsMinSamples is how many transactions a user needs before
+    // their amount profile is trusted.
+    AmountStatsMinSamples = 5
+)
+
+// AmountStats are the running statistics the processor keeps per user
+// (Welford's algorithm): N transactions, their Mean amount and M2, the sum
+// of squared deviations from it.
+type AmountStats struct {
+    N, Mean, M2 float64
+}
+
+// Source reads the per-user state the features are computed from. ok is
+// false when there is none, or it couldn't be read; the feature then takes
+// its neutral value.
+type Source interface {
+    UserRisk(ctx context.Context, userID string) (risk float64, ok bool)
+    // AverageAmount is the user's average transaction amount.
+    AverageAmount(ctx context.Context, userID string) (avg float64, ok bool)
+    AmountStats(ctx context.Context, userID string) (s AmountStats, ok bool)
+    // CategoryCounts is how many of the user's transactions were in
+    // category, and how many they made in all.
+    CategoryCounts(ctx context.Context, userID, category string) (count, total int64, ok bool)
+    // CardVelocity is how many card transactions the user made in the
+    // current velocity window.
+    CardVelocity(ctx context.Context, userID string) (n int, ok bool)
+}
+
+// UserRisk is the user's running risk score, DefaultUserRisk without one.
+func UserRisk(ctx context.Context, src Source, userID string) float64 {
+    if risk, ok := src.UserRisk(ctx, userID); ok { return risk }
+    return DefaultUserRisk
+}
+
+// History compares amount with the user's past amounts: its ratio to their
+// average and how many standard deviations it lies from their mean.
+func History(ctx context.Context, src Source, userID string, amount float64) (ratio, zscore float64) {
+    avg, _ := src.AverageAmount(ctx, userID)
+    ratio = AmountRatio(amount, avg)
+    if s, ok := src.AmountStats(ctx, userID); ok { zscore = s.ZScore(amount) }
+    return ratio, zscore
+}
+
+// AmountRatio is amount over avg, or over DefaultAverageAmount when avg
+// isn't positive.
+func AmountRatio(amount, avg float64) float64 {
+    if avg <= 0 { avg = DefaultAverageAmount }
+    return amount / avg
+}
+
+// ZScore is how many standard deviations amount lies from the mean, 0 with
+// fewer than AmountStatsMinSamples transactions.
+func (s AmountStats) ZScore(amount float64) float64 {
+    if s.N < AmountStatsMinSamples { return 0 }
+    // A user who always spends the same amount has no spread at all; the
+    // floor keeps every small deviation from looking infinitely unusual.
+    sd := math.Max(math.Sqrt(s.M2/(s.N-1)), 0.05*math.Abs(s.Mean))
+    if sd == 0 { return 0 }
+    return (amount - s.Mean) / sd
+}
+
+// Category holds the merchant category features of a transaction.
+type Category struct {
+    // Name is the category of the MCC (see internal/mcc), "" if unknown.
+    Name     string
+    HighRisk bool
+    // FirstTime is set when the user has never paid in the category.
+    FirstTime bool
+    // Share is the fraction of the user's transactions in the category.
+    Share float64
+}
+
+// Categorize computes the category features of a payment with merchant
+// category code by userID; highRisk lists the categories that count as
+// high risk. Without counts the transaction isn't treated as a first.
+func Categorize(ctx context.Context, src Source, userID, code string, highRisk []string) Category {
+    if code == "" { return Category{} }
+    c := Category{Name: mcc.Category(code)}
+    for _, h := range highRisk {
+        if h == c.Name { c.HighRisk = true }
+    }
+    count, total, ok := src.CategoryCounts(ctx, userID, c.Name)
+    if !ok { return c }
+    c.FirstTime = count == 0
+    if total > 0 { c.Share = float64(count) / float64(total) }
+    return c
+}
+
+// CardVelocity is how many card transactions the user made in the current
+// window, not counting this one.
+func CardVelocity(ctx context.Context, src Source, userID string) int {
+    n, _ := src.CardVelocity(ctx, userID)
+    return n
+}
@@ -0,0 +1,74 @@
+package feature
+
+import "net/netip"
+
+// Reasons a GeoRules check blocks a transaction.
+const (
+    BlockedCountry = "blocked_country"
+    BlockedIPRange = "blocked_ip_range"
+)
+
+// GeoRules are lists of blocked and high-risk countries (ISO 3166 alpha-2,
+// upper case) and IP ranges.
+type GeoRules struct {
+    blockedCountries, riskyCountries map[string]bool
+    blockedNets, riskyNets           []netip.Prefix
+}
+
+func NewGeoRules() *GeoRules {
+    return &GeoRules{blockedCountries: map[string]bool{}, riskyCountries: map[string]bool{}}
+}
+
+// AddCountry blocks country, or with block unset makes it high risk.
+func (r *GeoRules) AddCountry(country string, block bool) {
+    if block { r.blockedCountries[country] = true } else { r.riskyCountries[country] = true }
+}
+
+// AddRange blocks the addresses in p, or with block unset makes them high
+// risk.
+func (r *GeoRules) AddRange(p netip.Prefix, block bool) {
+    if block { r.blockedNets = append(r.blockedNets, p) } else { r.riskyNets = append(r.riskyNets, p) }
+}
+
+// Geo holds the result of checking a transaction against GeoRules.
+type Geo struct {
+    // IPCountry is the country of the IP address, "" if unknown.
+    IPCountry string
+    // Block is BlockedCountry or BlockedIPRange when a block rule matched.
+    Block           string
+    HighRiskCountry bool
+    HighRiskIPRange bool
+}
+
+// Check checks the transaction's country, the IP address and its country,
+// as ipCountry resolves it, against the rules. Risk from a high-risk country
+// is waived while a travel notice covers the transaction; blocks always
+// apply. Either of country and ip may be empty.
+func (r *GeoRules) Check(country, ip string, ipCountry func(netip.Addr) string, travelNotice bool) Geo {
+    var g Geo
+    var countries []string
+    if country != "" { countries = append(countries, country) }
+    if addr, err := netip.ParseAddr(ip); err == nil {
+        addr = addr.Unmap()
+        g.IPCountry = ipCountry(addr)
+        if g.IPCountry != "" { countries = append(countries, g.IPCountry) }
+        switch {
+        case inNets(r.blockedNets, addr):
+            g.Block = BlockedIPRange
+        case inNets(r.riskyNets, addr):
+            g.HighRiskIPRange = true
+        }
+    }
+    for _, c := range countries {
+        if r.blockedCountries[c] && g.Block == "" { g.Block = BlockedCountry }
+        if r.riskyCountries[c] && !travelNotice { g.HighRiskCountry = true }
+    }
+    return g
+}
+
+func inNets(nets []netip.Prefix, ip netip.Addr) bool {
+    for _, p := range nets {
+        if p.Contains(ip) { return true }
+    }
+    return false
+}
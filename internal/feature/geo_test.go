@@ -0,0 +1,53 @@
+package feature
+
+import (
+    "net/netip"
+    "testing"
+)
+
+func TestGeoCheck(t *testing.T) {
+    r := NewGeoRules()
+    r.AddCountry("KP", true)
+    r.AddCountry("NG", false)
+    r.AddRange(netip.MustParsePrefix("198.51.100.0/24"), true)
+    r.AddRange(netip.MustParsePrefix("203.0.113.0/24"), false)
+    r.AddRange(netip.MustParsePrefix("2001:db8:bad::/48"), true)
+    // ipCountry stands in for the GeoIP database.
+    ipCountry := func(a netip.Addr) string {
+        switch {
+        case netip.MustParsePrefix("192.0.2.0/24").Contains(a):
+            return "NG"
+        case netip.MustParsePrefix("100.64.0.0/10").Contains(a):
+            return "KP"
+        case a.Is4():
+            return "GB"
+        }
+        return ""
+    }
+    cases := []struct {
+        name    string
+        country string
+        ip      string
+        travel  bool
+        want    Geo
+    }{
+        {"nothing to check", "", "", false, Geo{}},
+        {"clean", "GB", "8.8.8.8", false, Geo{IPCountry: "GB"}},
+        {"blocked country", "KP", "", false, Geo{Block: BlockedCountry}},
+        {"blocked IP country", "GB", "100.64.0.1", false, Geo{IPCountry: "KP", Block: BlockedCountry}},
+        {"high-risk country", "NG", "", false, Geo{HighRiskCountry: true}},
+        {"high-risk IP country", "", "192.0.2.1", false, Geo{IPCountry: "NG", HighRiskCountry: true}},
+        {"travel notice waives a high-risk country", "NG", "192.0.2.1", true, Geo{IPCountry: "NG"}},
+        {"travel notice doesn't waive a block", "KP", "", true, Geo{Block: BlockedCountry}},
+        {"travel notice doesn't waive a risky range", "", "203.0.113.9", true, Geo{IPCountry: "GB", HighRiskIPRange: true}},
+        {"blocked range", "", "198.51.100.7", false, Geo{IPCountry: "GB", Block: BlockedIPRange}},
+        {"blocked range wins over blocked country", "KP", "198.51.100.7", false, Geo{IPCountry: "GB", Block: BlockedIPRange}},
+        {"high-risk range", "GB", "203.0.113.9", false, Geo{IPCountry: "GB", HighRiskIPRange: true}},
+        {"IPv4-mapped IPv6 in a blocked range", "", "::ffff:198.51.100.7", false, Geo{IPCountry: "GB", Block: BlockedIPRange}},
+        {"IPv6 blocked range", "", "2001:db8:bad::1", false, Geo{Block: BlockedIPRange}},
+        {"unparsable IP is skipped", "NG", "not an ip", false, Geo{HighRiskCountry: true}},
+    }
+    for _, c := range cases {
+        if got := r.Check(c.country, c.ip, ipCountry, c.travel); got != c.want { t.Errorf("%s: got %+v, want %+v", c.name, got, c.want) }
+    }
+}
@@ -0,0 +1,128 @@
+// Package flags evaluates feature flags kept in the Redis hash feature_flags
+// (field: flag name, value: JSON-encoded Flag), so a behaviour can be turned
+// on for some tenants or a share of traffic, and changed at runtime, without
+// a redeploy. Services hold a Client that re-reads the hash every few
+// seconds, so evaluating a flag never touches Redis.
+package flags
+
+import (
+    "context"
+    "encoding/json"
+    "hash/fnv"
+    "log"
+    "sort"
+    "sync/atomic"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+const redisKey = "feature_flags"
+
+// Flag is on for every tenant in Tenants; for everyone else it is on when
+// Enabled, for Percentage percent of keys. A key always lands in the same
+// bucket for a given flag, so a user doesn't flip between variants.
+type Flag struct {
+    Enabled    bool     `json:"enabled"`
+    Percentage int      `json:"percentage"`
+    Tenants    []string `json:"tenants,omitempty"`
+}
+
+// parse decodes a stored flag; Percentage defaults to 100 when omitted.
+func parse(s string) (Flag, error) {
+    f := Flag{Percentage: 100}
+    err := json.Unmarshal([]byte(s), &f)
+    return f, err
+}
+
+func (f Flag) on(name, tenant, key string) bool {
+    for _, t := range f.Tenants {
+        if t != "" && t == tenant { return true }
+    }
+    if !f.Enabled { return false }
+    if f.Percentage >= 100 { return true }
+    h := fnv.New32a()
+    h.Write([]byte(name + ":" + key))
+    return int(h.Sum32()%100) < f.Percentage
+}
+
+type Client struct {
+    rdb   *redis.Client
+    flags atomic.Pointer[map[string]Flag]
+}
+
+func New(rdb *redis.Client) *Client {
+    c := &Client{rdb: rdb}
+    c.flags.Store(&map[string]Flag{})
+    return c
+}
+
+// On reports whether flag name is on for tenant and key (typically the user
+// id). def applies while the flag isn't defined, so a flag can take over an
+// existing setting and fall back to it when deleted.
+func (c *Client) On(name string, def bool, tenant, key string) bool {
+    f, ok := (*c.flags.Load())[name]
+    if !ok { return def }
+    return f.on(name, tenant, key)
+}
+
+// Refresh reloads every flag. A flag whose value doesn't parse is logged and
+// treated as undefined.
+func (c *Client) Refresh(ctx context.Context) error {
+    raw, err := c.rdb.HGetAll(ctx, redisKey).Result()
+    if err != nil { return err }
+    next := make(map[string]Flag, len(raw))
+    for name, s := range raw {
+        f, err := parse(s)
+        if err != nil { log.Printf("feature flag %s: %v", name, err); continue }
+        next[name] = f
+    }
+    c.flags.Store(&next)
+    return nil
+}
+
+// Run refreshes the flags every interval until ctx is done. The last good
+// set stays in effect while Redis is unreachable.
+func (c *Client) Run(ctx context.Context, interval time.Duration) {
+    t := time.NewTicker(interval)
+    defer t.Stop()
+    for {
+        if err := c.Refresh(ctx); err != nil { log.Printf("feature flag refresh failed: %v", err) }
+        select {
+        case <-ctx.Done():
+            return
+        case <-t.C:
+        }
+    }
+}
+
+// Set creates or replaces a flag; clients pick it up on their next refresh.
+func (c *Client) Set(ctx context.Context, name string, f Flag) error {
+    b, err := json.Marshal(f)
+    if err != nil { return err }
+    return c.rdb.HSet(ctx, redisKey, name, b).Err()
+}
+
+func (c *Client) Delete(ctx context.Context, name string) error {
+    return c.rdb.HDel(ctx, redisKey, name).Err()
+}
+
+// NamedFlag is a Flag with its name, as returned by List.
+type NamedFlag struct {
+    Name string `json:"name"`
+    Flag
+}
+
+// List reads every flag from Redis, sorted by name.
+func (c *Client) List(ctx context.Context) ([]NamedFlag, error) {
+    raw, err := c.rdb.HGetAll(ctx, redisKey).Result()
+    if err != nil { return nil, err }
+    out := make([]NamedFlag, 0, len(raw))
+    for name, s := range raw {
+        f, err := parse(s)
+        if err != nil { return nil, err }
+        out = append(out, NamedFlag{Name: name, Flag: f})
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+    return out, nil
+}
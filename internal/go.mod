@@ -0,0 +1,13 @@
+module example.com/fraud/internal
+
+go 1.22
+
+require (
+    github.com/go-redis/redis/v8 v8.11.5
+    github.com/hamba/avro/v2 v2.27.0
+    github.com/jackc/pgx/v5 v5.6.0
+    github.com/prometheus/client_golang v1.19.1
+    github.com/segmentio/kafka-go v0.4.47
+    google.golang.org/protobuf v1.34.2
+    gopkg.in/yaml.v3 v3.0.1
+)
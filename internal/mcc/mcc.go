@@ -0,0 +1,39 @@
+// Package mcc groups ISO 18245 merchant category codes into the categories
+// the services track per user and score.
+package mcc
+
+// Named categories. Codes that belong to none are tracked under the code
+// itself.
+const (
+    Gambling      = "gambling"
+    Crypto        = "crypto"
+    GiftCards     = "gift_cards"
+    MoneyTransfer = "money_transfer"
+)
+
+var categories = map[string]string{
+    "7800": Gambling,      // government-owned lotteries
+    "7801": Gambling,      // government-licensed online casinos
+    "7802": Gambling,      // government-licensed horse/dog racing
+    "7995": Gambling,      // betting, casino gaming chips, off-track betting
+    "6051": Crypto,        // quasi cash: foreign currency, money orders, cryptocurrency
+    "6540": GiftCards,     // stored value card purchase and load
+    "5947": GiftCards,     // gift, card, novelty and souvenir shops
+    "4829": MoneyTransfer, // wire transfers and money orders
+}
+
+// Valid reports whether code is four digits.
+func Valid(code string) bool {
+    if len(code) != 4 { return false }
+    for _, c := range code {
+        if c < '0' || c > '9' { return false }
+    }
+    return true
+}
+
+// Category returns the named category of code, or code itself when it has
+// none.
+func Category(code string) string {
+    if c, ok := categories[code]; ok { return c }
+    return code
+}
@@ -0,0 +1,62 @@
+// Package s3 is a minimal S3 client for writing objects, signed with AWS
+// Signature Version 4. It uses path-style URLs, so it also works with
+// S3-compatible stores such as MinIO.
+package s3
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+
+    "example.com/fraud/internal/sigv4"
+)
+
+type Client struct {
+    endpoint, region, bucket string
+    accessKey, secretKey     string
+    sessionToken             string
+    client                   *http.Client
+}
+
+// NewClient returns a client for bucket. An empty endpoint means AWS's
+// regional endpoint; sessionToken is only needed for temporary credentials.
+func NewClient(endpoint, region, bucket, accessKey, secretKey, sessionToken string) *Client {
+    if endpoint == "" { endpoint = "https://s3." + region + ".amazonaws.com" }
+    return &Client{
+        endpoint:     strings.TrimSuffix(endpoint, "/"),
+        region:       region,
+        bucket:       bucket,
+        accessKey:    accessKey,
+        secretKey:    secretKey,
+        sessionToken: sessionToken,
+        client:       &http.Client{Timeout: 60 * time.Second},
+    }
+}
+
+// Put writes body to key, replacing any object already there.
+func (c *Client) Put(ctx context.Context, key, contentType string, body []byte) error {
+    u, err := url.Parse(c.endpoint)
+    if err != nil { return err }
+    u.Path = "/" + c.bucket + "/" + key
+    u.RawPath = sigv4.EscapePath(u.Path)
+    req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+    if err != nil { return err }
+    req.Header.Set("Content-Type", contentType)
+    sum := sha256.Sum256(body)
+    sigv4.Sign(req, hex.EncodeToString(sum[:]), "s3", c.region, sigv4.Credentials{AccessKeyID: c.accessKey, SecretAccessKey: c.secretKey, SessionToken: c.sessionToken}, time.Now())
+    resp, err := c.client.Do(req)
+    if err != nil { return err }
+    defer resp.Body.Close()
+    if resp.StatusCode >= 300 {
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return fmt.Errorf("s3 put %s: %s: %s", key, resp.Status, bytes.TrimSpace(msg))
+    }
+    return nil
+}
@@ -0,0 +1,115 @@
+// Package search is a minimal Elasticsearch/OpenSearch client covering what
+// the services need: index templates, bulk indexing and searches. Both
+// engines speak the same REST API for these calls.
+package search
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// Index kinds. Documents go to monthly indices, <prefix><kind>-YYYY.MM, and
+// are searched through the <prefix><kind>-* pattern.
+const (
+    Transactions = "transactions"
+    Alerts       = "alerts"
+)
+
+type Client struct {
+    url, username, password, prefix string
+    client                          *http.Client
+}
+
+func NewClient(url, username, password, prefix string) *Client {
+    return &Client{url: strings.TrimSuffix(url, "/"), username: username, password: password, prefix: prefix, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Index returns the monthly index of kind that holds documents from t.
+func (c *Client) Index(kind string, t time.Time) string {
+    return c.prefix + kind + "-" + t.UTC().Format("2006.01")
+}
+
+// Pattern matches every monthly index of kind.
+func (c *Client) Pattern(kind string) string { return c.prefix + kind + "-*" }
+
+// Do sends a request and returns the response when its status is below 300;
+// the caller closes the body.
+func (c *Client) Do(ctx context.Context, method, path, contentType string, body []byte) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+    if err != nil { return nil, err }
+    if body != nil { req.Header.Set("Content-Type", contentType) }
+    if c.username != "" { req.SetBasicAuth(c.username, c.password) }
+    resp, err := c.client.Do(req)
+    if err != nil { return nil, err }
+    if resp.StatusCode >= 300 {
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        resp.Body.Close()
+        return nil, fmt.Errorf("search %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+    }
+    return resp, nil
+}
+
+// PutTemplate installs the index template for kind with the given field
+// mappings, so new monthly indices are created with them.
+func (c *Client) PutTemplate(ctx context.Context, kind string, properties map[string]interface{}) error {
+    b, _ := json.Marshal(map[string]interface{}{
+        "index_patterns": []string{c.Pattern(kind)},
+        "template":       map[string]interface{}{"mappings": map[string]interface{}{"properties": properties}},
+    })
+    resp, err := c.Do(ctx, http.MethodPut, "/_index_template/"+c.prefix+kind, "application/json", b)
+    if err != nil { return err }
+    return resp.Body.Close()
+}
+
+// BulkItem is one document to index, replacing any with the same id.
+type BulkItem struct {
+    Index string
+    ID    string
+    Doc   interface{}
+}
+
+// Bulk indexes items in one request. It fails if any item failed; items
+// are idempotent by id, so the whole batch can be sent again.
+func (c *Client) Bulk(ctx context.Context, items []BulkItem) error {
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    for _, it := range items {
+        if err := enc.Encode(map[string]interface{}{"index": map[string]string{"_index": it.Index, "_id": it.ID}}); err != nil { return err }
+        if err := enc.Encode(it.Doc); err != nil { return err }
+    }
+    resp, err := c.Do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", buf.Bytes())
+    if err != nil { return err }
+    defer resp.Body.Close()
+    var out struct {
+        Errors bool `json:"errors"`
+        Items  []map[string]struct {
+            Status int             `json:"status"`
+            Error  json.RawMessage `json:"error"`
+        } `json:"items"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil { return err }
+    if !out.Errors { return nil }
+    failed, first := 0, ""
+    for _, it := range out.Items {
+        for _, r := range it {
+            if r.Status < 300 { continue }
+            if failed++; first == "" { first = string(r.Error) }
+        }
+    }
+    return fmt.Errorf("bulk: %d of %d items failed, first: %s", failed, len(items), first)
+}
+
+// Search runs query against every index of kind and returns the raw
+// response body.
+func (c *Client) Search(ctx context.Context, kind string, query []byte) ([]byte, error) {
+    resp, err := c.Do(ctx, http.MethodPost, "/"+c.Pattern(kind)+"/_search", "application/json", query)
+    if err != nil { return nil, err }
+    defer resp.Body.Close()
+    return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,114 @@
+// Package secrets reads credentials from a secrets manager: a Vault KV
+// secret or an AWS Secrets Manager secret holding a JSON object. Either way
+// the result is a map of names to values.
+package secrets
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "example.com/fraud/internal/sigv4"
+)
+
+// Source is a secret to read.
+type Source interface {
+    Fetch(ctx context.Context) (map[string]string, error)
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Vault reads the KV secret at Path, e.g. secret/data/fraud for version 2
+// of the engine mounted at secret/, or secret/fraud for version 1. The
+// token is TokenFile's content when it is set, so a Vault agent can renew
+// it, and Token otherwise.
+type Vault struct {
+    Addr      string
+    Token     string
+    TokenFile string
+    Path      string
+}
+
+func (v Vault) Fetch(ctx context.Context) (map[string]string, error) {
+    token := v.Token
+    if v.TokenFile != "" {
+        b, err := os.ReadFile(v.TokenFile)
+        if err != nil { return nil, err }
+        token = strings.TrimSpace(string(b))
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(v.Addr, "/")+"/v1/"+strings.TrimPrefix(v.Path, "/"), nil)
+    if err != nil { return nil, err }
+    req.Header.Set("X-Vault-Token", token)
+    body, err := do(req, "vault")
+    if err != nil { return nil, err }
+    var resp struct {
+        Data map[string]json.RawMessage `json:"data"`
+    }
+    if err := json.Unmarshal(body, &resp); err != nil { return nil, fmt.Errorf("vault: %w", err) }
+    // Version 2 nests the values under data.data, beside data.metadata.
+    if inner, ok := resp.Data["data"]; ok && resp.Data["metadata"] != nil { return values("vault", inner) }
+    raw, _ := json.Marshal(resp.Data)
+    return values("vault", raw)
+}
+
+// AWS reads SecretID, whose SecretString must be a JSON object, from
+// Secrets Manager in Region.
+type AWS struct {
+    Region      string
+    SecretID    string
+    Credentials sigv4.Credentials
+}
+
+func (a AWS) Fetch(ctx context.Context) (map[string]string, error) {
+    payload, _ := json.Marshal(map[string]string{"SecretId": a.SecretID})
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://secretsmanager."+a.Region+".amazonaws.com/", bytes.NewReader(payload))
+    if err != nil { return nil, err }
+    req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+    req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+    sum := sha256.Sum256(payload)
+    sigv4.Sign(req, hex.EncodeToString(sum[:]), "secretsmanager", a.Region, a.Credentials, time.Now())
+    body, err := do(req, "secrets manager")
+    if err != nil { return nil, err }
+    var resp struct {
+        SecretString string `json:"SecretString"`
+    }
+    if err := json.Unmarshal(body, &resp); err != nil { return nil, fmt.Errorf("secrets manager: %w", err) }
+    return values("secrets manager", []byte(resp.SecretString))
+}
+
+func do(req *http.Request, name string) ([]byte, error) {
+    resp, err := client.Do(req)
+    if err != nil { return nil, err }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    if err != nil { return nil, err }
+    if resp.StatusCode != http.StatusOK { return nil, fmt.Errorf("%s: %s: %s", name, resp.Status, bytes.TrimSpace(body)) }
+    return body, nil
+}
+
+// values reads a JSON object of names to values. Numbers and booleans are
+// taken as written; anything else is an error.
+func values(name string, raw []byte) (map[string]string, error) {
+    var m map[string]interface{}
+    if err := json.Unmarshal(raw, &m); err != nil { return nil, fmt.Errorf("%s: secret is not a JSON object: %w", name, err) }
+    out := make(map[string]string, len(m))
+    for k, v := range m {
+        switch v := v.(type) {
+        case string:
+            out[k] = v
+        case float64, bool:
+            out[k] = fmt.Sprint(v)
+        default:
+            return nil, fmt.Errorf("%s: %s is not a string", name, k)
+        }
+    }
+    return out, nil
+}